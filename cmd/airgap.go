@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/telemetry"
+	"k8s.io/klog/v2"
+)
+
+// localProviders are the providers serving the models on the host.
+var localProviders = []string{"ollama", "llamacpp"}
+
+// checkAirGapped verifies that an air-gapped run sends no data out of the
+// host: the provider must serve the model on a loopback address, and the
+// features calling remote services must be disabled. The tool calls are
+// checked by the agent, see tools.CheckEgress.
+func checkAirGapped(ctx context.Context, opt *Options) error {
+	provider := providerScheme(opt.ProviderID)
+	var endpoint *url.URL
+	switch provider {
+	case "ollama":
+		endpoint = gollm.OllamaHost()
+	case "llamacpp":
+		var err error
+		if endpoint, err = gollm.LlamaCppHost(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("--air-gapped only supports the local providers %s, not %q", strings.Join(localProviders, " and "), provider)
+	}
+	if err := checkLoopback(ctx, endpoint.Hostname()); err != nil {
+		return fmt.Errorf("--air-gapped needs the %s server on the host: %w", provider, err)
+	}
+
+	switch {
+	case opt.Telemetry == telemetry.ModeOn:
+		return fmt.Errorf("--air-gapped cannot be combined with --telemetry=on")
+	case opt.MCPClient:
+		return fmt.Errorf("--air-gapped cannot be combined with --mcp-client, MCP servers may be remote")
+	case opt.ReportsConfigPath != "":
+		return fmt.Errorf("--air-gapped cannot be combined with --reports-config, reports are delivered to remote endpoints")
+	case opt.IncidentID != "":
+		return fmt.Errorf("--air-gapped cannot be combined with --incident-id, incidents are imported from remote services")
+	}
+	return nil
+}
+
+// enforceAirGapped checks an air-gapped run, see checkAirGapped, and restricts
+// the connections of kubectl-ai to the host, see restrictToLoopback.
+func enforceAirGapped(ctx context.Context, opt *Options) error {
+	if err := checkAirGapped(ctx, opt); err != nil {
+		return err
+	}
+	restrictToLoopback(http.DefaultTransport.(*http.Transport))
+	klog.Info("Air-gapped mode: connections are restricted to the host", "provider", opt.ProviderID)
+	return nil
+}
+
+// providerScheme returns the provider of a provider ID, e.g. "ollama" for
+// "ollama://" or "ollama".
+func providerScheme(providerID string) string {
+	scheme, _, _ := strings.Cut(providerID, ":")
+	return scheme
+}
+
+// checkLoopback returns an error unless all the addresses of host are
+// loopback addresses.
+func checkLoopback(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !addr.IP.IsLoopback() {
+			return fmt.Errorf("%s resolves to %s, which is not a loopback address", host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// restrictToLoopback makes the HTTP clients derived from the default
+// transport, e.g. the ones of the LLM providers, refuse to connect outside of
+// the host. The addresses are checked once resolved, so that a host name
+// cannot be used to reach another host. The kubectl calls of the tools run in
+// their own processes and reach the API server of the kubeconfig.
+func restrictToLoopback(transport *http.Transport) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+				klog.Warningf("Air-gapped: refused a connection to %s", address)
+				return fmt.Errorf("air-gapped: refusing to connect to %s outside of the host", address)
+			}
+			return nil
+		},
+	}
+	transport.DialContext = dialer.DialContext
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/telemetry"
+)
+
+func TestCheckAirGapped(t *testing.T) {
+	tests := []struct {
+		name     string
+		opt      Options
+		ollama   string
+		llamacpp string
+		wantErr  bool
+	}{
+		{name: "ollama on the host", opt: Options{ProviderID: "ollama"}, ollama: "127.0.0.1:11434"},
+		{name: "llamacpp on the host", opt: Options{ProviderID: "llamacpp://"}, llamacpp: "http://[::1]:8080/"},
+		{name: "remote provider", opt: Options{ProviderID: "gemini"}, wantErr: true},
+		{name: "ollama on another host", opt: Options{ProviderID: "ollama"}, ollama: "http://10.0.0.5:11434", wantErr: true},
+		{name: "telemetry", opt: Options{ProviderID: "ollama", Telemetry: telemetry.ModeOn}, ollama: "127.0.0.1:11434", wantErr: true},
+		{name: "MCP client", opt: Options{ProviderID: "ollama", MCPClient: true}, ollama: "127.0.0.1:11434", wantErr: true},
+		{name: "incident import", opt: Options{ProviderID: "ollama", IncidentID: "Q2W3E4R5T6Y7U8"}, ollama: "127.0.0.1:11434", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OLLAMA_HOST", tt.ollama)
+			t.Setenv("LLAMACPP_HOST", tt.llamacpp)
+			err := checkAirGapped(context.Background(), &tt.opt)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkAirGapped() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRestrictToLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	restrictToLoopback(transport)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("connecting to the host: %v", err)
+	}
+	resp.Body.Close()
+	// The connection is refused before it is attempted.
+	if _, err := client.Get("http://192.0.2.1/"); err == nil {
+		t.Errorf("connecting to another host succeeded, want an error")
+	}
+}
+
+func TestAirGappedSubcommands(t *testing.T) {
+	for _, args := range [][]string{
+		{"plan-upgrade", "--target", "1.31"},
+		{"bench"},
+		{"init"},
+		{"report", "run", "weekly"},
+	} {
+		t.Run(args[0], func(t *testing.T) {
+			var opt Options
+			opt.InitDefaults()
+			rootCmd, err := BuildRootCommand(&opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rootCmd.SetArgs(append(args, "--air-gapped", "--llm-provider", "gemini"))
+			rootCmd.SilenceUsage = true
+			rootCmd.SilenceErrors = true
+			err = rootCmd.ExecuteContext(context.Background())
+			if err == nil || !strings.Contains(err.Error(), "--air-gapped only supports the local providers") {
+				t.Errorf("%s --air-gapped with a remote provider: error = %v, want the provider refused", args[0], err)
+			}
+		})
+	}
+}
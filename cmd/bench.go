@@ -0,0 +1,407 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/agent"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type benchOptions struct {
+	providers     []string
+	query         string
+	expect        string
+	runs          int
+	mockToolsPath string
+	timeout       time.Duration
+	format        string
+}
+
+func newBenchCommand(opt *Options) *cobra.Command {
+	var bo benchOptions
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Compare the latency and accuracy of LLM providers on the same query",
+		Long: "bench runs the same query with each provider and reports the latency, the number of agent iterations, the tokens used and whether the answer matched the expected answer. " +
+			"Providers are given as provider or provider/model, e.g. --providers gemini/gemini-2.5-flash,openai/gpt-4.1. " +
+			"Commands that modify resources are never run; use --mock-tools to answer tool calls with canned outputs instead of querying the cluster.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBench(cmd.Context(), *opt, bo, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringSliceVar(&bo.providers, "providers", nil, "comma separated providers to compare, as provider or provider/model")
+	cmd.Flags().StringVar(&bo.query, "query", "", "query to run with each provider")
+	cmd.Flags().StringVar(&bo.expect, "expect", "", "regular expression the final answer must match for the run to succeed (defaults to any answer)")
+	cmd.Flags().IntVar(&bo.runs, "runs", 1, "number of runs per provider")
+	cmd.Flags().StringVar(&bo.mockToolsPath, "mock-tools", "", "path to a YAML file of canned tool outputs, used instead of running the tools")
+	cmd.Flags().DurationVar(&bo.timeout, "timeout", 5*time.Minute, "maximum duration of a run")
+	cmd.Flags().StringVar(&bo.format, "format", "text", "output format: text or json")
+	_ = cmd.MarkFlagRequired("providers")
+	_ = cmd.MarkFlagRequired("query")
+	return cmd
+}
+
+// benchRun is the outcome of a single run of the query.
+type benchRun struct {
+	Latency    time.Duration `json:"-"`
+	LatencyMS  int64         `json:"latencyMS"`
+	Iterations int           `json:"iterations"`
+	Tokens     int64         `json:"tokens"`
+	Success    bool          `json:"success"`
+	Answer     string        `json:"answer,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// benchResult aggregates the runs of a provider.
+type benchResult struct {
+	Provider string     `json:"provider"`
+	Model    string     `json:"model"`
+	Runs     []benchRun `json:"runs"`
+}
+
+func (r *benchResult) successes() int {
+	n := 0
+	for _, run := range r.Runs {
+		if run.Success {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *benchResult) averages() (latency time.Duration, iterations float64, tokens float64) {
+	if len(r.Runs) == 0 {
+		return 0, 0, 0
+	}
+	for _, run := range r.Runs {
+		latency += run.Latency
+		iterations += float64(run.Iterations)
+		tokens += float64(run.Tokens)
+	}
+	n := len(r.Runs)
+	return latency / time.Duration(n), iterations / float64(n), tokens / float64(n)
+}
+
+func runBench(ctx context.Context, opt Options, bo benchOptions, out io.Writer) error {
+	if bo.format != "text" && bo.format != "json" {
+		return fmt.Errorf("invalid --format %q, supported values: text, json", bo.format)
+	}
+	if bo.runs < 1 {
+		return fmt.Errorf("--runs must be at least 1")
+	}
+	var expect *regexp.Regexp
+	if bo.expect != "" {
+		var err error
+		if expect, err = regexp.Compile(bo.expect); err != nil {
+			return fmt.Errorf("invalid --expect: %w", err)
+		}
+	}
+	var executor sandbox.Executor
+	if bo.mockToolsPath != "" {
+		mock, err := loadMockExecutor(bo.mockToolsPath)
+		if err != nil {
+			return err
+		}
+		executor = mock
+	} else {
+		if err := tools.SetSensitiveEnvPatterns(opt.ToolEnvDenylist); err != nil {
+			return fmt.Errorf("invalid --tool-env-denylist: %w", err)
+		}
+		if err := resolveKubeConfigPath(&opt); err != nil {
+			return fmt.Errorf("failed to resolve kubeconfig path: %w", err)
+		}
+	}
+
+	var results []*benchResult
+	for _, spec := range bo.providers {
+		providerOpt := opt
+		providerOpt.ProviderID, providerOpt.ModelID = parseBenchProvider(spec, opt.ModelID)
+		result := &benchResult{Provider: providerOpt.ProviderID, Model: providerOpt.ModelID}
+		for i := range bo.runs {
+			fmt.Fprintf(os.Stderr, "Running %s/%s (%d/%d)...\n", result.Provider, result.Model, i+1, bo.runs)
+			result.Runs = append(result.Runs, runBenchQuery(ctx, providerOpt, bo, executor, expect))
+		}
+		results = append(results, result)
+	}
+
+	if bo.format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tMODEL\tSUCCESS\tAVG LATENCY\tAVG ITERATIONS\tAVG TOKENS")
+	for _, r := range results {
+		latency, iterations, tokens := r.averages()
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\t%.1f\t%.0f\n", r.Provider, r.Model, r.successes(), len(r.Runs), latency.Round(time.Millisecond), iterations, tokens)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, r := range results {
+		for i, run := range r.Runs {
+			if run.Error != "" {
+				fmt.Fprintf(out, "\n%s/%s run %d failed: %s\n", r.Provider, r.Model, i+1, run.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// parseBenchProvider splits a provider/model spec. Provider URLs such as
+// openai://host use the default model.
+func parseBenchProvider(spec, defaultModel string) (provider, model string) {
+	spec = strings.TrimSpace(spec)
+	if !strings.Contains(spec, "://") {
+		if provider, model, ok := strings.Cut(spec, "/"); ok {
+			return provider, model
+		}
+	}
+	return spec, defaultModel
+}
+
+// runBenchQuery runs the query once with a fresh agent.
+func runBenchQuery(ctx context.Context, opt Options, bo benchOptions, executor sandbox.Executor, expect *regexp.Regexp) benchRun {
+	ctx, cancel := context.WithTimeout(ctx, bo.timeout)
+	defer cancel()
+
+	var run benchRun
+	client, err := newLLMClient(ctx, opt)
+	if err != nil {
+		run.Error = err.Error()
+		return run
+	}
+	counter := &usageCounter{}
+	a := &agent.Agent{
+		Model:               opt.ModelID,
+		Provider:            opt.ProviderID,
+		ToolArgsRepairModel: opt.ToolArgsRepairModel,
+		Kubeconfig:          opt.KubeConfigPath,
+		Env:                 opt.Env,
+		LLM:                 &countingClient{Client: client, counter: counter},
+		MaxIterations:       opt.MaxIterations,
+		MaxAPICallsPerRun:   opt.MaxAPICallsPerRun,
+		BatchKubectlQueries: opt.BatchKubectlQueries,
+		PromptTemplateFile:  opt.PromptTemplateFilePath,
+		ExtraPromptPaths:    opt.ExtraPromptPaths,
+		Tools:               tools.Default(),
+		Executor:            executor,
+		RemoveWorkDir:       true,
+		ReadOnly:            true,
+		EnableToolUseShim:   opt.EnableToolUseShim,
+		Deterministic:       opt.Deterministic,
+		LLMTurnTimeout:      opt.LLMTurnTimeout.Duration,
+		LLMStallTimeout:     opt.LLMStallTimeout.Duration,
+		RunOnce:             true,
+		InitialQuery:        bo.query,
+		Session:             &api.Session{ChatMessageStore: sessions.NewInMemoryChatStore()},
+	}
+	defer a.Close()
+
+	start := time.Now()
+	if err := a.Init(ctx); err != nil {
+		run.Error = err.Error()
+		return run
+	}
+	if err := a.Run(ctx, ""); err != nil {
+		run.Error = err.Error()
+		return run
+	}
+	run.Answer, err = waitForBenchAnswer(ctx, a)
+	run.Latency = time.Since(start)
+	run.LatencyMS = run.Latency.Milliseconds()
+	run.Iterations, run.Tokens = counter.get()
+	if err != nil {
+		run.Error = err.Error()
+		return run
+	}
+	run.Success = run.Answer != "" && (expect == nil || expect.MatchString(run.Answer))
+	if !run.Success && run.Answer != "" {
+		run.Error = "answer does not match --expect"
+	}
+	return run
+}
+
+// waitForBenchAnswer collects the output of the agent until it exits, and
+// returns the last answer of the model.
+func waitForBenchAnswer(ctx context.Context, a *agent.Agent) (string, error) {
+	var answer string
+	var agentErr error
+	handle := func(m any) {
+		msg, ok := m.(*api.Message)
+		if !ok {
+			return
+		}
+		switch {
+		case msg.Source == api.MessageSourceModel && msg.Type == api.MessageTypeText:
+			answer, _ = msg.Payload.(string)
+		case msg.Type == api.MessageTypeError:
+			agentErr = fmt.Errorf("%v", msg.Payload)
+		}
+	}
+
+	// The agent does not close its output in RunOnce mode, so we poll its state.
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return answer, ctx.Err()
+		case m, ok := <-a.Output:
+			if !ok {
+				return answer, agentErr
+			}
+			handle(m)
+		case <-ticker.C:
+			if a.AgentState() != api.AgentStateExited {
+				continue
+			}
+			for {
+				select {
+				case m := <-a.Output:
+					handle(m)
+				default:
+					if err := a.LastErr(); err != nil {
+						return answer, err
+					}
+					return answer, agentErr
+				}
+			}
+		}
+	}
+}
+
+// usageCounter counts the LLM calls and tokens of a run.
+type usageCounter struct {
+	mu     sync.Mutex
+	calls  int
+	tokens int64
+}
+
+func (c *usageCounter) add(tokens int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	c.tokens += tokens
+}
+
+func (c *usageCounter) get() (calls int, tokens int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls, c.tokens
+}
+
+// countingClient wraps a gollm.Client to count the calls and tokens of its chats.
+type countingClient struct {
+	gollm.Client
+	counter *usageCounter
+}
+
+func (c *countingClient) StartChat(systemPrompt, model string) gollm.Chat {
+	return &countingChat{Chat: c.Client.StartChat(systemPrompt, model), counter: c.counter}
+}
+
+type countingChat struct {
+	gollm.Chat
+	counter *usageCounter
+}
+
+func (c *countingChat) Send(ctx context.Context, contents ...any) (gollm.ChatResponse, error) {
+	resp, err := c.Chat.Send(ctx, contents...)
+	if err == nil {
+		c.counter.add(gollm.UsageTokens(resp.UsageMetadata()))
+	}
+	return resp, err
+}
+
+func (c *countingChat) SendStreaming(ctx context.Context, contents ...any) (gollm.ChatResponseIterator, error) {
+	stream, err := c.Chat.SendStreaming(ctx, contents...)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(gollm.ChatResponse, error) bool) {
+		// Providers report the cumulative usage on the stream chunks, so we keep the largest.
+		var tokens int64
+		defer func() { c.counter.add(tokens) }()
+		for resp, err := range stream {
+			if resp != nil {
+				tokens = max(tokens, gollm.UsageTokens(resp.UsageMetadata()))
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}, nil
+}
+
+// mockToolOutput is a canned output of the tool commands containing Command.
+type mockToolOutput struct {
+	Command  string `json:"command"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	ExitCode int    `json:"exitCode,omitempty"`
+}
+
+// mockExecutor answers tool commands with canned outputs, so runs do not
+// depend on the state of a cluster.
+type mockExecutor struct {
+	outputs []mockToolOutput
+}
+
+var _ sandbox.Executor = &mockExecutor{}
+
+func loadMockExecutor(path string) (*mockExecutor, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mock tools: %w", err)
+	}
+	var outputs []mockToolOutput
+	if err := yaml.Unmarshal(b, &outputs); err != nil {
+		return nil, fmt.Errorf("parsing mock tools %q: %w", path, err)
+	}
+	return &mockExecutor{outputs: outputs}, nil
+}
+
+// Execute returns the output of the first entry whose command is contained in the command.
+func (e *mockExecutor) Execute(ctx context.Context, command string, env []string, workDir string) (*sandbox.ExecResult, error) {
+	for _, o := range e.outputs {
+		if strings.Contains(command, o.Command) {
+			return &sandbox.ExecResult{Command: command, Stdout: o.Stdout, Stderr: o.Stderr, ExitCode: o.ExitCode}, nil
+		}
+	}
+	return &sandbox.ExecResult{Command: command, Stderr: "no mock output for this command", ExitCode: 1}, nil
+}
+
+func (e *mockExecutor) Close(ctx context.Context) error {
+	return nil
+}
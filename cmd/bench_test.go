@@ -0,0 +1,77 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseBenchProvider(t *testing.T) {
+	tests := []struct {
+		spec                    string
+		wantProvider, wantModel string
+	}{
+		{spec: "gemini", wantProvider: "gemini", wantModel: "default-model"},
+		{spec: "openai/gpt-4.1", wantProvider: "openai", wantModel: "gpt-4.1"},
+		{spec: " ollama/qwen3:8b", wantProvider: "ollama", wantModel: "qwen3:8b"},
+		{spec: "openai://gateway.example.com/v1", wantProvider: "openai://gateway.example.com/v1", wantModel: "default-model"},
+	}
+	for _, tt := range tests {
+		provider, model := parseBenchProvider(tt.spec, "default-model")
+		if provider != tt.wantProvider || model != tt.wantModel {
+			t.Errorf("parseBenchProvider(%q) = %q, %q, want %q, %q", tt.spec, provider, model, tt.wantProvider, tt.wantModel)
+		}
+	}
+}
+
+func TestMockExecutor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mock-tools.yaml")
+	mockTools := `
+- command: get pods
+  stdout: |
+    NAME       READY   STATUS             RESTARTS
+    checkout   0/1     CrashLoopBackOff   12
+- command: logs checkout
+  stderr: container not found
+  exitCode: 1
+`
+	if err := os.WriteFile(path, []byte(mockTools), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	executor, err := loadMockExecutor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	result, err := executor.Execute(ctx, "kubectl get pods -n shop", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 0 || result.Stdout == "" {
+		t.Errorf("unexpected result for get pods: %+v", result)
+	}
+	result, _ = executor.Execute(ctx, "kubectl logs checkout", nil, "")
+	if result.ExitCode != 1 || result.Stderr != "container not found" {
+		t.Errorf("unexpected result for logs: %+v", result)
+	}
+	result, _ = executor.Execute(ctx, "kubectl get nodes", nil, "")
+	if result.ExitCode == 0 {
+		t.Errorf("expected an error for a command without mock output, got %+v", result)
+	}
+}
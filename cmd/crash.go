@@ -0,0 +1,126 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/journal"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/telemetry"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"sigs.k8s.io/yaml"
+)
+
+// crashReportJournalEvents is the number of trailing journal events included in a diagnostics bundle.
+const crashReportJournalEvents = 50
+
+const newIssueURL = "https://github.com/GoogleCloudPlatform/kubectl-ai/issues/new"
+
+// crashReporter writes a redacted diagnostics bundle when kubectl-ai panics
+// or exits with a fatal error, to be attached to a GitHub issue.
+type crashReporter struct {
+	// opt is set once the configuration is loaded.
+	opt *Options
+}
+
+// recoverPanic must be deferred in main. It reports the panic and exits.
+func (c *crashReporter) recoverPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	fmt.Fprintf(os.Stderr, "panic: %v\n\n%s\n", r, stack)
+	c.report(fmt.Sprintf("panic: %v", r), stack)
+	os.Exit(2)
+}
+
+// reportError reports a fatal error returned by run. Cancellations and usage errors are not reported.
+func (c *crashReporter) reportError(err error) {
+	switch telemetry.ErrorCategory(err) {
+	case telemetry.ErrorCategoryNone, telemetry.ErrorCategoryCanceled, telemetry.ErrorCategoryUsage:
+		return
+	}
+	c.report(fmt.Sprintf("error: %v", err), nil)
+}
+
+func (c *crashReporter) report(reason string, stack []byte) {
+	bundle := c.bundle(reason, stack)
+	f, err := os.CreateTemp("", "kubectl-ai-crash-*.txt")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: writing diagnostics bundle: %v\n", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(bundle); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: writing diagnostics bundle: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nA diagnostics bundle was written to %s\n", f.Name())
+	fmt.Fprintf(os.Stderr, "Secrets were removed, but please review it before attaching it to an issue at %s\n", newIssueURL)
+}
+
+// bundle returns the redacted diagnostics bundle.
+func (c *crashReporter) bundle(reason string, stack []byte) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# kubectl-ai diagnostics bundle\n\n")
+	fmt.Fprintf(&b, "time: %s\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "version: %s\ncommit: %s\ndate: %s\n", version, commit, date)
+	fmt.Fprintf(&b, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "\n## Reason\n\n%s\n", reason)
+	if len(stack) > 0 {
+		fmt.Fprintf(&b, "\n## Stack trace\n\n%s\n", stack)
+	}
+
+	if c.opt != nil {
+		fmt.Fprintf(&b, "\n## Configuration\n\n")
+		config, err := yaml.Marshal(redactOptions(*c.opt))
+		if err != nil {
+			fmt.Fprintf(&b, "(encoding configuration: %v)\n", err)
+		} else {
+			b.Write(config)
+		}
+
+		if c.opt.TracePath != "" {
+			fmt.Fprintf(&b, "\n## Last journal events (%s)\n\n", c.opt.TracePath)
+			b.Write(journal.LastEvents(c.opt.TracePath, crashReportJournalEvents))
+		}
+	}
+
+	var secrets []string
+	if c.opt != nil {
+		secrets = journal.SecretValues(os.Environ(), c.opt.ToolEnvDenylist)
+	} else {
+		secrets = journal.SecretValues(os.Environ(), tools.DefaultSensitiveEnvPatterns)
+	}
+	return []byte(journal.RedactSecrets(b.String(), secrets))
+}
+
+// redactOptions returns a copy of the options without the values that may hold secrets.
+func redactOptions(opt Options) Options {
+	if len(opt.Env) > 0 {
+		env := make(map[string]string, len(opt.Env))
+		for k := range opt.Env {
+			env[k] = journal.RedactedValue
+		}
+		opt.Env = env
+	}
+	return opt
+}
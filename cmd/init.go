@@ -0,0 +1,380 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// initProvider is an LLM provider offered by the init wizard.
+type initProvider struct {
+	id   string
+	name string
+	// envVars are the environment variables configuring the provider;
+	// the provider is detected when all of them are set.
+	envVars      []string
+	defaultModel string
+}
+
+var initProviders = []initProvider{
+	{id: "gemini", name: "Google Gemini", envVars: []string{"GEMINI_API_KEY"}, defaultModel: "gemini-2.5-pro"},
+	{id: "vertexai", name: "Google Vertex AI", envVars: []string{"GOOGLE_CLOUD_PROJECT"}, defaultModel: "gemini-2.5-pro"},
+	{id: "openai", name: "OpenAI", envVars: []string{"OPENAI_API_KEY"}, defaultModel: "gpt-4.1"},
+	{id: "azopenai", name: "Azure OpenAI", envVars: []string{"AZURE_OPENAI_API_KEY", "AZURE_OPENAI_ENDPOINT"}},
+	{id: "grok", name: "xAI Grok", envVars: []string{"GROK_API_KEY"}, defaultModel: "grok-3-beta"},
+	{id: "deepseek", name: "DeepSeek", envVars: []string{"DEEPSEEK_API_KEY"}, defaultModel: "deepseek-chat"},
+	{id: "qwen", name: "Alibaba Qwen (DashScope)", envVars: []string{"DASHSCOPE_API_KEY"}, defaultModel: "qwen-plus"},
+	{id: "bedrock", name: "AWS Bedrock", envVars: []string{"AWS_PROFILE"}, defaultModel: "us.anthropic.claude-sonnet-4-20250514-v1:0"},
+	{id: "ollama", name: "Ollama (local models)", envVars: []string{"OLLAMA_HOST"}, defaultModel: "gemma3:12b-it-qat"},
+	{id: "llamacpp", name: "llama.cpp (local models)", envVars: []string{"LLAMACPP_HOST"}},
+}
+
+func (p initProvider) detected() bool {
+	for _, env := range p.envVars {
+		if os.Getenv(env) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func newInitCommand(opt *Options) *cobra.Command {
+	var configFile string
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Interactively set up the LLM provider and model, and check access to the cluster",
+		Long: `init is a setup wizard for first-time users. It detects the API keys available in the environment,
+lets you pick an LLM provider and model, verifies access to the cluster, writes the config file,
+and optionally installs shell completion.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFile == "" {
+				var err error
+				if configFile, err = expandConfigPath(defaultConfigPaths[0]); err != nil {
+					return err
+				}
+			}
+			w := &initWizard{
+				opt:        *opt,
+				in:         bufio.NewReader(cmd.InOrStdin()),
+				out:        cmd.OutOrStdout(),
+				configFile: configFile,
+				rootCmd:    cmd.Root(),
+			}
+			return w.run(cmd.Context())
+		},
+	}
+	cmd.Flags().StringVar(&configFile, "config-file", "", "config file to write (defaults to ~/.config/kubectl-ai/config.yaml)")
+	return cmd
+}
+
+type initWizard struct {
+	opt        Options
+	in         *bufio.Reader
+	out        io.Writer
+	configFile string
+	rootCmd    *cobra.Command
+}
+
+func (w *initWizard) run(ctx context.Context) error {
+	fmt.Fprintln(w.out, "Welcome to kubectl-ai! This wizard writes your configuration to", w.configFile)
+
+	provider, err := w.chooseProvider()
+	if err != nil {
+		return err
+	}
+	model, err := w.chooseModel(ctx, provider)
+	if err != nil {
+		return err
+	}
+	w.checkCluster(ctx)
+
+	if err := w.writeConfig(provider.id, model); err != nil {
+		return err
+	}
+
+	if install, err := w.confirm("Install shell completion?", false); err != nil {
+		return err
+	} else if install {
+		if err := w.installCompletion(); err != nil {
+			fmt.Fprintf(w.out, "Could not install shell completion: %v\n", err)
+		}
+	}
+
+	fmt.Fprintln(w.out, "\nYou're all set! Try: kubectl-ai \"what is running in my cluster?\"")
+	return nil
+}
+
+func (w *initWizard) chooseProvider() (initProvider, error) {
+	fmt.Fprintln(w.out, "\nLLM providers:")
+	defaultChoice := 0
+	for i, p := range initProviders {
+		status := "set " + strings.Join(p.envVars, " and ") + " to use it"
+		if p.detected() {
+			status = "detected " + strings.Join(p.envVars, " and ")
+			if defaultChoice == 0 {
+				defaultChoice = i + 1
+			}
+		}
+		fmt.Fprintf(w.out, "  %d. %s (%s)\n", i+1, p.name, status)
+	}
+	if defaultChoice == 0 {
+		fmt.Fprintln(w.out, "No API key was detected in the environment; the provider will work once its variables are set.")
+		defaultChoice = 1
+	}
+
+	for {
+		answer, err := w.ask("Choose a provider", strconv.Itoa(defaultChoice))
+		if err != nil {
+			return initProvider{}, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(initProviders) {
+			return initProviders[n-1], nil
+		}
+		for _, p := range initProviders {
+			if p.id == answer {
+				return p, nil
+			}
+		}
+		fmt.Fprintf(w.out, "Please enter a number between 1 and %d.\n", len(initProviders))
+	}
+}
+
+func (w *initWizard) chooseModel(ctx context.Context, provider initProvider) (string, error) {
+	defaultModel := provider.defaultModel
+	if provider.detected() {
+		// Listing the models also checks the credentials of the provider.
+		opt := w.opt
+		opt.ProviderID = provider.id
+		ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		defer cancel()
+		models, err := listModels(ctx, opt)
+		switch {
+		case err != nil:
+			fmt.Fprintf(w.out, "Could not list the models of %s: %v\n", provider.name, err)
+		case len(models) > 0:
+			const maxListed = 15
+			fmt.Fprintf(w.out, "Available models (%d):\n", len(models))
+			for i, m := range models {
+				if i == maxListed {
+					fmt.Fprintf(w.out, "  ... and %d more\n", len(models)-maxListed)
+					break
+				}
+				fmt.Fprintf(w.out, "  %s\n", m)
+			}
+			if defaultModel == "" {
+				defaultModel = models[0]
+			}
+		}
+	}
+
+	for {
+		model, err := w.ask("Model", defaultModel)
+		if err != nil {
+			return "", err
+		}
+		if model != "" {
+			return model, nil
+		}
+		fmt.Fprintln(w.out, "Please enter a model name.")
+	}
+}
+
+func listModels(ctx context.Context, opt Options) ([]string, error) {
+	client, err := newLLMClient(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return client.ListModels(ctx)
+}
+
+// checkCluster verifies the access to the cluster. Failures are reported but
+// do not stop the wizard, as the cluster may be set up later.
+func (w *initWizard) checkCluster(ctx context.Context) {
+	fmt.Fprintln(w.out, "\nChecking access to the cluster...")
+	opt := w.opt
+	if err := resolveKubeConfigPath(&opt); err != nil {
+		fmt.Fprintf(w.out, "  Could not resolve the kubeconfig: %v\n", err)
+		return
+	}
+	if opt.KubeConfigPath == "" {
+		fmt.Fprintln(w.out, "  No kubeconfig found; set KUBECONFIG or pass --kubeconfig to use kubectl-ai with a cluster.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	env := append(os.Environ(), "KUBECONFIG="+opt.KubeConfigPath)
+	executor := sandbox.NewLocalExecutor()
+	defer executor.Close(ctx)
+
+	checks := []struct{ description, command string }{
+		{"Current context", "kubectl config current-context"},
+		{"Server reachable", "kubectl version --request-timeout=10s"},
+		{"Can list pods", "kubectl auth can-i list pods --all-namespaces --request-timeout=10s"},
+	}
+	for _, check := range checks {
+		result, err := executor.Execute(ctx, check.command, env, "")
+		switch {
+		case err != nil:
+			fmt.Fprintf(w.out, "  ✗ %s: %v\n", check.description, err)
+		case result.ExitCode != 0 || result.Error != "":
+			fmt.Fprintf(w.out, "  ✗ %s: %s\n", check.description, firstLine(result.Stderr+result.Error+result.Stdout))
+		default:
+			fmt.Fprintf(w.out, "  ✓ %s: %s\n", check.description, firstLine(result.Stdout))
+		}
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// writeConfig sets the provider and model in the config file, keeping the other settings.
+func (w *initWizard) writeConfig(provider, model string) error {
+	config := map[string]any{}
+	existing, err := os.ReadFile(w.configFile)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(existing, &config); err != nil {
+			return fmt.Errorf("parsing existing config file %s: %w", w.configFile, err)
+		}
+		if config == nil {
+			config = map[string]any{}
+		}
+		overwrite, err := w.confirm(fmt.Sprintf("\nUpdate llmProvider and model in %s?", w.configFile), true)
+		if err != nil {
+			return err
+		}
+		if !overwrite {
+			fmt.Fprintln(w.out, "Config file left unchanged.")
+			return nil
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	config["llmProvider"] = provider
+	config["model"] = model
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.configFile), 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(w.configFile, data, 0o644); err != nil {
+		return fmt.Errorf("writing config file: %w", err)
+	}
+	fmt.Fprintf(w.out, "Configuration written to %s\n", w.configFile)
+	return nil
+}
+
+func (w *initWizard) installCompletion() error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	shell := filepath.Base(os.Getenv("SHELL"))
+
+	var path, hint string
+	var generate func(io.Writer) error
+	switch shell {
+	case "bash":
+		path = filepath.Join(home, ".local", "share", "bash-completion", "completions", "kubectl-ai")
+		generate = func(out io.Writer) error { return w.rootCmd.GenBashCompletionV2(out, true) }
+	case "zsh":
+		path = filepath.Join(home, ".zsh", "completions", "_kubectl-ai")
+		hint = "Add `fpath=(~/.zsh/completions $fpath)` before `compinit` in your ~/.zshrc."
+		generate = func(out io.Writer) error { return w.rootCmd.GenZshCompletion(out) }
+	case "fish":
+		path = filepath.Join(home, ".config", "fish", "completions", "kubectl-ai.fish")
+		generate = func(out io.Writer) error { return w.rootCmd.GenFishCompletion(out, true) }
+	default:
+		return fmt.Errorf("unsupported shell %q, run `kubectl-ai completion --help` to set it up manually", shell)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := generate(f); err != nil {
+		return err
+	}
+	fmt.Fprintf(w.out, "%s completion installed to %s. Restart your shell to enable it.\n", shell, path)
+	if hint != "" {
+		fmt.Fprintln(w.out, hint)
+	}
+	return nil
+}
+
+// ask prompts for a value, returning the default value on an empty answer.
+func (w *initWizard) ask(question, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	answer, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		if err == io.EOF {
+			return "", fmt.Errorf("init requires an interactive terminal")
+		}
+		return "", err
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
+
+func (w *initWizard) confirm(question string, defaultYes bool) (bool, error) {
+	defaultValue := "y/N"
+	if defaultYes {
+		defaultValue = "Y/n"
+	}
+	answer, err := w.ask(question, defaultValue)
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	default:
+		return defaultYes, nil
+	}
+}
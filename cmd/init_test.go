@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestInitWizardProviderAndConfig(t *testing.T) {
+	// No provider is detected, so the wizard does not call any LLM API.
+	for _, p := range initProviders {
+		for _, env := range p.envVars {
+			t.Setenv(env, "")
+		}
+	}
+	configFile := filepath.Join(t.TempDir(), "kubectl-ai", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(configFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configFile, []byte("llmProvider: gemini\nmaxIterations: 30\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pick an invalid choice, then OpenAI by name, keep its default model and confirm the config update.
+	w := &initWizard{
+		in:         bufio.NewReader(strings.NewReader("42\nopenai\n\n\n")),
+		out:        io.Discard,
+		configFile: configFile,
+	}
+	provider, err := w.chooseProvider()
+	if err != nil {
+		t.Fatalf("chooseProvider() error: %v", err)
+	}
+	if provider.id != "openai" {
+		t.Fatalf("chooseProvider() = %q, want openai", provider.id)
+	}
+	model, err := w.chooseModel(context.Background(), provider)
+	if err != nil {
+		t.Fatalf("chooseModel() error: %v", err)
+	}
+	if model != "gpt-4.1" {
+		t.Errorf("chooseModel() = %q, want the default gpt-4.1", model)
+	}
+	if err := w.writeConfig(provider.id, model); err != nil {
+		t.Fatalf("writeConfig() error: %v", err)
+	}
+
+	b, err := os.ReadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config map[string]any
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config["llmProvider"] != "openai" || config["model"] != "gpt-4.1" || config["maxIterations"] != float64(30) {
+		t.Errorf("config file = %v, want the new provider and model and the other settings kept", config)
+	}
+}
+
+func TestInitWizardRequiresInput(t *testing.T) {
+	w := &initWizard{in: bufio.NewReader(strings.NewReader("")), out: io.Discard}
+	if _, err := w.chooseProvider(); err == nil {
+		t.Errorf("chooseProvider() expected an error without input")
+	}
+}
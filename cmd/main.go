@@ -24,23 +24,25 @@ import (
 	"io"
 	"log"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"slices"
 	"strings"
-	"syscall"
+	"time"
 
-	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/agent"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/incidents"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/journal"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/telemetry"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/ui"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/ui/html"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/ui/jsonrpc"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
 )
@@ -59,6 +61,9 @@ func BuildRootCommand(opt *Options) (*cobra.Command, error) {
 		Long:  "kubectl-ai is a command-line tool that allows you to interact with your Kubernetes cluster using natural language queries. It leverages large language models to understand your intent and translate it into kubectl",
 		Args:  cobra.MaximumNArgs(1), // Only one positional arg is allowed.
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opt.applyResumedSession(cmd.Flags()); err != nil {
+				return err
+			}
 			return RunRootCommand(cmd.Context(), *opt, args)
 		},
 	}
@@ -72,18 +77,68 @@ func BuildRootCommand(opt *Options) (*cobra.Command, error) {
 		},
 	})
 
-	if err := opt.bindCLIFlags(rootCmd.Flags()); err != nil {
+	rootCmd.AddCommand(newPlanUpgradeCommand(opt))
+	rootCmd.AddCommand(newReportCommand(opt))
+	rootCmd.AddCommand(newSessionsCommand(opt))
+	rootCmd.AddCommand(newInitCommand(opt))
+	rootCmd.AddCommand(newBenchCommand(opt))
+	rootCmd.AddCommand(newPolicyCommand(opt))
+
+	// Flags are persistent so that subcommands share the provider, model and cluster settings.
+	if err := opt.bindCLIFlags(rootCmd.PersistentFlags()); err != nil {
 		return nil, err
 	}
+	// Profiles are applied once the flags are parsed, as explicit flags take precedence.
+	// The UI is selected once the profile is applied.
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := opt.applyProfile(cmd.Flags()); err != nil {
+			return err
+		}
+		if err := opt.applyOutput(); err != nil {
+			return err
+		}
+		// The subcommands create their own LLM clients, the air gap covers them too.
+		if opt.AirGapped {
+			if err := enforceAirGapped(cmd.Context(), opt); err != nil {
+				return err
+			}
+		}
+		opt.UIType = resolveUIType(opt, detectUIEnvironment(opt, cmd.Flags()))
+		return nil
+	}
 	return rootCmd, nil
 }
 
 type Options struct {
 	ProviderID string `json:"llmProvider,omitempty"`
 	ModelID    string `json:"model,omitempty"`
+	// ToolArgsRepairModel is the model used to repair malformed tool call arguments.
+	// If empty, ModelID is used.
+	ToolArgsRepairModel string `json:"toolArgsRepairModel,omitempty"`
 	// SkipPermissions is a flag to skip asking for confirmation before executing kubectl commands
 	// that modifies resources in the cluster.
 	SkipPermissions bool `json:"skipPermissions,omitempty"`
+	// EchoCommands shows the exact command and environment of every tool call before it runs.
+	EchoCommands bool `json:"echoCommands,omitempty"`
+	// PreToolHooks are shell commands run before each tool call, with the call as JSON
+	// on their stdin. A hook exiting with a non-zero status vetoes the call.
+	PreToolHooks []string `json:"preToolHooks,omitempty"`
+	// PostToolHooks are shell commands run after each tool call, with the call and its result.
+	PostToolHooks []string `json:"postToolHooks,omitempty"`
+	// Policies are Rego files or directories evaluated for each tool call,
+	// which may allow, deny or require the approval of the call.
+	Policies []string `json:"policies,omitempty"`
+	// KubectlPolicy is a YAML file of rules allowing, denying or asking to confirm
+	// kubectl calls by verb, resource and namespace.
+	KubectlPolicy string `json:"kubectlPolicy,omitempty"`
+	// KubectlTool is the kubectl tool given to the model, "quoted" or "command".
+	KubectlTool string `json:"kubectlTool,omitempty"`
+	// EnabledTools are the patterns of the names of the tools given to the model, all if empty.
+	EnabledTools []string `json:"tools,omitempty"`
+	// DisabledTools are the patterns of the names of the tools withheld from the model.
+	DisabledTools []string `json:"disableTools,omitempty"`
+	// BashAllowlist are the programs the shell commands of the tools may run, all if empty.
+	BashAllowlist []string `json:"bashAllowlist,omitempty"`
 	// EnableToolUseShim is a flag to enable tool use shim.
 	// TODO(droot): figure out a better way to discover if the model supports tool use
 	// and set this automatically.
@@ -96,13 +151,71 @@ type Options struct {
 	// ExternalTools enables discovery and exposure of external MCP tools (only works with --mcp-server)
 	ExternalTools bool `json:"externalTools,omitempty"`
 	MaxIterations int  `json:"maxIterations,omitempty"`
+	// ContextWindow is the number of tokens the model accepts, guessed from the model if 0.
+	ContextWindow int `json:"contextWindow,omitempty"`
+	// MaxAPICallsPerRun caps the cluster API calls made while answering a single query (0 = unlimited).
+	MaxAPICallsPerRun int `json:"maxAPICallsPerRun,omitempty"`
+	// MaxToolOutputBytes truncates the tool outputs sent to the model beyond this size (0 = never truncate).
+	MaxToolOutputBytes int `json:"maxToolOutputBytes,omitempty"`
+	// IssueReportThreshold is the number of occurrences of the same provider error after which a pre-filled issue is offered (0 = never).
+	IssueReportThreshold int `json:"issueReportThreshold,omitempty"`
+	// BatchKubectlQueries merges related kubectl get calls of the same turn into one invocation.
+	BatchKubectlQueries bool `json:"batchKubectlQueries,omitempty"`
+	// ClusterFacts gathers a fact sheet of the cluster at the start of the session for the system prompt.
+	ClusterFacts bool `json:"clusterFacts,omitempty"`
+	// ReadCacheTTL serves identical kubectl reads of a session from a cache for this duration (0 = no cache).
+	ReadCacheTTL metav1.Duration `json:"readCacheTTL,omitempty"`
 	// MCPServerMode is the mode of the MCP server. only works with --mcp-server.
 	MCPServerMode string `json:"mcpServerMode,omitempty"`
 	// Set the HTTP endpoint port for the MCP server when using HTTP transports like streamable-http.
 	HTTPPort int `json:"httpPort,omitempty"`
+	// MCPListen is the address the MCP server serves streamable HTTP on, e.g. ":9090".
+	// It overrides MCPServerMode and HTTPPort.
+	MCPListen string `json:"mcpListen,omitempty"`
 	// KubeConfigPath is the path to the kubeconfig file.
 	// If not provided, the default kubeconfig path will be used.
 	KubeConfigPath string `json:"kubeConfigPath,omitempty"`
+	// KubeContext is the kubeconfig context to use instead of the current context.
+	KubeContext string `json:"kubeContext,omitempty"`
+	// Namespace is the default namespace of the commands run by the tools.
+	Namespace string `json:"namespace,omitempty"`
+	// ReadOnly refuses the tool calls that modify resources instead of asking for permission.
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// AirGapped only allows local providers and refuses the tool calls and the
+	// connections that may send data out of the host.
+	AirGapped bool `json:"airGapped,omitempty"`
+	// DryRun runs the kubectl commands that modify resources with --dry-run=server
+	// and skips the other ones, and presents the plan of the skipped changes.
+	DryRun bool `json:"dryRun,omitempty"`
+	// Maintenance restricts the changes of resources to maintenance windows, and forbids them during change freezes.
+	Maintenance *agent.MaintenancePolicy `json:"maintenance,omitempty"`
+	// Alertmanager is the Alertmanager service of the alerts tool, as namespace/name:port (discovered if empty).
+	Alertmanager string `json:"alertmanager,omitempty"`
+	// Loki configures the Loki instance of the loki_query tool, given to the model when set.
+	Loki *tools.LokiConfig `json:"loki,omitempty"`
+	// Incidents configures the PagerDuty and Opsgenie APIs incidents are imported from.
+	Incidents *incidents.Config `json:"incidents,omitempty"`
+	// IncidentID is the ID of the incident to import into the context of the session.
+	IncidentID string `json:"-"`
+	// StageChanges stages the changes touching several resources, applies them after a
+	// single approval, and rolls back the applied steps if a later step fails.
+	StageChanges bool `json:"stageChanges,omitempty"`
+	// AnswerCandidates is the number of candidates sampled for the final answer of each task.
+	AnswerCandidates int `json:"answerCandidates,omitempty"`
+	// AnswerSelection selects the final answer among its candidates, "vote" or "pick".
+	AnswerSelection string `json:"answerSelection,omitempty"`
+	// Memory enables the long-term memory of the cluster, kept across sessions
+	// in a markdown file per profile or kube context.
+	Memory bool `json:"memory,omitempty"`
+	// Profile is the name of the profile of Profiles to apply.
+	Profile string `json:"profile,omitempty"`
+	// Profiles are named sets of per-cluster defaults, see Profile.
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+	// Env holds environment variables injected into every tool subprocess for the session.
+	Env map[string]string `json:"env,omitempty"`
+	// ToolEnvDenylist holds patterns of environment variable names (e.g. "*_API_KEY")
+	// that are stripped from the environment passed to tool subprocesses.
+	ToolEnvDenylist []string `json:"toolEnvDenylist,omitempty"`
 
 	PromptTemplateFilePath string   `json:"promptTemplateFilePath,omitempty"`
 	ExtraPromptPaths       []string `json:"extraPromptPaths,omitempty"`
@@ -110,23 +223,73 @@ type Options struct {
 	RemoveWorkDir          bool     `json:"removeWorkDir,omitempty"`
 	ToolConfigPaths        []string `json:"toolConfigPaths,omitempty"`
 
-	// UIType is the type of user interface to use.
+	// UIType is the type of user interface to use, "auto" selects it from the terminal and the flags.
 	UIType ui.Type `json:"uiType,omitempty"`
+	// StreamJSON writes each message as a JSON line to stdout, with --ui none.
+	StreamJSON bool `json:"streamJSON,omitempty"`
+	// Output is the output format of the non-interactive mode, text or json.
+	Output string `json:"output,omitempty"`
+	// Inline renders the TUI without the alternate screen, e.g. in tmux panes, keeping the scrollback.
+	Inline bool `json:"inline,omitempty"`
 	// UIListenAddress is the address to listen for the web UI.
 	UIListenAddress string `json:"uiListenAddress,omitempty"`
+	// BackstageAPI serves the JSON API of the Backstage plugin alongside the web UI.
+	// The service token is read from the KUBECTL_AI_BACKSTAGE_TOKEN environment variable.
+	BackstageAPI bool `json:"backstageAPI,omitempty"`
+	// BackstageAllowedOrigins are the origins allowed to call the Backstage API from a browser.
+	BackstageAllowedOrigins []string `json:"backstageAllowedOrigins,omitempty"`
+	// ReportsConfigPath is the path to the scheduled reports configuration.
+	// Scheduled reports run alongside the web UI and the MCP server.
+	ReportsConfigPath string `json:"reportsConfigPath,omitempty"`
 
 	// SkipVerifySSL is a flag to skip verifying the SSL certificate of the LLM provider.
 	SkipVerifySSL bool `json:"skipVerifySSL,omitempty"`
+	// LLMHeaders are extra HTTP headers sent to the LLM provider, keyed by provider ID
+	// (e.g. "openai"), e.g. gateway routing or OpenAI organization headers.
+	LLMHeaders map[string]map[string]string `json:"llmHeaders,omitempty"`
+	// LLMHeaderArgs are "Name: Value" headers sent to the LLM provider of this run, set with --llm-header.
+	LLMHeaderArgs []string `json:"-"`
+	// VertexAIProject, VertexAILocation and VertexAIEndpoint select the project, the
+	// location and the API endpoint of the vertexai provider, discovered if empty.
+	VertexAIProject  string `json:"vertexaiProject,omitempty"`
+	VertexAILocation string `json:"vertexaiLocation,omitempty"`
+	VertexAIEndpoint string `json:"vertexaiEndpoint,omitempty"`
+	// SafetySettings are the block thresholds of the safety filters of Gemini and Vertex AI
+	// by harm category, e.g. {dangerous_content: block_only_high}.
+	SafetySettings map[string]string `json:"safetySettings,omitempty"`
+	// Deterministic requests temperature 0, top_p 1 and a fixed seed from the LLM provider,
+	// and disables the retry jitter, for reproducible evaluation runs.
+	Deterministic bool `json:"deterministic,omitempty"`
+	// LLMTurnTimeout bounds the time of a response of the model, 0 for no bound.
+	LLMTurnTimeout metav1.Duration `json:"llmTurnTimeout,omitempty"`
+	// LLMStallTimeout bounds the time without a chunk of a streamed response,
+	// which is requested again if nothing was received yet, 0 for no bound.
+	LLMStallTimeout metav1.Duration `json:"llmStallTimeout,omitempty"`
+	// MaxRPM and MaxTPM bound the requests and the tokens sent to the LLM
+	// provider per minute, 0 for no bound.
+	MaxRPM int   `json:"maxRPM,omitempty"`
+	MaxTPM int64 `json:"maxTPM,omitempty"`
+
+	// Telemetry is the opt-in anonymous usage metrics mode: off, on or log.
+	Telemetry telemetry.Mode `json:"telemetry,omitempty"`
+	// TelemetryEndpoint is the URL the usage metrics are sent to when Telemetry is on.
+	TelemetryEndpoint string `json:"telemetryEndpoint,omitempty"`
 
 	// Session management options
-	ResumeSession  string `json:"resumeSession,omitempty"`
+	ResumeSession string `json:"resumeSession,omitempty"`
+	// Resume resumes the most recent session, Session the session with the given ID.
+	Resume         bool   `json:"resume,omitempty"`
+	Session        string `json:"session,omitempty"`
 	NewSession     bool   `json:"newSession,omitempty"`
 	ListSessions   bool   `json:"listSessions,omitempty"`
 	DeleteSession  string `json:"deleteSession,omitempty"`
 	SessionBackend string `json:"sessionBackend,omitempty"`
 
-	// ShowToolOutput is a flag to disable truncation of tool output in the terminal UI.
+	// ShowToolOutput shows the output of the tools, it is the verbose verbosity.
 	ShowToolOutput bool `json:"showToolOutput,omitempty"`
+	// Verbosity is the detail of the output of the agent shown by the UIs,
+	// independent of the logs set with -v.
+	Verbosity ui.Verbosity `json:"verbosity,omitempty"`
 
 	// Sandbox enables execution of tools in a sandbox environment.
 	// Supported values: "k8s", "seatbelt".
@@ -149,9 +312,14 @@ var defaultConfigPaths = []string{
 
 func (o *Options) InitDefaults() {
 	o.ProviderID = "gemini"
+	// LLM_CLIENT is the provider URL of the gollm clients, e.g. openai://my-endpoint:8000/v1.
+	if provider := os.Getenv("LLM_CLIENT"); provider != "" {
+		o.ProviderID = provider
+	}
 	o.ModelID = "gemini-2.5-pro"
 	// by default, confirm before executing kubectl commands that modify resources in the cluster.
 	o.SkipPermissions = false
+	o.EchoCommands = false
 	o.MCPServer = false
 	o.MCPClient = false
 	// by default, external tools are disabled (only works with --mcp-server)
@@ -162,18 +330,47 @@ func (o *Options) InitDefaults() {
 	o.Quiet = false
 	o.MCPServer = false
 	o.MaxIterations = 20
+	o.MaxAPICallsPerRun = 0
+	o.MaxToolOutputBytes = 32 * 1024
+	o.BatchKubectlQueries = false
+	o.ReadCacheTTL = metav1.Duration{}
+	o.ClusterFacts = false
+	o.IssueReportThreshold = 3
 	o.KubeConfigPath = ""
+	o.KubeContext = ""
+	o.Namespace = ""
+	o.ReadOnly = false
+	o.AirGapped = false
+	o.DryRun = false
+	o.StageChanges = false
+	o.AnswerCandidates = 1
+	o.AnswerSelection = agent.AnswerSelectionVote
+	o.KubectlTool = tools.KubectlToolCommand
+	o.Memory = false
+	o.Profile = ""
+	// by default, strip LLM API keys from the environment of tool subprocesses.
+	o.ToolEnvDenylist = tools.DefaultSensitiveEnvPatterns
 	o.PromptTemplateFilePath = ""
 	o.ExtraPromptPaths = []string{}
 	o.TracePath = filepath.Join(os.TempDir(), "kubectl-ai-trace.txt")
 	o.RemoveWorkDir = false
 	o.ToolConfigPaths = defaultToolConfigPaths
 	// Default to terminal UI
-	o.UIType = ui.UITypeTerminal
+	o.UIType = ui.UITypeAuto
 	// Default UI listen address for HTML UI
-	o.UIListenAddress = "localhost:8888"
+	o.UIListenAddress = defaultUIListenAddress
+	o.Inline = false
+	o.StreamJSON = false
+	o.Output = outputText
+	o.BackstageAPI = false
 	// Default to not skipping SSL verification
 	o.SkipVerifySSL = false
+	o.Deterministic = false
+	o.LLMTurnTimeout = metav1.Duration{Duration: 10 * time.Minute}
+	o.LLMStallTimeout = metav1.Duration{Duration: 2 * time.Minute}
+	// Telemetry is opt-in
+	o.Telemetry = telemetry.ModeOff
+	o.TelemetryEndpoint = ""
 	// Default MCP server mode is stdio
 	o.MCPServerMode = "stdio"
 	// Default port for HTTP endpoint when using streamable-http mode
@@ -187,6 +384,7 @@ func (o *Options) InitDefaults() {
 
 	// By default, hide tool outputs
 	o.ShowToolOutput = false
+	o.Verbosity = ui.VerbosityNormal
 
 	o.Sandbox = ""
 	o.SandboxImage = "bitnami/kubectl:latest"
@@ -202,25 +400,10 @@ func (o *Options) LoadConfiguration(b []byte) error {
 func (o *Options) LoadConfigurationFile() error {
 	configPaths := defaultConfigPaths
 	for _, configPath := range configPaths {
-		pathWithPlaceholdersExpanded := configPath
-
-		if strings.Contains(pathWithPlaceholdersExpanded, "{CONFIG}") {
-			configDir, err := os.UserConfigDir()
-			if err != nil {
-				return fmt.Errorf("getting user config directory (for config file path %q): %w", configPath, err)
-			}
-			pathWithPlaceholdersExpanded = strings.ReplaceAll(pathWithPlaceholdersExpanded, "{CONFIG}", configDir)
-		}
-
-		if strings.Contains(pathWithPlaceholdersExpanded, "{HOME}") {
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				return fmt.Errorf("getting user home directory (for config file path %q): %w", configPath, err)
-			}
-			pathWithPlaceholdersExpanded = strings.ReplaceAll(pathWithPlaceholdersExpanded, "{HOME}", homeDir)
+		configPath, err := expandConfigPath(configPath)
+		if err != nil {
+			return err
 		}
-
-		configPath = filepath.Clean(pathWithPlaceholdersExpanded)
 		configBytes, err := os.ReadFile(configPath)
 		if err != nil {
 			if os.IsNotExist(err) {
@@ -237,23 +420,41 @@ func (o *Options) LoadConfigurationFile() error {
 	return nil
 }
 
+// expandConfigPath expands the {CONFIG} and {HOME} placeholders of a config file path.
+func expandConfigPath(configPath string) (string, error) {
+	pathWithPlaceholdersExpanded := configPath
+
+	if strings.Contains(pathWithPlaceholdersExpanded, "{CONFIG}") {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("getting user config directory (for config file path %q): %w", configPath, err)
+		}
+		pathWithPlaceholdersExpanded = strings.ReplaceAll(pathWithPlaceholdersExpanded, "{CONFIG}", configDir)
+	}
+
+	if strings.Contains(pathWithPlaceholdersExpanded, "{HOME}") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("getting user home directory (for config file path %q): %w", configPath, err)
+		}
+		pathWithPlaceholdersExpanded = strings.ReplaceAll(pathWithPlaceholdersExpanded, "{HOME}", homeDir)
+	}
+
+	return filepath.Clean(pathWithPlaceholdersExpanded), nil
+}
+
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	ctx, cancel := handleSignals(context.Background())
 	defer cancel()
 
-	go func() {
-		<-ctx.Done()
-		// restore default behavior for a second signal
-		signal.Stop(make(chan os.Signal))
-		cancel()
-		klog.Flush()
-		fmt.Fprintf(os.Stderr, "\nReceived signal, shutting down gracefully... (press Ctrl+C again to force)\n")
-	}()
+	crash := &crashReporter{}
+	defer crash.recoverPanic()
 
-	if err := run(ctx); err != nil {
+	if err := run(ctx, crash); err != nil {
 		// Don't print error if it's a context cancellation
 		if !errors.Is(err, context.Canceled) {
 			fmt.Fprintln(os.Stderr, err)
+			crash.reportError(err)
 		}
 		// Exit with non-zero status code on error, unless it's a graceful shutdown.
 		if errors.Is(err, context.Canceled) {
@@ -263,7 +464,7 @@ func main() {
 	}
 }
 
-func run(ctx context.Context) error {
+func run(ctx context.Context, crash *crashReporter) error {
 	// klog setup must happen before Cobra parses any flags
 
 	// add commandline flags for logging
@@ -283,6 +484,7 @@ func run(ctx context.Context) error {
 	if err := opt.LoadConfigurationFile(); err != nil {
 		return fmt.Errorf("failed to load config file: %w", err)
 	}
+	crash.opt = &opt
 
 	rootCmd, err := BuildRootCommand(&opt)
 	if err != nil {
@@ -297,7 +499,10 @@ func run(ctx context.Context) error {
 	// do this early, before the third-party code logs anything.
 	redirectStdLogToKlog()
 
-	if err := rootCmd.ExecuteContext(ctx); err != nil {
+	start := time.Now()
+	executedCmd, err := rootCmd.ExecuteContextC(ctx)
+	recordTelemetry(ctx, opt, executedCmd, time.Since(start), err)
+	if err != nil {
 		return err
 	}
 
@@ -306,7 +511,28 @@ func run(ctx context.Context) error {
 
 func (opt *Options) bindCLIFlags(f *pflag.FlagSet) error {
 	f.IntVar(&opt.MaxIterations, "max-iterations", opt.MaxIterations, "maximum number of iterations agent will try before giving up")
+	f.IntVar(&opt.ContextWindow, "context-window", opt.ContextWindow, "number of tokens the model accepts; older messages are summarized before the conversation exceeds it (0 = guess from the model, negative = never summarize)")
+	f.IntVar(&opt.MaxAPICallsPerRun, "max-api-calls", opt.MaxAPICallsPerRun, "maximum number of cluster API calls (kubectl invocations) the agent can make per query (0 = unlimited)")
+	f.IntVar(&opt.MaxToolOutputBytes, "max-tool-output-bytes", opt.MaxToolOutputBytes, "size beyond which tool outputs sent to the model are truncated; the full output is kept in the work directory for the model to read by ranges (0 = never truncate)")
+	f.IntVar(&opt.IssueReportThreshold, "issue-report-threshold", opt.IssueReportThreshold, "number of occurrences of the same provider error after which /report-issue is offered to generate a pre-filled GitHub issue (0 = never)")
+	f.BoolVar(&opt.BatchKubectlQueries, "batch-kubectl-queries", opt.BatchKubectlQueries, "merge related kubectl get calls requested in the same turn into a single invocation")
+	f.BoolVar(&opt.ClusterFacts, "cluster-facts", opt.ClusterFacts, "gather the server version, node count, namespaces, CRD groups and default storage class of the cluster at the start of the session, with a single batched command, and give them to the model")
+	f.DurationVar(&opt.ReadCacheTTL.Duration, "read-cache-ttl", opt.ReadCacheTTL.Duration, "serve identical kubectl reads of a session (e.g. kubectl get ns) from a cache for this duration, 0 to disable the cache")
 	f.StringVar(&opt.KubeConfigPath, "kubeconfig", opt.KubeConfigPath, "path to kubeconfig file")
+	f.StringVar(&opt.KubeContext, "context", opt.KubeContext, "kubeconfig context to use instead of the current context")
+	f.StringVarP(&opt.Namespace, "namespace", "n", opt.Namespace, "default namespace of the commands run by the tools")
+	f.BoolVar(&opt.ReadOnly, "read-only", opt.ReadOnly, "refuse tool calls that modify resources instead of asking for permission")
+	f.BoolVar(&opt.AirGapped, "air-gapped", opt.AirGapped, "only allow local providers (ollama, llamacpp), refuse tool calls that may send data out of the host, and refuse connections to other hosts")
+	f.BoolVar(&opt.DryRun, "dry-run", opt.DryRun, "run the kubectl commands that modify resources with --dry-run=server, skip the other ones, and present a plan of the changes instead of applying them")
+	f.BoolVar(&opt.StageChanges, "stage-changes", opt.StageChanges, "show the diffs of the changes touching several resources in a single approval, and roll back the applied steps if a later step fails")
+	f.StringVar(&opt.Alertmanager, "alertmanager", opt.Alertmanager, "Alertmanager service queried by the alerts tool through the API server, as namespace/name:port, e.g. monitoring/alertmanager-operated:9093 (discovered if empty)")
+	f.StringVar(&opt.IncidentID, "incident-id", opt.IncidentID, "ID of a PagerDuty incident or Opsgenie alert whose title, notes and recent alerts seed the session, e.g. Q2W3E4R5T6Y7U8, or pagerduty:<id> and opsgenie:<id> when both are configured")
+	f.IntVar(&opt.AnswerCandidates, "answer-candidates", opt.AnswerCandidates, "number of candidates sampled for the final answer of each task, in one request for the providers supporting it (n > 1)")
+	f.StringVar(&opt.AnswerSelection, "answer-selection", opt.AnswerSelection, "how the final answer is selected among its candidates: vote (the model selects the most consistent one) or pick (the user picks one)")
+	f.BoolVar(&opt.Memory, "memory", opt.Memory, "keep a long-term memory of the cluster across sessions, in ~/.kubectl-ai/memory/<profile or context>.md")
+	f.StringVar(&opt.Profile, "profile", opt.Profile, "name of the profile of the config file to apply (cluster context, namespace, provider, model and tool policy)")
+	f.StringSliceVar(&opt.ToolEnvDenylist, "tool-env-denylist", opt.ToolEnvDenylist, "patterns of environment variable names stripped from tool subprocess environments (empty disables stripping)")
+	f.StringToStringVar(&opt.Env, "env", opt.Env, "environment variables to inject into tool subprocesses, e.g. --env HELM_NAMESPACE=apps,AWS_PROFILE=dev")
 	f.StringVar(&opt.PromptTemplateFilePath, "prompt-template-file-path", opt.PromptTemplateFilePath, "path to custom prompt template file")
 	f.StringArrayVar(&opt.ExtraPromptPaths, "extra-prompt-paths", opt.ExtraPromptPaths, "extra prompt template paths")
 	f.StringVar(&opt.TracePath, "trace-path", opt.TracePath, "path to the trace file")
@@ -314,25 +540,60 @@ func (opt *Options) bindCLIFlags(f *pflag.FlagSet) error {
 
 	f.StringVar(&opt.ProviderID, "llm-provider", opt.ProviderID, "language model provider")
 	f.StringVar(&opt.ModelID, "model", opt.ModelID, "language model e.g. gemini-2.0-flash-thinking-exp-01-21, gemini-2.0-flash")
+	f.StringVar(&opt.ToolArgsRepairModel, "tool-args-repair-model", opt.ToolArgsRepairModel, "model used to repair malformed tool call arguments (defaults to --model)")
 	f.BoolVar(&opt.SkipPermissions, "skip-permissions", opt.SkipPermissions, "(dangerous) skip asking for confirmation before executing kubectl commands that modify resources")
+	f.BoolVar(&opt.EchoCommands, "echo-commands", opt.EchoCommands, "show the exact command and a summary of its environment before every tool call runs, even when auto-approved")
+	f.StringArrayVar(&opt.PreToolHooks, "pre-tool-hook", opt.PreToolHooks, "shell command run before each tool call with the call as JSON on stdin, a non-zero exit status vetoes the call (can be repeated)")
+	f.StringArrayVar(&opt.PostToolHooks, "post-tool-hook", opt.PostToolHooks, "shell command run after each tool call with the call and its result as JSON on stdin (can be repeated)")
+	f.StringVar(&opt.KubectlPolicy, "kubectl-policy", opt.KubectlPolicy, "YAML file of rules allowing, denying or asking to confirm kubectl calls by verb, resource and namespace (see 'kubectl-ai policy test')")
+	f.StringVar(&opt.KubectlTool, "kubectl-tool", opt.KubectlTool, "kubectl tool given to the model: command (a kubectl command line run by the shell) or quoted (verb, resource, namespace and flags as fields, run as a single quoted kubectl call, experimental)")
+	f.StringSliceVar(&opt.EnabledTools, "tools", opt.EnabledTools, "names of the tools given to the model, e.g. kubectl,bash, all if empty. Names may be shell patterns or the names of MCP servers")
+	f.StringSliceVar(&opt.DisabledTools, "disable-tools", opt.DisabledTools, "names of the tools withheld from the model, e.g. bash or gdrive. Names may be shell patterns or the names of MCP servers")
+	f.StringSliceVar(&opt.BashAllowlist, "bash-allowlist", opt.BashAllowlist, "programs the shell commands of the tools may run besides kubectl, e.g. jq,grep,awk, all if empty")
+	f.StringArrayVar(&opt.Policies, "policy", opt.Policies, "Rego policy file or directory evaluated for each tool call, deciding to allow, deny or require approval (can be repeated)")
 	f.BoolVar(&opt.MCPServer, "mcp-server", opt.MCPServer, "run in MCP server mode")
 	f.BoolVar(&opt.ExternalTools, "external-tools", opt.ExternalTools, "in MCP server mode, discover and expose external MCP tools")
 	f.StringArrayVar(&opt.ToolConfigPaths, "custom-tools-config", opt.ToolConfigPaths, "path to custom tools config file or directory")
 	f.BoolVar(&opt.MCPClient, "mcp-client", opt.MCPClient, "enable MCP client mode to connect to external MCP servers")
 	f.StringVar(&opt.MCPServerMode, "mcp-server-mode", opt.MCPServerMode, "mode of the MCP server. Supported values: stdio, streamable-http")
 	f.IntVar(&opt.HTTPPort, "http-port", opt.HTTPPort, "port for the HTTP endpoint in MCP server mode (used with --mcp-server when --mcp-server-mode is streamable-http)")
+	f.StringVar(&opt.MCPListen, "mcp-listen", opt.MCPListen, "address the MCP server serves streamable HTTP on, e.g. :9090 (used with --mcp-server, overrides --mcp-server-mode and --http-port). Set $"+mcpServerTokenEnv+" to require a bearer token")
 	f.BoolVar(&opt.EnableToolUseShim, "enable-tool-use-shim", opt.EnableToolUseShim, "enable tool use shim")
 	f.BoolVar(&opt.Quiet, "quiet", opt.Quiet, "run in non-interactive mode, requires a query to be provided as a positional argument")
 
-	f.Var(&opt.UIType, "ui-type", "user interface type to use. Supported values: terminal, web, tui.")
+	f.Var(&opt.UIType, "ui", "user interface to use: auto, terminal, tui, html, jsonrpc (JSON-RPC over stdio, for editor extensions) or none (run the query once and print its output). auto uses html when serving, tui in a terminal and none when the input or output is piped")
+	f.Var(&opt.UIType, "ui-type", "user interface to use")
+	if err := f.MarkDeprecated("ui-type", "use --ui instead"); err != nil {
+		return err
+	}
+	f.BoolVar(&opt.StreamJSON, "stream-json", opt.StreamJSON, "with --ui none, write each message (type, id, content, timestamp) as a JSON line to stdout as it occurs, for programs wrapping kubectl-ai")
+	f.StringVar(&opt.Output, "output", opt.Output, "output format of the non-interactive mode: text, or json to stream the messages, tool calls and results as JSON lines ending with the final answer, the error and the token usage (implies --ui none --stream-json)")
+	f.BoolVar(&opt.Inline, "inline", opt.Inline, "render the TUI inline instead of in the alternate screen, keeping the scrollback (e.g. in tmux panes)")
 	f.StringVar(&opt.UIListenAddress, "ui-listen-address", opt.UIListenAddress, "address to listen for the HTML UI.")
+	f.BoolVar(&opt.BackstageAPI, "backstage-api", opt.BackstageAPI, "serve the JSON API of the Backstage plugin with the web UI, authenticated by the "+backstageTokenEnv+" service token")
+	f.StringSliceVar(&opt.BackstageAllowedOrigins, "backstage-allowed-origins", opt.BackstageAllowedOrigins, "origins allowed to call the Backstage API from a browser (\"*\" allows any origin)")
+	f.StringVar(&opt.ReportsConfigPath, "reports-config", opt.ReportsConfigPath, "path to the scheduled reports config, run alongside the web UI and the MCP server")
 	f.BoolVar(&opt.SkipVerifySSL, "skip-verify-ssl", opt.SkipVerifySSL, "skip verifying the SSL certificate of the LLM provider")
-	f.BoolVar(&opt.ShowToolOutput, "show-tool-output", opt.ShowToolOutput, "show tool output in the terminal UI")
+	f.StringVar(&opt.VertexAIProject, "vertexai-project", opt.VertexAIProject, "GCP project of the vertexai provider (defaults to $GOOGLE_CLOUD_PROJECT or the project of gcloud)")
+	f.StringVar(&opt.VertexAILocation, "vertexai-location", opt.VertexAILocation, "location of the vertexai provider, e.g. europe-west4 or global (defaults to $GOOGLE_CLOUD_LOCATION, or us-central1)")
+	f.StringVar(&opt.VertexAIEndpoint, "vertexai-endpoint", opt.VertexAIEndpoint, "base URL of the Vertex AI API, e.g. a Private Service Connect endpoint (defaults to the endpoint of the location)")
+	f.StringArrayVar(&opt.LLMHeaderArgs, "llm-header", opt.LLMHeaderArgs, "extra HTTP header sent to the LLM provider, as \"Name: Value\" (can be repeated)")
+	f.BoolVar(&opt.Deterministic, "deterministic", opt.Deterministic, "use temperature 0, top_p 1 and a fixed seed (where supported) and no retry jitter, for reproducible runs")
+	f.DurationVar(&opt.LLMTurnTimeout.Duration, "llm-turn-timeout", opt.LLMTurnTimeout.Duration, "maximum duration of a response of the model, 0 for no limit")
+	f.DurationVar(&opt.LLMStallTimeout.Duration, "llm-stall-timeout", opt.LLMStallTimeout.Duration, "maximum duration without output of a streamed response of the model, which is requested again (up to 3 times) if nothing was received yet, 0 for no limit")
+	f.IntVar(&opt.MaxRPM, "max-rpm", opt.MaxRPM, "maximum number of requests sent to the LLM provider per minute, the requests beyond it are delayed (0 = no limit)")
+	f.Int64Var(&opt.MaxTPM, "max-tpm", opt.MaxTPM, "maximum number of tokens used per minute, the requests are delayed while the tokens used in the last minute exceed it (0 = no limit)")
+	f.BoolVar(&opt.ShowToolOutput, "show-tool-output", opt.ShowToolOutput, "show the output of the tools, same as --verbosity=verbose")
+	f.Var(&opt.Verbosity, "verbosity", "detail of the agent output shown in the UIs: quiet (the answers only), normal (also the commands run and the reasoning of the model) or verbose (also the output of the commands), independent of -v")
+	f.StringVar((*string)(&opt.Telemetry), "telemetry", string(opt.Telemetry), "anonymous usage metrics, never including queries or cluster data: off, on (requires telemetryEndpoint) or log (write to the log only)")
+	f.StringVar(&opt.TelemetryEndpoint, "telemetry-endpoint", opt.TelemetryEndpoint, "URL the usage metrics are sent to with --telemetry=on")
 
 	f.StringVar(&opt.Sandbox, "sandbox", opt.Sandbox, "execute tools in a sandbox environment (k8s, seatbelt)")
 	f.StringVar(&opt.SandboxImage, "sandbox-image", opt.SandboxImage, "container image to use for the sandbox")
 
 	f.StringVar(&opt.ResumeSession, "resume-session", opt.ResumeSession, "ID of session to resume (use 'latest' for the most recent session)")
+	f.BoolVar(&opt.Resume, "resume", opt.Resume, "resume the most recent session, with its model and kubeconfig context unless set on the command line")
+	f.StringVar(&opt.Session, "session", opt.Session, "ID of the session to resume, with its model and kubeconfig context unless set on the command line")
 	f.BoolVar(&opt.ListSessions, "list-sessions", opt.ListSessions, "list all available sessions")
 	f.StringVar(&opt.DeleteSession, "delete-session", opt.DeleteSession, "delete a session by ID")
 	f.BoolVar(&opt.NewSession, "new-session", opt.NewSession, "start a new persistent session")
@@ -356,11 +617,57 @@ func RunRootCommand(ctx context.Context, opt Options, args []string) error {
 		return fmt.Errorf("--external-tools can only be used with --mcp-server")
 	}
 
+	if opt.Inline && opt.UIType != ui.UITypeTUI {
+		return fmt.Errorf("--inline can only be used with --ui tui")
+	}
+
+	if opt.UIType == ui.UITypeJSONRPC && opt.Quiet {
+		return fmt.Errorf("--quiet cannot be used with --ui jsonrpc")
+	}
+
+	if opt.BackstageAPI && opt.UIType != ui.UITypeWeb {
+		return fmt.Errorf("--backstage-api can only be used with --ui html")
+	}
+
+	if opt.StreamJSON && opt.UIType != ui.UITypeNone {
+		return fmt.Errorf("--stream-json and --output json can only be used with --ui none")
+	}
+
+	if opt.UIType == ui.UITypeNone {
+		// Without a UI, the query runs once and its output is printed.
+		opt.Quiet = true
+	}
+
+	if opt.KubectlTool != tools.KubectlToolQuoted && opt.KubectlTool != tools.KubectlToolCommand {
+		return fmt.Errorf("invalid --kubectl-tool %q, expected %s or %s", opt.KubectlTool, tools.KubectlToolQuoted, tools.KubectlToolCommand)
+	}
+	if opt.AnswerSelection != agent.AnswerSelectionVote && opt.AnswerSelection != agent.AnswerSelectionPick {
+		return fmt.Errorf("invalid --answer-selection %q, expected %s or %s", opt.AnswerSelection, agent.AnswerSelectionVote, agent.AnswerSelectionPick)
+	}
+	switch opt.Verbosity {
+	case ui.VerbosityQuiet, ui.VerbosityNormal, ui.VerbosityVerbose:
+	default:
+		return fmt.Errorf("invalid --verbosity %q, expected %s, %s or %s", opt.Verbosity, ui.VerbosityQuiet, ui.VerbosityNormal, ui.VerbosityVerbose)
+	}
+	if opt.ShowToolOutput && opt.Verbosity == ui.VerbosityNormal {
+		opt.Verbosity = ui.VerbosityVerbose
+	}
+
+	if err = tools.SetSensitiveEnvPatterns(opt.ToolEnvDenylist); err != nil {
+		return fmt.Errorf("invalid --tool-env-denylist: %w", err)
+	}
+
 	// resolve kubeconfig path with priority: flag/env > KUBECONFIG > default path
 	if err = resolveKubeConfigPath(&opt); err != nil {
 		return fmt.Errorf("failed to resolve kubeconfig path: %w", err)
 	}
 
+	if opt.ReportsConfigPath != "" && (opt.MCPServer || opt.UIType == ui.UITypeWeb) {
+		if err = startReportScheduler(ctx, opt); err != nil {
+			return err
+		}
+	}
+
 	if opt.MCPServer {
 		if err = startMCPServer(ctx, opt); err != nil {
 			return fmt.Errorf("failed to start MCP server: %w", err)
@@ -386,6 +693,10 @@ func RunRootCommand(ctx context.Context, opt Options, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to check if stdin has data: %w", err)
 	}
+	if opt.UIType == ui.UITypeJSONRPC {
+		// stdin carries the requests of the editor.
+		hasInputData = false
+	}
 
 	// Handles positional args or stdin
 	var queryFromCmd string
@@ -393,6 +704,9 @@ func RunRootCommand(ctx context.Context, opt Options, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to resolve query input %w", err)
 	}
+	if opt.Quiet && queryFromCmd == "" {
+		return fmt.Errorf("no query provided: pass a query as an argument or on stdin, or use --ui terminal or --ui tui for an interactive session")
+	}
 
 	klog.Info("Application started", "pid", os.Getpid())
 
@@ -420,38 +734,110 @@ func RunRootCommand(ctx context.Context, opt Options, args []string) error {
 		return fmt.Errorf("failed to create session manager: %w", err)
 	}
 
+	var memoryFile string
+	if opt.Memory {
+		memoryFile, err = memoryFilePath(opt)
+		if err != nil {
+			return fmt.Errorf("resolving memory file: %w", err)
+		}
+	}
+
+	var policy *agent.PolicyEvaluator
+	if len(opt.Policies) > 0 {
+		policy, err = agent.NewPolicyEvaluator(ctx, opt.Policies)
+		if err != nil {
+			return fmt.Errorf("loading policies: %w", err)
+		}
+	}
+	if opt.Maintenance != nil {
+		if err := opt.Maintenance.Validate(); err != nil {
+			return err
+		}
+	}
+	if opt.Loki != nil {
+		if err := opt.Loki.Validate(); err != nil {
+			return err
+		}
+	}
+	var incident *incidents.Incident
+	if opt.IncidentID != "" {
+		config := incidents.Config{}
+		if opt.Incidents != nil {
+			config = *opt.Incidents
+		}
+		incident, err = config.Fetch(ctx, opt.IncidentID)
+		if err != nil {
+			return fmt.Errorf("importing incident: %w", err)
+		}
+	}
+	var kubectlPolicy *tools.KubectlPolicy
+	if opt.KubectlPolicy != "" {
+		kubectlPolicy, err = tools.LoadKubectlPolicy(opt.KubectlPolicy)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Build agentFactory for new agents
 	agentFactory := func(ctx context.Context) (*agent.Agent, error) {
-		var client gollm.Client
-		var err error
-		if opt.SkipVerifySSL {
-			client, err = gollm.NewClient(ctx, opt.ProviderID, gollm.WithSkipVerifySSL())
-		} else {
-			client, err = gollm.NewClient(ctx, opt.ProviderID)
-		}
+		client, err := newLLMClient(ctx, opt)
 		if err != nil {
-			return nil, fmt.Errorf("creating llm client: %w", err)
+			return nil, err
 		}
 
 		return &agent.Agent{
-			Model:              opt.ModelID,
-			Provider:           opt.ProviderID,
-			Kubeconfig:         opt.KubeConfigPath,
-			LLM:                client,
-			MaxIterations:      opt.MaxIterations,
-			PromptTemplateFile: opt.PromptTemplateFilePath,
-			ExtraPromptPaths:   opt.ExtraPromptPaths,
-			Tools:              tools.Default(),
-			Recorder:           recorder,
-			RemoveWorkDir:      opt.RemoveWorkDir,
-			SkipPermissions:    opt.SkipPermissions,
-			EnableToolUseShim:  opt.EnableToolUseShim,
-			MCPClientEnabled:   opt.MCPClient,
-			Sandbox:            opt.Sandbox,
-			SandboxImage:       opt.SandboxImage,
-			SessionBackend:     opt.SessionBackend,
-			RunOnce:            opt.Quiet,
-			InitialQuery:       queryFromCmd,
+			Model:                opt.ModelID,
+			Provider:             opt.ProviderID,
+			ToolArgsRepairModel:  opt.ToolArgsRepairModel,
+			Kubeconfig:           opt.KubeConfigPath,
+			Namespace:            opt.Namespace,
+			MemoryFile:           memoryFile,
+			ClusterFacts:         opt.ClusterFacts,
+			Incident:             incident,
+			Alertmanager:         opt.Alertmanager,
+			Loki:                 opt.Loki,
+			Env:                  opt.Env,
+			LLM:                  client,
+			MaxIterations:        opt.MaxIterations,
+			ContextWindow:        opt.ContextWindow,
+			MaxAPICallsPerRun:    opt.MaxAPICallsPerRun,
+			MaxToolOutputBytes:   opt.MaxToolOutputBytes,
+			BatchKubectlQueries:  opt.BatchKubectlQueries,
+			ReadCacheTTL:         opt.ReadCacheTTL.Duration,
+			PromptTemplateFile:   opt.PromptTemplateFilePath,
+			ExtraPromptPaths:     opt.ExtraPromptPaths,
+			Tools:                tools.Default(),
+			Recorder:             recorder,
+			TracePath:            opt.TracePath,
+			IssueReportThreshold: opt.IssueReportThreshold,
+			RemoveWorkDir:        opt.RemoveWorkDir,
+			SkipPermissions:      opt.SkipPermissions,
+			EchoCommands:         opt.EchoCommands,
+			PreToolHooks:         opt.PreToolHooks,
+			PostToolHooks:        opt.PostToolHooks,
+			Policy:               policy,
+			KubectlPolicy:        kubectlPolicy,
+			KubectlTool:          opt.KubectlTool,
+			EnabledTools:         opt.EnabledTools,
+			DisabledTools:        opt.DisabledTools,
+			BashAllowlist:        opt.BashAllowlist,
+			ReadOnly:             opt.ReadOnly,
+			AirGapped:            opt.AirGapped,
+			DryRun:               opt.DryRun,
+			StageChanges:         opt.StageChanges,
+			Maintenance:          opt.Maintenance,
+			AnswerCandidates:     opt.AnswerCandidates,
+			AnswerSelection:      opt.AnswerSelection,
+			EnableToolUseShim:    opt.EnableToolUseShim,
+			Deterministic:        opt.Deterministic,
+			LLMTurnTimeout:       opt.LLMTurnTimeout.Duration,
+			LLMStallTimeout:      opt.LLMStallTimeout.Duration,
+			MCPClientEnabled:     opt.MCPClient,
+			Sandbox:              opt.Sandbox,
+			SandboxImage:         opt.SandboxImage,
+			SessionBackend:       opt.SessionBackend,
+			RunOnce:              opt.Quiet,
+			InitialQuery:         queryFromCmd,
 		}, nil
 	}
 
@@ -482,9 +868,14 @@ func RunRootCommand(ctx context.Context, opt Options, args []string) error {
 
 	// If no session loaded (or resume failed/not requested), create a new one
 	if session == nil {
+		kubeContext, err := currentKubeContext(opt)
+		if err != nil {
+			return err
+		}
 		meta := sessions.Metadata{
-			ModelID:    opt.ModelID,
-			ProviderID: opt.ProviderID,
+			ModelID:     opt.ModelID,
+			ProviderID:  opt.ProviderID,
+			KubeContext: kubeContext,
 		}
 		session, err = sessionManager.NewSession(meta)
 		if err != nil {
@@ -511,24 +902,45 @@ func RunRootCommand(ctx context.Context, opt Options, args []string) error {
 
 	var userInterface ui.UI
 	switch opt.UIType {
+	case ui.UITypeNone:
+		if opt.StreamJSON {
+			userInterface = ui.NewStreamJSONUI(defaultAgent, os.Stdout)
+			break
+		}
+		userInterface, err = ui.NewTerminalUI(defaultAgent, hasInputData, opt.Verbosity, recorder)
+		if err != nil {
+			return fmt.Errorf("creating terminal UI: %w", err)
+		}
 	case ui.UITypeTerminal:
 		// since stdin is already consumed, we use TTY for taking input from user
 		useTTYForInput := hasInputData
-		userInterface, err = ui.NewTerminalUI(defaultAgent, useTTYForInput, opt.ShowToolOutput, recorder)
+		userInterface, err = ui.NewTerminalUI(defaultAgent, useTTYForInput, opt.Verbosity, recorder)
 		if err != nil {
 			return fmt.Errorf("creating terminal UI: %w", err)
 		}
 	case ui.UITypeWeb:
-		userInterface, err = html.NewHTMLUserInterface(agentManager, sessionManager, opt.ModelID, opt.ProviderID, opt.UIListenAddress, recorder)
+		htmlUI, err := html.NewHTMLUserInterface(agentManager, sessionManager, opt.ModelID, opt.ProviderID, opt.UIListenAddress, opt.Verbosity, recorder)
 		if err != nil {
 			return fmt.Errorf("creating web UI: %w", err)
 		}
+		if opt.BackstageAPI {
+			if err := enableBackstageAPI(htmlUI, opt); err != nil {
+				return err
+			}
+		}
+		userInterface = htmlUI
 	case ui.UITypeTUI:
-		userInterface = ui.NewTUI(defaultAgent)
+		userInterface = ui.NewTUI(defaultAgent, opt.Inline, opt.Verbosity)
+	case ui.UITypeJSONRPC:
+		userInterface = jsonrpc.NewServer(defaultAgent, os.Stdin, os.Stdout)
 	default:
-		return fmt.Errorf("ui-type mode %q is not known", opt.UIType)
+		return fmt.Errorf("ui %q is not known", opt.UIType)
 	}
 
+	if opt.UIType == ui.UITypeNone || opt.UIType == ui.UITypeTerminal {
+		// Ctrl+C interrupts the task of the agent and returns to the prompt.
+		setTaskInterrupt(ctx, defaultAgent.Interrupt)
+	}
 	err = userInterface.Run(ctx)
 	if err != nil && !errors.Is(err, context.Canceled) {
 		return fmt.Errorf("running UI: %w", err)
@@ -677,27 +1089,47 @@ func resolveKubeConfigPath(opt *Options) error {
 		}
 	}
 
-	// We resolve the kubeconfig path to an absolute path, so we can run kubectl from any working directory.
+	// We resolve the kubeconfig paths to absolute paths, so we can run kubectl from any working directory.
+	// Like $KUBECONFIG, the path may list several files to merge.
 	if opt.KubeConfigPath != "" {
-		p, err := filepath.Abs(opt.KubeConfigPath)
-		if err != nil {
-			return fmt.Errorf("failed to get absolute path for kubeconfig file %q: %w", opt.KubeConfigPath, err)
+		var paths []string
+		for _, path := range filepath.SplitList(opt.KubeConfigPath) {
+			if path == "" {
+				continue
+			}
+			p, err := filepath.Abs(path)
+			if err != nil {
+				return fmt.Errorf("failed to get absolute path for kubeconfig file %q: %w", path, err)
+			}
+			paths = append(paths, p)
 		}
-		opt.KubeConfigPath = p
+		opt.KubeConfigPath = strings.Join(paths, string(filepath.ListSeparator))
 	}
 
-	return nil
+	return scopeKubeConfig(opt)
 }
 
+// mcpServerTokenEnv is the environment variable holding the bearer token of the HTTP MCP server.
+const mcpServerTokenEnv = "KUBECTL_AI_MCP_TOKEN"
+
 func startMCPServer(ctx context.Context, opt Options) error {
 	workDir := filepath.Join(os.TempDir(), "kubectl-ai-mcp")
 	if err := os.MkdirAll(workDir, 0o755); err != nil {
 		return fmt.Errorf("error creating work directory: %w", err)
 	}
-	mcpServer, err := newKubectlMCPServer(ctx, opt.KubeConfigPath, tools.Default(), workDir, opt.ExternalTools, opt.MCPServerMode, opt.HTTPPort)
+	mode, listenAddr := opt.MCPServerMode, fmt.Sprintf(":%d", opt.HTTPPort)
+	if opt.MCPListen != "" {
+		mode, listenAddr = "streamable-http", opt.MCPListen
+	}
+	serverTools := tools.Default()
+	if err := serverTools.Select(opt.EnabledTools, opt.DisabledTools); err != nil {
+		return err
+	}
+	mcpServer, err := newKubectlMCPServer(ctx, opt.KubeConfigPath, serverTools, workDir, opt.ExternalTools, mode, listenAddr)
 	if err != nil {
 		return fmt.Errorf("creating mcp server: %w", err)
 	}
+	mcpServer.token = os.Getenv(mcpServerTokenEnv)
 	return mcpServer.Serve(ctx)
 }
 
@@ -719,19 +1151,7 @@ func handleListSessions(opt Options) error {
 	}
 
 	fmt.Println("Available sessions:")
-	fmt.Println("ID\t\tCreated\t\t\tLast Accessed\t\tModel\t\tProvider")
-	fmt.Println("--\t\t-------\t\t\t-------------\t\t-----\t\t--------")
-
-	for _, session := range sessionList {
-		fmt.Printf("%s\t%s\t%s\t%s\t%s\n",
-			session.ID,
-			session.CreatedAt.Format("2006-01-02 15:04:05"),
-			session.LastModified.Format("2006-01-02 15:04:05"),
-			session.ModelID,
-			session.ProviderID)
-	}
-
-	return nil
+	return printSessionTree(os.Stdout, sessionList)
 }
 
 // handleDeleteSession deletes a session by ID.
@@ -767,3 +1187,28 @@ func handleDeleteSession(opt Options) error {
 	fmt.Printf("Session %s deleted successfully.\n", opt.DeleteSession)
 	return nil
 }
+
+// backstageTokenEnv is the environment variable holding the service token of the Backstage API.
+const backstageTokenEnv = "KUBECTL_AI_BACKSTAGE_TOKEN"
+
+// enableBackstageAPI serves the JSON API of the Backstage plugin, scoping the
+// queries to the namespace of the service they were started from.
+func enableBackstageAPI(htmlUI *html.HTMLUserInterface, opt Options) error {
+	token := os.Getenv(backstageTokenEnv)
+	if token == "" {
+		return fmt.Errorf("--backstage-api requires a service token in $%s", backstageTokenEnv)
+	}
+	return htmlUI.EnableBackstageAPI(html.BackstageConfig{
+		Token:          token,
+		AllowedOrigins: opt.BackstageAllowedOrigins,
+		ScopeKubeconfig: func(namespace string) (string, error) {
+			scoped := opt
+			scoped.KubeContext = ""
+			scoped.Namespace = namespace
+			if err := scopeKubeConfig(&scoped); err != nil {
+				return "", err
+			}
+			return scoped.KubeConfigPath, nil
+		},
+	})
+}
@@ -16,7 +16,12 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/mcp"
@@ -33,10 +38,12 @@ type kubectlMCPServer struct {
 	workDir       string
 	mcpManager    *mcp.Manager // Add MCP manager for external tool calls
 	mcpServerMode string       // Server mode (e.g., "streamable-http", "stdio")
-	httpPort      int          // Port for HTTP-based server modes
+	listenAddr    string       // Listen address for HTTP-based server modes, e.g. ":9080"
+	// token is the bearer token the HTTP clients must send, if not empty.
+	token string
 }
 
-func newKubectlMCPServer(ctx context.Context, kubectlConfig string, tools tools.Tools, workDir string, exposeExternalTools bool, serverMode string, httpPort int) (*kubectlMCPServer, error) {
+func newKubectlMCPServer(ctx context.Context, kubectlConfig string, tools tools.Tools, workDir string, exposeExternalTools bool, serverMode string, listenAddr string) (*kubectlMCPServer, error) {
 	s := &kubectlMCPServer{
 		kubectlConfig: kubectlConfig,
 		workDir:       workDir,
@@ -47,7 +54,7 @@ func newKubectlMCPServer(ctx context.Context, kubectlConfig string, tools tools.
 		),
 		tools:         tools,
 		mcpServerMode: serverMode,
-		httpPort:      httpPort,
+		listenAddr:    listenAddr,
 	}
 
 	// Add built-in tools
@@ -165,17 +172,53 @@ func (s *kubectlMCPServer) Serve(ctx context.Context) error {
 
 	switch s.mcpServerMode {
 	case "streamable-http":
-		// Start the server in streamable HTTP mode
-		klog.Infof("Starting MCP server in streamable HTTP mode on port %d", s.httpPort)
-		httpServer := server.NewStreamableHTTPServer(s.server)
-		endpoint := fmt.Sprintf(":%d", s.httpPort)
-		klog.Infof("Listening for streamable HTTP connections on port %d", s.httpPort)
-		return httpServer.Start(endpoint)
+		return s.serveHTTP(ctx)
 	default:
 		return server.ServeStdio(s.server)
 	}
 }
 
+// serveHTTP serves the MCP protocol over streamable HTTP at /mcp until ctx is done.
+func (s *kubectlMCPServer) serveHTTP(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", requireBearerToken(s.token, server.NewStreamableHTTPServer(s.server)))
+	httpServer := &http.Server{Addr: s.listenAddr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			klog.Warningf("Failed to shut down the MCP server: %v", err)
+		}
+	}()
+
+	if s.token == "" {
+		klog.Warningf("The MCP server on %s accepts unauthenticated connections, set $%s to require a bearer token", s.listenAddr, mcpServerTokenEnv)
+	}
+	klog.Infof("Listening for streamable HTTP connections on %s", s.listenAddr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// requireBearerToken rejects the requests without the bearer token, unless token is empty.
+func requireBearerToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
 func (s *kubectlMCPServer) handleToolCall(ctx context.Context, request mcpgo.CallToolRequest) (*mcpgo.CallToolResult, error) {
 	toolName := request.Params.Name
 
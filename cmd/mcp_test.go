@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -38,7 +40,7 @@ func TestKubectlMCPServerHTTPClientIntegration(t *testing.T) {
 
 	workDir := t.TempDir()
 
-	server, err := newKubectlMCPServer(ctx, "", toolset, workDir, false, "streamable-http", port)
+	server, err := newKubectlMCPServer(ctx, "", toolset, workDir, false, "streamable-http", fmt.Sprintf("127.0.0.1:%d", port))
 	if err != nil {
 		t.Fatalf("failed to create MCP server: %v", err)
 	}
@@ -105,6 +107,39 @@ func TestKubectlMCPServerHTTPClientIntegration(t *testing.T) {
 	}
 }
 
+func TestRequireBearerToken(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "no token configured", token: "", authorization: "", wantStatus: http.StatusOK},
+		{name: "missing token", token: "secret", authorization: "", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", token: "secret", authorization: "Basic secret", wantStatus: http.StatusUnauthorized},
+		{name: "valid token", token: "secret", authorization: "Bearer secret", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			requireBearerToken(tt.token, next).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
 func waitForHTTPServer(t *testing.T, port int) {
 	t.Helper()
 
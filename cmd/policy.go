@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"github.com/spf13/cobra"
+)
+
+func newPolicyCommand(opt *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Validate the kubectl policy",
+	}
+	cmd.AddCommand(newTestPolicyCommand(opt))
+	return cmd
+}
+
+func newTestPolicyCommand(opt *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "test <command>",
+		Short: "Show the decision of the kubectl policy on a command",
+		Long: `test loads the kubectl policy of --kubectl-policy (or kubectlPolicy in the config file) and shows the decision on
+each kubectl call of the command, e.g. kubectl-ai policy test 'kubectl delete pods -l app=web -n prod'.
+Calls without a context flag run in --context, or the current kubeconfig context, and calls without a namespace
+flag in --namespace, or the namespace of their context.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opt.KubectlPolicy == "" {
+				return fmt.Errorf("no kubectl policy: set --kubectl-policy or kubectlPolicy in the config file")
+			}
+			policy, err := tools.LoadKubectlPolicy(opt.KubectlPolicy)
+			if err != nil {
+				return err
+			}
+			if err := resolveKubeConfigPath(opt); err != nil {
+				return fmt.Errorf("failed to resolve kubeconfig path: %w", err)
+			}
+			scope := tools.KubeconfigScope(opt.KubeConfigPath)
+			if opt.Namespace != "" {
+				scope.Namespace = opt.Namespace
+			}
+
+			requests, err := tools.ParseKubectlRequests(args[0], scope)
+			if err != nil {
+				return err
+			}
+			if len(requests) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "The command runs no kubectl calls.")
+				return nil
+			}
+			out := cmd.OutOrStdout()
+			for _, request := range requests {
+				fmt.Fprintf(out, "  %s\n", policy.EvaluateRequest(request).Explain())
+			}
+			fmt.Fprintf(out, "Decision: %s\n", policy.Evaluate(args[0], scope).Action)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/agent"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"github.com/spf13/pflag"
+)
+
+// Profile is a named set of per-cluster defaults, selected with --profile,
+// e.g. a read-only profile for the production cluster.
+// Fields left empty keep the value of the config file.
+type Profile struct {
+	KubeConfigPath string `json:"kubeConfigPath,omitempty"`
+	KubeContext    string `json:"kubeContext,omitempty"`
+	Namespace      string `json:"namespace,omitempty"`
+	ProviderID     string `json:"llmProvider,omitempty"`
+	ModelID        string `json:"model,omitempty"`
+
+	// Tool policy.
+	ReadOnly        *bool    `json:"readOnly,omitempty"`
+	SkipPermissions *bool    `json:"skipPermissions,omitempty"`
+	MaxAPICalls     *int     `json:"maxAPICallsPerRun,omitempty"`
+	ToolEnvDenylist []string `json:"toolEnvDenylist,omitempty"`
+	// Maintenance replaces the maintenance windows and change freezes of the config file.
+	Maintenance *agent.MaintenancePolicy `json:"maintenance,omitempty"`
+
+	// Memory enables the long-term memory of the cluster of the profile.
+	Memory *bool `json:"memory,omitempty"`
+}
+
+// applyProfile applies the selected profile to the options. Flags set on the
+// command line take precedence over the profile.
+func (o *Options) applyProfile(flags *pflag.FlagSet) error {
+	if o.Profile == "" {
+		return nil
+	}
+	p, ok := o.Profiles[o.Profile]
+	if !ok {
+		names := make([]string, 0, len(o.Profiles))
+		for name := range o.Profiles {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return fmt.Errorf("unknown profile %q, profiles in the config file: %v", o.Profile, names)
+	}
+
+	setString := func(flag string, dst *string, value string) {
+		if value != "" && !flags.Changed(flag) {
+			*dst = value
+		}
+	}
+	setString("kubeconfig", &o.KubeConfigPath, p.KubeConfigPath)
+	setString("context", &o.KubeContext, p.KubeContext)
+	setString("namespace", &o.Namespace, p.Namespace)
+	setString("llm-provider", &o.ProviderID, p.ProviderID)
+	setString("model", &o.ModelID, p.ModelID)
+
+	if p.ReadOnly != nil && !flags.Changed("read-only") {
+		o.ReadOnly = *p.ReadOnly
+	}
+	if p.SkipPermissions != nil && !flags.Changed("skip-permissions") {
+		o.SkipPermissions = *p.SkipPermissions
+	}
+	if p.MaxAPICalls != nil && !flags.Changed("max-api-calls") {
+		o.MaxAPICallsPerRun = *p.MaxAPICalls
+	}
+	if p.ToolEnvDenylist != nil && !flags.Changed("tool-env-denylist") {
+		o.ToolEnvDenylist = p.ToolEnvDenylist
+	}
+	if p.Memory != nil && !flags.Changed("memory") {
+		o.Memory = *p.Memory
+	}
+	if p.Maintenance != nil {
+		o.Maintenance = p.Maintenance
+	}
+
+	if o.ReadOnly && o.SkipPermissions {
+		return fmt.Errorf("profile %q: read-only and skip-permissions cannot be combined", o.Profile)
+	}
+	return nil
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// scopeKubeConfig points the options at a kubeconfig using the selected
+// context and namespace, so that every tool subprocess uses them. The
+// kubeconfig is written to the user cache directory, as the tools only
+// receive the kubeconfig path.
+func scopeKubeConfig(opt *Options) error {
+	if opt.KubeContext == "" && opt.Namespace == "" {
+		return nil
+	}
+	if opt.KubeConfigPath == "" {
+		return fmt.Errorf("--context and --namespace require a kubeconfig file")
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return fmt.Errorf("getting user cache directory: %w", err)
+	}
+	path, err := tools.WriteScopedKubeconfig(opt.KubeConfigPath, opt.KubeContext, opt.Namespace, filepath.Join(cacheDir, "kubectl-ai", "kubeconfigs"))
+	if err != nil {
+		return err
+	}
+	opt.KubeConfigPath = path
+	return nil
+}
+
+// memoryFilePath returns the long-term memory file of the cluster, named
+// after the selected profile, or else the current kube context.
+func memoryFilePath(opt Options) (string, error) {
+	name := opt.Profile
+	if name == "" {
+		var err error
+		if name, err = currentKubeContext(opt); err != nil {
+			return "", err
+		}
+	}
+	if name == "" {
+		name = "default"
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting user home directory: %w", err)
+	}
+	return filepath.Join(home, ".kubectl-ai", "memory", unsafeFileNameChars.ReplaceAllString(name, "-")+".md"), nil
+}
+
+// currentKubeContext returns the current context of the kubeconfig, if any.
+func currentKubeContext(opt Options) (string, error) {
+	if opt.KubeConfigPath == "" {
+		return "", nil
+	}
+	// Missing kubeconfig files are skipped.
+	config, err := tools.LoadKubeconfig(opt.KubeConfigPath)
+	if err != nil {
+		return "", err
+	}
+	return config.CurrentContext, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestApplyProfile(t *testing.T) {
+	config := `
+model: gemini-2.5-pro
+profiles:
+  prod-readonly:
+    kubeContext: prod
+    namespace: shop
+    llmProvider: openai
+    model: gpt-4.1
+    readOnly: true
+`
+	tests := []struct {
+		name         string
+		args         []string
+		wantErr      bool
+		wantContext  string
+		wantModel    string
+		wantReadOnly bool
+	}{
+		{name: "no profile", args: nil, wantModel: "gemini-2.5-pro"},
+		{name: "profile", args: []string{"--profile", "prod-readonly"}, wantContext: "prod", wantModel: "gpt-4.1", wantReadOnly: true},
+		{name: "flags take precedence", args: []string{"--profile", "prod-readonly", "--model", "gemini-2.5-flash", "--read-only=false"}, wantContext: "prod", wantModel: "gemini-2.5-flash"},
+		{name: "unknown profile", args: []string{"--profile", "staging"}, wantErr: true},
+		{name: "read-only with skip-permissions", args: []string{"--profile", "prod-readonly", "--skip-permissions"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opt Options
+			opt.InitDefaults()
+			if err := opt.LoadConfiguration([]byte(config)); err != nil {
+				t.Fatal(err)
+			}
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			if err := opt.bindCLIFlags(flags); err != nil {
+				t.Fatal(err)
+			}
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := opt.applyProfile(flags)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("applyProfile() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyProfile() error: %v", err)
+			}
+			if opt.KubeContext != tt.wantContext || opt.ModelID != tt.wantModel || opt.ReadOnly != tt.wantReadOnly {
+				t.Errorf("applyProfile() context=%q model=%q readOnly=%v, want context=%q model=%q readOnly=%v",
+					opt.KubeContext, opt.ModelID, opt.ReadOnly, tt.wantContext, tt.wantModel, tt.wantReadOnly)
+			}
+		})
+	}
+}
+
+func TestScopeKubeConfig(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster: {server: https://dev.example.com}
+- name: prod
+  cluster: {server: https://prod.example.com}
+users:
+- name: admin
+  user: {token: secret}
+contexts:
+- name: dev
+  context: {cluster: dev, user: admin}
+- name: prod
+  context: {cluster: prod, user: admin, namespace: default}
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	opt := Options{KubeConfigPath: kubeconfig, KubeContext: "prod", Namespace: "shop"}
+	if err := scopeKubeConfig(&opt); err != nil {
+		t.Fatalf("scopeKubeConfig() error: %v", err)
+	}
+	if opt.KubeConfigPath == kubeconfig {
+		t.Fatalf("scopeKubeConfig() did not change the kubeconfig path")
+	}
+	scoped, err := clientcmd.LoadFromFile(opt.KubeConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scoped.CurrentContext != "prod" || scoped.Contexts["prod"].Namespace != "shop" {
+		t.Errorf("scoped kubeconfig has current context %q with namespace %q, want prod and shop", scoped.CurrentContext, scoped.Contexts["prod"].Namespace)
+	}
+	if info, err := os.Stat(opt.KubeConfigPath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("scoped kubeconfig should only be readable by the user, got %v", info.Mode())
+	}
+
+	opt = Options{KubeConfigPath: kubeconfig, KubeContext: "staging"}
+	if err := scopeKubeConfig(&opt); err == nil {
+		t.Errorf("scopeKubeConfig() expected an error for an unknown context")
+	}
+
+	// The contexts of a list of kubeconfig files are merged.
+	kind := filepath.Join(t.TempDir(), "kind")
+	if err := os.WriteFile(kind, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: kind
+  cluster: {server: https://127.0.0.1:6443}
+contexts:
+- name: kind
+  context: {cluster: kind, user: admin}
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opt = Options{KubeConfigPath: kubeconfig + string(filepath.ListSeparator) + kind, KubeContext: "kind"}
+	if err := scopeKubeConfig(&opt); err != nil {
+		t.Fatalf("scopeKubeConfig() error with merged kubeconfigs: %v", err)
+	}
+	scoped, err = clientcmd.LoadFromFile(opt.KubeConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scoped.CurrentContext != "kind" || scoped.AuthInfos["admin"] == nil {
+		t.Errorf("scoped kubeconfig has current context %q, want kind with the user of the first file", scoped.CurrentContext)
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/reports"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+)
+
+func newReportCommand(opt *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Generate reports about the cluster from live data",
+	}
+	cmd.AddCommand(newCapacityReportCommand(opt))
+	cmd.AddCommand(newRunReportCommand(opt))
+	cmd.AddCommand(newServeReportsCommand(opt))
+	return cmd
+}
+
+func newRunReportCommand(opt *Options) *cobra.Command {
+	var format, outputFile, configPath, deliver string
+	var pricing reports.Pricing
+	cmd := &cobra.Command{
+		Use:       "run <template>",
+		Short:     "Generate a report from a template, optionally delivering it",
+		Long:      fmt.Sprintf("run generates a report from one of the templates (%v) and writes it as markdown or HTML.\nWith --deliver, the report is instead delivered as configured for the named report in the reports config.", reports.TemplateNames()),
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: reports.TemplateNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			env, cleanup, err := newReportEnv(*opt)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			env.Pricing = pricing
+
+			if deliver != "" {
+				cfg, err := reports.LoadConfig(configPath)
+				if err != nil {
+					return err
+				}
+				for _, r := range cfg.Reports {
+					if r.Name == deliver {
+						r.Template = args[0]
+						if cmd.Flags().Changed("format") {
+							r.Format = reports.Format(format)
+						}
+						if pricing == (reports.Pricing{}) {
+							env.Pricing = cfg.Pricing
+						}
+						return reports.RunAndDeliver(ctx, r, env)
+					}
+				}
+				return fmt.Errorf("no report named %q in %s", deliver, configPath)
+			}
+
+			report, err := reports.Generate(ctx, args[0], env)
+			if err != nil {
+				return err
+			}
+			content, err := reports.Render(report, reports.Format(format))
+			if err != nil {
+				return err
+			}
+			if outputFile == "" {
+				_, err = io.WriteString(cmd.OutOrStdout(), content)
+				return err
+			}
+			if err := os.WriteFile(outputFile, []byte(content), 0o644); err != nil {
+				return fmt.Errorf("writing report: %w", err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Report written to %s\n", outputFile)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", string(reports.FormatMarkdown), "output format: markdown or html")
+	cmd.Flags().StringVar(&outputFile, "output-file", "", "write the report to this file instead of stdout")
+	cmd.Flags().StringVar(&deliver, "deliver", "", "deliver the report using the delivery of the report with this name in the reports config")
+	cmd.Flags().StringVar(&configPath, "config", defaultReportsConfigPath(), "path to the reports config, used with --deliver")
+	cmd.Flags().Float64Var(&pricing.CPUHour, "cpu-hour-price", 0, "price of one CPU core for one hour, used by the cost report")
+	cmd.Flags().Float64Var(&pricing.MemoryGiBHour, "memory-gib-hour-price", 0, "price of one GiB of memory for one hour, used by the cost report")
+	cmd.Flags().StringVar(&pricing.Currency, "currency", "", "currency displayed with the prices, e.g. USD")
+	return cmd
+}
+
+func newServeReportsCommand(opt *Options) *cobra.Command {
+	var configPath string
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the scheduled reports of the reports config until interrupted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			cfg, err := reports.LoadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			env, cleanup, err := newReportEnv(*opt)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			return reports.Serve(ctx, cfg, env)
+		},
+	}
+	cmd.Flags().StringVar(&configPath, "config", defaultReportsConfigPath(), "path to the reports config")
+	return cmd
+}
+
+// startReportScheduler runs the scheduled reports in the background until ctx is done.
+func startReportScheduler(ctx context.Context, opt Options) error {
+	cfg, err := reports.LoadConfig(opt.ReportsConfigPath)
+	if err != nil {
+		return err
+	}
+	env, cleanup, err := newReportEnv(opt)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer cleanup()
+		if err := reports.Serve(ctx, cfg, env); err != nil {
+			klog.Errorf("report scheduler stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// newReportEnv prepares the environment report templates collect their data from.
+// The returned function removes the work directory.
+func newReportEnv(opt Options) (reports.Env, func(), error) {
+	if err := tools.SetSensitiveEnvPatterns(opt.ToolEnvDenylist); err != nil {
+		return reports.Env{}, nil, fmt.Errorf("invalid --tool-env-denylist: %w", err)
+	}
+	if err := resolveKubeConfigPath(&opt); err != nil {
+		return reports.Env{}, nil, fmt.Errorf("failed to resolve kubeconfig path: %w", err)
+	}
+	workDir, err := os.MkdirTemp("", "kubectl-ai-report-")
+	if err != nil {
+		return reports.Env{}, nil, fmt.Errorf("creating work directory: %w", err)
+	}
+	env := reports.Env{
+		Executor:   sandbox.NewLocalExecutor(),
+		Kubeconfig: opt.KubeConfigPath,
+		WorkDir:    workDir,
+	}
+	return env, func() { os.RemoveAll(workDir) }, nil
+}
+
+func defaultReportsConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "reports.yaml"
+	}
+	return filepath.Join(home, ".config", "kubectl-ai", "reports.yaml")
+}
+
+func newCapacityReportCommand(opt *Options) *cobra.Command {
+	var scaleFactor float64
+	var replicas []string
+	var format string
+	cmd := &cobra.Command{
+		Use:   "capacity",
+		Short: "Report requests and limits against allocatable capacity per node pool",
+		Long: `capacity aggregates the CPU and memory requests and limits of the running pods against the allocatable capacity of each node pool.
+With --scale-factor or --replicas, it simulates a scale-up scenario by placing the additional pods on the nodes of their node pool, and reports the remaining headroom and the pods that would not fit.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scenario := tools.CapacityScenario{ScaleFactor: scaleFactor}
+			var err error
+			if scenario.Replicas, err = tools.ParseCapacityReplicas(replicas); err != nil {
+				return err
+			}
+			return runCapacityReport(cmd.Context(), *opt, scenario, format, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().Float64Var(&scaleFactor, "scale-factor", 1, "simulate scaling the replicas of every Deployment and StatefulSet by this factor")
+	cmd.Flags().StringArrayVar(&replicas, "replicas", nil, "simulate scaling a workload, as <namespace>/<kind>/<name>=<replicas> (can be repeated)")
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text or json")
+	return cmd
+}
+
+func runCapacityReport(ctx context.Context, opt Options, scenario tools.CapacityScenario, format string, out io.Writer) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid --format %q, supported values: text, json", format)
+	}
+	if err := tools.SetSensitiveEnvPatterns(opt.ToolEnvDenylist); err != nil {
+		return fmt.Errorf("invalid --tool-env-denylist: %w", err)
+	}
+	if err := resolveKubeConfigPath(&opt); err != nil {
+		return fmt.Errorf("failed to resolve kubeconfig path: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "kubectl-ai-report-")
+	if err != nil {
+		return fmt.Errorf("creating work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	report, err := tools.CollectCapacity(ctx, sandbox.NewLocalExecutor(), opt.KubeConfigPath, workDir, scenario)
+	if err != nil {
+		return fmt.Errorf("collecting capacity: %w", err)
+	}
+	if report.Error != "" {
+		return fmt.Errorf("collecting capacity: %s", report.Error)
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return renderCapacityReport(out, report)
+}
+
+// renderCapacityReport renders the capacity report as text tables.
+func renderCapacityReport(out io.Writer, report *tools.CapacityReport) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE POOL\tNODES\tPODS\tCPU ALLOCATABLE\tCPU REQUESTS\tCPU LIMITS\tMEMORY ALLOCATABLE\tMEMORY REQUESTS\tMEMORY LIMITS")
+	for _, pool := range report.NodePools {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.2f\t%s\t%s\t%s\t%s\t%s\n",
+			pool.Name, pool.Nodes, pool.Pods,
+			pool.AllocatableCPU,
+			withPercent(fmt.Sprintf("%.2f", pool.RequestedCPU), pool.RequestedCPU, pool.AllocatableCPU),
+			withPercent(fmt.Sprintf("%.2f", pool.LimitCPU), pool.LimitCPU, pool.AllocatableCPU),
+			formatBytes(pool.AllocatableMemory),
+			withPercent(formatBytes(pool.RequestedMemory), float64(pool.RequestedMemory), float64(pool.AllocatableMemory)),
+			withPercent(formatBytes(pool.LimitMemory), float64(pool.LimitMemory), float64(pool.AllocatableMemory)),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	if report.Scenario != nil {
+		fmt.Fprintln(out, "Headroom after the simulated scale-up:")
+	} else {
+		fmt.Fprintln(out, "Headroom:")
+	}
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if report.Scenario != nil {
+		fmt.Fprintln(w, "NODE POOL\tADDED PODS\tUNSCHEDULABLE PODS\tCPU HEADROOM\tMEMORY HEADROOM")
+	} else {
+		fmt.Fprintln(w, "NODE POOL\tCPU HEADROOM\tMEMORY HEADROOM")
+	}
+	for _, pool := range report.NodePools {
+		if report.Scenario != nil {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%.2f\t%s\n", pool.Name, pool.AddedPods, pool.UnschedulablePods, pool.HeadroomCPU, formatBytes(pool.HeadroomMemory))
+		} else {
+			fmt.Fprintf(w, "%s\t%.2f\t%s\n", pool.Name, pool.HeadroomCPU, formatBytes(pool.HeadroomMemory))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(out, "\nWarning: %s", warning)
+	}
+	if len(report.Warnings) > 0 {
+		fmt.Fprintln(out)
+	}
+	return nil
+}
+
+func withPercent(value string, part, total float64) string {
+	if total == 0 {
+		return value
+	}
+	return fmt.Sprintf("%s (%.0f%%)", value, part/total*100)
+}
+
+func formatBytes(b int64) string {
+	const gi = 1 << 30
+	if b >= gi {
+		return fmt.Sprintf("%.1fGi", float64(b)/gi)
+	}
+	return resource.NewQuantity(b, resource.BinarySI).String()
+}
@@ -0,0 +1,290 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func newSessionsCommand(opt *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "List, export and import persistent sessions, and report the feedback on their answers",
+	}
+	cmd.AddCommand(newListSessionsCommand(opt))
+	cmd.AddCommand(newExportSessionCommand(opt))
+	cmd.AddCommand(newImportSessionCommand(opt))
+	cmd.AddCommand(newFeedbackCommand(opt))
+	return cmd
+}
+
+func newListSessionsCommand(opt *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the sessions, with the sessions forked from each session (with /fork) below it",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := newPersistentSessionManager(*opt)
+			if err != nil {
+				return err
+			}
+			sessionList, err := manager.ListSessions()
+			if err != nil {
+				return fmt.Errorf("failed to list sessions: %w", err)
+			}
+			if len(sessionList) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No sessions found.")
+				return nil
+			}
+			return printSessionTree(cmd.OutOrStdout(), sessionList)
+		},
+	}
+}
+
+// printSessionTree prints the sessions as the tree of their forks.
+func printSessionTree(w io.Writer, sessionList []*api.Session) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCREATED\tLAST ACCESSED\tMODEL\tPROVIDER")
+	for _, entry := range sessions.Tree(sessionList) {
+		session := entry.Session
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\t%s\n",
+			entry.Prefix,
+			session.ID,
+			session.CreatedAt.Format("2006-01-02 15:04:05"),
+			session.LastModified.Format("2006-01-02 15:04:05"),
+			session.ModelID,
+			session.ProviderID)
+	}
+	return tw.Flush()
+}
+
+func newFeedbackCommand(opt *Options) *cobra.Command {
+	var since time.Duration
+	var format string
+	cmd := &cobra.Command{
+		Use:   "feedback",
+		Short: "Report the ratings of the answers given with /feedback, per model",
+		Long: `feedback aggregates the ratings of the answers given with '/feedback up|down [comment]' in all the sessions, per provider and model.
+The text format lists the ratings per model and the comments of the answers rated down; the json format also holds every rating, with its session, request and comment, for further analysis.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "text" && format != "json" {
+				return fmt.Errorf("invalid --format %q, supported values: text, json", format)
+			}
+			manager, err := newPersistentSessionManager(*opt)
+			if err != nil {
+				return err
+			}
+			sessionList, err := manager.ListSessions()
+			if err != nil {
+				return fmt.Errorf("failed to list sessions: %w", err)
+			}
+			var start time.Time
+			if since > 0 {
+				start = time.Now().Add(-since)
+			}
+			report := sessions.AggregateFeedback(sessionList, start)
+			if format == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			return printFeedbackReport(cmd.OutOrStdout(), report)
+		},
+	}
+	cmd.Flags().DurationVar(&since, "since", 0, "only report the ratings given in this past duration, e.g. 168h (0 = all)")
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text or json")
+	return cmd
+}
+
+// printFeedbackReport prints the ratings per model, and the comments of the answers rated down.
+func printFeedbackReport(w io.Writer, report *sessions.FeedbackReport) error {
+	if len(report.Entries) == 0 {
+		_, err := fmt.Fprintln(w, "No feedback found.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROVIDER\tMODEL\tUP\tDOWN\tAPPROVAL")
+	for _, model := range report.Models {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.0f%%\n", model.Provider, model.Model, model.Up, model.Down, 100*model.Approval())
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var comments []sessions.FeedbackEntry
+	for _, entry := range report.Entries {
+		if entry.Rating == api.FeedbackDown && entry.Comment != "" {
+			comments = append(comments, entry)
+		}
+	}
+	if len(comments) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "\nComments on the answers rated down:")
+	for _, entry := range comments {
+		fmt.Fprintf(w, "  - %s (session %s, %s): %s\n", entry.Time.Format("2006-01-02 15:04"), entry.SessionID, entry.Model, entry.Comment)
+		if entry.Query != "" {
+			fmt.Fprintf(w, "    request: %s\n", entry.Query)
+		}
+	}
+	return nil
+}
+
+func newExportSessionCommand(opt *Options) *cobra.Command {
+	var outputFile string
+	cmd := &cobra.Command{
+		Use:   "export <session-id>",
+		Short: "Export a session to a file, to continue it on another machine",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manager, err := newPersistentSessionManager(*opt)
+			if err != nil {
+				return err
+			}
+			export, err := manager.ExportSession(args[0])
+			if err != nil {
+				return fmt.Errorf("exporting session %s: %w", args[0], err)
+			}
+
+			out := cmd.OutOrStdout()
+			if outputFile != "" {
+				f, err := os.Create(outputFile)
+				if err != nil {
+					return fmt.Errorf("creating %s: %w", outputFile, err)
+				}
+				defer f.Close()
+				out = f
+			}
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(export); err != nil {
+				return fmt.Errorf("writing session export: %w", err)
+			}
+			if outputFile != "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Session %s exported to %s\n", export.ID, outputFile)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&outputFile, "output-file", "", "write the session to this file instead of stdout")
+	return cmd
+}
+
+func newImportSessionCommand(opt *Options) *cobra.Command {
+	var onConflict string
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import a session exported with 'sessions export' into the local store",
+		Long: `import loads a session exported with 'kubectl-ai sessions export', e.g. by a teammate, into the local session store.
+Continue it with --resume-session <id>. When a session with the same ID already exists, --on-conflict decides what happens:
+rename imports it under a new ID, merge adds the missing messages to the existing session, replace overwrites it, and fail aborts.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("reading session export: %w", err)
+			}
+			var export sessions.Export
+			if err := json.Unmarshal(b, &export); err != nil {
+				return fmt.Errorf("parsing session export %s: %w", args[0], err)
+			}
+
+			manager, err := newPersistentSessionManager(*opt)
+			if err != nil {
+				return err
+			}
+			session, err := manager.ImportSession(&export, sessions.ConflictPolicy(onConflict))
+			if err != nil {
+				return fmt.Errorf("importing session: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Imported session %s with %d messages. Continue it with: kubectl-ai --resume-session %s\n",
+				session.ID, len(session.AllMessages()), session.ID)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&onConflict, "on-conflict", string(sessions.ConflictRename), "what to do when the session ID already exists: rename, merge, replace or fail")
+	return cmd
+}
+
+// newPersistentSessionManager returns a session manager for the configured
+// backend, using the filesystem instead of memory as sessions must outlive the command.
+func newPersistentSessionManager(opt Options) (*sessions.SessionManager, error) {
+	backend := opt.SessionBackend
+	if backend == "memory" {
+		backend = "filesystem"
+	}
+	manager, err := sessions.NewSessionManager(backend)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session manager: %w", err)
+	}
+	return manager, nil
+}
+
+// applyResumedSession selects the session to resume with --resume or
+// --session, and restores the provider, the model and the kubeconfig context
+// of the session, so that a restarted kubectl-ai picks up where it left off.
+// Flags set on the command line take precedence over the session.
+func (o *Options) applyResumedSession(flags *pflag.FlagSet) error {
+	switch {
+	case o.Resume && o.Session != "":
+		return fmt.Errorf("--resume and --session cannot be combined")
+	case o.Resume:
+		o.ResumeSession = "latest"
+	case o.Session != "":
+		o.ResumeSession = o.Session
+	}
+	if o.ResumeSession == "" {
+		return nil
+	}
+
+	manager, err := newPersistentSessionManager(*o)
+	if err != nil {
+		return err
+	}
+	var session *api.Session
+	if o.ResumeSession == "latest" {
+		if session, err = manager.GetLatestSession(); err != nil {
+			return fmt.Errorf("failed to get latest session: %w", err)
+		}
+		if session == nil {
+			// A new session is created.
+			return nil
+		}
+	} else if session, err = manager.FindSessionByID(o.ResumeSession); err != nil {
+		return fmt.Errorf("session %s not found: %w", o.ResumeSession, err)
+	}
+	o.ResumeSession = session.ID
+
+	setString := func(flag string, dst *string, value string) {
+		if value != "" && !flags.Changed(flag) {
+			*dst = value
+		}
+	}
+	setString("llm-provider", &o.ProviderID, session.ProviderID)
+	setString("model", &o.ModelID, session.ModelID)
+	setString("context", &o.KubeContext, session.KubeContext)
+	return nil
+}
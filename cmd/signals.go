@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// doubleInterruptWindow is the delay within which a second Ctrl+C exits,
+// even though the first one interrupted a task.
+const doubleInterruptWindow = 2 * time.Second
+
+// signalHandler turns a Ctrl+C during a task of the agent into an interrupt
+// of the task, which returns to the prompt. A Ctrl+C with no task running, a
+// second Ctrl+C in a row, or a SIGTERM shuts down gracefully, and one more
+// signal kills the process.
+type signalHandler struct {
+	shutdown context.CancelFunc
+
+	mu sync.Mutex
+	// interrupt interrupts the running task, and returns false if there is none.
+	interrupt     func() bool
+	lastInterrupt time.Time
+}
+
+type signalHandlerKey struct{}
+
+// handleSignals returns a context canceled on shutdown, whose signal handler
+// interrupts the tasks set with setTaskInterrupt.
+func handleSignals(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &signalHandler{shutdown: cancel}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go h.run(ctx, signals)
+	return context.WithValue(ctx, signalHandlerKey{}, h), func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// setTaskInterrupt sets the function interrupting the running task on Ctrl+C,
+// e.g. the Interrupt of the agent of the terminal UI.
+func setTaskInterrupt(ctx context.Context, interrupt func() bool) {
+	if h, ok := ctx.Value(signalHandlerKey{}).(*signalHandler); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.interrupt = interrupt
+	}
+}
+
+func (h *signalHandler) run(ctx context.Context, signals chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signals:
+			if sig == syscall.SIGINT && h.interruptTask(time.Now()) {
+				fmt.Fprintf(os.Stderr, "\nInterrupting the task... (press Ctrl+C again to exit)\n")
+				continue
+			}
+			// restore default behavior for a further signal
+			signal.Stop(signals)
+			h.shutdown()
+			klog.Flush()
+			fmt.Fprintf(os.Stderr, "\nReceived signal, shutting down gracefully... (press Ctrl+C again to force)\n")
+			return
+		}
+	}
+}
+
+// interruptTask interrupts the running task, and returns false if there is
+// none, or if a task was interrupted less than doubleInterruptWindow ago.
+func (h *signalHandler) interruptTask(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.interrupt == nil || now.Sub(h.lastInterrupt) < doubleInterruptWindow {
+		return false
+	}
+	if !h.interrupt() {
+		return false
+	}
+	h.lastInterrupt = now
+	return true
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignalHandlerInterruptTask(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	running := true
+	interrupts := 0
+	h := &signalHandler{}
+
+	if h.interruptTask(t0) {
+		t.Error("interruptTask() without an agent = true, want false")
+	}
+
+	h.interrupt = func() bool {
+		if running {
+			interrupts++
+		}
+		return running
+	}
+	for _, step := range []struct {
+		at      time.Duration
+		running bool
+		want    bool
+	}{
+		{at: 0, running: true, want: true},
+		// A second Ctrl+C in a row exits.
+		{at: time.Second, running: true, want: false},
+		{at: 5 * time.Second, running: true, want: true},
+		// A Ctrl+C with no task running exits.
+		{at: 10 * time.Second, running: false, want: false},
+	} {
+		running = step.running
+		if got := h.interruptTask(t0.Add(step.at)); got != step.want {
+			t.Errorf("interruptTask() at %s = %v, want %v", step.at, got, step.want)
+		}
+	}
+	if interrupts != 2 {
+		t.Errorf("interrupts = %d, want 2", interrupts)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/telemetry"
+	"github.com/spf13/cobra"
+)
+
+// recordTelemetry records the anonymous usage event of the command run, when telemetry is enabled.
+func recordTelemetry(ctx context.Context, opt Options, cmd *cobra.Command, duration time.Duration, runErr error) {
+	if opt.Telemetry == telemetry.ModeOff || opt.Telemetry == "" {
+		return
+	}
+	configDir, err := expandConfigPath(filepath.Join("{CONFIG}", "kubectl-ai"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: telemetry disabled: %v\n", err)
+		return
+	}
+	client, err := telemetry.New(opt.Telemetry, opt.TelemetryEndpoint, configDir, version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: telemetry disabled: %v\n", err)
+		return
+	}
+
+	command := "kubectl-ai"
+	uiType := ""
+	if cmd != nil {
+		command = cmd.CommandPath()
+		if !cmd.HasParent() {
+			switch {
+			case opt.MCPServer:
+				uiType = "mcp-server"
+			case !opt.Quiet:
+				uiType = string(opt.UIType)
+			}
+		}
+	}
+	// The command context may be cancelled on exit; the client has its own timeout.
+	client.Record(context.WithoutCancel(ctx), client.NewEvent(command, opt.ProviderID, uiType, duration, runErr))
+}
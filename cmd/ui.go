@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/ui"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// defaultUIListenAddress is the default address of the web UI.
+const defaultUIListenAddress = "localhost:8888"
+
+// The output formats of the non-interactive mode.
+const (
+	outputText = "text"
+	// outputJSON streams the messages as JSON lines, ending with the result of the query.
+	outputJSON = "json"
+)
+
+// applyOutput selects the JSON stream for --output json.
+func (o *Options) applyOutput() error {
+	switch o.Output {
+	case "", outputText:
+	case outputJSON:
+		o.StreamJSON = true
+	default:
+		return fmt.Errorf("invalid --output %q, expected %s or %s", o.Output, outputText, outputJSON)
+	}
+	return nil
+}
+
+// uiEnvironment describes what the UI is selected from when --ui is auto.
+type uiEnvironment struct {
+	// serving is true when a listen address or the Backstage API was requested.
+	serving bool
+	// interactive is true when both stdin and stdout are terminals.
+	interactive bool
+}
+
+// resolveUIType returns the UI to use. Unless one was selected explicitly,
+// the web UI is used when serving, the TUI in a terminal, and no UI when the
+// input or the output is piped, with --quiet or with --stream-json.
+func resolveUIType(opt *Options, env uiEnvironment) ui.Type {
+	switch {
+	case opt.UIType != ui.UITypeAuto && opt.UIType != "":
+		return opt.UIType
+	case env.serving:
+		return ui.UITypeWeb
+	case opt.Quiet || opt.StreamJSON || !env.interactive:
+		return ui.UITypeNone
+	default:
+		return ui.UITypeTUI
+	}
+}
+
+// detectUIEnvironment inspects the flags and the terminal to select the UI.
+func detectUIEnvironment(opt *Options, flags *pflag.FlagSet) uiEnvironment {
+	return uiEnvironment{
+		serving:     opt.BackstageAPI || flags.Changed("ui-listen-address") || opt.UIListenAddress != defaultUIListenAddress,
+		interactive: term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())),
+	}
+}
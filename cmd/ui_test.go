@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/ui"
+)
+
+func TestResolveUIType(t *testing.T) {
+	tests := []struct {
+		name       string
+		uiType     ui.Type
+		quiet      bool
+		streamJSON bool
+		env        uiEnvironment
+		want       ui.Type
+	}{
+		{
+			name:   "explicit UI",
+			uiType: ui.UITypeTerminal,
+			env:    uiEnvironment{serving: true},
+			want:   ui.UITypeTerminal,
+		},
+		{
+			name:   "serving",
+			uiType: ui.UITypeAuto,
+			env:    uiEnvironment{serving: true, interactive: true},
+			want:   ui.UITypeWeb,
+		},
+		{
+			name:   "terminal",
+			uiType: ui.UITypeAuto,
+			env:    uiEnvironment{interactive: true},
+			want:   ui.UITypeTUI,
+		},
+		{
+			name:   "piped",
+			uiType: ui.UITypeAuto,
+			want:   ui.UITypeNone,
+		},
+		{
+			name:   "quiet in a terminal",
+			uiType: ui.UITypeAuto,
+			quiet:  true,
+			env:    uiEnvironment{interactive: true},
+			want:   ui.UITypeNone,
+		},
+		{
+			name:       "JSON stream in a terminal",
+			uiType:     ui.UITypeAuto,
+			streamJSON: true,
+			env:        uiEnvironment{interactive: true},
+			want:       ui.UITypeNone,
+		},
+		{
+			name: "unset UI from a config file",
+			env:  uiEnvironment{interactive: true},
+			want: ui.UITypeTUI,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := &Options{UIType: tt.uiType, Quiet: tt.quiet, StreamJSON: tt.streamJSON}
+			if got := resolveUIType(opt, tt.env); got != tt.want {
+				t.Errorf("resolveUIType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOutput(t *testing.T) {
+	tests := []struct {
+		output         string
+		wantStreamJSON bool
+		wantErr        bool
+	}{
+		{output: outputText},
+		{output: outputJSON, wantStreamJSON: true},
+		{output: "yaml", wantErr: true},
+	}
+	for _, tt := range tests {
+		opt := &Options{Output: tt.output}
+		err := opt.applyOutput()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("applyOutput(%q) error = %v, want error %v", tt.output, err, tt.wantErr)
+		}
+		if opt.StreamJSON != tt.wantStreamJSON {
+			t.Errorf("applyOutput(%q) StreamJSON = %v, want %v", tt.output, opt.StreamJSON, tt.wantStreamJSON)
+		}
+	}
+}
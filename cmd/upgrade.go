@@ -0,0 +1,165 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"github.com/spf13/cobra"
+)
+
+const upgradePlanPromptTemplate = `You are a Kubernetes upgrade expert. Write an upgrade plan for upgrading the cluster from %s to %s.
+
+The following facts were collected from the cluster (JSON):
+%s
+
+Write the plan as a markdown document with the following sections:
+1. Summary: the current and target versions, and an overall risk assessment (low, medium or high).
+2. Blockers: the APIs removed by the target version that are still in use, listing every affected resource and the API version to migrate it to.
+3. Risks: PodDisruptionBudgets that would block node drains, node pools whose kubelet version would violate the version skew policy, and any other risk supported by the facts.
+4. Step-by-step plan: numbered steps, starting with the pre-upgrade migrations, then the control plane upgrade (one minor version at a time), then each node pool, with a validation check after each step.
+5. Rollback: how to recover if a step fails.
+
+Only state what is supported by the facts; when information is missing, say so and give the kubectl command to check it.`
+
+func newPlanUpgradeCommand(opt *Options) *cobra.Command {
+	var target, outputPath string
+	cmd := &cobra.Command{
+		Use:   "plan-upgrade",
+		Short: "Generate a step-by-step upgrade plan for the cluster",
+		Long:  "plan-upgrade checks the cluster for APIs removed by the target version, PodDisruptionBudgets that block node drains and the node pool inventory, then writes an upgrade plan document with risks.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPlanUpgrade(cmd.Context(), *opt, target, outputPath)
+		},
+	}
+	cmd.Flags().StringVar(&target, "target", "", "Kubernetes version to upgrade to, e.g. 1.31")
+	cmd.Flags().StringVar(&outputPath, "output-file", "", "path of the file to write the plan to (defaults to stdout)")
+	_ = cmd.MarkFlagRequired("target")
+	return cmd
+}
+
+// runPlanUpgrade first executes the data gathering steps of the plan with the
+// data tools, then asks the model to turn the collected facts into a plan document.
+func runPlanUpgrade(ctx context.Context, opt Options, target, outputPath string) error {
+	if err := tools.SetSensitiveEnvPatterns(opt.ToolEnvDenylist); err != nil {
+		return fmt.Errorf("invalid --tool-env-denylist: %w", err)
+	}
+	if err := resolveKubeConfigPath(&opt); err != nil {
+		return fmt.Errorf("failed to resolve kubeconfig path: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "kubectl-ai-plan-upgrade-")
+	if err != nil {
+		return fmt.Errorf("creating work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	fmt.Fprintf(os.Stderr, "Collecting upgrade facts for Kubernetes %s (deprecated APIs, node pools, PodDisruptionBudgets)...\n", target)
+	facts, err := tools.CollectUpgradeFacts(ctx, sandbox.NewLocalExecutor(), opt.KubeConfigPath, workDir, target)
+	if err != nil {
+		return fmt.Errorf("collecting upgrade facts: %w", err)
+	}
+	if facts.Deprecations.Error != "" {
+		return fmt.Errorf("checking deprecated APIs: %s", facts.Deprecations.Error)
+	}
+	factsJSON, err := json.MarshalIndent(facts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling upgrade facts: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Writing the upgrade plan...\n")
+	client, err := newLLMClient(ctx, opt)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	resp, err := client.GenerateCompletion(ctx, &gollm.CompletionRequest{
+		Model:  opt.ModelID,
+		Prompt: fmt.Sprintf(upgradePlanPromptTemplate, facts.Deprecations.ServerVersion, facts.Deprecations.TargetVersion, factsJSON),
+	})
+	if err != nil {
+		return fmt.Errorf("generating upgrade plan: %w", err)
+	}
+
+	if outputPath == "" {
+		fmt.Println(resp.Response())
+		return nil
+	}
+	if err := os.WriteFile(outputPath, []byte(resp.Response()), 0o644); err != nil {
+		return fmt.Errorf("writing upgrade plan: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Upgrade plan written to %s\n", outputPath)
+	return nil
+}
+
+// llmHeaders returns the extra HTTP headers configured for the LLM provider.
+func llmHeaders(opt Options) (http.Header, error) {
+	providerID, _, _ := strings.Cut(opt.ProviderID, "://")
+	headers := make(http.Header)
+	for name, value := range opt.LLMHeaders[providerID] {
+		headers.Set(name, value)
+	}
+	// Headers passed on the command line replace the configured headers of the same name.
+	args := make(http.Header)
+	for _, arg := range opt.LLMHeaderArgs {
+		name, value, err := gollm.ParseHeader(arg)
+		if err != nil {
+			return nil, fmt.Errorf("parsing --llm-header: %w", err)
+		}
+		args.Add(name, value)
+	}
+	for name, values := range args {
+		headers[name] = values
+	}
+	return headers, nil
+}
+
+// newLLMClient creates the LLM client for the configured provider.
+func newLLMClient(ctx context.Context, opt Options) (gollm.Client, error) {
+	var opts []gollm.Option
+	if opt.SkipVerifySSL {
+		opts = append(opts, gollm.WithSkipVerifySSL())
+	}
+	if opt.Deterministic {
+		opts = append(opts, gollm.WithDeterministic())
+	}
+	headers, err := llmHeaders(opt)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, gollm.WithHeaders(headers))
+	opts = append(opts, gollm.WithVertexAI(gollm.VertexAISettings{Project: opt.VertexAIProject, Location: opt.VertexAILocation, Endpoint: opt.VertexAIEndpoint}))
+	opts = append(opts, gollm.WithRateLimit(gollm.RateLimit{RequestsPerMinute: opt.MaxRPM, TokensPerMinute: opt.MaxTPM}))
+	for _, category := range slices.Sorted(maps.Keys(opt.SafetySettings)) {
+		opts = append(opts, gollm.WithSafetySettings(gollm.SafetySetting{Category: category, Threshold: opt.SafetySettings[category]}))
+	}
+	client, err := gollm.NewClient(ctx, opt.ProviderID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating llm client: %w", err)
+	}
+	return client, nil
+}
@@ -22,10 +22,11 @@ REPO_ROOT="$(git rev-parse --show-toplevel)"
 cd ${REPO_ROOT}
 
 dev/tasks/generate-github-actions.sh
+dev/tasks/generate-helm-chart.sh
 
 changes=$(git status --porcelain)
 if [[ -n "${changes}" ]]; then
-  echo "FAIL: Changes detected from dev/tasks/generate-github-actions.sh:"
+  echo "FAIL: Changes detected from dev/tasks/generate-github-actions.sh or dev/tasks/generate-helm-chart.sh:"
   git diff | head -n60
   echo "${changes}"
   exit 1
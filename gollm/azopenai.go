@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"slices"
 	"strings"
@@ -40,6 +41,23 @@ func init() {
 	}
 }
 
+// azureAPIVersionParam is the query parameter selecting the Azure OpenAI API version.
+const azureAPIVersionParam = "api-version"
+
+// azureAPIVersionRoundTripper overrides the API version requested by the Azure OpenAI SDK.
+type azureAPIVersionRoundTripper struct {
+	next       http.RoundTripper
+	apiVersion string
+}
+
+func (rt *azureAPIVersionRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	query.Set(azureAPIVersionParam, rt.apiVersion)
+	req.URL.RawQuery = query.Encode()
+	return rt.next.RoundTrip(req)
+}
+
 /*
 azureOpenAIFactory is the provider factory function for Azure OpenAI.
 Supports ClientOptions for custom configuration.
@@ -51,6 +69,8 @@ func azureOpenAIFactory(ctx context.Context, opts ClientOptions) (Client, error)
 type AzureOpenAIClient struct {
 	client   *azopenai.Client
 	endpoint string
+
+	deterministic bool
 }
 
 var _ Client = &AzureOpenAIClient{}
@@ -67,11 +87,19 @@ func NewAzureOpenAIClient(ctx context.Context, opts ClientOptions) (*AzureOpenAI
 		return nil, fmt.Errorf("AZURE_OPENAI_ENDPOINT environment variable not set")
 	}
 	azureOpenAIClient := AzureOpenAIClient{
-		endpoint: azureOpenAIEndpoint,
+		endpoint:      azureOpenAIEndpoint,
+		deterministic: opts.Deterministic,
 	}
 
 	// Create a custom HTTP client (supports SkipVerifySSL)
 	httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
+	headers := opts.Headers.Clone()
+	if apiVersion := headers.Get(azureAPIVersionParam); apiVersion != "" {
+		// The API version is a query parameter, the api-version header overrides it.
+		headers.Del(azureAPIVersionParam)
+		httpClient.Transport = &azureAPIVersionRoundTripper{next: httpClient.Transport, apiVersion: apiVersion}
+	}
+	httpClient = withHeaders(httpClient, headers)
 
 	azureOpenAIKey := os.Getenv("AZURE_OPENAI_API_KEY")
 	clientOpts := &azopenai.ClientOptions{
@@ -112,6 +140,9 @@ func (c *AzureOpenAIClient) GenerateCompletion(ctx context.Context, request *Com
 		},
 		DeploymentName: &request.Model,
 	}
+	if c.deterministic {
+		setAzureOpenAIDeterministic(&req)
+	}
 
 	resp, err := c.client.GetChatCompletions(ctx, req, nil)
 	if err != nil {
@@ -205,14 +236,22 @@ func (c *AzureOpenAIClient) SetResponseSchema(schema *Schema) error {
 
 func (c *AzureOpenAIClient) StartChat(systemPrompt string, model string) Chat {
 	return &AzureOpenAIChat{
-		client: c.client,
-		model:  model,
+		client:        c.client,
+		model:         model,
+		deterministic: c.deterministic,
 		history: []azopenai.ChatRequestMessageClassification{
 			&azopenai.ChatRequestSystemMessage{Content: azopenai.NewChatRequestSystemMessageContent(systemPrompt)},
 		},
 	}
 }
 
+// setAzureOpenAIDeterministic configures greedy sampling with a fixed seed.
+func setAzureOpenAIDeterministic(req *azopenai.ChatCompletionsOptions) {
+	req.Temperature = ptrTo(float32(0))
+	req.TopP = ptrTo(float32(1))
+	req.Seed = ptrTo(int64(DeterministicSeed))
+}
+
 type AzureOpenAICompletionResponse struct {
 	response string
 }
@@ -230,6 +269,8 @@ type AzureOpenAIChat struct {
 	model   string
 	history []azopenai.ChatRequestMessageClassification
 	tools   []azopenai.ChatCompletionsToolDefinitionClassification
+
+	deterministic bool
 }
 
 func (c *AzureOpenAIChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
@@ -250,11 +291,15 @@ func (c *AzureOpenAIChat) Send(ctx context.Context, contents ...any) (ChatRespon
 		}
 	}
 
-	resp, err := c.client.GetChatCompletions(ctx, azopenai.ChatCompletionsOptions{
+	req := azopenai.ChatCompletionsOptions{
 		DeploymentName: &c.model,
 		Messages:       c.history,
 		Tools:          c.tools,
-	}, nil)
+	}
+	if c.deterministic {
+		setAzureOpenAIDeterministic(&req)
+	}
+	resp, err := c.client.GetChatCompletions(ctx, req, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -377,14 +422,17 @@ func (p *AzureOpenAIPart) AsText() (string, bool) {
 func (p *AzureOpenAIPart) AsFunctionCalls() ([]FunctionCall, bool) {
 	if p.functionCall != nil {
 		argumentsObj := map[string]any{}
-		err := json.Unmarshal([]byte(*p.functionCall.Arguments), &argumentsObj)
-		if err != nil {
-			return nil, false
+		var rawArgs string
+		if err := json.Unmarshal([]byte(*p.functionCall.Arguments), &argumentsObj); err != nil {
+			klog.V(2).Infof("Error unmarshalling function arguments for %s: %v", *p.functionCall.Name, err)
+			argumentsObj = map[string]any{}
+			rawArgs = *p.functionCall.Arguments
 		}
 		functionCalls := []FunctionCall{
 			{
-				Name:      *p.functionCall.Name,
-				Arguments: argumentsObj,
+				Name:         *p.functionCall.Name,
+				Arguments:    argumentsObj,
+				RawArguments: rawArgs,
 			},
 		}
 		return functionCalls, true
@@ -47,6 +47,8 @@ func newBedrockClientFactory(ctx context.Context, opts ClientOptions) (Client, e
 // BedrockClient implements the gollm.Client interface for AWS Bedrock models
 type BedrockClient struct {
 	client *bedrockruntime.Client
+
+	deterministic bool
 }
 
 // Ensure BedrockClient implements the Client interface
@@ -58,7 +60,12 @@ func NewBedrockClient(ctx context.Context, opts ClientOptions) (*BedrockClient,
 	configCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	cfg, err := config.LoadDefaultConfig(configCtx)
+	var loadOptions []func(*config.LoadOptions) error
+	if len(opts.Headers) > 0 {
+		httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
+		loadOptions = append(loadOptions, config.WithHTTPClient(withHeaders(httpClient, opts.Headers)))
+	}
+	cfg, err := config.LoadDefaultConfig(configCtx, loadOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
@@ -69,7 +76,8 @@ func NewBedrockClient(ctx context.Context, opts ClientOptions) (*BedrockClient,
 	}
 
 	return &BedrockClient{
-		client: bedrockruntime.NewFromConfig(cfg),
+		client:        bedrockruntime.NewFromConfig(cfg),
+		deterministic: opts.Deterministic,
 	}, nil
 }
 
@@ -201,11 +209,9 @@ func (c *bedrockChat) Send(ctx context.Context, contents ...any) (ChatResponse,
 
 	// Prepare the request
 	input := &bedrockruntime.ConverseInput{
-		ModelId:  aws.String(c.model),
-		Messages: c.messages,
-		InferenceConfig: &types.InferenceConfiguration{
-			MaxTokens: aws.Int32(4096),
-		},
+		ModelId:         aws.String(c.model),
+		Messages:        c.messages,
+		InferenceConfig: c.inferenceConfig(),
 	}
 
 	// Add system prompt if provided
@@ -242,6 +248,19 @@ func (c *bedrockChat) Send(ctx context.Context, contents ...any) (ChatResponse,
 	return response, nil
 }
 
+// inferenceConfig returns the inference parameters of the requests.
+// Bedrock does not support a sampling seed.
+func (c *bedrockChat) inferenceConfig() *types.InferenceConfiguration {
+	config := &types.InferenceConfiguration{
+		MaxTokens: aws.Int32(4096),
+	}
+	if c.client.deterministic {
+		config.Temperature = aws.Float32(0)
+		config.TopP = aws.Float32(1)
+	}
+	return config
+}
+
 // SendStreaming sends a message and returns a streaming response
 func (c *bedrockChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
 	if len(contents) == 0 {
@@ -255,11 +274,9 @@ func (c *bedrockChat) SendStreaming(ctx context.Context, contents ...any) (ChatR
 
 	// Prepare the streaming request
 	input := &bedrockruntime.ConverseStreamInput{
-		ModelId:  aws.String(c.model),
-		Messages: c.messages,
-		InferenceConfig: &types.InferenceConfiguration{
-			MaxTokens: aws.Int32(4096),
-		},
+		ModelId:         aws.String(c.model),
+		Messages:        c.messages,
+		InferenceConfig: c.inferenceConfig(),
 	}
 
 	// Add system prompt if provided
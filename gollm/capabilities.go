@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import "strings"
+
+// ModelCapabilities are the capabilities of a model the prompts adapt to.
+type ModelCapabilities struct {
+	// NativeToolCalls is true if the model calls tools through the API of its
+	// provider, false if the tools must be described in the prompt and called
+	// in JSON, see --enable-tool-use-shim.
+	NativeToolCalls bool
+	// ContextWindow is the number of tokens the model accepts.
+	ContextWindow int
+	// Markdown is true if the model formats its answers in markdown reliably.
+	Markdown bool
+}
+
+// defaultModelCapabilities are the capabilities of the models the registry
+// does not know.
+var defaultModelCapabilities = ModelCapabilities{NativeToolCalls: true, ContextWindow: 128_000, Markdown: true}
+
+// modelCapabilities is the registry of the capabilities of the model
+// families, matched in order by model name prefix whatever the provider, as
+// the same models are served by several. Prefixes ending with ":" match the
+// models without a tag too, e.g. "llama3:" matches "llama3".
+var modelCapabilities = []struct {
+	modelPrefix  string
+	capabilities ModelCapabilities
+}{
+	{modelPrefix: "gemini", capabilities: ModelCapabilities{NativeToolCalls: true, ContextWindow: 1_000_000, Markdown: true}},
+	{modelPrefix: "gpt-4.1", capabilities: ModelCapabilities{NativeToolCalls: true, ContextWindow: 1_000_000, Markdown: true}},
+	{modelPrefix: "gpt-5", capabilities: ModelCapabilities{NativeToolCalls: true, ContextWindow: 400_000, Markdown: true}},
+	{modelPrefix: "claude", capabilities: ModelCapabilities{NativeToolCalls: true, ContextWindow: 200_000, Markdown: true}},
+	{modelPrefix: "anthropic.claude", capabilities: ModelCapabilities{NativeToolCalls: true, ContextWindow: 200_000, Markdown: true}},
+	{modelPrefix: "deepseek-chat", capabilities: ModelCapabilities{NativeToolCalls: true, ContextWindow: 64_000, Markdown: true}},
+	{modelPrefix: "deepseek-r1", capabilities: ModelCapabilities{NativeToolCalls: false, ContextWindow: 128_000, Markdown: true}},
+	{modelPrefix: "qwen", capabilities: ModelCapabilities{NativeToolCalls: true, ContextWindow: 128_000, Markdown: true}},
+	{modelPrefix: "gemma2", capabilities: ModelCapabilities{NativeToolCalls: false, ContextWindow: 8_192, Markdown: false}},
+	{modelPrefix: "gemma", capabilities: ModelCapabilities{NativeToolCalls: false, ContextWindow: 128_000, Markdown: true}},
+	{modelPrefix: "llama3:", capabilities: ModelCapabilities{NativeToolCalls: false, ContextWindow: 8_192, Markdown: false}},
+	{modelPrefix: "llama3.", capabilities: ModelCapabilities{NativeToolCalls: true, ContextWindow: 128_000, Markdown: true}},
+	{modelPrefix: "mistral", capabilities: ModelCapabilities{NativeToolCalls: true, ContextWindow: 32_768, Markdown: true}},
+}
+
+// bedrockRegionPrefixes are the prefixes of the IDs of the cross-region
+// inference profiles of Bedrock, e.g. "us." for "us.anthropic.claude-...".
+var bedrockRegionPrefixes = []string{"us.", "eu.", "apac.", "global."}
+
+// LookupModelCapabilities returns the capabilities of a model, e.g.
+// "gemini-2.5-pro", from the registry. The model name is matched without its
+// path or region, e.g. "gemma3:4b" for "google/gemma3:4b".
+func LookupModelCapabilities(model string) ModelCapabilities {
+	model = strings.ToLower(model)
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	for _, prefix := range bedrockRegionPrefixes {
+		if trimmed, ok := strings.CutPrefix(model, prefix); ok {
+			model = trimmed
+			break
+		}
+	}
+	for _, entry := range modelCapabilities {
+		if strings.HasPrefix(model+":", entry.modelPrefix) {
+			return entry.capabilities
+		}
+	}
+	return defaultModelCapabilities
+}
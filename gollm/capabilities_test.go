@@ -0,0 +1,40 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import "testing"
+
+func TestLookupModelCapabilities(t *testing.T) {
+	tests := []struct {
+		model string
+		want  ModelCapabilities
+	}{
+		{model: "gemini-2.5-pro", want: ModelCapabilities{NativeToolCalls: true, ContextWindow: 1_000_000, Markdown: true}},
+		{model: "us.anthropic.claude-sonnet-4-20250514-v1:0", want: ModelCapabilities{NativeToolCalls: true, ContextWindow: 200_000, Markdown: true}},
+		{model: "gemma2:9b", want: ModelCapabilities{NativeToolCalls: false, ContextWindow: 8_192, Markdown: false}},
+		{model: "google/gemma3:4b", want: ModelCapabilities{NativeToolCalls: false, ContextWindow: 128_000, Markdown: true}},
+		{model: "llama3", want: ModelCapabilities{NativeToolCalls: false, ContextWindow: 8_192, Markdown: false}},
+		{model: "llama3.1:8b", want: ModelCapabilities{NativeToolCalls: true, ContextWindow: 128_000, Markdown: true}},
+		{model: "unknown-model", want: defaultModelCapabilities},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := LookupModelCapabilities(tt.model); got != tt.want {
+				t.Errorf("LookupModelCapabilities(%q) = %+v, want %+v", tt.model, got, tt.want)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"iter"
 	"math/rand/v2"
 	"net"
 	"net/http"
@@ -36,8 +37,9 @@ import (
 var globalRegistry registry
 
 type registry struct {
-	mutex     sync.Mutex
-	providers map[string]FactoryFunc
+	mutex       sync.Mutex
+	providers   map[string]FactoryFunc
+	middlewares []Middleware
 }
 
 func (r *registry) listProviders() []string {
@@ -53,9 +55,24 @@ func (r *registry) listProviders() []string {
 type ClientOptions struct {
 	URL           *url.URL
 	SkipVerifySSL bool
+	// Deterministic requests greedy sampling (temperature 0, top_p 1) and a
+	// fixed seed where the provider supports it, for reproducible runs.
+	Deterministic bool
+	// Headers are extra HTTP headers sent with every request to the provider.
+	// They can also be passed in the provider URL, see WithHeaders.
+	Headers http.Header
+	// Middlewares intercept the requests and responses of the client, see Middleware.
+	Middlewares []Middleware
+	// SafetySettings are the safety thresholds of the providers that support them, see WithSafetySettings.
+	SafetySettings []SafetySetting
+	// VertexAI are the project, the location and the endpoint of the vertexai provider, see WithVertexAI.
+	VertexAI VertexAISettings
 	// Extend with more options as needed
 }
 
+// DeterministicSeed is the sampling seed sent to the providers that support it in deterministic mode.
+const DeterministicSeed = 42
+
 // Option is a functional option for configuring ClientOptions.
 type Option func(*ClientOptions)
 
@@ -66,6 +83,13 @@ func WithSkipVerifySSL() Option {
 	}
 }
 
+// WithDeterministic enables deterministic sampling, see ClientOptions.Deterministic.
+func WithDeterministic() Option {
+	return func(o *ClientOptions) {
+		o.Deterministic = true
+	}
+}
+
 type FactoryFunc func(ctx context.Context, opts ClientOptions) (Client, error)
 
 func RegisterProvider(id string, factoryFunc FactoryFunc) error {
@@ -108,17 +132,27 @@ func (r *registry) NewClient(ctx context.Context, providerID string, opts ...Opt
 
 	// Build ClientOptions
 	clientOpts := ClientOptions{
-		URL: u,
+		URL:         u,
+		Middlewares: append([]Middleware{}, r.middlewares...),
 	}
 	// Support environment variable override for SkipVerifySSL
 	if v := os.Getenv("LLM_SKIP_VERIFY_SSL"); v == "1" || strings.ToLower(v) == "true" {
 		clientOpts.SkipVerifySSL = true
 	}
+	urlHeaders, err := headersFromURL(u)
+	if err != nil {
+		return nil, fmt.Errorf("parsing provider id %q: %w", providerID, err)
+	}
+	WithHeaders(urlHeaders)(&clientOpts)
 	for _, opt := range opts {
 		opt(&clientOpts)
 	}
 
-	return factoryFunc(ctx, clientOpts)
+	client, err := factoryFunc(ctx, clientOpts)
+	if err != nil {
+		return nil, err
+	}
+	return NewMiddlewareClient(client, clientOpts.Middlewares...), nil
 }
 
 /*
@@ -143,6 +177,8 @@ type APIError struct {
 	StatusCode int
 	Message    string
 	Err        error
+	// RetryAfter is the delay before retrying the request asked by the provider, if any.
+	RetryAfter time.Duration
 }
 
 func (e *APIError) Error() string {
@@ -157,8 +193,7 @@ func (e *APIError) Unwrap() error {
 }
 
 // IsRetryableFunc defines the signature for functions that check if an error is retryable.
-// TODO (droot): Adjust the signature to allow underlying client to relay the backoff
-// delay etc. for example, Gemini's error codes contain retryDelay information.
+// The delay asked by the provider, e.g. in Gemini's retryDelay, is read by RetryDelayHint.
 type IsRetryableFunc func(error) bool
 
 // DefaultIsRetryableError provides a default implementation based on common HTTP codes and network errors.
@@ -213,6 +248,10 @@ type RetryConfig struct {
 	MaxBackoff     time.Duration
 	BackoffFactor  float64
 	Jitter         bool
+	// MaxRetryAfter bounds the delays asked by the provider that are honored,
+	// see RetryDelayHint. Errors asking for longer delays are not retried.
+	// Defaults to a minute.
+	MaxRetryAfter time.Duration
 }
 
 // DefaultRetryConfig provides sensible defaults (same as before)
@@ -275,6 +314,18 @@ func Retry[T any](
 		if config.Jitter {
 			waitTime += time.Duration(rand.Float64() * float64(backoff) / 2)
 		}
+		// The provider may ask for a longer delay, e.g. until its quota is reset.
+		if hint := RetryDelayHint(lastErr); hint > 0 {
+			maxRetryAfter := config.MaxRetryAfter
+			if maxRetryAfter <= 0 {
+				maxRetryAfter = defaultMaxRetryAfter
+			}
+			if hint > maxRetryAfter {
+				log.Info("Not retrying, the provider asks for a longer delay than allowed", "retryAfter", hint, "maxRetryAfter", maxRetryAfter)
+				return zero, lastErr
+			}
+			waitTime = max(waitTime, hint)
+		}
 
 		log.V(2).Info("Waiting before next retry attempt", "waitTime", waitTime, "nextAttempt", attempt+1, "maxAttempts", config.MaxAttempts)
 
@@ -330,9 +381,32 @@ func (rc *retryChat[C]) Send(ctx context.Context, contents ...any) (ChatResponse
 	return Retry[ChatResponse](ctx, rc.config, rc.underlying.IsRetryableError, operation)
 }
 
-// Embed implements the Client interface for the retryClient decorator.
+// SendStreaming retries the responses failing before their first chunk, e.g.
+// with a 429 error. The failures after the first chunk are returned, as the
+// chunks before them were already consumed.
 func (rc *retryChat[C]) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
-	return rc.underlying.SendStreaming(ctx, contents...)
+	operation := func(ctx context.Context) (ChatResponseIterator, error) {
+		stream, err := rc.underlying.SendStreaming(ctx, contents...)
+		if err != nil {
+			return nil, err
+		}
+		next, stop := iter.Pull2(iter.Seq2[ChatResponse, error](stream))
+		response, err, ok := next()
+		if ok && response == nil && err != nil {
+			stop()
+			return nil, err
+		}
+		return func(yield func(ChatResponse, error) bool) {
+			defer stop()
+			for ok {
+				if !yield(response, err) {
+					return
+				}
+				response, err, ok = next()
+			}
+		}, nil
+	}
+	return Retry[ChatResponseIterator](ctx, rc.config, rc.underlying.IsRetryableError, operation)
 }
 
 func (rc *retryChat[C]) SetFunctionDefinitions(functionDefinitions []*FunctionDefinition) error {
@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// failingStreamChat fails the first failures streamed responses before their
+// first chunk, and streams a chunk for the others.
+type failingStreamChat struct {
+	Chat
+	failures int
+	streams  int
+}
+
+func (c *failingStreamChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	c.streams++
+	fail := c.streams <= c.failures
+	return func(yield func(ChatResponse, error) bool) {
+		if fail {
+			yield(nil, &APIError{StatusCode: http.StatusTooManyRequests, Message: "quota exceeded", RetryAfter: time.Millisecond})
+			return
+		}
+		yield(emptyResponse{}, nil)
+	}, nil
+}
+
+func (c *failingStreamChat) IsRetryableError(err error) bool {
+	return DefaultIsRetryableError(err)
+}
+
+func TestRetryChatSendStreaming(t *testing.T) {
+	config := RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1}
+	tests := []struct {
+		name        string
+		failures    int
+		wantStreams int
+		wantErr     bool
+	}{
+		{name: "first chunk", failures: 0, wantStreams: 1},
+		{name: "retried", failures: 2, wantStreams: 3},
+		{name: "too many failures", failures: 3, wantStreams: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := &failingStreamChat{failures: tt.failures}
+			stream, err := NewRetryChat(chat, config).SendStreaming(context.Background(), "list the pods")
+			if chat.streams != tt.wantStreams {
+				t.Errorf("streams = %d, want %d", chat.streams, tt.wantStreams)
+			}
+			if tt.wantErr {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) {
+					t.Errorf("SendStreaming() error = %v, want the APIError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SendStreaming() error = %v", err)
+			}
+			chunks := 0
+			for response, err := range stream {
+				if err != nil || response == nil {
+					t.Fatalf("chunk = %v, %v", response, err)
+				}
+				chunks++
+			}
+			if chunks != 1 {
+				t.Errorf("chunks = %d, want 1", chunks)
+			}
+		})
+	}
+}
+
+func TestRetryHonorsDelayHint(t *testing.T) {
+	config := RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, BackoffFactor: 1, MaxRetryAfter: 100 * time.Millisecond}
+	tests := []struct {
+		name         string
+		retryAfter   time.Duration
+		wantAttempts int
+		wantWait     time.Duration
+	}{
+		{name: "short delay is waited", retryAfter: 50 * time.Millisecond, wantAttempts: 2, wantWait: 50 * time.Millisecond},
+		{name: "long delay is not retried", retryAfter: time.Hour, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			start := time.Now()
+			_, err := Retry(context.Background(), config, DefaultIsRetryableError, func(ctx context.Context) (string, error) {
+				attempts++
+				if attempts == 1 {
+					return "", &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: tt.retryAfter}
+				}
+				return "ok", nil
+			})
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d (err %v)", attempts, tt.wantAttempts, err)
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantWait {
+				t.Errorf("Retry waited %s, want at least %s", elapsed, tt.wantWait)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"k8s.io/klog/v2"
+)
+
+// The gateway provider talks to OpenAI compatible proxies such as LiteLLM,
+// which many enterprises mandate to access language models. Compared to the
+// openai provider:
+//   - model names are passed through verbatim (e.g. "bedrock/anthropic.claude-3"),
+//     with no OpenAI default model and no Responses API,
+//   - the API key is a gateway virtual key, optionally sent in a custom header,
+//   - the budget headers returned by the gateway are logged,
+//   - the nonstandard error bodies of the gateways are used to decide whether to retry.
+//
+// It is configured with the environment variables:
+//   - LLM_GATEWAY_URL: the base URL of the gateway, e.g. http://litellm:4000
+//     (defaults to https://<host> of the provider URL, e.g. gateway://litellm.corp.example.com)
+//   - LLM_GATEWAY_API_KEY: the virtual key (defaults to OPENAI_API_KEY)
+//   - LLM_GATEWAY_KEY_HEADER: the header carrying the virtual key, e.g. x-litellm-api-key
+//     (defaults to Authorization: Bearer <key>)
+func init() {
+	for _, id := range []string{"gateway", "litellm"} {
+		if err := RegisterProvider(id, newGatewayClientFactory); err != nil {
+			klog.Fatalf("Failed to register %s provider: %v", id, err)
+		}
+	}
+}
+
+// gatewayBudgetHeaders are the response headers reporting the spend and the
+// remaining budget or rate limits of the gateway virtual key.
+var gatewayBudgetHeaders = []string{
+	"X-Litellm-Response-Cost",
+	"X-Litellm-Key-Spend",
+	"X-Litellm-Key-Remaining-Budget",
+	"X-Litellm-Key-Max-Budget",
+	"X-Ratelimit-Remaining-Requests",
+	"X-Ratelimit-Remaining-Tokens",
+}
+
+// newGatewayClientFactory is the factory function for creating gateway clients.
+func newGatewayClientFactory(ctx context.Context, opts ClientOptions) (Client, error) {
+	return NewGatewayClient(ctx, opts)
+}
+
+// NewGatewayClient creates a client for an OpenAI compatible gateway, see the gateway provider.
+func NewGatewayClient(ctx context.Context, opts ClientOptions) (*OpenAIClient, error) {
+	baseURL := os.Getenv("LLM_GATEWAY_URL")
+	if baseURL == "" && opts.URL != nil && opts.URL.Host != "" {
+		u := *opts.URL
+		u.Scheme = "https"
+		baseURL = u.String()
+	}
+	if baseURL == "" {
+		return nil, errors.New("gateway URL not found. Set via LLM_GATEWAY_URL env var or the provider URL, e.g. gateway://litellm.example.com")
+	}
+
+	apiKey := os.Getenv("LLM_GATEWAY_API_KEY")
+	if apiKey == "" {
+		apiKey = openAIAPIKey
+	}
+	if apiKey == "" {
+		return nil, errors.New("gateway API key not found. Set via LLM_GATEWAY_API_KEY (or OPENAI_API_KEY) env var")
+	}
+
+	options := []option.RequestOption{option.WithBaseURL(baseURL)}
+	if keyHeader := os.Getenv("LLM_GATEWAY_KEY_HEADER"); keyHeader != "" {
+		// Do not send the key (or OPENAI_API_KEY, read by the SDK) as a bearer token too.
+		options = append(options, option.WithHeaderDel("Authorization"), option.WithHeader(keyHeader, apiKey))
+	} else {
+		options = append(options, option.WithAPIKey(apiKey))
+	}
+	klog.Infof("Using OpenAI compatible gateway: %s", baseURL)
+
+	httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
+	httpClient = withHeaders(httpClient, opts.Headers)
+	httpClient.Transport = &gatewayBudgetRoundTripper{next: httpClient.Transport}
+	httpClient = withJournaling(httpClient)
+	options = append(options, option.WithHTTPClient(httpClient))
+
+	return &OpenAIClient{
+		client:        openai.NewClient(options...),
+		deterministic: opts.Deterministic,
+		gateway:       true,
+	}, nil
+}
+
+// gatewayBudgetRoundTripper logs the budget headers returned by the gateway.
+type gatewayBudgetRoundTripper struct {
+	next http.RoundTripper
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (rt *gatewayBudgetRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	var keysAndValues []any
+	for _, name := range gatewayBudgetHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			keysAndValues = append(keysAndValues, strings.ToLower(name), value)
+		}
+	}
+	if len(keysAndValues) > 0 {
+		klog.V(1).InfoS("Gateway budget", keysAndValues...)
+	}
+	return resp, nil
+}
+
+// gatewayError is the error of a gateway, parsed from its response.
+type gatewayError struct {
+	// StatusCode is the HTTP status code, or the upstream status code reported in the body.
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+// parseGatewayError parses the error bodies returned by OpenAI compatible gateways:
+//   - OpenAI: {"error": {"message": "...", "type": "...", "code": "..."}}
+//   - LiteLLM: the same, where code is the status of the upstream provider, e.g. "429"
+//   - FastAPI based proxies: {"detail": "..."} or {"detail": {"error": "..."}}
+//   - API gateways: {"message": "..."}, {"error": "..."} or a plain text body
+func parseGatewayError(statusCode int, body string) gatewayError {
+	gwErr := gatewayError{StatusCode: statusCode}
+
+	// The fields of the error object, at the top level or in "error" or "detail".
+	type errorObject struct {
+		Message string          `json:"message"`
+		Error   string          `json:"error"`
+		Type    string          `json:"type"`
+		Code    json.RawMessage `json:"code"`
+	}
+	var raw struct {
+		errorObject
+		Error  json.RawMessage `json:"error"`
+		Detail json.RawMessage `json:"detail"`
+	}
+	if err := json.Unmarshal([]byte(body), &raw); err != nil {
+		gwErr.Message = strings.TrimSpace(body)
+		return gwErr
+	}
+	obj := raw.errorObject
+	for _, field := range []json.RawMessage{raw.Error, raw.Detail} {
+		if len(field) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(field, &obj.Message); err == nil {
+			break
+		}
+		if err := json.Unmarshal(field, &obj); err == nil {
+			break
+		}
+	}
+
+	gwErr.Type = obj.Type
+	gwErr.Message = obj.Message
+	if gwErr.Message == "" {
+		gwErr.Message = obj.Error
+	}
+	// The code is a number or a string, and only sometimes a status code.
+	code, err := strconv.Atoi(strings.Trim(string(obj.Code), `"`))
+	if err == nil && code >= 400 && code < 600 {
+		gwErr.StatusCode = code
+	}
+	return gwErr
+}
+
+// retryable returns true if retrying the request may succeed.
+func (e gatewayError) retryable() bool {
+	text := strings.ToLower(e.Type + " " + e.Message)
+	// An exhausted budget is not transient, unlike a rate limit.
+	if strings.Contains(text, "budget") {
+		return false
+	}
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	for _, transient := range []string{"rate limit", "ratelimit", "overloaded", "timeout", "timed out", "temporarily unavailable"} {
+		if strings.Contains(text, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRetryableGatewayError determines if an error returned through a gateway should be retried.
+func isRetryableGatewayError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		gwErr := parseGatewayError(apiErr.StatusCode, apiErr.RawJSON())
+		if gwErr.Message == "" {
+			gwErr.Message = apiErr.Message
+		}
+		klog.V(2).InfoS("Gateway error", "status", gwErr.StatusCode, "type", gwErr.Type, "message", gwErr.Message)
+		return gwErr.retryable()
+	}
+	return DefaultIsRetryableError(err)
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"testing"
+)
+
+func TestParseGatewayError(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		body          string
+		wantStatus    int
+		wantMessage   string
+		wantRetryable bool
+	}{
+		{
+			name:          "openai rate limit",
+			statusCode:    429,
+			body:          `{"error": {"message": "Rate limit reached", "type": "requests", "code": "rate_limit_exceeded"}}`,
+			wantStatus:    429,
+			wantMessage:   "Rate limit reached",
+			wantRetryable: true,
+		},
+		{
+			name:          "litellm upstream status in code",
+			statusCode:    400,
+			body:          `{"error": {"message": "litellm.RateLimitError: upstream throttled", "type": null, "param": null, "code": "429"}}`,
+			wantStatus:    429,
+			wantMessage:   "litellm.RateLimitError: upstream throttled",
+			wantRetryable: true,
+		},
+		{
+			name:          "litellm budget exceeded",
+			statusCode:    400,
+			body:          `{"error": {"message": "Budget has been exceeded! Current cost: 10.2, Max budget: 10.0", "type": "budget_exceeded", "code": "400"}}`,
+			wantStatus:    400,
+			wantMessage:   "Budget has been exceeded! Current cost: 10.2, Max budget: 10.0",
+			wantRetryable: false,
+		},
+		{
+			name:          "budget exceeded with a retryable status",
+			statusCode:    429,
+			body:          `{"error": {"message": "Max budget exceeded for key", "code": 429}}`,
+			wantStatus:    429,
+			wantMessage:   "Max budget exceeded for key",
+			wantRetryable: false,
+		},
+		{
+			name:          "fastapi detail",
+			statusCode:    500,
+			body:          `{"detail": {"error": "model overloaded"}}`,
+			wantStatus:    500,
+			wantMessage:   "model overloaded",
+			wantRetryable: true,
+		},
+		{
+			name:          "api gateway message",
+			statusCode:    403,
+			body:          `{"message": "Forbidden"}`,
+			wantStatus:    403,
+			wantMessage:   "Forbidden",
+			wantRetryable: false,
+		},
+		{
+			name:          "string error",
+			statusCode:    400,
+			body:          `{"error": "upstream request timed out"}`,
+			wantStatus:    400,
+			wantMessage:   "upstream request timed out",
+			wantRetryable: true,
+		},
+		{
+			name:          "plain text",
+			statusCode:    502,
+			body:          "Bad Gateway\n",
+			wantStatus:    502,
+			wantMessage:   "Bad Gateway",
+			wantRetryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseGatewayError(tt.statusCode, tt.body)
+			if got.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", got.StatusCode, tt.wantStatus)
+			}
+			if got.Message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", got.Message, tt.wantMessage)
+			}
+			if got.retryable() != tt.wantRetryable {
+				t.Errorf("retryable() = %v, want %v", got.retryable(), tt.wantRetryable)
+			}
+		})
+	}
+}
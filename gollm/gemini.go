@@ -26,6 +26,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"google.golang.org/genai"
 
@@ -46,7 +47,7 @@ func init() {
 // geminiFactory is the provider factory function for Gemini.
 // Supports ClientOptions for consistency, but skipVerifySSL is not used.
 func geminiFactory(ctx context.Context, opts ClientOptions) (Client, error) {
-	opt := GeminiAPIClientOptions{}
+	opt := GeminiAPIClientOptions{Deterministic: opts.Deterministic, Headers: opts.Headers, SafetySettings: opts.SafetySettings}
 	return NewGeminiAPIClient(ctx, opt)
 }
 
@@ -54,6 +55,12 @@ func geminiFactory(ctx context.Context, opts ClientOptions) (Client, error) {
 type GeminiAPIClientOptions struct {
 	// API Key for GenAI. Required for BackendGeminiAPI.
 	APIKey string
+	// Deterministic, see ClientOptions.Deterministic.
+	Deterministic bool
+	// Headers are extra HTTP headers sent with every request.
+	Headers http.Header
+	// SafetySettings are the thresholds of the safety filters.
+	SafetySettings []SafetySetting
 }
 
 // NewGeminiAPIClient builds a client for the Gemini API.
@@ -65,6 +72,10 @@ func NewGeminiAPIClient(ctx context.Context, opt GeminiAPIClientOptions) (*Googl
 	if apiKey == "" {
 		return nil, fmt.Errorf("GEMINI_API_KEY environment variable not set")
 	}
+	safetySettings, err := toGeminiSafetySettings(opt.SafetySettings)
+	if err != nil {
+		return nil, err
+	}
 	skipVerifySSL := false
 	httpClient := createCustomHTTPClient(skipVerifySSL)
 	httpClient = withJournaling(httpClient)
@@ -72,6 +83,9 @@ func NewGeminiAPIClient(ctx context.Context, opt GeminiAPIClientOptions) (*Googl
 		APIKey:     apiKey,
 		Backend:    genai.BackendGeminiAPI,
 		HTTPClient: httpClient,
+		HTTPOptions: genai.HTTPOptions{
+			Headers: opt.Headers,
+		},
 	}
 
 	client, err := genai.NewClient(ctx, cc)
@@ -80,7 +94,9 @@ func NewGeminiAPIClient(ctx context.Context, opt GeminiAPIClientOptions) (*Googl
 	}
 
 	return &GoogleAIClient{
-		client: client,
+		client:         client,
+		deterministic:  opt.Deterministic,
+		safetySettings: safetySettings,
 	}, nil
 }
 
@@ -90,15 +106,46 @@ type VertexAIClientOptions struct {
 	Project string
 	// GCP Location/Region for Vertex AI. Required for BackendVertexAI. See https://cloud.google.com/vertex-ai/docs/general/locations
 	Location string
+	// Endpoint is the base URL of the API, e.g. https://europe-west4-aiplatform.googleapis.com/
+	// or a Private Service Connect endpoint. Defaults to the endpoint of the location.
+	Endpoint string
+	// Deterministic, see ClientOptions.Deterministic.
+	Deterministic bool
+	// Headers are extra HTTP headers sent with every request.
+	Headers http.Header
+	// SafetySettings are the thresholds of the safety filters.
+	SafetySettings []SafetySetting
 }
 
 // vertexaiViaGeminiFactory is the provider factory function for VertexAI via Gemini.
 // Supports ClientOptions for consistency, but skipVerifySSL is not used.
 func vertexaiViaGeminiFactory(ctx context.Context, opts ClientOptions) (Client, error) {
-	opt := VertexAIClientOptions{}
+	opt := VertexAIClientOptions{
+		Project:        opts.VertexAI.Project,
+		Location:       opts.VertexAI.Location,
+		Endpoint:       opts.VertexAI.Endpoint,
+		Deterministic:  opts.Deterministic,
+		Headers:        opts.Headers,
+		SafetySettings: opts.SafetySettings,
+	}
 	return NewVertexAIClient(ctx, opt)
 }
 
+// VertexAISettings select the project, the location and the endpoint of the
+// vertexai provider. The empty ones are discovered, see NewVertexAIClient.
+type VertexAISettings struct {
+	Project  string
+	Location string
+	Endpoint string
+}
+
+// WithVertexAI sets the project, the location and the endpoint of the vertexai provider.
+func WithVertexAI(settings VertexAISettings) Option {
+	return func(o *ClientOptions) {
+		o.VertexAI = settings
+	}
+}
+
 // findDefaultGCPProject gets the default GCP project ID from gcloud
 func findDefaultGCPProject(ctx context.Context) (string, error) {
 	log := klog.FromContext(ctx)
@@ -136,12 +183,21 @@ func findDefaultGCPProject(ctx context.Context) (string, error) {
 func NewVertexAIClient(ctx context.Context, opt VertexAIClientOptions) (*GoogleAIClient, error) {
 	log := klog.FromContext(ctx)
 
+	safetySettings, err := toGeminiSafetySettings(opt.SafetySettings)
+	if err != nil {
+		return nil, err
+	}
+
 	cc := &genai.ClientConfig{
 		// Project ID is loaded from the GOOGLE_CLOUD_PROJECT environment variable
 		// Location/Region is loaded from either GOOGLE_CLOUD_LOCATION or GOOGLE_CLOUD_REGION environment variable
 		Backend:  genai.BackendVertexAI,
 		Project:  opt.Project,
 		Location: opt.Location,
+		HTTPOptions: genai.HTTPOptions{
+			BaseURL: strings.TrimSuffix(opt.Endpoint, "/"),
+			Headers: opt.Headers,
+		},
 	}
 
 	// ProjectID is required
@@ -169,7 +225,7 @@ func NewVertexAIClient(ctx context.Context, opt VertexAIClientOptions) (*GoogleA
 		// Fallback to us-central1
 		if location == "" {
 			location = "us-central1"
-			log.Info("defaulted location for vertex client", "location", opt.Location)
+			log.Info("defaulted location for vertex client", "location", location)
 		}
 
 		cc.Location = location
@@ -181,8 +237,12 @@ func NewVertexAIClient(ctx context.Context, opt VertexAIClientOptions) (*GoogleA
 		return nil, fmt.Errorf("building vertexai client: %w", err)
 	}
 
+	log.Info("created vertex client", "project", cc.Project, "location", cc.Location, "endpoint", cc.HTTPOptions.BaseURL)
+
 	return &GoogleAIClient{
-		client: client,
+		client:         client,
+		deterministic:  opt.Deterministic,
+		safetySettings: safetySettings,
 	}, nil
 }
 
@@ -193,21 +253,37 @@ type GoogleAIClient struct {
 
 	// responseSchema will constrain the output to match the given schema
 	responseSchema *genai.Schema
+
+	deterministic bool
+
+	// safetySettings are the thresholds of the safety filters, the defaults of the API if empty.
+	safetySettings []*genai.SafetySetting
 }
 
 var _ Client = &GoogleAIClient{}
 
-// ListModels lists the models available in the Gemini API.
+// ListModels lists the models available in the Gemini API, or the Google
+// publisher models of Vertex AI.
 func (c *GoogleAIClient) ListModels(ctx context.Context) (modelNames []string, err error) {
 	for model, err := range c.client.Models.All(ctx) {
 		if err != nil {
 			return nil, fmt.Errorf("error listing models: %w", err)
 		}
-		modelNames = append(modelNames, strings.TrimPrefix(model.Name, "models/"))
+		modelNames = append(modelNames, geminiModelID(model.Name))
 	}
 	return modelNames, nil
 }
 
+// geminiModelID returns the ID of a model, as passed to GenerateContent, from its
+// resource name, e.g. "models/gemini-2.5-pro" in the Gemini API or
+// "publishers/google/models/gemini-2.5-pro" in Vertex AI.
+func geminiModelID(name string) string {
+	if i := strings.LastIndex(name, "models/"); i >= 0 {
+		return name[i+len("models/"):]
+	}
+	return name
+}
+
 // Close frees the resources used by the client.
 func (c *GoogleAIClient) Close() error {
 	return nil
@@ -241,6 +317,24 @@ func (c *GoogleAIClient) GenerateCompletion(ctx context.Context, request *Comple
 			ResponseMIMEType: "application/json",
 		}
 	}
+	if c.deterministic {
+		if config == nil {
+			config = &genai.GenerateContentConfig{}
+		}
+		setGeminiDeterministic(config)
+	}
+	if len(c.safetySettings) > 0 {
+		if config == nil {
+			config = &genai.GenerateContentConfig{}
+		}
+		config.SafetySettings = c.safetySettings
+	}
+	if request.CandidateCount > 1 {
+		if config == nil {
+			config = &genai.GenerateContentConfig{}
+		}
+		config.CandidateCount = int32(request.CandidateCount)
+	}
 
 	content := []*genai.Content{
 		{Role: "user", Parts: []*genai.Part{{Text: request.Prompt}}},
@@ -249,12 +343,23 @@ func (c *GoogleAIClient) GenerateCompletion(ctx context.Context, request *Comple
 	log.Info("sending GenerateContent request to gemini", "content", content)
 	result, err := c.client.Models.GenerateContent(ctx, request.Model, content, config)
 	if err != nil {
-		return nil, err
+		return nil, geminiAPIError(err)
+	}
+	if blocked := geminiSafetyBlock(result); blocked != nil {
+		return nil, blocked
 	}
 
 	return &GeminiCompletionResponse{geminiResponse: result, text: result.Text()}, nil
 }
 
+// setGeminiDeterministic configures greedy sampling with a fixed seed.
+func setGeminiDeterministic(config *genai.GenerateContentConfig) {
+	config.Temperature = ptrTo(float32(0))
+	config.TopP = ptrTo(float32(1))
+	config.TopK = ptrTo(float32(1))
+	config.Seed = ptrTo(int32(DeterministicSeed))
+}
+
 // StartChat starts a new chat with the model.
 func (c *GoogleAIClient) StartChat(systemPrompt string, model string) Chat {
 	// Some values that are recommended by aistudio
@@ -277,9 +382,13 @@ func (c *GoogleAIClient) StartChat(systemPrompt string, model string) Chat {
 			TopP:             &topP,
 			MaxOutputTokens:  maxOutputTokens,
 			ResponseMIMEType: "text/plain",
+			SafetySettings:   c.safetySettings,
 		},
 		history: []*genai.Content{},
 	}
+	if c.deterministic {
+		setGeminiDeterministic(chat.genConfig)
+	}
 
 	if chat.model == "gemma-3-27b-it" {
 		// Note: gemma-3-27b-it does not allow system prompt
@@ -417,12 +526,20 @@ func (c *GeminiChat) Send(ctx context.Context, contents ...any) (ChatResponse, e
 	c.history = append(c.history, genaiContent)
 	result, err := c.client.Models.GenerateContent(ctx, c.model, c.history, c.genConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate content: %w", err)
+		// The message is removed, so that a retry does not send it twice.
+		c.history = c.history[:len(c.history)-1]
+		return nil, fmt.Errorf("failed to generate content: %w", geminiAPIError(err))
+	}
+	if blocked := geminiSafetyBlock(result); blocked != nil {
+		return nil, blocked
 	}
 	if result == nil || len(result.Candidates) == 0 {
 		return nil, fmt.Errorf("no response from Gemini")
 	}
-	c.history = append(c.history, result.Candidates[0].Content)
+	if content := result.Candidates[0].Content; content != nil && len(content.Parts) > 0 {
+		// An empty turn in the history fails the following requests.
+		c.history = append(c.history, content)
+	}
 	geminiResponse := result
 	log.V(1).Info("got LLM response", "response", geminiResponse)
 	return &GeminiChatResponse{geminiResponse: geminiResponse}, nil
@@ -448,6 +565,7 @@ func (c *GeminiChat) SendStreaming(ctx context.Context, contents ...any) (ChatRe
 	return func(yield func(ChatResponse, error) bool) {
 		next, stop := iter.Pull2(stream)
 		defer stop()
+		received := false
 		for {
 			geminiResponse, err, ok := next()
 			if !ok {
@@ -455,11 +573,19 @@ func (c *GeminiChat) SendStreaming(ctx context.Context, contents ...any) (ChatRe
 			}
 
 			if err != nil {
+				if !received {
+					// The message is removed, so that a retry does not send it twice.
+					c.history = c.history[:len(c.history)-1]
+				}
 				// Always check for and yield an error first.
-				yield(nil, err)
+				yield(nil, geminiAPIError(err))
 				return
 			}
 
+			if blocked := geminiSafetyBlock(geminiResponse); blocked != nil {
+				yield(nil, blocked)
+				return
+			}
 			if geminiResponse == nil || len(geminiResponse.Candidates) == 0 {
 				return
 			}
@@ -481,6 +607,7 @@ func (c *GeminiChat) SendStreaming(ctx context.Context, contents ...any) (ChatRe
 				return
 			}
 			c.history = append(c.history, content)
+			received = true
 			// yield only when we have a non-empty response
 			if !yield(&GeminiChatResponse{geminiResponse: geminiResponse}, err) {
 				return
@@ -632,7 +759,10 @@ type GeminiCompletionResponse struct {
 	text           string
 }
 
-var _ CompletionResponse = &GeminiCompletionResponse{}
+var (
+	_ CompletionResponse     = &GeminiCompletionResponse{}
+	_ MultiCandidateResponse = &GeminiCompletionResponse{}
+)
 
 func (r *GeminiCompletionResponse) MarshalJSON() ([]byte, error) {
 	formatted := RecordCompletionResponse{
@@ -650,6 +780,26 @@ func (r *GeminiCompletionResponse) UsageMetadata() any {
 	return r.geminiResponse.UsageMetadata
 }
 
+// Responses returns the text of every candidate of the response.
+func (r *GeminiCompletionResponse) Responses() []string {
+	var responses []string
+	for _, candidate := range r.geminiResponse.Candidates {
+		if candidate.Content == nil {
+			continue
+		}
+		var text strings.Builder
+		for _, part := range candidate.Content.Parts {
+			if part.Text != "" && !part.Thought {
+				text.WriteString(part.Text)
+			}
+		}
+		if text.Len() > 0 {
+			responses = append(responses, text.String())
+		}
+	}
+	return responses
+}
+
 func (r *GeminiCompletionResponse) String() string {
 	return fmt.Sprintf("{text=%q}", r.text)
 }
@@ -681,3 +831,103 @@ func (c *GeminiChat) IsRetryableError(err error) bool {
 
 	return false
 }
+
+// geminiAPIError converts an error of the Gemini API to an *APIError, with the
+// delay of its RetryInfo detail, e.g. for the 429 errors of an exhausted
+// quota, so that the retries wait for it. Other errors are returned as is.
+func geminiAPIError(err error) error {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	converted := &APIError{StatusCode: apiErr.Code, Message: apiErr.Message, Err: err}
+	for _, detail := range apiErr.Details {
+		if kind, _ := detail["@type"].(string); !strings.HasSuffix(kind, "google.rpc.RetryInfo") {
+			continue
+		}
+		// e.g. "31s", the JSON form of a google.protobuf.Duration.
+		if delay, _ := detail["retryDelay"].(string); delay != "" {
+			if d, err := time.ParseDuration(delay); err == nil && d > 0 {
+				converted.RetryAfter = d
+			}
+		}
+	}
+	return converted
+}
+
+// geminiHarmCategories are the harm categories of the safety settings.
+var geminiHarmCategories = map[genai.HarmCategory]bool{
+	genai.HarmCategoryHateSpeech:       true,
+	genai.HarmCategoryDangerousContent: true,
+	genai.HarmCategoryHarassment:       true,
+	genai.HarmCategorySexuallyExplicit: true,
+	genai.HarmCategoryCivicIntegrity:   true,
+}
+
+// geminiHarmBlockThresholds are the block thresholds of the safety settings.
+var geminiHarmBlockThresholds = map[genai.HarmBlockThreshold]bool{
+	genai.HarmBlockThresholdBlockLowAndAbove:    true,
+	genai.HarmBlockThresholdBlockMediumAndAbove: true,
+	genai.HarmBlockThresholdBlockOnlyHigh:       true,
+	genai.HarmBlockThresholdBlockNone:           true,
+	genai.HarmBlockThresholdOff:                 true,
+}
+
+// toGeminiSafetySettings converts the safety settings to the Gemini API,
+// rejecting unknown categories and thresholds.
+func toGeminiSafetySettings(settings []SafetySetting) ([]*genai.SafetySetting, error) {
+	var geminiSettings []*genai.SafetySetting
+	for _, setting := range settings {
+		setting = setting.normalize()
+		category := genai.HarmCategory(setting.Category)
+		if !geminiHarmCategories[category] {
+			return nil, fmt.Errorf("unknown harm category %q, expected one of hate_speech, dangerous_content, harassment, sexually_explicit or civic_integrity", setting.Category)
+		}
+		threshold := genai.HarmBlockThreshold(setting.Threshold)
+		if !geminiHarmBlockThresholds[threshold] {
+			return nil, fmt.Errorf("unknown block threshold %q for %s, expected one of block_low_and_above, block_medium_and_above, block_only_high, block_none or off", setting.Threshold, setting.Category)
+		}
+		geminiSettings = append(geminiSettings, &genai.SafetySetting{Category: category, Threshold: threshold})
+	}
+	return geminiSettings, nil
+}
+
+// geminiSafetyBlock returns the error of a response whose prompt or candidate
+// was blocked by the safety filters, or nil.
+func geminiSafetyBlock(response *genai.GenerateContentResponse) *SafetyBlockedError {
+	if response == nil {
+		return nil
+	}
+	if feedback := response.PromptFeedback; feedback != nil && feedback.BlockReason != "" {
+		return &SafetyBlockedError{
+			Prompt:     true,
+			Reason:     string(feedback.BlockReason),
+			Categories: blockedHarmCategories(feedback.SafetyRatings),
+			Message:    feedback.BlockReasonMessage,
+		}
+	}
+	if len(response.Candidates) == 0 {
+		return nil
+	}
+	candidate := response.Candidates[0]
+	switch candidate.FinishReason {
+	case genai.FinishReasonSafety, genai.FinishReasonBlocklist, genai.FinishReasonProhibitedContent, genai.FinishReasonSPII:
+		return &SafetyBlockedError{
+			Reason:     string(candidate.FinishReason),
+			Categories: blockedHarmCategories(candidate.SafetyRatings),
+			Message:    candidate.FinishMessage,
+		}
+	}
+	return nil
+}
+
+// blockedHarmCategories returns the categories of the ratings that blocked the content.
+func blockedHarmCategories(ratings []*genai.SafetyRating) []string {
+	var categories []string
+	for _, rating := range ratings {
+		if rating != nil && rating.Blocked {
+			categories = append(categories, string(rating.Category))
+		}
+	}
+	return categories
+}
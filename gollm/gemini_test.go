@@ -0,0 +1,205 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+func TestToGeminiSafetySettings(t *testing.T) {
+	got, err := toGeminiSafetySettings([]SafetySetting{
+		{Category: "dangerous_content", Threshold: "block_only_high"},
+		{Category: "HARM_CATEGORY_HARASSMENT", Threshold: "OFF"},
+	})
+	if err != nil {
+		t.Fatalf("toGeminiSafetySettings() error = %v", err)
+	}
+	want := []genai.SafetySetting{
+		{Category: genai.HarmCategoryDangerousContent, Threshold: genai.HarmBlockThresholdBlockOnlyHigh},
+		{Category: genai.HarmCategoryHarassment, Threshold: genai.HarmBlockThresholdOff},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("toGeminiSafetySettings() = %d settings, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if *got[i] != want[i] {
+			t.Errorf("setting %d = %+v, want %+v", i, *got[i], want[i])
+		}
+	}
+
+	for _, invalid := range []SafetySetting{
+		{Category: "violence", Threshold: "block_none"},
+		{Category: "dangerous_content", Threshold: "block_sometimes"},
+	} {
+		if _, err := toGeminiSafetySettings([]SafetySetting{invalid}); err == nil {
+			t.Errorf("toGeminiSafetySettings(%+v) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestGeminiSafetyBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *genai.GenerateContentResponse
+		want     *SafetyBlockedError
+	}{
+		{
+			name: "blocked prompt",
+			response: &genai.GenerateContentResponse{
+				PromptFeedback: &genai.GenerateContentResponsePromptFeedback{
+					BlockReason: genai.BlockedReasonSafety,
+					SafetyRatings: []*genai.SafetyRating{
+						{Category: genai.HarmCategoryDangerousContent, Blocked: true},
+						{Category: genai.HarmCategoryHarassment},
+					},
+				},
+			},
+			want: &SafetyBlockedError{Prompt: true, Reason: "SAFETY", Categories: []string{"HARM_CATEGORY_DANGEROUS_CONTENT"}},
+		},
+		{
+			name: "blocked response",
+			response: &genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonProhibitedContent, FinishMessage: "prohibited"}},
+			},
+			want: &SafetyBlockedError{Reason: "PROHIBITED_CONTENT", Message: "prohibited"},
+		},
+		{
+			name: "not blocked",
+			response: &genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonStop}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := geminiSafetyBlock(tt.response)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("geminiSafetyBlock() = %v, want %v", got, tt.want)
+			}
+			if got == nil {
+				return
+			}
+			if got.Prompt != tt.want.Prompt || got.Reason != tt.want.Reason || got.Message != tt.want.Message || !slices.Equal(got.Categories, tt.want.Categories) {
+				t.Errorf("geminiSafetyBlock() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeminiCompletionCandidates(t *testing.T) {
+	resp := &GeminiCompletionResponse{
+		geminiResponse: &genai.GenerateContentResponse{
+			Candidates: []*genai.Candidate{
+				{Content: &genai.Content{Parts: []*genai.Part{{Text: "thinking", Thought: true}, {Text: "The pod "}, {Text: "is pending."}}}},
+				{FinishReason: genai.FinishReasonSafety},
+				{Content: &genai.Content{Parts: []*genai.Part{{Text: "The node is full."}}}},
+			},
+		},
+		text: "The pod is pending.",
+	}
+	want := []string{"The pod is pending.", "The node is full."}
+	if got := CompletionCandidates(resp); !slices.Equal(got, want) {
+		t.Errorf("CompletionCandidates() = %q, want %q", got, want)
+	}
+}
+
+func TestGeminiAPIError(t *testing.T) {
+	quota := genai.APIError{Code: http.StatusTooManyRequests, Message: "Resource has been exhausted", Details: []map[string]any{
+		{"@type": "type.googleapis.com/google.rpc.QuotaFailure"},
+		{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "31s"},
+	}}
+	err := geminiAPIError(fmt.Errorf("streaming: %w", quota))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("geminiAPIError() = %v, want an APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter != 31*time.Second {
+		t.Errorf("geminiAPIError() = %+v, want status 429 and a 31s delay", apiErr)
+	}
+	if RetryDelayHint(err) != 31*time.Second || !(&GeminiChat{}).IsRetryableError(err) {
+		t.Errorf("the converted error is not retried after 31s")
+	}
+
+	other := errors.New("connection reset")
+	if got := geminiAPIError(other); got != other {
+		t.Errorf("geminiAPIError(%v) = %v, want it unchanged", other, got)
+	}
+}
+
+func TestVertexAIListModels(t *testing.T) {
+	var modelsPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if req.URL.Path == "/token" {
+			fmt.Fprint(w, `{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`)
+			return
+		}
+		modelsPath = req.URL.Path
+		fmt.Fprint(w, `{"publisherModels": [{"name": "publishers/google/models/gemini-2.5-pro"}, {"name": "publishers/google/models/gemini-2.5-flash"}]}`)
+	}))
+	defer server.Close()
+
+	// Application default credentials of a service account, exchanging its signed assertions with the test server.
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adc, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "kubectl-ai@my-project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})),
+		"token_uri":    server.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(credentials, adc, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", credentials)
+
+	ctx := context.Background()
+	client, err := NewClient(ctx, "vertexai", WithVertexAI(VertexAISettings{Project: "my-project", Location: "europe-west4", Endpoint: server.URL}))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if want := []string{"gemini-2.5-pro", "gemini-2.5-flash"}; !slices.Equal(models, want) {
+		t.Errorf("ListModels() = %v, want %v", models, want)
+	}
+	if want := "/v1beta1/publishers/google/models"; modelsPath != want {
+		t.Errorf("ListModels() requested %s, want the publisher models %s", modelsPath, want)
+	}
+}
@@ -44,6 +44,8 @@ func newGrokClientFactory(ctx context.Context, opts ClientOptions) (Client, erro
 // GrokClient implements the gollm.Client interface for X.AI's Grok model.
 type GrokClient struct {
 	client openai.Client
+
+	deterministic bool
 }
 
 // Ensure GrokClient implements the Client interface.
@@ -69,12 +71,14 @@ func NewGrokClient(ctx context.Context, opts ClientOptions) (*GrokClient, error)
 
 	// Use the OpenAI client with custom base URL and custom HTTP client
 	httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
+	httpClient = withHeaders(httpClient, opts.Headers)
 	return &GrokClient{
 		client: openai.NewClient(
 			option.WithAPIKey(apiKey),
 			option.WithBaseURL(endpoint),
 			option.WithHTTPClient(httpClient),
 		),
+		deterministic: opts.Deterministic,
 	}, nil
 }
 
@@ -100,9 +104,10 @@ func (c *GrokClient) StartChat(systemPrompt, model string) Chat {
 	}
 
 	return &grokChatSession{
-		client:  c.client,
-		history: history,
-		model:   model,
+		client:        c.client,
+		history:       history,
+		model:         model,
+		deterministic: c.deterministic,
 	}
 }
 
@@ -134,6 +139,9 @@ func (c *GrokClient) GenerateCompletion(ctx context.Context, req *CompletionRequ
 			openai.UserMessage(req.Prompt),
 		},
 	}
+	if c.deterministic {
+		setOpenAIDeterministic(&chatReq)
+	}
 
 	completion, err := c.client.Chat.Completions.New(ctx, chatReq)
 	if err != nil {
@@ -174,6 +182,7 @@ type grokChatSession struct {
 	model               string
 	functionDefinitions []*FunctionDefinition            // Stored in gollm format
 	tools               []openai.ChatCompletionToolParam // Stored in OpenAI format
+	deterministic       bool
 }
 
 // Ensure grokChatSession implements the Chat interface.
@@ -247,6 +256,9 @@ func (cs *grokChatSession) Send(ctx context.Context, contents ...any) (ChatRespo
 		chatReq.Tools = cs.tools
 		// chatReq.ToolChoice = openai.ToolChoiceAuto // Or specify if needed
 	}
+	if cs.deterministic {
+		setOpenAIDeterministic(&chatReq)
+	}
 
 	// Call the Grok API
 	klog.V(1).InfoS("Sending request to Grok Chat API", "model", cs.model, "messages", len(chatReq.Messages), "tools", len(chatReq.Tools))
@@ -309,6 +321,9 @@ func (cs *grokChatSession) SendStreaming(ctx context.Context, contents ...any) (
 	if len(cs.tools) > 0 {
 		chatReq.Tools = cs.tools
 	}
+	if cs.deterministic {
+		setOpenAIDeterministic(&chatReq)
+	}
 
 	// Start the Grok streaming request
 	klog.V(1).InfoS("Sending streaming request to Grok API",
@@ -473,13 +488,17 @@ func (p *grokPart) AsFunctionCalls() ([]FunctionCall, bool) {
 			continue
 		}
 		var args map[string]any
-		// Attempt to unmarshal arguments, ignore error for now if it fails
-		_ = json.Unmarshal([]byte(tc.Function.Arguments), &args)
+		var rawArgs string
+		// Keep the raw arguments around if they fail to parse, so the caller can repair them
+		if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil && tc.Function.Arguments != "" {
+			rawArgs = tc.Function.Arguments
+		}
 
 		gollmCalls[i] = FunctionCall{
-			ID:        tc.ID,
-			Name:      tc.Function.Name,
-			Arguments: args,
+			ID:           tc.ID,
+			Name:         tc.Function.Name,
+			Arguments:    args,
+			RawArguments: rawArgs,
 		}
 	}
 	return gollmCalls, true
@@ -612,12 +631,14 @@ func (p *grokStreamPart) AsFunctionCalls() ([]FunctionCall, bool) {
 		}
 
 		var args map[string]any
+		var rawArgs string
 		// Attempt to unmarshal arguments if present
 		if tc.Function.Arguments != "" {
 			if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
 				klog.V(2).Infof("Error unmarshaling function arguments: %v", err)
 				// Continue with empty args if unmarshal fails
 				args = make(map[string]any)
+				rawArgs = tc.Function.Arguments
 			}
 		} else {
 			// Initialize empty args map if no arguments provided
@@ -625,9 +646,10 @@ func (p *grokStreamPart) AsFunctionCalls() ([]FunctionCall, bool) {
 		}
 
 		completeCalls = append(completeCalls, FunctionCall{
-			ID:        tc.ID,
-			Name:      tc.Function.Name,
-			Arguments: args,
+			ID:           tc.ID,
+			Name:         tc.Function.Name,
+			Arguments:    args,
+			RawArguments: rawArgs,
 		})
 	}
 
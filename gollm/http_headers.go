@@ -0,0 +1,103 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// headerQueryParam is the provider URL query parameter holding extra HTTP headers,
+// e.g. openai://api.example.com?header=OpenAI-Organization:org-123
+const headerQueryParam = "header"
+
+// WithHeaders adds extra HTTP headers to every request sent to the provider,
+// e.g. gateway routing headers or the OpenAI organization and project headers.
+// Headers set here replace the headers of the same name set by the provider SDK.
+func WithHeaders(headers http.Header) Option {
+	return func(o *ClientOptions) {
+		if len(headers) == 0 {
+			return
+		}
+		if o.Headers == nil {
+			o.Headers = make(http.Header)
+		}
+		for name, values := range headers {
+			o.Headers[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+}
+
+// ParseHeader parses a "Name: Value" header.
+func ParseHeader(s string) (name, value string, err error) {
+	name, value, ok := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid header %q, expected Name: Value", s)
+	}
+	return name, strings.TrimSpace(value), nil
+}
+
+// headersFromURL extracts the headers passed in the query of the provider URL,
+// and removes them from the URL so that providers do not forward them.
+func headersFromURL(u *url.URL) (http.Header, error) {
+	query := u.Query()
+	if !query.Has(headerQueryParam) {
+		return nil, nil
+	}
+	headers := make(http.Header)
+	for _, h := range query[headerQueryParam] {
+		name, value, err := ParseHeader(h)
+		if err != nil {
+			return nil, err
+		}
+		headers.Add(name, value)
+	}
+	query.Del(headerQueryParam)
+	u.RawQuery = query.Encode()
+	return headers, nil
+}
+
+// headerRoundTripper wraps an existing http.RoundTripper to add extra headers to every request.
+type headerRoundTripper struct {
+	next    http.RoundTripper
+	headers http.Header
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (hrt *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the request.
+	req = req.Clone(req.Context())
+	for name, values := range hrt.headers {
+		req.Header[name] = append([]string(nil), values...)
+	}
+	return hrt.next.RoundTrip(req)
+}
+
+// withHeaders is a decorator function that wraps an http.Client's transport
+// to add extra headers to every request. It is a no-op without headers.
+func withHeaders(client *http.Client, headers http.Header) *http.Client {
+	if len(headers) == 0 {
+		return client
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = &headerRoundTripper{next: next, headers: headers}
+	return client
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestHeadersFromURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		wantHeaders http.Header
+		wantURL     string
+		wantErr     bool
+	}{
+		{
+			name:    "no headers",
+			url:     "openai://api.example.com/v1?foo=bar",
+			wantURL: "openai://api.example.com/v1?foo=bar",
+		},
+		{
+			name: "headers",
+			url:  "openai://api.example.com?header=OpenAI-Organization:org-123&header=x-route:%20team-a&foo=bar",
+			wantHeaders: http.Header{
+				"Openai-Organization": {"org-123"},
+				"X-Route":             {"team-a"},
+			},
+			wantURL: "openai://api.example.com?foo=bar",
+		},
+		{
+			name:    "invalid header",
+			url:     "openai://api.example.com?header=novalue",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatalf("url.Parse() error: %v", err)
+			}
+			headers, err := headersFromURL(u)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("headersFromURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(headers, tt.wantHeaders) {
+				t.Errorf("headersFromURL() = %v, want %v", headers, tt.wantHeaders)
+			}
+			if u.String() != tt.wantURL {
+				t.Errorf("URL = %q, want %q", u.String(), tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestWithHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer server.Close()
+
+	var opts ClientOptions
+	WithHeaders(http.Header{"x-route": {"team-a"}, "User-Agent": {"gateway"}})(&opts)
+	client := withHeaders(createCustomHTTPClient(false), opts.Headers)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest() error: %v", err)
+	}
+	req.Header.Set("User-Agent", "sdk")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	resp.Body.Close()
+
+	if got.Get("X-Route") != "team-a" {
+		t.Errorf("X-Route = %q, want team-a", got.Get("X-Route"))
+	}
+	if got.Get("User-Agent") != "gateway" {
+		t.Errorf("User-Agent = %q, want the configured header to replace the SDK header", got.Get("User-Agent"))
+	}
+	if req.Header.Get("User-Agent") != "sdk" {
+		t.Errorf("the original request was modified")
+	}
+}
@@ -68,6 +68,11 @@ type Chat interface {
 type CompletionRequest struct {
 	Model  string `json:"model,omitempty"`
 	Prompt string `json:"prompt,omitempty"`
+
+	// CandidateCount is the number of completions to sample in one request,
+	// for the providers supporting it (n > 1). The others return a single
+	// completion, see CompletionCandidates.
+	CandidateCount int `json:"candidateCount,omitempty"`
 }
 
 // CompletionResponse is a response from the GenerateCompletion method.
@@ -76,12 +81,52 @@ type CompletionResponse interface {
 	UsageMetadata() any
 }
 
+// MultiCandidateResponse is implemented by the completion responses holding
+// the candidates of a request with a CandidateCount.
+type MultiCandidateResponse interface {
+	// Responses returns the text of the candidates, the first is Response().
+	Responses() []string
+}
+
+// CompletionCandidates returns the text of the candidates of a completion
+// response, a single one if the provider does not sample several.
+func CompletionCandidates(resp CompletionResponse) []string {
+	if multi, ok := resp.(MultiCandidateResponse); ok {
+		if responses := multi.Responses(); len(responses) > 0 {
+			return responses
+		}
+	}
+	return []string{resp.Response()}
+}
+
+// ContextWindowReporter is implemented by the clients that know the context
+// window of the model they serve, e.g. a local server started with a fixed
+// context size.
+type ContextWindowReporter interface {
+	// ContextWindow returns the number of tokens the model accepts, 0 if unknown.
+	ContextWindow() int
+}
+
+// ContextWindow returns the context window reported by the client, 0 if it
+// does not report it.
+func ContextWindow(client Client) int {
+	if reporter, ok := client.(ContextWindowReporter); ok {
+		return reporter.ContextWindow()
+	}
+	return 0
+}
+
 // FunctionCall is a function call to a language model.
 // The LLM will reply with a FunctionCall to a user-defined function, and we will send the results back.
 type FunctionCall struct {
 	ID        string         `json:"id,omitempty"`
 	Name      string         `json:"name,omitempty"`
 	Arguments map[string]any `json:"arguments,omitempty"`
+
+	// RawArguments holds the arguments exactly as returned by the provider
+	// when they could not be parsed into Arguments (e.g. malformed JSON).
+	// It is empty when Arguments were parsed successfully.
+	RawArguments string `json:"rawArguments,omitempty"`
 }
 
 // FunctionDefinition is a user-defined function that can be called by the LLM.
@@ -23,6 +23,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"k8s.io/klog/v2"
 
@@ -41,12 +43,28 @@ func llamacppFactory(ctx context.Context, opts ClientOptions) (Client, error) {
 	return NewLlamaCppClient(ctx, opts)
 }
 
+// llamacppLoadTimeout bounds the wait for the llama.cpp server to load its model.
+const llamacppLoadTimeout = 5 * time.Minute
+
+// llamacppProbeInterval is the interval of the health probes while the server loads its model.
+var llamacppProbeInterval = 2 * time.Second
+
 type LlamaCppClient struct {
 	baseURL        *url.URL
 	httpClient     *http.Client
 	responseSchema *llamacppSchema
+
+	deterministic bool
+
+	// contextWindow is the context size of the slots of the server, read
+	// from /props, 0 if unknown.
+	contextWindow int
+	// template is what the chat template of the model supports.
+	template llamacppTemplate
 }
 
+var _ ContextWindowReporter = &LlamaCppClient{}
+
 type LlamaCppChat struct {
 	client  *LlamaCppClient
 	model   string
@@ -56,37 +74,200 @@ type LlamaCppChat struct {
 
 var _ Client = &LlamaCppClient{}
 
-// NewLlamaCppClient creates a new client for llama.cpp.
-// Supports custom HTTP client and skipVerifySSL via ClientOptions.
-func NewLlamaCppClient(ctx context.Context, opts ClientOptions) (*LlamaCppClient, error) {
+// LlamaCppHost returns the URL of the llama.cpp server, set by $LLAMACPP_HOST.
+func LlamaCppHost() (*url.URL, error) {
 	host := os.Getenv("LLAMACPP_HOST")
 	if host == "" {
 		host = "http://127.0.0.1:8080/"
 	}
-
 	baseURL, err := url.Parse(host)
 	if err != nil {
 		return nil, fmt.Errorf("parsing host %q: %w", host, err)
 	}
+	return baseURL, nil
+}
+
+// NewLlamaCppClient creates a new client for llama.cpp.
+// Supports custom HTTP client and skipVerifySSL via ClientOptions.
+func NewLlamaCppClient(ctx context.Context, opts ClientOptions) (*LlamaCppClient, error) {
+	baseURL, err := LlamaCppHost()
+	if err != nil {
+		return nil, err
+	}
 	klog.Infof("using llama.cpp with base url %v", baseURL.String())
 
 	httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
+	httpClient = withHeaders(httpClient, opts.Headers)
 
-	return &LlamaCppClient{
-		baseURL:    baseURL,
-		httpClient: httpClient,
-	}, nil
+	client := &LlamaCppClient{
+		baseURL:       baseURL,
+		httpClient:    httpClient,
+		deterministic: opts.Deterministic,
+		template:      parseChatTemplate(""),
+	}
+	if err := client.waitUntilHealthy(ctx, llamacppLoadTimeout); err != nil {
+		return nil, err
+	}
+	client.readProps(ctx)
+	return client, nil
 }
 
 func (c *LlamaCppClient) Close() error {
 	return nil
 }
 
+// ContextWindow returns the context size of the slots of the server, see
+// --ctx-size and --parallel of llama-server, 0 if the server does not report it.
+func (c *LlamaCppClient) ContextWindow() int {
+	return c.contextWindow
+}
+
+// waitUntilHealthy probes the /health endpoint of the server, and waits for
+// it while it loads its model. It fails if the server is not reachable or
+// reports an error, e.g. when the model does not fit in the memory of the GPU.
+func (c *LlamaCppClient) waitUntilHealthy(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		status, body, err := c.doGet(ctx, "health")
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("llama.cpp server at %v is still loading the model after %v", c.baseURL, timeout)
+			}
+			return fmt.Errorf("llama.cpp server at %v is not reachable, is llama-server running? %w", c.baseURL, err)
+		}
+		switch status {
+		case http.StatusOK:
+			return nil
+		case http.StatusServiceUnavailable:
+			klog.Infof("waiting for the llama.cpp server to load the model: %s", body)
+		default:
+			return fmt.Errorf("llama.cpp server at %v is not healthy: %d %s", c.baseURL, status, body)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("llama.cpp server at %v is still loading the model after %v", c.baseURL, timeout)
+		case <-time.After(llamacppProbeInterval):
+		}
+	}
+}
+
+// llamacppProps are the properties of the server, see GET /props.
+type llamacppProps struct {
+	DefaultGenerationSettings struct {
+		// NCtx is the context size of a slot.
+		NCtx int `json:"n_ctx"`
+	} `json:"default_generation_settings"`
+	TotalSlots   int    `json:"total_slots"`
+	ModelPath    string `json:"model_path"`
+	ChatTemplate string `json:"chat_template"`
+}
+
+// readProps reads the context size and the chat template of the model from
+// the properties of the server. Servers without /props keep the defaults.
+func (c *LlamaCppClient) readProps(ctx context.Context) {
+	status, body, err := c.doGet(ctx, "props")
+	if err != nil || status != http.StatusOK {
+		klog.Warningf("cannot read the properties of the llama.cpp server, using the default context window: %d %s %v", status, body, err)
+		return
+	}
+	var props llamacppProps
+	if err := json.Unmarshal(body, &props); err != nil {
+		klog.Warningf("parsing the properties of the llama.cpp server: %v", err)
+		return
+	}
+	c.contextWindow = props.DefaultGenerationSettings.NCtx
+	c.template = parseChatTemplate(props.ChatTemplate)
+	klog.Infof("llama.cpp server serves %q with a context window of %d tokens and %d slots (system role: %v, tool role: %v)",
+		props.ModelPath, c.contextWindow, props.TotalSlots, c.template.systemRole, c.template.toolRole)
+}
+
+// doGet sends a GET request to the server, and returns the status and the body of the response.
+func (c *LlamaCppClient) doGet(ctx context.Context, relativePath string) (int, []byte, error) {
+	u := c.baseURL.JoinPath(relativePath)
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("building http request: %w", err)
+	}
+	httpResponse, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return 0, nil, fmt.Errorf("performing http request: %w", err)
+	}
+	defer httpResponse.Body.Close()
+	b, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("reading response body: %w", err)
+	}
+	return httpResponse.StatusCode, b, nil
+}
+
+// llamacppTemplate is what the chat template of the model supports. Models
+// whose template has no system or tool role, e.g. Gemma, reject or garble
+// the messages of these roles, so they are sent as user messages.
+type llamacppTemplate struct {
+	systemRole bool
+	toolRole   bool
+}
+
+// parseChatTemplate guesses the roles a Jinja chat template supports. An
+// unknown template is assumed to support them all.
+func parseChatTemplate(template string) llamacppTemplate {
+	if template == "" {
+		return llamacppTemplate{systemRole: true, toolRole: true}
+	}
+	mentions := func(role string) bool {
+		return strings.Contains(template, "'"+role+"'") || strings.Contains(template, `"`+role+`"`)
+	}
+	return llamacppTemplate{
+		systemRole: mentions("system") && !strings.Contains(strings.ToLower(template), "system role not supported"),
+		toolRole:   mentions("tool") || mentions("ipython"),
+	}
+}
+
+// adapt returns the messages of a chat as the template of the model supports
+// them: the system prompt is prepended to the first user message, and the
+// tool results are sent as user messages, if the template lacks their role.
+func (t llamacppTemplate) adapt(history []llamacppChatMessage) []llamacppChatMessage {
+	if t.systemRole && t.toolRole {
+		return history
+	}
+	var messages []llamacppChatMessage
+	var systemPrompt string
+	for _, message := range history {
+		switch {
+		case message.Role == "system" && !t.systemRole:
+			if message.Content != nil {
+				systemPrompt += *message.Content + "\n\n"
+			}
+			continue
+		case message.Role == "tool" && !t.toolRole:
+			content := "Result of the tool call:\n"
+			if message.Content != nil {
+				content += *message.Content
+			}
+			message = llamacppChatMessage{Role: "user", Content: ptrTo(content)}
+		}
+		if message.Role == "user" && systemPrompt != "" {
+			content := systemPrompt
+			if message.Content != nil {
+				content += *message.Content
+			}
+			message.Content = ptrTo(content)
+			systemPrompt = ""
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
 func (c *LlamaCppClient) GenerateCompletion(ctx context.Context, request *CompletionRequest) (CompletionResponse, error) {
 	llamacppRequest := &llamacppCompletionRequest{
 		Prompt:     request.Prompt,
 		JSONSchema: c.responseSchema,
 	}
+	if c.deterministic {
+		llamacppRequest.llamacppSampling = llamacppDeterministicSampling()
+	}
 
 	llamacppResponse, err := c.doCompletion(ctx, llamacppRequest)
 	if err != nil {
@@ -216,10 +397,13 @@ func (c *LlamaCppChat) Send(ctx context.Context, contents ...any) (ChatResponse,
 
 	req := &llamacppChatRequest{
 		Model:    c.model,
-		Messages: c.history,
+		Messages: c.client.template.adapt(c.history),
 		// Stream:   ptrTo(false),
 		Tools: c.tools,
 	}
+	if c.client.deterministic {
+		req.llamacppSampling = llamacppDeterministicSampling()
+	}
 
 	var llmacppResponse *LlamaCppChatResponse
 
@@ -381,6 +565,9 @@ func (p *LlamaCppPart) AsFunctionCalls() ([]FunctionCall, bool) {
 }
 
 func (c *LlamaCppChat) SetFunctionDefinitions(functionDefinitions []*FunctionDefinition) error {
+	if len(functionDefinitions) > 0 && !c.client.template.toolRole {
+		klog.Warning("the chat template of the llama.cpp model does not support tools, consider --enable-tool-use-shim")
+	}
 	var tools []llamacppTool
 	for _, functionDefinition := range functionDefinitions {
 		tools = append(tools, toLlamacppTool(functionDefinition))
@@ -435,6 +622,26 @@ type llamacppCompletionRequest struct {
 	Prompt string `json:"prompt,omitempty"`
 
 	JSONSchema *llamacppSchema `json:"json_schema,omitempty"`
+
+	llamacppSampling
+}
+
+// llamacppSampling holds the sampling parameters of a request, left to the server defaults when nil.
+type llamacppSampling struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	TopK        *int     `json:"top_k,omitempty"`
+	Seed        *int64   `json:"seed,omitempty"`
+}
+
+// llamacppDeterministicSampling returns the sampling parameters of greedy sampling with a fixed seed.
+func llamacppDeterministicSampling() llamacppSampling {
+	return llamacppSampling{
+		Temperature: ptrTo(0.0),
+		TopP:        ptrTo(1.0),
+		TopK:        ptrTo(1),
+		Seed:        ptrTo(int64(DeterministicSeed)),
+	}
 }
 
 type llamacppCompletionResponse struct {
@@ -485,6 +692,8 @@ type llamacppChatRequest struct {
 	Model    string                `json:"model,omitempty"`
 	Messages []llamacppChatMessage `json:"messages,omitempty"`
 	Tools    []llamacppTool        `json:"tools,omitempty"`
+
+	llamacppSampling
 }
 
 type llamacppChatResponse struct {
@@ -0,0 +1,144 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLlamaCppClientProbesServer(t *testing.T) {
+	llamacppProbeInterval = time.Millisecond
+	loading := 2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			if loading > 0 {
+				loading--
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`{"error":{"code":503,"message":"Loading model"}}`))
+				return
+			}
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/props":
+			json.NewEncoder(w).Encode(map[string]any{
+				"default_generation_settings": map[string]any{"n_ctx": 8192},
+				"total_slots":                 1,
+				"model_path":                  "gemma-2-9b-it.gguf",
+				"chat_template":               "{% if messages[0]['role'] == 'system' %}{{ raise_exception('System role not supported') }}{% endif %}",
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("LLAMACPP_HOST", server.URL)
+
+	client, err := NewLlamaCppClient(context.Background(), ClientOptions{})
+	if err != nil {
+		t.Fatalf("NewLlamaCppClient() error = %v", err)
+	}
+	if loading != 0 {
+		t.Errorf("the client did not wait for the model to load")
+	}
+	if got := ContextWindow(client); got != 8192 {
+		t.Errorf("ContextWindow() = %d, want 8192", got)
+	}
+	if client.template.systemRole || client.template.toolRole {
+		t.Errorf("template = %+v, want neither the system nor the tool role", client.template)
+	}
+}
+
+func TestNewLlamaCppClientUnhealthy(t *testing.T) {
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"code":500,"message":"failed to allocate buffer"}}`, http.StatusInternalServerError)
+	}))
+	defer unhealthy.Close()
+	stopped := httptest.NewServer(http.NotFoundHandler())
+	stopped.Close()
+
+	tests := []struct {
+		name    string
+		host    string
+		wantErr string
+	}{
+		{name: "server error", host: unhealthy.URL, wantErr: "failed to allocate buffer"},
+		{name: "not reachable", host: stopped.URL, wantErr: "not reachable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LLAMACPP_HOST", tt.host)
+			_, err := NewLlamaCppClient(context.Background(), ClientOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewLlamaCppClient() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLlamaCppTemplateAdapt(t *testing.T) {
+	history := []llamacppChatMessage{
+		{Role: "system", Content: ptrTo("You are kubectl-ai.")},
+		{Role: "user", Content: ptrTo("list the pods")},
+		{Role: "assistant", ToolCalls: []llamacppToolCall{{Type: "function", Function: llamacppFunctionCall{Name: "kubectl"}}}},
+		{Role: "tool", Content: ptrTo(`{"stdout":"web-1"}`)},
+	}
+	tests := []struct {
+		name     string
+		template string
+		want     []string
+	}{
+		{
+			name:     "unknown template",
+			template: "",
+			want:     []string{"system: You are kubectl-ai.", "user: list the pods", "assistant: ", `tool: {"stdout":"web-1"}`},
+		},
+		{
+			name:     "system and tool roles",
+			template: "{% for message in messages %}{% if message['role'] == 'system' %}{% elif message['role'] == 'tool' %}{% endif %}{% endfor %}",
+			want:     []string{"system: You are kubectl-ai.", "user: list the pods", "assistant: ", `tool: {"stdout":"web-1"}`},
+		},
+		{
+			name:     "no system role",
+			template: "{% if messages[0]['role'] == 'system' %}{{ raise_exception('System role not supported') }}{% endif %}{% if message['role'] == 'tool' %}{% endif %}",
+			want:     []string{"user: You are kubectl-ai.\n\nlist the pods", "assistant: ", `tool: {"stdout":"web-1"}`},
+		},
+		{
+			name:     "no tool role",
+			template: "{% if message['role'] == 'system' %}{% endif %}",
+			want:     []string{"system: You are kubectl-ai.", "user: list the pods", "assistant: ", "user: Result of the tool call:\n{\"stdout\":\"web-1\"}"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, message := range parseChatTemplate(tt.template).adapt(history) {
+				content := ""
+				if message.Content != nil {
+					content = *message.Content
+				}
+				got = append(got, message.Role+": "+content)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("adapt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
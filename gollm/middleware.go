@@ -0,0 +1,241 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"fmt"
+)
+
+// RequestKind is the kind of a request intercepted by a Middleware.
+type RequestKind string
+
+const (
+	// RequestKindChatStart is the start of a chat. Only the system prompt and the model are set.
+	RequestKindChatStart RequestKind = "chat.start"
+	// RequestKindChat is a message sent in a chat, with Send or SendStreaming.
+	RequestKindChat RequestKind = "chat"
+	// RequestKindCompletion is a GenerateCompletion request.
+	RequestKindCompletion RequestKind = "completion"
+)
+
+// Request is a request to a language model, as seen by a Middleware.
+// Middlewares can modify its fields to rewrite the request.
+type Request struct {
+	Kind  RequestKind
+	Model string
+
+	// SystemPrompt is the system prompt of a chat, set for RequestKindChatStart.
+	SystemPrompt string
+	// Contents are the contents of a chat message (strings and FunctionCallResults), set for RequestKindChat.
+	Contents []any
+	// Streaming is true for the messages sent with SendStreaming.
+	Streaming bool
+	// Prompt is the prompt of a completion, set for RequestKindCompletion.
+	Prompt string
+
+	// reportedUsage is the usage reported so far for the chunks of a streamed response.
+	reportedUsage Usage
+	// rateLimitedTokens are the tokens of the chunks of a streamed response
+	// recorded so far by the rate limit.
+	rateLimitedTokens int64
+}
+
+// Response is a response of a language model, as seen by a Middleware.
+// Middlewares can replace its fields to rewrite the response.
+type Response struct {
+	// Chat is the response to a chat message. For streaming messages, the
+	// middlewares are called once per chunk.
+	Chat ChatResponse
+	// Completion is the response to a completion request.
+	Completion CompletionResponse
+	// Err is the error returned by the provider, if any.
+	Err error
+}
+
+// Middleware intercepts the requests sent to and the responses received from
+// a language model, independently of the provider, e.g. to log them, filter
+// PII or add a corporate prompt prefix.
+type Middleware interface {
+	// OnRequest is called before the request is sent. Returning an error aborts the request.
+	OnRequest(ctx context.Context, req *Request) error
+	// OnResponse is called with the response of the request. Returning an error fails the request.
+	OnResponse(ctx context.Context, req *Request, resp *Response) error
+}
+
+// MiddlewareFuncs implements Middleware with functions. Nil functions are skipped.
+type MiddlewareFuncs struct {
+	Request  func(ctx context.Context, req *Request) error
+	Response func(ctx context.Context, req *Request, resp *Response) error
+}
+
+var _ Middleware = MiddlewareFuncs{}
+
+func (m MiddlewareFuncs) OnRequest(ctx context.Context, req *Request) error {
+	if m.Request == nil {
+		return nil
+	}
+	return m.Request(ctx, req)
+}
+
+func (m MiddlewareFuncs) OnResponse(ctx context.Context, req *Request, resp *Response) error {
+	if m.Response == nil {
+		return nil
+	}
+	return m.Response(ctx, req, resp)
+}
+
+// WithMiddlewares adds middlewares to the client, after the ones registered with RegisterMiddleware.
+func WithMiddlewares(middlewares ...Middleware) Option {
+	return func(o *ClientOptions) {
+		o.Middlewares = append(o.Middlewares, middlewares...)
+	}
+}
+
+// RegisterMiddleware registers a middleware applied to all the clients created with NewClient.
+// Like RegisterProvider, it is meant to be called from an init function.
+func RegisterMiddleware(middleware Middleware) {
+	globalRegistry.mutex.Lock()
+	defer globalRegistry.mutex.Unlock()
+	globalRegistry.middlewares = append(globalRegistry.middlewares, middleware)
+}
+
+// NewMiddlewareClient wraps the client so that the middlewares see its requests
+// and responses. Requests go through the middlewares in order, responses in
+// reverse order.
+func NewMiddlewareClient(client Client, middlewares ...Middleware) Client {
+	if len(middlewares) == 0 {
+		return client
+	}
+	return &middlewareClient{Client: client, middlewares: middlewares}
+}
+
+type middlewareChain []Middleware
+
+func (m middlewareChain) onRequest(ctx context.Context, req *Request) error {
+	for _, middleware := range m {
+		if err := middleware.OnRequest(ctx, req); err != nil {
+			return fmt.Errorf("%s request middleware: %w", req.Kind, err)
+		}
+	}
+	return nil
+}
+
+func (m middlewareChain) onResponse(ctx context.Context, req *Request, resp *Response) error {
+	for i := len(m) - 1; i >= 0; i-- {
+		if err := m[i].OnResponse(ctx, req, resp); err != nil {
+			return fmt.Errorf("%s response middleware: %w", req.Kind, err)
+		}
+	}
+	return nil
+}
+
+type middlewareClient struct {
+	Client
+	middlewares middlewareChain
+}
+
+var _ Client = &middlewareClient{}
+
+// ContextWindow returns the context window reported by the wrapped client.
+func (c *middlewareClient) ContextWindow() int {
+	return ContextWindow(c.Client)
+}
+
+func (c *middlewareClient) StartChat(systemPrompt, model string) Chat {
+	req := &Request{Kind: RequestKindChatStart, Model: model, SystemPrompt: systemPrompt}
+	// StartChat cannot fail, so a middleware error is returned by the first message.
+	err := c.middlewares.onRequest(context.Background(), req)
+	return &middlewareChat{
+		Chat:        c.Client.StartChat(req.SystemPrompt, req.Model),
+		model:       req.Model,
+		middlewares: c.middlewares,
+		startErr:    err,
+	}
+}
+
+func (c *middlewareClient) GenerateCompletion(ctx context.Context, request *CompletionRequest) (CompletionResponse, error) {
+	req := &Request{Kind: RequestKindCompletion, Model: request.Model, Prompt: request.Prompt}
+	if err := c.middlewares.onRequest(ctx, req); err != nil {
+		return nil, err
+	}
+	completion, err := c.Client.GenerateCompletion(ctx, &CompletionRequest{Model: req.Model, Prompt: req.Prompt, CandidateCount: request.CandidateCount})
+	resp := &Response{Completion: completion, Err: err}
+	if err := c.middlewares.onResponse(ctx, req, resp); err != nil {
+		return nil, err
+	}
+	return resp.Completion, resp.Err
+}
+
+type middlewareChat struct {
+	Chat
+	model       string
+	middlewares middlewareChain
+	startErr    error
+}
+
+func (c *middlewareChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	req := &Request{Kind: RequestKindChat, Model: c.model, Contents: contents}
+	if err := c.onRequest(ctx, req); err != nil {
+		return nil, err
+	}
+	chatResponse, err := c.Chat.Send(ctx, req.Contents...)
+	resp := &Response{Chat: chatResponse, Err: err}
+	if err := c.middlewares.onResponse(ctx, req, resp); err != nil {
+		return nil, err
+	}
+	return resp.Chat, resp.Err
+}
+
+func (c *middlewareChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	req := &Request{Kind: RequestKindChat, Model: c.model, Contents: contents, Streaming: true}
+	if err := c.onRequest(ctx, req); err != nil {
+		return nil, err
+	}
+	stream, err := c.Chat.SendStreaming(ctx, req.Contents...)
+	if err != nil {
+		resp := &Response{Err: err}
+		if err := c.middlewares.onResponse(ctx, req, resp); err != nil {
+			return nil, err
+		}
+		return nil, resp.Err
+	}
+	return func(yield func(ChatResponse, error) bool) {
+		for chunk, err := range stream {
+			if chunk == nil && err == nil {
+				// End of the stream, nothing for the middlewares to see.
+				if !yield(nil, nil) {
+					return
+				}
+				continue
+			}
+			resp := &Response{Chat: chunk, Err: err}
+			if err := c.middlewares.onResponse(ctx, req, resp); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(resp.Chat, resp.Err) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (c *middlewareChat) onRequest(ctx context.Context, req *Request) error {
+	if c.startErr != nil {
+		return c.startErr
+	}
+	return c.middlewares.onRequest(ctx, req)
+}
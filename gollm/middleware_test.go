@@ -0,0 +1,139 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeCompletion string
+
+func (f fakeCompletion) Response() string   { return string(f) }
+func (f fakeCompletion) UsageMetadata() any { return nil }
+
+// fakeClient records the requests it receives.
+type fakeClient struct {
+	Client
+	systemPrompt string
+	model        string
+	prompt       string
+	contents     []any
+}
+
+func (c *fakeClient) StartChat(systemPrompt, model string) Chat {
+	c.systemPrompt = systemPrompt
+	c.model = model
+	return &fakeChat{client: c}
+}
+
+func (c *fakeClient) GenerateCompletion(ctx context.Context, req *CompletionRequest) (CompletionResponse, error) {
+	c.prompt = req.Prompt
+	return fakeCompletion("answer to " + req.Prompt), nil
+}
+
+type fakeChat struct {
+	Chat
+	client *fakeClient
+}
+
+func (c *fakeChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	c.client.contents = contents
+	return nil, errors.New("provider error")
+}
+
+func TestMiddlewareClient(t *testing.T) {
+	var calls []string
+	recorder := func(name string) Middleware {
+		return MiddlewareFuncs{
+			Request: func(ctx context.Context, req *Request) error {
+				calls = append(calls, name+".request")
+				return nil
+			},
+			Response: func(ctx context.Context, req *Request, resp *Response) error {
+				calls = append(calls, name+".response")
+				return nil
+			},
+		}
+	}
+	prefix := MiddlewareFuncs{
+		Request: func(ctx context.Context, req *Request) error {
+			req.SystemPrompt = "Corporate policy. " + req.SystemPrompt
+			req.Prompt = "Corporate policy. " + req.Prompt
+			return nil
+		},
+		Response: func(ctx context.Context, req *Request, resp *Response) error {
+			if resp.Completion != nil {
+				resp.Completion = fakeCompletion(strings.ToUpper(resp.Completion.Response()))
+			}
+			return nil
+		},
+	}
+
+	fake := &fakeClient{}
+	client := NewMiddlewareClient(fake, recorder("first"), recorder("second"), prefix)
+
+	completion, err := client.GenerateCompletion(context.Background(), &CompletionRequest{Prompt: "hello"})
+	if err != nil {
+		t.Fatalf("GenerateCompletion() error: %v", err)
+	}
+	if fake.prompt != "Corporate policy. hello" {
+		t.Errorf("provider got prompt %q", fake.prompt)
+	}
+	if got, want := completion.Response(), "ANSWER TO CORPORATE POLICY. HELLO"; got != want {
+		t.Errorf("Response() = %q, want %q", got, want)
+	}
+	wantCalls := []string{"first.request", "second.request", "second.response", "first.response"}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("middleware calls = %v, want %v", calls, wantCalls)
+	}
+
+	chat := client.StartChat("Be helpful.", "model")
+	if fake.systemPrompt != "Corporate policy. Be helpful." {
+		t.Errorf("provider got system prompt %q", fake.systemPrompt)
+	}
+	if _, err := chat.Send(context.Background(), "hi"); err == nil || err.Error() != "provider error" {
+		t.Errorf("Send() error = %v, want provider error", err)
+	}
+	if !reflect.DeepEqual(fake.contents, []any{"hi"}) {
+		t.Errorf("provider got contents %v", fake.contents)
+	}
+}
+
+func TestMiddlewareClientRequestError(t *testing.T) {
+	deny := MiddlewareFuncs{
+		Request: func(ctx context.Context, req *Request) error {
+			for _, content := range req.Contents {
+				if s, ok := content.(string); ok && strings.Contains(s, "password") {
+					return errors.New("message contains a password")
+				}
+			}
+			return nil
+		},
+	}
+
+	fake := &fakeClient{}
+	chat := NewMiddlewareClient(fake, deny).StartChat("", "model")
+	_, err := chat.Send(context.Background(), "my password is hunter2")
+	if err == nil || !strings.Contains(err.Error(), "message contains a password") {
+		t.Fatalf("Send() error = %v, want the middleware error", err)
+	}
+	if fake.contents != nil {
+		t.Errorf("provider got contents %v, want none", fake.contents)
+	}
+}
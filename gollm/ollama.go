@@ -17,7 +17,11 @@ package gollm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/ollama/ollama/api"
 	"github.com/ollama/ollama/envconfig"
@@ -44,6 +48,8 @@ const (
 
 type OllamaClient struct {
 	client *api.Client
+
+	deterministic bool
 }
 
 type OllamaChat struct {
@@ -51,6 +57,8 @@ type OllamaChat struct {
 	model   string
 	history []api.Message
 	tools   []api.Tool
+
+	deterministic bool
 }
 
 var _ Client = &OllamaClient{}
@@ -60,13 +68,20 @@ var _ Client = &OllamaClient{}
 func NewOllamaClient(ctx context.Context, opts ClientOptions) (*OllamaClient, error) {
 	// Create custom HTTP client with SSL verification option from client options
 	httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
-	client := api.NewClient(envconfig.Host(), httpClient)
+	httpClient = withHeaders(httpClient, opts.Headers)
+	client := api.NewClient(OllamaHost(), httpClient)
 
 	return &OllamaClient{
-		client: client,
+		client:        client,
+		deterministic: opts.Deterministic,
 	}, nil
 }
 
+// OllamaHost returns the URL of the ollama server, set by $OLLAMA_HOST.
+func OllamaHost() *url.URL {
+	return envconfig.Host()
+}
+
 func (c *OllamaClient) Close() error {
 	return nil
 }
@@ -77,6 +92,9 @@ func (c *OllamaClient) GenerateCompletion(ctx context.Context, request *Completi
 		Prompt: request.Prompt,
 		Stream: ptrTo(false),
 	}
+	if c.deterministic {
+		req.Options = ollamaDeterministicOptions()
+	}
 
 	var ollamaResponse *OllamaCompletionResponse
 
@@ -113,8 +131,9 @@ func (c *OllamaClient) SetResponseSchema(schema *Schema) error {
 
 func (c *OllamaClient) StartChat(systemPrompt, model string) Chat {
 	return &OllamaChat{
-		client: c.client,
-		model:  model,
+		client:        c.client,
+		model:         model,
+		deterministic: c.deterministic,
 		history: []api.Message{
 			{
 				Role:    "system",
@@ -124,6 +143,16 @@ func (c *OllamaClient) StartChat(systemPrompt, model string) Chat {
 	}
 }
 
+// ollamaDeterministicOptions are the model options of greedy sampling with a fixed seed.
+func ollamaDeterministicOptions() map[string]any {
+	return map[string]any{
+		"temperature": 0,
+		"top_p":       1,
+		"top_k":       1,
+		"seed":        DeterministicSeed,
+	}
+}
+
 type OllamaCompletionResponse struct {
 	response string
 }
@@ -136,77 +165,125 @@ func (r *OllamaCompletionResponse) UsageMetadata() any {
 	return nil
 }
 
-func (c *OllamaChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
-	log := klog.FromContext(ctx)
+// addContents appends the contents sent by the user to the history. Function
+// results are sent as tool messages, in the order of the calls of the model.
+func (c *OllamaChat) addContents(contents []any) error {
 	for _, content := range contents {
 		switch v := content.(type) {
 		case string:
-			message := api.Message{
+			c.history = append(c.history, api.Message{
 				Role:    "user",
 				Content: v,
-			}
-			c.history = append(c.history, message)
+			})
 		case FunctionCallResult:
-			message := api.Message{
-				Role:    "user",
-				Content: fmt.Sprintf("Function call result: %s", v.Result),
+			result, err := json.Marshal(v.Result)
+			if err != nil {
+				return fmt.Errorf("marshaling result of function %q: %w", v.Name, err)
 			}
-			c.history = append(c.history, message)
+			c.history = append(c.history, api.Message{
+				Role:    "tool",
+				Content: string(result),
+			})
 		default:
-			return nil, fmt.Errorf("unsupported content type: %T", v)
+			return fmt.Errorf("unsupported content type: %T", v)
 		}
 	}
+	return nil
+}
 
+func (c *OllamaChat) chatRequest(stream bool) *api.ChatRequest {
 	req := &api.ChatRequest{
 		Model:    c.model,
 		Messages: c.history,
-		// set streaming to false
-		Stream: new(bool),
-		Tools:  c.tools,
+		Stream:   &stream,
+		Tools:    c.tools,
+	}
+	if c.deterministic {
+		req.Options = ollamaDeterministicOptions()
+	}
+	return req
+}
+
+// chatError explains the errors of the models that cannot call tools, which
+// need the tool-use shim.
+func (c *OllamaChat) chatError(err error) error {
+	if len(c.tools) > 0 && strings.Contains(err.Error(), "does not support tools") {
+		return fmt.Errorf("model %q does not support function calling, use a model with tools support or --enable-tool-use-shim: %w", c.model, err)
+	}
+	return err
+}
+
+func (c *OllamaChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	log := klog.FromContext(ctx)
+	if err := c.addContents(contents); err != nil {
+		return nil, err
 	}
 
 	var ollamaResponse *OllamaChatResponse
 
 	respFunc := func(resp api.ChatResponse) error {
 		log.Info("received response from ollama", "resp", resp)
-		ollamaResponse = &OllamaChatResponse{
-			ollamaResponse: resp,
-			candidates: []*OllamaCandidate{
-				{
-					parts: []OllamaPart{
-						{
-							text:      resp.Message.Content,
-							toolCalls: resp.Message.ToolCalls,
-						},
-					},
-				},
-			},
-		}
+		ollamaResponse = newOllamaChatResponse(resp)
 		c.history = append(c.history, resp.Message)
 		return nil
 	}
 
-	err := c.client.Chat(ctx, req, respFunc)
+	err := c.client.Chat(ctx, c.chatRequest(false), respFunc)
 	if err != nil {
-		return nil, err
+		return nil, c.chatError(err)
 	}
 
 	log.Info("ollama response", "parsed_response", ollamaResponse)
 	return ollamaResponse, nil
 }
 
+// errStreamStopped aborts a chat stream the caller stopped reading.
+var errStreamStopped = errors.New("stream stopped")
+
 func (c *OllamaChat) IsRetryableError(err error) bool {
-	// TODO(droot): Implement this
-	return false
+	var statusErr api.StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return DefaultIsRetryableError(err)
 }
 
 func (c *OllamaChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
-	// TODO: Implement streaming
-	response, err := c.Send(ctx, contents...)
-	if err != nil {
+	if err := c.addContents(contents); err != nil {
 		return nil, err
 	}
-	return singletonChatResponseIterator(response), nil
+	req := c.chatRequest(true)
+
+	return func(yield func(ChatResponse, error) bool) {
+		// The message of the model is streamed in chunks, the history
+		// keeps it whole: its text and the tool calls of all chunks.
+		message := api.Message{Role: "assistant"}
+		var text strings.Builder
+		err := c.client.Chat(ctx, req, func(resp api.ChatResponse) error {
+			text.WriteString(resp.Message.Content)
+			message.ToolCalls = append(message.ToolCalls, resp.Message.ToolCalls...)
+			if resp.Message.Content == "" && len(resp.Message.ToolCalls) == 0 && !resp.Done {
+				return nil
+			}
+			if !yield(newOllamaChatResponse(resp), nil) {
+				return errStreamStopped
+			}
+			return nil
+		})
+		if errors.Is(err, errStreamStopped) {
+			return
+		}
+		if err != nil {
+			yield(nil, c.chatError(err))
+			return
+		}
+		message.Content = text.String()
+		c.history = append(c.history, message)
+	}, nil
 }
 
 func (c *OllamaChat) Initialize(messages []*kctlApi.Message) error {
@@ -214,6 +291,22 @@ func (c *OllamaChat) Initialize(messages []*kctlApi.Message) error {
 	return nil
 }
 
+func newOllamaChatResponse(resp api.ChatResponse) *OllamaChatResponse {
+	return &OllamaChatResponse{
+		ollamaResponse: resp,
+		candidates: []*OllamaCandidate{
+			{
+				parts: []OllamaPart{
+					{
+						text:      resp.Message.Content,
+						toolCalls: resp.Message.ToolCalls,
+					},
+				},
+			},
+		},
+	}
+}
+
 type OllamaChatResponse struct {
 	candidates     []*OllamaCandidate
 	ollamaResponse api.ChatResponse
@@ -232,8 +325,13 @@ func (r *OllamaChatResponse) String() string {
 	return fmt.Sprintf("OllamaChatResponse{candidates=%v}", r.candidates)
 }
 
+// UsageMetadata returns the token counts of the response, reported with its
+// last chunk.
 func (r *OllamaChatResponse) UsageMetadata() any {
-	return nil
+	if !r.ollamaResponse.Done {
+		return nil
+	}
+	return r.ollamaResponse.Metrics
 }
 
 func (r *OllamaChatResponse) Candidates() []Candidate {
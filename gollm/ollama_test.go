@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ollama/ollama/api"
+)
+
+// newTestOllamaChat returns a chat with a server streaming chunks, and the
+// requests it received.
+func newTestOllamaChat(t *testing.T, chunks []api.ChatResponse) (*OllamaChat, *[]api.ChatRequest) {
+	t.Helper()
+	var requests []api.ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		requests = append(requests, req)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, chunk := range chunks {
+			json.NewEncoder(w).Encode(chunk)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &OllamaClient{client: api.NewClient(base, server.Client())}
+	return client.StartChat("system prompt", "qwen3").(*OllamaChat), &requests
+}
+
+func TestOllamaSendStreaming(t *testing.T) {
+	toolCall := api.ToolCall{Function: api.ToolCallFunction{Name: "kubectl", Arguments: map[string]any{"command": "kubectl get pods"}}}
+	chat, requests := newTestOllamaChat(t, []api.ChatResponse{
+		{Message: api.Message{Role: "assistant", Content: "Listing "}},
+		{Message: api.Message{Role: "assistant", Content: "the pods."}},
+		{Message: api.Message{Role: "assistant", ToolCalls: []api.ToolCall{toolCall}}},
+		{Message: api.Message{Role: "assistant"}, Done: true, Metrics: api.Metrics{PromptEvalCount: 40, EvalCount: 9}},
+	})
+	if err := chat.SetFunctionDefinitions([]*FunctionDefinition{{Name: "kubectl", Parameters: &Schema{Type: TypeObject}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	iterator, err := chat.SendStreaming(context.Background(), "list the pods")
+	if err != nil {
+		t.Fatalf("SendStreaming() error = %v", err)
+	}
+	var text string
+	var calls []FunctionCall
+	var usage any
+	for response, err := range iterator {
+		if err != nil {
+			t.Fatalf("streaming error = %v", err)
+		}
+		for _, part := range response.Candidates()[0].Parts() {
+			if s, ok := part.AsText(); ok {
+				text += s
+			}
+			if c, ok := part.AsFunctionCalls(); ok {
+				calls = append(calls, c...)
+			}
+		}
+		if u := response.UsageMetadata(); u != nil {
+			usage = u
+		}
+	}
+
+	if text != "Listing the pods." {
+		t.Errorf("streamed text = %q, want %q", text, "Listing the pods.")
+	}
+	if len(calls) != 1 || calls[0].Name != "kubectl" || calls[0].Arguments["command"] != "kubectl get pods" {
+		t.Errorf("streamed function calls = %+v, want the kubectl call", calls)
+	}
+	if input, output := ParseUsage(usage); input != 40 || output != 9 {
+		t.Errorf("usage = %d, %d, want 40, 9", input, output)
+	}
+	if len(*requests) != 1 || !*(*requests)[0].Stream || len((*requests)[0].Tools) != 1 {
+		t.Fatalf("requests = %+v, want one streaming request with the tool", *requests)
+	}
+
+	// The history keeps the whole message of the model.
+	last := chat.history[len(chat.history)-1]
+	if last.Role != "assistant" || last.Content != "Listing the pods." || len(last.ToolCalls) != 1 {
+		t.Errorf("last message of the history = %+v, want the whole message of the model", last)
+	}
+}
+
+func TestOllamaSendFunctionCallResult(t *testing.T) {
+	chat, requests := newTestOllamaChat(t, []api.ChatResponse{
+		{Message: api.Message{Role: "assistant", Content: "There are no pods."}, Done: true},
+	})
+
+	result := FunctionCallResult{Name: "kubectl", Result: map[string]any{"stdout": "No resources found"}}
+	if _, err := chat.Send(context.Background(), result); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	messages := (*requests)[0].Messages
+	sent := messages[len(messages)-1]
+	if sent.Role != "tool" || sent.Content != `{"stdout":"No resources found"}` {
+		t.Errorf("sent message = %+v, want a tool message with the result", sent)
+	}
+}
+
+func TestOllamaIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "server overloaded", err: api.StatusError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "model not found", err: api.StatusError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "other error", err: context.Canceled, want: false},
+	}
+	chat := &OllamaChat{}
+	for _, tt := range tests {
+		if got := chat.IsRetryableError(tt.err); got != tt.want {
+			t.Errorf("%s: IsRetryableError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
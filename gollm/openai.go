@@ -19,7 +19,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
 	openai "github.com/openai/openai-go"
@@ -74,41 +76,117 @@ func init() {
 // OpenAIClient implements the gollm.Client interface for OpenAI models.
 type OpenAIClient struct {
 	client openai.Client
+
+	deterministic bool
+	// gateway enables the compatibility mode for OpenAI compatible gateways, see NewGatewayClient.
+	gateway bool
+	// preset is the provider ID of an OpenAI compatible preset, see NewOpenAIPresetClient.
+	preset string
 }
 
 // Ensure OpenAIClient implements the Client interface.
 var _ Client = &OpenAIClient{}
 
-// NewOpenAIClient creates a new client for interacting with OpenAI.
+// openAIEndpointConfig is the configuration of an OpenAI compatible endpoint
+// passed in the provider URL, e.g. openai://my-endpoint:8000/v1?scheme=http.
+type openAIEndpointConfig struct {
+	// BaseURL is the base URL of the API, empty for the OPENAI_ENDPOINT or
+	// OPENAI_API_BASE env var, or the OpenAI API.
+	BaseURL string
+	// APIKeyEnv is the env var holding the API key, OPENAI_API_KEY by default.
+	APIKeyEnv     string
+	SkipVerifySSL bool
+}
+
+// parseOpenAIEndpoint returns the endpoint configuration of a provider URL.
+// The host and path of the URL are the base URL of the API, /v1 if there is no
+// path, served over https unless the scheme query parameter is http. The
+// api-key-env query parameter names the env var holding the API key, and
+// skip-verify-ssl skips verifying the certificate of the endpoint, e.g.
+// openai://vllm.lab:8000/v1?api-key-env=VLLM_API_KEY&skip-verify-ssl=true.
+func parseOpenAIEndpoint(u *url.URL) (openAIEndpointConfig, error) {
+	config := openAIEndpointConfig{APIKeyEnv: "OPENAI_API_KEY"}
+	if u == nil {
+		return config, nil
+	}
+	query := u.Query()
+	if env := query.Get("api-key-env"); env != "" {
+		config.APIKeyEnv = env
+	}
+	if v := query.Get("skip-verify-ssl"); v != "" {
+		skip, err := strconv.ParseBool(v)
+		if err != nil {
+			return config, fmt.Errorf("invalid skip-verify-ssl %q: %w", v, err)
+		}
+		config.SkipVerifySSL = skip
+	}
+	if u.Host == "" {
+		return config, nil
+	}
+	scheme := query.Get("scheme")
+	switch scheme {
+	case "":
+		scheme = "https"
+	case "http", "https":
+	default:
+		return config, fmt.Errorf("invalid scheme %q, expected http or https", scheme)
+	}
+	path := u.Path
+	if path == "" || path == "/" {
+		path = "/v1"
+	}
+	config.BaseURL = (&url.URL{Scheme: scheme, Host: u.Host, Path: path}).String()
+	return config, nil
+}
+
+// NewOpenAIClient creates a new client for interacting with OpenAI, or with an
+// OpenAI compatible server such as vLLM, LM Studio or llamafile, whose base URL
+// is passed in the provider URL, see parseOpenAIEndpoint.
 // Supports custom HTTP client (e.g., for skipping SSL verification).
 func NewOpenAIClient(ctx context.Context, opts ClientOptions) (*OpenAIClient, error) {
-	// Get API key from loaded env var
-	apiKey := openAIAPIKey
-	if apiKey == "" {
-		return nil, errors.New("OpenAI API key not found. Set via OPENAI_API_KEY env var")
+	endpoint, err := parseOpenAIEndpoint(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing OpenAI provider URL: %w", err)
 	}
 
-	// Set options for client creation
-	options := []option.RequestOption{option.WithAPIKey(apiKey)}
-
 	// Check for custom endpoint or API base URL
-	baseURL := openAIEndpoint
+	baseURL := endpoint.BaseURL
+	if baseURL == "" {
+		baseURL = openAIEndpoint
+	}
 	if baseURL == "" {
 		baseURL = openAIAPIBase
 	}
 
+	// Get API key from loaded env var. OpenAI compatible servers may not need one.
+	apiKey := openAIAPIKey
+	if endpoint.APIKeyEnv != "OPENAI_API_KEY" {
+		apiKey = os.Getenv(endpoint.APIKeyEnv)
+	}
+	if apiKey == "" && baseURL == "" {
+		return nil, fmt.Errorf("OpenAI API key not found. Set via %s env var", endpoint.APIKeyEnv)
+	}
+
+	// Set options for client creation
+	var options []option.RequestOption
+	if apiKey != "" {
+		options = append(options, option.WithAPIKey(apiKey))
+	}
+
 	if baseURL != "" {
 		klog.Infof("Using custom OpenAI base URL: %s", baseURL)
 		options = append(options, option.WithBaseURL(baseURL))
 	}
 
 	// Support custom HTTP client (e.g., skip SSL verification)
-	httpClient := createCustomHTTPClient(opts.SkipVerifySSL)
+	httpClient := createCustomHTTPClient(opts.SkipVerifySSL || endpoint.SkipVerifySSL)
+	httpClient = withHeaders(httpClient, opts.Headers)
 	httpClient = withJournaling(httpClient)
 	options = append(options, option.WithHTTPClient(httpClient))
 
 	return &OpenAIClient{
-		client: openai.NewClient(options...),
+		client:        openai.NewClient(options...),
+		deterministic: opts.Deterministic,
 	}, nil
 }
 
@@ -122,10 +200,17 @@ func (c *OpenAIClient) Close() error {
 func (c *OpenAIClient) StartChat(systemPrompt, model string) Chat {
 	// Get the model to use for this chat
 	selectedModel := getOpenAIModel(model)
+	if c.gateway && model == "" {
+		// Gateways route on the model name, there is no sensible default.
+		selectedModel = openAIModel
+	}
+	if c.preset != "" && model == "" {
+		selectedModel = openAIPresets[c.preset].DefaultModel
+	}
 
 	klog.V(1).Infof("Starting new OpenAI chat session with model: %s", selectedModel)
 
-	if openAIUseResponsesAPI {
+	if openAIUseResponsesAPI && !c.gateway && c.preset == "" {
 		// Initialize history with system prompt if provided
 		history := responses.ResponseInputParam{}
 		if systemPrompt != "" {
@@ -139,7 +224,7 @@ func (c *OpenAIClient) StartChat(systemPrompt, model string) Chat {
 			})
 		}
 
-		return &openAIResponseChatSession{
+		session := &openAIResponseChatSession{
 			client:  c.client,
 			history: history,
 			model:   selectedModel,
@@ -154,6 +239,12 @@ func (c *OpenAIClient) StartChat(systemPrompt, model string) Chat {
 				Store: openai.Bool(false),
 			},
 		}
+		if c.deterministic {
+			// The Responses API does not support a seed.
+			session.params.Temperature = openai.Float(0)
+			session.params.TopP = openai.Float(1)
+		}
+		return session
 	}
 	// by default use completion endpoint
 
@@ -164,9 +255,12 @@ func (c *OpenAIClient) StartChat(systemPrompt, model string) Chat {
 	}
 
 	return &openAIChatSession{
-		client:  c.client,
-		history: history,
-		model:   selectedModel,
+		client:        c.client,
+		history:       history,
+		model:         selectedModel,
+		deterministic: c.deterministic,
+		gateway:       c.gateway,
+		stripThinking: c.preset != "",
 		// functionDefinitions and tools will be set later via SetFunctionDefinitions
 	}
 }
@@ -174,6 +268,13 @@ func (c *OpenAIClient) StartChat(systemPrompt, model string) Chat {
 // simpleCompletionResponse is a basic implementation of CompletionResponse.
 type simpleCompletionResponse struct {
 	content string
+	// candidates are the contents of all the choices, when several were requested.
+	candidates []string
+}
+
+// Responses returns the contents of all the choices.
+func (r *simpleCompletionResponse) Responses() []string {
+	return r.candidates
 }
 
 // Response returns the completion content.
@@ -192,12 +293,19 @@ func (c *OpenAIClient) GenerateCompletion(ctx context.Context, req *CompletionRe
 	klog.V(1).Infof("Prompt:\n%s", req.Prompt)
 
 	// Use the Chat Completions API with the new v1.0.0 API
-	completion, err := c.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+	chatReq := openai.ChatCompletionNewParams{
 		Model: openai.ChatModel(req.Model),
 		Messages: []openai.ChatCompletionMessageParamUnion{
 			openai.UserMessage(req.Prompt),
 		},
-	})
+	}
+	if c.deterministic {
+		setOpenAIDeterministic(&chatReq)
+	}
+	if req.CandidateCount > 1 {
+		chatReq.N = openai.Int(int64(req.CandidateCount))
+	}
+	completion, err := c.client.Chat.Completions.New(ctx, chatReq)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate OpenAI completion: %w", err)
@@ -212,6 +320,13 @@ func (c *OpenAIClient) GenerateCompletion(ctx context.Context, req *CompletionRe
 	resp := &simpleCompletionResponse{
 		content: completion.Choices[0].Message.Content,
 	}
+	if len(completion.Choices) > 1 {
+		for _, choice := range completion.Choices {
+			if choice.Message.Content != "" {
+				resp.candidates = append(resp.candidates, choice.Message.Content)
+			}
+		}
+	}
 
 	return resp, nil
 }
@@ -247,6 +362,10 @@ type openAIChatSession struct {
 	model               string
 	functionDefinitions []*FunctionDefinition            // Stored in gollm format
 	tools               []openai.ChatCompletionToolParam // Stored in OpenAI format
+	deterministic       bool
+	gateway             bool
+	// stripThinking removes the reasoning in <think> tags from the content, see thinkFilter.
+	stripThinking bool
 }
 
 // Ensure openAIChatSession implements the Chat interface.
@@ -297,6 +416,9 @@ func (cs *openAIChatSession) Send(ctx context.Context, contents ...any) (ChatRes
 	if len(cs.tools) > 0 {
 		chatReq.Tools = cs.tools
 	}
+	if cs.deterministic {
+		setOpenAIDeterministic(&chatReq)
+	}
 
 	// Call the OpenAI API
 	klog.V(1).InfoS("Sending request to OpenAI Chat API", "model", cs.model, "messages", len(chatReq.Messages), "tools", len(chatReq.Tools))
@@ -314,6 +436,12 @@ func (cs *openAIChatSession) Send(ctx context.Context, contents ...any) (ChatRes
 		return nil, errors.New("received empty response from OpenAI (no choices)")
 	}
 
+	if cs.stripThinking {
+		for i := range completion.Choices {
+			completion.Choices[i].Message.Content = stripThinking(completion.Choices[i].Message.Content)
+		}
+	}
+
 	// Add assistant's response (first choice) to history
 	assistantMsg := completion.Choices[0].Message
 	// Convert to param type before appending to history
@@ -345,6 +473,9 @@ func (cs *openAIChatSession) SendStreaming(ctx context.Context, contents ...any)
 	if len(cs.tools) > 0 {
 		chatReq.Tools = cs.tools
 	}
+	if cs.deterministic {
+		setOpenAIDeterministic(&chatReq)
+	}
 
 	// Start the OpenAI streaming request
 	klog.V(1).InfoS("Sending streaming request to OpenAI API",
@@ -356,6 +487,13 @@ func (cs *openAIChatSession) SendStreaming(ctx context.Context, contents ...any)
 
 	// Create an accumulator to track the full response
 	acc := openai.ChatCompletionAccumulator{}
+	// Tool call arguments may be split across many chunks, so we collect them
+	// ourselves and only hand them out once the model finishes its turn.
+	toolCallAcc := newOpenAIToolCallAccumulator()
+	var thinking *thinkFilter
+	if cs.stripThinking {
+		thinking = &thinkFilter{}
+	}
 
 	// Create and return the stream iterator
 	return func(yield func(ChatResponse, error) bool) {
@@ -364,6 +502,8 @@ func (cs *openAIChatSession) SendStreaming(ctx context.Context, contents ...any)
 		var lastResponseChunk *openAIChatStreamResponse
 		var currentContent strings.Builder
 		var currentToolCalls []openai.ChatCompletionMessageToolCall
+		// reasoning is the reasoning streamed apart from the content, which is only logged.
+		var reasoning strings.Builder
 
 		// Process stream chunks
 		for stream.Next() {
@@ -384,20 +524,15 @@ func (cs *openAIChatSession) SendStreaming(ctx context.Context, contents ...any)
 				return
 			}
 
-			// Handle tool call completion
+			// Accumulate tool call fragments, and release them once the choice is finished
 			var toolCallsForThisChunk []openai.ChatCompletionMessageToolCall
-			if tool, ok := acc.JustFinishedToolCall(); ok {
-				klog.V(2).Infof("Tool call finished: %s %s", tool.Name, tool.Arguments)
-				newToolCall := openai.ChatCompletionMessageToolCall{
-					ID: tool.ID,
-					Function: openai.ChatCompletionMessageToolCallFunction{
-						Name:      tool.Name,
-						Arguments: tool.Arguments,
-					},
+			if len(chunk.Choices) > 0 {
+				choice := chunk.Choices[0]
+				toolCallAcc.add(choice.Delta.ToolCalls)
+				if choice.FinishReason != "" {
+					toolCallsForThisChunk = toolCallAcc.flush()
+					currentToolCalls = append(currentToolCalls, toolCallsForThisChunk...)
 				}
-				currentToolCalls = append(currentToolCalls, newToolCall)
-				// Only include the newly finished tool call in this chunk
-				toolCallsForThisChunk = []openai.ChatCompletionMessageToolCall{newToolCall}
 			}
 
 			streamResponse := &openAIChatStreamResponse{
@@ -410,9 +545,10 @@ func (cs *openAIChatSession) SendStreaming(ctx context.Context, contents ...any)
 			// Only process content if there are choices and a delta
 			if len(chunk.Choices) > 0 {
 				delta := chunk.Choices[0].Delta
-				if delta.Content != "" {
-					currentContent.WriteString(delta.Content)
-					streamResponse.content = delta.Content // Only set content if there's new content
+				reasoning.WriteString(deltaReasoning(delta))
+				if content := thinking.write(delta.Content); content != "" {
+					currentContent.WriteString(content)
+					streamResponse.content = content // Only set content if there's new content
 				}
 			}
 
@@ -439,6 +575,45 @@ func (cs *openAIChatSession) SendStreaming(ctx context.Context, contents ...any)
 			return
 		}
 
+		if reasoning.Len() > 0 {
+			klog.V(2).Infof("Model reasoning: %s", reasoning.String())
+		}
+
+		// Release the end of the content held back in case it started a <think> tag.
+		if rest := thinking.flush(); rest != "" {
+			currentContent.WriteString(rest)
+			streamResponse := &openAIChatStreamResponse{
+				streamChunk: openai.ChatCompletionChunk{Choices: []openai.ChatCompletionChunkChoice{{}}},
+				accumulator: acc,
+				content:     rest,
+			}
+			if lastResponseChunk == nil {
+				lastResponseChunk = streamResponse
+			}
+			if !yield(streamResponse, nil) {
+				return
+			}
+		}
+
+		// Some OpenAI-compatible servers end the stream without a finish_reason;
+		// release any tool calls that are still pending.
+		if pending := toolCallAcc.flush(); len(pending) > 0 {
+			currentToolCalls = append(currentToolCalls, pending...)
+			streamResponse := &openAIChatStreamResponse{
+				streamChunk: openai.ChatCompletionChunk{
+					Choices: []openai.ChatCompletionChunkChoice{{FinishReason: "tool_calls"}},
+				},
+				accumulator: acc,
+				toolCalls:   pending,
+			}
+			if lastResponseChunk == nil {
+				lastResponseChunk = streamResponse
+			}
+			if !yield(streamResponse, nil) {
+				return
+			}
+		}
+
 		// Update conversation history with the complete message
 		if lastResponseChunk != nil {
 			completeMessage := openai.ChatCompletionMessage{
@@ -456,11 +631,77 @@ func (cs *openAIChatSession) SendStreaming(ctx context.Context, contents ...any)
 	}, nil
 }
 
+// openAIToolCallAccumulator collects streamed tool call fragments, keyed by
+// the tool call index, until the model signals the end of its turn.
+type openAIToolCallAccumulator struct {
+	calls []*openai.ChatCompletionMessageToolCall
+	// byIndex maps a tool call index to its position in calls.
+	byIndex map[int64]int
+}
+
+func newOpenAIToolCallAccumulator() *openAIToolCallAccumulator {
+	return &openAIToolCallAccumulator{byIndex: make(map[int64]int)}
+}
+
+// add merges the tool call fragments of a single chunk.
+func (a *openAIToolCallAccumulator) add(deltas []openai.ChatCompletionChunkChoiceDeltaToolCall) {
+	for _, delta := range deltas {
+		pos, ok := a.byIndex[delta.Index]
+		// Some servers reuse the same index for distinct calls; a new ID starts a new call.
+		if ok && delta.ID != "" && a.calls[pos].ID != "" && a.calls[pos].ID != delta.ID {
+			ok = false
+		}
+		if !ok {
+			a.calls = append(a.calls, &openai.ChatCompletionMessageToolCall{Type: "function"})
+			pos = len(a.calls) - 1
+			a.byIndex[delta.Index] = pos
+		}
+
+		call := a.calls[pos]
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		// The name is normally sent once, but tolerate servers that stream it too.
+		if delta.Function.Name != "" && delta.Function.Name != call.Function.Name {
+			call.Function.Name += delta.Function.Name
+		}
+		call.Function.Arguments += delta.Function.Arguments
+	}
+}
+
+// flush returns the accumulated tool calls, in the order they were started,
+// and resets the accumulator. This is where arguments are validated as JSON;
+// invalid arguments are kept as-is so the caller can attempt to repair them.
+func (a *openAIToolCallAccumulator) flush() []openai.ChatCompletionMessageToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+
+	toolCalls := make([]openai.ChatCompletionMessageToolCall, 0, len(a.calls))
+	for _, call := range a.calls {
+		if strings.TrimSpace(call.Function.Arguments) == "" {
+			call.Function.Arguments = "{}"
+		}
+		if !json.Valid([]byte(call.Function.Arguments)) {
+			klog.Warningf("Tool call %q (%s) finished with invalid JSON arguments: %s", call.Function.Name, call.ID, call.Function.Arguments)
+		}
+		klog.V(2).Infof("Tool call finished: %s %s", call.Function.Name, call.Function.Arguments)
+		toolCalls = append(toolCalls, *call)
+	}
+
+	a.calls = nil
+	a.byIndex = make(map[int64]int)
+	return toolCalls
+}
+
 // IsRetryableError determines if an error from the OpenAI API should be retried.
 func (cs *openAIChatSession) IsRetryableError(err error) bool {
 	if err == nil {
 		return false
 	}
+	if cs.gateway {
+		return isRetryableGatewayError(err)
+	}
 	return DefaultIsRetryableError(err)
 }
 
@@ -802,6 +1043,14 @@ func (cs *openAIChatSession) convertSchemaToBytes(schema *Schema, functionName s
 	return bytes, nil
 }
 
+// setOpenAIDeterministic configures greedy sampling with a fixed seed.
+// It is shared by the OpenAI compatible providers.
+func setOpenAIDeterministic(req *openai.ChatCompletionNewParams) {
+	req.Temperature = openai.Float(0)
+	req.TopP = openai.Float(1)
+	req.Seed = openai.Int(DeterministicSeed)
+}
+
 // newOpenAIClientFactory is the factory function for creating OpenAI clients.
 func newOpenAIClientFactory(ctx context.Context, opts ClientOptions) (Client, error) {
 	return NewOpenAIClient(ctx, opts)
@@ -847,19 +1096,22 @@ func convertToolCallsToFunctionCalls(toolCalls []openai.ChatCompletionMessageToo
 
 		// Parse function arguments with error handling
 		var args map[string]any
+		var rawArgs string
 		if tc.Function.Arguments != "" {
 			if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
 				klog.V(2).Infof("Error unmarshalling function arguments for %s: %v", tc.Function.Name, err)
 				args = make(map[string]any)
+				rawArgs = tc.Function.Arguments
 			}
 		} else {
 			args = make(map[string]any)
 		}
 
 		calls = append(calls, FunctionCall{
-			ID:        tc.ID,
-			Name:      tc.Function.Name,
-			Arguments: args,
+			ID:           tc.ID,
+			Name:         tc.Function.Name,
+			Arguments:    args,
+			RawArguments: rawArgs,
 		})
 	}
 	return calls, len(calls) > 0
@@ -0,0 +1,213 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"k8s.io/klog/v2"
+)
+
+// openAIPreset is the OpenAI compatible API of a model vendor whose models
+// reason before they answer.
+type openAIPreset struct {
+	// BaseURL is the base URL of the API, replaced by the host of the provider
+	// URL if any, e.g. deepseek://deepseek.internal:8000/v1?scheme=http.
+	BaseURL string
+	// APIKeyEnv is the env var holding the API key, replaced by the
+	// api-key-env query parameter of the provider URL if any.
+	APIKeyEnv string
+	// DefaultModel is the model of the chats started without a model.
+	DefaultModel string
+}
+
+// openAIPresets are the presets by provider ID.
+var openAIPresets = map[string]openAIPreset{
+	"deepseek": {
+		BaseURL:      "https://api.deepseek.com/v1",
+		APIKeyEnv:    "DEEPSEEK_API_KEY",
+		DefaultModel: "deepseek-chat",
+	},
+	"qwen": {
+		BaseURL:      "https://dashscope-intl.aliyuncs.com/compatible-mode/v1",
+		APIKeyEnv:    "DASHSCOPE_API_KEY",
+		DefaultModel: "qwen-plus",
+	},
+}
+
+func init() {
+	for id := range openAIPresets {
+		if err := RegisterProvider(id, newOpenAIPresetClientFactory(id)); err != nil {
+			klog.Fatalf("Failed to register %s provider: %v", id, err)
+		}
+	}
+}
+
+func newOpenAIPresetClientFactory(id string) FactoryFunc {
+	return func(ctx context.Context, opts ClientOptions) (Client, error) {
+		return NewOpenAIPresetClient(ctx, id, opts)
+	}
+}
+
+// NewOpenAIPresetClient creates a client for the OpenAI compatible API of a
+// preset, e.g. "deepseek". The reasoning of the models is kept out of their
+// answers and tool calls, see thinkFilter and deltaReasoning.
+func NewOpenAIPresetClient(ctx context.Context, id string, opts ClientOptions) (*OpenAIClient, error) {
+	preset, ok := openAIPresets[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown OpenAI compatible preset %q", id)
+	}
+	endpoint, err := parseOpenAIEndpoint(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s provider URL: %w", id, err)
+	}
+	baseURL := preset.BaseURL
+	if endpoint.BaseURL != "" {
+		baseURL = endpoint.BaseURL
+	}
+	apiKeyEnv := preset.APIKeyEnv
+	if endpoint.APIKeyEnv != "OPENAI_API_KEY" {
+		apiKeyEnv = endpoint.APIKeyEnv
+	}
+	apiKey := os.Getenv(apiKeyEnv)
+	if apiKey == "" {
+		return nil, fmt.Errorf("%s API key not found. Set via %s env var", id, apiKeyEnv)
+	}
+	klog.Infof("Using %s OpenAI compatible API: %s", id, baseURL)
+
+	httpClient := createCustomHTTPClient(opts.SkipVerifySSL || endpoint.SkipVerifySSL)
+	httpClient = withHeaders(httpClient, opts.Headers)
+	httpClient = withJournaling(httpClient)
+
+	return &OpenAIClient{
+		client: openai.NewClient(
+			option.WithBaseURL(baseURL),
+			option.WithAPIKey(apiKey),
+			option.WithHTTPClient(httpClient),
+		),
+		deterministic: opts.Deterministic,
+		preset:        id,
+	}, nil
+}
+
+// The tags enclosing the reasoning of the models streaming it in their content,
+// e.g. Qwen3 or the DeepSeek-R1 distills served without a reasoning parser.
+const (
+	thinkStartTag = "<think>"
+	thinkEndTag   = "</think>"
+)
+
+// thinkFilter removes the reasoning enclosed in <think> tags from the content
+// streamed by a model, so that it is neither shown as the answer nor parsed
+// for tool calls. Tags split across chunks are recognized. A nil filter keeps
+// the content as is.
+type thinkFilter struct {
+	thinking bool
+	// trimLeft drops the whitespace following the reasoning.
+	trimLeft bool
+	// pending is the end of the previous chunk, which may start a tag.
+	pending string
+}
+
+// write returns the content of a chunk without the reasoning.
+func (f *thinkFilter) write(chunk string) string {
+	if f == nil {
+		return chunk
+	}
+	s := f.pending + chunk
+	f.pending = ""
+	var out strings.Builder
+	for s != "" {
+		tag := thinkStartTag
+		if f.thinking {
+			tag = thinkEndTag
+		}
+		i := strings.Index(s, tag)
+		if i < 0 {
+			keep := partialTagSuffix(s, tag)
+			if !f.thinking {
+				f.output(&out, s[:len(s)-keep])
+			}
+			f.pending = s[len(s)-keep:]
+			break
+		}
+		if !f.thinking {
+			f.output(&out, s[:i])
+		}
+		s = s[i+len(tag):]
+		f.thinking = !f.thinking
+		f.trimLeft = !f.thinking
+	}
+	return out.String()
+}
+
+// flush returns the content held back at the end of the stream.
+func (f *thinkFilter) flush() string {
+	if f == nil || f.thinking {
+		return ""
+	}
+	var out strings.Builder
+	f.output(&out, f.pending)
+	f.pending = ""
+	return out.String()
+}
+
+func (f *thinkFilter) output(out *strings.Builder, s string) {
+	if f.trimLeft {
+		s = strings.TrimLeft(s, " \t\r\n")
+		f.trimLeft = s == ""
+	}
+	out.WriteString(s)
+}
+
+// partialTagSuffix returns the length of the longest end of s that starts tag.
+func partialTagSuffix(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// stripThinking returns the content of a complete message without the reasoning.
+func stripThinking(content string) string {
+	f := &thinkFilter{}
+	return f.write(content) + f.flush()
+}
+
+// deltaReasoning returns the reasoning of a streamed chunk, sent by DeepSeek
+// and Qwen in the reasoning_content field, or by vLLM in the reasoning field.
+// The OpenAI SDK keeps these fields out of the content, and of the history
+// sent back to the API, which DeepSeek requires.
+func deltaReasoning(delta openai.ChatCompletionChunkChoiceDelta) string {
+	for _, name := range []string{"reasoning_content", "reasoning"} {
+		field, ok := delta.JSON.ExtraFields[name]
+		if !ok {
+			continue
+		}
+		var reasoning string
+		if err := json.Unmarshal([]byte(field.Raw()), &reasoning); err == nil && reasoning != "" {
+			return reasoning
+		}
+	}
+	return ""
+}
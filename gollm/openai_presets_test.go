@@ -0,0 +1,129 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestThinkFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{name: "no reasoning", chunks: []string{"The pod ", "is running."}, want: "The pod is running."},
+		{name: "reasoning", chunks: []string{"<think>check the pods</think>\n\nThe pod is running."}, want: "The pod is running."},
+		{name: "split tags", chunks: []string{"<th", "ink>check", " the pods</thi", "nk>", "\n", "The pod is running."}, want: "The pod is running."},
+		{name: "unfinished reasoning", chunks: []string{"<think>check the pods"}, want: ""},
+		{name: "less than", chunks: []string{"replicas <", " 3"}, want: "replicas < 3"},
+		{name: "trailing partial tag", chunks: []string{"done <thi"}, want: "done <thi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &thinkFilter{}
+			var got string
+			for _, chunk := range tt.chunks {
+				got += f.write(chunk)
+			}
+			got += f.flush()
+			if got != tt.want {
+				t.Errorf("filtered content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIPresetSendStreaming(t *testing.T) {
+	chunks := []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":"","reasoning_content":"The user wants the pods."}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"<think>{\"name\": \"bash\"}</th"}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"ink>Listing the pods."}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"kubectl","arguments":"{\"command\":\"kubectl get pods\"}"}}]},"finish_reason":"tool_calls"}]}`,
+	}
+	var requestedModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		requestedModel = req.Model
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEEPSEEK_API_KEY", "test-key")
+	client, err := NewOpenAIPresetClient(context.Background(), "deepseek", ClientOptions{URL: &url.URL{Scheme: "deepseek", Host: u.Host, RawQuery: "scheme=http"}})
+	if err != nil {
+		t.Fatalf("NewOpenAIPresetClient() error = %v", err)
+	}
+	chat := client.StartChat("system prompt", "")
+	iterator, err := chat.SendStreaming(context.Background(), "list the pods")
+	if err != nil {
+		t.Fatalf("SendStreaming() error = %v", err)
+	}
+	var text string
+	var calls []FunctionCall
+	for response, err := range iterator {
+		if err != nil {
+			t.Fatalf("streaming error = %v", err)
+		}
+		if response == nil {
+			break
+		}
+		for _, part := range response.Candidates()[0].Parts() {
+			if s, ok := part.AsText(); ok {
+				text += s
+			}
+			if c, ok := part.AsFunctionCalls(); ok {
+				calls = append(calls, c...)
+			}
+		}
+	}
+	if requestedModel != "deepseek-chat" {
+		t.Errorf("model = %q, want the default model of the preset", requestedModel)
+	}
+	if text != "Listing the pods." {
+		t.Errorf("text = %q, want the content without the reasoning", text)
+	}
+	if len(calls) != 1 || calls[0].Name != "kubectl" {
+		t.Errorf("calls = %+v, want the kubectl call only", calls)
+	}
+}
+
+func TestNewOpenAIPresetClientAPIKey(t *testing.T) {
+	t.Setenv("DASHSCOPE_API_KEY", "")
+	_, err := NewOpenAIPresetClient(context.Background(), "qwen", ClientOptions{})
+	if err == nil || !strings.Contains(err.Error(), "DASHSCOPE_API_KEY") {
+		t.Errorf("NewOpenAIPresetClient() error = %v, want the missing DASHSCOPE_API_KEY", err)
+	}
+}
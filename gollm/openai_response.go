@@ -326,18 +326,21 @@ func convertResponseToolCallToFunctionCall(responseToolCall responses.ResponseFu
 	fc.Name = responseToolCall.Name
 	// Parse function arguments with error handling
 	var args map[string]any
+	var rawArgs string
 	if responseToolCall.Arguments != "" {
 		if err := json.Unmarshal([]byte(responseToolCall.Arguments), &args); err != nil {
 			klog.V(2).Infof("Error unmarshalling function arguments for %s: %v", fc.Name, err)
 			args = make(map[string]any)
+			rawArgs = responseToolCall.Arguments
 		}
 	} else {
 		args = make(map[string]any)
 	}
 
 	return FunctionCall{
-		ID:        responseToolCall.CallID,
-		Name:      responseToolCall.Name,
-		Arguments: args,
+		ID:           responseToolCall.CallID,
+		Name:         responseToolCall.Name,
+		Arguments:    args,
+		RawArguments: rawArgs,
 	}, nil
 }
@@ -16,6 +16,7 @@ package gollm
 
 import (
 	"encoding/json"
+	"net/url"
 	"testing"
 
 	"github.com/openai/openai-go"
@@ -513,6 +514,10 @@ func TestConvertToolCallsToFunctionCalls(t *testing.T) {
 				if len(calls[0].Arguments) != 0 {
 					t.Errorf("expected empty arguments due to parse error, got %v", calls[0].Arguments)
 				}
+				// Raw arguments should be preserved so the caller can repair them
+				if calls[0].RawArguments != `{"command":"kubectl get pods", invalid json}` {
+					t.Errorf("expected raw arguments to be preserved, got %q", calls[0].RawArguments)
+				}
 			},
 		},
 		{
@@ -604,3 +609,156 @@ func TestConvertToolCallsToFunctionCalls(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenAIToolCallAccumulator(t *testing.T) {
+	delta := func(index int64, id, name, args string) openai.ChatCompletionChunkChoiceDeltaToolCall {
+		return openai.ChatCompletionChunkChoiceDeltaToolCall{
+			Index: index,
+			ID:    id,
+			Function: openai.ChatCompletionChunkChoiceDeltaToolCallFunction{
+				Name:      name,
+				Arguments: args,
+			},
+		}
+	}
+
+	t.Run("fragments across chunks", func(t *testing.T) {
+		acc := newOpenAIToolCallAccumulator()
+		acc.add([]openai.ChatCompletionChunkChoiceDeltaToolCall{delta(0, "call_1", "kubectl", "")})
+		acc.add([]openai.ChatCompletionChunkChoiceDeltaToolCall{delta(0, "", "", `{"command":"kub`)})
+		acc.add([]openai.ChatCompletionChunkChoiceDeltaToolCall{
+			delta(0, "", "", `ectl get pods"}`),
+			delta(1, "call_2", "bash", `{"command":`),
+		})
+		acc.add([]openai.ChatCompletionChunkChoiceDeltaToolCall{delta(1, "", "", `"ls"}`)})
+
+		toolCalls := acc.flush()
+		if len(toolCalls) != 2 {
+			t.Fatalf("expected 2 tool calls, got %d", len(toolCalls))
+		}
+		if toolCalls[0].ID != "call_1" || toolCalls[0].Function.Name != "kubectl" || toolCalls[0].Function.Arguments != `{"command":"kubectl get pods"}` {
+			t.Errorf("unexpected first tool call: %+v", toolCalls[0])
+		}
+		if toolCalls[1].ID != "call_2" || toolCalls[1].Function.Name != "bash" || toolCalls[1].Function.Arguments != `{"command":"ls"}` {
+			t.Errorf("unexpected second tool call: %+v", toolCalls[1])
+		}
+
+		if remaining := acc.flush(); len(remaining) != 0 {
+			t.Errorf("expected accumulator to be empty after flush, got %d tool calls", len(remaining))
+		}
+	})
+
+	t.Run("reused index with new id", func(t *testing.T) {
+		acc := newOpenAIToolCallAccumulator()
+		acc.add([]openai.ChatCompletionChunkChoiceDeltaToolCall{delta(0, "call_1", "kubectl", `{"command":"kubectl get ns"}`)})
+		acc.add([]openai.ChatCompletionChunkChoiceDeltaToolCall{delta(0, "call_2", "kubectl", `{"command":"kubectl get pods"}`)})
+
+		toolCalls := acc.flush()
+		if len(toolCalls) != 2 {
+			t.Fatalf("expected 2 tool calls, got %d", len(toolCalls))
+		}
+		if toolCalls[1].ID != "call_2" || toolCalls[1].Function.Arguments != `{"command":"kubectl get pods"}` {
+			t.Errorf("unexpected second tool call: %+v", toolCalls[1])
+		}
+	})
+
+	t.Run("empty and invalid arguments", func(t *testing.T) {
+		acc := newOpenAIToolCallAccumulator()
+		acc.add([]openai.ChatCompletionChunkChoiceDeltaToolCall{
+			delta(0, "call_1", "list_things", ""),
+			delta(1, "call_2", "kubectl", `{"command":"kubectl get`),
+		})
+
+		toolCalls := acc.flush()
+		if toolCalls[0].Function.Arguments != "{}" {
+			t.Errorf("expected empty arguments to be normalized to {}, got %q", toolCalls[0].Function.Arguments)
+		}
+
+		calls, ok := convertToolCallsToFunctionCalls(toolCalls)
+		if !ok || len(calls) != 2 {
+			t.Fatalf("expected 2 function calls, got %d", len(calls))
+		}
+		if calls[1].RawArguments != `{"command":"kubectl get` {
+			t.Errorf("expected truncated arguments to be preserved, got %q", calls[1].RawArguments)
+		}
+	})
+}
+
+func TestSetOpenAIDeterministic(t *testing.T) {
+	req := openai.ChatCompletionNewParams{
+		Model:    openai.ChatModel("gpt-4.1"),
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("list pods")},
+	}
+	setOpenAIDeterministic(&req)
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshalling request: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshalling request: %v", err)
+	}
+	// Zero values must be sent explicitly, not omitted.
+	want := map[string]float64{"temperature": 0, "top_p": 1, "seed": DeterministicSeed}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("request field %q = %v, want %v (request: %s)", k, got[k], v, data)
+		}
+	}
+}
+
+func TestParseOpenAIEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    openAIEndpointConfig
+		wantErr bool
+	}{
+		{
+			name: "openai",
+			url:  "openai://",
+			want: openAIEndpointConfig{APIKeyEnv: "OPENAI_API_KEY"},
+		},
+		{
+			name: "vllm",
+			url:  "openai://my-endpoint:8000/v1?scheme=http",
+			want: openAIEndpointConfig{BaseURL: "http://my-endpoint:8000/v1", APIKeyEnv: "OPENAI_API_KEY"},
+		},
+		{
+			name: "default path",
+			url:  "openai://llm.example.com",
+			want: openAIEndpointConfig{BaseURL: "https://llm.example.com/v1", APIKeyEnv: "OPENAI_API_KEY"},
+		},
+		{
+			name: "api key env and skip verify",
+			url:  "openai://vllm.lab:8443/v1?api-key-env=VLLM_API_KEY&skip-verify-ssl=true",
+			want: openAIEndpointConfig{BaseURL: "https://vllm.lab:8443/v1", APIKeyEnv: "VLLM_API_KEY", SkipVerifySSL: true},
+		},
+		{
+			name:    "invalid scheme",
+			url:     "openai://my-endpoint:8000/v1?scheme=ftp",
+			wantErr: true,
+		},
+		{
+			name:    "invalid skip verify",
+			url:     "openai://my-endpoint:8000/v1?skip-verify-ssl=maybe",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseOpenAIEndpoint(u)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOpenAIEndpoint(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseOpenAIEndpoint(%q) = %+v, want %+v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
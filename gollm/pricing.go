@@ -0,0 +1,83 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"path"
+	"strings"
+)
+
+// ModelPrice is the price of a model, in USD per million tokens.
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// modelPrices are the list prices of the hosted models, by model name
+// prefix, for the prompts of the standard context length.
+var modelPrices = map[string]ModelPrice{
+	"gemini-2.5-pro":        {Input: 1.25, Output: 10},
+	"gemini-2.5-flash":      {Input: 0.30, Output: 2.50},
+	"gemini-2.5-flash-lite": {Input: 0.10, Output: 0.40},
+	"gemini-2.0-flash":      {Input: 0.10, Output: 0.40},
+	"gemini-2.0-flash-lite": {Input: 0.075, Output: 0.30},
+	"gemini-1.5-pro":        {Input: 1.25, Output: 5},
+	"gemini-1.5-flash":      {Input: 0.075, Output: 0.30},
+	"gpt-4.1":               {Input: 2, Output: 8},
+	"gpt-4.1-mini":          {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":          {Input: 0.10, Output: 0.40},
+	"gpt-4o":                {Input: 2.50, Output: 10},
+	"gpt-4o-mini":           {Input: 0.15, Output: 0.60},
+	"o3":                    {Input: 2, Output: 8},
+	"o3-mini":               {Input: 1.10, Output: 4.40},
+	"o4-mini":               {Input: 1.10, Output: 4.40},
+	"claude-opus-4":         {Input: 15, Output: 75},
+	"claude-sonnet-4":       {Input: 3, Output: 15},
+	"claude-3-7-sonnet":     {Input: 3, Output: 15},
+	"claude-3-5-sonnet":     {Input: 3, Output: 15},
+	"claude-3-5-haiku":      {Input: 0.80, Output: 4},
+	"grok-4":                {Input: 3, Output: 15},
+	"grok-3":                {Input: 3, Output: 15},
+	"grok-3-mini":           {Input: 0.30, Output: 0.50},
+}
+
+// PriceOf returns the price of a model, matched by the longest known name
+// prefix. Provider specific prefixes are ignored, e.g. "models/" or the
+// "us.anthropic." of the Bedrock model IDs. It returns false for the models
+// of unknown price, e.g. the local ones.
+func PriceOf(model string) (ModelPrice, bool) {
+	name := strings.ToLower(path.Base(model))
+	var price ModelPrice
+	matched := ""
+	for prefix, p := range modelPrices {
+		if len(prefix) <= len(matched) {
+			continue
+		}
+		if strings.HasPrefix(name, prefix) || strings.Contains(name, "."+prefix) {
+			price, matched = p, prefix
+		}
+	}
+	return price, matched != ""
+}
+
+// EstimateCost returns the cost of the usage of a model in USD, or false if
+// the price of the model is unknown.
+func EstimateCost(model string, inputTokens, outputTokens int64) (float64, bool) {
+	price, ok := PriceOf(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(inputTokens)*price.Input + float64(outputTokens)*price.Output) / 1e6, true
+}
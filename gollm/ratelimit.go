@@ -0,0 +1,202 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	openai "github.com/openai/openai-go"
+	"k8s.io/klog/v2"
+)
+
+// RateLimit bounds the requests sent to a provider, so that the bursts of
+// requests of an agent do not exceed the quotas of the provider.
+type RateLimit struct {
+	// RequestsPerMinute bounds the requests sent in any minute, 0 for no bound.
+	RequestsPerMinute int
+	// TokensPerMinute bounds the tokens used in any minute, 0 for no bound.
+	// The tokens of a request are only known from its response, so a request
+	// is sent once the tokens used in the last minute are below the bound.
+	TokensPerMinute int64
+}
+
+// WithRateLimit delays the requests of the client to stay within the limit.
+// The limit is shared by all the chats of the client.
+func WithRateLimit(limit RateLimit) Option {
+	return func(o *ClientOptions) {
+		if limit.RequestsPerMinute > 0 || limit.TokensPerMinute > 0 {
+			o.Middlewares = append(o.Middlewares, NewRateLimitMiddleware(limit))
+		}
+	}
+}
+
+// NewRateLimitMiddleware returns a middleware delaying the requests to stay
+// within the limit, see WithRateLimit.
+func NewRateLimitMiddleware(limit RateLimit) Middleware {
+	l := &rateLimiter{limit: limit, window: time.Minute}
+	return MiddlewareFuncs{Request: l.onRequest, Response: l.onResponse}
+}
+
+// tokenUse is the number of tokens used by a response.
+type tokenUse struct {
+	at     time.Time
+	tokens int64
+}
+
+// rateLimiter tracks the requests and the tokens of a sliding window.
+type rateLimiter struct {
+	limit  RateLimit
+	window time.Duration
+
+	mu       sync.Mutex
+	requests []time.Time
+	tokens   []tokenUse
+	// pausedUntil is the end of the delay asked by the provider in its last
+	// error, if any, see RetryDelayHint.
+	pausedUntil time.Time
+}
+
+// onRequest waits until the request can be sent within the limit.
+func (l *rateLimiter) onRequest(ctx context.Context, req *Request) error {
+	if req.Kind == RequestKindChatStart {
+		return nil
+	}
+	for {
+		delay := l.reserve(time.Now())
+		if delay <= 0 {
+			return nil
+		}
+		klog.FromContext(ctx).V(1).Info("Delaying the request to stay within the rate limit", "delay", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return context.Cause(ctx)
+		}
+	}
+}
+
+// onResponse records the tokens used by a response, and the delay asked by
+// the provider in an error. The chunks of a streamed response report its
+// cumulative usage.
+func (l *rateLimiter) onResponse(ctx context.Context, req *Request, resp *Response) error {
+	if hint := RetryDelayHint(resp.Err); hint > 0 {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		// Longer delays, e.g. until a daily quota is reset, are left to the retries.
+		l.pausedUntil = time.Now().Add(min(hint, defaultMaxRetryAfter))
+		return nil
+	}
+	var metadata any
+	switch {
+	case resp.Chat != nil:
+		metadata = resp.Chat.UsageMetadata()
+	case resp.Completion != nil:
+		metadata = resp.Completion.UsageMetadata()
+	}
+	tokens := UsageTokens(metadata)
+	if tokens <= req.rateLimitedTokens {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = append(l.tokens, tokenUse{at: time.Now(), tokens: tokens - req.rateLimitedTokens})
+	req.rateLimitedTokens = tokens
+	return nil
+}
+
+// reserve records a request sent at now and returns 0 if it is within the
+// limit, or returns the time to wait before trying again.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start := now.Add(-l.window)
+	for len(l.requests) > 0 && !l.requests[0].After(start) {
+		l.requests = l.requests[1:]
+	}
+	for len(l.tokens) > 0 && !l.tokens[0].at.After(start) {
+		l.tokens = l.tokens[1:]
+	}
+
+	delay := l.pausedUntil.Sub(now)
+	if n := l.limit.RequestsPerMinute; n > 0 && len(l.requests) >= n {
+		// The request is sent once the oldest of the last n requests leaves the window.
+		delay = max(delay, l.requests[len(l.requests)-n].Sub(start))
+	}
+	if l.limit.TokensPerMinute > 0 {
+		var used int64
+		for _, use := range l.tokens {
+			used += use.tokens
+		}
+		// The request is sent once enough of the oldest uses leave the window.
+		for _, use := range l.tokens {
+			if used < l.limit.TokensPerMinute {
+				break
+			}
+			used -= use.tokens
+			delay = max(delay, use.at.Sub(start))
+		}
+	}
+	if delay > 0 {
+		return delay
+	}
+	l.requests = append(l.requests, now)
+	return 0
+}
+
+// defaultMaxRetryAfter bounds the delays asked by the providers that are
+// honored when RetryConfig.MaxRetryAfter is not set.
+const defaultMaxRetryAfter = time.Minute
+
+// RetryDelayHint returns the delay before retrying a request asked by the
+// provider in its error, e.g. the Retry-After header of a 429 response or the
+// RetryAfter of an APIError, or 0 if there is none.
+func RetryDelayHint(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+	var openaiErr *openai.Error
+	if errors.As(err, &openaiErr) && openaiErr.Response != nil {
+		return parseRetryAfter(openaiErr.Response.Header)
+	}
+	return 0
+}
+
+// parseRetryAfter returns the delay of the retry-after-ms or Retry-After
+// headers of a response, or 0 if there is none.
+func parseRetryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	openai "github.com/openai/openai-go"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	l := &rateLimiter{limit: RateLimit{RequestsPerMinute: 2}, window: time.Minute}
+	for _, step := range []struct {
+		at   time.Duration
+		want time.Duration
+	}{
+		{at: 0, want: 0},
+		{at: 10 * time.Second, want: 0},
+		// The first request leaves the window at 60s.
+		{at: 20 * time.Second, want: 40 * time.Second},
+		{at: 60 * time.Second, want: 0},
+		{at: 65 * time.Second, want: 5 * time.Second},
+	} {
+		if got := l.reserve(t0.Add(step.at)); got != step.want {
+			t.Errorf("requests: reserve at %s = %s, want %s", step.at, got, step.want)
+		}
+	}
+
+	l = &rateLimiter{limit: RateLimit{TokensPerMinute: 1000}, window: time.Minute}
+	l.tokens = []tokenUse{{at: t0, tokens: 600}, {at: t0.Add(20 * time.Second), tokens: 300}}
+	if got := l.reserve(t0.Add(30 * time.Second)); got != 0 {
+		t.Errorf("tokens below the limit: reserve = %s, want 0", got)
+	}
+	l.tokens = append(l.tokens, tokenUse{at: t0.Add(30 * time.Second), tokens: 200})
+	// 1100 tokens were used, the first 600 leave the window at 60s.
+	if got := l.reserve(t0.Add(40 * time.Second)); got != 20*time.Second {
+		t.Errorf("tokens above the limit: reserve = %s, want 20s", got)
+	}
+
+	l = &rateLimiter{window: time.Minute, pausedUntil: t0.Add(30 * time.Second)}
+	if got := l.reserve(t0); got != 30*time.Second {
+		t.Errorf("paused: reserve = %s, want 30s", got)
+	}
+}
+
+func TestRetryDelayHint(t *testing.T) {
+	retryAfter := func(name, value string) error {
+		header := make(http.Header)
+		header.Set(name, value)
+		return fmt.Errorf("OpenAI chat completion failed: %w", &openai.Error{StatusCode: http.StatusTooManyRequests, Response: &http.Response{Header: header}})
+	}
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{name: "no hint", err: errors.New("connection reset"), want: 0},
+		{name: "api error", err: &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Second}, want: 3 * time.Second},
+		{name: "retry-after seconds", err: retryAfter("Retry-After", "20"), want: 20 * time.Second},
+		{name: "retry-after-ms", err: retryAfter("retry-after-ms", "1500"), want: 1500 * time.Millisecond},
+		{name: "retry-after invalid", err: retryAfter("Retry-After", "soon"), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RetryDelayHint(tt.err); got != tt.want {
+				t.Errorf("RetryDelayHint() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
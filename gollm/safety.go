@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SafetySetting is the threshold at which the safety filters of a provider
+// block the content of a harm category. Only Gemini and Vertex AI support it.
+type SafetySetting struct {
+	// Category is the harm category, e.g. "dangerous_content" or "HARM_CATEGORY_DANGEROUS_CONTENT".
+	Category string `json:"category"`
+	// Threshold is the block threshold, e.g. "block_only_high", "block_none" or "off".
+	Threshold string `json:"threshold"`
+}
+
+// WithSafetySettings sets the safety thresholds of the providers that support them.
+func WithSafetySettings(settings ...SafetySetting) Option {
+	return func(o *ClientOptions) {
+		o.SafetySettings = append(o.SafetySettings, settings...)
+	}
+}
+
+// normalize returns the setting with the category and threshold in the
+// upper case form of the APIs, e.g. "HARM_CATEGORY_DANGEROUS_CONTENT".
+func (s SafetySetting) normalize() SafetySetting {
+	category := strings.ToUpper(strings.TrimSpace(s.Category))
+	if !strings.HasPrefix(category, "HARM_CATEGORY_") {
+		category = "HARM_CATEGORY_" + category
+	}
+	return SafetySetting{Category: category, Threshold: strings.ToUpper(strings.TrimSpace(s.Threshold))}
+}
+
+// SafetyBlockedError is returned when the safety filters of the provider
+// blocked the prompt or the response, instead of an empty response.
+type SafetyBlockedError struct {
+	// Prompt is true if the prompt was blocked, false if the response was.
+	Prompt bool
+	// Reason is the reason reported by the provider, e.g. "SAFETY" or "PROHIBITED_CONTENT".
+	Reason string
+	// Categories are the harm categories that blocked the content, if reported.
+	Categories []string
+	// Message is the explanation of the provider, if any.
+	Message string
+}
+
+func (e *SafetyBlockedError) Error() string {
+	blocked := "response"
+	if e.Prompt {
+		blocked = "prompt"
+	}
+	s := fmt.Sprintf("the %s was blocked by the safety filters of the model (reason: %s", blocked, e.Reason)
+	if len(e.Categories) > 0 {
+		s += ", categories: " + strings.Join(e.Categories, ", ")
+	}
+	s += ")"
+	if e.Message != "" {
+		s += ": " + e.Message
+	}
+	return s + ". Rephrase the request, or raise the safety thresholds of these categories"
+}
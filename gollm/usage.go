@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Usage is the token usage of a response, independently of the provider.
+type Usage struct {
+	// Provider is the ID of the provider, e.g. "gemini".
+	Provider     string `json:"provider,omitempty"`
+	Model        string `json:"model,omitempty"`
+	InputTokens  int64  `json:"inputTokens"`
+	OutputTokens int64  `json:"outputTokens"`
+}
+
+// UsageCallback is called with the usage of the responses of a client, for
+// the providers reporting it.
+type UsageCallback func(ctx context.Context, usage Usage)
+
+// WithUsageCallback calls callback with the usage of every response of the
+// client. The usage of a streamed response is reported as it grows.
+func WithUsageCallback(callback UsageCallback) Option {
+	return func(o *ClientOptions) {
+		provider := ""
+		if o.URL != nil {
+			provider = o.URL.Scheme
+		}
+		o.Middlewares = append(o.Middlewares, NewUsageMiddleware(provider, callback))
+	}
+}
+
+// NewUsageMiddleware returns a middleware calling callback with the usage of
+// the responses of a client of provider.
+func NewUsageMiddleware(provider string, callback UsageCallback) Middleware {
+	return MiddlewareFuncs{
+		Response: func(ctx context.Context, req *Request, resp *Response) error {
+			var metadata any
+			switch {
+			case resp.Chat != nil:
+				metadata = resp.Chat.UsageMetadata()
+			case resp.Completion != nil:
+				metadata = resp.Completion.UsageMetadata()
+			}
+			input, output := ParseUsage(metadata)
+			// The chunks of a streamed response report its cumulative usage,
+			// only the growth since the previous chunk is new.
+			usage := Usage{
+				Provider:     provider,
+				Model:        req.Model,
+				InputTokens:  max(input-req.reportedUsage.InputTokens, 0),
+				OutputTokens: max(output-req.reportedUsage.OutputTokens, 0),
+			}
+			if usage.InputTokens == 0 && usage.OutputTokens == 0 {
+				return nil
+			}
+			req.reportedUsage.InputTokens = max(input, req.reportedUsage.InputTokens)
+			req.reportedUsage.OutputTokens = max(output, req.reportedUsage.OutputTokens)
+			callback(ctx, usage)
+			return nil
+		},
+	}
+}
+
+// UsageTokens returns the total tokens of the provider specific usage metadata
+// of a response, or 0 if the provider does not report it.
+func UsageTokens(usage any) int64 {
+	input, output := ParseUsage(usage)
+	return input + output
+}
+
+// ParseUsage returns the input and output tokens of the provider specific
+// usage metadata of a response, or 0 if the provider does not report them.
+// Tokens counted in the total but neither as input nor output, e.g. the
+// thoughts of Gemini, are output tokens.
+func ParseUsage(usage any) (input, output int64) {
+	if usage == nil {
+		return 0, 0
+	}
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return 0, 0
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return 0, 0
+	}
+	var total int64
+	for k, v := range fields {
+		n, ok := v.(float64)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.ReplaceAll(k, "_", "")) {
+		case "totaltokencount", "totaltokens":
+			total = int64(n)
+		case "prompttokencount", "prompttokens", "inputtokens", "promptevalcount":
+			input = int64(n)
+		case "candidatestokencount", "completiontokens", "outputtokens", "evalcount":
+			output = int64(n)
+		}
+	}
+	if total > input+output {
+		output = total - input
+	}
+	return input, output
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestUsageTokens(t *testing.T) {
+	type geminiUsage struct {
+		PromptTokenCount int32 `json:"promptTokenCount"`
+		TotalTokenCount  int32 `json:"totalTokenCount"`
+	}
+	type openAIUsage struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+	}
+	tests := []struct {
+		name  string
+		usage any
+		want  int64
+	}{
+		{name: "nil", usage: nil, want: 0},
+		{name: "gemini", usage: &geminiUsage{PromptTokenCount: 100, TotalTokenCount: 150}, want: 150},
+		{name: "input and output only", usage: openAIUsage{PromptTokens: 100, CompletionTokens: 20}, want: 120},
+		{name: "not a struct", usage: "unknown", want: 0},
+	}
+	for _, tt := range tests {
+		if got := UsageTokens(tt.usage); got != tt.want {
+			t.Errorf("%s: UsageTokens() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseUsage(t *testing.T) {
+	type geminiUsage struct {
+		PromptTokenCount     int32 `json:"promptTokenCount"`
+		CandidatesTokenCount int32 `json:"candidatesTokenCount"`
+		TotalTokenCount      int32 `json:"totalTokenCount"`
+	}
+	type bedrockUsage struct {
+		InputTokens  *int32
+		OutputTokens *int32
+	}
+	input, output := int32(30), int32(7)
+	tests := []struct {
+		name       string
+		usage      any
+		wantInput  int64
+		wantOutput int64
+	}{
+		{name: "nil", usage: nil},
+		{name: "gemini with thoughts", usage: &geminiUsage{PromptTokenCount: 100, CandidatesTokenCount: 20, TotalTokenCount: 150}, wantInput: 100, wantOutput: 50},
+		{name: "bedrock", usage: bedrockUsage{InputTokens: &input, OutputTokens: &output}, wantInput: 30, wantOutput: 7},
+		{name: "ollama", usage: map[string]any{"total_duration": 5000, "prompt_eval_count": 40, "eval_count": 9}, wantInput: 40, wantOutput: 9},
+	}
+	for _, tt := range tests {
+		gotInput, gotOutput := ParseUsage(tt.usage)
+		if gotInput != tt.wantInput || gotOutput != tt.wantOutput {
+			t.Errorf("%s: ParseUsage() = %d, %d, want %d, %d", tt.name, gotInput, gotOutput, tt.wantInput, tt.wantOutput)
+		}
+	}
+}
+
+func TestUsageMiddleware(t *testing.T) {
+	var reported []Usage
+	middleware := NewUsageMiddleware("gemini", func(_ context.Context, usage Usage) {
+		reported = append(reported, usage)
+	})
+	req := &Request{Kind: RequestKindChat, Model: "gemini-2.5-flash", Streaming: true}
+	// The chunks of the stream report the cumulative usage.
+	for _, usage := range []map[string]int{
+		{"prompt_tokens": 100, "completion_tokens": 5},
+		{"prompt_tokens": 100, "completion_tokens": 5},
+		{"prompt_tokens": 100, "completion_tokens": 12},
+		nil,
+	} {
+		var metadata any
+		if usage != nil {
+			metadata = usage
+		}
+		if err := middleware.OnResponse(context.Background(), req, &Response{Chat: fakeUsageResponse{metadata}}); err != nil {
+			t.Fatalf("OnResponse() error = %v", err)
+		}
+	}
+	want := []Usage{
+		{Provider: "gemini", Model: "gemini-2.5-flash", InputTokens: 100, OutputTokens: 5},
+		{Provider: "gemini", Model: "gemini-2.5-flash", OutputTokens: 7},
+	}
+	if !reflect.DeepEqual(reported, want) {
+		t.Errorf("reported usage = %+v, want %+v", reported, want)
+	}
+}
+
+type fakeUsageResponse struct{ usage any }
+
+func (r fakeUsageResponse) UsageMetadata() any      { return r.usage }
+func (r fakeUsageResponse) Candidates() []Candidate { return nil }
+
+func TestEstimateCost(t *testing.T) {
+	tests := []struct {
+		model  string
+		want   float64
+		wantOK bool
+	}{
+		{model: "gemini-2.5-flash", want: 0.30 + 2.50, wantOK: true},
+		{model: "gemini-2.5-flash-lite-preview-06-17", want: 0.10 + 0.40, wantOK: true},
+		{model: "models/gemini-2.5-pro", want: 1.25 + 10, wantOK: true},
+		{model: "us.anthropic.claude-sonnet-4-20250514-v1:0", want: 3 + 15, wantOK: true},
+		{model: "gpt-4o-mini-2024-07-18", want: 0.15 + 0.60, wantOK: true},
+		{model: "llama3.1:8b", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := EstimateCost(tt.model, 1_000_000, 1_000_000)
+		if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("EstimateCost(%q) = %v, %v, want %v, %v", tt.model, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// ErrResponseTimeout is the error of the responses that stalled or did not
+// complete in time, see WatchdogConfig.
+var ErrResponseTimeout = errors.New("the model did not respond in time")
+
+// WatchdogConfig bounds the time the responses of a chat take.
+type WatchdogConfig struct {
+	// TurnTimeout bounds the time of a whole response, 0 for no bound.
+	TurnTimeout time.Duration
+	// StallTimeout bounds the time without a chunk of a streamed response,
+	// including the time before the first chunk, 0 for no bound.
+	StallTimeout time.Duration
+	// MaxAttempts is the number of times a response timing out before its
+	// first chunk is requested. Responses timing out later are not retried,
+	// as their first chunks were already consumed.
+	MaxAttempts int
+	// OnRetry, if set, is called before a response is requested again.
+	OnRetry func(attempt int, err error)
+}
+
+// watchdogChat is a decorator aborting the responses of a chat that stall or
+// take too long, so that a wedged connection to the provider does not block
+// its caller forever.
+type watchdogChat struct {
+	Chat
+	config WatchdogConfig
+}
+
+// NewWatchdogChat wraps the chat so that its responses time out as configured.
+func NewWatchdogChat(chat Chat, config WatchdogConfig) Chat {
+	return &watchdogChat{Chat: chat, config: config}
+}
+
+func (c *watchdogChat) Send(ctx context.Context, contents ...any) (ChatResponse, error) {
+	if c.config.TurnTimeout <= 0 {
+		return c.Chat.Send(ctx, contents...)
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, c.config.TurnTimeout, c.turnTimeoutError())
+	defer cancel()
+	response, err := c.Chat.Send(ctx, contents...)
+	if err != nil && errors.Is(context.Cause(ctx), ErrResponseTimeout) {
+		return nil, context.Cause(ctx)
+	}
+	return response, err
+}
+
+// SendStreaming waits for the first chunk of the response, requesting it
+// again if it times out, and returns the chunks as they come. The chunks
+// timing out afterwards end the response with an ErrResponseTimeout.
+func (c *watchdogChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	for attempt := 1; ; attempt++ {
+		stream := c.start(ctx, contents)
+		first, ok, err := stream.next()
+		if err == nil {
+			if first.sendErr {
+				stream.cancel()
+				return nil, first.err
+			}
+			return stream.responses(first, ok), nil
+		}
+		stream.cancel()
+		if !errors.Is(err, ErrResponseTimeout) || attempt >= c.config.MaxAttempts {
+			return nil, err
+		}
+		klog.FromContext(ctx).Info("Requesting a response that timed out again", "attempt", attempt, "error", err)
+		if c.config.OnRetry != nil {
+			c.config.OnRetry(attempt+1, err)
+		}
+	}
+}
+
+func (c *watchdogChat) turnTimeoutError() error {
+	return fmt.Errorf("%w: no complete response within %s", ErrResponseTimeout, c.config.TurnTimeout)
+}
+
+// streamEvent is a chunk of a streamed response, or the error of SendStreaming.
+type streamEvent struct {
+	response ChatResponse
+	err      error
+	// sendErr is set for the error returned by SendStreaming.
+	sendErr bool
+}
+
+// watchedStream is a streamed response read in the background.
+type watchedStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	events chan streamEvent
+	stall  time.Duration
+}
+
+// start sends the contents and reads the response in the background. The
+// reads stop once the stream is canceled, provided the provider honors the
+// cancellation of the context.
+func (c *watchdogChat) start(ctx context.Context, contents []any) *watchedStream {
+	var cancel context.CancelFunc
+	if c.config.TurnTimeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, c.config.TurnTimeout, c.turnTimeoutError())
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	s := &watchedStream{ctx: ctx, cancel: cancel, events: make(chan streamEvent), stall: c.config.StallTimeout}
+	go func() {
+		defer close(s.events)
+		send := func(event streamEvent) bool {
+			select {
+			case s.events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		stream, err := c.Chat.SendStreaming(ctx, contents...)
+		if err != nil {
+			send(streamEvent{err: err, sendErr: true})
+			return
+		}
+		for response, err := range stream {
+			if !send(streamEvent{response: response, err: err}) {
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// next returns the next event of the stream, false once it ended, or the
+// error ending the wait: an ErrResponseTimeout, or the cause of the
+// cancellation of the context of the caller.
+func (s *watchedStream) next() (streamEvent, bool, error) {
+	var stalled <-chan time.Time
+	if s.stall > 0 {
+		timer := time.NewTimer(s.stall)
+		defer timer.Stop()
+		stalled = timer.C
+	}
+	select {
+	case event, ok := <-s.events:
+		if !ok && s.ctx.Err() != nil {
+			// The reads stopped as the context ended.
+			return streamEvent{}, false, context.Cause(s.ctx)
+		}
+		return event, ok, nil
+	case <-stalled:
+		return streamEvent{}, false, fmt.Errorf("%w: nothing received for %s", ErrResponseTimeout, s.stall)
+	case <-s.ctx.Done():
+		return streamEvent{}, false, context.Cause(s.ctx)
+	}
+}
+
+// responses returns the chunks of the stream, starting with its first event.
+func (s *watchedStream) responses(first streamEvent, ok bool) ChatResponseIterator {
+	return func(yield func(ChatResponse, error) bool) {
+		defer s.cancel()
+		event := first
+		for ok {
+			if !yield(event.response, event.err) {
+				return
+			}
+			var err error
+			if event, ok, err = s.next(); err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+	}
+}
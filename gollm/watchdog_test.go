@@ -0,0 +1,118 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gollm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type emptyResponse struct{}
+
+func (emptyResponse) UsageMetadata() any      { return nil }
+func (emptyResponse) Candidates() []Candidate { return nil }
+
+// stallingChat streams the chunks of its responses in turn, and stalls
+// before the first chunk of the first stalls responses and after the last
+// chunk of the others, until the context ends.
+type stallingChat struct {
+	Chat
+	stalls int
+	chunks int
+	// streams counts the calls of SendStreaming, made in the background.
+	streams atomic.Int32
+}
+
+func (c *stallingChat) SendStreaming(ctx context.Context, contents ...any) (ChatResponseIterator, error) {
+	stall := int(c.streams.Add(1)) <= c.stalls
+	return func(yield func(ChatResponse, error) bool) {
+		if !stall {
+			for i := 0; i < c.chunks; i++ {
+				if !yield(emptyResponse{}, nil) {
+					return
+				}
+			}
+		}
+		<-ctx.Done()
+		yield(nil, ctx.Err())
+	}, nil
+}
+
+func TestWatchdogChatSendStreaming(t *testing.T) {
+	tests := []struct {
+		name        string
+		stalls      int
+		maxAttempts int
+		// wantChunks is the number of chunks received, -1 if SendStreaming fails.
+		wantChunks  int
+		wantStreams int
+		wantRetries int
+	}{
+		{name: "stalls after the first chunks", stalls: 0, maxAttempts: 3, wantChunks: 2, wantStreams: 1},
+		{name: "retried until the first chunk", stalls: 2, maxAttempts: 3, wantChunks: 2, wantStreams: 3, wantRetries: 2},
+		{name: "attempts exhausted", stalls: 3, maxAttempts: 3, wantChunks: -1, wantStreams: 3, wantRetries: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			underlying := &stallingChat{stalls: tt.stalls, chunks: 2}
+			retries := 0
+			chat := NewWatchdogChat(underlying, WatchdogConfig{
+				StallTimeout: 20 * time.Millisecond,
+				MaxAttempts:  tt.maxAttempts,
+				OnRetry:      func(attempt int, err error) { retries++ },
+			})
+
+			chunks := -1
+			stream, err := chat.SendStreaming(context.Background(), "hello")
+			if err == nil {
+				chunks = 0
+				for response, err := range stream {
+					if err != nil {
+						if !errors.Is(err, ErrResponseTimeout) {
+							t.Errorf("stream error = %v, want an ErrResponseTimeout", err)
+						}
+						break
+					}
+					if response != nil {
+						chunks++
+					}
+				}
+			} else if !errors.Is(err, ErrResponseTimeout) {
+				t.Errorf("SendStreaming() error = %v, want an ErrResponseTimeout", err)
+			}
+			if chunks != tt.wantChunks || int(underlying.streams.Load()) != tt.wantStreams || retries != tt.wantRetries {
+				t.Errorf("got %d chunks, %d streams, %d retries, want %d, %d, %d", chunks, underlying.streams.Load(), retries, tt.wantChunks, tt.wantStreams, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestWatchdogChatTurnTimeout(t *testing.T) {
+	chat := NewWatchdogChat(&stallingChat{chunks: 1}, WatchdogConfig{TurnTimeout: 20 * time.Millisecond})
+	stream, err := chat.SendStreaming(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("SendStreaming() error = %v", err)
+	}
+	var lastErr error
+	for _, err := range stream {
+		lastErr = err
+	}
+	if !errors.Is(lastErr, ErrResponseTimeout) {
+		t.Errorf("stream ended with %v, want an ErrResponseTimeout", lastErr)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/journal"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"k8s.io/klog/v2"
+)
+
+// The choices of the approval prompt of the tool calls that modify resources.
+// The first three are kept stable for the UIs answering with y/n.
+const (
+	approvalChoiceYes = iota + 1
+	approvalChoiceYesDontAskAgain
+	approvalChoiceNo
+	approvalChoiceEdit
+	approvalChoiceSkip
+	approvalChoiceAlways
+)
+
+// approvalOptions returns the options of the approval prompt.
+func approvalOptions() []api.UserChoiceOption {
+	return []api.UserChoiceOption{
+		{Value: "yes", Label: "Yes"},
+		{Value: "yes_and_dont_ask_me_again", Label: "Yes, and don't ask me again"},
+		{Value: "no", Label: "No"},
+		{Value: "edit", Label: "Edit the command, then run it"},
+		{Value: "skip", Label: "Skip these commands, and run the others"},
+		{Value: "always", Label: "Always allow these commands in this session"},
+	}
+}
+
+// kubectlSubcommandVerbs are the kubectl verbs whose subcommand is part of
+// the command pattern, e.g. "kubectl rollout restart *".
+var kubectlSubcommandVerbs = map[string]bool{
+	"rollout":     true,
+	"set":         true,
+	"create":      true,
+	"config":      true,
+	"certificate": true,
+	"auth":        true,
+}
+
+// kubectlValueFlags are the kubectl global flags taking a separate value,
+// which must not be mistaken for the verb, e.g. "kubectl -n prod scale".
+var kubectlValueFlags = map[string]bool{
+	"-n":           true,
+	"--namespace":  true,
+	"--context":    true,
+	"--kubeconfig": true,
+	"--cluster":    true,
+	"--user":       true,
+}
+
+// commandPattern returns the pattern allowing a command for the rest of the
+// session, e.g. "kubectl scale *" for "kubectl scale deploy/web --replicas=3".
+// Compound commands are only allowed verbatim, as a wildcard could hide a
+// chained command.
+func commandPattern(command string) string {
+	command = strings.TrimSpace(command)
+	if strings.ContainsAny(command, "|&;<>`\n") || strings.Contains(command, "$(") {
+		return command
+	}
+
+	var words []string
+	fields := strings.Fields(command)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if strings.HasPrefix(field, "-") {
+			if kubectlValueFlags[field] {
+				i++
+			}
+			continue
+		}
+		words = append(words, field)
+		if len(words) == 2 && !(words[0] == "kubectl" && kubectlSubcommandVerbs[field]) || len(words) == 3 {
+			break
+		}
+	}
+	if len(words) < 2 {
+		return command
+	}
+	return strings.Join(words, " ") + " *"
+}
+
+// toolCallPattern returns the pattern allowing a tool call for the rest of the session.
+func toolCallPattern(call ToolCallAnalysis) string {
+	if command, ok := call.FunctionCall.Arguments["command"].(string); ok {
+		return commandPattern(command)
+	}
+	return call.FunctionCall.Name + "(*)"
+}
+
+// allowedBySession returns true if the user always allows the tool call in this session.
+func (c *Agent) allowedBySession(call ToolCallAnalysis) bool {
+	return slices.Contains(c.Session.AllowedCommands, toolCallPattern(call))
+}
+
+// needsApproval returns true if the policies require an approval of the tool
+// call, if the model misclassified it, or if it may modify resources and the
+// user did not allow it for the session. Destructive calls are never allowed
+// for the session.
+func (c *Agent) needsApproval(call ToolCallAnalysis) bool {
+	if call.PolicyDecision == PolicyRequireApproval || call.classificationMismatch() {
+		return true
+	}
+	return call.ModifiesResourceStr != "no" && (call.destructive() || !c.allowedBySession(call))
+}
+
+// allowForSession records the patterns of the pending tool calls needing
+// approval in the session, so that the user is not asked again.
+func (c *Agent) allowForSession(ctx context.Context) {
+	for _, call := range c.pendingFunctionCalls {
+		// The calls the policies require an approval of, the misclassified ones
+		// and the destructive ones are asked every time.
+		if !c.needsApproval(call) || call.PolicyDecision == PolicyRequireApproval || call.classificationMismatch() || call.destructive() {
+			continue
+		}
+		pattern := toolCallPattern(call)
+		c.Session.AllowedCommands = append(c.Session.AllowedCommands, pattern)
+		c.addMessage(api.MessageSourceAgent, api.MessageTypeText, fmt.Sprintf("Allowing `%s` for the rest of this session.", pattern))
+	}
+	c.saveSessionMetadata(ctx)
+}
+
+// destructiveVerbs are the kubectl verbs deleting resources, or evicting all the pods of a node.
+var destructiveVerbs = map[string]bool{
+	"delete": true,
+	"drain":  true,
+}
+
+// destructive returns true if the command of a kubectl or bash tool call
+// deletes resources, e.g. "kubectl delete namespace prod".
+func (call ToolCallAnalysis) destructive() bool {
+	if call.FunctionCall.Name != "kubectl" && call.FunctionCall.Name != "bash" {
+		return false
+	}
+	command, ok := call.FunctionCall.Arguments["command"].(string)
+	if !ok {
+		return false
+	}
+	commands, err := kubectl.Parse(command)
+	if err != nil {
+		return false
+	}
+	for _, cmd := range commands {
+		if destructiveVerbs[cmd.Verb] && !cmd.DryRun() {
+			return true
+		}
+	}
+	return false
+}
+
+// approvalPrompt returns the prompt asking the user to approve the pending
+// tool calls needing approval, flagging the destructive ones. The prompt of
+// a staged change shows its plan.
+func (c *Agent) approvalPrompt() string {
+	if len(c.staged) > 0 {
+		return stagedPlan(c.staged, c.pendingFunctionCalls) + "\nDo you want to proceed ?"
+	}
+	var lines []string
+	for _, call := range c.callsNeedingApproval() {
+		line := call.ParsedToolCall.Description()
+		if call.destructive() {
+			line += " (⚠️ deletes resources)"
+		}
+		lines = append(lines, line)
+	}
+	return "The following commands require your approval to run:\n* " + strings.Join(lines, "\n* ") + "\n\nDo you want to proceed ?"
+}
+
+// declineToolCalls reports the pending tool calls as declined by the user.
+// Every call gets a result, as the providers expect one per call.
+func (c *Agent) declineToolCalls(ctx context.Context) {
+	c.learnFromDecline(ctx, c.callsNeedingApproval())
+	for _, call := range c.pendingFunctionCalls {
+		result := map[string]any{
+			"error":     "User declined to run this operation.",
+			"status":    "declined",
+			"retryable": false,
+		}
+		if c.EnableToolUseShim {
+			c.currChatContent = append(c.currChatContent, fmt.Sprintf("Result of running %q:\n%v", call.FunctionCall.Name, result["error"]))
+			continue
+		}
+		c.currChatContent = append(c.currChatContent, gollm.FunctionCallResult{
+			ID:     call.FunctionCall.ID,
+			Name:   call.FunctionCall.Name,
+			Result: result,
+		})
+	}
+	c.pendingFunctionCalls = []ToolCallAnalysis{}
+	c.addMessage(api.MessageSourceAgent, api.MessageTypeError, "Operation was skipped. User declined to run this operation.")
+}
+
+// classificationMismatch returns true if the model claimed that the tool call
+// modifies resources, or does not, and the server-side dry run of the call,
+// or the tool if it was not dry run, classified it otherwise. A claim of
+// "unknown" is no claim, and the calls the tool cannot classify are not
+// verified.
+func (call ToolCallAnalysis) classificationMismatch() bool {
+	claimed := call.ClaimedModifiesResource
+	if claimed == "" || claimed == "unknown" || call.ModifiesResourceStr == "unknown" {
+		return false
+	}
+	if call.ServerModifiesResource != "" {
+		return claimed != call.ServerModifiesResource
+	}
+	return claimed != call.ModifiesResourceStr
+}
+
+// verifyClassification dry runs on the server the kubectl command of a tool
+// call the model and the tool classify differently, and records whether the
+// server would change objects: "no" if the objects of the dry run are the
+// current ones, e.g. for a scale to the current replicas, "yes" otherwise.
+// Nothing is recorded if the command cannot be dry run or its dry run fails.
+func (c *Agent) verifyClassification(ctx context.Context, call *ToolCallAnalysis) {
+	if call.ClaimedModifiesResource == "" || call.ClaimedModifiesResource == "unknown" || call.ClaimedModifiesResource == call.ModifiesResourceStr {
+		return
+	}
+	if call.FunctionCall.Name != "kubectl" && call.FunctionCall.Name != "bash" {
+		return
+	}
+	command, _ := call.FunctionCall.Arguments["command"].(string)
+	dryRun, ok := dryRunCommand(command)
+	if !ok {
+		return
+	}
+	commands, _ := kubectl.Parse(command)
+	cmd := commands[0]
+
+	log := klog.FromContext(ctx)
+	result, err := c.runKubectl(ctx, dryRun)
+	if err != nil || result.ExitCode != 0 || result.Error != "" {
+		log.Info("Not verifying the classification of the tool call, its dry run failed", "command", dryRun, "error", err)
+		return
+	}
+	call.ServerModifiesResource = "yes"
+	if !dryRunVerbs[cmd.Verb] {
+		// e.g. a delete, whose dry run succeeds if the objects exist.
+		return
+	}
+	objects, after := dryRunObjects(result.Stdout)
+	if len(objects) == 0 {
+		return
+	}
+	var flags string
+	if cmd.Context != "" {
+		flags = " --context " + tools.ShellQuote(cmd.Context)
+	}
+	for _, object := range objects {
+		get := "kubectl get " + tools.ShellQuote(object.resource) + namespaceFlag(object.namespace) + flags + " -o yaml --ignore-not-found"
+		current, err := c.runKubectl(ctx, get)
+		if err != nil || current.ExitCode != 0 || current.Error != "" || strings.TrimSpace(current.Stdout) == "" {
+			return
+		}
+		if cleanObject(current.Stdout) != after[object.resource] {
+			return
+		}
+	}
+	call.ServerModifiesResource = "no"
+}
+
+// recordClassificationMismatch logs a tool call the model misclassified, and
+// records it in the journal.
+func (c *Agent) recordClassificationMismatch(ctx context.Context, call ToolCallAnalysis) {
+	klog.FromContext(ctx).Info("The model misclassified a tool call", "command", call.ParsedToolCall.Description(),
+		"claimed", call.ClaimedModifiesResource, "modifiesResource", call.ModifiesResourceStr, "serverModifiesResource", call.ServerModifiesResource)
+	journal.RecorderFromContext(ctx).Write(ctx, &journal.Event{
+		Timestamp: time.Now(),
+		Action:    "classification-mismatch",
+		Payload: map[string]any{
+			"tool":             call.FunctionCall.Name,
+			"arguments":        call.FunctionCall.Arguments,
+			"claimed":          call.ClaimedModifiesResource,
+			"modifiesResource": call.ModifiesResourceStr,
+			"serverModifies":   call.ServerModifiesResource,
+		},
+	})
+}
+
+// saveSessionMetadata saves the policy and the preferences of the session.
+func (c *Agent) saveSessionMetadata(ctx context.Context) {
+	manager, err := sessions.NewSessionManager(c.SessionBackend)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "creating session manager to save the session")
+		return
+	}
+	if err := manager.UpdateLastAccessed(c.Session); err != nil {
+		klog.FromContext(ctx).Error(err, "saving the session")
+	}
+}
+
+// callsNeedingApproval returns the pending tool calls needing approval.
+func (c *Agent) callsNeedingApproval() []ToolCallAnalysis {
+	var calls []ToolCallAnalysis
+	for _, call := range c.pendingFunctionCalls {
+		if c.needsApproval(call) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// skipToolCallsNeedingApproval reports the pending tool calls needing approval
+// as skipped by the user, and keeps the other ones pending.
+func (c *Agent) skipToolCallsNeedingApproval(ctx context.Context) {
+	skipped := c.callsNeedingApproval()
+	var remaining []ToolCallAnalysis
+	for _, call := range c.pendingFunctionCalls {
+		if c.needsApproval(call) {
+			c.rejectToolCall(call, fmt.Errorf("the user skipped %q", call.ParsedToolCall.Description()))
+			continue
+		}
+		remaining = append(remaining, call)
+	}
+	c.pendingFunctionCalls = remaining
+	c.learnFromDecline(ctx, skipped)
+}
+
+// editToolCall replaces the command of the first pending tool call needing
+// approval with the command edited by the user. The other pending tool calls
+// are approved as they are.
+func (c *Agent) editToolCall(ctx context.Context, command string) error {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return errors.New("no edited command was received")
+	}
+	for i, call := range c.pendingFunctionCalls {
+		if !c.needsApproval(call) {
+			continue
+		}
+		if _, ok := call.FunctionCall.Arguments["command"].(string); !ok {
+			return fmt.Errorf("%q has no command to edit", call.ParsedToolCall.Description())
+		}
+		functionCall := call.FunctionCall
+		functionCall.Arguments = maps.Clone(call.FunctionCall.Arguments)
+		functionCall.Arguments["command"] = command
+		analysis, err := c.analyzeToolCalls(ctx, []gollm.FunctionCall{functionCall})
+		if err != nil {
+			return err
+		}
+		if analysis[0].IsInteractive {
+			return fmt.Errorf("the edited command %q is interactive", command)
+		}
+		c.pendingFunctionCalls[i] = analysis[0]
+		c.learnFromEdit(ctx, call.FunctionCall.Arguments["command"].(string), command)
+		return nil
+	}
+	return errors.New("no command needs approval")
+}
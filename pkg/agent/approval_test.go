@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+)
+
+func TestCommandPattern(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "kubectl scale deploy/web --replicas=3", want: "kubectl scale *"},
+		{command: "kubectl -n prod delete pod web-0", want: "kubectl delete *"},
+		{command: "kubectl --context=prod apply -f web.yaml", want: "kubectl apply *"},
+		{command: "kubectl rollout restart deployment/web", want: "kubectl rollout restart *"},
+		{command: "helm upgrade web ./chart", want: "helm upgrade *"},
+		{command: "kubectl delete pod web-0 && kubectl delete pod web-1", want: "kubectl delete pod web-0 && kubectl delete pod web-1"},
+		{command: "kubectl apply -f $(ls *.yaml)", want: "kubectl apply -f $(ls *.yaml)"},
+		{command: "reboot", want: "reboot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := commandPattern(tt.command); got != tt.want {
+				t.Errorf("commandPattern(%q) = %q, want %q", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassificationMismatch(t *testing.T) {
+	tests := []struct {
+		claimed, modifiesResource, server string
+		want                              bool
+	}{
+		{claimed: "", modifiesResource: "yes", want: false},
+		{claimed: "no", modifiesResource: "no", want: false},
+		{claimed: "no", modifiesResource: "yes", want: true},
+		{claimed: "yes", modifiesResource: "no", want: true},
+		{claimed: "unknown", modifiesResource: "no", want: false},
+		{claimed: "unknown", modifiesResource: "yes", want: false},
+		{claimed: "no", modifiesResource: "unknown", want: false},
+		{claimed: "no", modifiesResource: "yes", server: "yes", want: true},
+		{claimed: "no", modifiesResource: "yes", server: "no", want: false},
+	}
+	for _, tt := range tests {
+		call := ToolCallAnalysis{ClaimedModifiesResource: tt.claimed, ModifiesResourceStr: tt.modifiesResource, ServerModifiesResource: tt.server}
+		if got := call.classificationMismatch(); got != tt.want {
+			t.Errorf("classificationMismatch() with claimed %q, classified %q and dry run %q = %v, want %v", tt.claimed, tt.modifiesResource, tt.server, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyClassification(t *testing.T) {
+	deployment := func(replicas string) *sandbox.ExecResult {
+		return &sandbox.ExecResult{Stdout: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: shop\n  resourceVersion: \"42\"\nspec:\n  replicas: " + replicas + "\n"}
+	}
+	executor := &stagingExecutor{results: map[string]*sandbox.ExecResult{
+		"kubectl scale deploy/web --replicas=3 -n shop --dry-run=server": deployment("3"),
+		"kubectl scale deploy/web --replicas=1 -n shop --dry-run=server": deployment("1"),
+		"kubectl get 'deployment.v1.apps/web' --namespace 'shop'":        deployment("1"),
+		"kubectl delete pod web-0 -n shop --dry-run=server":              {ExitCode: 1, Stderr: `pods "web-0" not found`},
+	}}
+	a := &Agent{executor: executor}
+	a.Tools.Init()
+	a.Tools.RegisterTool(tools.NewBashTool(executor))
+
+	tests := []struct {
+		command, claimed string
+		wantServer       string
+		wantMismatch     bool
+	}{
+		// The model claims a change to the replicas is read-only.
+		{command: "kubectl scale deploy/web --replicas=3 -n shop", claimed: "no", wantServer: "yes", wantMismatch: true},
+		// The deployment already has one replica, the scale changes nothing.
+		{command: "kubectl scale deploy/web --replicas=1 -n shop", claimed: "no", wantServer: "no", wantMismatch: false},
+		// The dry run fails, the classification of the tool stands.
+		{command: "kubectl delete pod web-0 -n shop", claimed: "no", wantServer: "", wantMismatch: true},
+		// A claim of "unknown" is no claim, the call is not dry run.
+		{command: "kubectl scale deploy/web --replicas=3 -n shop", claimed: "unknown", wantServer: "", wantMismatch: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command+" "+tt.claimed, func(t *testing.T) {
+			executor.commands = nil
+			analysis, err := a.analyzeToolCalls(context.Background(), []gollm.FunctionCall{
+				{Name: "bash", Arguments: map[string]any{"command": tt.command, "modifies_resource": tt.claimed}},
+			})
+			if err != nil {
+				t.Fatalf("analyzeToolCalls() error = %v", err)
+			}
+			call := analysis[0]
+			if call.ServerModifiesResource != tt.wantServer || call.classificationMismatch() != tt.wantMismatch {
+				t.Errorf("dry run classified %q, mismatch = %v, want %q and %v (ran %q)", call.ServerModifiesResource, call.classificationMismatch(), tt.wantServer, tt.wantMismatch, executor.commands)
+			}
+			if tt.claimed == "unknown" && len(executor.commands) != 0 {
+				t.Errorf("ran %q, want no dry run", executor.commands)
+			}
+		})
+	}
+}
+
+func TestDestructive(t *testing.T) {
+	tests := []struct {
+		tool    string
+		command string
+		want    bool
+	}{
+		{tool: "kubectl", command: "kubectl delete namespace prod", want: true},
+		{tool: "kubectl", command: "kubectl -n prod delete pod web-0 --grace-period=0", want: true},
+		{tool: "bash", command: "kubectl get pods -o name | xargs kubectl delete", want: true},
+		{tool: "kubectl", command: "kubectl drain node-1 --ignore-daemonsets", want: true},
+		{tool: "kubectl", command: "kubectl delete pod web-0 --dry-run=server"},
+		{tool: "kubectl", command: "kubectl scale deploy/web --replicas=0"},
+		{tool: "remember", command: "kubectl delete is never run by this tool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			call := ToolCallAnalysis{FunctionCall: gollm.FunctionCall{Name: tt.tool, Arguments: map[string]any{"command": tt.command}}}
+			if got := call.destructive(); got != tt.want {
+				t.Errorf("destructive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
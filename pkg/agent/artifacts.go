@@ -0,0 +1,87 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// maxArtifactScanFiles bounds the files of the work directory compared before
+// and after each tool call to find its artifacts.
+const maxArtifactScanFiles = 1000
+
+// fileState is the state of a file of the work directory.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// WorkDir returns the working directory of the tool calls, where they write
+// their artifacts.
+func (c *Agent) WorkDir() string {
+	return c.workDir
+}
+
+// workDirFiles returns the state of the regular files of the work directory,
+// by absolute path. Hidden directories are skipped.
+func (c *Agent) workDirFiles() map[string]fileState {
+	files := make(map[string]fileState)
+	if c.workDir == "" {
+		return files
+	}
+	filepath.WalkDir(c.workDir, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return nil
+		case len(files) >= maxArtifactScanFiles:
+			return fs.SkipAll
+		case d.IsDir() && path != c.workDir && strings.HasPrefix(d.Name(), "."):
+			return fs.SkipDir
+		case !d.Type().IsRegular():
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return files
+}
+
+// newArtifacts returns the files of the work directory created or modified
+// since the before state was taken, sorted by name.
+func (c *Agent) newArtifacts(before map[string]fileState) []*api.ArtifactBlock {
+	var artifacts []*api.ArtifactBlock
+	for path, state := range c.workDirFiles() {
+		if previous, ok := before[path]; ok && previous.size == state.size && previous.modTime.Equal(state.modTime) {
+			continue
+		}
+		name, err := filepath.Rel(c.workDir, path)
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, &api.ArtifactBlock{Name: filepath.ToSlash(name), Path: path, Size: state.size})
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Name < artifacts[j].Name })
+	return artifacts
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestNewArtifacts(t *testing.T) {
+	workDir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(workDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("unchanged.yaml", "kind: Pod\n")
+	write("web.yaml", "kind: Pod\n")
+
+	c := &Agent{workDir: workDir}
+	before := c.workDirFiles()
+	write("logs/web-0.log", "started\n")
+	write("web.yaml", "kind: Deployment\n")
+	write(".cache/state", "hidden\n")
+
+	var got []string
+	for _, artifact := range c.newArtifacts(before) {
+		got = append(got, artifact.Name)
+		if artifact.Path != filepath.Join(workDir, filepath.FromSlash(artifact.Name)) {
+			t.Errorf("artifact %q has path %q, want it in the work directory", artifact.Name, artifact.Path)
+		}
+	}
+	if want := []string{"logs/web-0.log", "web.yaml"}; !slices.Equal(got, want) {
+		t.Errorf("newArtifacts() = %v, want %v", got, want)
+	}
+}
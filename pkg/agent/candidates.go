@@ -0,0 +1,188 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"k8s.io/klog/v2"
+)
+
+// The ways of selecting the final answer among its candidates.
+const (
+	// AnswerSelectionVote asks the model for the candidate most consistent with the others.
+	AnswerSelectionVote = "vote"
+	// AnswerSelectionPick asks the user to pick a candidate.
+	AnswerSelectionPick = "pick"
+)
+
+// maxAnswerPreviewChars bounds the preview of a candidate in the options of the choice.
+const maxAnswerPreviewChars = 60
+
+const answerPromptTemplate = `You are an agent operating a Kubernetes cluster on behalf of a user.
+
+Answer the last request of the user in the conversation below, from the facts established by the commands that were run. Reply with the answer only.
+
+Conversation:
+%s`
+
+const votePromptTemplate = `Several answers were sampled for the last request of the user in the conversation below.
+
+Select the answer most consistent with the other answers and with the facts of the conversation: the one stating most accurately the conclusion most answers agree on. Reply with the number of the answer only.
+
+Conversation:
+%s
+Answers:
+%s`
+
+// voteNumber matches the number of the answer in the reply to the vote prompt.
+var voteNumber = regexp.MustCompile(`\d+`)
+
+// sampleAnswers returns the candidates of the final answer: the answer of the
+// chat, followed by the ones sampled from the conversation. Providers sampling
+// several completions per request (n > 1) are asked for them at once, the
+// others once per candidate. Sampling errors are logged, the answers sampled
+// so far are kept.
+func (c *Agent) sampleAnswers(ctx context.Context, answer string) []string {
+	log := klog.FromContext(ctx)
+	answers := []string{answer}
+	prompt := fmt.Sprintf(answerPromptTemplate, c.answerTranscript())
+	for attempt := 1; attempt < c.AnswerCandidates && len(answers) < c.AnswerCandidates; attempt++ {
+		resp, err := c.LLM.GenerateCompletion(ctx, &gollm.CompletionRequest{
+			Model:          c.Model,
+			Prompt:         prompt,
+			CandidateCount: c.AnswerCandidates - len(answers),
+		})
+		if err != nil {
+			log.Error(err, "sampling candidate answers")
+			break
+		}
+		for _, candidate := range gollm.CompletionCandidates(resp) {
+			if candidate = strings.TrimSpace(candidate); candidate != "" && len(answers) < c.AnswerCandidates {
+				answers = append(answers, candidate)
+			}
+		}
+	}
+	return answers
+}
+
+// answerTranscript returns the conversation the candidate answers are sampled
+// from, starting with the summary of the messages compacted, if any.
+func (c *Agent) answerTranscript() string {
+	history := c.chatHistory()
+	var b strings.Builder
+	if c.budget.summary != "" && c.budget.summarized <= len(history) {
+		fmt.Fprintf(&b, "Summary of the earlier conversation:\n%s\n\n", c.budget.summary)
+		history = history[c.budget.summarized:]
+	}
+	b.WriteString(formatTranscript(history, maxCompactedOutputChars))
+	return b.String()
+}
+
+// voteAnswer returns the index of the candidate the model finds the most
+// consistent with the others, the first one if the vote fails.
+func (c *Agent) voteAnswer(ctx context.Context, answers []string) int {
+	var b strings.Builder
+	for i, answer := range answers {
+		fmt.Fprintf(&b, "Answer %d:\n%s\n\n", i+1, answer)
+	}
+	resp, err := c.LLM.GenerateCompletion(ctx, &gollm.CompletionRequest{
+		Model:  c.Model,
+		Prompt: fmt.Sprintf(votePromptTemplate, c.answerTranscript(), b.String()),
+	})
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "voting for the candidate answer")
+		return 0
+	}
+	return parseVote(resp.Response(), len(answers))
+}
+
+// parseVote returns the index of the answer numbered in the reply of the
+// model, 0 if the reply holds no valid number.
+func parseVote(reply string, count int) int {
+	n, err := strconv.Atoi(voteNumber.FindString(reply))
+	if err != nil || n < 1 || n > count {
+		return 0
+	}
+	return n - 1
+}
+
+// presentAnswers shows the candidates and asks the user to pick one. The
+// choice is handled by pickAnswer.
+func (c *Agent) presentAnswers(answers []string) {
+	c.pendingAnswers = answers
+	for i, answer := range answers {
+		c.addMessage(api.MessageSourceModel, api.MessageTypeText, fmt.Sprintf("**Answer %d**\n\n%s", i+1, answer))
+	}
+	c.setAgentState(api.AgentStateWaitingForInput)
+	c.addMessage(api.MessageSourceAgent, api.MessageTypeUserChoiceRequest, &api.UserChoiceRequest{
+		Prompt:  "Which answer do you want to keep?",
+		Options: answerOptions(answers),
+	})
+}
+
+// answerOptions returns the options of the choice of the answer, labeled with
+// the start of each answer.
+func answerOptions(answers []string) []api.UserChoiceOption {
+	options := make([]api.UserChoiceOption, len(answers))
+	for i, answer := range answers {
+		preview, _, _ := strings.Cut(strings.TrimSpace(answer), "\n")
+		if len(preview) > maxAnswerPreviewChars {
+			preview = strings.TrimSpace(preview[:maxAnswerPreviewChars]) + "..."
+		}
+		options[i] = api.UserChoiceOption{Value: strconv.Itoa(i + 1), Label: fmt.Sprintf("Answer %d: %s", i+1, preview)}
+	}
+	return options
+}
+
+// pickAnswer keeps the answer picked by the user and completes the task.
+func (c *Agent) pickAnswer(ctx context.Context, choice *api.UserChoiceResponse) {
+	answers := c.pendingAnswers
+	c.pendingAnswers = nil
+	index := choice.Choice - 1
+	if index < 0 || index >= len(answers) {
+		klog.FromContext(ctx).Error(nil, "Invalid answer choice", "choice", choice.Choice)
+		index = 0
+	}
+	c.keepAnswer(answers, index)
+	c.addMessage(api.MessageSourceAgent, api.MessageTypeText, fmt.Sprintf("Kept answer %d.", index+1))
+}
+
+// keepAnswer records the answer selected among the candidates. The chat only
+// holds the first candidate, so another answer is sent to the model with the
+// next query of the user.
+func (c *Agent) keepAnswer(answers []string, index int) {
+	c.selectedAnswer = ""
+	if index > 0 {
+		c.selectedAnswer = answers[index]
+	}
+}
+
+// withSelectedAnswer prefixes a query of the user with the answer selected
+// for the previous one, if it is not the answer the chat holds.
+func (c *Agent) withSelectedAnswer(query string) string {
+	if c.selectedAnswer == "" {
+		return query
+	}
+	answer := c.selectedAnswer
+	c.selectedAnswer = ""
+	return "The answer kept for my previous request, instead of yours, was:\n" + answer + "\n\n" + query
+}
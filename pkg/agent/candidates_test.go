@@ -0,0 +1,128 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/internal/mocks"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeCandidates is a completion sampling several candidates.
+type fakeCandidates []string
+
+func (r fakeCandidates) Response() string    { return r[0] }
+func (r fakeCandidates) UsageMetadata() any  { return nil }
+func (r fakeCandidates) Responses() []string { return r }
+
+func TestSampleAnswers(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []gollm.CompletionResponse
+		want      []string
+	}{
+		{
+			name:      "n > 1",
+			responses: []gollm.CompletionResponse{fakeCandidates{"b", "c"}},
+			want:      []string{"a", "b", "c"},
+		},
+		{
+			name:      "one candidate per request",
+			responses: []gollm.CompletionResponse{fakeCompletion("b"), fakeCompletion(" c\n")},
+			want:      []string{"a", "b", "c"},
+		},
+		{
+			name:      "more candidates than requested",
+			responses: []gollm.CompletionResponse{fakeCandidates{"b", "c", "d"}},
+			want:      []string{"a", "b", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			client := mocks.NewMockClient(ctrl)
+			a := &Agent{
+				LLM:              client,
+				Model:            "test-model",
+				AnswerCandidates: 3,
+				Session:          &api.Session{ChatMessageStore: sessions.NewInMemoryChatStore()},
+			}
+			for _, resp := range tt.responses {
+				client.EXPECT().GenerateCompletion(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *gollm.CompletionRequest) (gollm.CompletionResponse, error) {
+					if req.CandidateCount < 1 {
+						t.Errorf("CandidateCount = %d, want at least 1", req.CandidateCount)
+					}
+					return resp, nil
+				})
+			}
+			if got := a.sampleAnswers(context.Background(), "a"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sampleAnswers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVote(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  int
+	}{
+		{reply: "2", want: 1},
+		{reply: "Answer 3 is the most consistent.", want: 2},
+		{reply: "4", want: 0},
+		{reply: "0", want: 0},
+		{reply: "none", want: 0},
+	}
+	for _, tt := range tests {
+		if got := parseVote(tt.reply, 3); got != tt.want {
+			t.Errorf("parseVote(%q, 3) = %d, want %d", tt.reply, got, tt.want)
+		}
+	}
+}
+
+func TestAnswerOptions(t *testing.T) {
+	options := answerOptions([]string{
+		"The pod is crash looping.\nIts logs show a missing config map.",
+		"The image of the container cannot be pulled from the private registry.",
+	})
+	want := []api.UserChoiceOption{
+		{Value: "1", Label: "Answer 1: The pod is crash looping."},
+		{Value: "2", Label: "Answer 2: The image of the container cannot be pulled from the private..."},
+	}
+	if !reflect.DeepEqual(options, want) {
+		t.Errorf("answerOptions() = %+v, want %+v", options, want)
+	}
+}
+
+func TestWithSelectedAnswer(t *testing.T) {
+	a := &Agent{}
+	a.keepAnswer([]string{"first", "second"}, 1)
+	if got, want := a.withSelectedAnswer("next"), "The answer kept for my previous request, instead of yours, was:\nsecond\n\nnext"; got != want {
+		t.Errorf("withSelectedAnswer() = %q, want %q", got, want)
+	}
+	if got := a.withSelectedAnswer("again"); got != "again" {
+		t.Errorf("withSelectedAnswer() = %q, want the query only once the answer was sent", got)
+	}
+	a.keepAnswer([]string{"first", "second"}, 0)
+	if got := a.withSelectedAnswer("next"); got != "next" {
+		t.Errorf("withSelectedAnswer() = %q, want the query when the chat holds the answer", got)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"k8s.io/klog/v2"
+)
+
+// clusterFactsTimeout bounds the start of the session when the cluster is slow or unreachable.
+const clusterFactsTimeout = 20 * time.Second
+
+// collectClusterFacts gathers the fact sheet of the cluster for the system
+// prompt. The session starts without it when the cluster cannot be reached.
+func (c *Agent) collectClusterFacts(ctx context.Context) string {
+	ctx, cancel := context.WithTimeout(ctx, clusterFactsTimeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, tools.EnvKey, c.toolEnv())
+
+	facts, err := tools.CollectClusterFacts(ctx, c.executor, c.Kubeconfig, c.workDir)
+	if err != nil {
+		klog.Warningf("Failed to gather the cluster facts: %v", err)
+		return ""
+	}
+	if len(facts.Unavailable) > 0 {
+		klog.V(1).Infof("Cluster facts unavailable: %s", strings.Join(facts.Unavailable, ", "))
+	}
+	return facts.Markdown()
+}
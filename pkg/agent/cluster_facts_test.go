@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/internal/mocks"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"go.uber.org/mock/gomock"
+)
+
+// hangingExecutor runs no command, it blocks until the context is done, as
+// the commands of an unreachable cluster do.
+type hangingExecutor struct {
+	calls chan string
+}
+
+func (e *hangingExecutor) Execute(ctx context.Context, command string, _ []string, _ string) (*sandbox.ExecResult, error) {
+	e.calls <- command
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (e *hangingExecutor) Close(context.Context) error { return nil }
+
+func TestInitSkipsClusterFactsInRunOnceMode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockClient(ctrl)
+	chat := mocks.NewMockChat(ctrl)
+	client.EXPECT().StartChat(gomock.Any(), "test-model").Return(chat)
+	client.EXPECT().Close().Return(nil).AnyTimes()
+	chat.EXPECT().Initialize(gomock.Any()).Return(nil)
+	chat.EXPECT().SetFunctionDefinitions(gomock.Any()).Return(nil)
+
+	executor := &hangingExecutor{calls: make(chan string, 10)}
+	store := sessions.NewInMemoryChatStore()
+	a := &Agent{
+		LLM:           client,
+		Model:         "test-model",
+		Provider:      "mock",
+		Executor:      executor,
+		Kubeconfig:    filepath.Join(t.TempDir(), "kubeconfig"),
+		RemoveWorkDir: true,
+		InitialQuery:  "is the web pod running?",
+		RunOnce:       true,
+		ClusterFacts:  true,
+		Session: &api.Session{
+			ID:               "test-session",
+			ChatMessageStore: store,
+			AgentState:       api.AgentStateIdle,
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- a.Init(ctx) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		defer a.Close()
+	case command := <-executor.calls:
+		t.Fatalf("Init() ran %q in RunOnce mode", command)
+	case <-time.After(clusterFactsTimeout / 2):
+		t.Fatalf("Init() blocked on the hanging executor")
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// compactionThreshold is the fraction of the context window the history
+	// may use before it is compacted.
+	compactionThreshold = 0.8
+	// keepRecentMessages is the number of recent messages sent verbatim after
+	// a compaction, the older ones are summarized.
+	keepRecentMessages = 10
+	// maxCompactedOutputChars bounds the tool outputs of the recent messages
+	// sent after a compaction.
+	maxCompactedOutputChars = 4000
+	// charsPerToken estimates the tokens of a text when the provider does not
+	// report its usage.
+	charsPerToken = 4
+)
+
+const compactionPromptTemplate = `You are helping an agent that operates a Kubernetes cluster keep its conversation within the context window of the model.
+
+Summarize the conversation below for the agent, who will continue it from the summary alone. Keep the requests of the user, the facts learned about the cluster (names, namespaces, versions, errors), the actions taken and their outcome, and what remains to be done. Drop raw command outputs once their relevant facts are kept.
+%s
+Conversation:
+%s`
+
+// contextBudget tracks the tokens of the history sent to the model, and the
+// summary that replaces its older messages once it was compacted.
+type contextBudget struct {
+	// used is the number of tokens of the last request and its response.
+	used int64
+	// summary replaces the first summarized messages of the chat history.
+	summary    string
+	summarized int
+}
+
+// contextWindow returns the number of tokens the model accepts, reported by
+// the provider or read from the model capability registry unless
+// ContextWindow is set.
+func (c *Agent) contextWindow() int64 {
+	if c.ContextWindow != 0 {
+		return int64(c.ContextWindow)
+	}
+	if window := gollm.ContextWindow(c.LLM); window > 0 {
+		return int64(window)
+	}
+	return int64(gollm.LookupModelCapabilities(c.Model).ContextWindow)
+}
+
+// recordUsage records the tokens of a turn, as reported by the provider or
+// estimated from the contents sent and received.
+func (c *Agent) recordUsage(reported int64, sent []any, received string, calls []gollm.FunctionCall) {
+	if reported > 0 {
+		c.budget.used = reported
+		return
+	}
+	c.budget.used += estimateTokens(sent...) + estimateTokens(received) + estimateTokens(calls)
+}
+
+// compactHistoryIfNeeded compacts the history sent to the model if sending
+// the current contents would exceed the compaction threshold of its context window.
+func (c *Agent) compactHistoryIfNeeded(ctx context.Context) {
+	window := c.contextWindow()
+	if window < 0 {
+		return
+	}
+	needed := c.budget.used + estimateTokens(c.currChatContent...)
+	if float64(needed) <= compactionThreshold*float64(window) {
+		return
+	}
+	log := klog.FromContext(ctx)
+	log.Info("Compacting the chat history", "tokens", needed, "contextWindow", window)
+	if err := c.compactHistory(ctx); err != nil {
+		log.Error(err, "compacting the chat history")
+	}
+}
+
+// compactHistory summarizes the older messages of the history and restarts
+// the chat with the summary and the recent messages, whose tool outputs are
+// truncated. Restarting the chat works with every provider, including the
+// ones that cannot be initialized with a history. The session keeps the full history.
+func (c *Agent) compactHistory(ctx context.Context) error {
+	history := c.chatHistory()
+	if c.budget.summarized > len(history) {
+		// The history was cleared or replaced.
+		c.budget = contextBudget{}
+	}
+	messages := history[c.budget.summarized:]
+
+	older := len(messages) - keepRecentMessages
+	if older > 0 {
+		summary, err := c.summarizeHistory(ctx, messages[:older])
+		if err != nil {
+			return err
+		}
+		c.budget.summary = summary
+		c.budget.summarized += older
+		messages = messages[older:]
+	}
+
+	chat := c.newChat()
+	if !c.EnableToolUseShim {
+		if err := chat.SetFunctionDefinitions(c.functionDefinitions); err != nil {
+			return fmt.Errorf("setting function definitions: %w", err)
+		}
+	}
+	c.llmChat = chat
+
+	var b strings.Builder
+	b.WriteString("The conversation was compacted to fit the context window of the model.\n")
+	if c.budget.summary != "" {
+		fmt.Fprintf(&b, "\nSummary of the earlier conversation:\n%s\n", c.budget.summary)
+	}
+	fmt.Fprintf(&b, "\nMost recent messages:\n%s", formatTranscript(messages, maxCompactedOutputChars))
+
+	// Function results cannot be sent to the new chat, which has not seen
+	// the function calls, so the pending results are sent as text.
+	contents := []any{b.String()}
+	for _, content := range c.currChatContent {
+		if result, ok := content.(gollm.FunctionCallResult); ok {
+			content = fmt.Sprintf("Result of running %q:\n%s", result.Name, truncateOutput(formatPayload(result.Result), maxCompactedOutputChars))
+		}
+		contents = append(contents, content)
+	}
+	c.currChatContent = contents
+	c.budget.used = 0
+	return nil
+}
+
+// summarizeHistory asks the model to summarize messages, extending the
+// summary of the previous compaction.
+func (c *Agent) summarizeHistory(ctx context.Context, messages []*api.Message) (string, error) {
+	previous := ""
+	if c.budget.summary != "" {
+		previous = "\nSummary of the conversation before it:\n" + c.budget.summary + "\n"
+	}
+	resp, err := c.LLM.GenerateCompletion(ctx, &gollm.CompletionRequest{
+		Model:  c.Model,
+		Prompt: fmt.Sprintf(compactionPromptTemplate, previous, formatTranscript(messages, maxCompactedOutputChars)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("summarizing the chat history: %w", err)
+	}
+	summary := strings.TrimSpace(resp.Response())
+	if summary == "" {
+		return "", fmt.Errorf("summarizing the chat history: empty summary")
+	}
+	return summary, nil
+}
+
+// formatTranscript formats messages as text, one per paragraph, with their
+// tool outputs truncated to maxOutputChars.
+func formatTranscript(messages []*api.Message, maxOutputChars int) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		var text string
+		switch msg.Type {
+		case api.MessageTypeText, api.MessageTypeError:
+			text = formatPayload(msg.Payload)
+		case api.MessageTypeToolCallRequest:
+			text = "Running: " + formatPayload(msg.Payload)
+		case api.MessageTypeToolCallResponse:
+			text = "Result: " + truncateOutput(formatPayload(msg.Payload), maxOutputChars)
+		default:
+			// Prompts and choices are not part of the conversation with the model.
+			continue
+		}
+		fmt.Fprintf(&b, "[%s] %s\n\n", msg.Source, text)
+	}
+	return b.String()
+}
+
+func formatPayload(payload any) string {
+	if s, ok := payload.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Sprint(payload)
+	}
+	return string(b)
+}
+
+func truncateOutput(s string, maxChars int) string {
+	if len(s) <= maxChars {
+		return s
+	}
+	return fmt.Sprintf("%s\n[... %d characters truncated]", s[:maxChars], len(s)-maxChars)
+}
+
+// estimateTokens estimates the tokens of contents from the size of their text.
+func estimateTokens(contents ...any) int64 {
+	var chars int
+	for _, content := range contents {
+		chars += len(formatPayload(content))
+	}
+	return int64(chars / charsPerToken)
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/internal/mocks"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"go.uber.org/mock/gomock"
+)
+
+type fakeCompletion string
+
+func (r fakeCompletion) Response() string   { return string(r) }
+func (r fakeCompletion) UsageMetadata() any { return nil }
+
+func TestCompactHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockClient(ctrl)
+	chat := mocks.NewMockChat(ctrl)
+
+	store := sessions.NewInMemoryChatStore()
+	for i := range 15 {
+		message := &api.Message{ID: fmt.Sprint(i), Source: api.MessageSourceAgent, Type: api.MessageTypeToolCallResponse, Payload: fmt.Sprintf("output %d %s", i, strings.Repeat("x", 5000))}
+		if err := store.AddChatMessage(message); err != nil {
+			t.Fatalf("adding message: %v", err)
+		}
+	}
+	a := &Agent{
+		LLM:                 client,
+		Model:               "test-model",
+		ContextWindow:       20_000,
+		budget:              contextBudget{used: 19_000},
+		Session:             &api.Session{ChatMessageStore: store},
+		functionDefinitions: []*gollm.FunctionDefinition{{Name: "kubectl"}},
+		currChatContent:     []any{gollm.FunctionCallResult{Name: "kubectl", Result: map[string]any{"stdout": "pod-1"}}},
+	}
+
+	client.EXPECT().GenerateCompletion(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *gollm.CompletionRequest) (gollm.CompletionResponse, error) {
+		if !strings.Contains(req.Prompt, "output 4 ") || strings.Contains(req.Prompt, "output 5 ") {
+			t.Errorf("summary prompt does not hold the 5 older messages:\n%s", req.Prompt)
+		}
+		return fakeCompletion("The user is debugging pod-1."), nil
+	})
+	client.EXPECT().StartChat(gomock.Any(), "test-model").Return(chat)
+	chat.EXPECT().SetFunctionDefinitions(a.functionDefinitions).Return(nil)
+
+	a.compactHistoryIfNeeded(context.Background())
+
+	if a.budget.summarized != 5 || a.budget.summary != "The user is debugging pod-1." {
+		t.Errorf("budget = %+v, want 5 messages summarized", a.budget)
+	}
+	if len(a.currChatContent) != 2 {
+		t.Fatalf("currChatContent = %v, want the compacted history and the pending result", a.currChatContent)
+	}
+	compacted := a.currChatContent[0].(string)
+	for _, want := range []string{"The user is debugging pod-1.", "output 5 ", "output 14 ", "characters truncated"} {
+		if !strings.Contains(compacted, want) {
+			t.Errorf("compacted history does not contain %q", want)
+		}
+	}
+	if result, ok := a.currChatContent[1].(string); !ok || !strings.Contains(result, "pod-1") {
+		t.Errorf("pending function result = %v, want it as text", a.currChatContent[1])
+	}
+
+	// The history fits the context window again.
+	a.compactHistoryIfNeeded(context.Background())
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		contents []any
+		want     int64
+	}{
+		{contents: nil, want: 0},
+		{contents: []any{"12345678"}, want: 2},
+		{contents: []any{"1234", map[string]any{"a": "b"}}, want: 3},
+	}
+	for _, tt := range tests {
+		if got := estimateTokens(tt.contents...); got != tt.want {
+			t.Errorf("estimateTokens(%v) = %d, want %d", tt.contents, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+)
+
+// contextsReport lists the contexts of the kubeconfig, the current one first.
+func contextsReport(contexts []tools.KubeContext) string {
+	if len(contexts) == 0 {
+		return "The kubeconfig has no contexts."
+	}
+	var current, others []string
+	for _, context := range contexts {
+		line := fmt.Sprintf("`%s`: cluster %s, namespace %s", context.Name, context.Cluster, context.Namespace)
+		if context.Current {
+			current = append(current, line+" (current)")
+		} else {
+			others = append(others, line)
+		}
+	}
+	return "Kubeconfig contexts:\n\n  - " + strings.Join(append(current, others...), "\n  - ") +
+		"\n\nCommands run in the current context, unless you name another one, e.g. \"list the nodes of prod\".\n\n"
+}
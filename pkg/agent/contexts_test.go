@@ -0,0 +1,36 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+)
+
+func TestContextsReport(t *testing.T) {
+	got := contextsReport([]tools.KubeContext{
+		{Name: "prod", Cluster: "gke-prod", Namespace: "apps"},
+		{Name: "staging", Cluster: "gke-staging", Namespace: "default", Current: true},
+	})
+	want := "  - `staging`: cluster gke-staging, namespace default (current)\n  - `prod`: cluster gke-prod, namespace apps\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("contextsReport() = %q, want the current context first:\n%s", got, want)
+	}
+	if got := contextsReport(nil); got != "The kubeconfig has no contexts." {
+		t.Errorf("contextsReport(nil) = %q", got)
+	}
+}
@@ -18,18 +18,21 @@ import (
 	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"os"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/incidents"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/journal"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/mcp"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
@@ -42,6 +45,9 @@ import (
 //go:embed systemprompt_template_default.txt
 var defaultSystemPromptTemplate string
 
+// asyncToolPollInterval is the interval at which the progress of async tool calls is shown.
+const asyncToolPollInterval = 2 * time.Second
+
 type Agent struct {
 	// Input is the channel to receive user input.
 	Input chan any
@@ -70,6 +76,15 @@ type Agent struct {
 	// currIteration tracks the current iteration of the agentic loop.
 	currIteration int
 
+	// interruptMu protects runCtx and interrupt.
+	interruptMu sync.Mutex
+	// runCtx is the context of the model and tool calls, canceled by Interrupt.
+	runCtx    context.Context
+	interrupt context.CancelCauseFunc
+	// interruptedContent is the chat content of an interrupted run, sent to
+	// the LLM with the next query.
+	interruptedContent []any
+
 	LLM gollm.Client
 
 	// PromptTemplateFile allows specifying a custom template file
@@ -80,33 +95,209 @@ type Agent struct {
 	Model            string
 	Provider         string
 
+	// ToolArgsRepairModel is the model used to repair malformed tool call arguments.
+	// If empty, Model is used.
+	ToolArgsRepairModel string
+
 	RemoveWorkDir bool
 
 	MaxIterations int
 
+	// ContextWindow is the number of tokens the model accepts. The history
+	// sent to the model is compacted before it would exceed it. It is guessed
+	// from the model if 0, and compaction is disabled if negative.
+	ContextWindow int
+
+	// budget tracks the tokens of the history sent to the model.
+	budget contextBudget
+
+	// MaxAPICallsPerRun caps the number of cluster API calls (kubectl invocations)
+	// the agent can make while answering a single query. 0 means unlimited.
+	MaxAPICallsPerRun int
+
+	// MaxToolOutputBytes truncates the tool outputs sent to the model beyond
+	// this size. The full outputs are stored in the work directory, where the
+	// model reads them with the read_output tool. 0 means never truncate.
+	MaxToolOutputBytes int
+
+	// BatchKubectlQueries enables merging related read-only kubectl get calls
+	// requested in the same turn into a single invocation.
+	BatchKubectlQueries bool
+
+	// apiCallBudget tracks the API calls of the current run.
+	apiCallBudget *tools.APICallBudget
+
+	// ReadCacheTTL is the time identical kubectl reads of the session are
+	// served from the read cache, e.g. "kubectl get ns". 0 disables the cache.
+	ReadCacheTTL time.Duration
+	// readCache is invalidated whenever a tool call may modify resources.
+	readCache *tools.ReadCache
+
 	// Kubeconfig is the path to the kubeconfig file.
 	Kubeconfig string
+
+	// Namespace is the default namespace of the session, set with --namespace
+	// or the ns command, to which Kubeconfig is scoped. The model is told
+	// about it, so that it does not guess namespaces.
+	Namespace string
+	// namespaceChanged is set when the ns command changed the namespace,
+	// until the model is told with the next query.
+	namespaceChanged bool
+
+	// MemoryFile is the markdown file holding the long-term memory of the
+	// cluster, loaded into the prompt and extended by the remember tool.
+	// Memory is disabled if empty.
+	MemoryFile string
+
+	// ClusterFacts gathers a fact sheet of the cluster (version, nodes,
+	// namespaces, CRD groups, default storage class) at the start of the
+	// session for the system prompt, so that the model does not discover
+	// these facts again in every conversation. It is not gathered in RunOnce mode.
+	ClusterFacts bool
+
+	// Alertmanager is the Alertmanager service queried by the alerts tool, as
+	// namespace/name:port. It is discovered in the cluster if empty.
+	Alertmanager string
+
+	// Loki configures the Loki instance of the loki_query tool, which is
+	// only given to the model when set.
+	Loki *tools.LokiConfig
+
+	// Incident is the incident imported with --incident-id, whose title, notes
+	// and recent alerts are part of the system prompt.
+	Incident *incidents.Incident
+
+	// Env holds session-scoped environment variables (e.g. HELM_NAMESPACE, AWS_PROFILE)
+	// that are injected into every tool subprocess.
+	Env map[string]string
+
 	// Sandbox indicates whether to execute tools in a sandbox environment
 	Sandbox string
 
 	// SandboxImage is the container image to use for the sandbox
 	SandboxImage string
 
+	// Executor, if set, executes the tools instead of the local executor when
+	// Sandbox is empty, e.g. to replay canned tool outputs.
+	Executor sandbox.Executor
+
 	SkipPermissions bool
 
+	// EchoCommands shows the exact command and a summary of the environment
+	// of every tool call before it runs, for a complete command transcript.
+	EchoCommands bool
+
+	// PreToolHooks are shell commands run before each tool call, with the call
+	// as JSON on their stdin. A hook exiting with a non-zero status vetoes the call.
+	PreToolHooks []string
+	// PostToolHooks are shell commands run after each tool call, with the call
+	// and its result as JSON on their stdin.
+	PostToolHooks []string
+
+	// Policy evaluates each tool call against Rego policies, which may deny it
+	// or require the approval of the user. Nil if no policies are configured.
+	Policy *PolicyEvaluator
+
+	// KubectlPolicy allows, denies or asks to confirm the kubectl calls of the
+	// kubectl and bash tools by verb, resource and namespace. Nil if not configured.
+	KubectlPolicy *tools.KubectlPolicy
+
+	// KubectlTool is the kubectl tool given to the model: tools.KubectlToolQuoted
+	// for the calls given as fields, a command line run by the shell otherwise. The
+	// tool use shim always uses the command line.
+	KubectlTool string
+
+	// ReadOnly refuses the tool calls that modify or may modify resources,
+	// instead of asking for permission to run them.
+	ReadOnly bool
+
+	// AirGapped denies the tool calls that may send data out of the host,
+	// see tools.CheckEgress, and the calls of MCP tools.
+	AirGapped bool
+
+	// DryRun replaces the kubectl calls that modify resources with their
+	// server-side dry run, skips the other tool calls that modify resources,
+	// and presents the plan of the skipped changes at the end of each task.
+	DryRun bool
+	// plan holds the tool calls not applied in dry-run mode during the current task.
+	plan []plannedStep
+
+	// Maintenance refuses the tool calls that modify resources outside of the
+	// maintenance windows or during a change freeze. Nil if not configured.
+	Maintenance *MaintenancePolicy
+
+	// StageChanges stages the tool calls of a turn modifying several
+	// resources: their diffs are shown in a single approval, and the applied
+	// steps are rolled back if a later step fails.
+	StageChanges bool
+	// staged are the steps of the change staged for the pending tool calls.
+	staged []stagedStep
+
+	// AnswerCandidates is the number of candidates sampled for the final
+	// answer of a task, selected according to AnswerSelection. A single
+	// answer is generated if it is less than 2.
+	AnswerCandidates int
+	// AnswerSelection is how the final answer is selected among its
+	// candidates, AnswerSelectionVote (the default) or AnswerSelectionPick.
+	AnswerSelection string
+	// pendingAnswers are the candidates of the answer the user is asked to pick from.
+	pendingAnswers []string
+	// selectedAnswer is the answer selected for the last query, when the chat holds another candidate.
+	selectedAnswer string
+
 	Tools tools.Tools
 
+	// EnabledTools are the patterns of the names of the tools given to the
+	// model, all if empty. DisabledTools are the patterns of the tools withheld
+	// from it, see tools.Tools.Select.
+	EnabledTools  []string
+	DisabledTools []string
+
+	// BashAllowlist are the programs the shell commands of the tool calls may
+	// run, all if empty, see tools.CheckAllowedPrograms.
+	BashAllowlist []string
+
 	EnableToolUseShim bool
 
+	// Deterministic disables the randomized retry jitter, for reproducible runs.
+	// Deterministic sampling is configured on the LLM client.
+	Deterministic bool
+
+	// LLMTurnTimeout bounds the time of a response of the model, 0 for no bound.
+	LLMTurnTimeout time.Duration
+	// LLMStallTimeout bounds the time without a chunk of a streamed response
+	// of the model, 0 for no bound. The responses stalling before their first
+	// chunk are requested again.
+	LLMStallTimeout time.Duration
+
 	// MCPClientEnabled indicates whether MCP client mode is enabled
 	MCPClientEnabled bool
 
 	// Recorder captures events for diagnostics
 	Recorder journal.Recorder
+	// TracePath is the file of the journal, quoted in the issues generated by /report-issue.
+	TracePath string
+
+	// IssueReportThreshold is the number of occurrences of the same provider
+	// error after which the user is offered a pre-filled GitHub issue. 0 disables the offer.
+	IssueReportThreshold int
+	// providerFailures are the provider errors of the session, by signature.
+	providerFailures map[string]*providerFailure
+	// lastFailure is the last provider error, reported by /report-issue.
+	lastFailure *providerFailure
+	// historyRepaired is set once the chat history rejected by the provider
+	// was repaired, until the next response, see repairHistory.
+	historyRepaired bool
 
 	llmChat gollm.Chat
 
+	// systemPrompt and functionDefinitions start new chats when the history is compacted.
+	systemPrompt        string
+	functionDefinitions []*gollm.FunctionDefinition
+
 	workDir string
+	// previousWorkDirs are the work directories of the sessions forked from, see ForkSession.
+	previousWorkDirs []string
 
 	// executor is the executor for tool execution
 	executor sandbox.Executor
@@ -133,6 +324,10 @@ type Agent struct {
 	// lastErr is the most recent error run into, for use across the stack
 	lastErr error
 
+	// lastToolResult is the full result of the last tool call other than
+	// extract, which the extract tool evaluates its expressions against.
+	lastToolResult any
+
 	// cancel is the function to cancel the agent's context
 	cancel context.CancelFunc
 }
@@ -152,17 +347,24 @@ func (s *Agent) GetSession() *api.Session {
 	return &sessionCopy
 }
 
+// chatHistory returns the messages of the session with the payloads stored as
+// attachments loaded back, as the model needs the full tool outputs.
+func (c *Agent) chatHistory() []*api.Message {
+	return sessions.ResolveAttachments(c.Session.ChatMessageStore, c.Session.ChatMessageStore.ChatMessages())
+}
+
 // addMessage creates a new message, adds it to the session, and sends it to the output channel
 func (c *Agent) addMessage(source api.MessageSource, messageType api.MessageType, payload any) *api.Message {
+	return c.postMessage(&api.Message{Source: source, Type: messageType, Payload: payload})
+}
+
+// postMessage sets the ID and the timestamp of a message, adds it to the
+// session, and sends it to the output channel.
+func (c *Agent) postMessage(message *api.Message) *api.Message {
 	c.sessionMu.Lock()
 	defer c.sessionMu.Unlock()
-	message := &api.Message{
-		ID:        uuid.New().String(),
-		Source:    source,
-		Type:      messageType,
-		Payload:   payload,
-		Timestamp: time.Now(),
-	}
+	message.ID = uuid.New().String()
+	message.Timestamp = time.Now()
 
 	// session should always have a ChatMessageStore at this point
 	c.Session.ChatMessageStore.AddChatMessage(message)
@@ -201,6 +403,9 @@ func (s *Agent) Init(ctx context.Context) error {
 	s.Input = make(chan any, 10)
 	s.Output = make(chan any, 10)
 	s.currIteration = 0
+	if s.ReadCacheTTL > 0 {
+		s.readCache = tools.NewReadCache(s.ReadCacheTTL)
+	}
 	// when we support session, we will need to initialize this with the
 	// current history of the conversation.
 	s.currChatContent = []any{}
@@ -267,8 +472,11 @@ func (s *Agent) Init(ctx context.Context) error {
 		log.Info("Using Seatbelt executor")
 
 	case "":
-		// No sandbox, use local executor
-		s.executor = sandbox.NewLocalExecutor()
+		// No sandbox, use the provided executor or the local executor
+		s.executor = s.Executor
+		if s.executor == nil {
+			s.executor = sandbox.NewLocalExecutor()
+		}
 
 	default:
 		return fmt.Errorf("unknown sandbox type: %s", s.Sandbox)
@@ -280,48 +488,77 @@ func (s *Agent) Init(ctx context.Context) error {
 	// We clone existing tools (e.g. custom tools) to ensure we have a fresh map
 	// This avoids polluting the global default tools and ensures thread safety.
 	s.Tools = s.Tools.CloneWithExecutor(s.executor)
+	s.registerBuiltinTools()
+
+	var memory string
+	if s.MemoryFile != "" {
+		s.Tools.RegisterTool(tools.NewRememberTool(s.MemoryFile))
+		memory, err = tools.LoadMemory(s.MemoryFile)
+		if err != nil {
+			klog.Warningf("Failed to load the memory: %v", err)
+		}
+	}
+
+	// The tools of the MCP servers are exposed to the LLM and listed in the
+	// system prompt alongside the built-in tools.
+	if s.MCPClientEnabled {
+		if err := s.InitializeMCPClient(ctx); err != nil {
+			klog.Errorf("Failed to initialize MCP client: %v", err)
+			return fmt.Errorf("failed to initialize MCP client: %w", err)
+		}
+
+		// Update MCP status in session
+		if err := s.UpdateMCPStatus(ctx, s.MCPClientEnabled); err != nil {
+			klog.Warningf("Failed to update MCP status: %v", err)
+		}
+	}
+
+	if err := s.Tools.Select(s.EnabledTools, s.DisabledTools); err != nil {
+		return err
+	}
+	klog.V(1).Infof("Tools given to the model: %s", strings.Join(s.Tools.Names(), ", "))
+
+	if !s.EnableToolUseShim && !gollm.LookupModelCapabilities(s.Model).NativeToolCalls {
+		klog.Warningf("Model %s may not support native tool calls, consider --enable-tool-use-shim", s.Model)
+	}
 
-	s.Tools.RegisterTool(tools.NewBashTool(s.executor))
-	s.Tools.RegisterTool(tools.NewKubectlTool(s.executor))
+	// A missing kubeconfig is reported by the commands that need it.
+	contexts, _ := tools.KubeContexts(s.Kubeconfig)
+
+	// A single query would wait for the fact sheet, up to clusterFactsTimeout
+	// when the cluster is unreachable, for little gain.
+	var clusterFacts string
+	if s.ClusterFacts && !s.RunOnce {
+		clusterFacts = s.collectClusterFacts(ctx)
+	}
 
 	systemPrompt, err := s.generatePrompt(ctx, defaultSystemPromptTemplate, PromptData{
 		Tools:             s.Tools,
 		EnableToolUseShim: s.EnableToolUseShim,
 		// RunOnce is a good proxy to indicate the agentic session is non-interactive mode.
 		SessionIsInteractive: !s.RunOnce,
+		DryRun:               s.DryRun,
+		Memory:               memory,
+		ClusterFacts:         clusterFacts,
+		Incident:             s.Incident.Markdown(),
+		Namespace:            s.Namespace,
+		Contexts:             contexts,
 	})
 	if err != nil {
 		return fmt.Errorf("generating system prompt: %w", err)
 	}
 
+	// Track the token usage of the session, whatever the provider.
+	s.LLM = gollm.NewMiddlewareClient(s.LLM, gollm.NewUsageMiddleware(providerName(s.Provider), s.addUsage))
+
 	// Start a new chat session
-	s.llmChat = gollm.NewRetryChat(
-		s.LLM.StartChat(systemPrompt, s.Model),
-		gollm.RetryConfig{
-			MaxAttempts:    3,
-			InitialBackoff: 10 * time.Second,
-			MaxBackoff:     60 * time.Second,
-			BackoffFactor:  2,
-			Jitter:         true,
-		},
-	)
-	err = s.llmChat.Initialize(s.Session.ChatMessageStore.ChatMessages())
+	s.systemPrompt = systemPrompt
+	s.llmChat = s.newChat()
+	err = s.llmChat.Initialize(s.chatHistory())
 	if err != nil {
 		return fmt.Errorf("initializing chat session: %w", err)
 	}
 
-	if s.MCPClientEnabled {
-		if err := s.InitializeMCPClient(ctx); err != nil {
-			klog.Errorf("Failed to initialize MCP client: %v", err)
-			return fmt.Errorf("failed to initialize MCP client: %w", err)
-		}
-
-		// Update MCP status in session
-		if err := s.UpdateMCPStatus(ctx, s.MCPClientEnabled); err != nil {
-			klog.Warningf("Failed to update MCP status: %v", err)
-		}
-	}
-
 	if !s.EnableToolUseShim {
 		var functionDefinitions []*gollm.FunctionDefinition
 		for _, tool := range s.Tools.AllTools() {
@@ -331,6 +568,7 @@ func (s *Agent) Init(ctx context.Context) error {
 		sort.Slice(functionDefinitions, func(i, j int) bool {
 			return functionDefinitions[i].Name < functionDefinitions[j].Name
 		})
+		s.functionDefinitions = functionDefinitions
 		if err := s.llmChat.SetFunctionDefinitions(functionDefinitions); err != nil {
 			return fmt.Errorf("setting function definitions: %w", err)
 		}
@@ -339,11 +577,74 @@ func (s *Agent) Init(ctx context.Context) error {
 	return nil
 }
 
+// newChat starts a chat session with the system prompt, retrying the requests
+// that fail with a retryable error, and aborting the responses that stall or
+// take too long, see gollm.NewWatchdogChat.
+func (c *Agent) newChat() gollm.Chat {
+	const maxAttempts = 3
+	chat := gollm.NewRetryChat(
+		c.LLM.StartChat(c.systemPrompt, c.Model),
+		gollm.RetryConfig{
+			MaxAttempts:    maxAttempts,
+			InitialBackoff: 10 * time.Second,
+			MaxBackoff:     60 * time.Second,
+			BackoffFactor:  2,
+			Jitter:         !c.Deterministic,
+		},
+	)
+	if c.LLMTurnTimeout <= 0 && c.LLMStallTimeout <= 0 {
+		return chat
+	}
+	return gollm.NewWatchdogChat(chat, gollm.WatchdogConfig{
+		TurnTimeout:  c.LLMTurnTimeout,
+		StallTimeout: c.LLMStallTimeout,
+		MaxAttempts:  maxAttempts,
+		OnRetry: func(attempt int, err error) {
+			c.addMessage(api.MessageSourceAgent, api.MessageTypeText, fmt.Sprintf("Retrying the request to the model (attempt %d of %d): %v.", attempt, maxAttempts, err))
+		},
+	})
+}
+
+// registerBuiltinTools registers the built-in tools, bound to the agent's executor.
+func (c *Agent) registerBuiltinTools() {
+	c.Tools.RegisterTool(tools.NewBashTool(c.executor))
+	// The action format of the tool use shim only has a command line.
+	if c.KubectlTool != tools.KubectlToolQuoted || c.EnableToolUseShim {
+		c.Tools.RegisterTool(tools.NewKubectlTool(c.executor, c.KubectlPolicy))
+	} else {
+		c.Tools.RegisterTool(tools.NewQuotedKubectlTool(c.executor, c.KubectlPolicy))
+	}
+	if !c.EnableToolUseShim {
+		c.Tools.RegisterTool(tools.NewHelmTool(c.executor))
+	}
+	c.Tools.RegisterTool(tools.NewCompareTool(c.executor))
+	c.Tools.RegisterTool(tools.NewChangeHistoryTool(c.executor))
+	c.Tools.RegisterTool(tools.NewEventsTool(c.executor))
+	c.Tools.RegisterTool(tools.NewAlertmanagerTool(c.executor, c.Alertmanager))
+	if c.Loki != nil {
+		c.Tools.RegisterTool(tools.NewLokiTool(c.executor, *c.Loki))
+	}
+	c.Tools.RegisterTool(tools.NewLintManifestTool(c.executor))
+	c.Tools.RegisterTool(tools.NewDeprecationTool(c.executor))
+	c.Tools.RegisterTool(tools.NewCapacityTool(c.executor))
+	c.Tools.RegisterTool(tools.NewInventoryTool(c.executor))
+	c.Tools.RegisterTool(tools.NewCollectBundleTool(c.executor))
+	c.Tools.RegisterTool(tools.NewListContextsTool())
+	// read_output reads the truncated outputs and the collected bundles.
+	c.Tools.RegisterTool(tools.NewReadOutputTool(c.MaxToolOutputBytes))
+	// get_resource_field fetches the fields of the objects too large to be sent whole.
+	c.Tools.RegisterTool(tools.NewGetResourceFieldTool(c.executor, c.KubectlPolicy))
+	c.Tools.RegisterTool(tools.NewExtractTool(c.executor, c.KubectlPolicy))
+}
+
 func (c *Agent) Close() error {
-	if c.workDir != "" {
-		if c.RemoveWorkDir {
-			if err := os.RemoveAll(c.workDir); err != nil {
-				klog.Warningf("error cleaning up directory %q: %v", c.workDir, err)
+	if c.RemoveWorkDir {
+		for _, dir := range append(c.previousWorkDirs, c.workDir) {
+			if dir == "" {
+				continue
+			}
+			if err := os.RemoveAll(dir); err != nil {
+				klog.Warningf("error cleaning up directory %q: %v", dir, err)
 			}
 		}
 	}
@@ -395,6 +696,10 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 			initialQuery = c.InitialQuery
 		}
 
+		if c.Incident != nil && len(c.Session.Messages) == 0 {
+			c.addMessage(api.MessageSourceAgent, api.MessageTypeText, fmt.Sprintf("Imported %s incident %s: %s, with %d notes and %d recent alerts.", c.Incident.Source, c.Incident.ID, c.Incident.Title, len(c.Incident.Notes), len(c.Incident.Alerts)))
+		}
+
 		if initialQuery != "" {
 			c.addMessage(api.MessageSourceUser, api.MessageTypeText, initialQuery)
 			answer, handled, err := c.handleMetaQuery(ctx, initialQuery)
@@ -418,7 +723,8 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 				// Start the agentic loop with the initial query
 				c.setAgentState(api.AgentStateRunning)
 				c.currIteration = 0
-				c.currChatContent = []any{initialQuery}
+				c.apiCallBudget = tools.NewAPICallBudget(c.MaxAPICallsPerRun)
+				c.currChatContent = []any{c.withNamespaceChange(c.withLearnedPreferences(initialQuery))}
 				c.pendingFunctionCalls = []ToolCallAnalysis{}
 			}
 		} else {
@@ -428,7 +734,9 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 			}
 		}
 		c.lastErr = nil
+		loopCtx := ctx
 		for {
+			ctx := c.runContext(loopCtx)
 			var userInput any
 			log.Info("Agent loop iteration", "state", c.AgentState())
 			switch c.AgentState() {
@@ -442,7 +750,7 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 				log.Info("initiating user input")
 				c.addMessage(api.MessageSourceAgent, api.MessageTypeUserInputRequest, ">>>")
 				select {
-				case <-ctx.Done():
+				case <-loopCtx.Done():
 					log.Info("Agent loop done")
 					return
 				case userInput = <-c.Input:
@@ -450,7 +758,8 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 					if userInput == io.EOF {
 						log.Info("Agent loop done, EOF received")
 						c.setAgentState(api.AgentStateExited)
-						c.addMessage(api.MessageSourceAgent, api.MessageTypeText, "It has been a pleasure assisting you. Have a great day!")
+						c.saveSessionMetadata(ctx)
+						c.addMessage(api.MessageSourceAgent, api.MessageTypeText, c.farewell())
 						return
 					}
 					query, ok := userInput.(*api.UserInputResponse)
@@ -489,7 +798,9 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 
 					c.setAgentState(api.AgentStateRunning)
 					c.currIteration = 0
-					c.currChatContent = []any{query.Query}
+					c.apiCallBudget = tools.NewAPICallBudget(c.MaxAPICallsPerRun)
+					c.currChatContent = append(c.interruptedContent, c.withSelectedAnswer(c.withNamespaceChange(c.withLearnedPreferences(query.Query))))
+					c.interruptedContent = nil
 					c.pendingFunctionCalls = []ToolCallAnalysis{}
 					log.Info("Set agent state to running, will process agentic loop", "currIteration", c.currIteration, "currChatContent", len(c.currChatContent))
 				}
@@ -502,14 +813,15 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 					return
 				}
 				select {
-				case <-ctx.Done():
+				case <-loopCtx.Done():
 					log.Info("Agent loop done")
 					return
 				case userInput = <-c.Input:
 					if userInput == io.EOF {
 						log.Info("Agent loop done, EOF received")
 						c.setAgentState(api.AgentStateExited)
-						c.addMessage(api.MessageSourceAgent, api.MessageTypeText, "It has been a pleasure assisting you. Have a great day!")
+						c.saveSessionMetadata(ctx)
+						c.addMessage(api.MessageSourceAgent, api.MessageTypeText, c.farewell())
 						return
 					}
 					choiceResponse, ok := userInput.(*api.UserChoiceResponse)
@@ -517,9 +829,20 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 						log.Error(nil, "Received unexpected input from channel", "userInput", userInput)
 						return
 					}
+					if len(c.pendingAnswers) > 0 {
+						c.pickAnswer(ctx, choiceResponse)
+						c.setAgentState(api.AgentStateDone)
+						c.presentPlan()
+						continue
+					}
 					dispatchToolCalls := c.handleChoice(ctx, choiceResponse)
 					if dispatchToolCalls {
+						// The approved tool calls may be interrupted.
+						c.setAgentState(api.AgentStateRunning)
 						if err := c.DispatchToolCalls(ctx); err != nil {
+							if c.interrupted(ctx) {
+								continue
+							}
 							log.Error(err, "error dispatching tool calls")
 							c.setAgentState(api.AgentStateDone)
 							c.pendingFunctionCalls = []ToolCallAnalysis{}
@@ -533,6 +856,9 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 							}
 							continue
 						}
+						if c.interrupted(ctx) {
+							continue
+						}
 						// Clear pending function calls after execution
 						c.pendingFunctionCalls = []ToolCallAnalysis{}
 						c.setAgentState(api.AgentStateRunning)
@@ -560,16 +886,27 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 					c.setAgentState(api.AgentStateDone)
 					c.pendingFunctionCalls = []ToolCallAnalysis{}
 					c.addMessage(api.MessageSourceAgent, api.MessageTypeText, "Maximum number of iterations reached.")
+					c.presentPlan()
 					continue
 				}
 
 				// we run the agentic loop for one iteration
+				c.compactHistoryIfNeeded(ctx)
+				c.currChatContent = sanitizeContents(c.currChatContent)
+				sentContent := c.currChatContent
 				stream, err := c.llmChat.SendStreaming(ctx, c.currChatContent...)
 				if err != nil {
+					if c.interrupted(ctx) {
+						continue
+					}
+					if c.repairHistory(ctx, err, sentContent) {
+						continue
+					}
 					log.Error(err, "error sending streaming LLM response")
 					c.setAgentState(api.AgentStateDone)
 					c.pendingFunctionCalls = []ToolCallAnalysis{}
 					c.lastErr = err
+					c.recordProviderError(ctx, err, sentContent)
 					continue
 				}
 
@@ -598,6 +935,8 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 				// accumulator for streamed text
 				var streamedText string
 				var llmError error
+				// Providers report the cumulative usage on the stream chunks, so we keep the largest.
+				var usedTokens int64
 
 				for response, err := range stream {
 					if err != nil {
@@ -622,6 +961,7 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 						break
 					}
 
+					usedTokens = max(usedTokens, gollm.UsageTokens(response.UsageMetadata()))
 					candidate := response.Candidates()[0]
 
 					for _, part := range candidate.Parts() {
@@ -638,18 +978,50 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 						}
 					}
 				}
+				if c.interrupted(ctx) {
+					continue
+				}
+				if llmError != nil && len(functionCalls) == 0 && streamedText == "" && c.repairHistory(ctx, llmError, sentContent) {
+					c.setAgentState(api.AgentStateRunning)
+					c.lastErr = nil
+					continue
+				}
 				if llmError != nil {
 					log.Error(llmError, "error streaming LLM response")
 					c.setAgentState(api.AgentStateDone)
 					c.pendingFunctionCalls = []ToolCallAnalysis{}
 					c.addMessage(api.MessageSourceAgent, api.MessageTypeError, "Error: "+llmError.Error())
 					c.lastErr = llmError
+					c.recordProviderError(ctx, llmError, sentContent)
 					continue
 				}
 				log.Info("streamedText", "streamedText", streamedText)
-
+				c.historyRepaired = false
+				c.recordUsage(usedTokens, sentContent, streamedText, functionCalls)
+
+				if len(functionCalls) == 0 && streamedText != "" && c.AnswerCandidates > 1 {
+					answers := c.sampleAnswers(ctx, streamedText)
+					if len(answers) > 1 && c.AnswerSelection == AnswerSelectionPick && !c.RunOnce {
+						c.currChatContent = []any{}
+						c.currIteration = 0
+						c.pendingFunctionCalls = []ToolCallAnalysis{}
+						c.presentAnswers(answers)
+						continue
+					}
+					if len(answers) > 1 {
+						index := c.voteAnswer(ctx, answers)
+						c.keepAnswer(answers, index)
+						streamedText = answers[index]
+					}
+				}
 				if streamedText != "" {
-					c.addMessage(api.MessageSourceModel, api.MessageTypeText, streamedText)
+					// The text accompanying tool calls is the reasoning of the model, not an answer.
+					message := &api.Message{Source: api.MessageSourceModel, Type: api.MessageTypeText, Payload: streamedText, Reasoning: len(functionCalls) > 0}
+					if len(functionCalls) == 0 {
+						// The resources referenced by the answer are opened with /open.
+						message.ResourceLinks = resourceLinks(streamedText)
+					}
+					c.postMessage(message)
 				}
 				// If no function calls to be made, we're done
 				if len(functionCalls) == 0 {
@@ -666,9 +1038,23 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 						log.Info("Empty response with no tool calls from LLM.")
 						c.addMessage(api.MessageSourceAgent, api.MessageTypeText, "Empty response from LLM")
 					}
+					c.presentPlan()
+					c.saveSessionMetadata(ctx)
 					continue
 				}
 
+				// Try to heal tool calls whose arguments the provider could not parse,
+				// and report back the ones we could not repair so the model can retry.
+				functionCalls, malformedCalls := c.repairFunctionCalls(ctx, functionCalls)
+				if len(malformedCalls) > 0 {
+					c.currChatContent = append(c.currChatContent, c.malformedToolCallObservations(malformedCalls)...)
+					if len(functionCalls) == 0 {
+						c.pendingFunctionCalls = []ToolCallAnalysis{}
+						c.currIteration = c.currIteration + 1
+						continue
+					}
+				}
+
 				toolCallAnalysisResults, err := c.analyzeToolCalls(ctx, functionCalls)
 				if err != nil {
 					log.Error(err, "error analyzing tool calls")
@@ -680,14 +1066,52 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 					continue
 				}
 
+				if c.DryRun {
+					toolCallAnalysisResults, err = c.planToolCalls(ctx, toolCallAnalysisResults)
+					if err != nil {
+						log.Error(err, "error planning tool calls")
+						c.setAgentState(api.AgentStateDone)
+						c.pendingFunctionCalls = []ToolCallAnalysis{}
+						c.addMessage(api.MessageSourceAgent, api.MessageTypeError, "Error: "+err.Error())
+						c.lastErr = err
+						continue
+					}
+				}
+
+				if c.ReadOnly {
+					toolCallAnalysisResults = c.filterReadOnly(ctx, toolCallAnalysisResults)
+					if len(toolCallAnalysisResults) == 0 {
+						c.pendingFunctionCalls = []ToolCallAnalysis{}
+						c.currIteration = c.currIteration + 1
+						continue // Skip execution, all the calls modify resources
+					}
+				}
+
+				if c.Maintenance != nil {
+					toolCallAnalysisResults = c.filterMaintenance(ctx, toolCallAnalysisResults)
+					if len(toolCallAnalysisResults) == 0 {
+						c.pendingFunctionCalls = []ToolCallAnalysis{}
+						c.currIteration = c.currIteration + 1
+						continue // Skip execution, all the calls modify resources
+					}
+				}
+
 				// mark the tools for dispatching
 				c.pendingFunctionCalls = toolCallAnalysisResults
 
 				interactiveToolCallIndex := -1
-				modifiesResourceToolCallIndex := -1
+				deniedToolCallIndex := -1
+				needsApproval := false
+				policyRequiresApproval := false
 				for i, result := range toolCallAnalysisResults {
-					if result.ModifiesResourceStr != "no" {
-						modifiesResourceToolCallIndex = i
+					if c.needsApproval(result) {
+						needsApproval = true
+					}
+					switch result.PolicyDecision {
+					case PolicyDeny:
+						deniedToolCallIndex = i
+					case PolicyRequireApproval:
+						policyRequiresApproval = true
 					}
 					if result.IsInteractive {
 						interactiveToolCallIndex = i
@@ -695,31 +1119,25 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 				}
 
 				if interactiveToolCallIndex >= 0 {
-					// Show error block for both shim enabled and disabled modes
-					errorMessage := fmt.Sprintf("  %s\n", toolCallAnalysisResults[interactiveToolCallIndex].IsInteractiveError.Error())
-					c.addMessage(api.MessageSourceAgent, api.MessageTypeError, errorMessage)
-
-					if c.EnableToolUseShim {
-						// Add the error as an observation
-						observation := fmt.Sprintf("Result of running %q:\n%v",
-							toolCallAnalysisResults[interactiveToolCallIndex].FunctionCall.Name,
-							toolCallAnalysisResults[interactiveToolCallIndex].IsInteractiveError.Error())
-						c.currChatContent = append(c.currChatContent, observation)
-					} else {
-						// For models with tool-use support (shim disabled), use proper FunctionCallResult
-						// Note: This assumes the model supports sending FunctionCallResult
-						c.currChatContent = append(c.currChatContent, gollm.FunctionCallResult{
-							ID:     toolCallAnalysisResults[interactiveToolCallIndex].FunctionCall.ID,
-							Name:   toolCallAnalysisResults[interactiveToolCallIndex].FunctionCall.Name,
-							Result: map[string]any{"error": toolCallAnalysisResults[interactiveToolCallIndex].IsInteractiveError.Error()},
-						})
-					}
+					c.rejectToolCall(toolCallAnalysisResults[interactiveToolCallIndex], toolCallAnalysisResults[interactiveToolCallIndex].IsInteractiveError)
 					c.pendingFunctionCalls = []ToolCallAnalysis{} // reset pending function calls
 					c.currIteration = c.currIteration + 1
 					continue // Skip execution for interactive commands
 				}
 
-				if !c.SkipPermissions && modifiesResourceToolCallIndex >= 0 {
+				if deniedToolCallIndex >= 0 {
+					c.rejectToolCall(toolCallAnalysisResults[deniedToolCallIndex], policyDenial(toolCallAnalysisResults[deniedToolCallIndex]))
+					c.pendingFunctionCalls = []ToolCallAnalysis{} // reset pending function calls
+					c.currIteration = c.currIteration + 1
+					continue // Skip execution for commands denied by the policies
+				}
+
+				if c.StageChanges && !c.DryRun {
+					c.staged = c.stageChanges(ctx)
+				}
+
+				// The policies may require an approval even when permissions are skipped.
+				if (!c.SkipPermissions || policyRequiresApproval) && needsApproval {
 					// In RunOnce mode, exit with error if permission is required
 					if c.RunOnce {
 						var commandDescriptions []string
@@ -737,19 +1155,14 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 					}
 
 					var commandDescriptions []string
-					for _, call := range c.pendingFunctionCalls {
+					for _, call := range c.callsNeedingApproval() {
 						commandDescriptions = append(commandDescriptions, call.ParsedToolCall.Description())
 					}
-					confirmationPrompt := "The following commands require your approval to run:\n* " + strings.Join(commandDescriptions, "\n* ")
-					confirmationPrompt += "\n\nDo you want to proceed ?"
 
 					choiceRequest := &api.UserChoiceRequest{
-						Prompt: confirmationPrompt,
-						Options: []api.UserChoiceOption{
-							{Value: "yes", Label: "Yes"},
-							{Value: "yes_and_dont_ask_me_again", Label: "Yes, and don't ask me again"},
-							{Value: "no", Label: "No"},
-						},
+						Prompt:   c.approvalPrompt(),
+						Options:  approvalOptions(),
+						Commands: commandDescriptions,
 					}
 					c.setAgentState(api.AgentStateWaitingForInput)
 					c.addMessage(api.MessageSourceAgent, api.MessageTypeUserChoiceRequest, choiceRequest)
@@ -761,6 +1174,9 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 
 				// we are here means we are in the clear to dispatch the tool calls
 				if err := c.DispatchToolCalls(ctx); err != nil {
+					if c.interrupted(ctx) {
+						continue
+					}
 					log.Error(err, "error dispatching tool calls")
 					c.setAgentState(api.AgentStateDone)
 					c.pendingFunctionCalls = []ToolCallAnalysis{}
@@ -769,6 +1185,9 @@ func (c *Agent) Run(ctx context.Context, initialQuery string) error {
 					c.lastErr = err
 					continue
 				}
+				if c.interrupted(ctx) {
+					continue
+				}
 				c.currIteration = c.currIteration + 1
 				c.pendingFunctionCalls = []ToolCallAnalysis{}
 				log.Info("Tool calls dispatched successfully", "currIteration", c.currIteration, "currChatContentLen", len(c.currChatContent), "agentState", c.AgentState())
@@ -787,12 +1206,31 @@ func (c *Agent) handleMetaQuery(ctx context.Context, query string) (answer strin
 		if err := c.Session.ChatMessageStore.ClearChatMessages(); err != nil {
 			return "Failed to clear the conversation", false, err
 		}
-		c.llmChat.Initialize(c.Session.ChatMessageStore.ChatMessages())
+		c.llmChat.Initialize(c.chatHistory())
+		c.budget = contextBudget{}
+		c.plan = nil
+		c.selectedAnswer = ""
+		c.interruptedContent = nil
 		c.sessionMu.Unlock()
 		return "Cleared the conversation.", true, nil
 	case "exit", "quit":
 		c.setAgentState(api.AgentStateExited)
-		return "It has been a pleasure assisting you. Have a great day!", true, nil
+		c.saveSessionMetadata(ctx)
+		return c.farewell(), true, nil
+	case "fork", "/fork":
+		parentID, forkID, err := c.ForkSession()
+		if err != nil {
+			return "", false, err
+		}
+		return fmt.Sprintf("Forked session %s into %s, with a copy of its history and files. Go back to the original with `resume %s`.", parentID, forkID, parentID), true, nil
+	case "report-issue", "/report-issue":
+		answer, err := c.reportIssue()
+		if err != nil {
+			return "", false, err
+		}
+		return answer, true, nil
+	case "cost", "/cost":
+		return usageReport(c.SessionUsage()), true, nil
 	case "model":
 		return "Current model is `" + c.Model + "`", true, nil
 	case "models":
@@ -803,6 +1241,23 @@ func (c *Agent) handleMetaQuery(ctx context.Context, query string) (answer strin
 		return "Available models:\n\n  - " + strings.Join(models, "\n  - ") + "\n\n", true, nil
 	case "tools":
 		return "Available tools:\n\n  - " + strings.Join(c.Tools.Names(), "\n  - ") + "\n\n", true, nil
+	case "contexts", "/contexts":
+		contexts, err := tools.KubeContexts(c.Kubeconfig)
+		if err != nil {
+			return "", false, fmt.Errorf("listing contexts: %w", err)
+		}
+		return contextsReport(contexts), true, nil
+	case "env", "/env":
+		env := c.toolEnv()
+		if len(env) == 0 {
+			return "No session environment variables are set.", true, nil
+		}
+		return "Session environment variables:\n\n  - " + strings.Join(env, "\n  - ") + "\n\n", true, nil
+	case "preferences", "/preferences":
+		if len(c.Session.LearnedPreferences) == 0 {
+			return "No preferences learned in this session yet.", true, nil
+		}
+		return "Preferences learned in this session:\n\n  - " + strings.Join(c.Session.LearnedPreferences, "\n  - ") + "\n\n", true, nil
 	case "session":
 		if c.SessionBackend != "filesystem" {
 			return "Ephemeral session (memory backed). No persistent info available.", true, nil
@@ -836,8 +1291,11 @@ func (c *Agent) handleMetaQuery(ctx context.Context, query string) (answer strin
 		availableSessions += "ID\t\t\tCreated\t\t\tLast Accessed\t\tModel\t\tProvider\n"
 		availableSessions += "--\t\t\t-------\t\t\t-------------\t\t-----\t\t--------\n"
 
-		for _, session := range sessionList {
-			availableSessions += fmt.Sprintf("%s\t%s\t%s\t%s\t%s\n",
+		// Forked sessions are listed below the session they were forked from.
+		for _, entry := range sessions.Tree(sessionList) {
+			session := entry.Session
+			availableSessions += fmt.Sprintf("%s%s\t%s\t%s\t%s\t%s\n",
+				entry.Prefix,
 				session.ID,
 				session.CreatedAt.Format("2006-01-02 15:04"),
 				session.LastModified.Format("2006-01-02 15:04"),
@@ -849,21 +1307,131 @@ func (c *Agent) handleMetaQuery(ctx context.Context, query string) (answer strin
 		return availableSessions, true, nil
 	}
 
-	if strings.HasPrefix(query, "resume-session") {
-		parts := strings.Split(query, " ")
-		if len(parts) != 2 {
-			return "Invalid command. Usage: resume-session <session_id>", true, nil
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "", false, nil
+	}
+	switch fields[0] {
+	case "resume", "resume-session":
+		// "resume" may start a query for the model, e.g. "resume the rollout of web".
+		if fields[0] == "resume" && (len(fields) > 2 || len(fields) == 2 && !c.sessionExists(fields[1])) {
+			return "", false, nil
+		}
+		if len(fields) > 2 {
+			return "Invalid command. Usage: resume-session [session_id]", true, nil
+		}
+		var sessionID string
+		if len(fields) == 2 {
+			sessionID = fields[1]
+		} else {
+			sessionID, err = c.previousSessionID()
+			if err != nil {
+				return "", false, err
+			}
+			if sessionID == "" {
+				return "No previous session found.", true, nil
+			}
 		}
-		sessionID := parts[1]
 		if err := c.LoadSession(sessionID); err != nil {
 			return "", false, err
 		}
 		return fmt.Sprintf("Resumed session %s.", sessionID), true, nil
+	case "feedback", "/feedback":
+		rating, comment, ok := parseFeedback(fields[1:])
+		if !ok {
+			// "feedback" may start a query for the model, e.g. "feedback loops in the HPA".
+			if fields[0] == "feedback" {
+				return "", false, nil
+			}
+			return "Invalid command. " + feedbackUsage, true, nil
+		}
+		return c.recordFeedback(ctx, rating, comment), true, nil
+	case "ns", "/ns":
+		// "ns" may start a query for the model, e.g. "ns shop has no quota, why?".
+		if len(fields) > 2 {
+			if fields[0] == "ns" {
+				return "", false, nil
+			}
+			return "Invalid command. Usage: /ns [name]", true, nil
+		}
+		namespace := ""
+		if len(fields) == 2 {
+			namespace = fields[1]
+		}
+		answer, err := c.switchNamespace(ctx, namespace)
+		if err != nil {
+			return "", false, err
+		}
+		return answer, true, nil
+	case "open", "/open":
+		if len(fields) != 2 {
+			// "open" may start a query for the model, e.g. "open ports of the web service".
+			if fields[0] == "open" {
+				return "", false, nil
+			}
+			return "Invalid command. Usage: /open <n>", true, nil
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil && fields[0] == "open" {
+			return "", false, nil
+		}
+		answer, err := c.openResourceLink(ctx, fields[1])
+		if err != nil {
+			return "", false, err
+		}
+		return answer, true, nil
+	case "delete-session":
+		if len(fields) != 2 {
+			return "Invalid command. Usage: delete-session <session_id>", true, nil
+		}
+		if fields[1] == c.Session.ID {
+			return "Cannot delete the current session.", true, nil
+		}
+		manager, err := sessions.NewSessionManager(c.SessionBackend)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to create session manager: %w", err)
+		}
+		if err := manager.DeleteSession(fields[1]); err != nil {
+			return "", false, fmt.Errorf("failed to delete session %s: %w", fields[1], err)
+		}
+		return fmt.Sprintf("Deleted session %s.", fields[1]), true, nil
 	}
 
 	return "", false, nil
 }
 
+// sessionExists returns true if a session with the given ID exists.
+func (c *Agent) sessionExists(id string) bool {
+	manager, err := sessions.NewSessionManager(c.SessionBackend)
+	if err != nil {
+		return false
+	}
+	_, err = manager.FindSessionByID(id)
+	return err == nil
+}
+
+// previousSessionID returns the ID of the most recent session other than the
+// current one, or "" if there is none.
+func (c *Agent) previousSessionID() (string, error) {
+	manager, err := sessions.NewSessionManager(c.SessionBackend)
+	if err != nil {
+		return "", fmt.Errorf("failed to create session manager: %w", err)
+	}
+	sessionList, err := manager.ListSessions()
+	if err != nil {
+		return "", fmt.Errorf("failed to list sessions: %w", err)
+	}
+	var previous *api.Session
+	for _, session := range sessionList {
+		if session.ID != c.Session.ID && (previous == nil || session.LastModified.After(previous.LastModified)) {
+			previous = session
+		}
+	}
+	if previous == nil {
+		return "", nil
+	}
+	return previous.ID, nil
+}
+
 func (c *Agent) NewSession() (string, error) {
 	if _, err := c.SaveSession(); err != nil {
 		return "", fmt.Errorf("failed to save current session: %w", err)
@@ -912,9 +1480,7 @@ func (c *Agent) NewSession() (string, error) {
 
 		// Re-bind all tools to the new executor
 		c.Tools = c.Tools.CloneWithExecutor(c.executor)
-
-		c.Tools.RegisterTool(tools.NewBashTool(c.executor))
-		c.Tools.RegisterTool(tools.NewKubectlTool(c.executor))
+		c.registerBuiltinTools()
 		c.sessionMu.Unlock()
 	}
 
@@ -953,17 +1519,18 @@ func (c *Agent) SaveSession() (string, error) {
 		return "", fmt.Errorf("failed to create new session: %w", err)
 	}
 
-	messages := c.ChatMessageStore.ChatMessages()
+	// Load the attachments back, so the new session stores its own copy.
+	messages := sessions.ResolveAttachments(c.ChatMessageStore, c.ChatMessageStore.ChatMessages())
 	if err := newSession.ChatMessageStore.SetChatMessages(messages); err != nil {
 		return "", fmt.Errorf("failed to save chat messages to new session: %w", err)
 	}
 
 	c.ChatMessageStore = newSession.ChatMessageStore
 	c.Session = newSession
-	c.Session.Messages = messages
+	c.Session.Messages = newSession.ChatMessageStore.ChatMessages()
 
 	if c.llmChat != nil {
-		_ = c.llmChat.Initialize(c.Session.ChatMessageStore.ChatMessages())
+		_ = c.llmChat.Initialize(c.chatHistory())
 	}
 
 	return newSession.ID, nil
@@ -1016,10 +1583,14 @@ func (c *Agent) LoadSession(sessionID string) error {
 	}
 
 	if c.llmChat != nil {
-		if err := c.llmChat.Initialize(c.Session.ChatMessageStore.ChatMessages()); err != nil {
+		if err := c.llmChat.Initialize(c.chatHistory()); err != nil {
 			return fmt.Errorf("failed to re-initialize chat with new session: %w", err)
 		}
 	}
+	c.budget = contextBudget{}
+	c.plan = nil
+	c.selectedAnswer = ""
+	c.interruptedContent = nil
 
 	return nil
 }
@@ -1037,18 +1608,82 @@ func (c *Agent) listModels(ctx context.Context) ([]string, error) {
 
 func (c *Agent) DispatchToolCalls(ctx context.Context) error {
 	log := klog.FromContext(ctx)
+	batched := c.batchKubectlQueries(ctx)
+	staged := c.staged
+	c.staged = nil
+
 	// execute all pending function calls
-	for _, call := range c.pendingFunctionCalls {
+	for i, call := range c.pendingFunctionCalls {
 		// Only show "Running" message and proceed with execution for non-interactive commands
 		toolDescription := call.ParsedToolCall.Description()
 
 		c.addMessage(api.MessageSourceModel, api.MessageTypeToolCallRequest, toolDescription)
 
-		output, err := call.ParsedToolCall.InvokeTool(ctx, tools.InvokeToolOptions{
-			Kubeconfig: c.Kubeconfig,
-			WorkDir:    c.workDir,
-			Executor:   c.executor,
-		})
+		var output any
+		var err error
+		var artifacts []*api.ArtifactBlock
+		rolledBack := false
+		if batchCommand, ok := batched[i]; ok {
+			output = &sandbox.ExecResult{
+				Command: toolDescription,
+				Stdout:  fmt.Sprintf("This query was batched with related queries; its output is included in the result of %q.", batchCommand),
+			}
+		} else {
+			if c.EchoCommands {
+				c.echoToolCall(call)
+			}
+			if err := c.runPreToolHooks(ctx, call); err != nil {
+				c.rejectToolCall(call, err)
+				if isStaged(staged, i) {
+					c.addMessage(api.MessageSourceAgent, api.MessageTypeError, c.rollBack(ctx, staged, i))
+					c.skipRemainingSteps(c.pendingFunctionCalls[i+1:])
+					break
+				}
+				continue
+			}
+			invokeOptions := tools.InvokeToolOptions{
+				Kubeconfig:    c.Kubeconfig,
+				WorkDir:       c.workDir,
+				Executor:      c.executor,
+				Env:           c.toolEnv(),
+				APICallBudget: c.apiCallBudget,
+				LastResult:    c.lastToolResult,
+				ReadCache:     c.readCache,
+			}
+			if !c.RunOnce {
+				invokeOptions.Prompter = c.promptForToolInput
+			}
+			workDirFiles := c.workDirFiles()
+			if handle, ok := call.ParsedToolCall.InvokeToolAsync(ctx, invokeOptions); ok {
+				output, err = c.waitForAsyncToolCall(ctx, handle)
+			} else {
+				output, err = call.ParsedToolCall.InvokeTool(ctx, invokeOptions)
+			}
+			if err == nil && !c.RunOnce && tools.DetectKubeAuthError(output) != nil {
+				output, err = c.handleKubeAuthError(ctx, call, invokeOptions, output)
+			}
+			c.runPostToolHooks(ctx, call, output, err)
+			if call.ModifiesResourceStr != "no" {
+				// Even a failed call may have modified some resources.
+				c.readCache.Invalidate()
+			}
+			if isStaged(staged, i) && stepFailed(output, err) {
+				summary := c.rollBack(ctx, staged, i)
+				c.addMessage(api.MessageSourceAgent, api.MessageTypeError, summary)
+				output = withRollback(output, summary)
+				rolledBack = true
+			}
+			if err == nil {
+				// Binary outputs are stored before the artifacts are listed,
+				// so that they are reported with them.
+				if sanitized, err := tools.HandleBinaryOutput(c.workDir, output); err != nil {
+					log.Error(err, "error storing binary tool output")
+				} else {
+					output = sanitized
+				}
+			}
+			artifacts = c.newArtifacts(workDirFiles)
+		}
 
 		if err != nil {
 			log.Error(err, "error executing action", "output", output)
@@ -1060,20 +1695,31 @@ func (c *Agent) DispatchToolCalls(ctx context.Context) error {
 		if execResult, ok := output.(*sandbox.ExecResult); ok && execResult != nil && execResult.StreamType == "timeout" {
 			c.addMessage(api.MessageSourceAgent, api.MessageTypeError, "\nTimeout reached after 7 seconds\n")
 		}
+		// Successive extractions evaluate the same result.
+		if _, ok := call.ParsedToolCall.GetTool().(*tools.ExtractTool); !ok {
+			c.lastToolResult = output
+		}
+		// Large outputs are truncated before they are sent to the model, the
+		// blocks are built from the full output.
+		sent, err := tools.LimitOutput(c.workDir, c.MaxToolOutputBytes, output)
+		if err != nil {
+			log.Error(err, "error truncating tool output")
+			sent = output
+		}
 		// Add the tool call result to maintain conversation flow
 		var payload any
 		if c.EnableToolUseShim {
 			// Add the error as an observation
 			observation := fmt.Sprintf("Result of running %q:\n%v",
 				call.FunctionCall.Name,
-				output)
+				sent)
 			c.currChatContent = append(c.currChatContent, observation)
 			payload = observation
 		} else {
 			// If shim is disabled, convert the result to a map and append FunctionCallResult
-			result, err := tools.ToolResultToMap(output)
+			result, err := tools.ToolResultToMap(sent)
 			if err != nil {
-				log.Error(err, "error converting tool result to map", "output", output)
+				log.Error(err, "error converting tool result to map", "output", sent)
 				return err
 			}
 			payload = result
@@ -1084,10 +1730,129 @@ func (c *Agent) DispatchToolCalls(ctx context.Context) error {
 			})
 		}
 		c.addMessage(api.MessageSourceAgent, api.MessageTypeToolCallResponse, payload)
+		// Structured results are also shown as blocks, which are not sent to the model.
+		if blockTool, ok := call.ParsedToolCall.GetTool().(tools.BlockTool); ok {
+			if block := blockTool.OutputBlock(call.FunctionCall.Arguments, output); block != nil {
+				c.addMessage(api.MessageSourceAgent, block.MessageType(), block)
+			}
+		}
+		// The files the tool call wrote to the work directory can be downloaded.
+		for _, artifact := range artifacts {
+			c.addMessage(api.MessageSourceAgent, artifact.MessageType(), artifact)
+		}
+		if rolledBack {
+			c.skipRemainingSteps(c.pendingFunctionCalls[i+1:])
+			break
+		}
 	}
 	return nil
 }
 
+// waitForAsyncToolCall polls an async tool call until it completes, and shows
+// its progress in the UI. Only the final result is sent to the model.
+func (c *Agent) waitForAsyncToolCall(ctx context.Context, handle *tools.AsyncHandle) (any, error) {
+	ticker := time.NewTicker(asyncToolPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			handle.Cancel()
+			return handle.Result()
+		case <-handle.Done():
+			c.addAsyncProgress(handle)
+			return handle.Result()
+		case <-ticker.C:
+			c.addAsyncProgress(handle)
+		}
+	}
+}
+
+// addAsyncProgress shows the output and the progress reported by an async
+// tool call since the last poll.
+func (c *Agent) addAsyncProgress(handle *tools.AsyncHandle) {
+	if progress := handle.Progress(); progress != "" {
+		c.addMessage(api.MessageSourceAgent, api.MessageTypeToolCallProgress, progress)
+	}
+	if block := handle.ProgressBlock(); block != nil {
+		c.addMessage(api.MessageSourceAgent, block.MessageType(), block)
+	}
+}
+
+// promptForToolInput asks the user to answer the prompt of a tool subprocess
+// waiting for input, e.g. a helm plugin asking for confirmation.
+func (c *Agent) promptForToolInput(ctx context.Context, prompt string) (string, error) {
+	c.setAgentState(api.AgentStateWaitingForInput)
+	defer c.setAgentState(api.AgentStateRunning)
+	c.addMessage(api.MessageSourceAgent, api.MessageTypeToolInputRequest, prompt)
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case userInput := <-c.Input:
+		if userInput == io.EOF {
+			return "", io.EOF
+		}
+		response, ok := userInput.(*api.UserInputResponse)
+		if !ok {
+			return "", fmt.Errorf("unexpected input %T for the prompt of the command", userInput)
+		}
+		return response.Query, nil
+	}
+}
+
+// batchKubectlQueries merges related read-only kubectl get calls among the
+// pending function calls. The first call of each batch is rewritten to run the
+// batched command; the returned map holds the batched command for the other
+// calls of the batch, which must not be invoked.
+func (c *Agent) batchKubectlQueries(ctx context.Context) map[int]string {
+	if !c.BatchKubectlQueries {
+		return nil
+	}
+
+	var commands []string
+	var indexes []int
+	for i, call := range c.pendingFunctionCalls {
+		if call.FunctionCall.Name != "kubectl" {
+			continue
+		}
+		command, ok := call.FunctionCall.Arguments["command"].(string)
+		if !ok {
+			continue
+		}
+		commands = append(commands, command)
+		indexes = append(indexes, i)
+	}
+
+	batched := make(map[int]string)
+	for _, batch := range tools.PlanKubectlQueries(commands) {
+		if len(batch.Indexes) < 2 {
+			continue
+		}
+		leader := indexes[batch.Indexes[0]]
+		toolCall, err := c.Tools.ParseToolInvocation(ctx, "kubectl", map[string]any{
+			"command": batch.Command,
+		})
+		if err != nil {
+			klog.FromContext(ctx).Error(err, "failed to batch kubectl queries", "command", batch.Command)
+			continue
+		}
+		c.pendingFunctionCalls[leader].ParsedToolCall = toolCall
+		for _, j := range batch.Indexes[1:] {
+			batched[indexes[j]] = batch.Command
+		}
+	}
+	return batched
+}
+
+// toolEnv returns the session-scoped environment variables as sorted "KEY=VALUE" pairs.
+func (c *Agent) toolEnv() []string {
+	env := make([]string, 0, len(c.Env))
+	for k, v := range c.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
 // The key idea is to treat all tool calls to be executed atomically or not
 // If all tool calls are readonly call, it is straight forward
 // if some of the tool calls are not readonly, then the interesting question is should the permission
@@ -1100,22 +1865,68 @@ type ToolCallAnalysis struct {
 	IsInteractive       bool
 	IsInteractiveError  error
 	ModifiesResourceStr string
+	// ClaimedModifiesResource is the classification the model claimed in the
+	// modifies_resource argument of the call, if any.
+	ClaimedModifiesResource string
+	// ServerModifiesResource is the classification of the server-side dry run
+	// of the call, empty if it was not dry run, see verifyClassification.
+	ServerModifiesResource string
+	// PolicyDecision and PolicyReason are the decision of the policies on the call, if any.
+	PolicyDecision PolicyDecision
+	PolicyReason   string
+}
+
+// rejectToolCall reports why a tool call is not run to the user, and to the model as the result of the call.
+func (c *Agent) rejectToolCall(call ToolCallAnalysis, reason error) {
+	// Show error block for both shim enabled and disabled modes
+	c.addMessage(api.MessageSourceAgent, api.MessageTypeError, fmt.Sprintf("  %s\n", reason.Error()))
+
+	if c.EnableToolUseShim {
+		// Add the error as an observation
+		observation := fmt.Sprintf("Result of running %q:\n%v", call.FunctionCall.Name, reason.Error())
+		c.currChatContent = append(c.currChatContent, observation)
+	} else {
+		// For models with tool-use support (shim disabled), use proper FunctionCallResult
+		// Note: This assumes the model supports sending FunctionCallResult
+		result := map[string]any{"error": reason.Error()}
+		var refusal *tools.ReadOnlyRefusal
+		if errors.As(reason, &refusal) {
+			result["readOnlyRefusal"] = refusal
+		}
+		c.currChatContent = append(c.currChatContent, gollm.FunctionCallResult{
+			ID:     call.FunctionCall.ID,
+			Name:   call.FunctionCall.Name,
+			Result: result,
+		})
+	}
 }
 
 func (c *Agent) analyzeToolCalls(ctx context.Context, toolCalls []gollm.FunctionCall) ([]ToolCallAnalysis, error) {
 	toolCallAnalysis := make([]ToolCallAnalysis, len(toolCalls))
 	for i, call := range toolCalls {
-		toolCallAnalysis[i].FunctionCall = call
 		toolCall, err := c.Tools.ParseToolInvocation(ctx, call.Name, call.Arguments)
 		if err != nil {
 			return nil, fmt.Errorf("error parsing tool call: %w", err)
 		}
+		// The quoted kubectl calls are approved, edited and audited as
+		// the command they render.
+		call.Arguments = toolCall.Arguments()
+		toolCallAnalysis[i].FunctionCall = call
 		toolCallAnalysis[i].IsInteractive, err = toolCall.GetTool().IsInteractive(call.Arguments)
 		if err != nil {
 			toolCallAnalysis[i].IsInteractiveError = err
 		}
 		toolCallAnalysis[i].ModifiesResourceStr = toolCall.GetTool().CheckModifiesResource(call.Arguments)
+		toolCallAnalysis[i].ClaimedModifiesResource, _ = call.Arguments["modifies_resource"].(string)
 		toolCallAnalysis[i].ParsedToolCall = toolCall
+		c.evaluatePolicy(ctx, &toolCallAnalysis[i])
+		// The calls denied by the policies are not dry run either.
+		if toolCallAnalysis[i].PolicyDecision != PolicyDeny {
+			c.verifyClassification(ctx, &toolCallAnalysis[i])
+		}
+		if toolCallAnalysis[i].classificationMismatch() {
+			c.recordClassificationMismatch(ctx, toolCallAnalysis[i])
+		}
 	}
 	return toolCallAnalysis, nil
 }
@@ -1128,24 +1939,33 @@ func (c *Agent) handleChoice(ctx context.Context, choice *api.UserChoiceResponse
 
 	// Normalize the input
 	switch choice.Choice {
-	case 1:
+	case approvalChoiceYes:
 		dispatchToolCalls = true
-	case 2:
+	case approvalChoiceYesDontAskAgain:
 		c.SkipPermissions = true
 		dispatchToolCalls = true
-	case 3:
-		c.currChatContent = append(c.currChatContent, gollm.FunctionCallResult{
-			ID:   c.pendingFunctionCalls[0].FunctionCall.ID,
-			Name: c.pendingFunctionCalls[0].FunctionCall.Name,
-			Result: map[string]any{
-				"error":     "User declined to run this operation.",
-				"status":    "declined",
-				"retryable": false,
-			},
-		})
-		c.pendingFunctionCalls = []ToolCallAnalysis{}
+	case approvalChoiceEdit:
+		// The edited command was not staged.
+		c.staged = nil
+		if err := c.editToolCall(ctx, choice.Command); err != nil {
+			log.Error(err, "editing the tool call")
+			c.pendingFunctionCalls = []ToolCallAnalysis{}
+			dispatchToolCalls = false
+			c.addMessage(api.MessageSourceAgent, api.MessageTypeError, fmt.Sprintf("Cannot edit the command: %v. Cancelling operation.", err))
+			break
+		}
+		dispatchToolCalls = true
+	case approvalChoiceSkip:
+		c.staged = nil
+		c.skipToolCallsNeedingApproval(ctx)
+		dispatchToolCalls = len(c.pendingFunctionCalls) > 0
+	case approvalChoiceAlways:
+		c.allowForSession(ctx)
+		dispatchToolCalls = true
+	case approvalChoiceNo:
+		c.staged = nil
+		c.declineToolCalls(ctx)
 		dispatchToolCalls = false
-		c.addMessage(api.MessageSourceAgent, api.MessageTypeError, "Operation was skipped. User declined to run this operation.")
 	default:
 		// This case should technically not be reachable due to AskForConfirmation loop
 		err := fmt.Errorf("invalid confirmation choice: %q", choice.Choice)
@@ -1176,7 +1996,7 @@ func (a *Agent) generatePrompt(_ context.Context, defaultPromptTemplate string,
 		promptTemplate += "\n" + string(content)
 	}
 
-	tmpl, err := template.New("promptTemplate").Parse(promptTemplate)
+	tmpl, err := template.New("promptTemplate").Funcs(a.promptFuncs(&data)).Parse(promptTemplate)
 	if err != nil {
 		return "", fmt.Errorf("building template for prompt: %w", err)
 	}
@@ -1189,6 +2009,28 @@ func (a *Agent) generatePrompt(_ context.Context, defaultPromptTemplate string,
 	return result.String(), nil
 }
 
+// promptFuncs returns the functions conditioning the sections of the prompt
+// templates on the provider and the model, from the model capability
+// registry, e.g. {{if supports "markdown"}} or {{if lt contextWindow 32000}}.
+func (a *Agent) promptFuncs(data *PromptData) template.FuncMap {
+	capabilities := gollm.LookupModelCapabilities(a.Model)
+	return template.FuncMap{
+		"provider":      func() string { return providerName(a.Provider) },
+		"model":         func() string { return a.Model },
+		"contextWindow": a.contextWindow,
+		"supports": func(capability string) (bool, error) {
+			switch capability {
+			case "native-tools":
+				// The tools are called in JSON with the tool use shim.
+				return !data.EnableToolUseShim, nil
+			case "markdown":
+				return capabilities.Markdown, nil
+			}
+			return false, fmt.Errorf("unknown capability %q, expected native-tools or markdown", capability)
+		},
+	}
+}
+
 // PromptData represents the structure of the data to be filled into the template.
 type PromptData struct {
 	Query string
@@ -1196,13 +2038,31 @@ type PromptData struct {
 
 	EnableToolUseShim    bool
 	SessionIsInteractive bool
+	DryRun               bool
+
+	// Memory holds the facts about the cluster remembered in previous sessions.
+	Memory string
+
+	// ClusterFacts is the fact sheet of the cluster gathered at the start of the session.
+	ClusterFacts string
+
+	// Incident is the incident the session investigates, rendered in markdown.
+	Incident string
+
+	// Namespace is the default namespace of the session, if set by the user.
+	Namespace string
+
+	// Contexts are the contexts of the kubeconfig the commands may target.
+	Contexts []tools.KubeContext
 }
 
+// ToolsAsJSON returns the definitions of the tools sorted by name, so that
+// the prompt is the same for the same tools.
 func (a *PromptData) ToolsAsJSON() string {
 	var toolDefinitions []*gollm.FunctionDefinition
 
-	for _, tool := range a.Tools.AllTools() {
-		toolDefinitions = append(toolDefinitions, tool.FunctionDefinition())
+	for _, name := range a.Tools.Names() {
+		toolDefinitions = append(toolDefinitions, a.Tools.Lookup(name).FunctionDefinition())
 	}
 
 	json, err := json.MarshalIndent(toolDefinitions, "", "  ")
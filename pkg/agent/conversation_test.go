@@ -16,7 +16,9 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -25,11 +27,13 @@ import (
 	"github.com/GoogleCloudPlatform/kubectl-ai/internal/mocks"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
 	"go.uber.org/mock/gomock"
 )
 
 func TestHandleMetaQuery(t *testing.T) {
 	ctx := context.Background()
+	const deletedSessionID = "20250101-0001"
 
 	tests := []struct {
 		name         string
@@ -123,6 +127,19 @@ func TestHandleMetaQuery(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:   "cost",
+			query:  "/cost",
+			expect: "| gemini | gemini-2.5-flash | 1000000 | 100000 | $0.55 |",
+			expectations: func(t *testing.T) *Agent {
+				a := &Agent{}
+				a.Session = &api.Session{Usage: []api.ModelUsage{
+					{Provider: "gemini", Model: "gemini-2.5-flash", InputTokens: 1_000_000, OutputTokens: 100_000},
+					{Provider: "ollama", Model: "llama3", InputTokens: 10, OutputTokens: 5},
+				}}
+				return a
+			},
+		},
 		{
 			name:   "model",
 			query:  "model",
@@ -176,6 +193,16 @@ func TestHandleMetaQuery(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:   "env",
+			query:  "/env",
+			expect: "Session environment variables:\n\n  - AWS_PROFILE=dev\n  - HELM_NAMESPACE=apps\n\n",
+			expectations: func(t *testing.T) *Agent {
+				a := &Agent{Env: map[string]string{"HELM_NAMESPACE": "apps", "AWS_PROFILE": "dev"}}
+				a.Session = &api.Session{}
+				return a
+			},
+		},
 		{
 			name:   "session",
 			query:  "session",
@@ -235,6 +262,55 @@ func TestHandleMetaQuery(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:   "resume",
+			query:  "resume",
+			expect: "Resumed session",
+			expectations: func(t *testing.T) *Agent {
+				manager, err := sessions.NewSessionManager("memory")
+				if err != nil {
+					t.Fatalf("creating session manager: %v", err)
+				}
+				if _, err := manager.NewSession(sessions.Metadata{ProviderID: "p", ModelID: "m", KubeContext: "prod"}); err != nil {
+					t.Fatalf("creating session: %v", err)
+				}
+				current, err := manager.NewSession(sessions.Metadata{ProviderID: "p", ModelID: "m"})
+				if err != nil {
+					t.Fatalf("creating session: %v", err)
+				}
+				a := &Agent{SessionBackend: "memory", ChatMessageStore: current.ChatMessageStore}
+				a.Session = current
+				return a
+			},
+			verify: func(t *testing.T, a *Agent, _ string) {
+				if a.Session.KubeContext != "prod" {
+					t.Fatalf("resumed session %s, want the previous session with the prod context", a.Session.ID)
+				}
+			},
+		},
+		{
+			name:   "delete-session",
+			query:  "delete-session " + deletedSessionID,
+			expect: "Deleted session " + deletedSessionID,
+			expectations: func(t *testing.T) *Agent {
+				manager, err := sessions.NewSessionManager("memory")
+				if err != nil {
+					t.Fatalf("creating session manager: %v", err)
+				}
+				if _, err := manager.ImportSession(&sessions.Export{ID: deletedSessionID}, sessions.ConflictReplace); err != nil {
+					t.Fatalf("creating session: %v", err)
+				}
+				a := &Agent{SessionBackend: "memory"}
+				a.Session = &api.Session{ID: "current"}
+				return a
+			},
+			verify: func(t *testing.T, _ *Agent, _ string) {
+				manager, _ := sessions.NewSessionManager("memory")
+				if _, err := manager.FindSessionByID(deletedSessionID); err == nil {
+					t.Fatalf("session %s was not deleted", deletedSessionID)
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -412,3 +488,255 @@ func TestAgent_NewSession_NoDeadlock(t *testing.T) {
 		t.Fatal("NewSession timed out (potential deadlock)")
 	}
 }
+
+func TestParseRepairedArguments(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     map[string]any
+		wantErr  bool
+	}{
+		{
+			name:     "plain json",
+			response: `{"command":"kubectl get pods"}`,
+			want:     map[string]any{"command": "kubectl get pods"},
+		},
+		{
+			name:     "json in markdown fence",
+			response: "```json\n{\"command\":\"kubectl get ns\",\"modifies_resource\":\"no\"}\n```",
+			want:     map[string]any{"command": "kubectl get ns", "modifies_resource": "no"},
+		},
+		{
+			name:     "no json",
+			response: "I cannot do that",
+			wantErr:  true,
+		},
+		{
+			name:     "still malformed",
+			response: `{"command": kubectl get pods}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRepairedArguments(tt.response)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("expected %s=%v, got %v", k, v, got[k])
+				}
+			}
+		})
+	}
+}
+
+// malformedCall is a call of mocktool whose arguments the provider could not parse.
+func malformedCall(raw string) gollm.Part {
+	return fakePart{calls: []gollm.FunctionCall{{ID: "1", Name: "mocktool", RawArguments: raw}}}
+}
+
+func TestAgentLoopMalformedToolArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		// responses are the responses of the model, in order.
+		responses []gollm.ChatResponse
+		// repair is the response of the repair completion, repairErr its error.
+		repair    string
+		repairErr error
+		// wantRuns are the arguments of the runs of the tool.
+		wantRuns []map[string]any
+		// wantStatus is the status of the first tool result sent to the model.
+		wantStatus string
+	}{
+		{
+			name: "repaired",
+			responses: []gollm.ChatResponse{
+				chatWith(malformedCall(`{"command": "kubectl get pods"`)),
+				chatWith(fText("done")),
+			},
+			repair:     "```json\n{\"command\": \"kubectl get pods\"}\n```",
+			wantRuns:   []map[string]any{{"command": "kubectl get pods"}},
+			wantStatus: "ok",
+		},
+		{
+			name: "not repaired, the model retries",
+			responses: []gollm.ChatResponse{
+				chatWith(malformedCall(`{command: kubectl get pods}`)),
+				chatWith(fCalls("mocktool", map[string]any{"command": "kubectl get pods"})),
+				chatWith(fText("done")),
+			},
+			repair:     "I cannot fix these arguments",
+			wantRuns:   []map[string]any{{"command": "kubectl get pods"}},
+			wantStatus: "invalid_arguments",
+		},
+		{
+			name: "repair fails, the model gives up",
+			responses: []gollm.ChatResponse{
+				chatWith(malformedCall(`{"command": `)),
+				chatWith(fText("done")),
+			},
+			repairErr:  errors.New("quota exceeded"),
+			wantStatus: "invalid_arguments",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			client := mocks.NewMockClient(ctrl)
+			chat := mocks.NewMockChat(ctrl)
+			client.EXPECT().StartChat(gomock.Any(), "test-model").Return(chat)
+			client.EXPECT().Close().Return(nil).AnyTimes()
+			chat.EXPECT().Initialize(gomock.Any()).Return(nil)
+			chat.EXPECT().SetFunctionDefinitions(gomock.Any()).Return(nil)
+			client.EXPECT().GenerateCompletion(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, req *gollm.CompletionRequest) (gollm.CompletionResponse, error) {
+				if !strings.Contains(req.Prompt, `"mocktool"`) {
+					t.Errorf("repair prompt = %q, want the name of the tool", req.Prompt)
+				}
+				return fakeCompletion(tt.repair), tt.repairErr
+			})
+
+			// sent holds the contents sent to the model after the query.
+			var sent [][]any
+			var calls []any
+			for _, response := range tt.responses {
+				calls = append(calls, chat.EXPECT().SendStreaming(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, contents ...any) (gollm.ChatResponseIterator, error) {
+					sent = append(sent, contents)
+					return func(yield func(gollm.ChatResponse, error) bool) { yield(response, nil) }, nil
+				}))
+			}
+			gomock.InOrder(calls...)
+
+			var runs []map[string]any
+			tool := mocks.NewMockTool(ctrl)
+			tool.EXPECT().Name().Return("mocktool").AnyTimes()
+			tool.EXPECT().Description().Return("mock tool").AnyTimes()
+			tool.EXPECT().FunctionDefinition().Return(&gollm.FunctionDefinition{Name: "mocktool"}).AnyTimes()
+			tool.EXPECT().IsInteractive(gomock.Any()).Return(false, nil).AnyTimes()
+			tool.EXPECT().CheckModifiesResource(gomock.Any()).Return("no").AnyTimes()
+			tool.EXPECT().Run(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, args map[string]any) (any, error) {
+				runs = append(runs, args)
+				return map[string]any{"status": "ok"}, nil
+			}).AnyTimes()
+
+			var toolset tools.Tools
+			toolset.Init()
+			toolset.RegisterTool(tool)
+			store := sessions.NewInMemoryChatStore()
+			a := &Agent{
+				ChatMessageStore: store,
+				LLM:              client,
+				Model:            "test-model",
+				Tools:            toolset,
+				MaxIterations:    4,
+				Session: &api.Session{
+					ID:               "test-session",
+					ChatMessageStore: store,
+					AgentState:       api.AgentStateIdle,
+				},
+			}
+			if err := a.Init(ctx); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			defer a.Close()
+			if err := a.Run(ctx, ""); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			recvUntil(t, ctx, a.Output, func(m *api.Message) bool { return m.Type == api.MessageTypeUserInputRequest })
+			a.Input <- &api.UserInputResponse{Query: "list the pods"}
+			recvUntil(t, ctx, a.Output, func(m *api.Message) bool {
+				return m.Source == api.MessageSourceModel && m.Type == api.MessageTypeText && m.Payload == "done"
+			})
+
+			if !reflect.DeepEqual(runs, tt.wantRuns) {
+				t.Errorf("tool runs = %v, want %v", runs, tt.wantRuns)
+			}
+			if len(sent) != len(tt.responses) {
+				t.Fatalf("sent %d requests to the model, want %d", len(sent), len(tt.responses))
+			}
+			// The result of the malformed call is sent back, with the ID of the call.
+			result, ok := sent[1][0].(gollm.FunctionCallResult)
+			if !ok || result.ID != "1" || result.Name != "mocktool" {
+				t.Fatalf("first tool result sent to the model = %#v, want the result of the call", sent[1])
+			}
+			if status, _ := result.Result["status"].(string); status != tt.wantStatus {
+				t.Errorf("status of the first tool result = %q, want %q (result %v)", status, tt.wantStatus, result.Result)
+			}
+			if tt.wantStatus == "invalid_arguments" {
+				if message, _ := result.Result["error"].(string); !strings.Contains(message, "not valid JSON") || result.Result["retryable"] != true {
+					t.Errorf("tool result = %v, want a retryable error on the arguments", result.Result)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePromptCapabilities(t *testing.T) {
+	tests := []struct {
+		name        string
+		model       string
+		toolUseShim bool
+		want        []string
+		notWant     []string
+	}{
+		{
+			name:    "native tools, large context and markdown",
+			model:   "gemini-2.5-pro",
+			want:    []string{"Format your answers in markdown"},
+			notWant: []string{"<tools>", "## Limited context", "plain text"},
+		},
+		{
+			name:        "tool use shim",
+			model:       "gemini-2.5-pro",
+			toolUseShim: true,
+			want:        []string{"<tools>", `"thought"`},
+		},
+		{
+			name:    "small context without markdown",
+			model:   "llama3:8b",
+			want:    []string{"## Limited context", "holds 8192 tokens", "Answer in plain text"},
+			notWant: []string{"Format your answers in markdown"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Agent{Model: tt.model, Provider: "ollama"}
+			prompt, err := a.generatePrompt(context.Background(), defaultSystemPromptTemplate, PromptData{EnableToolUseShim: tt.toolUseShim})
+			if err != nil {
+				t.Fatalf("generatePrompt() error = %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(prompt, s) {
+					t.Errorf("prompt does not contain %q", s)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(prompt, s) {
+					t.Errorf("prompt contains %q", s)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePromptUnknownCapability(t *testing.T) {
+	a := &Agent{Model: "gemini-2.5-pro"}
+	if _, err := a.generatePrompt(context.Background(), `{{if supports "telepathy"}}yes{{end}}`, PromptData{}); err == nil {
+		t.Fatal("expected an error for an unknown capability")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// providerName returns the name of a provider, without the URL of its
+// endpoint, e.g. "openai" for "openai://localhost:8000".
+func providerName(provider string) string {
+	name, _, _ := strings.Cut(provider, "://")
+	return name
+}
+
+// addUsage adds the usage of a response of the model to the usage of the session.
+func (c *Agent) addUsage(_ context.Context, usage gollm.Usage) {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+	for i := range c.Session.Usage {
+		u := &c.Session.Usage[i]
+		if u.Provider == usage.Provider && u.Model == usage.Model {
+			u.InputTokens += usage.InputTokens
+			u.OutputTokens += usage.OutputTokens
+			return
+		}
+	}
+	c.Session.Usage = append(c.Session.Usage, api.ModelUsage{
+		Provider:     usage.Provider,
+		Model:        usage.Model,
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+	})
+}
+
+// SessionUsage returns a copy of the usage of the session.
+func (c *Agent) SessionUsage() []api.ModelUsage {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+	return append([]api.ModelUsage(nil), c.Session.Usage...)
+}
+
+// usageReport formats the tokens and the estimated cost in USD of the usage
+// of a session, per provider and model, as a markdown table.
+func usageReport(usage []api.ModelUsage) string {
+	if len(usage) == 0 {
+		return "No token usage was reported by the model in this session."
+	}
+	var b strings.Builder
+	b.WriteString("| Provider | Model | Input tokens | Output tokens | Estimated cost |\n")
+	b.WriteString("|---|---|---:|---:|---:|\n")
+	var input, output int64
+	var total float64
+	unpriced := false
+	for _, u := range usage {
+		input += u.InputTokens
+		output += u.OutputTokens
+		cost := "unknown"
+		if usd, ok := gollm.EstimateCost(u.Model, u.InputTokens, u.OutputTokens); ok {
+			total += usd
+			cost = formatUSD(usd)
+		} else {
+			unpriced = true
+		}
+		fmt.Fprintf(&b, "| %s | %s | %d | %d | %s |\n", u.Provider, u.Model, u.InputTokens, u.OutputTokens, cost)
+	}
+	totalCost := formatUSD(total)
+	if unpriced {
+		// The price of some models, e.g. the local ones, is unknown.
+		totalCost = "at least " + totalCost
+	}
+	fmt.Fprintf(&b, "| **Total** | | %d | %d | %s |\n", input, output, totalCost)
+	return b.String()
+}
+
+// formatUSD formats an amount in USD, with the precision of the small costs of single sessions.
+func formatUSD(usd float64) string {
+	if usd != 0 && usd < 0.01 {
+		return fmt.Sprintf("$%.4f", usd)
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+// farewell returns the message of the end of the session, with its usage report.
+func (c *Agent) farewell() string {
+	const goodbye = "It has been a pleasure assisting you. Have a great day!"
+	usage := c.SessionUsage()
+	if len(usage) == 0 {
+		return goodbye
+	}
+	return "Usage of this session:\n\n" + usageReport(usage) + "\n" + goodbye
+}
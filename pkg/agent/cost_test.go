@@ -0,0 +1,68 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+func TestAddUsage(t *testing.T) {
+	a := &Agent{Session: &api.Session{}}
+	ctx := context.Background()
+	a.addUsage(ctx, gollm.Usage{Provider: "gemini", Model: "gemini-2.5-pro", InputTokens: 100, OutputTokens: 10})
+	a.addUsage(ctx, gollm.Usage{Provider: "gemini", Model: "gemini-2.5-flash", InputTokens: 50, OutputTokens: 5})
+	a.addUsage(ctx, gollm.Usage{Provider: "gemini", Model: "gemini-2.5-pro", InputTokens: 200, OutputTokens: 20})
+
+	want := []api.ModelUsage{
+		{Provider: "gemini", Model: "gemini-2.5-pro", InputTokens: 300, OutputTokens: 30},
+		{Provider: "gemini", Model: "gemini-2.5-flash", InputTokens: 50, OutputTokens: 5},
+	}
+	if got := a.SessionUsage(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SessionUsage() = %+v, want %+v", got, want)
+	}
+}
+
+func TestUsageReport(t *testing.T) {
+	report := usageReport([]api.ModelUsage{
+		{Provider: "openai", Model: "gpt-4.1", InputTokens: 10_000, OutputTokens: 1_000},
+		{Provider: "ollama", Model: "llama3", InputTokens: 500, OutputTokens: 50},
+	})
+	for _, want := range []string{
+		"| openai | gpt-4.1 | 10000 | 1000 | $0.03 |",
+		"| ollama | llama3 | 500 | 50 | unknown |",
+		"| **Total** | | 10500 | 1050 | at least $0.03 |",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("usageReport() does not contain %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestProviderName(t *testing.T) {
+	for provider, want := range map[string]string{
+		"gemini":                  "gemini",
+		"openai://localhost:8000": "openai",
+	} {
+		if got := providerName(provider); got != want {
+			t.Errorf("providerName(%q) = %q, want %q", provider, got, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/journal"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
+	"k8s.io/klog/v2"
+)
+
+// dryRunVerbs are the kubectl verbs supporting --dry-run=server, and whether
+// they print the resulting objects with -o yaml.
+var dryRunVerbs = map[string]bool{
+	"apply": true, "create": true, "patch": true, "replace": true,
+	"scale": true, "autoscale": true, "expose": true, "run": true,
+	"set": true, "label": true, "annotate": true, "taint": true,
+	"delete": false, "drain": false, "cordon": false, "uncordon": false,
+}
+
+// plannedStep is a tool call that modifies resources, which was not applied in dry-run mode.
+type plannedStep struct {
+	// Command is the tool call as proposed by the model.
+	Command string `json:"command"`
+	// DryRunCommand is the command run instead, empty if the call was skipped.
+	DryRunCommand string `json:"dryRunCommand,omitempty"`
+}
+
+// dryRunCommand returns the server-side dry run of a kubectl command, e.g.
+// "kubectl scale deploy/web --replicas=3 --dry-run=server -o yaml". Only
+// single kubectl commands whose verb supports dry runs are rewritten.
+func dryRunCommand(command string) (string, bool) {
+	command = strings.TrimSpace(command)
+	if strings.ContainsAny(command, "|&;<>`\n") || strings.Contains(command, "$(") {
+		return "", false
+	}
+	commands, err := kubectl.Parse(command)
+	if err != nil || len(commands) != 1 {
+		return "", false
+	}
+	cmd := commands[0]
+	printsObjects, ok := dryRunVerbs[cmd.Verb]
+	if !ok || cmd.Trailing != nil || cmd.DryRun() || !strings.HasPrefix(command, cmd.Binary+" ") {
+		return "", false
+	}
+	command += " --dry-run=server"
+	if printsObjects && !cmd.HasFlag("-o", "--output") {
+		command += " -o yaml"
+	}
+	return command, true
+}
+
+// planToolCalls replaces the tool calls that modify resources with their
+// server-side dry run, or skips them if they cannot be dry run, and records
+// them in the plan presented at the end of the task.
+func (c *Agent) planToolCalls(ctx context.Context, calls []ToolCallAnalysis) ([]ToolCallAnalysis, error) {
+	var planned []ToolCallAnalysis
+	for _, call := range calls {
+		if call.ModifiesResourceStr == "no" {
+			planned = append(planned, call)
+			continue
+		}
+		step := plannedStep{Command: call.ParsedToolCall.Description()}
+		command, ok := call.FunctionCall.Arguments["command"].(string)
+		if ok && (call.FunctionCall.Name == "kubectl" || call.FunctionCall.Name == "bash") {
+			step.DryRunCommand, ok = dryRunCommand(command)
+		}
+		c.recordPlannedStep(ctx, step)
+		if !ok {
+			c.rejectToolCall(call, fmt.Errorf("dry run: %q was not run, it may modify resources and cannot be dry run; it was added to the plan", step.Command))
+			continue
+		}
+
+		functionCall := call.FunctionCall
+		functionCall.Arguments = maps.Clone(call.FunctionCall.Arguments)
+		functionCall.Arguments["command"] = step.DryRunCommand
+		// A dry run does not modify resources.
+		functionCall.Arguments["modifies_resource"] = "no"
+		analysis, err := c.analyzeToolCalls(ctx, []gollm.FunctionCall{functionCall})
+		if err != nil {
+			return nil, err
+		}
+		planned = append(planned, analysis[0])
+	}
+	return planned, nil
+}
+
+// recordPlannedStep adds a step to the plan, and records it in the journal.
+func (c *Agent) recordPlannedStep(ctx context.Context, step plannedStep) {
+	klog.FromContext(ctx).Info("Planning tool call", "command", step.Command, "dryRunCommand", step.DryRunCommand)
+	c.plan = append(c.plan, step)
+	journal.RecorderFromContext(ctx).Write(ctx, &journal.Event{
+		Timestamp: time.Now(),
+		Action:    "dry-run",
+		Payload:   step,
+	})
+}
+
+// presentPlan shows the steps planned during the task, and starts a new plan.
+func (c *Agent) presentPlan() {
+	if len(c.plan) == 0 {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("Dry run: the following commands were not applied.\n")
+	for i, step := range c.plan {
+		fmt.Fprintf(&b, "\n%d. `%s`", i+1, step.Command)
+		if step.DryRunCommand != "" {
+			fmt.Fprintf(&b, "\n   validated with `%s`", step.DryRunCommand)
+		} else {
+			b.WriteString("\n   skipped, it cannot be dry run")
+		}
+	}
+	c.plan = nil
+	c.addMessage(api.MessageSourceAgent, api.MessageTypeText, b.String())
+}
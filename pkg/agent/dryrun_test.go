@@ -0,0 +1,44 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import "testing"
+
+func TestDryRunCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+		wantOK  bool
+	}{
+		{command: "kubectl scale deploy/web --replicas=3", want: "kubectl scale deploy/web --replicas=3 --dry-run=server -o yaml", wantOK: true},
+		{command: "kubectl apply -f app.yaml -o json", want: "kubectl apply -f app.yaml -o json --dry-run=server", wantOK: true},
+		{command: "kubectl -n prod delete pod web", want: "kubectl -n prod delete pod web --dry-run=server", wantOK: true},
+		{command: "kubectl set image deploy/web web=nginx:1.27", want: "kubectl set image deploy/web web=nginx:1.27 --dry-run=server -o yaml", wantOK: true},
+		{command: "kubectl rollout restart deploy/web"},
+		{command: "kubectl exec web -- rm -rf /tmp/cache"},
+		{command: "kubectl apply -f app.yaml --dry-run=client"},
+		{command: "kubectl delete pod web && kubectl get pods"},
+		{command: "kubectl get pods -o name | xargs kubectl delete"},
+		{command: "helm upgrade web ./chart"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got, ok := dryRunCommand(tt.command)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("dryRunCommand(%q) = %q, %v, want %q, %v", tt.command, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
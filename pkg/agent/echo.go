@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// commandEcho describes a tool call about to run, shown with EchoCommands.
+type commandEcho struct {
+	command    string
+	kubeconfig string
+	workDir    string
+	executor   string
+	// envNames are the names of the session-scoped variables, their values may be secrets.
+	envNames []string
+	approval string
+}
+
+// String renders the echo as markdown: the command, then its environment and
+// how it was approved on one line.
+func (e commandEcho) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "About to run:\n```shell\n%s\n```\n", e.command)
+
+	kubeconfig := e.kubeconfig
+	if kubeconfig == "" {
+		kubeconfig = "default"
+	}
+	details := []string{
+		fmt.Sprintf("kubeconfig `%s`", kubeconfig),
+		fmt.Sprintf("workdir `%s`", e.workDir),
+		"executor " + e.executor,
+	}
+	if len(e.envNames) > 0 {
+		details = append(details, "env "+strings.Join(e.envNames, ", "))
+	}
+	details = append(details, e.approval)
+	b.WriteString(strings.Join(details, " · "))
+	return b.String()
+}
+
+// echoToolCall shows the exact command of a tool call and a summary of the
+// environment it runs in, before it runs, even when it was not approved by the user.
+func (c *Agent) echoToolCall(call ToolCallAnalysis) {
+	echo := commandEcho{
+		command:    call.ParsedToolCall.Description(),
+		kubeconfig: c.Kubeconfig,
+		workDir:    c.workDir,
+		executor:   "local",
+	}
+	switch {
+	case c.Sandbox != "":
+		echo.executor = "sandbox " + c.Sandbox
+	case c.Executor != nil:
+		echo.executor = "custom"
+	}
+	for name := range c.Env {
+		echo.envNames = append(echo.envNames, name)
+	}
+	slices.Sort(echo.envNames)
+	switch {
+	case call.PolicyDecision == PolicyRequireApproval:
+		echo.approval = "approved by the user (required by policy)"
+	case call.ModifiesResourceStr == "no":
+		echo.approval = "read-only"
+	case c.SkipPermissions:
+		echo.approval = "auto-approved (--skip-permissions)"
+	case c.allowedBySession(call):
+		echo.approval = "allowed for this session"
+	default:
+		echo.approval = "approved by the user"
+	}
+	c.addMessage(api.MessageSourceAgent, api.MessageTypeText, echo.String())
+}
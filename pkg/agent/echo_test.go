@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import "testing"
+
+func TestCommandEchoString(t *testing.T) {
+	tests := []struct {
+		name string
+		echo commandEcho
+		want string
+	}{
+		{
+			name: "local command",
+			echo: commandEcho{
+				command:    "kubectl get pods -n web",
+				kubeconfig: "/home/me/.kube/config",
+				workDir:    "/tmp/agent-workdir-1",
+				executor:   "local",
+				approval:   "read-only",
+			},
+			want: "About to run:\n```shell\nkubectl get pods -n web\n```\n" +
+				"kubeconfig `/home/me/.kube/config` · workdir `/tmp/agent-workdir-1` · executor local · read-only",
+		},
+		{
+			name: "session environment in a sandbox",
+			echo: commandEcho{
+				command:  "helm upgrade web ./chart",
+				workDir:  "/tmp/agent-workdir-2",
+				executor: "sandbox k8s",
+				envNames: []string{"AWS_PROFILE", "HELM_NAMESPACE"},
+				approval: "auto-approved (--skip-permissions)",
+			},
+			want: "About to run:\n```shell\nhelm upgrade web ./chart\n```\n" +
+				"kubeconfig `default` · workdir `/tmp/agent-workdir-2` · executor sandbox k8s · env AWS_PROFILE, HELM_NAMESPACE · auto-approved (--skip-permissions)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.echo.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
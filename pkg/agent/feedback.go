@@ -0,0 +1,107 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/journal"
+	"k8s.io/klog/v2"
+)
+
+const feedbackUsage = "Usage: /feedback up|down [comment]"
+
+// feedbackRatings are the words rating an answer up or down.
+var feedbackRatings = map[string]api.FeedbackRating{
+	"up": api.FeedbackUp, "+1": api.FeedbackUp, "good": api.FeedbackUp, "👍": api.FeedbackUp,
+	"down": api.FeedbackDown, "-1": api.FeedbackDown, "bad": api.FeedbackDown, "👎": api.FeedbackDown,
+}
+
+// parseFeedback parses the arguments of the feedback command, e.g. "down wrong
+// namespace", into the rating and the comment of the answer.
+func parseFeedback(args []string) (rating api.FeedbackRating, comment string, ok bool) {
+	if len(args) == 0 {
+		return "", "", false
+	}
+	rating, ok = feedbackRatings[strings.ToLower(args[0])]
+	if !ok {
+		return "", "", false
+	}
+	return rating, strings.Join(args[1:], " "), true
+}
+
+// recordFeedback rates the last answer of the session, replacing a previous
+// rating of the same answer. The rating is saved with the session and
+// recorded in the journal.
+func (c *Agent) recordFeedback(ctx context.Context, rating api.FeedbackRating, comment string) string {
+	answer, query := lastAnswer(c.Session.AllMessages())
+	if answer == nil {
+		return "There is no answer to rate yet."
+	}
+	feedback := api.Feedback{
+		Rating:    rating,
+		Comment:   comment,
+		MessageID: answer.ID,
+		Query:     query,
+		Provider:  providerName(c.Provider),
+		Model:     c.Model,
+		Time:      time.Now(),
+	}
+
+	c.sessionMu.Lock()
+	rated := false
+	for i := range c.Session.Feedback {
+		if c.Session.Feedback[i].MessageID == answer.ID {
+			c.Session.Feedback[i] = feedback
+			rated = true
+		}
+	}
+	if !rated {
+		c.Session.Feedback = append(c.Session.Feedback, feedback)
+	}
+	c.sessionMu.Unlock()
+
+	klog.FromContext(ctx).Info("Recording feedback", "rating", rating, "messageID", answer.ID)
+	journal.RecorderFromContext(ctx).Write(ctx, &journal.Event{
+		Timestamp: feedback.Time,
+		Action:    "feedback",
+		Payload:   feedback,
+	})
+	c.saveSessionMetadata(ctx)
+
+	if rating == api.FeedbackUp {
+		return "Thanks, the answer was rated up."
+	}
+	return "Thanks, the answer was rated down."
+}
+
+// lastAnswer returns the last answer of the model among messages, and the
+// request of the user it answers.
+func lastAnswer(messages []*api.Message) (answer *api.Message, query string) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		switch {
+		case answer == nil && msg.Source == api.MessageSourceModel && msg.Type == api.MessageTypeText:
+			answer = msg
+		case answer != nil && msg.Source == api.MessageSourceUser && msg.Type == api.MessageTypeText:
+			query, _ = msg.Payload.(string)
+			return answer, query
+		}
+	}
+	return answer, ""
+}
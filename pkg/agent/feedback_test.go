@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+)
+
+func TestParseFeedback(t *testing.T) {
+	tests := []struct {
+		args        []string
+		wantRating  api.FeedbackRating
+		wantComment string
+		wantOK      bool
+	}{
+		{args: []string{"up"}, wantRating: api.FeedbackUp, wantOK: true},
+		{args: []string{"Down", "wrong", "namespace"}, wantRating: api.FeedbackDown, wantComment: "wrong namespace", wantOK: true},
+		{args: []string{"👎"}, wantRating: api.FeedbackDown, wantOK: true},
+		{args: []string{"loops", "in", "the", "HPA"}},
+		{},
+	}
+	for _, tt := range tests {
+		rating, comment, ok := parseFeedback(tt.args)
+		if rating != tt.wantRating || comment != tt.wantComment || ok != tt.wantOK {
+			t.Errorf("parseFeedback(%q) = %q, %q, %v, want %q, %q, %v", tt.args, rating, comment, ok, tt.wantRating, tt.wantComment, tt.wantOK)
+		}
+	}
+}
+
+func TestRecordFeedback(t *testing.T) {
+	manager, err := sessions.NewSessionManager("memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := manager.NewSession(sessions.Metadata{ModelID: "test-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Agent{Session: session, SessionBackend: "memory", Provider: "gemini", Model: "gemini-2.5-pro"}
+
+	if got := a.recordFeedback(context.Background(), api.FeedbackUp, ""); got != "There is no answer to rate yet." {
+		t.Errorf("recordFeedback() without answer = %q", got)
+	}
+
+	for _, msg := range []*api.Message{
+		{ID: "1", Source: api.MessageSourceUser, Type: api.MessageTypeText, Payload: "why is web pending?"},
+		{ID: "2", Source: api.MessageSourceModel, Type: api.MessageTypeText, Payload: "The node pool is full."},
+		{ID: "3", Source: api.MessageSourceUser, Type: api.MessageTypeText, Payload: "/feedback up"},
+	} {
+		session.ChatMessageStore.AddChatMessage(msg)
+	}
+	a.recordFeedback(context.Background(), api.FeedbackUp, "")
+	a.recordFeedback(context.Background(), api.FeedbackDown, "the quota was exceeded")
+
+	if len(session.Feedback) != 1 {
+		t.Fatalf("Feedback = %+v, want the second rating to replace the first one", session.Feedback)
+	}
+	got := session.Feedback[0]
+	if got.Rating != api.FeedbackDown || got.Comment != "the quota was exceeded" || got.MessageID != "2" || got.Query != "why is web pending?" || got.Provider != "gemini" || got.Model != "gemini-2.5-pro" {
+		t.Errorf("Feedback = %+v", got)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"k8s.io/klog/v2"
+)
+
+// ForkSession clones the current session into a new session and continues
+// in it, to explore an alternative without losing the original line of
+// investigation. The fork gets a copy of the history and of the files of the
+// work directory, the artifacts, while the original files are left as they
+// are. It returns the IDs of the original session and of the fork.
+func (c *Agent) ForkSession() (parentID, forkID string, err error) {
+	// Ephemeral sessions are saved first, to be resumed later.
+	parentID, err = c.SaveSession()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to save current session: %w", err)
+	}
+	manager, err := sessions.NewSessionManager(c.SessionBackend)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create session manager: %w", err)
+	}
+	c.sessionMu.Lock()
+	err = manager.UpdateLastAccessed(c.Session)
+	c.sessionMu.Unlock()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to save current session: %w", err)
+	}
+
+	var workDir string
+	if c.workDir != "" {
+		workDir, err = os.MkdirTemp("", "agent-workdir-*")
+		if err != nil {
+			return "", "", fmt.Errorf("creating the work directory of the fork: %w", err)
+		}
+		if err := copyDir(workDir, c.workDir); err != nil {
+			os.RemoveAll(workDir)
+			return "", "", fmt.Errorf("copying the work directory: %w", err)
+		}
+	}
+
+	fork, err := manager.ForkSession(parentID)
+	if err != nil {
+		if workDir != "" {
+			os.RemoveAll(workDir)
+		}
+		return "", "", fmt.Errorf("failed to fork session %s: %w", parentID, err)
+	}
+	if workDir != "" {
+		klog.Info("Copied the work directory for the fork", "from", c.workDir, "to", workDir)
+		c.previousWorkDirs = append(c.previousWorkDirs, c.workDir)
+		c.workDir = workDir
+	}
+	if err := c.LoadSession(fork.ID); err != nil {
+		return "", "", fmt.Errorf("failed to load the fork %s: %w", fork.ID, err)
+	}
+	return parentID, fork.ID, nil
+}
+
+// copyDir copies the directories and the regular files of src into dst.
+// Other files, e.g. symlinks, are skipped.
+func copyDir(dst, src string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case d.Type().IsRegular():
+			return copyFile(target, path)
+		}
+		return nil
+	})
+}
+
+func copyFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
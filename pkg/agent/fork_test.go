@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+)
+
+func TestForkSession(t *testing.T) {
+	manager, err := sessions.NewSessionManager("memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := manager.NewSession(sessions.Metadata{ModelID: "test-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent.ChatMessageStore.AddChatMessage(&api.Message{ID: "1", Source: api.MessageSourceUser, Type: api.MessageTypeText, Payload: "why is web pending?"})
+
+	workDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workDir, "reports"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "reports", "nodes.txt"), []byte("node-1 full"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a := &Agent{
+		Session:          parent,
+		ChatMessageStore: parent.ChatMessageStore,
+		SessionBackend:   "memory",
+		workDir:          workDir,
+	}
+
+	parentID, forkID, err := a.ForkSession()
+	if err != nil {
+		t.Fatalf("ForkSession() error: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(a.workDir) })
+
+	if parentID != parent.ID || a.Session.ID != forkID || a.Session.ParentID != parent.ID {
+		t.Errorf("ForkSession() = %s, %s, session %s forked from %s, want a new session forked from %s", parentID, forkID, a.Session.ID, a.Session.ParentID, parent.ID)
+	}
+	if got := len(a.Session.ChatMessageStore.ChatMessages()); got != 1 {
+		t.Errorf("fork history has %d messages, want 1", got)
+	}
+
+	if a.workDir == workDir {
+		t.Fatalf("fork work directory = %s, want a copy", a.workDir)
+	}
+	copied, err := os.ReadFile(filepath.Join(a.workDir, "reports", "nodes.txt"))
+	if err != nil || string(copied) != "node-1 full" {
+		t.Errorf("copied artifact = %q, %v, want the original content", copied, err)
+	}
+	// The fork does not change the files of the original session.
+	if err := os.WriteFile(filepath.Join(a.workDir, "reports", "nodes.txt"), []byte("node-1 scaled"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if original, _ := os.ReadFile(filepath.Join(workDir, "reports", "nodes.txt")); string(original) != "node-1 full" {
+		t.Errorf("original artifact = %q after the fork changed it", original)
+	}
+}
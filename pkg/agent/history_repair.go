@@ -0,0 +1,76 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"k8s.io/klog/v2"
+)
+
+// malformedHistory matches the errors of the providers rejecting the shape of
+// the history sent rather than its contents, e.g. the empty turn left by an
+// empty response of the model, or a tool result without its tool call.
+var malformedHistory = regexp.MustCompile(`(?i)(must not be empty|contents\.parts|at least one part|empty (text|content|message)|tool_call_id|tool_use_id|must be a response to a prece+ding message|function (call|response) turn|roles must alternate)`)
+
+// isMalformedHistoryError returns true if the provider rejected the request
+// because of its history. Resending the same history fails the same way.
+func isMalformedHistoryError(err error) bool {
+	var apiErr *gollm.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode != 0 && apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	return malformedHistory.MatchString(err.Error())
+}
+
+// sanitizeContents removes the empty texts of the contents sent to the model,
+// which the providers reject as empty parts.
+func sanitizeContents(contents []any) []any {
+	sanitized := contents[:0:0]
+	for _, content := range contents {
+		if text, ok := content.(string); ok && strings.TrimSpace(text) == "" {
+			continue
+		}
+		sanitized = append(sanitized, content)
+	}
+	return sanitized
+}
+
+// repairHistory restarts the chat after the provider rejected its history, so
+// that the agent does not fail the same way on every following request. The
+// chat is restarted as when the history is compacted: the history held by the
+// provider chat, including the offending turn, is replaced by a transcript of
+// the session, and the rejected contents are sent again without their empty
+// parts. It returns false if the error is not about the history, or if the
+// history was already repaired since the last response.
+func (c *Agent) repairHistory(ctx context.Context, err error, sent []any) bool {
+	if c.historyRepaired || !isMalformedHistoryError(err) {
+		return false
+	}
+	log := klog.FromContext(ctx)
+	log.Info("Repairing the chat history rejected by the provider", "error", err)
+	c.historyRepaired = true
+	c.currChatContent = sanitizeContents(sent)
+	if err := c.compactHistory(ctx); err != nil {
+		log.Error(err, "repairing the chat history")
+		return false
+	}
+	return true
+}
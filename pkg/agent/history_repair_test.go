@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/internal/mocks"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIsMalformedHistoryError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "gemini empty parts", err: fmt.Errorf("failed to generate content: %w", &gollm.APIError{StatusCode: http.StatusBadRequest, Message: "* GenerateContentRequest.contents[3].parts: contents.parts must not be empty."}), want: true},
+		{name: "openai tool message", err: errors.New(`400 Bad Request: messages with role 'tool' must be a response to a preceeding message with 'tool_calls'`), want: true},
+		{name: "anthropic tool result", err: errors.New("messages.2: unexpected `tool_use_id` found in `tool_result` blocks"), want: true},
+		{name: "rate limited", err: &gollm.APIError{StatusCode: http.StatusTooManyRequests, Message: "contents.parts must not be empty"}, want: false},
+		{name: "other bad request", err: &gollm.APIError{StatusCode: http.StatusBadRequest, Message: "API key not valid"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMalformedHistoryError(tt.err); got != tt.want {
+				t.Errorf("isMalformedHistoryError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeContents(t *testing.T) {
+	result := gollm.FunctionCallResult{Name: "kubectl", Result: map[string]any{}}
+	got := sanitizeContents([]any{"", result, " \n", "list the pods"})
+	want := []any{result, "list the pods"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sanitizeContents() = %v, want %v", got, want)
+	}
+}
+
+func TestRepairHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockClient(ctrl)
+	chat := mocks.NewMockChat(ctrl)
+	rejectedChat := mocks.NewMockChat(ctrl)
+
+	store := sessions.NewInMemoryChatStore()
+	for _, message := range []*api.Message{
+		{ID: "1", Source: api.MessageSourceUser, Type: api.MessageTypeText, Payload: "is web-0 running?"},
+		{ID: "2", Source: api.MessageSourceModel, Type: api.MessageTypeToolCallRequest, Payload: "kubectl get pod web-0"},
+	} {
+		if err := store.AddChatMessage(message); err != nil {
+			t.Fatalf("adding message: %v", err)
+		}
+	}
+	a := &Agent{
+		LLM:     client,
+		Model:   "test-model",
+		Session: &api.Session{ChatMessageStore: store},
+		llmChat: rejectedChat,
+	}
+	client.EXPECT().StartChat(gomock.Any(), "test-model").Return(chat)
+	chat.EXPECT().SetFunctionDefinitions(gomock.Any()).Return(nil)
+
+	rejected := &gollm.APIError{StatusCode: http.StatusBadRequest, Message: "contents.parts must not be empty"}
+	sent := []any{gollm.FunctionCallResult{Name: "kubectl", Result: map[string]any{"stdout": "web-0   1/1   Running"}}, ""}
+	if !a.repairHistory(context.Background(), rejected, sent) {
+		t.Fatal("repairHistory() = false, want the history repaired")
+	}
+	if a.llmChat == rejectedChat {
+		t.Error("the chat was not restarted")
+	}
+	if len(a.currChatContent) != 2 {
+		t.Fatalf("currChatContent = %v, want the transcript and the result without the empty text", a.currChatContent)
+	}
+	if transcript := a.currChatContent[0].(string); !strings.Contains(transcript, "is web-0 running?") {
+		t.Errorf("transcript does not hold the history:\n%s", transcript)
+	}
+	if result, ok := a.currChatContent[1].(string); !ok || !strings.Contains(result, "Running") {
+		t.Errorf("function result = %v, want it as text", a.currChatContent[1])
+	}
+
+	// The history is repaired once until the next response.
+	if a.repairHistory(context.Background(), rejected, sent) {
+		t.Error("repairHistory() = true after a repair, want false")
+	}
+	a.historyRepaired = false
+	if a.repairHistory(context.Background(), errors.New("connection reset"), sent) {
+		t.Error("repairHistory() = true for another error, want false")
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"k8s.io/klog/v2"
+)
+
+// hookTimeout bounds the run time of a tool hook.
+const hookTimeout = 30 * time.Second
+
+// The events of the tool hooks.
+const (
+	hookEventPreTool  = "pre_tool"
+	hookEventPostTool = "post_tool"
+)
+
+// hookInput is the JSON sent on the stdin of a tool hook.
+type hookInput struct {
+	Event            string         `json:"event"`
+	SessionID        string         `json:"sessionId,omitempty"`
+	Tool             string         `json:"tool"`
+	Arguments        map[string]any `json:"arguments"`
+	Command          string         `json:"command"`
+	ModifiesResource string         `json:"modifiesResource"`
+	Kubeconfig       string         `json:"kubeconfig,omitempty"`
+	// Result and Error are set for the post_tool event.
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (c *Agent) newHookInput(event string, call ToolCallAnalysis) *hookInput {
+	input := &hookInput{
+		Event:            event,
+		Tool:             call.FunctionCall.Name,
+		Arguments:        call.FunctionCall.Arguments,
+		Command:          call.ParsedToolCall.Description(),
+		ModifiesResource: call.ModifiesResourceStr,
+		Kubeconfig:       c.Kubeconfig,
+	}
+	if c.Session != nil {
+		input.SessionID = c.Session.ID
+	}
+	return input
+}
+
+// runHook runs a hook script with sh, the input as JSON on its stdin. It
+// returns an error with the output of the hook if the hook exits with a non-zero status.
+func runHook(ctx context.Context, hook string, input *hookInput, kubeconfig string) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("encoding hook input: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(), "KUBECTL_AI_HOOK_EVENT="+input.Event)
+	if kubeconfig != "" {
+		cmd.Env = append(cmd.Env, "KUBECONFIG="+kubeconfig)
+	}
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(output.String())
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && message != "" {
+			return fmt.Errorf("%s (exit status %d)", message, exitErr.ExitCode())
+		}
+		return err
+	}
+	return nil
+}
+
+// runPreToolHooks runs the pre-tool hooks of a tool call. The tool call must
+// not run if one of them fails.
+func (c *Agent) runPreToolHooks(ctx context.Context, call ToolCallAnalysis) error {
+	for _, hook := range c.PreToolHooks {
+		if err := runHook(ctx, hook, c.newHookInput(hookEventPreTool, call), c.Kubeconfig); err != nil {
+			return fmt.Errorf("blocked by the pre-tool hook %q: %w", hook, err)
+		}
+	}
+	return nil
+}
+
+// runPostToolHooks runs the post-tool hooks of a tool call with its result.
+// Their failures are reported to the user, the result is kept.
+func (c *Agent) runPostToolHooks(ctx context.Context, call ToolCallAnalysis, result any, toolErr error) {
+	for _, hook := range c.PostToolHooks {
+		input := c.newHookInput(hookEventPostTool, call)
+		input.Result = result
+		if toolErr != nil {
+			input.Error = toolErr.Error()
+		}
+		if err := runHook(ctx, hook, input, c.Kubeconfig); err != nil {
+			klog.FromContext(ctx).Error(err, "running post-tool hook", "hook", hook)
+			c.addMessage(api.MessageSourceAgent, api.MessageTypeError, fmt.Sprintf("The post-tool hook %q failed: %v", hook, err))
+		}
+	}
+}
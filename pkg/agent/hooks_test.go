@@ -0,0 +1,70 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRunHook(t *testing.T) {
+	input := &hookInput{
+		Event:            hookEventPreTool,
+		Tool:             "kubectl",
+		Arguments:        map[string]any{"command": "kubectl delete pod web-0 -n prod"},
+		Command:          "kubectl delete pod web-0 -n prod",
+		ModifiesResource: "yes",
+	}
+
+	tests := []struct {
+		name    string
+		hook    string
+		wantErr string
+	}{
+		{
+			name: "allows",
+			hook: "true",
+		},
+		{
+			name: "reads the call on stdin",
+			hook: `grep -q '"command":"kubectl delete pod web-0 -n prod"' && test "$KUBECTL_AI_HOOK_EVENT" = pre_tool`,
+		},
+		{
+			name:    "vetoes with a message",
+			hook:    `echo "a ticket ID is required for prod changes" >&2; exit 3`,
+			wantErr: "a ticket ID is required for prod changes (exit status 3)",
+		},
+		{
+			name:    "vetoes without a message",
+			hook:    "exit 1",
+			wantErr: "exit status 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runHook(context.Background(), tt.hook, input, "")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("runHook() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("runHook() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"k8s.io/klog/v2"
+)
+
+// errInterrupted is the cause of the cancellation of the run context by Interrupt.
+var errInterrupted = errors.New("interrupted by the user")
+
+// Interrupt stops the model call or the tool calls of the running task, if
+// any, and returns false if there is none. The agent then waits for the next
+// query, which is sent to the model with the results of the tool calls
+// completed so far.
+func (c *Agent) Interrupt() bool {
+	if c.AgentState() != api.AgentStateRunning {
+		return false
+	}
+	c.interruptMu.Lock()
+	defer c.interruptMu.Unlock()
+	if c.interrupt != nil {
+		c.interrupt(errInterrupted)
+	}
+	return true
+}
+
+// runContext returns the context of the model and tool calls, derived from
+// the context of the agent loop. A new one is started once the previous one
+// was interrupted.
+func (c *Agent) runContext(ctx context.Context) context.Context {
+	c.interruptMu.Lock()
+	defer c.interruptMu.Unlock()
+	if c.runCtx == nil || c.runCtx.Err() != nil {
+		c.runCtx, c.interrupt = context.WithCancelCause(ctx)
+	}
+	return c.runCtx
+}
+
+// interrupted ends the task if ctx was canceled by Interrupt, and returns
+// true if it was.
+func (c *Agent) interrupted(ctx context.Context) bool {
+	if !errors.Is(context.Cause(ctx), errInterrupted) {
+		return false
+	}
+	klog.FromContext(ctx).Info("Task interrupted by the user", "currIteration", c.currIteration)
+	c.interruptedContent = append(c.currChatContent, c.interruptedResults()...)
+	c.currChatContent = []any{}
+	c.currIteration = 0
+	c.pendingFunctionCalls = []ToolCallAnalysis{}
+	c.lastErr = nil
+	c.setAgentState(api.AgentStateDone)
+	c.addMessage(api.MessageSourceAgent, api.MessageTypeText, "Interrupted.")
+	return true
+}
+
+// interruptedResults returns the results of the pending tool calls that did
+// not complete, as the model expects a result for each of its calls. The
+// calls are dispatched in order, one result each.
+func (c *Agent) interruptedResults() []any {
+	if c.EnableToolUseShim {
+		return nil
+	}
+	completed := 0
+	for _, content := range c.currChatContent {
+		if _, ok := content.(gollm.FunctionCallResult); ok {
+			completed++
+		}
+	}
+	var results []any
+	for _, call := range c.pendingFunctionCalls[min(completed, len(c.pendingFunctionCalls)):] {
+		results = append(results, gollm.FunctionCallResult{
+			ID:     call.FunctionCall.ID,
+			Name:   call.FunctionCall.Name,
+			Result: map[string]any{"error": errInterrupted.Error()},
+		})
+	}
+	return results
+}
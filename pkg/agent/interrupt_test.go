@@ -0,0 +1,78 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+)
+
+func TestInterrupt(t *testing.T) {
+	tests := []struct {
+		name  string
+		state api.AgentState
+		// wantContent is the number of chat contents kept for the next query.
+		wantContent     int
+		wantInterrupted bool
+	}{
+		{name: "running", state: api.AgentStateRunning, wantContent: 2, wantInterrupted: true},
+		{name: "idle", state: api.AgentStateIdle},
+		{name: "waiting for input", state: api.AgentStateWaitingForInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Agent{
+				Output: make(chan any, 10),
+				Session: &api.Session{
+					AgentState:       tt.state,
+					ChatMessageStore: sessions.NewInMemoryChatStore(),
+				},
+				pendingFunctionCalls: []ToolCallAnalysis{
+					{FunctionCall: gollm.FunctionCall{ID: "1", Name: "kubectl"}},
+					{FunctionCall: gollm.FunctionCall{ID: "2", Name: "kubectl"}},
+				},
+				currChatContent: []any{gollm.FunctionCallResult{ID: "1", Name: "kubectl"}},
+			}
+			ctx := a.runContext(context.Background())
+			a.Interrupt()
+
+			if got := a.interrupted(ctx); got != tt.wantInterrupted {
+				t.Fatalf("interrupted() = %v, want %v", got, tt.wantInterrupted)
+			}
+			if len(a.interruptedContent) != tt.wantContent {
+				t.Fatalf("got %d interrupted contents, want %d", len(a.interruptedContent), tt.wantContent)
+			}
+			if !tt.wantInterrupted {
+				if ctx.Err() != nil {
+					t.Errorf("the run context was canceled outside of a task")
+				}
+				return
+			}
+			if result, ok := a.interruptedContent[1].(gollm.FunctionCallResult); !ok || result.ID != "2" {
+				t.Errorf("got %+v, want the result of the call that did not complete", a.interruptedContent[1])
+			}
+			if a.AgentState() != api.AgentStateDone {
+				t.Errorf("got state %s, want %s", a.AgentState(), api.AgentStateDone)
+			}
+			if next := a.runContext(context.Background()); next.Err() != nil {
+				t.Errorf("the next run context is canceled: %v", next.Err())
+			}
+		})
+	}
+}
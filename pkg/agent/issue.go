@@ -0,0 +1,172 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/journal"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"k8s.io/klog/v2"
+)
+
+const newIssueURL = "https://github.com/GoogleCloudPlatform/kubectl-ai/issues/new"
+
+// issueJournalEvents is the number of trailing journal events quoted in an issue.
+const issueJournalEvents = 20
+
+// maxIssueTitleChars bounds the error quoted in the title of an issue.
+const maxIssueTitleChars = 80
+
+// volatileErrorParts match the parts of provider errors that differ between
+// occurrences of the same failure: request IDs, UUIDs and timestamps.
+var volatileErrorParts = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b|\b\d{4}-\d{2}-\d{2}t[0-9:.]+(z|[+-][0-9:]+)?|\b[0-9a-f]{16,}\b|\b\d{6,}\b`)
+
+// providerFailure is an error of the provider and the requests that ran into it.
+type providerFailure struct {
+	Error       string
+	Count       int
+	First, Last time.Time
+	// Request describes the last request that failed, without its contents.
+	Request requestMetadata
+}
+
+// requestMetadata describes a request sent to the provider, without the
+// contents that may hold cluster data.
+type requestMetadata struct {
+	Iteration       int
+	HistoryMessages int
+	// Contents describe the contents sent, e.g. "text (120 characters)".
+	Contents    []string
+	Functions   int
+	ToolUseShim bool
+}
+
+// errorSignature identifies the occurrences of the same provider error: its
+// first line without the parts that differ between occurrences.
+func errorSignature(err error) string {
+	line, _, _ := strings.Cut(err.Error(), "\n")
+	return volatileErrorParts.ReplaceAllString(strings.TrimSpace(line), "<id>")
+}
+
+// recordProviderError counts the errors of the provider on the contents
+// sent, and offers to generate an issue once the same error occurred
+// IssueReportThreshold times.
+func (c *Agent) recordProviderError(ctx context.Context, err error, sent []any) {
+	if c.providerFailures == nil {
+		c.providerFailures = make(map[string]*providerFailure)
+	}
+	signature := errorSignature(err)
+	failure := c.providerFailures[signature]
+	now := time.Now()
+	if failure == nil {
+		failure = &providerFailure{Error: err.Error(), First: now}
+		c.providerFailures[signature] = failure
+	}
+	failure.Count++
+	failure.Last = now
+	failure.Request = requestMetadata{
+		Iteration:       c.currIteration,
+		HistoryMessages: len(c.chatHistory()),
+		Contents:        describeContents(sent),
+		Functions:       len(c.functionDefinitions),
+		ToolUseShim:     c.EnableToolUseShim,
+	}
+	c.lastFailure = failure
+
+	if c.IssueReportThreshold > 0 && failure.Count == c.IssueReportThreshold {
+		klog.FromContext(ctx).Info("Provider error repeated", "error", signature, "count", failure.Count)
+		c.addMessage(api.MessageSourceAgent, api.MessageTypeText, fmt.Sprintf(
+			"The provider returned the same error %d times. Type `/report-issue` to generate a pre-filled GitHub issue with the redacted details of the failure.",
+			failure.Count))
+	}
+}
+
+// describeContents describes the contents sent to the provider by their kind and size.
+func describeContents(contents []any) []string {
+	var descriptions []string
+	for _, content := range contents {
+		switch content := content.(type) {
+		case string:
+			descriptions = append(descriptions, fmt.Sprintf("text (%d characters)", len(content)))
+		case gollm.FunctionCallResult:
+			descriptions = append(descriptions, fmt.Sprintf("function result %s (%d characters)", content.Name, len(formatPayload(content.Result))))
+		default:
+			descriptions = append(descriptions, fmt.Sprintf("%T", content))
+		}
+	}
+	return descriptions
+}
+
+// reportIssue writes a pre-filled GitHub issue about the last provider error
+// to a temporary file, and returns it with the link to open the issue.
+func (c *Agent) reportIssue() (string, error) {
+	if c.lastFailure == nil {
+		return "No provider error occurred in this session.", nil
+	}
+	title, body := c.issueTemplate(c.lastFailure)
+
+	f, err := os.CreateTemp("", "kubectl-ai-issue-*.md")
+	if err != nil {
+		return "", fmt.Errorf("writing issue: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(body); err != nil {
+		return "", fmt.Errorf("writing issue: %w", err)
+	}
+
+	link := newIssueURL + "?title=" + url.QueryEscape(title)
+	return fmt.Sprintf("Wrote a pre-filled issue to %s. Secrets were removed, but please review it before pasting it in a new issue at %s\n\n%s", f.Name(), link, body), nil
+}
+
+// issueTemplate returns the title and the body of an issue about a provider
+// failure, with the secrets of the environment removed.
+func (c *Agent) issueTemplate(failure *providerFailure) (title, body string) {
+	errorLine, _, _ := strings.Cut(failure.Error, "\n")
+	title = fmt.Sprintf("%s: %s", c.Provider, truncateOutput(errorLine, maxIssueTitleChars))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Description\n\n")
+	fmt.Fprintf(&b, "The %s provider returned the same error %d times between %s and %s.\n\n",
+		c.Provider, failure.Count, failure.First.UTC().Format(time.RFC3339), failure.Last.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "<!-- What were you asking kubectl-ai to do? -->\n\n")
+	fmt.Fprintf(&b, "## Error\n\n```text\n%s\n```\n\n", failure.Error)
+	fmt.Fprintf(&b, "## Environment\n\n")
+	fmt.Fprintf(&b, "- Provider: %s\n- Model: %s\n", c.Provider, c.Model)
+	fmt.Fprintf(&b, "- kubectl-ai version: <!-- output of kubectl-ai version -->\n")
+	fmt.Fprintf(&b, "- OS: %s/%s\n\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "## Last failed request\n\n")
+	fmt.Fprintf(&b, "- Iteration: %d\n- Messages in the session: %d\n", failure.Request.Iteration, failure.Request.HistoryMessages)
+	fmt.Fprintf(&b, "- Function definitions: %d\n- Tool use shim: %t\n", failure.Request.Functions, failure.Request.ToolUseShim)
+	fmt.Fprintf(&b, "- Contents sent:\n")
+	for _, content := range failure.Request.Contents {
+		fmt.Fprintf(&b, "  - %s\n", content)
+	}
+	if c.TracePath != "" {
+		fmt.Fprintf(&b, "\n## Last journal events\n\n<details>\n\n```yaml\n%s```\n\n</details>\n", journal.LastEvents(c.TracePath, issueJournalEvents))
+	}
+
+	secrets := journal.SecretValues(append(os.Environ(), c.toolEnv()...), tools.DefaultSensitiveEnvPatterns)
+	return journal.RedactSecrets(title, secrets), journal.RedactSecrets(b.String(), secrets)
+}
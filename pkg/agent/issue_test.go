@@ -0,0 +1,114 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/journal"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+)
+
+func TestErrorSignature(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{
+			a:    "Error 400, Message: contents.parts must not be empty, request id 3f2b8c1e-9a4d-4e7f-8b6a-1c2d3e4f5a6b",
+			b:    "Error 400, Message: contents.parts must not be empty, request id 0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d",
+			same: true,
+		},
+		{
+			a:    "rate limited at 2025-06-01T10:00:00Z, retry after 1717236000",
+			b:    "rate limited at 2025-06-01T10:05:12.5Z, retry after 1717236312",
+			same: true,
+		},
+		{
+			a: "Error 400, Message: contents.parts must not be empty",
+			b: "Error 500, Message: internal error",
+		},
+	}
+	for _, tt := range tests {
+		a, b := errorSignature(errors.New(tt.a)), errorSignature(errors.New(tt.b))
+		if (a == b) != tt.same {
+			t.Errorf("errorSignature(%q) = %q, errorSignature(%q) = %q, want same = %v", tt.a, a, tt.b, b, tt.same)
+		}
+	}
+}
+
+func TestRecordProviderError(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "gemini-secret-value")
+
+	tracePath := filepath.Join(t.TempDir(), "trace.txt")
+	recorder, err := journal.NewFileRecorder(tracePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := map[string]any{"url": "https://generativelanguage.googleapis.com/v1beta/models?key=gemini-secret-value"}
+	if err := recorder.Write(context.Background(), &journal.Event{Action: journal.ActionHTTPRequest, Payload: payload}); err != nil {
+		t.Fatal(err)
+	}
+	recorder.Close()
+
+	manager, err := sessions.NewSessionManager("memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := manager.NewSession(sessions.Metadata{ModelID: "gemini-2.5-pro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Agent{Session: session, Provider: "gemini", Model: "gemini-2.5-pro", TracePath: tracePath, IssueReportThreshold: 2, Output: make(chan any, 10)}
+
+	if got, _ := a.reportIssue(); got != "No provider error occurred in this session." {
+		t.Errorf("reportIssue() without errors = %q", got)
+	}
+
+	sent := []any{"", gollm.FunctionCallResult{Name: "kubectl", Result: map[string]any{"stdout": "ok"}}}
+	offers := func() int {
+		n := 0
+		for _, msg := range a.chatHistory() {
+			if s, ok := msg.Payload.(string); ok && strings.Contains(s, "/report-issue") {
+				n++
+			}
+		}
+		return n
+	}
+	for i, id := range []string{"3f2b8c1e-9a4d-4e7f-8b6a-1c2d3e4f5a6b", "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d", "1b2c3d4e-5f6a-4b7c-8d9e-0f1a2b3c4d5e"} {
+		a.recordProviderError(context.Background(), errors.New("Error 400, Message: contents.parts must not be empty, request id "+id), sent)
+		if want := min(i, 1); offers() != want {
+			t.Errorf("after %d errors, %d offers to report an issue, want %d", i+1, offers(), want)
+		}
+	}
+
+	title, body := a.issueTemplate(a.lastFailure)
+	if !strings.HasPrefix(title, "gemini: Error 400") {
+		t.Errorf("title = %q", title)
+	}
+	for _, want := range []string{"same error 3 times", "Model: gemini-2.5-pro", "text (0 characters)", "function result kubectl", "generativelanguage.googleapis.com"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "gemini-secret-value") {
+		t.Errorf("body contains the API key:\n%s", body)
+	}
+}
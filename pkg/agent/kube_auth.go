@@ -0,0 +1,161 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/journal"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+)
+
+// maxReauthAttempts bounds the retries of a tool call failing to authenticate.
+const maxReauthAttempts = 3
+
+// The choices of the prompt to re-authenticate.
+const (
+	reauthRunPlugin = "plugin"
+	reauthRetry     = "retry"
+	reauthCancel    = "cancel"
+)
+
+// handleKubeAuthError asks the user to re-authenticate when a tool call could
+// not authenticate to the cluster, optionally by running the exec credential
+// plugin of its context, and retries the call. It returns the output of the
+// last attempt, which tells the model to stop if the user cancelled.
+func (c *Agent) handleKubeAuthError(ctx context.Context, call ToolCallAnalysis, opts tools.InvokeToolOptions, output any) (any, error) {
+	log := klog.FromContext(ctx)
+	for attempt := 0; attempt < maxReauthAttempts; attempt++ {
+		authErr := tools.DetectKubeAuthError(output)
+		if authErr == nil {
+			return output, nil
+		}
+		log.Info("Tool call could not authenticate to the cluster", "kind", authErr.Kind, "message", authErr.Message)
+		journal.RecorderFromContext(ctx).Write(ctx, &journal.Event{
+			Timestamp: time.Now(),
+			Action:    "kube.auth-error",
+			Payload:   authErr,
+		})
+
+		plugin := c.execPlugin(ctx, call, authErr)
+		choice, err := c.askReauthentication(ctx, authErr, plugin)
+		if err != nil {
+			return output, err
+		}
+		switch choice {
+		case reauthCancel:
+			return output, nil
+		case reauthRunPlugin:
+			if err := c.runExecPlugin(ctx, plugin); err != nil {
+				c.addMessage(api.MessageSourceAgent, api.MessageTypeError, "Re-authenticating failed: "+err.Error())
+				continue
+			}
+		}
+		c.addMessage(api.MessageSourceModel, api.MessageTypeToolCallRequest, call.ParsedToolCall.Description())
+		if output, err = call.ParsedToolCall.InvokeTool(ctx, opts); err != nil {
+			return output, err
+		}
+	}
+	return output, nil
+}
+
+// execPlugin returns the exec credential plugin of the context of a tool
+// call, nil if it has none or the plugin is not installed.
+func (c *Agent) execPlugin(ctx context.Context, call ToolCallAnalysis, authErr *tools.KubeAuthError) *clientcmdapi.ExecConfig {
+	if authErr.Kind == tools.KubeAuthMissingPlugin {
+		return nil
+	}
+	// The call may target another context than the current one.
+	var kubeContext string
+	if command, ok := call.FunctionCall.Arguments["command"].(string); ok {
+		requests, _ := tools.ParseKubectlRequests(command, tools.KubectlScope{})
+		for _, request := range requests {
+			if request.Context != "" {
+				kubeContext = request.Context
+				break
+			}
+		}
+	}
+	plugin, err := tools.KubeExecPlugin(c.Kubeconfig, kubeContext)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "finding the exec credential plugin", "context", kubeContext)
+		return nil
+	}
+	return plugin
+}
+
+// askReauthentication asks the user to re-authenticate, and returns their choice.
+func (c *Agent) askReauthentication(ctx context.Context, authErr *tools.KubeAuthError, plugin *clientcmdapi.ExecConfig) (string, error) {
+	var options []api.UserChoiceOption
+	if plugin != nil {
+		options = append(options, api.UserChoiceOption{Value: reauthRunPlugin, Label: fmt.Sprintf("Run %s to re-authenticate, then retry", plugin.Command)})
+	}
+	options = append(options,
+		api.UserChoiceOption{Value: reauthRetry, Label: "Retry, I re-authenticated"},
+		api.UserChoiceOption{Value: reauthCancel, Label: "Cancel"},
+	)
+	hint := "Log in again, e.g. with the login command of your cloud or identity provider, then retry."
+	if authErr.Kind == tools.KubeAuthMissingPlugin {
+		hint = fmt.Sprintf("Install %s in your PATH, then retry.", authErr.Plugin)
+	}
+
+	c.setAgentState(api.AgentStateWaitingForInput)
+	defer c.setAgentState(api.AgentStateRunning)
+	c.addMessage(api.MessageSourceAgent, api.MessageTypeUserChoiceRequest, &api.UserChoiceRequest{
+		Prompt:  fmt.Sprintf("kubectl could not authenticate to the cluster: %s.\n\n`%s`\n\n%s", authErr.Reason(), authErr.Message, hint),
+		Options: options,
+	})
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case userInput := <-c.Input:
+		if userInput == io.EOF {
+			return "", io.EOF
+		}
+		response, ok := userInput.(*api.UserChoiceResponse)
+		if !ok {
+			return "", fmt.Errorf("unexpected input %T for the prompt to re-authenticate", userInput)
+		}
+		if response.Choice < 1 || response.Choice > len(options) {
+			return reauthCancel, nil
+		}
+		return options[response.Choice-1].Value, nil
+	}
+}
+
+// runExecPlugin runs an exec credential plugin, which refreshes the
+// credential it prints. The credential is neither shown nor recorded.
+func (c *Agent) runExecPlugin(ctx context.Context, plugin *clientcmdapi.ExecConfig) error {
+	command, env, err := tools.ExecPluginCommand(plugin, c.toolEnv())
+	if err != nil {
+		return err
+	}
+	result, err := c.executor.Execute(ctx, command, env, c.workDir)
+	if err != nil {
+		return fmt.Errorf("running %s: %w", plugin.Command, err)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("%s failed: %s", plugin.Command, strings.TrimSpace(result.Stderr))
+	}
+	c.addMessage(api.MessageSourceAgent, api.MessageTypeText, fmt.Sprintf("Re-authenticated with %s.", plugin.Command))
+	return nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+)
+
+// maxResourceLinks bounds the resources linked from an answer.
+const maxResourceLinks = 20
+
+// linkKinds are the kinds, and their short names, of the resource references
+// of the answers, e.g. "deployment/web" or "svc/web".
+var linkKinds = []string{
+	"pods", "pod", "po",
+	"deployments", "deployment", "deploy",
+	"statefulsets", "statefulset", "sts",
+	"daemonsets", "daemonset", "ds",
+	"replicasets", "replicaset", "rs",
+	"cronjobs", "cronjob", "cj",
+	"jobs", "job",
+	"services", "service", "svc",
+	"ingresses", "ingress", "ing",
+	"configmaps", "configmap", "cm",
+	"secrets", "secret",
+	"persistentvolumeclaims", "persistentvolumeclaim", "pvc",
+	"persistentvolumes", "persistentvolume", "pv",
+	"serviceaccounts", "serviceaccount", "sa",
+	"horizontalpodautoscalers", "horizontalpodautoscaler", "hpa",
+	"poddisruptionbudgets", "poddisruptionbudget", "pdb",
+	"networkpolicies", "networkpolicy", "netpol",
+	"clusterrolebindings", "clusterrolebinding", "clusterroles", "clusterrole",
+	"rolebindings", "rolebinding", "roles", "role",
+	"storageclasses", "storageclass",
+	"namespaces", "namespace", "ns",
+	"nodes", "node",
+}
+
+// resourceReference matches a reference to a resource, e.g. "deployment/web",
+// and its namespace when it follows, e.g. "pod/web-0 in namespace shop",
+// "pod/web-0 in the `shop` namespace" or "pod/web-0 -n shop". The references
+// in paths and URLs, e.g. "/api/v1/pods/web-0", are not matched.
+var resourceReference = regexp.MustCompile("(?:^|[^\\w/.-])(" + strings.Join(linkKinds, "|") + ")/([a-z0-9](?:[-a-z0-9.]*[a-z0-9])?)`?" +
+	"(?:\\s+in\\s+(?:the\\s+)?namespace\\s+`?([a-z0-9-]+)|\\s+in\\s+the\\s+`?([a-z0-9-]+)`?\\s+namespace|\\s+(?:-n|--namespace)[ =]([a-z0-9-]+))?")
+
+// resourceLinks returns the links to the resources referenced by an answer,
+// numbered in their order of appearance.
+func resourceLinks(answer string) []api.ResourceLink {
+	var links []api.ResourceLink
+	seen := make(map[string]bool)
+	for _, match := range resourceReference.FindAllStringSubmatch(answer, -1) {
+		resource := match[1] + "/" + match[2]
+		namespace := match[3] + match[4] + match[5]
+		if seen[namespace+"/"+resource] {
+			continue
+		}
+		seen[namespace+"/"+resource] = true
+		command := "kubectl describe " + resource
+		if namespace != "" {
+			command += " --namespace " + namespace
+		}
+		links = append(links, api.ResourceLink{Number: len(links) + 1, Resource: resource, Namespace: namespace, Command: command})
+		if len(links) == maxResourceLinks {
+			break
+		}
+	}
+	return links
+}
+
+// lastResourceLinks returns the resource links of the last answer of the model.
+func (c *Agent) lastResourceLinks() []api.ResourceLink {
+	messages := c.Session.ChatMessageStore.ChatMessages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Source == api.MessageSourceModel && msg.Type == api.MessageTypeText && !msg.Reasoning {
+			return msg.ResourceLinks
+		}
+	}
+	return nil
+}
+
+// openResourceLink runs the read command of a resource link of the last
+// answer, e.g. "/open 2", and returns its output.
+func (c *Agent) openResourceLink(ctx context.Context, arg string) (string, error) {
+	links := c.lastResourceLinks()
+	if len(links) == 0 {
+		return "The last answer does not reference resources to open.", nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(links) {
+		return fmt.Sprintf("Invalid command. Usage: /open <n>, with n from 1 to %d.", len(links)), nil
+	}
+	link := links[n-1]
+
+	call, err := c.Tools.ParseToolInvocation(ctx, "bash", map[string]any{"command": link.Command})
+	if err != nil {
+		return "", fmt.Errorf("opening %s: %w", link.Resource, err)
+	}
+	output, err := call.InvokeTool(ctx, tools.InvokeToolOptions{
+		Kubeconfig: c.Kubeconfig,
+		WorkDir:    c.workDir,
+		Executor:   c.executor,
+		Env:        c.toolEnv(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("opening %s: %w", link.Resource, err)
+	}
+	execResult, ok := output.(*sandbox.ExecResult)
+	if !ok || execResult == nil {
+		return "", fmt.Errorf("opening %s: unexpected result %T", link.Resource, output)
+	}
+	text := execResult.Stdout
+	if text == "" {
+		text = strings.TrimSpace(execResult.Stderr + "\n" + execResult.Error)
+	}
+	return fmt.Sprintf("`%s`\n\n```text\n%s\n```", link.Command, strings.TrimRight(text, "\n")), nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+)
+
+func TestResourceLinks(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   []api.ResourceLink
+	}{
+		{
+			name:   "references",
+			answer: "The `deployment/web` rollout is stuck: pod/web-7d9f-x2x is in CrashLoopBackOff, and svc/web has no endpoints.",
+			want: []api.ResourceLink{
+				{Number: 1, Resource: "deployment/web", Command: "kubectl describe deployment/web"},
+				{Number: 2, Resource: "pod/web-7d9f-x2x", Command: "kubectl describe pod/web-7d9f-x2x"},
+				{Number: 3, Resource: "svc/web", Command: "kubectl describe svc/web"},
+			},
+		},
+		{
+			name:   "namespaces",
+			answer: "Check pod/db-0 in namespace data, pvc/data-db-0 in the `data` namespace and cm/settings -n shop.",
+			want: []api.ResourceLink{
+				{Number: 1, Resource: "pod/db-0", Namespace: "data", Command: "kubectl describe pod/db-0 --namespace data"},
+				{Number: 2, Resource: "pvc/data-db-0", Namespace: "data", Command: "kubectl describe pvc/data-db-0 --namespace data"},
+				{Number: 3, Resource: "cm/settings", Namespace: "shop", Command: "kubectl describe cm/settings --namespace shop"},
+			},
+		},
+		{
+			name:   "duplicates",
+			answer: "Restart deployment/web. Once deployment/web is ready, the errors stop.",
+			want:   []api.ResourceLink{{Number: 1, Resource: "deployment/web", Command: "kubectl describe deployment/web"}},
+		},
+		{
+			name:   "paths and versions",
+			answer: "The API group apps/v1 serves /api/v1/namespaces/shop/pods/web-0, see https://example.com/pods/web.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resourceLinks(tt.answer); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resourceLinks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenResourceLinkUsage(t *testing.T) {
+	store := sessions.NewInMemoryChatStore()
+	a := &Agent{Session: &api.Session{ChatMessageStore: store}}
+
+	answer, err := a.openResourceLink(context.Background(), "1")
+	if err != nil || answer != "The last answer does not reference resources to open." {
+		t.Errorf("openResourceLink() without links = %q, %v", answer, err)
+	}
+
+	links := resourceLinks("Scale deployment/web.")
+	if err := store.AddChatMessage(&api.Message{ID: "1", Source: api.MessageSourceModel, Type: api.MessageTypeText, Payload: "Scale deployment/web.", ResourceLinks: links}); err != nil {
+		t.Fatalf("adding message: %v", err)
+	}
+	if err := store.AddChatMessage(&api.Message{ID: "2", Source: api.MessageSourceModel, Type: api.MessageTypeText, Payload: "Checking pod/web-0.", Reasoning: true}); err != nil {
+		t.Fatalf("adding message: %v", err)
+	}
+	if got := a.lastResourceLinks(); !reflect.DeepEqual(got, links) {
+		t.Errorf("lastResourceLinks() = %+v, want the links of the last answer %+v", got, links)
+	}
+	answer, err = a.openResourceLink(context.Background(), "2")
+	if err != nil || answer != "Invalid command. Usage: /open <n>, with n from 1 to 1." {
+		t.Errorf("openResourceLink() out of range = %q, %v", answer, err)
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/journal"
+	"k8s.io/klog/v2"
+)
+
+// MaintenancePolicy restricts the changes of resources to maintenance
+// windows, and forbids them during change freezes.
+type MaintenancePolicy struct {
+	// Windows are the recurring periods in which resources may be modified,
+	// at any time if empty.
+	Windows []MaintenanceWindow `json:"windows,omitempty"`
+	// Freezes are the periods in which resources must not be modified, even
+	// during a window.
+	Freezes []ChangeFreeze `json:"freezes,omitempty"`
+	// OverridePhrase, if set, is the phrase the user types to modify resources
+	// outside of the windows anyway. The changes are refused if empty.
+	OverridePhrase string `json:"overridePhrase,omitempty"`
+}
+
+// MaintenanceWindow is a recurring period, e.g. from 22:00 to 02:00 on Saturdays.
+type MaintenanceWindow struct {
+	// Days are the days the window starts on, e.g. ["sat", "sun"], every day if empty.
+	Days []string `json:"days,omitempty"`
+	// Start and End are the times of the window, e.g. "22:00" and "02:00". A
+	// window ending before its start ends the next day.
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Timezone is the IANA time zone of the window, e.g. "Europe/Paris", the
+	// local time zone if empty.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// ChangeFreeze is a period in which resources must not be modified.
+type ChangeFreeze struct {
+	// Start and End are dates, e.g. "2025-12-20", which include the whole
+	// day, or RFC 3339 times. Dates are in the local time zone.
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Reason is shown to the user, e.g. "end of year freeze".
+	Reason string `json:"reason,omitempty"`
+}
+
+// weekdays are the names of the days of the windows.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// Validate returns an error if a window or a freeze is invalid.
+func (p *MaintenancePolicy) Validate() error {
+	for i, w := range p.Windows {
+		if _, err := w.location(); err != nil {
+			return fmt.Errorf("maintenance window %d: %w", i+1, err)
+		}
+		for _, day := range w.Days {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("maintenance window %d: unknown day %q, expected e.g. \"mon\" or \"monday\"", i+1, day)
+			}
+		}
+		if _, err := clockMinutes(w.Start); err != nil {
+			return fmt.Errorf("maintenance window %d: start: %w", i+1, err)
+		}
+		if _, err := clockMinutes(w.End); err != nil {
+			return fmt.Errorf("maintenance window %d: end: %w", i+1, err)
+		}
+	}
+	for i, f := range p.Freezes {
+		start, end, err := f.period()
+		if err != nil {
+			return fmt.Errorf("change freeze %d: %w", i+1, err)
+		}
+		if !end.After(start) {
+			return fmt.Errorf("change freeze %d: ends before it starts", i+1)
+		}
+	}
+	return nil
+}
+
+// Check returns why resources must not be modified at now, or "" if they may.
+func (p *MaintenancePolicy) Check(now time.Time) string {
+	for _, f := range p.Freezes {
+		start, end, err := f.period()
+		if err != nil || now.Before(start) || !now.Before(end) {
+			continue
+		}
+		reason := "change freeze"
+		if f.Reason != "" {
+			reason += " (" + f.Reason + ")"
+		}
+		return fmt.Sprintf("a %s is in effect until %s", reason, end.Format(time.RFC3339))
+	}
+	if len(p.Windows) == 0 {
+		return ""
+	}
+	var windows []string
+	for _, w := range p.Windows {
+		if w.contains(now) {
+			return ""
+		}
+		windows = append(windows, w.String())
+	}
+	return "it is outside of the maintenance windows: " + strings.Join(windows, ", ")
+}
+
+// contains returns true if the window, started today or the day before, contains t.
+func (w MaintenanceWindow) contains(t time.Time) bool {
+	loc, err := w.location()
+	if err != nil {
+		return false
+	}
+	start, err1 := clockMinutes(w.Start)
+	end, err2 := clockMinutes(w.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	if end <= start {
+		end += 24 * 60
+	}
+	t = t.In(loc)
+	for _, daysAgo := range []int{0, 1} {
+		day := t.AddDate(0, 0, -daysAgo)
+		if !w.startsOn(day.Weekday()) {
+			continue
+		}
+		midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+		from := midnight.Add(time.Duration(start) * time.Minute)
+		to := midnight.Add(time.Duration(end) * time.Minute)
+		if !t.Before(from) && t.Before(to) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w MaintenanceWindow) startsOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		if weekdays[strings.ToLower(name)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (w MaintenanceWindow) location() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(w.Timezone)
+}
+
+// String describes the window, e.g. "sat, sun 22:00-02:00 Europe/Paris".
+func (w MaintenanceWindow) String() string {
+	s := w.Start + "-" + w.End
+	if len(w.Days) > 0 {
+		s = strings.Join(w.Days, ", ") + " " + s
+	} else {
+		s = "daily " + s
+	}
+	if w.Timezone != "" {
+		s += " " + w.Timezone
+	}
+	return s
+}
+
+// clockMinutes returns the minutes since midnight of a time of day, e.g. "22:30".
+func clockMinutes(clock string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected e.g. \"22:30\"", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// period returns the start and the end of a freeze. A date ends at the end of the day.
+func (f ChangeFreeze) period() (start, end time.Time, err error) {
+	start, _, err = parseFreezeTime(f.Start)
+	if err != nil {
+		return start, end, fmt.Errorf("start: %w", err)
+	}
+	end, isDate, err := parseFreezeTime(f.End)
+	if err != nil {
+		return start, end, fmt.Errorf("end: %w", err)
+	}
+	if isDate {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+func parseFreezeTime(value string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, false, fmt.Errorf("invalid time %q, expected a date, e.g. \"2025-12-20\", or an RFC 3339 time", value)
+	}
+	return t, false, nil
+}
+
+// filterMaintenance refuses the tool calls that modify resources outside of
+// the maintenance windows or during a change freeze, unless the user types
+// the override phrase, and returns the other calls.
+func (c *Agent) filterMaintenance(ctx context.Context, calls []ToolCallAnalysis) []ToolCallAnalysis {
+	reason := c.Maintenance.Check(time.Now())
+	if reason == "" {
+		return calls
+	}
+	var allowed, changes []ToolCallAnalysis
+	for _, call := range calls {
+		if call.ModifiesResourceStr == "no" {
+			allowed = append(allowed, call)
+		} else {
+			changes = append(changes, call)
+		}
+	}
+	if len(changes) == 0 {
+		return calls
+	}
+
+	if c.Maintenance.OverridePhrase != "" && !c.RunOnce {
+		var commands []string
+		for _, call := range changes {
+			commands = append(commands, call.ParsedToolCall.Description())
+		}
+		prompt := fmt.Sprintf("Resources must not be modified now: %s.\n* %s\n\nType the override phrase to run these commands anyway, or anything else to refuse them:",
+			reason, strings.Join(commands, "\n* "))
+		answer, err := c.promptForToolInput(ctx, prompt)
+		if err == nil && strings.TrimSpace(answer) == c.Maintenance.OverridePhrase {
+			klog.FromContext(ctx).Info("Maintenance policy overridden by the user", "commands", commands, "reason", reason)
+			journal.RecorderFromContext(ctx).Write(ctx, &journal.Event{
+				Timestamp: time.Now(),
+				Action:    "maintenance-override",
+				Payload:   map[string]any{"commands": commands, "reason": reason},
+			})
+			return calls
+		}
+	}
+
+	for _, call := range changes {
+		klog.FromContext(ctx).Info("Refusing a tool call outside of the maintenance windows", "command", call.ParsedToolCall.Description(), "reason", reason)
+		c.rejectToolCall(call, maintenanceRefusal(call, reason))
+	}
+	return allowed
+}
+
+// maintenanceRefusal tells the model why a change was refused, so that it
+// tells the user instead of retrying it.
+func maintenanceRefusal(call ToolCallAnalysis, reason string) error {
+	return fmt.Errorf("%q was refused: %s. Do not retry it or work around it; tell the user the command to run once changes are allowed",
+		call.ParsedToolCall.Description(), reason)
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+)
+
+func TestMaintenancePolicyCheck(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("no time zone database: %v", err)
+	}
+	policy := &MaintenancePolicy{
+		Windows: []MaintenanceWindow{{Days: []string{"sat"}, Start: "22:00", End: "02:00", Timezone: "Europe/Paris"}},
+		Freezes: []ChangeFreeze{{Start: "2025-12-20T00:00:00+01:00", End: "2026-01-04T00:00:00+01:00", Reason: "end of year"}},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		now     time.Time
+		allowed bool
+		reason  string
+	}{
+		// 2025-06-07 is a Saturday.
+		{name: "in the window", now: time.Date(2025, 6, 7, 23, 0, 0, 0, paris), allowed: true},
+		{name: "in the window the next day", now: time.Date(2025, 6, 8, 1, 30, 0, 0, paris), allowed: true},
+		{name: "in the window in another time zone", now: time.Date(2025, 6, 7, 21, 0, 0, 0, time.UTC), allowed: true},
+		{name: "end of the window", now: time.Date(2025, 6, 8, 2, 0, 0, 0, paris), reason: "outside of the maintenance windows: sat 22:00-02:00 Europe/Paris"},
+		{name: "another day", now: time.Date(2025, 6, 6, 23, 0, 0, 0, paris), reason: "outside of the maintenance windows"},
+		// 2025-12-20 is a Saturday.
+		{name: "freeze", now: time.Date(2025, 12, 20, 23, 0, 0, 0, paris), reason: "change freeze (end of year) is in effect until 2026-01-04T00:00:00+01:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Check(tt.now)
+			if tt.allowed && got != "" || !tt.allowed && !strings.Contains(got, tt.reason) {
+				t.Errorf("Check(%s) = %q, want %q", tt.now, got, tt.reason)
+			}
+		})
+	}
+
+	if got := (&MaintenancePolicy{}).Check(time.Now()); got != "" {
+		t.Errorf("Check() without windows = %q, want changes allowed", got)
+	}
+}
+
+func TestMaintenancePolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  MaintenancePolicy
+		wantErr string
+	}{
+		{name: "day", policy: MaintenancePolicy{Windows: []MaintenanceWindow{{Days: []string{"someday"}, Start: "22:00", End: "23:00"}}}, wantErr: `unknown day "someday"`},
+		{name: "time", policy: MaintenancePolicy{Windows: []MaintenanceWindow{{Start: "10pm", End: "23:00"}}}, wantErr: `invalid time "10pm"`},
+		{name: "time zone", policy: MaintenancePolicy{Windows: []MaintenanceWindow{{Start: "22:00", End: "23:00", Timezone: "Mars/Olympus"}}}, wantErr: "maintenance window 1"},
+		{name: "freeze dates", policy: MaintenancePolicy{Freezes: []ChangeFreeze{{Start: "2025-12-20", End: "2025-12-19"}}}, wantErr: "ends before it starts"},
+		{name: "single day freeze", policy: MaintenancePolicy{Freezes: []ChangeFreeze{{Start: "2025-12-24", End: "2025-12-24"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFilterMaintenance(t *testing.T) {
+	a := &Agent{
+		RunOnce:     true,
+		Maintenance: &MaintenancePolicy{Freezes: []ChangeFreeze{{Start: "2000-01-01", End: "2999-12-31"}}, OverridePhrase: "override"},
+		Session:     &api.Session{ChatMessageStore: sessions.NewInMemoryChatStore()},
+		Output:      make(chan any, 10),
+	}
+	a.Tools.Init()
+	a.Tools.RegisterTool(tools.NewBashTool(nil))
+	calls, err := a.analyzeToolCalls(context.Background(), []gollm.FunctionCall{
+		{Name: "bash", Arguments: map[string]any{"command": "kubectl get pods"}},
+		{Name: "bash", Arguments: map[string]any{"command": "kubectl delete pod web"}},
+	})
+	if err != nil {
+		t.Fatalf("analyzeToolCalls() error = %v", err)
+	}
+
+	allowed := a.filterMaintenance(context.Background(), calls)
+	if len(allowed) != 1 || allowed[0].ParsedToolCall.Description() != "kubectl get pods" {
+		t.Errorf("filterMaintenance() = %v, want only the read-only call", allowed)
+	}
+	if len(a.currChatContent) != 1 {
+		t.Fatalf("filterMaintenance() sent %d results, want the refusal of the change", len(a.currChatContent))
+	}
+	result := a.currChatContent[0].(gollm.FunctionCallResult).Result["error"].(string)
+	if !strings.Contains(result, `"kubectl delete pod web" was refused: a change freeze is in effect`) {
+		t.Errorf("refusal = %q", result)
+	}
+}
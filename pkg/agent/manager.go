@@ -105,6 +105,19 @@ func (sm *AgentManager) FindSessionByID(id string) (*api.Session, error) {
 	return sm.sessionManager.FindSessionByID(id)
 }
 
+// GetSession returns the session with the given ID, from its agent if it is
+// running, else from the store. Unlike GetAgent, it does not start an agent,
+// so that past sessions can be read without resuming them.
+func (sm *AgentManager) GetSession(id string) (*api.Session, error) {
+	sm.mu.RLock()
+	agent, ok := sm.agents[id]
+	sm.mu.RUnlock()
+	if ok {
+		return agent.GetSession(), nil
+	}
+	return sm.sessionManager.FindSessionByID(id)
+}
+
 // DeleteSession delegates to the underlying store and closes the active agent if any.
 func (sm *AgentManager) DeleteSession(id string) error {
 	sm.mu.Lock()
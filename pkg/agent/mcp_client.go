@@ -26,7 +26,8 @@ import (
 )
 
 // InitializeMCPClient initializes MCP client functionality for the agent.
-// It connects to servers and registers discovered tools with the kubectl-ai tool system.
+// It connects to the servers of mcp.yaml and registers their tools in the
+// tools of the agent, next to the built-in tools.
 func (a *Agent) InitializeMCPClient(ctx context.Context) error {
 	// Initialize the MCP manager
 	manager, err := mcp.InitializeManager()
@@ -49,8 +50,12 @@ func (a *Agent) InitializeMCPClient(ctx context.Context) error {
 		schema.Name = mcpTool.UniqueToolName()
 		schema.Description = fmt.Sprintf("%s (from %s)", toolInfo.Description, serverName)
 
-		// Create and register MCP tool wrapper
-		tools.RegisterTool(mcpTool)
+		// Register the MCP tool wrapper with the tools of this agent only, other
+		// sessions connect to the servers on their own.
+		if a.Tools.Lookup(schema.Name) != nil {
+			return fmt.Errorf("tool %q is already registered", schema.Name)
+		}
+		a.Tools.RegisterTool(mcpTool)
 		return nil
 	})
 
@@ -0,0 +1,89 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+)
+
+// namespacePattern matches the names of namespaces, which are DNS labels.
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// switchNamespace handles the ns command: it reports the default namespace of
+// the session, or changes it to an existing namespace by scoping the
+// kubeconfig of the tools to it, and tells the model with the next query.
+func (c *Agent) switchNamespace(ctx context.Context, namespace string) (string, error) {
+	scope := tools.KubeconfigScope(c.Kubeconfig)
+	if namespace == "" {
+		return fmt.Sprintf("Commands run in namespace `%s`. Change it with `ns <name>`.", scope.Namespace), nil
+	}
+	if !namespacePattern.MatchString(namespace) || len(namespace) > 63 {
+		return fmt.Sprintf("Invalid namespace %q. Usage: ns <name>", namespace), nil
+	}
+	if namespace == scope.Namespace {
+		return fmt.Sprintf("Commands already run in namespace `%s`.", namespace), nil
+	}
+
+	// Refuse the namespaces that do not exist, rather than letting every command fail.
+	result, err := c.runKubectl(ctx, "kubectl get namespace "+tools.ShellQuote(namespace)+" -o name")
+	if err != nil {
+		return "", err
+	}
+	if result.ExitCode != 0 || result.Error != "" {
+		message := strings.TrimSpace(result.Stderr + " " + result.Error)
+		if !strings.Contains(message, "NotFound") && !strings.Contains(message, "not found") {
+			return "", fmt.Errorf("checking namespace %s: %s", namespace, message)
+		}
+		answer := fmt.Sprintf("Namespace `%s` not found.", namespace)
+		if list, err := c.runKubectl(ctx, "kubectl get namespaces -o name"); err == nil && list.ExitCode == 0 {
+			names := strings.Fields(strings.ReplaceAll(list.Stdout, "namespace/", ""))
+			if len(names) > 0 {
+				answer += " Namespaces: " + strings.Join(names, ", ") + "."
+			}
+		}
+		return answer, nil
+	}
+
+	kubeconfig, err := tools.WriteScopedKubeconfig(c.Kubeconfig, "", namespace, filepath.Join(c.workDir, "kubeconfigs"))
+	if err != nil {
+		return "", fmt.Errorf("setting namespace %s: %w", namespace, err)
+	}
+	c.Kubeconfig = kubeconfig
+	c.Namespace = namespace
+	c.namespaceChanged = true
+	if c.readCache != nil {
+		c.readCache.Invalidate()
+	}
+	if scope.Context != "" {
+		return fmt.Sprintf("Commands now run in namespace `%s` of context `%s`.", namespace, scope.Context), nil
+	}
+	return fmt.Sprintf("Commands now run in namespace `%s`.", namespace), nil
+}
+
+// withNamespaceChange tells the model about a namespace change of the ns
+// command with the next query of the user.
+func (c *Agent) withNamespaceChange(query string) string {
+	if !c.namespaceChanged {
+		return query
+	}
+	c.namespaceChanged = false
+	return query + fmt.Sprintf("\n\nThe default namespace of this session is now `%s`: commands without a namespace run in it.", c.Namespace)
+}
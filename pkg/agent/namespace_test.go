@@ -0,0 +1,87 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+)
+
+func TestSwitchNamespace(t *testing.T) {
+	dir := t.TempDir()
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	if err := os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+current-context: prod
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	executor := &stagingExecutor{results: map[string]*sandbox.ExecResult{
+		"kubectl get namespace 'shop' -o name": {Stdout: "namespace/shop\n"},
+		"kubectl get namespace 'shpo' -o name": {ExitCode: 1, Stderr: `Error from server (NotFound): namespaces "shpo" not found`},
+		"kubectl get namespaces -o name":       {Stdout: "namespace/default\nnamespace/shop\n"},
+	}}
+	a := &Agent{executor: executor, Kubeconfig: kubeconfig, workDir: dir}
+	a.Tools.Init()
+	a.Tools.RegisterTool(tools.NewBashTool(executor))
+	ctx := context.Background()
+
+	tests := []struct {
+		namespace string
+		want      string
+	}{
+		{namespace: "", want: "Commands run in namespace `default`. Change it with `ns <name>`."},
+		{namespace: "Shop!", want: `Invalid namespace "Shop!". Usage: ns <name>`},
+		{namespace: "shpo", want: "Namespace `shpo` not found. Namespaces: default, shop."},
+		{namespace: "shop", want: "Commands now run in namespace `shop` of context `prod`."},
+		{namespace: "", want: "Commands run in namespace `shop`. Change it with `ns <name>`."},
+		{namespace: "shop", want: "Commands already run in namespace `shop`."},
+	}
+	for _, tt := range tests {
+		got, err := a.switchNamespace(ctx, tt.namespace)
+		if err != nil || got != tt.want {
+			t.Errorf("switchNamespace(%q) = %q, %v, want %q", tt.namespace, got, err, tt.want)
+		}
+	}
+
+	if a.Namespace != "shop" || !strings.HasPrefix(a.Kubeconfig, filepath.Join(dir, "kubeconfigs")) {
+		t.Errorf("Namespace = %q, Kubeconfig = %q, want the kubeconfig scoped to shop", a.Namespace, a.Kubeconfig)
+	}
+	if got := a.withNamespaceChange("why is web down?"); !strings.HasSuffix(got, "The default namespace of this session is now `shop`: commands without a namespace run in it.") {
+		t.Errorf("withNamespaceChange() = %q, want the namespace change", got)
+	}
+	if got := a.withNamespaceChange("and now?"); got != "and now?" {
+		t.Errorf("withNamespaceChange() = %q, want the model to be told once", got)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"k8s.io/klog/v2"
+)
+
+// policyQuery is the Rego query evaluated for each tool call. Policies set
+// the decision and optionally the reason in the kubectl_ai package.
+const policyQuery = "data.kubectl_ai"
+
+// hookEventPolicy is the event of the input of the policies.
+const hookEventPolicy = "policy"
+
+// PolicyDecision is the decision of the policies on a tool call.
+type PolicyDecision string
+
+const (
+	// PolicyAllow lets the tool call run, after the usual approval rules.
+	PolicyAllow PolicyDecision = "allow"
+	// PolicyDeny refuses to run the tool call.
+	PolicyDeny PolicyDecision = "deny"
+	// PolicyRequireApproval asks the user to approve the tool call, even if
+	// it is read-only or permissions are skipped.
+	PolicyRequireApproval PolicyDecision = "require_approval"
+)
+
+// PolicyEvaluator evaluates tool calls against Rego policies with an embedded
+// OPA evaluator.
+type PolicyEvaluator struct {
+	query rego.PreparedEvalQuery
+}
+
+// NewPolicyEvaluator compiles the Rego policies of the given files and directories.
+func NewPolicyEvaluator(ctx context.Context, paths []string) (*PolicyEvaluator, error) {
+	query, err := rego.New(
+		rego.Query(policyQuery),
+		rego.Load(paths, nil),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("compiling policies %v: %w", paths, err)
+	}
+	return &PolicyEvaluator{query: query}, nil
+}
+
+// policyResult is the document of the kubectl_ai package.
+type policyResult struct {
+	Decision PolicyDecision `json:"decision"`
+	// Reason is usually a string, but may be any value, e.g. a set of messages.
+	Reason any `json:"reason"`
+}
+
+// Evaluate returns the decision of the policies on the input. Tool calls are
+// allowed when the policies set no decision.
+func (p *PolicyEvaluator) Evaluate(ctx context.Context, input any) (PolicyDecision, string, error) {
+	results, err := p.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return "", "", fmt.Errorf("evaluating policies: %w", err)
+	}
+	if len(results) == 0 || len(results[0].Expressions) == 0 {
+		return PolicyAllow, "", nil
+	}
+
+	data, err := json.Marshal(results[0].Expressions[0].Value)
+	if err != nil {
+		return "", "", fmt.Errorf("encoding policy result: %w", err)
+	}
+	var result policyResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", "", fmt.Errorf("decoding policy result %s: %w", data, err)
+	}
+	reason := ""
+	if result.Reason != nil {
+		reason = fmt.Sprint(result.Reason)
+	}
+
+	switch result.Decision {
+	case "":
+		return PolicyAllow, reason, nil
+	case PolicyAllow, PolicyDeny, PolicyRequireApproval:
+		return result.Decision, reason, nil
+	default:
+		return "", "", fmt.Errorf("unknown policy decision %q, expected allow, deny or require_approval", result.Decision)
+	}
+}
+
+// evaluatePolicy records the decision of the policies on a tool call. The
+// tool call is denied if the policies cannot be evaluated.
+func (c *Agent) evaluatePolicy(ctx context.Context, call *ToolCallAnalysis) {
+	log := klog.FromContext(ctx)
+	if c.Policy != nil {
+		decision, reason, err := c.Policy.Evaluate(ctx, c.newHookInput(hookEventPolicy, *call))
+		if err != nil {
+			log.Error(err, "evaluating policies", "command", call.ParsedToolCall.Description())
+			decision, reason = PolicyDeny, err.Error()
+		}
+		log.Info("Policy decision", "command", call.ParsedToolCall.Description(), "decision", decision, "reason", reason)
+		call.PolicyDecision = decision
+		call.PolicyReason = reason
+	}
+	if c.KubectlPolicy != nil && call.PolicyDecision != PolicyDeny {
+		c.evaluateKubectlPolicy(ctx, call)
+	}
+	if c.AirGapped && call.PolicyDecision != PolicyDeny {
+		c.evaluateEgressPolicy(ctx, call)
+	}
+	if len(c.BashAllowlist) > 0 && call.PolicyDecision != PolicyDeny {
+		c.evaluateBashAllowlist(ctx, call)
+	}
+}
+
+// evaluateBashAllowlist denies the tool calls whose shell command runs a
+// program out of the allowlist.
+func (c *Agent) evaluateBashAllowlist(ctx context.Context, call *ToolCallAnalysis) {
+	command, ok := call.FunctionCall.Arguments["command"].(string)
+	if !ok || (call.FunctionCall.Name != "bash" && call.FunctionCall.Name != "kubectl") {
+		return
+	}
+	refusal := tools.CheckAllowedPrograms(command, c.BashAllowlist)
+	if refusal == nil {
+		return
+	}
+	klog.FromContext(ctx).Info("Denying a tool call out of the bash allowlist", "command", command, "reason", refusal.Reason)
+	call.PolicyDecision = PolicyDeny
+	call.PolicyReason = "bash allowlist: " + refusal.Reason
+}
+
+// evaluateEgressPolicy denies the tool calls of an air-gapped session whose
+// command may send data out of the host, and the calls of MCP tools, whose
+// servers may be remote.
+func (c *Agent) evaluateEgressPolicy(ctx context.Context, call *ToolCallAnalysis) {
+	reason := ""
+	if _, ok := call.ParsedToolCall.GetTool().(*tools.MCPTool); ok {
+		reason = "MCP tools may call remote servers"
+	} else if command, ok := call.FunctionCall.Arguments["command"].(string); ok {
+		if refusal := tools.CheckEgress(command, tools.KubeServer(c.Kubeconfig)); refusal != nil {
+			reason = refusal.Reason
+		}
+	}
+	if reason == "" {
+		return
+	}
+	klog.FromContext(ctx).Info("Denying a tool call in air-gapped mode", "command", call.ParsedToolCall.Description(), "reason", reason)
+	call.PolicyDecision = PolicyDeny
+	call.PolicyReason = "air-gapped: " + reason
+}
+
+// evaluateKubectlPolicy records the decision of the kubectl policy on the
+// command of a kubectl or bash tool call, if it is stricter than the decision
+// of the Rego policies.
+func (c *Agent) evaluateKubectlPolicy(ctx context.Context, call *ToolCallAnalysis) {
+	if call.FunctionCall.Name != "kubectl" && call.FunctionCall.Name != "bash" {
+		return
+	}
+	command, ok := call.FunctionCall.Arguments["command"].(string)
+	if !ok {
+		return
+	}
+	decision := c.KubectlPolicy.Evaluate(command, tools.KubeconfigScope(c.Kubeconfig))
+	klog.FromContext(ctx).Info("Kubectl policy decision", "command", command, "decision", decision.Explain())
+	switch decision.Action {
+	case tools.KubectlPolicyDeny:
+		call.PolicyDecision = PolicyDeny
+		call.PolicyReason = "kubectl policy: " + decision.Explain()
+	case tools.KubectlPolicyConfirm:
+		call.PolicyDecision = PolicyRequireApproval
+		call.PolicyReason = "kubectl policy: " + decision.Explain()
+	}
+}
+
+// policyDenial returns the error reported when the policies deny a tool call.
+func policyDenial(call ToolCallAnalysis) error {
+	if call.PolicyReason == "" {
+		return fmt.Errorf("refusing to run %q: denied by policy", call.ParsedToolCall.Description())
+	}
+	return fmt.Errorf("refusing to run %q: denied by policy: %s", call.ParsedToolCall.Description(), call.PolicyReason)
+}
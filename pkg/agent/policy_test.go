@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testPolicy = `package kubectl_ai
+
+default decision := "allow"
+
+decision := "deny" if {
+	startswith(input.command, "kubectl delete namespace")
+}
+
+decision := "require_approval" if {
+	contains(input.command, "-n prod")
+	not startswith(input.command, "kubectl delete namespace")
+}
+
+reason := "namespaces are managed by the platform team" if decision == "deny"
+`
+
+func TestPolicyEvaluator(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "policy.rego")
+	if err := os.WriteFile(path, []byte(testPolicy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := NewPolicyEvaluator(ctx, []string{path})
+	if err != nil {
+		t.Fatalf("NewPolicyEvaluator() error: %v", err)
+	}
+
+	tests := []struct {
+		command      string
+		wantDecision PolicyDecision
+		wantReason   string
+	}{
+		{
+			command:      "kubectl get pods -n prod",
+			wantDecision: PolicyRequireApproval,
+		},
+		{
+			command:      "kubectl delete namespace staging",
+			wantDecision: PolicyDeny,
+			wantReason:   "namespaces are managed by the platform team",
+		},
+		{
+			command:      "kubectl scale deploy/web --replicas=3",
+			wantDecision: PolicyAllow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			input := &hookInput{Event: hookEventPolicy, Tool: "kubectl", Command: tt.command}
+			decision, reason, err := policy.Evaluate(ctx, input)
+			if err != nil {
+				t.Fatalf("Evaluate() error: %v", err)
+			}
+			if decision != tt.wantDecision || reason != tt.wantReason {
+				t.Errorf("Evaluate() = %q, %q, want %q, %q", decision, reason, tt.wantDecision, tt.wantReason)
+			}
+		})
+	}
+}
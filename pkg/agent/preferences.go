@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// maxLearnedPreferences bounds the preferences sent to the model, the oldest are dropped first.
+const maxLearnedPreferences = 10
+
+// preferenceValueFlags are the flags taking a separate value, compared as a
+// whole when learning from an edited command, e.g. "-o wide".
+var preferenceValueFlags = map[string]bool{
+	"-o":          true,
+	"--output":    true,
+	"-n":          true,
+	"--namespace": true,
+	"-l":          true,
+	"--selector":  true,
+	"-c":          true,
+	"--container": true,
+	"--context":   true,
+}
+
+// parsedCommand holds the flags and the namespace of a command.
+type parsedCommand struct {
+	flags     []string
+	namespace string
+}
+
+func parseCommand(command string) parsedCommand {
+	var parsed parsedCommand
+	fields := strings.Fields(command)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if !strings.HasPrefix(field, "-") {
+			continue
+		}
+		if preferenceValueFlags[field] && i+1 < len(fields) {
+			i++
+			field += " " + fields[i]
+		}
+		name, value, _ := strings.Cut(strings.Replace(field, " ", "=", 1), "=")
+		if name == "-n" || name == "--namespace" {
+			parsed.namespace = value
+			continue
+		}
+		parsed.flags = append(parsed.flags, field)
+	}
+	return parsed
+}
+
+// commandKind returns the kind of a command used in the preferences, e.g. "kubectl get".
+func commandKind(command string) string {
+	return strings.TrimSuffix(commandPattern(command), " *")
+}
+
+// preferencesFromEdit returns the preferences learned from the user editing a
+// proposed command, e.g. "add `-o wide` to `kubectl get` commands".
+func preferencesFromEdit(original, edited string) []string {
+	if strings.TrimSpace(original) == strings.TrimSpace(edited) {
+		return nil
+	}
+	before, after := parseCommand(original), parseCommand(edited)
+	kind := commandKind(original)
+
+	var preferences []string
+	if before.namespace != after.namespace && after.namespace != "" {
+		if before.namespace != "" {
+			preferences = append(preferences, fmt.Sprintf("use namespace `%s` rather than `%s`", after.namespace, before.namespace))
+		} else {
+			preferences = append(preferences, fmt.Sprintf("use namespace `%s`", after.namespace))
+		}
+	}
+	for _, flag := range after.flags {
+		if !slices.Contains(before.flags, flag) {
+			preferences = append(preferences, fmt.Sprintf("add `%s` to `%s` commands", flag, kind))
+		}
+	}
+	for _, flag := range before.flags {
+		if !slices.Contains(after.flags, flag) {
+			preferences = append(preferences, fmt.Sprintf("do not use `%s` in `%s` commands", flag, kind))
+		}
+	}
+	if len(preferences) == 0 {
+		preferences = append(preferences, fmt.Sprintf("prefers `%s` over `%s`", strings.TrimSpace(edited), strings.TrimSpace(original)))
+	}
+	return preferences
+}
+
+// preferenceFromDecline returns the preference learned from the user
+// declining a proposed command, e.g. "never touch namespace `kube-system`".
+func preferenceFromDecline(command string) string {
+	namespace := parseCommand(command).namespace
+	switch {
+	case strings.HasPrefix(namespace, "kube-"):
+		return fmt.Sprintf("never touch namespace `%s`", namespace)
+	case namespace != "":
+		return fmt.Sprintf("do not run `%s` commands in namespace `%s`", commandKind(command), namespace)
+	default:
+		return fmt.Sprintf("declined to run `%s`, do not propose it again", strings.TrimSpace(command))
+	}
+}
+
+// learnPreferences records preferences learned from a correction of the user.
+func (c *Agent) learnPreferences(preferences ...string) {
+	for _, preference := range preferences {
+		if slices.Contains(c.Session.LearnedPreferences, preference) {
+			continue
+		}
+		c.Session.LearnedPreferences = append(c.Session.LearnedPreferences, preference)
+		c.addMessage(api.MessageSourceAgent, api.MessageTypeText, "Noted for this session: "+preference+".")
+	}
+	if n := len(c.Session.LearnedPreferences); n > maxLearnedPreferences {
+		c.Session.LearnedPreferences = c.Session.LearnedPreferences[n-maxLearnedPreferences:]
+	}
+}
+
+// learnFromEdit records the preferences learned from the user editing a proposed command.
+func (c *Agent) learnFromEdit(ctx context.Context, original, edited string) {
+	c.learnPreferences(preferencesFromEdit(original, edited)...)
+	c.saveSessionMetadata(ctx)
+}
+
+// learnFromDecline records the preferences learned from the user declining proposed tool calls.
+func (c *Agent) learnFromDecline(ctx context.Context, calls []ToolCallAnalysis) {
+	if len(calls) == 0 {
+		return
+	}
+	for _, call := range calls {
+		if command, ok := call.FunctionCall.Arguments["command"].(string); ok {
+			c.learnPreferences(preferenceFromDecline(command))
+		}
+	}
+	c.saveSessionMetadata(ctx)
+}
+
+// withLearnedPreferences appends the preferences learned this session to a query of the user.
+func (c *Agent) withLearnedPreferences(query string) string {
+	if len(c.Session.LearnedPreferences) == 0 {
+		return query
+	}
+	return query + "\n\nPreferences learned from my corrections in this session:\n- " + strings.Join(c.Session.LearnedPreferences, "\n- ")
+}
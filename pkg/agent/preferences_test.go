@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPreferencesFromEdit(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		edited   string
+		want     []string
+	}{
+		{
+			name:     "added output flag",
+			original: "kubectl get pods -n prod",
+			edited:   "kubectl get pods -n prod -o wide",
+			want:     []string{"add `-o wide` to `kubectl get` commands"},
+		},
+		{
+			name:     "changed namespace and removed flag",
+			original: "kubectl delete pod web-0 -n default --force",
+			edited:   "kubectl delete pod web-0 --namespace=staging",
+			want: []string{
+				"use namespace `staging` rather than `default`",
+				"do not use `--force` in `kubectl delete` commands",
+			},
+		},
+		{
+			name:     "other edit",
+			original: "kubectl scale deploy/web --replicas=3",
+			edited:   "kubectl scale deploy/api --replicas=3",
+			want:     []string{"prefers `kubectl scale deploy/api --replicas=3` over `kubectl scale deploy/web --replicas=3`"},
+		},
+		{
+			name:     "unchanged",
+			original: "kubectl get pods",
+			edited:   " kubectl get pods ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := preferencesFromEdit(tt.original, tt.edited); !slices.Equal(got, tt.want) {
+				t.Errorf("preferencesFromEdit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreferenceFromDecline(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "kubectl delete pod coredns-0 -n kube-system", want: "never touch namespace `kube-system`"},
+		{command: "kubectl scale deploy/web --replicas=0 --namespace=prod", want: "do not run `kubectl scale` commands in namespace `prod`"},
+		{command: "kubectl drain node-1", want: "declined to run `kubectl drain node-1`, do not propose it again"},
+	}
+	for _, tt := range tests {
+		if got := preferenceFromDecline(tt.command); got != tt.want {
+			t.Errorf("preferenceFromDecline(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/internal/mocks"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"go.uber.org/mock/gomock"
+)
+
+// update rewrites the golden files of the prompt regression tests instead of
+// comparing them, e.g. after an intended change of the prompt template:
+//
+//	go test ./pkg/agent -run 'TestPromptGolden|TestPromptScenarios' -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// jsonBlock matches the JSON examples of the prompt.
+var jsonBlock = regexp.MustCompile("(?s)```json\n.*?\n```")
+
+// checkGolden compares got with the golden file testdata/<name>, or rewrites
+// the file with -update.
+func checkGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading the golden file, run the test with -update to create it: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s changed, run the test with -update if the change is intended:\n%s", path, firstDiff(got, string(want)))
+	}
+}
+
+// firstDiff describes the first line that differs between got and want.
+func firstDiff(got, want string) string {
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := 0; i < max(len(gotLines), len(wantLines)); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			return fmt.Sprintf("line %d:\n  got:  %q\n  want: %q", i+1, g, w)
+		}
+	}
+	return ""
+}
+
+func TestPromptGolden(t *testing.T) {
+	tests := []struct {
+		name         string
+		provider     string
+		model        string
+		toolUseShim  bool
+		runOnce      bool
+		dryRun       bool
+		contexts     []tools.KubeContext
+		clusterFacts string
+		memory       string
+	}{
+		{name: "gemini", provider: "gemini", model: "gemini-2.5-pro"},
+		{name: "gemini-shim", provider: "gemini", model: "gemini-2.5-pro", toolUseShim: true},
+		{name: "ollama-llama3", provider: "ollama", model: "llama3:8b"},
+		{name: "ollama-llama3-shim", provider: "ollama", model: "llama3:8b", toolUseShim: true},
+		{
+			name:     "openai-run-once-dry-run",
+			provider: "openai",
+			model:    "gpt-4.1",
+			runOnce:  true,
+			dryRun:   true,
+			contexts: []tools.KubeContext{
+				{Name: "staging", Cluster: "staging", Namespace: "default", Current: true},
+				{Name: "prod", Cluster: "prod", Namespace: "web"},
+			},
+			clusterFacts: "- Kubernetes version: v1.30.2\n- Nodes: 3\n- Namespaces (3): default, kube-system, web\n- Custom resource API groups (0): none\n- Default storage class: standard-rwo",
+			memory:       "- The web deployment of prod is managed by Argo CD.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Agent{Provider: tt.provider, Model: tt.model, EnableToolUseShim: tt.toolUseShim, RunOnce: tt.runOnce, DryRun: tt.dryRun}
+			a.Tools.Init()
+			a.registerBuiltinTools()
+			prompt, err := a.generatePrompt(context.Background(), defaultSystemPromptTemplate, PromptData{
+				Tools:                a.Tools,
+				EnableToolUseShim:    tt.toolUseShim,
+				SessionIsInteractive: !tt.runOnce,
+				DryRun:               tt.dryRun,
+				ClusterFacts:         tt.clusterFacts,
+				Memory:               tt.memory,
+				Contexts:             tt.contexts,
+			})
+			if err != nil {
+				t.Fatalf("generatePrompt() error = %v", err)
+			}
+			checkGolden(t, filepath.Join("prompts", tt.name+".golden"), prompt)
+
+			// The responses of the model in the format of the examples of
+			// the prompt must be understood by the tool use shim.
+			examples := jsonBlock.FindAllString(prompt, -1)
+			if tt.toolUseShim && len(examples) != 2 {
+				t.Fatalf("prompt has %d JSON examples, want the action and answer examples", len(examples))
+			}
+			for _, example := range examples {
+				resp, err := parseReActResponse(example)
+				if err != nil {
+					t.Errorf("parsing the example of the prompt: %v", err)
+					continue
+				}
+				if resp.Thought == "" || (resp.Action == nil) == (resp.Answer == "") {
+					t.Errorf("example %+v has no thought, or not exactly one of action and answer", resp)
+				}
+			}
+		})
+	}
+}
+
+// cannedExecutor runs no command, it returns the same output for all of them.
+type cannedExecutor struct {
+	stdout string
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func (e *cannedExecutor) Execute(_ context.Context, command string, _ []string, _ string) (*sandbox.ExecResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.commands = append(e.commands, command)
+	return &sandbox.ExecResult{Command: command, Stdout: e.stdout}, nil
+}
+
+func (e *cannedExecutor) Close(context.Context) error { return nil }
+
+// scenarioTranscript is what the agent sends to the provider in a scenario,
+// compared with the golden file of the scenario.
+type scenarioTranscript struct {
+	// FunctionDefinitions are the tools declared to the provider, without the tool use shim.
+	FunctionDefinitions []*gollm.FunctionDefinition `json:"functionDefinitions,omitempty"`
+	// Requests are the contents of each request, the query and the results of the tool calls.
+	Requests [][]any `json:"requests"`
+	// Commands are the commands run by the tools.
+	Commands []string `json:"commands"`
+	// Answer is the last text of the model shown to the user.
+	Answer string `json:"answer"`
+}
+
+func shimReply(response string) gollm.Part {
+	return fText("```json\n" + response + "\n```")
+}
+
+func TestPromptScenarios(t *testing.T) {
+	tests := []struct {
+		name        string
+		model       string
+		toolUseShim bool
+		query       string
+		// replies are the parts replied by the model to the successive requests.
+		replies []gollm.Part
+		stdout  string
+	}{
+		{
+			name:  "native-kubectl",
+			model: "gemini-2.5-pro",
+			query: "is the web pod running?",
+			replies: []gollm.Part{
+				fCalls("kubectl", map[string]any{"command": "kubectl get pods -n default", "modifies_resource": "no"}),
+				fText("Yes, the pod web-0 is running."),
+			},
+			stdout: "NAME    READY   STATUS    RESTARTS   AGE\nweb-0   1/1     Running   0          3d\n",
+		},
+		{
+			name:  "native-bash-then-kubectl",
+			model: "gemini-2.5-pro",
+			query: "how many pods run in the default namespace, and what does web-0 log?",
+			replies: []gollm.Part{
+				fCalls("bash", map[string]any{"command": "kubectl get pods -n default -o name | wc -l", "modifies_resource": "no"}),
+				fCalls("kubectl", map[string]any{"command": "kubectl logs web-0 -n default --tail 20", "modifies_resource": "no"}),
+				fText("1 pod runs in the default namespace, web-0, which logs nothing unusual."),
+			},
+			stdout: "1\n",
+		},
+		{
+			name:        "shim-kubectl",
+			model:       "llama3:8b",
+			toolUseShim: true,
+			query:       "is the web pod running?",
+			replies: []gollm.Part{
+				shimReply(`{"thought": "I need the pods of the default namespace.", "action": {"name": "kubectl", "reason": "to list the pods", "command": "kubectl get pods -n default", "modifies_resource": "no"}}`),
+				shimReply(`{"thought": "The pod is running.", "answer": "Yes, the pod web-0 is running."}`),
+			},
+			stdout: "NAME    READY   STATUS    RESTARTS   AGE\nweb-0   1/1     Running   0          3d\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			ctrl := gomock.NewController(t)
+			client := mocks.NewMockClient(ctrl)
+			chat := mocks.NewMockChat(ctrl)
+
+			var transcript scenarioTranscript
+			replies := tt.replies
+			client.EXPECT().StartChat(gomock.Any(), tt.model).Return(chat)
+			client.EXPECT().Close().Return(nil).AnyTimes()
+			chat.EXPECT().Initialize(gomock.Any()).Return(nil)
+			chat.EXPECT().IsRetryableError(gomock.Any()).Return(false).AnyTimes()
+			chat.EXPECT().SetFunctionDefinitions(gomock.Any()).DoAndReturn(func(definitions []*gollm.FunctionDefinition) error {
+				transcript.FunctionDefinitions = definitions
+				return nil
+			}).MaxTimes(1)
+			chat.EXPECT().SendStreaming(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, contents ...any) (gollm.ChatResponseIterator, error) {
+				transcript.Requests = append(transcript.Requests, contents)
+				if len(replies) == 0 {
+					return nil, errors.New("no canned reply left")
+				}
+				reply := chatWith(replies[0])
+				replies = replies[1:]
+				return func(yield func(gollm.ChatResponse, error) bool) {
+					yield(reply, nil)
+				}, nil
+			}).Times(len(tt.replies))
+
+			executor := &cannedExecutor{stdout: tt.stdout}
+			store := sessions.NewInMemoryChatStore()
+			a := &Agent{
+				LLM:               client,
+				Model:             tt.model,
+				Provider:          "mock",
+				EnableToolUseShim: tt.toolUseShim,
+				Executor:          executor,
+				Kubeconfig:        filepath.Join(t.TempDir(), "kubeconfig"),
+				RemoveWorkDir:     true,
+				MaxIterations:     len(tt.replies) + 1,
+				InitialQuery:      tt.query,
+				RunOnce:           true,
+				SkipPermissions:   true,
+				Deterministic:     true,
+				Session: &api.Session{
+					ID:               "test-session",
+					ChatMessageStore: store,
+					AgentState:       api.AgentStateIdle,
+				},
+			}
+			if err := a.Init(ctx); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			defer a.Close()
+			if err := a.Run(ctx, ""); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			for a.AgentState() != api.AgentStateExited {
+				select {
+				case <-a.Output:
+				case <-time.After(10 * time.Millisecond):
+				case <-ctx.Done():
+					t.Fatalf("the agent did not complete the scenario, state %s", a.AgentState())
+				}
+			}
+			for _, m := range a.chatHistory() {
+				if m.Source == api.MessageSourceModel && m.Type == api.MessageTypeText {
+					transcript.Answer, _ = m.Payload.(string)
+				}
+			}
+			if err := a.LastErr(); err != nil {
+				t.Fatalf("LastErr() = %v", err)
+			}
+
+			transcript.Commands = executor.commands
+			b, err := json.MarshalIndent(transcript, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, filepath.Join("scenarios", tt.name+".golden"), string(b)+"\n")
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"k8s.io/klog/v2"
+)
+
+// filterReadOnly refuses the tool calls of a read-only session that modify or
+// may modify resources, and returns the other calls. The refusals tell the
+// model why and suggest read-only alternatives, so that it can re-plan.
+func (c *Agent) filterReadOnly(ctx context.Context, calls []ToolCallAnalysis) []ToolCallAnalysis {
+	var allowed []ToolCallAnalysis
+	for _, call := range calls {
+		refusal := readOnlyRefusal(call)
+		if refusal == nil {
+			allowed = append(allowed, call)
+			continue
+		}
+		klog.FromContext(ctx).Info("Refusing a tool call in read-only mode", "command", call.ParsedToolCall.Description(), "reason", refusal.Reason)
+		c.rejectToolCall(call, refusal)
+	}
+	return allowed
+}
+
+// readOnlyRefusal returns the refusal of a tool call in read-only mode, or nil
+// if it is read-only. The commands of the kubectl and bash tools are checked
+// by the read-only filter of the tools, the other tools by their classification.
+func readOnlyRefusal(call ToolCallAnalysis) *tools.ReadOnlyRefusal {
+	command, isCommand := call.FunctionCall.Arguments["command"].(string)
+	isCommand = isCommand && (call.FunctionCall.Name == "kubectl" || call.FunctionCall.Name == "bash")
+	if isCommand {
+		if refusal := tools.CheckReadOnly(command); refusal != nil {
+			return refusal
+		}
+	}
+	switch {
+	case call.ModifiesResourceStr == "no":
+		return nil
+	case isCommand && call.ModifiesResourceStr == "unknown":
+		// The filter knows the commands the tool cannot classify, e.g. pipelines.
+		return nil
+	}
+	return &tools.ReadOnlyRefusal{
+		Command: call.ParsedToolCall.Description(),
+		Reason:  fmt.Sprintf("the %s tool may modify resources", call.FunctionCall.Name),
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"k8s.io/klog/v2"
+)
+
+const toolArgsRepairPromptTemplate = `You previously tried to call the tool %q but the arguments you produced were not valid JSON.
+
+Tool parameters schema:
+%s
+
+Malformed arguments:
+%s
+
+Re-emit the intended arguments as a single valid JSON object that matches the schema.
+Respond with the JSON object only, without any explanation or markdown formatting.`
+
+// repairFunctionCalls runs a healing pass over function calls whose arguments
+// could not be parsed by the provider. For each such call, the model (or the
+// configured ToolArgsRepairModel) is asked to re-emit valid JSON arguments.
+// It returns the calls that are ready to be analyzed and dispatched, and the
+// calls that could not be repaired.
+func (c *Agent) repairFunctionCalls(ctx context.Context, calls []gollm.FunctionCall) (repaired []gollm.FunctionCall, malformed []gollm.FunctionCall) {
+	log := klog.FromContext(ctx)
+
+	for _, call := range calls {
+		if call.RawArguments == "" {
+			repaired = append(repaired, call)
+			continue
+		}
+
+		log.Info("attempting to repair malformed tool call arguments", "tool", call.Name, "rawArguments", call.RawArguments)
+		args, err := c.repairFunctionCallArguments(ctx, call)
+		if err != nil {
+			log.Error(err, "failed to repair tool call arguments", "tool", call.Name)
+			malformed = append(malformed, call)
+			continue
+		}
+
+		call.Arguments = args
+		call.RawArguments = ""
+		repaired = append(repaired, call)
+	}
+	return repaired, malformed
+}
+
+// repairFunctionCallArguments asks the LLM to re-emit valid JSON arguments for a single call.
+func (c *Agent) repairFunctionCallArguments(ctx context.Context, call gollm.FunctionCall) (map[string]any, error) {
+	tool := c.Tools.Lookup(call.Name)
+	if tool == nil {
+		return nil, fmt.Errorf("tool %q not recognized", call.Name)
+	}
+
+	schema := "{}"
+	if params := tool.FunctionDefinition().Parameters; params != nil {
+		b, err := json.MarshalIndent(params, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshalling schema for tool %q: %w", call.Name, err)
+		}
+		schema = string(b)
+	}
+
+	model := c.ToolArgsRepairModel
+	if model == "" {
+		model = c.Model
+	}
+
+	resp, err := c.LLM.GenerateCompletion(ctx, &gollm.CompletionRequest{
+		Model:  model,
+		Prompt: fmt.Sprintf(toolArgsRepairPromptTemplate, call.Name, schema, call.RawArguments),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generating repaired arguments: %w", err)
+	}
+
+	return parseRepairedArguments(resp.Response())
+}
+
+// parseRepairedArguments extracts the JSON object from the repair response,
+// tolerating surrounding markdown fences or stray text.
+func parseRepairedArguments(response string) (map[string]any, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("no JSON object found in %q", response)
+	}
+
+	args := make(map[string]any)
+	if err := json.Unmarshal([]byte(response[start:end+1]), &args); err != nil {
+		return nil, fmt.Errorf("parsing repaired arguments %q: %w", response, err)
+	}
+	return args, nil
+}
+
+// malformedToolCallObservations builds the chat content that tells the model
+// its arguments were unusable, so the agentic loop keeps going instead of
+// stalling on a call we cannot execute.
+func (c *Agent) malformedToolCallObservations(calls []gollm.FunctionCall) []any {
+	var contents []any
+	for _, call := range calls {
+		errorMessage := fmt.Sprintf("The arguments for tool %q were not valid JSON and could not be repaired: %s. Please retry the call with valid JSON arguments.", call.Name, call.RawArguments)
+		if c.EnableToolUseShim {
+			contents = append(contents, fmt.Sprintf("Result of running %q:\n%v", call.Name, errorMessage))
+			continue
+		}
+		contents = append(contents, gollm.FunctionCallResult{
+			ID:   call.ID,
+			Name: call.Name,
+			Result: map[string]any{
+				"error":     errorMessage,
+				"status":    "invalid_arguments",
+				"retryable": true,
+			},
+		})
+	}
+	return contents
+}
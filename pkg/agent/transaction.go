@@ -0,0 +1,419 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// maxDiffLines bounds the lines of the diff of an object in the plan of a change.
+	maxDiffLines = 40
+	// maxDiffCells bounds the size of the table comparing the lines of an
+	// object, beyond which the whole object is shown as replaced.
+	maxDiffCells = 1 << 20
+)
+
+// stagedStep is a tool call of a change touching several resources, staged
+// before the change is applied so that it can be rolled back.
+type stagedStep struct {
+	// index is the index of the call in the pending function calls.
+	index   int
+	command string
+	verb    string
+	// flags are the flags selecting the cluster of the command, e.g. " --context prod".
+	flags   string
+	objects []stagedObject
+	// preview is the diff of the objects, or the output of the dry run of the command.
+	preview string
+}
+
+// stagedObject is an object changed by a staged step.
+type stagedObject struct {
+	// resource is the object as kind/name, e.g. "deployment.v1.apps/web".
+	resource  string
+	namespace string
+	// before is the object as YAML before the change, empty if it did not exist.
+	before string
+}
+
+// stageChanges stages the pending tool calls when more than one of them
+// modifies resources: each step is dry run on the server, and the objects it
+// changes are saved. The change is not staged, and no step is rolled back,
+// if one of the steps cannot be dry run or its objects cannot be saved.
+func (c *Agent) stageChanges(ctx context.Context) []stagedStep {
+	log := klog.FromContext(ctx)
+	var steps []stagedStep
+	for i, call := range c.pendingFunctionCalls {
+		if call.ModifiesResourceStr == "no" {
+			continue
+		}
+		step, err := c.stageStep(ctx, call)
+		if err != nil {
+			log.Info("Not staging the change", "command", call.ParsedToolCall.Description(), "reason", err)
+			return nil
+		}
+		step.index = i
+		steps = append(steps, *step)
+	}
+	if len(steps) < 2 {
+		return nil
+	}
+	return steps
+}
+
+// stageStep dry runs a tool call, and saves the objects it changes.
+func (c *Agent) stageStep(ctx context.Context, call ToolCallAnalysis) (*stagedStep, error) {
+	command, ok := call.FunctionCall.Arguments["command"].(string)
+	if !ok || (call.FunctionCall.Name != "kubectl" && call.FunctionCall.Name != "bash") {
+		return nil, errors.New("only kubectl commands can be staged")
+	}
+	dryRun, ok := dryRunCommand(command)
+	if !ok {
+		return nil, errors.New("the command cannot be dry run")
+	}
+	commands, _ := kubectl.Parse(command)
+	cmd := commands[0]
+	if cmd.Verb == "drain" {
+		return nil, errors.New("evicted pods cannot be restored")
+	}
+
+	step := &stagedStep{command: strings.TrimSpace(command), verb: cmd.Verb}
+	if cmd.Context != "" {
+		step.flags = " --context " + tools.ShellQuote(cmd.Context)
+	}
+	result, err := c.runKubectl(ctx, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	var after map[string]string
+	if result.ExitCode != 0 || result.Error != "" {
+		// The dry run of a step may depend on the previous steps, e.g. on the
+		// namespace they create.
+		step.preview = "The dry run failed: " + strings.TrimSpace(result.Stderr+" "+result.Error)
+	} else if dryRunVerbs[cmd.Verb] {
+		step.objects, after = dryRunObjects(result.Stdout)
+	} else {
+		step.preview = strings.TrimSpace(result.Stdout)
+	}
+	if len(step.objects) == 0 {
+		if len(cmd.Resources) != 1 || len(cmd.Names) == 0 {
+			return nil, errors.New("the objects changed by the command are unknown")
+		}
+		for _, name := range cmd.Names {
+			step.objects = append(step.objects, stagedObject{resource: cmd.Resources[0] + "/" + name, namespace: cmd.Namespace})
+		}
+	}
+
+	var diffs []string
+	for i, object := range step.objects {
+		get := "kubectl get " + tools.ShellQuote(object.resource) + namespaceFlag(object.namespace) + step.flags + " -o yaml --ignore-not-found"
+		result, err := c.runKubectl(ctx, get)
+		if err != nil {
+			return nil, err
+		}
+		if result.ExitCode != 0 || result.Error != "" {
+			return nil, fmt.Errorf("saving %s: %s", object.resource, strings.TrimSpace(result.Stderr+" "+result.Error))
+		}
+		if strings.TrimSpace(result.Stdout) != "" {
+			step.objects[i].before = cleanObject(result.Stdout)
+		}
+		if step.preview == "" {
+			// The objects deleted by the step are absent after it.
+			diffs = append(diffs, fmt.Sprintf("--- %s\n%s", object.resource, diffLines(step.objects[i].before, after[object.resource])))
+		}
+	}
+	if step.preview == "" {
+		step.preview = strings.Join(diffs, "\n")
+	}
+	return step, nil
+}
+
+// dryRunObjects returns the objects printed by the server-side dry run of a
+// command, as a single object or a list, and their YAML by resource.
+func dryRunObjects(output string) ([]stagedObject, map[string]string) {
+	var object map[string]any
+	if err := yaml.Unmarshal([]byte(output), &object); err != nil {
+		return nil, nil
+	}
+	items := []any{object}
+	if kind, _ := object["kind"].(string); strings.HasSuffix(kind, "List") {
+		items, _ = object["items"].([]any)
+	}
+	var objects []stagedObject
+	after := make(map[string]string)
+	for _, item := range items {
+		item, _ := item.(map[string]any)
+		kind, _ := item["kind"].(string)
+		apiVersion, _ := item["apiVersion"].(string)
+		metadata, _ := item["metadata"].(map[string]any)
+		name, _ := metadata["name"].(string)
+		if kind == "" || apiVersion == "" || name == "" {
+			continue
+		}
+		// e.g. "deployment.v1.apps", which is not ambiguous across API groups.
+		resource := strings.ToLower(kind)
+		if group, version, ok := strings.Cut(apiVersion, "/"); ok {
+			resource += "." + version + "." + group
+		}
+		resource += "/" + name
+		namespace, _ := metadata["namespace"].(string)
+		objects = append(objects, stagedObject{resource: resource, namespace: namespace})
+		b, err := yaml.Marshal(item)
+		if err == nil {
+			after[resource] = cleanObject(string(b))
+		}
+	}
+	return objects, after
+}
+
+// cleanObject removes the fields set by the server from an object as YAML,
+// so that it can be compared and created or replaced again.
+func cleanObject(text string) string {
+	var object map[string]any
+	if err := yaml.Unmarshal([]byte(text), &object); err != nil {
+		return text
+	}
+	delete(object, "status")
+	if metadata, ok := object["metadata"].(map[string]any); ok {
+		for _, field := range []string{"managedFields", "resourceVersion", "uid", "creationTimestamp", "generation", "selfLink"} {
+			delete(metadata, field)
+		}
+	}
+	b, err := yaml.Marshal(object)
+	if err != nil {
+		return text
+	}
+	return string(b)
+}
+
+// diffLines returns the lines removed from and added to a text, prefixed with
+// "-" and "+", and the lines around them, prefixed with a space.
+func diffLines(before, after string) string {
+	a := splitLines(before)
+	b := splitLines(after)
+	if len(a)*len(b) > maxDiffCells {
+		return limitLines(append(prefixLines("-", a), prefixLines("+", b)...))
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+
+	if lcs[0][0] == len(a) && len(a) == len(b) {
+		return " (no changes)"
+	}
+	// Only the changed lines and one line around them are kept.
+	var kept []string
+	for k, line := range lines {
+		if !strings.HasPrefix(line, " ") || k > 0 && !strings.HasPrefix(lines[k-1], " ") || k+1 < len(lines) && !strings.HasPrefix(lines[k+1], " ") {
+			kept = append(kept, line)
+		} else if len(kept) == 0 || kept[len(kept)-1] != " ..." {
+			kept = append(kept, " ...")
+		}
+	}
+	return limitLines(kept)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func prefixLines(prefix string, lines []string) []string {
+	prefixed := make([]string, len(lines))
+	for i, line := range lines {
+		prefixed[i] = prefix + line
+	}
+	return prefixed
+}
+
+// limitLines joins at most maxDiffLines lines.
+func limitLines(lines []string) string {
+	if len(lines) > maxDiffLines {
+		lines = append(lines[:maxDiffLines], fmt.Sprintf(" ... %d more lines", len(lines)-maxDiffLines))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stagedPlan returns the plan of a staged change, with the diffs of its steps.
+func stagedPlan(steps []stagedStep, calls []ToolCallAnalysis) string {
+	var b strings.Builder
+	b.WriteString("The following changes are applied in order. If a step fails, the steps applied before it are rolled back.\n")
+	for i, step := range steps {
+		fmt.Fprintf(&b, "\n%d. `%s`", i+1, step.command)
+		if calls[step.index].destructive() {
+			b.WriteString(" (⚠️ deletes resources)")
+		}
+		fmt.Fprintf(&b, "\n```diff\n%s\n```\n", step.preview)
+	}
+	return b.String()
+}
+
+// isStaged returns true if the pending tool call of the index is a staged step.
+func isStaged(steps []stagedStep, index int) bool {
+	for _, step := range steps {
+		if step.index == index {
+			return true
+		}
+	}
+	return false
+}
+
+// stepFailed returns true if a staged step failed, and the change must be rolled back.
+func stepFailed(output any, err error) bool {
+	if err != nil {
+		return true
+	}
+	result, ok := output.(*sandbox.ExecResult)
+	return ok && result != nil && (result.ExitCode != 0 || result.Error != "")
+}
+
+// rollBack restores the objects changed by the staged steps applied before
+// the failed call of index failed, in reverse order, and returns a summary.
+func (c *Agent) rollBack(ctx context.Context, steps []stagedStep, failed int) string {
+	// The change is rolled back even when the task was interrupted.
+	ctx = context.WithoutCancel(ctx)
+	var lines []string
+	for k := len(steps) - 1; k >= 0; k-- {
+		step := steps[k]
+		if step.index >= failed {
+			continue
+		}
+		if err := c.rollBackStep(ctx, step); err != nil {
+			klog.FromContext(ctx).Error(err, "rolling back", "command", step.command)
+			lines = append(lines, fmt.Sprintf("could not roll back `%s`: %v", step.command, err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("rolled back `%s`", step.command))
+	}
+	if len(lines) == 0 {
+		return "The change failed at its first step, nothing was rolled back."
+	}
+	return "The change failed, the steps applied before the failed one were rolled back:\n* " + strings.Join(lines, "\n* ")
+}
+
+// rollBackStep restores the objects changed by a step: the objects created
+// are deleted, the objects deleted are created again, and the other ones are
+// replaced by their saved version.
+func (c *Agent) rollBackStep(ctx context.Context, step stagedStep) error {
+	for k := len(step.objects) - 1; k >= 0; k-- {
+		object := step.objects[k]
+		var command string
+		switch {
+		case object.before == "":
+			command = "kubectl delete " + tools.ShellQuote(object.resource) + namespaceFlag(object.namespace) + step.flags + " --ignore-not-found"
+		case step.verb == "delete":
+			command = "kubectl create" + step.flags + " -f - <<'KUBECTL_AI_ROLLBACK_EOF'\n" + object.before + "KUBECTL_AI_ROLLBACK_EOF"
+		default:
+			command = "kubectl replace" + step.flags + " -f - <<'KUBECTL_AI_ROLLBACK_EOF'\n" + object.before + "KUBECTL_AI_ROLLBACK_EOF"
+		}
+		result, err := c.runKubectl(ctx, command)
+		if err != nil {
+			return err
+		}
+		if result.ExitCode != 0 || result.Error != "" {
+			return fmt.Errorf("restoring %s: %s", object.resource, strings.TrimSpace(result.Stderr+" "+result.Error))
+		}
+	}
+	return nil
+}
+
+// withRollback adds the summary of a rollback to the result of the failed step.
+func withRollback(output any, summary string) any {
+	if result, ok := output.(*sandbox.ExecResult); ok && result != nil {
+		annotated := *result
+		annotated.Error = strings.TrimSpace(annotated.Error + "\n" + summary)
+		return &annotated
+	}
+	return output
+}
+
+// skipRemainingSteps reports the pending tool calls after a failed step of a
+// staged change as not run.
+func (c *Agent) skipRemainingSteps(calls []ToolCallAnalysis) {
+	for _, call := range calls {
+		c.rejectToolCall(call, fmt.Errorf("%q was not run, a previous step of the change failed", call.ParsedToolCall.Description()))
+	}
+}
+
+// runKubectl runs a kubectl command with the bash tool, outside of the tool
+// calls of the model.
+func (c *Agent) runKubectl(ctx context.Context, command string) (*sandbox.ExecResult, error) {
+	call, err := c.Tools.ParseToolInvocation(ctx, "bash", map[string]any{"command": command})
+	if err != nil {
+		return nil, err
+	}
+	output, err := call.InvokeTool(ctx, tools.InvokeToolOptions{
+		Kubeconfig: c.Kubeconfig,
+		WorkDir:    c.workDir,
+		Executor:   c.executor,
+		Env:        c.toolEnv(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("running %q: %w", command, err)
+	}
+	result, ok := output.(*sandbox.ExecResult)
+	if !ok || result == nil {
+		return nil, fmt.Errorf("running %q: unexpected result %T", command, output)
+	}
+	return result, nil
+}
+
+// namespaceFlag returns the namespace flag of a command, empty for the current namespace.
+func namespaceFlag(namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+	return " --namespace " + tools.ShellQuote(namespace)
+}
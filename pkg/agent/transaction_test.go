@@ -0,0 +1,184 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{
+			name:   "changed field",
+			before: "kind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 1\n  paused: false\n  strategy: {}\n",
+			after:  "kind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 3\n  paused: false\n  strategy: {}\n",
+			want:   " ...\n spec:\n-  replicas: 1\n+  replicas: 3\n   paused: false\n ...",
+		},
+		{
+			name:  "created",
+			after: "kind: ConfigMap\n",
+			want:  "+kind: ConfigMap",
+		},
+		{
+			name:   "deleted",
+			before: "kind: ConfigMap\n",
+			want:   "-kind: ConfigMap",
+		},
+		{
+			name:   "unchanged",
+			before: "kind: ConfigMap\n",
+			after:  "kind: ConfigMap\n",
+			want:   " (no changes)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffLines(tt.before, tt.after); got != tt.want {
+				t.Errorf("diffLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDryRunObjects(t *testing.T) {
+	output := `apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: web
+    namespace: shop
+    resourceVersion: "42"
+  spec:
+    replicas: 3
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: settings
+    namespace: shop
+  data:
+    mode: fast
+`
+	objects, after := dryRunObjects(output)
+	want := []stagedObject{
+		{resource: "deployment.v1.apps/web", namespace: "shop"},
+		{resource: "configmap/settings", namespace: "shop"},
+	}
+	if !reflect.DeepEqual(objects, want) {
+		t.Errorf("dryRunObjects() objects = %+v, want %+v", objects, want)
+	}
+	wantDeployment := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: shop\nspec:\n  replicas: 3\n"
+	if got := after["deployment.v1.apps/web"]; got != wantDeployment {
+		t.Errorf("dryRunObjects() deployment = %q, want %q without the fields set by the server", got, wantDeployment)
+	}
+}
+
+// stagingExecutor returns the output of the first command prefix matching
+// each command, and an empty output for the other commands.
+type stagingExecutor struct {
+	results  map[string]*sandbox.ExecResult
+	commands []string
+}
+
+func (e *stagingExecutor) Execute(_ context.Context, command string, _ []string, _ string) (*sandbox.ExecResult, error) {
+	e.commands = append(e.commands, command)
+	for prefix, result := range e.results {
+		if strings.HasPrefix(command, prefix) {
+			return result, nil
+		}
+	}
+	return &sandbox.ExecResult{Command: command}, nil
+}
+
+func (e *stagingExecutor) Close(context.Context) error { return nil }
+
+func TestStageChangesAndRollBack(t *testing.T) {
+	executor := &stagingExecutor{results: map[string]*sandbox.ExecResult{
+		"kubectl scale deploy/web --replicas=3 -n shop --dry-run=server": {
+			Stdout: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: shop\nspec:\n  replicas: 3\n",
+		},
+		"kubectl get 'deployment.v1.apps/web'": {
+			Stdout: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: shop\n  uid: 1234\nspec:\n  replicas: 1\nstatus:\n  replicas: 1\n",
+		},
+		"kubectl create configmap settings -n shop --from-literal=mode=fast --dry-run=server": {
+			Stdout: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n  namespace: shop\ndata:\n  mode: fast\n",
+		},
+	}}
+	a := &Agent{executor: executor, StageChanges: true}
+	a.Tools.Init()
+	a.Tools.RegisterTool(tools.NewBashTool(executor))
+	analysis, err := a.analyzeToolCalls(context.Background(), []gollm.FunctionCall{
+		{Name: "bash", Arguments: map[string]any{"command": "kubectl get pods -n shop"}},
+		{Name: "bash", Arguments: map[string]any{"command": "kubectl scale deploy/web --replicas=3 -n shop"}},
+		{Name: "bash", Arguments: map[string]any{"command": "kubectl create configmap settings -n shop --from-literal=mode=fast"}},
+	})
+	if err != nil {
+		t.Fatalf("analyzeToolCalls() error = %v", err)
+	}
+	a.pendingFunctionCalls = analysis
+
+	steps := a.stageChanges(context.Background())
+	if len(steps) != 2 || steps[0].index != 1 || steps[1].index != 2 {
+		t.Fatalf("stageChanges() = %+v, want the two calls modifying resources", steps)
+	}
+	if want := "--- deployment.v1.apps/web\n ...\n spec:\n-  replicas: 1\n+  replicas: 3"; steps[0].preview != want {
+		t.Errorf("preview of the scale = %q, want %q", steps[0].preview, want)
+	}
+	if steps[1].objects[0].before != "" {
+		t.Errorf("the configmap was saved as %q, want it absent", steps[1].objects[0].before)
+	}
+	plan := stagedPlan(steps, a.pendingFunctionCalls)
+	if !strings.Contains(plan, "1. `kubectl scale deploy/web --replicas=3 -n shop`") || !strings.Contains(plan, "2. `kubectl create configmap settings") {
+		t.Errorf("stagedPlan() = %q, want the steps in order", plan)
+	}
+
+	executor.commands = nil
+	summary := a.rollBack(context.Background(), steps, 2)
+	wantCommands := []string{"kubectl replace -f - <<'KUBECTL_AI_ROLLBACK_EOF'\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: shop\nspec:\n  replicas: 1\nKUBECTL_AI_ROLLBACK_EOF"}
+	if !reflect.DeepEqual(executor.commands, wantCommands) {
+		t.Errorf("rollBack() ran %q, want %q", executor.commands, wantCommands)
+	}
+	if !strings.Contains(summary, "rolled back `kubectl scale deploy/web --replicas=3 -n shop`") {
+		t.Errorf("rollBack() = %q, want the scale rolled back", summary)
+	}
+
+	executor.commands = nil
+	a.rollBack(context.Background(), steps, 3)
+	if len(executor.commands) != 2 || executor.commands[0] != "kubectl delete 'configmap/settings' --namespace 'shop' --ignore-not-found" {
+		t.Errorf("rollBack() ran %q, want the configmap deleted first", executor.commands)
+	}
+
+	// A change is not staged if the objects of one of its steps are unknown.
+	a.pendingFunctionCalls, _ = a.analyzeToolCalls(context.Background(), []gollm.FunctionCall{
+		{Name: "bash", Arguments: map[string]any{"command": "kubectl scale deploy/web --replicas=3 -n shop"}},
+		{Name: "bash", Arguments: map[string]any{"command": "kubectl delete pods -l app=web -n shop"}},
+	})
+	if steps := a.stageChanges(context.Background()); steps != nil {
+		t.Errorf("stageChanges() = %+v, want nil", steps)
+	}
+}
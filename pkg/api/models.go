@@ -20,10 +20,12 @@ import (
 )
 
 type Session struct {
-	ID               string
-	Name             string
-	ProviderID       string
-	ModelID          string
+	ID         string
+	Name       string
+	ProviderID string
+	ModelID    string
+	// KubeContext is the kubeconfig context the session was started with.
+	KubeContext      string
 	Messages         []*Message
 	AgentState       AgentState
 	CreatedAt        time.Time
@@ -31,6 +33,49 @@ type Session struct {
 	ChatMessageStore ChatMessageStore
 	// MCP status information
 	MCPStatus *MCPStatus
+	// AllowedCommands are the command patterns the user always allows in this
+	// session without approval, e.g. "kubectl scale *".
+	AllowedCommands []string
+	// LearnedPreferences are the preferences learned from the corrections of
+	// the user in this session, e.g. "never touch namespace `kube-system`".
+	LearnedPreferences []string
+	// Labels attach the session to other systems, e.g. the Backstage entity it was started from.
+	Labels map[string]string
+	// Usage is the token usage of the session, per provider and model.
+	Usage []ModelUsage
+	// ParentID is the ID of the session this session was forked from, if any.
+	ParentID string
+	// Feedback are the ratings of the answers of the session by the user.
+	Feedback []Feedback
+}
+
+// ModelUsage is the token usage of a model of a provider.
+type ModelUsage struct {
+	Provider     string `json:"provider"`
+	Model        string `json:"model"`
+	InputTokens  int64  `json:"inputTokens"`
+	OutputTokens int64  `json:"outputTokens"`
+}
+
+// FeedbackRating is the rating of an answer, up or down.
+type FeedbackRating string
+
+const (
+	FeedbackUp   FeedbackRating = "up"
+	FeedbackDown FeedbackRating = "down"
+)
+
+// Feedback is the rating of an answer of the agent by the user.
+type Feedback struct {
+	Rating  FeedbackRating `json:"rating"`
+	Comment string         `json:"comment,omitempty"`
+	// MessageID is the ID of the message of the answer.
+	MessageID string `json:"messageID"`
+	// Query is the request of the user the answer was given to.
+	Query    string    `json:"query,omitempty"`
+	Provider string    `json:"provider,omitempty"`
+	Model    string    `json:"model,omitempty"`
+	Time     time.Time `json:"time"`
 }
 
 type AgentState string
@@ -55,14 +100,68 @@ const (
 	MessageTypeUserInputResponse  MessageType = "user-input-response"
 	MessageTypeUserChoiceRequest  MessageType = "user-choice-request"
 	MessageTypeUserChoiceResponse MessageType = "user-choice-response"
+
+	// MessageTypeToolCallProgress is the output of an async tool call while it runs.
+	// It is shown in the UI, but not sent to the model.
+	MessageTypeToolCallProgress MessageType = "tool-call-progress"
+	// MessageTypeToolInputRequest is the prompt of a tool subprocess waiting for input.
+	// The UI answers it with a UserInputResponse, which is written to the stdin of the subprocess.
+	MessageTypeToolInputRequest MessageType = "tool-input-request"
+	// MessageTypeTable and MessageTypeCode show the structured result of a tool
+	// call, e.g. the table of "kubectl get pods" or the YAML of "kubectl get pod -o yaml".
+	// They are shown in the UI after the tool-call-response, but not sent to the model.
+	MessageTypeTable MessageType = "table"
+	MessageTypeCode  MessageType = "code"
+	// MessageTypeProgress is the progress of a long running tool call. Each
+	// update is a new message, the UIs show the latest one as a progress bar.
+	MessageTypeProgress MessageType = "progress"
+	// MessageTypeArtifact is a file produced by a tool call, e.g. a manifest or a log bundle.
+	MessageTypeArtifact MessageType = "artifact"
 )
 
+// Message is a message of a session. It is encoded to JSON with the
+// MessageSchemaVersion, and its Payload is decoded to the type matching its Type,
+// see payloads.go.
 type Message struct {
-	ID        string
-	Source    MessageSource
-	Type      MessageType
+	ID     string
+	Source MessageSource
+	Type   MessageType
+	// Payload holds a string for text, error, tool-call-request and user-input-request messages,
+	// the tool result (a map, or a string with the tool use shim) or an AttachmentPreview for
+	// tool-call-response messages, a *UserChoiceRequest, *UserChoiceResponse or
+	// *UserInputResponse for the user choice and input messages, and a *TableBlock
+	// or *CodeBlock for the table and code messages, and a *ProgressBlock for the
+	// progress messages, and an *ArtifactBlock for the artifact messages.
 	Payload   any
 	Timestamp time.Time
+	// AttachmentID is set when the payload was too large to be kept in the message.
+	// Payload then holds an AttachmentPreview, and the full payload is stored as
+	// an attachment of the chat message store.
+	AttachmentID string `json:",omitempty"`
+	// Reasoning is set on the text of the model accompanying its tool calls,
+	// as opposed to its answers.
+	Reasoning bool `json:",omitempty"`
+	// ResourceLinks are the resources referenced by an answer of the model.
+	ResourceLinks []ResourceLink `json:",omitempty"`
+}
+
+// ResourceLink is a resource referenced by an answer of the model, e.g.
+// "deployment/web", which the user opens with "/open <number>".
+type ResourceLink struct {
+	Number    int    `json:"number"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	// Command is the read-only command opening the resource, e.g.
+	// "kubectl describe deployment/web --namespace shop".
+	Command string `json:"command"`
+}
+
+// AttachmentPreview replaces the payload of a message whose payload is stored as an attachment.
+type AttachmentPreview struct {
+	// Preview is the beginning of the JSON encoded payload.
+	Preview string `json:"preview"`
+	// Size is the size of the JSON encoded payload in bytes.
+	Size int `json:"size"`
 }
 
 type MessageSource string
@@ -76,6 +175,8 @@ const (
 type UserChoiceRequest struct {
 	Prompt  string
 	Options []UserChoiceOption
+	// Commands are the commands the user is asked to approve, if any.
+	Commands []string `json:",omitempty"`
 }
 
 type UserChoiceOption struct {
@@ -85,12 +186,69 @@ type UserChoiceOption struct {
 
 type UserChoiceResponse struct {
 	Choice int `json:"choice"`
+	// Command is the command edited by the user, for the edit choice of the approval prompt.
+	Command string `json:"command,omitempty"`
 }
 
 type UserInputResponse struct {
 	Query string `json:"query"`
 }
 
+// Block is the payload of the messages showing the structured result of a tool call.
+type Block interface {
+	// MessageType is the type of the messages of the block.
+	MessageType() MessageType
+}
+
+// TableBlock is a table, e.g. the output of "kubectl get pods".
+type TableBlock struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+func (*TableBlock) MessageType() MessageType { return MessageTypeTable }
+
+// CodeBlock is a code listing, e.g. the YAML manifest of a resource.
+type CodeBlock struct {
+	// Language is the language of the code for syntax highlighting, e.g. "yaml" or "json".
+	Language string `json:"language,omitempty"`
+	Code     string `json:"code"`
+}
+
+func (*CodeBlock) MessageType() MessageType { return MessageTypeCode }
+
+// ProgressBlock is the progress of a long running tool call, e.g. "Scanning
+// resources" at 3 of 12. The progress is indeterminate if Total is 0.
+type ProgressBlock struct {
+	Label string `json:"label"`
+	// Stage is the current step, e.g. the resource being scanned.
+	Stage string `json:"stage,omitempty"`
+	Done  int    `json:"done,omitempty"`
+	Total int    `json:"total,omitempty"`
+}
+
+func (*ProgressBlock) MessageType() MessageType { return MessageTypeProgress }
+
+// Percent returns the completed percentage, or -1 if the progress is indeterminate.
+func (p *ProgressBlock) Percent() int {
+	if p.Total <= 0 {
+		return -1
+	}
+	return min(100, max(0, p.Done*100/p.Total))
+}
+
+// ArtifactBlock is a file a tool call created or modified in the work
+// directory of the session, e.g. a manifest or a log bundle.
+type ArtifactBlock struct {
+	// Name is the path of the file relative to the work directory, with slashes.
+	Name string `json:"name"`
+	// Path is the absolute path of the file.
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+func (*ArtifactBlock) MessageType() MessageType { return MessageTypeArtifact }
+
 // MCPStatus represents the overall status of MCP servers and tools
 type MCPStatus struct {
 	ServerInfoList []ServerConnectionInfo `json:"serverInfoList,omitempty"`
@@ -125,6 +283,13 @@ type ChatMessageStore interface {
 	ClearChatMessages() error
 }
 
+// AttachmentStore is implemented by chat message stores that keep large
+// message payloads as content-addressed attachments.
+type AttachmentStore interface {
+	// Attachment returns the JSON encoded payload stored under the given ID.
+	Attachment(id string) ([]byte, error)
+}
+
 func (s *Session) AllMessages() []*Message {
 	if s.ChatMessageStore == nil {
 		return nil
@@ -133,6 +298,6 @@ func (s *Session) AllMessages() []*Message {
 }
 
 func (s *Session) String() string {
-	return fmt.Sprintf("Session ID: %s\nProvider: %s\nModel: %s\nCreated At: %s\nLast Modified: %s\nAgent State: %s",
-		s.ID, s.ProviderID, s.ModelID, s.CreatedAt.Format(time.RFC3339), s.LastModified.Format(time.RFC3339), s.AgentState)
+	return fmt.Sprintf("Session ID: %s\nProvider: %s\nModel: %s\nKube Context: %s\nCreated At: %s\nLast Modified: %s\nAgent State: %s",
+		s.ID, s.ProviderID, s.ModelID, s.KubeContext, s.CreatedAt.Format(time.RFC3339), s.LastModified.Format(time.RFC3339), s.AgentState)
 }
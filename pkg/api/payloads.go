@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MessageSchemaVersion is the version of the JSON encoding of Message.
+// Messages written before the version was introduced have no version field
+// and decode as version 0, which has the same layout.
+//
+// Bump the version when the encoding of an existing payload changes, and keep
+// decoding the previous versions. New message types do not need a new version:
+// their payloads decode as generic JSON values in older releases.
+const MessageSchemaVersion = 1
+
+// payloadDecoders decodes the payload of each message type to its Go type.
+var payloadDecoders = map[MessageType]func(data json.RawMessage) (any, error){
+	MessageTypeText:               decodePayload[string],
+	MessageTypeError:              decodePayload[string],
+	MessageTypeToolCallRequest:    decodePayload[string],
+	MessageTypeToolCallResponse:   decodePayload[any],
+	MessageTypeToolCallProgress:   decodePayload[string],
+	MessageTypeToolInputRequest:   decodePayload[string],
+	MessageTypeUserInputRequest:   decodePayload[string],
+	MessageTypeUserInputResponse:  decodePayload[*UserInputResponse],
+	MessageTypeUserChoiceRequest:  decodePayload[*UserChoiceRequest],
+	MessageTypeUserChoiceResponse: decodePayload[*UserChoiceResponse],
+	MessageTypeTable:              decodePayload[*TableBlock],
+	MessageTypeCode:               decodePayload[*CodeBlock],
+	MessageTypeProgress:           decodePayload[*ProgressBlock],
+	MessageTypeArtifact:           decodePayload[*ArtifactBlock],
+}
+
+func decodePayload[T any](data json.RawMessage) (any, error) {
+	var payload T
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// MarshalJSON encodes the message with the current MessageSchemaVersion.
+func (m Message) MarshalJSON() ([]byte, error) {
+	type message Message
+	return json.Marshal(struct {
+		Version int
+		message
+	}{
+		Version: MessageSchemaVersion,
+		message: message(m),
+	})
+}
+
+// UnmarshalJSON decodes a message of any schema version, decoding its
+// payload to the Go type of its message type. Payloads of unknown message
+// types, or that do not match the type of their message type, are decoded
+// as generic JSON values so that newer session files can still be read.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type message Message
+	var raw struct {
+		Version int
+		message
+		Payload json.RawMessage
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = Message(raw.message)
+
+	if len(raw.Payload) == 0 || bytes.Equal(raw.Payload, []byte("null")) {
+		m.Payload = nil
+		return nil
+	}
+
+	decode, ok := payloadDecoders[m.Type]
+	if m.AttachmentID != "" {
+		decode, ok = decodePayload[AttachmentPreview], true
+	}
+	if ok {
+		if payload, err := decode(raw.Payload); err == nil {
+			m.Payload = payload
+			return nil
+		}
+	}
+	payload, err := decodePayload[any](raw.Payload)
+	if err != nil {
+		return err
+	}
+	m.Payload = payload
+	return nil
+}
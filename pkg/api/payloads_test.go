@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessageJSONRoundTrip(t *testing.T) {
+	timestamp := time.Date(2025, 6, 11, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		message Message
+	}{
+		{name: "text", message: Message{Type: MessageTypeText, Source: MessageSourceModel, Payload: "hello"}},
+		{name: "error", message: Message{Type: MessageTypeError, Source: MessageSourceAgent, Payload: "Error: boom"}},
+		{name: "tool call", message: Message{Type: MessageTypeToolCallRequest, Source: MessageSourceModel, Payload: "kubectl get pods"}},
+		{name: "tool result", message: Message{Type: MessageTypeToolCallResponse, Source: MessageSourceAgent, Payload: map[string]any{"stdout": "pod-1", "exitCode": float64(0)}}},
+		{name: "shim tool result", message: Message{Type: MessageTypeToolCallResponse, Source: MessageSourceAgent, Payload: "Result of running \"kubectl\""}},
+		{name: "attachment", message: Message{Type: MessageTypeToolCallResponse, Source: MessageSourceAgent, AttachmentID: "abc", Payload: AttachmentPreview{Preview: `{"stdout":"`, Size: 20000}}},
+		{name: "approval request", message: Message{Type: MessageTypeUserChoiceRequest, Source: MessageSourceAgent, Payload: &UserChoiceRequest{
+			Prompt:  "Do you want to proceed?",
+			Options: []UserChoiceOption{{Label: "Yes", Value: "yes"}, {Label: "No", Value: "no"}},
+		}}},
+		{name: "approval response", message: Message{Type: MessageTypeUserChoiceResponse, Source: MessageSourceUser, Payload: &UserChoiceResponse{Choice: 1}}},
+		{name: "input response", message: Message{Type: MessageTypeUserInputResponse, Source: MessageSourceUser, Payload: &UserInputResponse{Query: "why is my pod failing?"}}},
+		{name: "table", message: Message{Type: MessageTypeTable, Source: MessageSourceAgent, Payload: &TableBlock{
+			Columns: []string{"NAME", "READY", "STATUS"},
+			Rows:    [][]string{{"web-1", "1/1", "Running"}},
+		}}},
+		{name: "code", message: Message{Type: MessageTypeCode, Source: MessageSourceAgent, Payload: &CodeBlock{Language: "yaml", Code: "apiVersion: v1\nkind: Pod\n"}}},
+		{name: "progress", message: Message{Type: MessageTypeProgress, Source: MessageSourceAgent, Payload: &ProgressBlock{Label: "Scanning resources", Stage: "cronjobs.batch", Done: 3, Total: 12}}},
+		{name: "artifact", message: Message{Type: MessageTypeArtifact, Source: MessageSourceAgent, Payload: &ArtifactBlock{Name: "logs/web.tar.gz", Path: "/tmp/agent-workdir-1/logs/web.tar.gz", Size: 2048}}},
+		{name: "no payload", message: Message{Type: MessageTypeText, Source: MessageSourceAgent}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.message.ID = "id"
+			tt.message.Timestamp = timestamp
+
+			data, err := json.Marshal(&tt.message)
+			if err != nil {
+				t.Fatalf("Marshal() error: %v", err)
+			}
+			if !strings.Contains(string(data), `"Version":1`) {
+				t.Errorf("Marshal() = %s, missing the schema version", data)
+			}
+
+			var got Message
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.message) {
+				t.Errorf("round trip = %#v, want %#v", got, tt.message)
+			}
+		})
+	}
+}
+
+func TestMessageUnmarshalCompatibility(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want any
+	}{
+		{
+			name: "unversioned",
+			data: `{"ID":"1","Source":"agent","Type":"user-choice-request","Payload":{"Prompt":"Proceed?","Options":[{"label":"Yes","value":"yes"}]},"Timestamp":"2025-06-11T10:30:00Z"}`,
+			want: &UserChoiceRequest{Prompt: "Proceed?", Options: []UserChoiceOption{{Label: "Yes", Value: "yes"}}},
+		},
+		{
+			name: "unknown message type",
+			data: `{"Version":2,"ID":"1","Source":"agent","Type":"plan","Payload":{"steps":["a"]},"Timestamp":"2025-06-11T10:30:00Z"}`,
+			want: map[string]any{"steps": []any{"a"}},
+		},
+		{
+			name: "payload not matching its type",
+			data: `{"Version":2,"ID":"1","Source":"agent","Type":"text","Payload":{"text":"hi","format":"markdown"},"Timestamp":"2025-06-11T10:30:00Z"}`,
+			want: map[string]any{"text": "hi", "format": "markdown"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Message
+			if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+				t.Fatalf("Unmarshal() error: %v", err)
+			}
+			if !reflect.DeepEqual(got.Payload, tt.want) {
+				t.Errorf("Payload = %#v, want %#v", got.Payload, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deploy generates the Helm chart deploying kubectl-ai in a cluster,
+// serving the web UI or the MCP server. The chart is written to charts/kubectl-ai
+// by dev/tasks/generate-helm-chart.sh, its values are defined by Values.
+package deploy
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+//go:embed templates/*
+var templates embed.FS
+
+// ChartVersion is the version of the chart, bumped when the templates or the values change.
+const ChartVersion = "0.1.0"
+
+// The modes kubectl-ai is served in.
+const (
+	// ModeHTML serves the web UI.
+	ModeHTML = "html"
+	// ModeMCP serves the MCP server over streamable HTTP.
+	ModeMCP = "mcp"
+)
+
+// ProviderKeyEnvs are the environment variables the LLM providers read their
+// keys and endpoints from, the keys of the provider secret.
+var ProviderKeyEnvs = []string{
+	"GEMINI_API_KEY",
+	"OPENAI_API_KEY",
+	"OPENAI_ENDPOINT",
+	"AZURE_OPENAI_API_KEY",
+	"AZURE_OPENAI_ENDPOINT",
+	"GROK_API_KEY",
+	"LLM_GATEWAY_API_KEY",
+	"LLM_GATEWAY_URL",
+}
+
+// Values are the values of the chart, written to its values.yaml with their defaults.
+type Values struct {
+	Image ImageValues `json:"image"`
+	// Mode is html to serve the web UI, or mcp to serve the MCP server.
+	Mode        string `json:"mode"`
+	LLMProvider string `json:"llmProvider"`
+	Model       string `json:"model"`
+	// ReadOnly refuses the tool calls modifying resources, and binds the view cluster role.
+	ReadOnly bool `json:"readOnly"`
+	// ExtraArgs are added to the arguments of kubectl-ai.
+	ExtraArgs []string `json:"extraArgs"`
+
+	Service        ServiceValues        `json:"service"`
+	ServiceAccount ServiceAccountValues `json:"serviceAccount"`
+	RBAC           RBACValues           `json:"rbac"`
+	ProviderSecret ProviderSecretValues `json:"providerSecret"`
+	// GoogleCredentialsSecret is the secret holding the key.json of a Google
+	// service account, for the vertexai provider. It is mounted read-only.
+	GoogleCredentialsSecret string              `json:"googleCredentialsSecret"`
+	NetworkPolicy           NetworkPolicyValues `json:"networkPolicy"`
+	Resources               ResourcesValues     `json:"resources"`
+
+	NodeSelector map[string]string `json:"nodeSelector"`
+	Tolerations  []map[string]any  `json:"tolerations"`
+	Affinity     map[string]any    `json:"affinity"`
+}
+
+type ImageValues struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	PullPolicy string `json:"pullPolicy"`
+}
+
+type ServiceValues struct {
+	Type string `json:"type"`
+	Port int    `json:"port"`
+}
+
+type ServiceAccountValues struct {
+	Create      bool              `json:"create"`
+	Name        string            `json:"name"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// RBACValues bind a cluster role to the service account, across the cluster
+// or in the listed namespaces only.
+type RBACValues struct {
+	Create bool `json:"create"`
+	// ClusterRole is bound to the service account, view in read-only mode if empty, edit otherwise.
+	ClusterRole string   `json:"clusterRole"`
+	Namespaces  []string `json:"namespaces"`
+}
+
+// ProviderSecretValues are the keys of the LLM providers, set as environment
+// variables from a secret: an existing one, or one created from Data.
+type ProviderSecretValues struct {
+	ExistingSecret string            `json:"existingSecret"`
+	Data           map[string]string `json:"data"`
+}
+
+// NetworkPolicyValues restrict the traffic of the pod: ingress to the served
+// port from the listed peers, and egress to DNS and to HTTPS endpoints only.
+type NetworkPolicyValues struct {
+	Enabled bool `json:"enabled"`
+	// IngressFrom are the NetworkPolicy peers allowed to reach the served port, everyone if empty.
+	IngressFrom []map[string]any `json:"ingressFrom"`
+	// EgressCIDRs are the destinations of the HTTPS traffic: the Kubernetes
+	// API server and the endpoints of the LLM provider.
+	EgressCIDRs []string `json:"egressCIDRs"`
+	// EgressPorts are the destination ports of the egress traffic, e.g. 6443 for the API server.
+	EgressPorts []int `json:"egressPorts"`
+}
+
+type ResourcesValues struct {
+	Requests map[string]string `json:"requests"`
+	Limits   map[string]string `json:"limits"`
+}
+
+// DefaultValues returns the default values of the chart: the web UI with
+// Gemini in read-only mode, hardened by a network policy.
+func DefaultValues() Values {
+	return Values{
+		Image: ImageValues{
+			Repository: "kubectl-ai-distroless",
+			Tag:        "latest",
+			PullPolicy: "IfNotPresent",
+		},
+		Mode:        ModeHTML,
+		LLMProvider: "gemini",
+		Model:       "gemini-2.5-pro",
+		ReadOnly:    true,
+		ExtraArgs:   []string{},
+		Service:     ServiceValues{Type: "ClusterIP", Port: 80},
+		ServiceAccount: ServiceAccountValues{
+			Create:      true,
+			Annotations: map[string]string{},
+		},
+		RBAC:           RBACValues{Create: true, Namespaces: []string{}},
+		ProviderSecret: ProviderSecretValues{Data: map[string]string{}},
+		NetworkPolicy: NetworkPolicyValues{
+			Enabled:     true,
+			IngressFrom: []map[string]any{},
+			EgressCIDRs: []string{"0.0.0.0/0"},
+			EgressPorts: []int{443, 6443},
+		},
+		Resources: ResourcesValues{
+			Requests: map[string]string{"cpu": "100m", "memory": "256Mi"},
+			Limits:   map[string]string{"cpu": "1", "memory": "1Gi"},
+		},
+		NodeSelector: map[string]string{},
+		Tolerations:  []map[string]any{},
+		Affinity:     map[string]any{},
+	}
+}
+
+const chartYAML = `apiVersion: v2
+name: kubectl-ai
+description: Serves the kubectl-ai web UI or MCP server in a Kubernetes cluster.
+type: application
+version: %s
+appVersion: %q
+home: https://github.com/GoogleCloudPlatform/kubectl-ai
+`
+
+const valuesHeader = `# Generated by dev/tasks/generate-helm-chart.sh from pkg/deploy, do not edit.
+#
+# The keys of providerSecret.data are set as environment variables, e.g.
+# %s.
+`
+
+// WriteChart writes the chart to dir: Chart.yaml, values.yaml with the
+// default values, and the templates.
+func WriteChart(dir string) error {
+	values, err := yaml.Marshal(DefaultValues())
+	if err != nil {
+		return fmt.Errorf("encoding values: %w", err)
+	}
+	files := map[string]string{
+		"Chart.yaml":  fmt.Sprintf(chartYAML, ChartVersion, DefaultValues().Image.Tag),
+		"values.yaml": fmt.Sprintf(valuesHeader, strings.Join(ProviderKeyEnvs, ", ")) + string(values),
+	}
+	err = fs.WalkDir(templates, "templates", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := templates.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[path] = string(b)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reading templates: %w", err)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestWriteChart(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteChart(dir); err != nil {
+		t.Fatalf("WriteChart() error: %v", err)
+	}
+
+	for _, name := range []string{"Chart.yaml", "templates/_helpers.tpl", "templates/deployment.yaml", "templates/networkpolicy.yaml", "templates/rbac.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("WriteChart() did not write %s: %v", name, err)
+		}
+	}
+
+	// values.yaml holds the default values.
+	b, err := os.ReadFile(filepath.Join(dir, "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values Values
+	if err := yaml.UnmarshalStrict(b, &values); err != nil {
+		t.Fatalf("parsing values.yaml: %v", err)
+	}
+	if want := DefaultValues(); !reflect.DeepEqual(values, want) {
+		t.Errorf("values.yaml = %+v, want %+v", values, want)
+	}
+}
@@ -0,0 +1,44 @@
+{{/* Generated by dev/tasks/generate-helm-chart.sh from pkg/deploy, do not edit. */}}
+
+{{- define "kubectl-ai.fullname" -}}
+{{- if contains .Chart.Name .Release.Name -}}
+{{- .Release.Name | trunc 63 | trimSuffix "-" -}}
+{{- else -}}
+{{- printf "%s-%s" .Release.Name .Chart.Name | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+{{- end -}}
+
+{{- define "kubectl-ai.labels" -}}
+app.kubernetes.io/name: {{ .Chart.Name }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+app.kubernetes.io/version: {{ .Chart.AppVersion | quote }}
+app.kubernetes.io/managed-by: {{ .Release.Service }}
+helm.sh/chart: {{ printf "%s-%s" .Chart.Name .Chart.Version }}
+{{- end -}}
+
+{{- define "kubectl-ai.selectorLabels" -}}
+app.kubernetes.io/name: {{ .Chart.Name }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+{{- end -}}
+
+{{- define "kubectl-ai.serviceAccountName" -}}
+{{- if .Values.serviceAccount.create -}}
+{{- default (include "kubectl-ai.fullname" .) .Values.serviceAccount.name -}}
+{{- else -}}
+{{- default "default" .Values.serviceAccount.name -}}
+{{- end -}}
+{{- end -}}
+
+{{- define "kubectl-ai.providerSecretName" -}}
+{{- default (printf "%s-provider" (include "kubectl-ai.fullname" .)) .Values.providerSecret.existingSecret -}}
+{{- end -}}
+
+{{- define "kubectl-ai.clusterRole" -}}
+{{- if .Values.rbac.clusterRole -}}
+{{- .Values.rbac.clusterRole -}}
+{{- else if .Values.readOnly -}}
+view
+{{- else -}}
+edit
+{{- end -}}
+{{- end -}}
@@ -0,0 +1,238 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package incidents imports the context of an incident from PagerDuty or
+// Opsgenie, to seed the session of the on-call engineer investigating it.
+package incidents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// maxNotes and maxAlerts bound the notes and the alerts imported with an incident.
+	maxNotes  = 20
+	maxAlerts = 10
+	// maxTextChars bounds the length of the notes and the descriptions imported.
+	maxTextChars = 2000
+)
+
+// Config configures the incident management systems incidents are imported from.
+type Config struct {
+	PagerDuty *PagerDutyConfig `json:"pagerduty,omitempty"`
+	Opsgenie  *OpsgenieConfig  `json:"opsgenie,omitempty"`
+}
+
+// PagerDutyConfig configures the PagerDuty REST API.
+type PagerDutyConfig struct {
+	// Token is a REST API token. TokenEnv can be used instead to read it
+	// from an environment variable.
+	Token    string `json:"token,omitempty"`
+	TokenEnv string `json:"tokenEnv,omitempty"`
+	// BaseURL defaults to https://api.pagerduty.com, e.g.
+	// https://api.eu.pagerduty.com for the EU service region.
+	BaseURL string `json:"baseURL,omitempty"`
+}
+
+// OpsgenieConfig configures the Opsgenie API.
+type OpsgenieConfig struct {
+	// APIKey is an API key of an API integration. APIKeyEnv can be used
+	// instead to read it from an environment variable.
+	APIKey    string `json:"apiKey,omitempty"`
+	APIKeyEnv string `json:"apiKeyEnv,omitempty"`
+	// BaseURL defaults to https://api.opsgenie.com, e.g.
+	// https://api.eu.opsgenie.com for the EU instance.
+	BaseURL string `json:"baseURL,omitempty"`
+}
+
+// Incident is the context of an incident.
+type Incident struct {
+	// Source is the system the incident was imported from, "PagerDuty" or "Opsgenie".
+	Source    string    `json:"source"`
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status,omitempty"`
+	Priority  string    `json:"priority,omitempty"`
+	Service   string    `json:"service,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	// Description is the description of the incident, if any.
+	Description string  `json:"description,omitempty"`
+	Notes       []Note  `json:"notes,omitempty"`
+	Alerts      []Alert `json:"alerts,omitempty"`
+}
+
+// Note is a note added to an incident by a responder.
+type Note struct {
+	Author    string    `json:"author,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	Content   string    `json:"content"`
+}
+
+// Alert is a recent alert of an incident.
+type Alert struct {
+	Summary   string    `json:"summary"`
+	Status    string    `json:"status,omitempty"`
+	Severity  string    `json:"severity,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	// Details are the details of the alert as sent by the monitoring system,
+	// e.g. the labels of a Prometheus alert.
+	Details string `json:"details,omitempty"`
+}
+
+// Fetch imports an incident. The ID may be prefixed with the system it is
+// imported from, e.g. "pagerduty:Q2W3E4R5T6Y7U8" or "opsgenie:1234", which
+// is required when both are configured.
+func (c Config) Fetch(ctx context.Context, id string) (*Incident, error) {
+	source, bare, ok := strings.Cut(id, ":")
+	if ok {
+		id = strings.TrimSpace(bare)
+	} else {
+		switch {
+		case c.PagerDuty != nil && c.Opsgenie != nil:
+			return nil, fmt.Errorf("both PagerDuty and Opsgenie are configured, prefix the incident ID with pagerduty: or opsgenie:")
+		case c.PagerDuty != nil:
+			source = "pagerduty"
+		case c.Opsgenie != nil:
+			source = "opsgenie"
+		default:
+			return nil, fmt.Errorf("no incident management system is configured, set incidents.pagerduty or incidents.opsgenie in the config file")
+		}
+	}
+	if id == "" {
+		return nil, fmt.Errorf("missing incident ID")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	switch strings.ToLower(source) {
+	case "pagerduty":
+		if c.PagerDuty == nil {
+			return nil, fmt.Errorf("PagerDuty is not configured, set incidents.pagerduty in the config file")
+		}
+		return c.PagerDuty.fetch(ctx, id)
+	case "opsgenie":
+		if c.Opsgenie == nil {
+			return nil, fmt.Errorf("Opsgenie is not configured, set incidents.opsgenie in the config file")
+		}
+		return c.Opsgenie.fetch(ctx, id)
+	}
+	return nil, fmt.Errorf("unknown incident management system %q, expected pagerduty or opsgenie", source)
+}
+
+// Markdown renders the incident for the system prompt of the session.
+func (i *Incident) Markdown() string {
+	if i == nil {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s incident %s: %s\n", i.Source, i.ID, i.Title)
+	for _, field := range [][2]string{
+		{"Status", i.Status},
+		{"Priority", i.Priority},
+		{"Service", i.Service},
+		{"Created", formatTime(i.CreatedAt)},
+		{"URL", i.URL},
+	} {
+		if field[1] != "" {
+			fmt.Fprintf(&b, "- %s: %s\n", field[0], field[1])
+		}
+	}
+	if i.Description != "" {
+		fmt.Fprintf(&b, "\nDescription:\n%s\n", i.Description)
+	}
+	if len(i.Alerts) > 0 {
+		b.WriteString("\nRecent alerts:\n")
+		for _, alert := range i.Alerts {
+			fmt.Fprintf(&b, "- %s", alert.Summary)
+			var facts []string
+			for _, fact := range []string{alert.Status, alert.Severity, formatTime(alert.CreatedAt)} {
+				if fact != "" {
+					facts = append(facts, fact)
+				}
+			}
+			if len(facts) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(facts, ", "))
+			}
+			b.WriteString("\n")
+			if alert.Details != "" {
+				fmt.Fprintf(&b, "  Details: %s\n", alert.Details)
+			}
+		}
+	}
+	if len(i.Notes) > 0 {
+		b.WriteString("\nNotes of the responders:\n")
+		for _, note := range i.Notes {
+			fmt.Fprintf(&b, "- %s", formatTime(note.CreatedAt))
+			if note.Author != "" {
+				fmt.Fprintf(&b, " %s", note.Author)
+			}
+			fmt.Fprintf(&b, ": %s\n", note.Content)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// truncate bounds the length of a text imported with an incident.
+func truncate(text string) string {
+	text = strings.TrimSpace(text)
+	if len(text) > maxTextChars {
+		return text[:maxTextChars] + "..."
+	}
+	return text
+}
+
+// secret returns a secret of the config, or of the environment variable it names.
+func secret(value, env string) string {
+	if env != "" {
+		return os.Getenv(env)
+	}
+	return value
+}
+
+// getJSON sends a GET request with an authorization header, and decodes the JSON response into v.
+func getJSON(ctx context.Context, url, authorization string, header http.Header, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Authorization", authorization)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %s: %s", req.URL.Path, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package incidents
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serve serves the JSON responses by request path, checking the authorization header.
+func serve(t *testing.T, authorization string, responses map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if got := req.Header.Get("Authorization"); got != authorization {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		response, ok := responses[req.URL.Path]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchPagerDuty(t *testing.T) {
+	server := serve(t, "Token token=secret", map[string]string{
+		"/incidents/Q1": `{"incident": {"id": "Q1", "title": "High error rate on checkout", "description": "High error rate on checkout",
+			"status": "triggered", "urgency": "high", "priority": {"summary": "P1"}, "service": {"summary": "checkout"},
+			"created_at": "2025-06-11T10:00:00Z", "html_url": "https://example.pagerduty.com/incidents/Q1"}}`,
+		"/incidents/Q1/notes": `{"notes": [{"content": "Started after the 10:00 deploy.", "created_at": "2025-06-11T10:05:00Z", "user": {"summary": "Alex"}}]}`,
+		"/incidents/Q1/alerts": `{"alerts": [{"summary": "5xx ratio above 5%", "status": "triggered", "severity": "critical",
+			"created_at": "2025-06-11T10:00:00Z", "body": {"details": {"namespace": "shop"}}}]}`,
+	})
+	t.Setenv("PAGERDUTY_TOKEN", "secret")
+	config := Config{PagerDuty: &PagerDutyConfig{TokenEnv: "PAGERDUTY_TOKEN", BaseURL: server.URL}}
+
+	incident, err := config.Fetch(context.Background(), "Q1")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	want := `PagerDuty incident Q1: High error rate on checkout
+- Status: triggered
+- Priority: P1, high urgency
+- Service: checkout
+- Created: 2025-06-11T10:00:00Z
+- URL: https://example.pagerduty.com/incidents/Q1
+
+Recent alerts:
+- 5xx ratio above 5% (triggered, critical, 2025-06-11T10:00:00Z)
+  Details: {"namespace": "shop"}
+
+Notes of the responders:
+- 2025-06-11T10:05:00Z Alex: Started after the 10:00 deploy.`
+	if got := incident.Markdown(); got != want {
+		t.Errorf("Markdown() = %q, want %q", got, want)
+	}
+
+	if _, err := config.Fetch(context.Background(), "Q2"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Fetch() of a missing incident error = %v, want the status", err)
+	}
+}
+
+func TestFetchOpsgenie(t *testing.T) {
+	server := serve(t, "GenieKey secret", map[string]string{
+		"/v2/alerts/42": `{"data": {"id": "a-42", "tinyId": "42", "message": "Pods of api crash looping", "status": "open",
+			"priority": "P2", "source": "Prometheus", "createdAt": "2025-06-11T10:00:00Z", "details": {"namespace": "api"}}}`,
+		"/v2/alerts/42/notes": `{"data": [{"note": "Rolled back the config map, no effect.", "owner": "sam@example.com", "createdAt": "2025-06-11T10:10:00Z"}]}`,
+		"/v2/alerts": `{"data": [{"id": "a-42", "message": "Pods of api crash looping"},
+			{"id": "a-43", "message": "api latency above SLO", "status": "open", "priority": "P3", "createdAt": "2025-06-11T10:02:00Z"}]}`,
+	})
+	config := Config{Opsgenie: &OpsgenieConfig{APIKey: "secret", BaseURL: server.URL}}
+
+	incident, err := config.Fetch(context.Background(), "opsgenie:42")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if incident.Title != "Pods of api crash looping" || incident.Priority != "P2" || incident.Service != "Prometheus" {
+		t.Errorf("Fetch() = %+v", incident)
+	}
+	if len(incident.Notes) != 1 || incident.Notes[0].Author != "sam@example.com" {
+		t.Errorf("Fetch() notes = %+v", incident.Notes)
+	}
+	if len(incident.Alerts) != 2 || incident.Alerts[0].Details != `{"namespace":"api"}` || incident.Alerts[1].Summary != "api latency above SLO" {
+		t.Errorf("Fetch() alerts = %+v, want the alert with its details and the other open alert", incident.Alerts)
+	}
+}
+
+func TestFetchSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		id      string
+		wantErr string
+	}{
+		{name: "not configured", id: "Q1", wantErr: "no incident management system is configured"},
+		{name: "ambiguous", config: Config{PagerDuty: &PagerDutyConfig{}, Opsgenie: &OpsgenieConfig{}}, id: "Q1", wantErr: "prefix the incident ID"},
+		{name: "prefix of another system", config: Config{PagerDuty: &PagerDutyConfig{Token: "secret"}}, id: "opsgenie:42", wantErr: "Opsgenie is not configured"},
+		{name: "unknown system", config: Config{PagerDuty: &PagerDutyConfig{Token: "secret"}}, id: "jira:42", wantErr: "unknown incident management system"},
+		{name: "missing token", config: Config{PagerDuty: &PagerDutyConfig{TokenEnv: "KUBECTL_AI_TEST_UNSET"}}, id: "Q1", wantErr: "no PagerDuty API token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.config.Fetch(context.Background(), tt.id)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
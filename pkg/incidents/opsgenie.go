@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package incidents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type opsgenieAlert struct {
+	ID          string            `json:"id"`
+	TinyID      string            `json:"tinyId"`
+	Message     string            `json:"message"`
+	Description string            `json:"description"`
+	Status      string            `json:"status"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	Details     map[string]string `json:"details"`
+}
+
+type opsgenieNote struct {
+	Note      string    `json:"note"`
+	Owner     string    `json:"owner"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// fetch imports an alert with the Opsgenie Alert API v2, along with the
+// other open alerts as the recent alerts. The ID is the tiny ID shown in
+// the Opsgenie UI, e.g. 1234, or the full alert ID.
+func (o *OpsgenieConfig) fetch(ctx context.Context, id string) (*Incident, error) {
+	apiKey := secret(o.APIKey, o.APIKeyEnv)
+	if apiKey == "" {
+		return nil, fmt.Errorf("no Opsgenie API key configured")
+	}
+	base := strings.TrimSuffix(o.BaseURL, "/")
+	if base == "" {
+		base = "https://api.opsgenie.com"
+	}
+	base += "/v2/alerts"
+	identifierType := "id"
+	if isTinyID(id) {
+		identifierType = "tiny"
+	}
+	alertURL := base + "/" + url.PathEscape(id)
+	query := "?identifierType=" + identifierType
+	authorization := "GenieKey " + apiKey
+
+	var alert struct {
+		Data opsgenieAlert `json:"data"`
+	}
+	if err := getJSON(ctx, alertURL+query, authorization, nil, &alert); err != nil {
+		return nil, fmt.Errorf("fetching Opsgenie alert %s: %w", id, err)
+	}
+	var notes struct {
+		Data []opsgenieNote `json:"data"`
+	}
+	if err := getJSON(ctx, fmt.Sprintf("%s/notes%s&limit=%d", alertURL, query, maxNotes), authorization, nil, &notes); err != nil {
+		return nil, fmt.Errorf("fetching the notes of Opsgenie alert %s: %w", id, err)
+	}
+	var alerts struct {
+		Data []opsgenieAlert `json:"data"`
+	}
+	openAlerts := fmt.Sprintf("%s?query=%s&limit=%d&sort=createdAt&order=desc", base, url.QueryEscape("status:open"), maxAlerts+1)
+	if err := getJSON(ctx, openAlerts, authorization, nil, &alerts); err != nil {
+		return nil, fmt.Errorf("fetching the open Opsgenie alerts: %w", err)
+	}
+
+	a := alert.Data
+	result := &Incident{
+		Source:      "Opsgenie",
+		ID:          a.TinyID,
+		Title:       a.Message,
+		Status:      a.Status,
+		Priority:    a.Priority,
+		Service:     a.Source,
+		CreatedAt:   a.CreatedAt,
+		Description: truncate(a.Description),
+	}
+	if result.ID == "" {
+		result.ID = id
+	}
+	for _, note := range notes.Data[:min(len(notes.Data), maxNotes)] {
+		result.Notes = append(result.Notes, Note{Author: note.Owner, CreatedAt: note.CreatedAt, Content: truncate(note.Note)})
+	}
+	if len(a.Details) > 0 {
+		details, _ := json.Marshal(a.Details)
+		result.Alerts = append(result.Alerts, Alert{Summary: a.Message, Status: a.Status, Severity: a.Priority, CreatedAt: a.CreatedAt, Details: truncate(string(details))})
+	}
+	for _, other := range alerts.Data {
+		if other.ID == a.ID || len(result.Alerts) == maxAlerts {
+			continue
+		}
+		result.Alerts = append(result.Alerts, Alert{Summary: other.Message, Status: other.Status, Severity: other.Priority, CreatedAt: other.CreatedAt})
+	}
+	return result, nil
+}
+
+// isTinyID reports whether an alert ID is a tiny ID, which is numeric.
+func isTinyID(id string) bool {
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return id != ""
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package incidents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// pagerDutyReference is a reference to another PagerDuty object, e.g. the service of an incident.
+type pagerDutyReference struct {
+	Summary string `json:"summary"`
+}
+
+type pagerDutyIncident struct {
+	ID             string              `json:"id"`
+	IncidentNumber int                 `json:"incident_number"`
+	Title          string              `json:"title"`
+	Description    string              `json:"description"`
+	Status         string              `json:"status"`
+	Urgency        string              `json:"urgency"`
+	Priority       *pagerDutyReference `json:"priority"`
+	Service        pagerDutyReference  `json:"service"`
+	CreatedAt      time.Time           `json:"created_at"`
+	HTMLURL        string              `json:"html_url"`
+}
+
+type pagerDutyNote struct {
+	Content   string             `json:"content"`
+	CreatedAt time.Time          `json:"created_at"`
+	User      pagerDutyReference `json:"user"`
+}
+
+type pagerDutyAlert struct {
+	Summary   string    `json:"summary"`
+	Status    string    `json:"status"`
+	Severity  string    `json:"severity"`
+	CreatedAt time.Time `json:"created_at"`
+	Body      struct {
+		Details json.RawMessage `json:"details"`
+	} `json:"body"`
+}
+
+// fetch imports an incident with the PagerDuty REST API v2.
+func (p *PagerDutyConfig) fetch(ctx context.Context, id string) (*Incident, error) {
+	token := secret(p.Token, p.TokenEnv)
+	if token == "" {
+		return nil, fmt.Errorf("no PagerDuty API token configured")
+	}
+	base := strings.TrimSuffix(p.BaseURL, "/")
+	if base == "" {
+		base = "https://api.pagerduty.com"
+	}
+	base += "/incidents/" + url.PathEscape(id)
+	authorization := "Token token=" + token
+	header := http.Header{"Accept": {"application/vnd.pagerduty+json;version=2"}}
+
+	var incident struct {
+		Incident pagerDutyIncident `json:"incident"`
+	}
+	if err := getJSON(ctx, base, authorization, header, &incident); err != nil {
+		return nil, fmt.Errorf("fetching PagerDuty incident %s: %w", id, err)
+	}
+	var notes struct {
+		Notes []pagerDutyNote `json:"notes"`
+	}
+	if err := getJSON(ctx, base+"/notes", authorization, header, &notes); err != nil {
+		return nil, fmt.Errorf("fetching the notes of PagerDuty incident %s: %w", id, err)
+	}
+	var alerts struct {
+		Alerts []pagerDutyAlert `json:"alerts"`
+	}
+	if err := getJSON(ctx, fmt.Sprintf("%s/alerts?limit=%d&sort_by=created_at:desc", base, maxAlerts), authorization, header, &alerts); err != nil {
+		return nil, fmt.Errorf("fetching the alerts of PagerDuty incident %s: %w", id, err)
+	}
+
+	i := incident.Incident
+	result := &Incident{
+		Source:    "PagerDuty",
+		ID:        i.ID,
+		Title:     i.Title,
+		Status:    i.Status,
+		Service:   i.Service.Summary,
+		CreatedAt: i.CreatedAt,
+		URL:       i.HTMLURL,
+	}
+	var priority []string
+	if i.Priority != nil && i.Priority.Summary != "" {
+		priority = append(priority, i.Priority.Summary)
+	}
+	if i.Urgency != "" {
+		priority = append(priority, i.Urgency+" urgency")
+	}
+	result.Priority = strings.Join(priority, ", ")
+	if i.Description != i.Title {
+		result.Description = truncate(i.Description)
+	}
+	for _, note := range notes.Notes[:min(len(notes.Notes), maxNotes)] {
+		result.Notes = append(result.Notes, Note{Author: note.User.Summary, CreatedAt: note.CreatedAt, Content: truncate(note.Content)})
+	}
+	for _, alert := range alerts.Alerts[:min(len(alerts.Alerts), maxAlerts)] {
+		a := Alert{Summary: alert.Summary, Status: alert.Status, Severity: alert.Severity, CreatedAt: alert.CreatedAt}
+		if details := string(alert.Body.Details); details != "" && details != "null" {
+			a.Details = truncate(details)
+		}
+		result.Alerts = append(result.Alerts, a)
+	}
+	return result, nil
+}
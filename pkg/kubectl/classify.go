@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubectl
+
+import "strings"
+
+var (
+	readOnlyOps = map[string]bool{
+		"get": true, "describe": true, "explain": true, "top": true,
+		"logs": true, "api-resources": true, "api-versions": true,
+		"version": true, "config": true, "cluster-info": true,
+		"wait": true, "auth": true, "diff": true, "kustomize": true,
+		"help": true, "options": true, "proxy": true,
+		"completion": true, "convert": true, "events": true,
+		"port-forward": true, "can-i": true, "whoami": true,
+	}
+
+	writeOps = map[string]bool{
+		"create": true, "apply": true, "edit": true, "delete": true,
+		"patch": true, "replace": true, "scale": true, "autoscale": true,
+		"expose": true, "run": true, "exec": true, "set": true,
+		"label": true, "annotate": true, "taint": true, "drain": true,
+		"cordon": true, "uncordon": true, "debug": true, "attach": true,
+		"cp": true, "reconcile": true, "approve": true, "deny": true,
+		"certificate": true,
+	}
+
+	readOnlySubOps = map[string]map[string]bool{
+		"rollout": {
+			"history": true,
+			"status":  true,
+		},
+	}
+
+	writeSubOps = map[string]map[string]bool{
+		"rollout": {
+			"pause":   true,
+			"restart": true,
+			"resume":  true,
+			"undo":    true,
+		},
+	}
+)
+
+// ModifiesResource returns "yes" if the command may modify resources, "no" if
+// it is read-only, and "unknown" if it cannot be classified.
+func (c *Command) ModifiesResource() string {
+	if c.Verb == "" {
+		return "unknown"
+	}
+	// A flag before the verb whose value is not attached could be a boolean
+	// flag taken for a flag with a value, hiding the real verb, e.g. "kubectl --force delete".
+	for _, arg := range c.Args {
+		if !strings.HasPrefix(arg, "-") {
+			break
+		}
+		if !strings.Contains(arg, "=") {
+			return "unknown"
+		}
+	}
+
+	write := writeOps[c.Verb] || writeSubOps[c.Verb][c.Subcommand]
+	read := readOnlyOps[c.Verb] || readOnlySubOps[c.Verb][c.Subcommand]
+	switch {
+	case write && !c.DryRun():
+		return "yes"
+	case read || write:
+		return "no"
+	}
+	return "unknown"
+}
+
+// Interactive returns true if the command needs a terminal or runs until it
+// is interrupted, e.g. "kubectl edit" or "kubectl exec -it".
+func (c *Command) Interactive() bool {
+	switch c.Verb {
+	case "edit", "port-forward":
+		return true
+	case "exec", "attach", "run", "debug":
+		return c.HasFlag("-t", "--tty")
+	}
+	return false
+}
+
+// Streaming returns the kind of output the command streams until it is
+// interrupted, "watch", "logs" or "attach", or "" if it does not stream.
+func (c *Command) Streaming() string {
+	switch {
+	case (c.Verb == "get" || c.Verb == "events") && c.HasFlag("-w", "--watch", "--watch-only"):
+		return "watch"
+	case c.Verb == "logs" && c.HasFlag("-f", "--follow"):
+		return "logs"
+	case c.Verb == "attach":
+		return "attach"
+	}
+	return ""
+}
@@ -0,0 +1,449 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kubectl parses kubectl command lines into their verb, resources,
+// namespace and flags, to classify, check and audit the commands run by the
+// tools without matching strings.
+package kubectl
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Command is a kubectl invocation.
+type Command struct {
+	// Binary is the kubectl binary, e.g. "/usr/local/bin/kubectl".
+	Binary string `json:"binary,omitempty"`
+	// Args are the arguments after the binary.
+	Args []string `json:"args"`
+
+	Verb string `json:"verb,omitempty"`
+	// Subcommand is the action of the verbs taking one, e.g. "restart" for "rollout restart".
+	Subcommand string `json:"subcommand,omitempty"`
+	// Resources are the plural names of the resource types the command acts
+	// on, e.g. "deployments" for "deploy/web", or "pods" for "logs web".
+	Resources []string `json:"resources,omitempty"`
+	// Names are the names of the objects, e.g. "web" for "deploy/web".
+	Names []string `json:"names,omitempty"`
+
+	// Context is the value of the context flag, empty for the current context of the kubeconfig.
+	Context string `json:"context,omitempty"`
+	// Namespace is the value of the namespace flag, empty for the namespace of the kubeconfig context.
+	Namespace     string `json:"namespace,omitempty"`
+	AllNamespaces bool   `json:"allNamespaces,omitempty"`
+	// Flags are the values of the flags by name as written, e.g. "-o" or
+	// "--output". Boolean flags have an empty value.
+	Flags map[string][]string `json:"flags,omitempty"`
+	// OutputFormat is the output format without its template, e.g. "jsonpath"
+	// for "-o jsonpath={.items}".
+	OutputFormat string `json:"outputFormat,omitempty"`
+	// Trailing are the arguments after "--", e.g. the command of "kubectl exec".
+	Trailing []string `json:"trailing,omitempty"`
+}
+
+// valueFlags are the kubectl flags taking a value, which can be a separate argument.
+var valueFlags = map[string]bool{
+	"-n": true, "--namespace": true,
+	"--context": true, "--cluster": true, "--user": true, "--kubeconfig": true,
+	"--as": true, "--as-group": true, "--token": true, "-s": true, "--server": true,
+	"--request-timeout": true,
+	"-l":                true, "--selector": true, "--field-selector": true,
+	"-o": true, "--output": true, "--template": true, "--sort-by": true,
+	"-L": true, "--label-columns": true, "--chunk-size": true,
+	"-f": true, "--filename": true, "-k": true, "--kustomize": true,
+	"-c": true, "--container": true,
+	"-p": true, "--patch": true, "--type": true,
+	"--image": true, "--replicas": true, "--port": true, "--target-port": true,
+	"--protocol": true, "--name": true, "--env": true, "--labels": true, "--overrides": true,
+	"--timeout": true, "--for": true, "--grace-period": true,
+	"--tail": true, "--since": true, "--since-time": true,
+	"--to-revision": true, "--revision": true, "--field-manager": true,
+	"--min": true, "--max": true, "--cpu-percent": true,
+}
+
+// isValueFlag returns true if the flag of the verb takes a value.
+func isValueFlag(verb, name string) bool {
+	if verb == "logs" && name == "-f" {
+		// "kubectl logs -f" follows the logs.
+		return false
+	}
+	return valueFlags[name]
+}
+
+// subcommandVerbs are the verbs followed by an action, e.g. "rollout restart".
+var subcommandVerbs = map[string]bool{
+	"rollout":     true,
+	"set":         true,
+	"auth":        true,
+	"certificate": true,
+	"config":      true,
+	"alpha":       true,
+	"plugin":      true,
+}
+
+// verbResources are the resources of the verbs that do not name a resource type.
+var verbResources = map[string]string{
+	"logs":         "pods",
+	"exec":         "pods",
+	"attach":       "pods",
+	"port-forward": "pods",
+	"cp":           "pods",
+	"run":          "pods",
+	"debug":        "pods",
+	"drain":        "nodes",
+	"cordon":       "nodes",
+	"uncordon":     "nodes",
+	"certificate":  "certificatesigningrequests",
+}
+
+// noResourceVerbs are the verbs whose arguments are not resources.
+var noResourceVerbs = map[string]bool{
+	"version": true, "api-resources": true, "api-versions": true,
+	"cluster-info": true, "completion": true, "proxy": true, "options": true,
+	"help": true, "kustomize": true, "plugin": true, "config": true, "auth": true,
+	"apply": true, "replace": true, "diff": true, "convert": true,
+}
+
+// resourceAliases maps the short and singular resource names to their plural name.
+var resourceAliases = map[string]string{
+	"po": "pods", "pod": "pods",
+	"svc": "services", "service": "services",
+	"deploy": "deployments", "deployment": "deployments",
+	"rs": "replicasets", "replicaset": "replicasets",
+	"sts": "statefulsets", "statefulset": "statefulsets",
+	"ds": "daemonsets", "daemonset": "daemonsets",
+	"job": "jobs", "cj": "cronjobs", "cronjob": "cronjobs",
+	"cm": "configmaps", "configmap": "configmaps",
+	"secret": "secrets",
+	"ns":     "namespaces", "namespace": "namespaces",
+	"no": "nodes", "node": "nodes",
+	"pv": "persistentvolumes", "persistentvolume": "persistentvolumes",
+	"pvc": "persistentvolumeclaims", "persistentvolumeclaim": "persistentvolumeclaims",
+	"sa": "serviceaccounts", "serviceaccount": "serviceaccounts",
+	"ing": "ingresses", "ingress": "ingresses",
+	"netpol": "networkpolicies", "networkpolicy": "networkpolicies",
+	"hpa": "horizontalpodautoscalers", "horizontalpodautoscaler": "horizontalpodautoscalers",
+	"pdb": "poddisruptionbudgets", "poddisruptionbudget": "poddisruptionbudgets",
+	"ep": "endpoints", "ev": "events", "event": "events",
+	"crd": "customresourcedefinitions", "crds": "customresourcedefinitions", "customresourcedefinition": "customresourcedefinitions",
+	"role": "roles", "rolebinding": "rolebindings",
+	"clusterrole": "clusterroles", "clusterrolebinding": "clusterrolebindings",
+	"sc": "storageclasses", "storageclass": "storageclasses",
+	"csr": "certificatesigningrequests", "certificatesigningrequest": "certificatesigningrequests",
+}
+
+// NormalizeResource returns the plural name of a resource type, without its
+// API group, e.g. "deployments" for "deploy" or "deployments.apps".
+func NormalizeResource(resource string) string {
+	resource = strings.ToLower(resource)
+	resource, _, _ = strings.Cut(resource, ".")
+	if plural, ok := resourceAliases[resource]; ok {
+		return plural
+	}
+	return resource
+}
+
+// IsKubectl returns true if the binary is kubectl, e.g. "/usr/bin/kubectl",
+// "kubectl.exe" or a versioned "kubectl-1.28".
+func IsKubectl(binary string) bool {
+	base := filepath.Base(binary)
+	switch {
+	case base == "kubectl" || base == "kubectl.exe":
+		return true
+	case strings.HasPrefix(base, "kubectl."):
+		return true
+	case strings.HasPrefix(base, "kubectl-") && len(base) > len("kubectl-"):
+		return unicode.IsDigit(rune(base[len("kubectl-")]))
+	}
+	return false
+}
+
+// Parse returns the kubectl invocations of a shell command, e.g. two for
+// "kubectl get pods && kubectl delete pod web".
+func Parse(command string) ([]*Command, error) {
+	calls, err := Calls(command)
+	if err != nil {
+		return nil, err
+	}
+
+	var commands []*Command
+	for _, args := range calls {
+		if !IsKubectl(args[0]) {
+			continue
+		}
+		cmd := ParseArgs(args[1:])
+		cmd.Binary = args[0]
+		commands = append(commands, cmd)
+	}
+	return commands, nil
+}
+
+// Calls returns the arguments of the programs run by a shell command, after
+// their wrapper programs, e.g. ["kubectl", "delete"] and ["curl", "example.com"]
+// for "xargs -n1 kubectl delete; timeout 5 curl example.com".
+func Calls(command string) ([][]string, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil, fmt.Errorf("parsing command: %w", err)
+	}
+
+	var calls [][]string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		var args []string
+		for _, word := range call.Args {
+			args = append(args, wordString(word))
+		}
+		if args = Unwrap(args); len(args) > 0 {
+			calls = append(calls, args)
+		}
+		return true
+	})
+	return calls, nil
+}
+
+// wrapperPrograms run the command of their arguments, e.g. "xargs kubectl delete",
+// mapped to their options taking a value as a separate argument, e.g. "-u" for
+// "sudo -u alice kubectl ...".
+var wrapperPrograms = map[string]map[string]bool{
+	"env": {"-u": true, "--unset": true, "-C": true, "--chdir": true},
+	"sudo": {
+		"-u": true, "--user": true, "-g": true, "--group": true, "-h": true, "--host": true,
+		"-p": true, "--prompt": true, "-C": true, "--close-from": true, "-D": true, "--chdir": true,
+		"-r": true, "--role": true, "-t": true, "--type": true, "-U": true, "--other-user": true,
+		"-T": true, "--command-timeout": true,
+	},
+	"timeout": {"-k": true, "--kill-after": true, "-s": true, "--signal": true},
+	"xargs": {
+		"-n": true, "--max-args": true, "-L": true, "--max-lines": true, "-I": true,
+		"-d": true, "--delimiter": true, "-E": true, "-P": true, "--max-procs": true,
+		"-s": true, "--max-chars": true, "-a": true, "--arg-file": true,
+	},
+	"nohup":   {},
+	"nice":    {"-n": true, "--adjustment": true},
+	"time":    {"-f": true, "--format": true, "-o": true, "--output": true},
+	"watch":   {"-n": true, "--interval": true},
+	"exec":    {"-a": true},
+	"command": {},
+}
+
+// durationArg matches the numeric arguments of the wrapper programs, e.g. "30s" for "timeout 30s kubectl ...".
+var durationArg = regexp.MustCompile(`^\d+(\.\d+)?[smhd]?$`)
+
+// Unwrap returns the arguments of the command run by the wrapper programs of
+// a call, e.g. ["kubectl", "delete"] for "xargs -n1 kubectl delete".
+func Unwrap(args []string) []string {
+	for {
+		wrapped, ok := UnwrapOne(args)
+		if !ok {
+			return args
+		}
+		args = wrapped
+	}
+}
+
+// UnwrapOne returns the arguments of the command run by a wrapper program, e.g.
+// ["timeout", "5", "kubectl", "get"] for "sudo -u alice timeout 5 kubectl get",
+// and false if the program is not a wrapper. The options, variable assignments
+// and numeric arguments of the wrapper are skipped.
+func UnwrapOne(args []string) ([]string, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	valueFlags, ok := wrapperPrograms[filepath.Base(args[0])]
+	if !ok {
+		return nil, false
+	}
+	args = args[1:]
+	for len(args) > 0 {
+		arg := args[0]
+		if valueFlags[arg] && len(args) > 1 {
+			args = args[2:]
+			continue
+		}
+		if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") && !durationArg.MatchString(arg) {
+			break
+		}
+		args = args[1:]
+	}
+	return args, true
+}
+
+// wordString returns a shell word as written, without its surrounding quotes.
+func wordString(word *syntax.Word) string {
+	if lit := word.Lit(); lit != "" {
+		return lit
+	}
+	// e.g. 'python3 -c "print(1)"', whose value ends with a quote.
+	if len(word.Parts) == 1 {
+		if quoted, ok := word.Parts[0].(*syntax.SglQuoted); ok {
+			return quoted.Value
+		}
+	}
+	var sb strings.Builder
+	syntax.NewPrinter().Print(&sb, word)
+	return strings.Trim(sb.String(), "'\"")
+}
+
+// ParseArgs parses the arguments of kubectl, after the binary.
+func ParseArgs(args []string) *Command {
+	cmd := &Command{Args: args, Flags: make(map[string][]string)}
+
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			cmd.Trailing = args[i+1:]
+			i = len(args)
+			continue
+		case arg == "-" || !strings.HasPrefix(arg, "-"):
+			positional = append(positional, arg)
+			continue
+		}
+
+		// The verb is the first positional argument.
+		verb := ""
+		if len(positional) > 0 {
+			verb = positional[0]
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue && !strings.HasPrefix(name, "--") && len(name) > 2 {
+			// Short flags are combined, e.g. "-it", or have their value attached, e.g. "-nprod".
+			if isValueFlag(verb, name[:2]) {
+				name, value, hasValue = name[:2], name[2:], true
+			} else {
+				for _, c := range name[1 : len(name)-1] {
+					cmd.addFlag("-"+string(c), "")
+				}
+				name = "-" + name[len(name)-1:]
+			}
+		}
+		if !hasValue && isValueFlag(verb, name) && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		cmd.addFlag(name, value)
+	}
+
+	if len(positional) == 0 {
+		return cmd
+	}
+	cmd.Verb = positional[0]
+	positional = positional[1:]
+	if subcommandVerbs[cmd.Verb] && len(positional) > 0 {
+		cmd.Subcommand = positional[0]
+		positional = positional[1:]
+	}
+	cmd.parseResources(positional)
+	return cmd
+}
+
+func (c *Command) addFlag(name, value string) {
+	c.Flags[name] = append(c.Flags[name], value)
+	switch name {
+	case "-n", "--namespace":
+		c.Namespace = value
+	case "--context":
+		c.Context = value
+	case "-A", "--all-namespaces":
+		c.AllNamespaces = value == "" || value == "true"
+	case "-o", "--output":
+		format, _, _ := strings.Cut(value, "=")
+		c.OutputFormat = format
+	}
+}
+
+// parseResources sets the resources and the names of the objects from the
+// arguments after the verb, e.g. "pods web", "deploy/web svc/web" or "pods,services".
+func (c *Command) parseResources(args []string) {
+	if noResourceVerbs[c.Verb] {
+		return
+	}
+	if resource, ok := verbResources[c.Verb]; ok {
+		c.Resources = []string{resource}
+		for _, arg := range args {
+			if kind, name, ok := strings.Cut(arg, "/"); ok && !strings.Contains(kind, ":") && c.Verb != "cp" {
+				c.Resources = []string{NormalizeResource(kind)}
+				arg = name
+			}
+			c.Names = append(c.Names, arg)
+		}
+		return
+	}
+	if len(args) == 0 {
+		return
+	}
+
+	if !strings.Contains(args[0], "/") {
+		for _, kind := range strings.Split(args[0], ",") {
+			c.addResource(kind)
+		}
+		args = args[1:]
+	}
+	for _, arg := range args {
+		if strings.Contains(arg, "=") || strings.HasSuffix(arg, "-") {
+			// Labels, annotations and environment variables, e.g. "app=web" or "app-".
+			continue
+		}
+		if kind, name, ok := strings.Cut(arg, "/"); ok {
+			c.addResource(kind)
+			arg = name
+		}
+		c.Names = append(c.Names, arg)
+	}
+}
+
+func (c *Command) addResource(kind string) {
+	if resource := NormalizeResource(kind); resource != "" && !slices.Contains(c.Resources, resource) {
+		c.Resources = append(c.Resources, resource)
+	}
+}
+
+// Flag returns the last value of the first flag set of the given names, e.g.
+// Flag("-o", "--output").
+func (c *Command) Flag(names ...string) (string, bool) {
+	for _, name := range names {
+		if values, ok := c.Flags[name]; ok {
+			return values[len(values)-1], true
+		}
+	}
+	return "", false
+}
+
+// HasFlag returns true if one of the flags is set.
+func (c *Command) HasFlag(names ...string) bool {
+	_, ok := c.Flag(names...)
+	return ok
+}
+
+// DryRun returns true if the command only simulates its changes.
+func (c *Command) DryRun() bool {
+	value, ok := c.Flag("--dry-run")
+	return ok && value != "none" && value != "false"
+}
+
+func (c *Command) String() string {
+	return strings.Join(append([]string{"kubectl"}, c.Args...), " ")
+}
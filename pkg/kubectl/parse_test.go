@@ -0,0 +1,172 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubectl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []Command
+	}{
+		{
+			command: "kubectl get po -n prod -o jsonpath='{.items[*].metadata.name}'",
+			want: []Command{{
+				Verb:         "get",
+				Resources:    []string{"pods"},
+				Namespace:    "prod",
+				OutputFormat: "jsonpath",
+			}},
+		},
+		{
+			command: "kubectl -nprod rollout restart deploy/web deploy/api",
+			want: []Command{{
+				Verb:       "rollout",
+				Subcommand: "restart",
+				Resources:  []string{"deployments"},
+				Names:      []string{"web", "api"},
+				Namespace:  "prod",
+			}},
+		},
+		{
+			command: "kubectl delete pods,svc -l app=web --all-namespaces",
+			want: []Command{{
+				Verb:          "delete",
+				Resources:     []string{"pods", "services"},
+				AllNamespaces: true,
+			}},
+		},
+		{
+			command: "kubectl --context=staging get nodes && kubectl get nodes --context prod",
+			want: []Command{
+				{Verb: "get", Resources: []string{"nodes"}, Context: "staging"},
+				{Verb: "get", Resources: []string{"nodes"}, Context: "prod"},
+			},
+		},
+		{
+			command: "kubectl logs -f deployment/web -c app --tail 20",
+			want: []Command{{
+				Verb:      "logs",
+				Resources: []string{"deployments"},
+				Names:     []string{"web"},
+			}},
+		},
+		{
+			command: "kubectl label pod web app=web tier-",
+			want: []Command{{
+				Verb:      "label",
+				Resources: []string{"pods"},
+				Names:     []string{"web"},
+			}},
+		},
+		{
+			command: "kubectl get ns | grep prod && /usr/local/bin/kubectl exec -it web -- sh -c 'ls /'",
+			want: []Command{
+				{Verb: "get", Resources: []string{"namespaces"}},
+				{Verb: "exec", Resources: []string{"pods"}, Names: []string{"web"}},
+			},
+		},
+		{
+			command: "kubectl apply -f app.yaml --dry-run=server",
+			want:    []Command{{Verb: "apply"}},
+		},
+		{
+			command: "kubectl get pods -o name | xargs -n1 kubectl delete && timeout 30s kubectl -n prod get pods",
+			want: []Command{
+				{Verb: "get", Resources: []string{"pods"}, OutputFormat: "name"},
+				{Verb: "delete"},
+				{Verb: "get", Resources: []string{"pods"}, Namespace: "prod"},
+			},
+		},
+		{
+			command: "sudo -u alice kubectl delete pod web && timeout -s KILL 30 kubectl get nodes",
+			want: []Command{
+				{Verb: "delete", Resources: []string{"pods"}, Names: []string{"web"}},
+				{Verb: "get", Resources: []string{"nodes"}},
+			},
+		},
+		{
+			command: "kubectx prod",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			commands, err := Parse(tt.command)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			var got []Command
+			for _, cmd := range commands {
+				got = append(got, Command{
+					Verb:          cmd.Verb,
+					Subcommand:    cmd.Subcommand,
+					Resources:     cmd.Resources,
+					Names:         cmd.Names,
+					Context:       cmd.Context,
+					Namespace:     cmd.Namespace,
+					AllNamespaces: cmd.AllNamespaces,
+					OutputFormat:  cmd.OutputFormat,
+				})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		command          string
+		modifiesResource string
+		interactive      bool
+		streaming        string
+	}{
+		{"kubectl get pods -w", "no", false, "watch"},
+		{"kubectl get pods --watch", "no", false, "watch"},
+		{"kubectl logs web --follow", "no", false, "logs"},
+		{"kubectl logs -f web", "no", false, "logs"},
+		{"kubectl attach web -i", "yes", false, "attach"},
+		{"kubectl exec -it web -- sh", "yes", true, ""},
+		{"kubectl exec web -- kubectl edit deploy web", "yes", false, ""},
+		{"kubectl edit deploy web", "yes", true, ""},
+		{"kubectl port-forward svc/web 8080:80", "no", true, ""},
+		{"kubectl delete pod web --dry-run=client", "no", false, ""},
+		{"kubectl delete pod web --dry-run=none", "yes", false, ""},
+		{"kubectl --context prod delete pod web", "unknown", false, ""},
+		{"kubectl alpha debug web", "unknown", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			commands, err := Parse(tt.command)
+			if err != nil || len(commands) != 1 {
+				t.Fatalf("Parse() = %v, %v, want one command", commands, err)
+			}
+			cmd := commands[0]
+			if got := cmd.ModifiesResource(); got != tt.modifiesResource {
+				t.Errorf("ModifiesResource() = %q, want %q", got, tt.modifiesResource)
+			}
+			if got := cmd.Interactive(); got != tt.interactive {
+				t.Errorf("Interactive() = %v, want %v", got, tt.interactive)
+			}
+			if got := cmd.Streaming(); got != tt.streaming {
+				t.Errorf("Streaming() = %q, want %q", got, tt.streaming)
+			}
+		})
+	}
+}
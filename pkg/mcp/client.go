@@ -196,6 +196,8 @@ func (t Tool) IsFromServer(server string) bool {
 }
 
 // convertMCPToolsToTools converts MCP library tools to our Tool type.
+// Tools whose input schema cannot be translated are skipped, so that one
+// tool does not hide the other tools of its server.
 func convertMCPToolsToTools(mcpTools []mcp.Tool) ([]Tool, error) {
 	tools := make([]Tool, 0, len(mcpTools))
 	for _, mcpTool := range mcpTools {
@@ -208,13 +210,13 @@ func convertMCPToolsToTools(mcpTools []mcp.Tool) ([]Tool, error) {
 		if mcpTool.InputSchema.Type != "" {
 			schema, err := convertMCPInputSchema(&mcpTool.InputSchema)
 			if err != nil {
-				return nil, fmt.Errorf("converting MCP input schema to tool input schema: %w", err)
+				klog.Warningf("Skipping MCP tool %s: converting its input schema: %v", mcpTool.Name, err)
+				continue
 			}
 			tool.InputSchema = schema
 		} else {
-			// TODO: Use RawInputSchema if available
-			// klog.Warningf("no input schema for tool %s", mcpTool.Name)
-			return nil, fmt.Errorf("no input schema for tool %s", mcpTool.Name)
+			// Tools without an input schema take no arguments.
+			tool.InputSchema = &gollm.Schema{Type: gollm.TypeObject}
 		}
 
 		tools = append(tools, tool)
@@ -260,6 +262,22 @@ func convertMCPInputSchema(mcpInputSchema *mcp.ToolInputSchema) (*gollm.Schema,
 	return gollmSchema, nil
 }
 
+// schemaType returns the type of a JSON schema, the first type other than
+// "null" of a list of types, e.g. "string" for ["string", "null"].
+func schemaType(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case []interface{}:
+		for _, t := range value {
+			if t, ok := t.(string); ok && t != "null" {
+				return t, true
+			}
+		}
+	}
+	return "", false
+}
+
 func convertMCPMapSchema(key string, schemaMap map[string]interface{}) (*gollm.Schema, error) {
 	if schemaMap == nil {
 		return nil, fmt.Errorf("schema map is nil for key %q", key)
@@ -275,7 +293,7 @@ func convertMCPMapSchema(key string, schemaMap map[string]interface{}) (*gollm.S
 		gollmSchema.Description = description
 	}
 
-	mcpType, ok := schemaMap["type"].(string)
+	mcpType, ok := schemaType(schemaMap["type"])
 	if !ok {
 		// Fallback: treat any unrecognized schema as generic object
 		klog.V(2).InfoS("Unrecognized schema format, treating as object", "key", key)
@@ -288,19 +306,22 @@ func convertMCPMapSchema(key string, schemaMap map[string]interface{}) (*gollm.S
 	case "number":
 		gollmSchema.Type = gollm.TypeNumber
 	case "integer":
-		gollmSchema.Type = gollm.TypeNumber
+		gollmSchema.Type = gollm.TypeInteger
 	case "boolean":
 		gollmSchema.Type = gollm.TypeBoolean
 	case "array":
+		gollmSchema.Type = gollm.TypeArray
 		items, ok := schemaMap["items"].(map[string]interface{})
 		if !ok {
-			return nil, fmt.Errorf("did not find items for array: key %q: %+v", key, schemaMap)
+			// Arrays of any type are passed as arrays of strings, the providers need the type of the items.
+			klog.V(2).InfoS("Array schema without items, treating them as strings", "key", key)
+			gollmSchema.Items = &gollm.Schema{Type: gollm.TypeString}
+			break
 		}
 		itemsSchema, err := convertMCPMapSchema(key+".items", items)
 		if err != nil {
 			return nil, fmt.Errorf("converting MCP input schema to tool input schema: %w", err)
 		}
-		gollmSchema.Type = gollm.TypeArray
 		gollmSchema.Items = itemsSchema
 
 	case "object":
@@ -323,6 +344,13 @@ func convertMCPMapSchema(key string, schemaMap map[string]interface{}) (*gollm.S
 				gollmSchema.Properties[key] = propertySchema
 			}
 		}
+		if required, ok := schemaMap["required"].([]interface{}); ok {
+			for _, name := range required {
+				if name, ok := name.(string); ok {
+					gollmSchema.Required = append(gollmSchema.Required, name)
+				}
+			}
+		}
 	default:
 		return nil, fmt.Errorf("unexpected input schema type %q for key %q: %+v", mcpType, key, schemaMap)
 	}
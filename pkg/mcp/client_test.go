@@ -0,0 +1,118 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestConvertMCPToolsToTools(t *testing.T) {
+	tests := []struct {
+		name    string
+		tool    mcp.Tool
+		want    *gollm.Schema
+		skipped bool
+	}{
+		{
+			name: "scalar properties",
+			tool: mcp.Tool{Name: "scale", InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]any{
+					"name":     map[string]any{"type": "string", "description": "deployment name"},
+					"replicas": map[string]any{"type": "integer"},
+					"ratio":    map[string]any{"type": "number"},
+					"dryRun":   map[string]any{"type": []any{"boolean", "null"}},
+				},
+				Required: []string{"name"},
+			}},
+			want: &gollm.Schema{
+				Type: gollm.TypeObject,
+				Properties: map[string]*gollm.Schema{
+					"name":     {Type: gollm.TypeString, Description: "deployment name"},
+					"replicas": {Type: gollm.TypeInteger},
+					"ratio":    {Type: gollm.TypeNumber},
+					"dryRun":   {Type: gollm.TypeBoolean},
+				},
+				Required: []string{"name"},
+			},
+		},
+		{
+			name: "nested objects and arrays",
+			tool: mcp.Tool{Name: "label", InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]any{
+					"target": map[string]any{
+						"type":       "object",
+						"properties": map[string]any{"kind": map[string]any{"type": "string"}},
+						"required":   []any{"kind"},
+					},
+					"labels": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+					"extra":  map[string]any{"type": "array"},
+				},
+			}},
+			want: &gollm.Schema{
+				Type: gollm.TypeObject,
+				Properties: map[string]*gollm.Schema{
+					"target": {
+						Type:       gollm.TypeObject,
+						Properties: map[string]*gollm.Schema{"kind": {Type: gollm.TypeString}},
+						Required:   []string{"kind"},
+					},
+					"labels": {Type: gollm.TypeArray, Items: &gollm.Schema{Type: gollm.TypeString}},
+					"extra":  {Type: gollm.TypeArray, Items: &gollm.Schema{Type: gollm.TypeString}},
+				},
+			},
+		},
+		{
+			name: "no input schema",
+			tool: mcp.Tool{Name: "ping"},
+			want: &gollm.Schema{Type: gollm.TypeObject},
+		},
+		{
+			name: "unsupported property type",
+			tool: mcp.Tool{Name: "broken", InputSchema: mcp.ToolInputSchema{
+				Type:       "object",
+				Properties: map[string]any{"x": map[string]any{"type": "null"}},
+			}},
+			skipped: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The tool is converted next to a valid one, which must be kept.
+			tools, err := convertMCPToolsToTools([]mcp.Tool{tt.tool, {Name: "other"}})
+			if err != nil {
+				t.Fatalf("convertMCPToolsToTools() error = %v", err)
+			}
+			if tt.skipped {
+				if len(tools) != 1 || tools[0].Name != "other" {
+					t.Fatalf("expected only the other tool, got %+v", tools)
+				}
+				return
+			}
+			if len(tools) != 2 {
+				t.Fatalf("expected 2 tools, got %d", len(tools))
+			}
+			if !reflect.DeepEqual(tools[0].InputSchema, tt.want) {
+				t.Errorf("schema = %+v, want %+v", tools[0].InputSchema, tt.want)
+			}
+		})
+	}
+}
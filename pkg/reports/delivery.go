@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Delivery configures where a report is delivered.
+type Delivery struct {
+	Slack *SlackDelivery `json:"slack,omitempty"`
+	Email *EmailDelivery `json:"email,omitempty"`
+}
+
+// SlackDelivery posts reports to a Slack incoming webhook.
+type SlackDelivery struct {
+	// WebhookURL is the incoming webhook URL. WebhookURLEnv can be used
+	// instead to read it from an environment variable.
+	WebhookURL    string `json:"webhookURL,omitempty"`
+	WebhookURLEnv string `json:"webhookURLEnv,omitempty"`
+}
+
+// EmailDelivery sends reports by email through an SMTP server.
+type EmailDelivery struct {
+	// SMTPServer is the "host:port" of the SMTP server.
+	SMTPServer string `json:"smtpServer"`
+	Username   string `json:"username,omitempty"`
+	// PasswordEnv is the environment variable holding the SMTP password.
+	PasswordEnv string   `json:"passwordEnv,omitempty"`
+	From        string   `json:"from"`
+	To          []string `json:"to"`
+}
+
+// Deliver sends the report to every configured destination.
+// Slack always receives markdown; email receives the report in the given format.
+func (d Delivery) Deliver(ctx context.Context, report *Report, format Format) error {
+	var errs []string
+	if d.Slack != nil {
+		if err := d.Slack.deliver(ctx, report); err != nil {
+			errs = append(errs, fmt.Sprintf("slack: %v", err))
+		}
+	}
+	if d.Email != nil {
+		if err := d.Email.deliver(report, format); err != nil {
+			errs = append(errs, fmt.Sprintf("email: %v", err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("delivering report %q: %s", report.Title, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (s *SlackDelivery) deliver(ctx context.Context, report *Report) error {
+	url := s.WebhookURL
+	if s.WebhookURLEnv != "" {
+		url = os.Getenv(s.WebhookURLEnv)
+	}
+	if url == "" {
+		return fmt.Errorf("no webhook URL configured")
+	}
+
+	// Slack does not render markdown tables, so the report is sent as a code block.
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*%s*\n```\n%s\n```", report.Title, RenderMarkdown(report)),
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
+
+func (e *EmailDelivery) deliver(report *Report, format Format) error {
+	if e.SMTPServer == "" || e.From == "" || len(e.To) == 0 {
+		return fmt.Errorf("smtpServer, from and to must be configured")
+	}
+	content, err := Render(report, format)
+	if err != nil {
+		return err
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	if format == FormatHTML {
+		contentType = "text/html; charset=utf-8"
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", report.Title)
+	fmt.Fprintf(&msg, "Date: %s\r\n", report.GeneratedAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\nContent-Type: %s\r\n\r\n", contentType)
+	msg.WriteString(content)
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		host, _, err := net.SplitHostPort(e.SMTPServer)
+		if err != nil {
+			return fmt.Errorf("invalid smtpServer %q: %w", e.SMTPServer, err)
+		}
+		auth = smtp.PlainAuth("", e.Username, os.Getenv(e.PasswordEnv), host)
+	}
+	return smtp.SendMail(e.SMTPServer, auth, e.From, e.To, msg.Bytes())
+}
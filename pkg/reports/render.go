@@ -0,0 +1,121 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// Format is the output format of a rendered report.
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+// Render renders the report in the given format.
+func Render(report *Report, format Format) (string, error) {
+	switch format {
+	case FormatMarkdown, "":
+		return RenderMarkdown(report), nil
+	case FormatHTML:
+		return RenderHTML(report)
+	default:
+		return "", fmt.Errorf("unknown report format %q, supported formats: markdown, html", format)
+	}
+}
+
+// RenderMarkdown renders the report as a markdown document.
+func RenderMarkdown(report *Report) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", report.Title)
+	fmt.Fprintf(&sb, "_Generated at %s_\n", report.GeneratedAt.Format(time.RFC1123))
+
+	for _, section := range report.Sections {
+		fmt.Fprintf(&sb, "\n## %s\n\n", section.Heading)
+		if section.Summary != "" {
+			fmt.Fprintf(&sb, "%s\n\n", section.Summary)
+		}
+		if len(section.Header) == 0 {
+			continue
+		}
+		if len(section.Rows) == 0 {
+			sb.WriteString("Nothing to report.\n")
+			continue
+		}
+		writeMarkdownRow(&sb, section.Header)
+		separators := make([]string, len(section.Header))
+		for i := range separators {
+			separators[i] = "---"
+		}
+		writeMarkdownRow(&sb, separators)
+		for _, row := range section.Rows {
+			writeMarkdownRow(&sb, row)
+		}
+	}
+	return sb.String()
+}
+
+func writeMarkdownRow(sb *strings.Builder, cells []string) {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.ReplaceAll(cell, "|", `\|`)
+	}
+	fmt.Fprintf(sb, "| %s |\n", strings.Join(escaped, " | "))
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #202124; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #dadce0; padding: 4px 8px; text-align: left; }
+th { background: #f1f3f4; }
+.generated { color: #5f6368; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="generated">Generated at {{.GeneratedAt.Format "Mon, 02 Jan 2006 15:04:05 MST"}}</p>
+{{range .Sections}}
+<h2>{{.Heading}}</h2>
+{{if .Summary}}<p>{{.Summary}}</p>{{end}}
+{{if .Header}}{{if .Rows}}
+<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{else}}<p>Nothing to report.</p>{{end}}{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// RenderHTML renders the report as a standalone HTML document.
+func RenderHTML(report *Report) (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, report); err != nil {
+		return "", fmt.Errorf("rendering report: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reports implements report templates (capacity, security, cost,
+// failing workloads) built from live cluster data, their rendering to
+// markdown or HTML, their delivery by email or Slack, and their scheduling.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+// Report is a report made of sections of tables, independent of its rendering.
+type Report struct {
+	Title       string    `json:"title"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Sections    []Section `json:"sections"`
+}
+
+// Section is a part of a report.
+type Section struct {
+	Heading string `json:"heading"`
+	// Summary is a short paragraph shown before the table.
+	Summary string     `json:"summary,omitempty"`
+	Header  []string   `json:"header,omitempty"`
+	Rows    [][]string `json:"rows,omitempty"`
+}
+
+// Pricing is used by the cost report to estimate the cost of requested resources.
+type Pricing struct {
+	// CPUHour is the price of one CPU core for one hour.
+	CPUHour float64 `json:"cpuHour,omitempty"`
+	// MemoryGiBHour is the price of one GiB of memory for one hour.
+	MemoryGiBHour float64 `json:"memoryGiBHour,omitempty"`
+	// Currency is displayed next to the prices, e.g. "USD".
+	Currency string `json:"currency,omitempty"`
+}
+
+// Env is the environment the report templates collect their data from.
+type Env struct {
+	Executor   sandbox.Executor
+	Kubeconfig string
+	WorkDir    string
+	Pricing    Pricing
+}
+
+// Template builds a report from live cluster data.
+type Template func(ctx context.Context, env Env) (*Report, error)
+
+var templates = map[string]Template{
+	"capacity":          capacityReport,
+	"security":          securityReport,
+	"cost":              costReport,
+	"failing-workloads": failingWorkloadsReport,
+}
+
+// TemplateNames returns the names of the available report templates.
+func TemplateNames() []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate runs the named report template.
+func Generate(ctx context.Context, name string, env Env) (*Report, error) {
+	template, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown report template %q, available templates: %v", name, TemplateNames())
+	}
+	report, err := template(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("generating %s report: %w", name, err)
+	}
+	report.GeneratedAt = time.Now()
+	return report, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reports
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a cron schedule: minute, hour, day of month, month and day of week.
+type Schedule struct {
+	fields [5]map[int]bool
+	// domWildcard and dowWildcard follow the cron rule: when both day fields
+	// are restricted, a time matches if either of them matches.
+	domWildcard, dowWildcard bool
+}
+
+var scheduleDescriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+var scheduleBounds = [5]struct{ min, max int }{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week, 0 is Sunday
+}
+
+// ParseSchedule parses a cron expression with 5 fields (e.g. "0 8 * * 1-5"),
+// supporting "*", lists, ranges and steps, or one of @hourly, @daily, @weekly and @monthly.
+func ParseSchedule(expr string) (*Schedule, error) {
+	if descriptor, ok := scheduleDescriptors[strings.TrimSpace(expr)]; ok {
+		expr = descriptor
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q, expected 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+
+	s := &Schedule{domWildcard: parts[2] == "*", dowWildcard: parts[4] == "*"}
+	for i, part := range parts {
+		values, err := parseScheduleField(part, scheduleBounds[i].min, scheduleBounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", expr, err)
+		}
+		s.fields[i] = values
+	}
+	// Sunday can be written as 7.
+	if s.fields[4][7] {
+		s.fields[4][0] = true
+	}
+	return s, nil
+}
+
+func parseScheduleField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step %q", item)
+			}
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			loPart, hiPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(loPart); err != nil {
+				return nil, fmt.Errorf("invalid value %q", item)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiPart); err != nil {
+					return nil, fmt.Errorf("invalid range %q", item)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		// Allow 7 for Sunday in the day of week field.
+		upper := max
+		if max == 6 {
+			upper = 7
+		}
+		if lo < min || hi > upper || lo > hi {
+			return nil, fmt.Errorf("value %q out of range [%d-%d]", item, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// Next returns the first time matching the schedule strictly after t.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Every schedule matches at least once within 4 years (e.g. February 29th).
+	for limit := t.AddDate(4, 0, 0); t.Before(limit); {
+		if !s.fields[3][int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.fields[1][t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.fields[0][t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.fields[2][t.Day()]
+	dow := s.fields[4][int(t.Weekday())]
+	switch {
+	case s.domWildcard && s.dowWildcard:
+		return true
+	case s.domWildcard:
+		return dow
+	case s.dowWildcard:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reports
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// Wednesday.
+	from := time.Date(2025, 6, 11, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{expr: "@hourly", want: time.Date(2025, 6, 11, 11, 0, 0, 0, time.UTC)},
+		{expr: "@daily", want: time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{expr: "@weekly", want: time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)},
+		{expr: "@monthly", want: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", want: time.Date(2025, 6, 11, 10, 45, 0, 0, time.UTC)},
+		{expr: "0 8 * * 1-5", want: time.Date(2025, 6, 12, 8, 0, 0, 0, time.UTC)},
+		{expr: "0 8 * * 6,7", want: time.Date(2025, 6, 14, 8, 0, 0, 0, time.UTC)},
+		{expr: "30 10 * * *", want: time.Date(2025, 6, 12, 10, 30, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matches.
+		{expr: "0 9 1 * 5", want: time.Date(2025, 6, 13, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) error: %v", tt.expr, err)
+			}
+			if got := s.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@yearly"} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("ParseSchedule(%q) expected an error", expr)
+		}
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	report := &Report{
+		Title:       "Test report",
+		GeneratedAt: time.Date(2025, 6, 11, 10, 30, 0, 0, time.UTC),
+		Sections: []Section{
+			{Heading: "Findings", Summary: "1 finding.", Header: []string{"Pod", "Finding"}, Rows: [][]string{{"web", "a|b"}}},
+			{Heading: "Empty", Header: []string{"Pod"}},
+		},
+	}
+	want := `# Test report
+
+_Generated at Wed, 11 Jun 2025 10:30:00 UTC_
+
+## Findings
+
+1 finding.
+
+| Pod | Finding |
+| --- | --- |
+| web | a\|b |
+
+## Empty
+
+Nothing to report.
+`
+	if got := RenderMarkdown(report); got != want {
+		t.Errorf("RenderMarkdown() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reports
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the configuration of the scheduled reports.
+type Config struct {
+	Pricing Pricing           `json:"pricing,omitempty"`
+	Reports []ScheduledReport `json:"reports"`
+}
+
+// ScheduledReport is a report template run on a schedule.
+type ScheduledReport struct {
+	Name     string `json:"name"`
+	Template string `json:"template"`
+	// Schedule is a cron expression, e.g. "0 8 * * 1-5" or "@daily".
+	Schedule string   `json:"schedule"`
+	Format   Format   `json:"format,omitempty"`
+	Delivery Delivery `json:"delivery"`
+
+	schedule *Schedule
+}
+
+// LoadConfig loads and validates the scheduled reports configuration file.
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading reports config: %w", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing reports config %s: %w", path, err)
+	}
+
+	for i := range cfg.Reports {
+		r := &cfg.Reports[i]
+		if r.Name == "" {
+			r.Name = r.Template
+		}
+		if _, ok := templates[r.Template]; !ok {
+			return nil, fmt.Errorf("report %q: unknown template %q, available templates: %v", r.Name, r.Template, TemplateNames())
+		}
+		if r.schedule, err = ParseSchedule(r.Schedule); err != nil {
+			return nil, fmt.Errorf("report %q: %w", r.Name, err)
+		}
+		if r.Delivery.Slack == nil && r.Delivery.Email == nil {
+			return nil, fmt.Errorf("report %q: no delivery configured", r.Name)
+		}
+	}
+	return &cfg, nil
+}
+
+// Serve runs the scheduled reports until the context is cancelled.
+// Failures are logged, and do not stop the other reports.
+func Serve(ctx context.Context, cfg *Config, env Env) error {
+	if len(cfg.Reports) == 0 {
+		return fmt.Errorf("no reports configured")
+	}
+	env.Pricing = cfg.Pricing
+
+	next := make([]time.Time, len(cfg.Reports))
+	for i, r := range cfg.Reports {
+		next[i] = r.schedule.Next(time.Now())
+		klog.Infof("report %q scheduled at %s", r.Name, next[i])
+	}
+
+	for {
+		earliest := 0
+		for i := range next {
+			if next[i].Before(next[earliest]) {
+				earliest = i
+			}
+		}
+
+		timer := time.NewTimer(time.Until(next[earliest]))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		for i, r := range cfg.Reports {
+			if next[i].After(now) {
+				continue
+			}
+			if err := RunAndDeliver(ctx, r, env); err != nil {
+				klog.Errorf("scheduled report %q failed: %v", r.Name, err)
+			}
+			next[i] = r.schedule.Next(now)
+			klog.Infof("report %q scheduled at %s", r.Name, next[i])
+		}
+	}
+}
+
+// RunAndDeliver generates a report and delivers it.
+func RunAndDeliver(ctx context.Context, r ScheduledReport, env Env) error {
+	report, err := Generate(ctx, r.Template, env)
+	if err != nil {
+		return err
+	}
+	return r.Delivery.Deliver(ctx, report, r.Format)
+}
@@ -0,0 +1,268 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reports
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const hoursPerMonth = 730
+
+func capacityReport(ctx context.Context, env Env) (*Report, error) {
+	capacity, err := collectCapacity(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	section := Section{
+		Heading: "Requests and limits per node pool",
+		Header:  []string{"Node pool", "Nodes", "Pods", "CPU allocatable", "CPU requests", "CPU limits", "Memory allocatable", "Memory requests", "Memory limits"},
+	}
+	for _, pool := range capacity.NodePools {
+		section.Rows = append(section.Rows, []string{
+			pool.Name,
+			strconv.Itoa(pool.Nodes),
+			strconv.Itoa(pool.Pods),
+			formatCores(pool.AllocatableCPU),
+			formatShare(formatCores(pool.RequestedCPU), pool.RequestedCPU, pool.AllocatableCPU),
+			formatShare(formatCores(pool.LimitCPU), pool.LimitCPU, pool.AllocatableCPU),
+			formatGiB(pool.AllocatableMemory),
+			formatShare(formatGiB(pool.RequestedMemory), float64(pool.RequestedMemory), float64(pool.AllocatableMemory)),
+			formatShare(formatGiB(pool.LimitMemory), float64(pool.LimitMemory), float64(pool.AllocatableMemory)),
+		})
+	}
+	return &Report{Title: "Capacity report", Sections: []Section{section}}, nil
+}
+
+func costReport(ctx context.Context, env Env) (*Report, error) {
+	if env.Pricing.CPUHour == 0 && env.Pricing.MemoryGiBHour == 0 {
+		return nil, fmt.Errorf("the cost report requires pricing (cpuHour and/or memoryGiBHour) to be configured")
+	}
+	capacity, err := collectCapacity(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := env.Pricing.Currency
+	section := Section{
+		Heading: "Estimated monthly cost per node pool",
+		Summary: fmt.Sprintf("Estimated from allocatable and requested resources at %g %s per CPU hour and %g %s per GiB hour. Idle is the cost of allocatable resources no pod requests.",
+			env.Pricing.CPUHour, currency, env.Pricing.MemoryGiBHour, currency),
+		Header: []string{"Node pool", "Allocatable", "Requested", "Idle"},
+	}
+	var totalAllocatable, totalRequested float64
+	for _, pool := range capacity.NodePools {
+		allocatable := monthlyCost(env.Pricing, pool.AllocatableCPU, pool.AllocatableMemory)
+		requested := monthlyCost(env.Pricing, pool.RequestedCPU, pool.RequestedMemory)
+		totalAllocatable += allocatable
+		totalRequested += requested
+		section.Rows = append(section.Rows, []string{
+			pool.Name,
+			formatMoney(allocatable, currency),
+			formatMoney(requested, currency),
+			formatShare(formatMoney(allocatable-requested, currency), allocatable-requested, allocatable),
+		})
+	}
+	section.Rows = append(section.Rows, []string{
+		"Total",
+		formatMoney(totalAllocatable, currency),
+		formatMoney(totalRequested, currency),
+		formatShare(formatMoney(totalAllocatable-totalRequested, currency), totalAllocatable-totalRequested, totalAllocatable),
+	})
+	return &Report{Title: "Cost report", Sections: []Section{section}}, nil
+}
+
+func securityReport(ctx context.Context, env Env) (*Report, error) {
+	pods, err := tools.ListResources(ctx, env.Executor, env.Kubeconfig, env.WorkDir, "pods --all-namespaces")
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+
+	section := Section{
+		Heading: "Pod security findings",
+		Header:  []string{"Namespace", "Pod", "Container", "Finding"},
+	}
+	for _, pod := range pods {
+		for _, finding := range podSecurityFindings(pod) {
+			section.Rows = append(section.Rows, []string{pod.GetNamespace(), pod.GetName(), finding.container, finding.message})
+		}
+	}
+	section.Summary = fmt.Sprintf("%d findings across %d pods.", len(section.Rows), len(pods))
+	return &Report{Title: "Security report", Sections: []Section{section}}, nil
+}
+
+type securityFinding struct {
+	container string
+	message   string
+}
+
+// podSecurityFindings checks a pod against the common Pod Security Standards violations.
+func podSecurityFindings(pod unstructured.Unstructured) []securityFinding {
+	var findings []securityFinding
+	for _, field := range []string{"hostNetwork", "hostPID", "hostIPC"} {
+		if enabled, _, _ := unstructured.NestedBool(pod.Object, "spec", field); enabled {
+			findings = append(findings, securityFinding{message: "uses " + field})
+		}
+	}
+	volumes, _, _ := unstructured.NestedSlice(pod.Object, "spec", "volumes")
+	for _, v := range volumes {
+		if volume, ok := v.(map[string]any); ok {
+			if hostPath, _, _ := unstructured.NestedString(volume, "hostPath", "path"); hostPath != "" {
+				findings = append(findings, securityFinding{message: "mounts host path " + hostPath})
+			}
+		}
+	}
+
+	podRunAsNonRoot, _, _ := unstructured.NestedBool(pod.Object, "spec", "securityContext", "runAsNonRoot")
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+	for _, c := range containers {
+		container, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := container["name"].(string)
+		if privileged, _, _ := unstructured.NestedBool(container, "securityContext", "privileged"); privileged {
+			findings = append(findings, securityFinding{container: name, message: "runs privileged"})
+		}
+		if escalation, found, _ := unstructured.NestedBool(container, "securityContext", "allowPrivilegeEscalation"); !found || escalation {
+			findings = append(findings, securityFinding{container: name, message: "allows privilege escalation"})
+		}
+		runAsNonRoot, found, _ := unstructured.NestedBool(container, "securityContext", "runAsNonRoot")
+		if !runAsNonRoot && (found || !podRunAsNonRoot) {
+			findings = append(findings, securityFinding{container: name, message: "may run as root"})
+		}
+		added, _, _ := unstructured.NestedStringSlice(container, "securityContext", "capabilities", "add")
+		for _, capability := range added {
+			switch strings.ToUpper(capability) {
+			case "ALL", "SYS_ADMIN", "NET_ADMIN", "SYS_PTRACE", "SYS_MODULE":
+				findings = append(findings, securityFinding{container: name, message: "adds capability " + capability})
+			}
+		}
+	}
+	return findings
+}
+
+func failingWorkloadsReport(ctx context.Context, env Env) (*Report, error) {
+	pods, err := tools.ListResources(ctx, env.Executor, env.Kubeconfig, env.WorkDir, "pods --all-namespaces")
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+	deployments, err := tools.ListResources(ctx, env.Executor, env.Kubeconfig, env.WorkDir, "deployments --all-namespaces")
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+
+	podSection := Section{
+		Heading: "Failing pods",
+		Header:  []string{"Namespace", "Pod", "Phase", "Reason", "Restarts"},
+	}
+	for _, pod := range pods {
+		if reason, restarts, failing := podFailure(pod, time.Now()); failing {
+			phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+			podSection.Rows = append(podSection.Rows, []string{pod.GetNamespace(), pod.GetName(), phase, reason, strconv.FormatInt(restarts, 10)})
+		}
+	}
+	podSection.Summary = fmt.Sprintf("%d of %d pods are failing.", len(podSection.Rows), len(pods))
+
+	deploymentSection := Section{
+		Heading: "Degraded deployments",
+		Header:  []string{"Namespace", "Deployment", "Ready", "Unavailable"},
+	}
+	for _, deployment := range deployments {
+		replicas, _, _ := unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+		ready, _, _ := unstructured.NestedInt64(deployment.Object, "status", "readyReplicas")
+		unavailable, _, _ := unstructured.NestedInt64(deployment.Object, "status", "unavailableReplicas")
+		if unavailable == 0 && ready >= replicas {
+			continue
+		}
+		deploymentSection.Rows = append(deploymentSection.Rows, []string{
+			deployment.GetNamespace(), deployment.GetName(), fmt.Sprintf("%d/%d", ready, replicas), strconv.FormatInt(unavailable, 10),
+		})
+	}
+	deploymentSection.Summary = fmt.Sprintf("%d of %d deployments are degraded.", len(deploymentSection.Rows), len(deployments))
+
+	return &Report{Title: "Failing workloads report", Sections: []Section{podSection, deploymentSection}}, nil
+}
+
+// podFailure returns why a pod is failing, and its total number of container restarts.
+func podFailure(pod unstructured.Unstructured, now time.Time) (reason string, restarts int64, failing bool) {
+	phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+	statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", "containerStatuses")
+	for _, s := range statuses {
+		status, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+		count, _, _ := unstructured.NestedInt64(status, "restartCount")
+		restarts += count
+		if waiting, _, _ := unstructured.NestedString(status, "state", "waiting", "reason"); waiting != "" && waiting != "ContainerCreating" && waiting != "PodInitializing" {
+			reason = waiting
+		}
+	}
+
+	switch {
+	case reason != "":
+		return reason, restarts, true
+	case phase == "Failed":
+		reason, _, _ = unstructured.NestedString(pod.Object, "status", "reason")
+		return reason, restarts, true
+	case phase == "Pending" && now.Sub(pod.GetCreationTimestamp().Time) > 5*time.Minute:
+		return "Pending for more than 5 minutes", restarts, true
+	case restarts >= 5:
+		return "Restarting frequently", restarts, true
+	}
+	return "", restarts, false
+}
+
+func collectCapacity(ctx context.Context, env Env) (*tools.CapacityReport, error) {
+	capacity, err := tools.CollectCapacity(ctx, env.Executor, env.Kubeconfig, env.WorkDir, tools.CapacityScenario{})
+	if err != nil {
+		return nil, err
+	}
+	if capacity.Error != "" {
+		return nil, fmt.Errorf("%s", capacity.Error)
+	}
+	return capacity, nil
+}
+
+func monthlyCost(pricing Pricing, cores float64, memoryBytes int64) float64 {
+	return (cores*pricing.CPUHour + float64(memoryBytes)/(1<<30)*pricing.MemoryGiBHour) * hoursPerMonth
+}
+
+func formatCores(cores float64) string {
+	return strconv.FormatFloat(cores, 'f', 2, 64)
+}
+
+func formatGiB(bytes int64) string {
+	return fmt.Sprintf("%.1fGi", float64(bytes)/(1<<30))
+}
+
+func formatMoney(amount float64, currency string) string {
+	return strings.TrimSpace(fmt.Sprintf("%.2f %s", amount, currency))
+}
+
+func formatShare(value string, part, total float64) string {
+	if total == 0 {
+		return value
+	}
+	return fmt.Sprintf("%s (%.0f%%)", value, part/total*100)
+}
@@ -17,6 +17,7 @@ package sandbox
 import (
 	"context"
 	"fmt"
+	"io"
 )
 
 // Executor defines the interface for executing commands.
@@ -28,6 +29,16 @@ type Executor interface {
 	Close(ctx context.Context) error
 }
 
+// ProgressExecutor is implemented by the executors that can report the output
+// of a command while it runs, used by the async tool invocations.
+type ProgressExecutor interface {
+	Executor
+
+	// ExecuteWithProgress runs a command like Execute, and also writes its
+	// stdout and stderr to progress as they are produced.
+	ExecuteWithProgress(ctx context.Context, command string, env []string, workDir string, progress io.Writer) (*ExecResult, error)
+}
+
 // ExecResult represents the result of a command execution.
 type ExecResult struct {
 	Command    string `json:"command,omitempty"`
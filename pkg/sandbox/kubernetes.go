@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -113,7 +114,9 @@ func NewKubernetesSandbox(name string, opts ...Option) (*KubernetesSandbox, erro
 	}
 
 	// Initialize Kubernetes client
-	config, err := clientcmd.BuildConfigFromFlags("", s.kubeconfig)
+	// Like $KUBECONFIG, the kubeconfig path may list several files to merge.
+	rules := &clientcmd.ClientConfigLoadingRules{Precedence: filepath.SplitList(s.kubeconfig)}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
 	if err != nil {
 		return nil, fmt.Errorf("error building kubeconfig: %v", err)
 	}
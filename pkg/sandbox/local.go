@@ -17,9 +17,12 @@ package sandbox
 import (
 	"bytes"
 	"context"
+	"io"
 	"os"
 	"os/exec"
 	"runtime"
+	"sync"
+	"time"
 
 	"k8s.io/klog/v2"
 )
@@ -36,8 +39,20 @@ func NewLocalExecutor() *Local {
 	return &Local{}
 }
 
+var _ InteractiveExecutor = &Local{}
+
 // Execute executes the command locally.
 func (e *Local) Execute(ctx context.Context, command string, env []string, workDir string) (*ExecResult, error) {
+	return e.ExecuteWithProgress(ctx, command, env, workDir, nil)
+}
+
+// ExecuteWithProgress executes the command locally, writing its output to progress as it runs.
+func (e *Local) ExecuteWithProgress(ctx context.Context, command string, env []string, workDir string, progress io.Writer) (*ExecResult, error) {
+	return e.ExecuteInteractive(ctx, command, env, workDir, progress, nil)
+}
+
+// ExecuteInteractive executes the command locally, answering its prompts with prompter.
+func (e *Local) ExecuteInteractive(ctx context.Context, command string, env []string, workDir string, progress io.Writer, prompter Prompter) (*ExecResult, error) {
 	// Use the provided context directly
 	cmdCtx := ctx
 
@@ -53,8 +68,19 @@ func (e *Local) Execute(ctx context.Context, command string, env []string, workD
 	var stdoutBuf, stderrBuf bytes.Buffer
 	cmd.Stdout = &stdoutBuf
 	cmd.Stderr = &stderrBuf
+	if progress != nil {
+		// The writers are called from different goroutines.
+		progress = &syncWriter{w: progress}
+		cmd.Stdout = io.MultiWriter(&stdoutBuf, progress)
+		cmd.Stderr = io.MultiWriter(&stderrBuf, progress)
+	}
 
-	err := cmd.Run()
+	var err error
+	if prompter != nil {
+		err = runWithStdinBridge(ctx, cmd, prompter)
+	} else {
+		err = cmd.Run()
+	}
 
 	result := &ExecResult{
 		Command: command,
@@ -76,6 +102,45 @@ func (e *Local) Execute(ctx context.Context, command string, env []string, workD
 	return result, nil
 }
 
+// runWithStdinBridge runs cmd, answering the prompts it prints with prompter.
+func runWithStdinBridge(ctx context.Context, cmd *exec.Cmd, prompter Prompter) error {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	detector := &promptDetector{lastWrite: time.Now()}
+	cmd.Stdout = io.MultiWriter(cmd.Stdout, detector)
+	cmd.Stderr = io.MultiWriter(cmd.Stderr, detector)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Stop prompting the user as soon as the command exits.
+	bridgeCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		bridgeStdin(bridgeCtx, stdin, detector, prompter)
+	}()
+	err = cmd.Wait()
+	cancel()
+	wg.Wait()
+	return err
+}
+
+// syncWriter serializes the writes to w.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
 // Close is a no-op for Local executor.
 func (e *Local) Close(ctx context.Context) error {
 	return nil
@@ -0,0 +1,147 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// stdinPromptIdle is how long a command must stay silent after printing a
+	// prompt to be considered blocked waiting for input.
+	stdinPromptIdle = 2 * time.Second
+	// stdinPromptPollInterval is the interval at which the output is checked for prompts.
+	stdinPromptPollInterval = 250 * time.Millisecond
+	// maxPromptLength bounds the unterminated output line kept as a candidate prompt.
+	maxPromptLength = 1024
+)
+
+// Prompter asks the user to answer the prompt of a command waiting for input,
+// e.g. a helm plugin or gcloud auth asking for confirmation.
+type Prompter func(ctx context.Context, prompt string) (answer string, err error)
+
+// InteractiveExecutor is implemented by the executors that can detect a
+// command blocked waiting for input and pipe the answer of the user to its stdin.
+type InteractiveExecutor interface {
+	ProgressExecutor
+
+	// ExecuteInteractive runs a command like ExecuteWithProgress. When the command
+	// prints a prompt and waits, the prompt is passed to prompter and the answer
+	// is written to the stdin of the command. progress may be nil.
+	ExecuteInteractive(ctx context.Context, command string, env []string, workDir string, progress io.Writer, prompter Prompter) (*ExecResult, error)
+}
+
+// promptDetector tracks the output of a command to detect prompts: an
+// unterminated last line followed by silence.
+type promptDetector struct {
+	mu        sync.Mutex
+	lastWrite time.Time
+	line      []byte
+}
+
+func (d *promptDetector) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastWrite = time.Now()
+	if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+		d.line = append(d.line[:0], p[i+1:]...)
+	} else {
+		d.line = append(d.line, p...)
+	}
+	if len(d.line) > maxPromptLength {
+		d.line = d.line[len(d.line)-maxPromptLength:]
+	}
+	return len(p), nil
+}
+
+// pendingPrompt returns the prompt the command is waiting on, if any. A prompt is returned once.
+func (d *promptDetector) pendingPrompt(idle time.Duration) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.line) == 0 || time.Since(d.lastWrite) < idle {
+		return ""
+	}
+	prompt := strings.TrimSpace(string(d.line))
+	if !looksLikePrompt(prompt) {
+		return ""
+	}
+	d.line = d.line[:0]
+	return prompt
+}
+
+// waitsWithoutPrompt returns true if the command printed nothing for idle and
+// its last line is not a prompt: it may read its stdin, e.g. "cat" or
+// "kubectl apply -f -", but not for an answer of the user.
+func (d *promptDetector) waitsWithoutPrompt(idle time.Duration) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return time.Since(d.lastWrite) >= idle && !looksLikePrompt(strings.TrimSpace(string(d.line)))
+}
+
+// looksLikePrompt returns true if the line looks like a question to the user,
+// e.g. "Do you want to continue (Y/n)?" or "Password:".
+func looksLikePrompt(line string) bool {
+	if line == "" {
+		return false
+	}
+	switch line[len(line)-1] {
+	case ':', '?', ']', ')', '>':
+		return true
+	}
+	lower := strings.ToLower(line)
+	return strings.Contains(lower, "password") || strings.Contains(lower, "passphrase")
+}
+
+// bridgeStdin answers the prompts detected in the output of a command with the
+// answers of the user, until ctx is done. Stdin is closed once the command
+// waits without a prompt, so that the commands reading it get EOF as they
+// would from /dev/null.
+func bridgeStdin(ctx context.Context, stdin io.WriteCloser, detector *promptDetector, prompter Prompter) {
+	ticker := time.NewTicker(stdinPromptPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prompt := detector.pendingPrompt(stdinPromptIdle)
+			if prompt == "" {
+				if detector.waitsWithoutPrompt(stdinPromptIdle) {
+					stdin.Close()
+					return
+				}
+				continue
+			}
+			answer, err := prompter(ctx, prompt)
+			if err != nil {
+				// Close stdin so that the command fails instead of hanging.
+				klog.Infof("Not answering the prompt %q of the command: %v", prompt, err)
+				stdin.Close()
+				return
+			}
+			if _, err := io.WriteString(stdin, answer+"\n"); err != nil {
+				klog.Infof("Writing the answer to the stdin of the command: %v", err)
+				return
+			}
+		}
+	}
+}
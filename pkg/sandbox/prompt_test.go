@@ -0,0 +1,121 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLooksLikePrompt(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{line: "Do you want to continue (Y/n)?", want: true},
+		{line: "Enter verification code:", want: true},
+		{line: "Proceed? [y/N]", want: true},
+		{line: "Enter the password for the key", want: true},
+		{line: "Pulling layer 3/5", want: false},
+		{line: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := looksLikePrompt(tt.line); got != tt.want {
+			t.Errorf("looksLikePrompt(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestPromptDetector(t *testing.T) {
+	d := &promptDetector{}
+	d.Write([]byte("Fetching plugin...\nInstall it? [y/N] "))
+	if got := d.pendingPrompt(time.Hour); got != "" {
+		t.Errorf("pendingPrompt() = %q before the idle delay, want none", got)
+	}
+	if got := d.pendingPrompt(0); got != "Install it? [y/N]" {
+		t.Errorf("pendingPrompt() = %q, want the prompt", got)
+	}
+	if got := d.pendingPrompt(0); got != "" {
+		t.Errorf("pendingPrompt() = %q, want the prompt to be returned once", got)
+	}
+
+	d.Write([]byte("Downloading 50%"))
+	if got := d.pendingPrompt(0); got != "" {
+		t.Errorf("pendingPrompt() = %q for progress output, want none", got)
+	}
+}
+
+func TestLocalExecuteInteractive(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires bash")
+	}
+
+	var prompts []string
+	prompter := func(ctx context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return "yes", nil
+	}
+	result, err := NewLocalExecutor().ExecuteInteractive(context.Background(), `printf 'Continue? '; read answer; echo "got $answer"`, nil, "", nil, prompter)
+	if err != nil {
+		t.Fatalf("ExecuteInteractive() error: %v", err)
+	}
+	if len(prompts) != 1 || prompts[0] != "Continue?" {
+		t.Errorf("prompts = %q, want [Continue?]", prompts)
+	}
+	if !strings.Contains(result.Stdout, "got yes") {
+		t.Errorf("Stdout = %q, want the answer", result.Stdout)
+	}
+}
+
+func TestLocalExecuteInteractiveDeclined(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires bash")
+	}
+
+	prompter := func(ctx context.Context, prompt string) (string, error) {
+		return "", errors.New("declined")
+	}
+	result, err := NewLocalExecutor().ExecuteInteractive(context.Background(), `printf 'Continue? '; read answer || exit 3`, nil, "", nil, prompter)
+	if err != nil {
+		t.Fatalf("ExecuteInteractive() error: %v", err)
+	}
+	if result.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3 (stdin closed)", result.ExitCode)
+	}
+}
+
+func TestLocalExecuteInteractiveWithoutPrompt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires bash")
+	}
+
+	prompter := func(ctx context.Context, prompt string) (string, error) {
+		t.Errorf("prompter called with %q, want no prompt", prompt)
+		return "", errors.New("unexpected prompt")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 4*stdinPromptIdle)
+	defer cancel()
+	result, err := NewLocalExecutor().ExecuteInteractive(ctx, `echo reading; cat; echo done`, nil, "", nil, prompter)
+	if err != nil {
+		t.Fatalf("ExecuteInteractive() error: %v", err)
+	}
+	if result.ExitCode != 0 || !strings.Contains(result.Stdout, "done") {
+		t.Errorf("result = %+v, want cat to get EOF and the command to complete", result)
+	}
+}
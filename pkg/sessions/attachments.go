@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// AttachmentThreshold is the size of the JSON encoded payload of a tool
+	// call response above which the payload is stored as an attachment.
+	AttachmentThreshold = 16 * 1024
+	// attachmentPreviewSize is the size of the preview kept in the message.
+	attachmentPreviewSize = 1024
+)
+
+// splitAttachment returns the message to keep in the history and, when the
+// payload of a tool call response is larger than AttachmentThreshold, the
+// payload to store as an attachment. The given message is never modified.
+func splitAttachment(msg *api.Message) (*api.Message, []byte, error) {
+	if msg.Type != api.MessageTypeToolCallResponse || msg.AttachmentID != "" {
+		return msg, nil, nil
+	}
+	data, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) <= AttachmentThreshold {
+		return msg, nil, nil
+	}
+
+	sum := sha256.Sum256(data)
+	stored := *msg
+	stored.AttachmentID = hex.EncodeToString(sum[:])
+	stored.Payload = api.AttachmentPreview{
+		Preview: strings.ToValidUTF8(string(data[:attachmentPreviewSize]), ""),
+		Size:    len(data),
+	}
+	return &stored, data, nil
+}
+
+// validAttachmentID reports whether id is a content address produced by splitAttachment.
+func validAttachmentID(id string) bool {
+	if len(id) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// ResolveAttachments returns the messages with the payloads stored as
+// attachments loaded back, e.g. to replay the full history to the model.
+// Messages whose attachment cannot be loaded keep their preview.
+func ResolveAttachments(store api.ChatMessageStore, messages []*api.Message) []*api.Message {
+	attachments, ok := store.(api.AttachmentStore)
+	if !ok {
+		return messages
+	}
+	resolved := make([]*api.Message, len(messages))
+	for i, msg := range messages {
+		resolved[i] = msg
+		if msg.AttachmentID == "" {
+			continue
+		}
+		data, err := attachments.Attachment(msg.AttachmentID)
+		if err != nil {
+			klog.Warningf("loading attachment %s of message %s: %v", msg.AttachmentID, msg.ID, err)
+			continue
+		}
+		var payload any
+		if err := json.Unmarshal(data, &payload); err != nil {
+			klog.Warningf("decoding attachment %s of message %s: %v", msg.AttachmentID, msg.ID, err)
+			continue
+		}
+		full := *msg
+		full.Payload = payload
+		full.AttachmentID = ""
+		resolved[i] = &full
+	}
+	return resolved
+}
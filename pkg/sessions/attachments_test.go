@@ -0,0 +1,76 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+func TestAttachments(t *testing.T) {
+	stores := map[string]func(t *testing.T) api.ChatMessageStore{
+		"memory":     func(t *testing.T) api.ChatMessageStore { return NewInMemoryChatStore() },
+		"filesystem": func(t *testing.T) api.ChatMessageStore { return NewFileChatMessageStore(t.TempDir()) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			large := map[string]any{"stdout": strings.Repeat("pod-", AttachmentThreshold)}
+			small := map[string]any{"stdout": "ok"}
+			largeMsg := &api.Message{ID: "1", Type: api.MessageTypeToolCallResponse, Payload: large}
+			for _, msg := range []*api.Message{
+				largeMsg,
+				{ID: "2", Type: api.MessageTypeToolCallResponse, Payload: small},
+				{ID: "3", Type: api.MessageTypeText, Payload: strings.Repeat("x", AttachmentThreshold+1)},
+			} {
+				if err := store.AddChatMessage(msg); err != nil {
+					t.Fatalf("AddChatMessage() error: %v", err)
+				}
+			}
+			if largeMsg.AttachmentID != "" || largeMsg.Payload == nil {
+				t.Errorf("AddChatMessage() modified the given message")
+			}
+
+			messages := store.ChatMessages()
+			if len(messages) != 3 {
+				t.Fatalf("ChatMessages() returned %d messages, want 3", len(messages))
+			}
+			if messages[0].AttachmentID == "" {
+				t.Fatalf("large tool output was not stored as an attachment")
+			}
+			if messages[1].AttachmentID != "" || messages[2].AttachmentID != "" {
+				t.Errorf("only large tool outputs should be stored as attachments")
+			}
+			if _, err := store.(api.AttachmentStore).Attachment(messages[0].AttachmentID); err != nil {
+				t.Errorf("Attachment() error: %v", err)
+			}
+
+			resolved := ResolveAttachments(store, messages)
+			payload, ok := resolved[0].Payload.(map[string]any)
+			if !ok || payload["stdout"] != large["stdout"] {
+				t.Errorf("ResolveAttachments() did not restore the payload, got %T", resolved[0].Payload)
+			}
+
+			if err := store.ClearChatMessages(); err != nil {
+				t.Fatalf("ClearChatMessages() error: %v", err)
+			}
+			if _, err := store.(api.AttachmentStore).Attachment(messages[0].AttachmentID); err == nil {
+				t.Errorf("Attachment() should fail after ClearChatMessages()")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// exportFormatVersion is the version of the session export format.
+const exportFormatVersion = 1
+
+// Export is a session in a portable form, e.g. to hand off an investigation
+// to a teammate. Payloads stored as attachments are inlined in the messages.
+type Export struct {
+	Version      int            `json:"version"`
+	ID           string         `json:"id"`
+	ProviderID   string         `json:"providerID,omitempty"`
+	ModelID      string         `json:"modelID,omitempty"`
+	KubeContext  string         `json:"kubeContext,omitempty"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	LastModified time.Time      `json:"lastModified"`
+	Messages     []*api.Message `json:"messages"`
+}
+
+// ConflictPolicy is what ImportSession does when a session with the same ID already exists.
+type ConflictPolicy string
+
+const (
+	// ConflictRename imports the session under a new ID.
+	ConflictRename ConflictPolicy = "rename"
+	// ConflictMerge adds the messages missing from the existing session to it.
+	ConflictMerge ConflictPolicy = "merge"
+	// ConflictReplace replaces the existing session.
+	ConflictReplace ConflictPolicy = "replace"
+	// ConflictFail fails the import.
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// ExportSession returns the session with the given ID in a portable form.
+func (sm *SessionManager) ExportSession(id string) (*Export, error) {
+	session, err := sm.store.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	return &Export{
+		Version:      exportFormatVersion,
+		ID:           session.ID,
+		ProviderID:   session.ProviderID,
+		ModelID:      session.ModelID,
+		KubeContext:  session.KubeContext,
+		CreatedAt:    session.CreatedAt,
+		LastModified: session.LastModified,
+		Messages:     ResolveAttachments(session.ChatMessageStore, session.AllMessages()),
+	}, nil
+}
+
+// ImportSession loads an exported session into the store, handling an
+// existing session with the same ID according to the policy.
+func (sm *SessionManager) ImportSession(export *Export, policy ConflictPolicy) (*api.Session, error) {
+	if export.Version > exportFormatVersion {
+		return nil, fmt.Errorf("unsupported session export version %d, upgrade kubectl-ai to import it", export.Version)
+	}
+	// The ID names the session directory of the filesystem store.
+	if export.ID == "" || export.ID == "." || export.ID == ".." || strings.ContainsAny(export.ID, `/\`) {
+		return nil, fmt.Errorf("invalid session ID %q in session export", export.ID)
+	}
+
+	id := export.ID
+	existing, _ := sm.store.GetSession(id)
+	if existing != nil {
+		switch policy {
+		case ConflictFail:
+			return nil, fmt.Errorf("session %s already exists", id)
+		case ConflictReplace:
+			if err := sm.store.DeleteSession(id); err != nil {
+				return nil, fmt.Errorf("deleting existing session %s: %w", id, err)
+			}
+		case ConflictMerge:
+			return sm.mergeSession(existing, export)
+		case ConflictRename, "":
+			for existing != nil {
+				id = newSessionID()
+				existing, _ = sm.store.GetSession(id)
+			}
+		default:
+			return nil, fmt.Errorf("unknown conflict policy %q, supported values: rename, merge, replace, fail", policy)
+		}
+	}
+
+	session := &api.Session{
+		ID:           id,
+		Name:         "Session " + id,
+		ProviderID:   export.ProviderID,
+		ModelID:      export.ModelID,
+		KubeContext:  export.KubeContext,
+		AgentState:   api.AgentStateIdle,
+		CreatedAt:    export.CreatedAt,
+		LastModified: export.LastModified,
+	}
+	if err := sm.store.CreateSession(session); err != nil {
+		return nil, err
+	}
+	if err := session.ChatMessageStore.SetChatMessages(export.Messages); err != nil {
+		return nil, fmt.Errorf("saving messages of session %s: %w", id, err)
+	}
+	return session, nil
+}
+
+// mergeSession adds the exported messages missing from the existing session,
+// keeping the messages ordered by time.
+func (sm *SessionManager) mergeSession(existing *api.Session, export *Export) (*api.Session, error) {
+	messages := ResolveAttachments(existing.ChatMessageStore, existing.AllMessages())
+	seen := make(map[string]bool, len(messages))
+	for _, msg := range messages {
+		seen[msg.ID] = true
+	}
+	for _, msg := range export.Messages {
+		if !seen[msg.ID] {
+			messages = append(messages, msg)
+		}
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Timestamp.Before(messages[j].Timestamp)
+	})
+
+	if err := existing.ChatMessageStore.SetChatMessages(messages); err != nil {
+		return nil, fmt.Errorf("saving messages of session %s: %w", existing.ID, err)
+	}
+	if export.LastModified.After(existing.LastModified) {
+		existing.LastModified = export.LastModified
+	}
+	if err := sm.store.UpdateSession(existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
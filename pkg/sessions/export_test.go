@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessions
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+func TestImportSession(t *testing.T) {
+	start := time.Date(2025, 6, 11, 10, 0, 0, 0, time.UTC)
+	message := func(id string, minute int) *api.Message {
+		return &api.Message{ID: id, Type: api.MessageTypeText, Source: api.MessageSourceUser, Payload: "message " + id, Timestamp: start.Add(time.Duration(minute) * time.Minute)}
+	}
+
+	tests := []struct {
+		name       string
+		policy     ConflictPolicy
+		exportID   string
+		wantErr    bool
+		wantNewID  bool
+		wantIDs    []string
+		wantLocals int
+	}{
+		{name: "no conflict", policy: ConflictFail, exportID: "20250611-0002", wantIDs: []string{"a", "c"}, wantLocals: 2},
+		{name: "rename", policy: ConflictRename, exportID: "20250611-0001", wantNewID: true, wantIDs: []string{"a", "c"}, wantLocals: 2},
+		{name: "merge", policy: ConflictMerge, exportID: "20250611-0001", wantIDs: []string{"a", "b", "c"}, wantLocals: 1},
+		{name: "replace", policy: ConflictReplace, exportID: "20250611-0001", wantIDs: []string{"a", "c"}, wantLocals: 1},
+		{name: "fail", policy: ConflictFail, exportID: "20250611-0001", wantErr: true, wantLocals: 1},
+		{name: "path traversal", policy: ConflictRename, exportID: "../../etc", wantErr: true, wantLocals: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &SessionManager{store: newMemoryStore()}
+			local := &api.Session{ID: "20250611-0001", CreatedAt: start, LastModified: start}
+			if err := manager.store.CreateSession(local); err != nil {
+				t.Fatal(err)
+			}
+			local.ChatMessageStore.SetChatMessages([]*api.Message{message("a", 0), message("b", 1)})
+
+			// Round trip the export through JSON, as the CLI does.
+			data, err := json.Marshal(&Export{
+				Version:  exportFormatVersion,
+				ID:       tt.exportID,
+				Messages: []*api.Message{message("a", 0), message("c", 2)},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var export Export
+			if err := json.Unmarshal(data, &export); err != nil {
+				t.Fatal(err)
+			}
+
+			session, err := manager.ImportSession(&export, tt.policy)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ImportSession() expected an error")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("ImportSession() error: %v", err)
+				}
+				if newID := session.ID != tt.exportID; newID != tt.wantNewID {
+					t.Errorf("ImportSession() session ID = %s, export ID %s", session.ID, tt.exportID)
+				}
+				var ids []string
+				for _, msg := range session.AllMessages() {
+					ids = append(ids, msg.ID)
+				}
+				if len(ids) != len(tt.wantIDs) {
+					t.Fatalf("imported messages = %v, want %v", ids, tt.wantIDs)
+				}
+				for i := range ids {
+					if ids[i] != tt.wantIDs[i] {
+						t.Errorf("imported messages = %v, want %v", ids, tt.wantIDs)
+						break
+					}
+				}
+			}
+
+			sessions, _ := manager.ListSessions()
+			if len(sessions) != tt.wantLocals {
+				t.Errorf("store has %d sessions, want %d", len(sessions), tt.wantLocals)
+			}
+		})
+	}
+}
+
+func TestImportSessionUnsupportedVersion(t *testing.T) {
+	manager := &SessionManager{store: newMemoryStore()}
+	if _, err := manager.ImportSession(&Export{Version: exportFormatVersion + 1, ID: "20250611-0001"}, ConflictRename); err == nil {
+		t.Errorf("ImportSession() expected an error for a newer export version")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessions
+
+import (
+	"sort"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// FeedbackEntry is a rating of an answer of a session.
+type FeedbackEntry struct {
+	SessionID string `json:"sessionID"`
+	api.Feedback
+}
+
+// ModelFeedback counts the ratings of the answers of a model.
+type ModelFeedback struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Up       int    `json:"up"`
+	Down     int    `json:"down"`
+}
+
+// Approval returns the fraction of the answers rated up.
+func (m ModelFeedback) Approval() float64 {
+	if m.Up+m.Down == 0 {
+		return 0
+	}
+	return float64(m.Up) / float64(m.Up+m.Down)
+}
+
+// FeedbackReport aggregates the feedback of sessions, to measure the quality
+// of the answers over time.
+type FeedbackReport struct {
+	// Models are the ratings per provider and model, sorted by provider and model.
+	Models []ModelFeedback `json:"models"`
+	// Entries are the ratings, oldest first.
+	Entries []FeedbackEntry `json:"entries"`
+}
+
+// AggregateFeedback returns the report of the feedback of sessions given
+// since a time, all of it if since is zero.
+func AggregateFeedback(sessionList []*api.Session, since time.Time) *FeedbackReport {
+	report := &FeedbackReport{Models: []ModelFeedback{}, Entries: []FeedbackEntry{}}
+	counts := make(map[[2]string]*ModelFeedback)
+	for _, session := range sessionList {
+		for _, feedback := range session.Feedback {
+			if feedback.Time.Before(since) {
+				continue
+			}
+			report.Entries = append(report.Entries, FeedbackEntry{SessionID: session.ID, Feedback: feedback})
+			key := [2]string{feedback.Provider, feedback.Model}
+			model, ok := counts[key]
+			if !ok {
+				model = &ModelFeedback{Provider: feedback.Provider, Model: feedback.Model}
+				counts[key] = model
+			}
+			switch feedback.Rating {
+			case api.FeedbackUp:
+				model.Up++
+			case api.FeedbackDown:
+				model.Down++
+			}
+		}
+	}
+	for _, model := range counts {
+		report.Models = append(report.Models, *model)
+	}
+	sort.Slice(report.Models, func(i, j int) bool {
+		if report.Models[i].Provider != report.Models[j].Provider {
+			return report.Models[i].Provider < report.Models[j].Provider
+		}
+		return report.Models[i].Model < report.Models[j].Model
+	})
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		return report.Entries[i].Time.Before(report.Entries[j].Time)
+	})
+	return report
+}
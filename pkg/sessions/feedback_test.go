@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+func TestAggregateFeedback(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	up := func(model string, minutes int) api.Feedback {
+		return api.Feedback{Rating: api.FeedbackUp, Provider: "gemini", Model: model, Time: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+	down := func(model string, minutes int) api.Feedback {
+		return api.Feedback{Rating: api.FeedbackDown, Provider: "gemini", Model: model, Comment: "wrong namespace", Time: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+	sessionList := []*api.Session{
+		{ID: "a", Feedback: []api.Feedback{up("gemini-2.5-pro", 3), down("gemini-2.5-flash", 4)}},
+		{ID: "b"},
+		{ID: "c", Feedback: []api.Feedback{up("gemini-2.5-pro", 1), up("gemini-2.5-flash", 2)}},
+	}
+
+	report := AggregateFeedback(sessionList, time.Time{})
+	wantModels := []ModelFeedback{
+		{Provider: "gemini", Model: "gemini-2.5-flash", Up: 1, Down: 1},
+		{Provider: "gemini", Model: "gemini-2.5-pro", Up: 2},
+	}
+	if !reflect.DeepEqual(report.Models, wantModels) {
+		t.Errorf("Models = %+v, want %+v", report.Models, wantModels)
+	}
+	var order []string
+	for _, entry := range report.Entries {
+		order = append(order, entry.SessionID+"/"+entry.Model)
+	}
+	wantOrder := []string{"c/gemini-2.5-pro", "c/gemini-2.5-flash", "a/gemini-2.5-pro", "a/gemini-2.5-flash"}
+	if !reflect.DeepEqual(order, wantOrder) {
+		t.Errorf("Entries = %v, want %v", order, wantOrder)
+	}
+	if got := report.Models[0].Approval(); got != 0.5 {
+		t.Errorf("Approval() = %v, want 0.5", got)
+	}
+
+	recent := AggregateFeedback(sessionList, start.Add(3*time.Minute))
+	wantModels = []ModelFeedback{
+		{Provider: "gemini", Model: "gemini-2.5-flash", Down: 1},
+		{Provider: "gemini", Model: "gemini-2.5-pro", Up: 1},
+	}
+	if !reflect.DeepEqual(recent.Models, wantModels) || len(recent.Entries) != 2 {
+		t.Errorf("AggregateFeedback() since 3 minutes = %+v, want %+v and 2 entries", recent, wantModels)
+	}
+}
+
+func TestFeedbackPersisted(t *testing.T) {
+	store := &filesystemStore{basePath: t.TempDir()}
+	manager := &SessionManager{store: store}
+	session, err := manager.NewSession(Metadata{ProviderID: "gemini", ModelID: "gemini-2.5-pro"})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	feedback := api.Feedback{Rating: api.FeedbackDown, Comment: "too slow", MessageID: "m1", Query: "why is web failing?", Time: time.Now().UTC().Truncate(time.Second)}
+	session.Feedback = []api.Feedback{feedback}
+	if err := store.UpdateSession(session); err != nil {
+		t.Fatalf("UpdateSession: %v", err)
+	}
+	got, err := store.GetSession(session.ID)
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if !reflect.DeepEqual(got.Feedback, []api.Feedback{feedback}) {
+		t.Errorf("Feedback = %+v, want %+v", got.Feedback, session.Feedback)
+	}
+}
@@ -52,15 +52,28 @@ func (f *filesystemStore) GetSession(id string) (*api.Session, error) {
 		return nil, err
 	}
 
+	name := meta.Name
+	if name == "" {
+		name = "Session " + id
+	}
+
 	chatStore := NewFileChatMessageStore(sessionPath)
 	return &api.Session{
-		ID:               id,
-		ProviderID:       meta.ProviderID,
-		ModelID:          meta.ModelID,
-		AgentState:       api.AgentStateIdle,
-		CreatedAt:        meta.CreatedAt,
-		LastModified:     meta.LastAccessed,
-		ChatMessageStore: chatStore,
+		ID:                 id,
+		Name:               name,
+		ProviderID:         meta.ProviderID,
+		ModelID:            meta.ModelID,
+		KubeContext:        meta.KubeContext,
+		AgentState:         api.AgentStateIdle,
+		CreatedAt:          meta.CreatedAt,
+		LastModified:       meta.LastAccessed,
+		ChatMessageStore:   chatStore,
+		AllowedCommands:    meta.AllowedCommands,
+		LearnedPreferences: meta.LearnedPreferences,
+		Labels:             meta.Labels,
+		Usage:              meta.Usage,
+		ParentID:           meta.ParentID,
+		Feedback:           meta.Feedback,
 	}, nil
 }
 
@@ -74,10 +87,18 @@ func (f *filesystemStore) CreateSession(session *api.Session) error {
 	session.ChatMessageStore = chatStore
 
 	meta := Metadata{
-		ProviderID:   session.ProviderID,
-		ModelID:      session.ModelID,
-		CreatedAt:    session.CreatedAt,
-		LastAccessed: session.LastModified,
+		Name:               session.Name,
+		ProviderID:         session.ProviderID,
+		ModelID:            session.ModelID,
+		KubeContext:        session.KubeContext,
+		CreatedAt:          session.CreatedAt,
+		LastAccessed:       session.LastModified,
+		AllowedCommands:    session.AllowedCommands,
+		LearnedPreferences: session.LearnedPreferences,
+		Labels:             session.Labels,
+		Usage:              session.Usage,
+		ParentID:           session.ParentID,
+		Feedback:           session.Feedback,
 	}
 
 	data, err := yaml.Marshal(meta)
@@ -105,9 +126,17 @@ func (f *filesystemStore) UpdateSession(session *api.Session) error {
 		return err
 	}
 
+	meta.Name = session.Name
 	meta.ProviderID = session.ProviderID
 	meta.ModelID = session.ModelID
+	meta.KubeContext = session.KubeContext
 	meta.LastAccessed = session.LastModified
+	meta.AllowedCommands = session.AllowedCommands
+	meta.LearnedPreferences = session.LearnedPreferences
+	meta.Labels = session.Labels
+	meta.Usage = session.Usage
+	meta.ParentID = session.ParentID
+	meta.Feedback = session.Feedback
 
 	data, err := yaml.Marshal(meta)
 	if err != nil {
@@ -152,11 +181,15 @@ func (f *filesystemStore) DeleteSession(id string) error {
 }
 
 // FileChatMessageStore implements api.ChatMessageStore by persisting history to disk.
+// Large tool call responses are written to the attachments directory, named
+// after the SHA-256 of their content, see api.AttachmentStore.
 type FileChatMessageStore struct {
 	Path string
 	mu   sync.Mutex
 }
 
+var _ api.AttachmentStore = &FileChatMessageStore{}
+
 // NewFileChatMessageStore creates a new file-backed chat message store.
 func NewFileChatMessageStore(path string) *FileChatMessageStore {
 	return &FileChatMessageStore{Path: path}
@@ -167,6 +200,39 @@ func (s *FileChatMessageStore) HistoryPath() string {
 	return filepath.Join(s.Path, "history.json")
 }
 
+// AttachmentsPath returns the location of the attachments directory for this session.
+func (s *FileChatMessageStore) AttachmentsPath() string {
+	return filepath.Join(s.Path, "attachments")
+}
+
+// Attachment returns the payload stored under the given attachment ID.
+func (s *FileChatMessageStore) Attachment(id string) ([]byte, error) {
+	if !validAttachmentID(id) {
+		return nil, errors.New("attachment not found")
+	}
+	data, err := os.ReadFile(filepath.Join(s.AttachmentsPath(), id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.New("attachment not found")
+	}
+	return data, err
+}
+
+func (s *FileChatMessageStore) storeAttachment(record *api.Message) (*api.Message, error) {
+	record, data, err := splitAttachment(record)
+	if err != nil || data == nil {
+		return record, err
+	}
+	if err := os.MkdirAll(s.AttachmentsPath(), 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(s.AttachmentsPath(), record.AttachmentID+".json")
+	// Attachments are content-addressed, an existing file already holds the same payload.
+	if _, err := os.Stat(path); err == nil {
+		return record, nil
+	}
+	return record, os.WriteFile(path, data, 0o644)
+}
+
 // AddChatMessage appends a message to the existing history on disk.
 func (s *FileChatMessageStore) AddChatMessage(record *api.Message) error {
 	s.mu.Lock()
@@ -177,6 +243,11 @@ func (s *FileChatMessageStore) AddChatMessage(record *api.Message) error {
 		return err
 	}
 
+	record, err := s.storeAttachment(record)
+	if err != nil {
+		return err
+	}
+
 	path := s.HistoryPath()
 
 	// Check for legacy format and migrate if needed
@@ -240,11 +311,15 @@ func (s *FileChatMessageStore) ChatMessages() []*api.Message {
 	return messages
 }
 
-// ClearChatMessages truncates the history file, leaving an empty array.
+// ClearChatMessages truncates the history file, leaving an empty array,
+// and removes the attachments.
 func (s *FileChatMessageStore) ClearChatMessages() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := os.RemoveAll(s.AttachmentsPath()); err != nil {
+		return err
+	}
 	return s.writeMessages([]*api.Message{})
 }
 
@@ -325,6 +400,10 @@ func (s *FileChatMessageStore) writeMessages(messages []*api.Message) error {
 	defer f.Close()
 
 	for _, msg := range messages {
+		msg, err := s.storeAttachment(msg)
+		if err != nil {
+			return err
+		}
 		data, err := json.Marshal(msg)
 		if err != nil {
 			return err
@@ -0,0 +1,52 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessions
+
+import "testing"
+
+func TestSessionName(t *testing.T) {
+	manager := &SessionManager{store: &filesystemStore{basePath: t.TempDir()}}
+	tests := []struct {
+		name     string
+		meta     Metadata
+		renameTo string
+		wantName func(id string) string
+	}{
+		{name: "default name", wantName: func(id string) string { return "Session " + id }},
+		{name: "named", meta: Metadata{Name: "web outage"}, wantName: func(string) string { return "web outage" }},
+		{name: "renamed", meta: Metadata{Name: "web outage"}, renameTo: "web outage, fixed", wantName: func(string) string { return "web outage, fixed" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, err := manager.NewSession(tt.meta)
+			if err != nil {
+				t.Fatalf("NewSession: %v", err)
+			}
+			if tt.renameTo != "" {
+				session.Name = tt.renameTo
+				if err := manager.UpdateLastAccessed(session); err != nil {
+					t.Fatalf("UpdateLastAccessed: %v", err)
+				}
+			}
+			got, err := manager.FindSessionByID(session.ID)
+			if err != nil {
+				t.Fatalf("FindSessionByID: %v", err)
+			}
+			if want := tt.wantName(session.ID); got.Name != want {
+				t.Errorf("Name = %q, want %q", got.Name, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessions
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// ForkSession creates a new session with the history, the allowed commands
+// and the learned preferences of the session with the given ID, to explore
+// an alternative without changing it. The token usage of the fork starts
+// from zero.
+func (sm *SessionManager) ForkSession(id string) (*api.Session, error) {
+	parent, err := sm.store.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	fork, err := sm.NewSession(Metadata{
+		ProviderID:  parent.ProviderID,
+		ModelID:     parent.ModelID,
+		KubeContext: parent.KubeContext,
+		Labels:      maps.Clone(parent.Labels),
+		ParentID:    parent.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating the fork of session %s: %w", id, err)
+	}
+	fork.AllowedCommands = slices.Clone(parent.AllowedCommands)
+	fork.LearnedPreferences = slices.Clone(parent.LearnedPreferences)
+
+	// Load the attachments back, so the fork stores its own copy.
+	messages := ResolveAttachments(parent.ChatMessageStore, parent.ChatMessageStore.ChatMessages())
+	if err := fork.ChatMessageStore.SetChatMessages(messages); err != nil {
+		return nil, fmt.Errorf("copying the history of session %s: %w", id, err)
+	}
+	if err := sm.store.UpdateSession(fork); err != nil {
+		return nil, fmt.Errorf("saving the fork of session %s: %w", id, err)
+	}
+	return fork, nil
+}
+
+// TreeEntry is a session in the tree of the forks of the sessions.
+type TreeEntry struct {
+	Session *api.Session
+	// Prefix draws the branches of the tree before the session, e.g. "│   └── ".
+	Prefix string
+}
+
+// Tree returns the sessions as the tree of their forks: each session is
+// followed by its forks, oldest first. The sessions whose parent is not
+// listed are roots, kept in their order.
+func Tree(sessions []*api.Session) []TreeEntry {
+	listed := make(map[string]bool, len(sessions))
+	for _, session := range sessions {
+		listed[session.ID] = true
+	}
+	var roots []*api.Session
+	forks := make(map[string][]*api.Session)
+	for _, session := range sessions {
+		if session.ParentID == "" || !listed[session.ParentID] {
+			roots = append(roots, session)
+			continue
+		}
+		forks[session.ParentID] = append(forks[session.ParentID], session)
+	}
+	for _, children := range forks {
+		sort.SliceStable(children, func(i, j int) bool {
+			return children[i].CreatedAt.Before(children[j].CreatedAt)
+		})
+	}
+
+	var entries []TreeEntry
+	visited := make(map[string]bool, len(sessions))
+	var walk func(session *api.Session, prefix, indent string)
+	walk = func(session *api.Session, prefix, indent string) {
+		if visited[session.ID] {
+			return
+		}
+		visited[session.ID] = true
+		entries = append(entries, TreeEntry{Session: session, Prefix: prefix})
+		children := forks[session.ID]
+		for i, child := range children {
+			if i == len(children)-1 {
+				walk(child, indent+"└── ", indent+"    ")
+			} else {
+				walk(child, indent+"├── ", indent+"│   ")
+			}
+		}
+	}
+	for _, root := range roots {
+		walk(root, "", "")
+	}
+	// Sessions forked from each other, e.g. edited by hand, are roots too.
+	for _, session := range sessions {
+		walk(session, "", "")
+	}
+	return entries
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessions
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+func TestForkSession(t *testing.T) {
+	manager := &SessionManager{store: newMemoryStore()}
+	parent := &api.Session{
+		ID:              "20250611-0001",
+		ModelID:         "gemini-2.5-pro",
+		AllowedCommands: []string{"kubectl scale *"},
+		Usage:           []api.ModelUsage{{Provider: "gemini", Model: "gemini-2.5-pro", InputTokens: 100}},
+	}
+	if err := manager.store.CreateSession(parent); err != nil {
+		t.Fatal(err)
+	}
+	parent.ChatMessageStore.SetChatMessages([]*api.Message{
+		{ID: "a", Type: api.MessageTypeText, Source: api.MessageSourceUser, Payload: "why is web pending?"},
+	})
+
+	fork, err := manager.ForkSession(parent.ID)
+	if err != nil {
+		t.Fatalf("ForkSession() error: %v", err)
+	}
+	if fork.ID == parent.ID || fork.ParentID != parent.ID {
+		t.Errorf("fork ID = %s, parent ID = %s, want a new session forked from %s", fork.ID, fork.ParentID, parent.ID)
+	}
+	if fork.ModelID != parent.ModelID || !slices.Equal(fork.AllowedCommands, parent.AllowedCommands) || len(fork.Usage) != 0 {
+		t.Errorf("fork = %+v, want the model and the allowed commands of the parent, and no usage", fork)
+	}
+	if got := fork.ChatMessageStore.ChatMessages(); len(got) != 1 || got[0].ID != "a" {
+		t.Errorf("fork history = %v, want the history of the parent", got)
+	}
+
+	// The sessions are independent.
+	fork.ChatMessageStore.AddChatMessage(&api.Message{ID: "b", Type: api.MessageTypeText, Payload: "scale the nodes"})
+	if got := len(parent.ChatMessageStore.ChatMessages()); got != 1 {
+		t.Errorf("parent history has %d messages after the fork continued, want 1", got)
+	}
+
+	if _, err := manager.ForkSession("missing"); err == nil {
+		t.Errorf("ForkSession() of a missing session expected an error")
+	}
+}
+
+func TestTree(t *testing.T) {
+	start := time.Date(2025, 6, 11, 10, 0, 0, 0, time.UTC)
+	session := func(id, parentID string, minute int) *api.Session {
+		return &api.Session{ID: id, ParentID: parentID, CreatedAt: start.Add(time.Duration(minute) * time.Minute)}
+	}
+	entries := Tree([]*api.Session{
+		session("d", "b", 3),
+		session("c", "a", 4),
+		session("a", "", 0),
+		session("b", "a", 1),
+		session("e", "deleted", 2),
+		session("f", "b", 5),
+	})
+
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Prefix+entry.Session.ID)
+	}
+	want := []string{
+		"a",
+		"├── b",
+		"│   ├── d",
+		"│   └── f",
+		"└── c",
+		"e",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Tree() =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -48,18 +48,25 @@ func NewSessionManager(backend string) (*SessionManager, error) {
 }
 
 func (sm *SessionManager) NewSession(meta Metadata) (*api.Session, error) {
-	suffix := fmt.Sprintf("%04d", rand.Intn(10000))
-	sessionID := time.Now().Format("20060102") + "-" + suffix
+	sessionID := newSessionID()
+
+	name := meta.Name
+	if name == "" {
+		name = "Session " + sessionID
+	}
 
 	now := time.Now()
 	session := &api.Session{
 		ID:           sessionID,
-		Name:         "Session " + sessionID,
+		Name:         name,
 		ProviderID:   meta.ProviderID,
 		ModelID:      meta.ModelID,
+		KubeContext:  meta.KubeContext,
 		AgentState:   api.AgentStateIdle,
 		CreatedAt:    now,
 		LastModified: now,
+		Labels:       meta.Labels,
+		ParentID:     meta.ParentID,
 	}
 
 	if err := sm.store.CreateSession(session); err != nil {
@@ -69,6 +76,11 @@ func (sm *SessionManager) NewSession(meta Metadata) (*api.Session, error) {
 	return session, nil
 }
 
+func newSessionID() string {
+	suffix := fmt.Sprintf("%04d", rand.Intn(10000))
+	return time.Now().Format("20060102") + "-" + suffix
+}
+
 func (sm *SessionManager) ListSessions() ([]*api.Session, error) {
 	return sm.store.ListSessions()
 }
@@ -100,15 +100,20 @@ func (m *memoryStore) DeleteSession(id string) error {
 
 // InMemoryChatStore is an in-memory implementation of the api.ChatMessageStore interface.
 // It stores chat messages in a slice and is safe for concurrent use.
+// Large tool call responses are kept as attachments, see api.AttachmentStore.
 type InMemoryChatStore struct {
-	mu       sync.RWMutex
-	messages []*api.Message
+	mu          sync.RWMutex
+	messages    []*api.Message
+	attachments map[string][]byte
 }
 
+var _ api.AttachmentStore = &InMemoryChatStore{}
+
 // NewInMemoryChatStore creates a new InMemoryChatStore.
 func NewInMemoryChatStore() *InMemoryChatStore {
 	return &InMemoryChatStore{
-		messages: make([]*api.Message, 0),
+		messages:    make([]*api.Message, 0),
+		attachments: make(map[string][]byte),
 	}
 }
 
@@ -116,6 +121,10 @@ func NewInMemoryChatStore() *InMemoryChatStore {
 func (s *InMemoryChatStore) AddChatMessage(record *api.Message) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	record, err := s.storeAttachment(record)
+	if err != nil {
+		return err
+	}
 	s.messages = append(s.messages, record)
 	return nil
 }
@@ -124,10 +133,40 @@ func (s *InMemoryChatStore) AddChatMessage(record *api.Message) error {
 func (s *InMemoryChatStore) SetChatMessages(newHistory []*api.Message) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.messages = newHistory
+	messages := make([]*api.Message, len(newHistory))
+	for i, record := range newHistory {
+		var err error
+		if messages[i], err = s.storeAttachment(record); err != nil {
+			return err
+		}
+	}
+	s.messages = messages
 	return nil
 }
 
+func (s *InMemoryChatStore) storeAttachment(record *api.Message) (*api.Message, error) {
+	record, data, err := splitAttachment(record)
+	if err != nil || data == nil {
+		return record, err
+	}
+	if s.attachments == nil {
+		s.attachments = make(map[string][]byte)
+	}
+	s.attachments[record.AttachmentID] = data
+	return record, nil
+}
+
+// Attachment returns the payload stored under the given attachment ID.
+func (s *InMemoryChatStore) Attachment(id string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.attachments[id]
+	if !ok {
+		return nil, errors.New("attachment not found")
+	}
+	return data, nil
+}
+
 // ChatMessages returns all chat messages from the store.
 func (s *InMemoryChatStore) ChatMessages() []*api.Message {
 	s.mu.RLock()
@@ -142,5 +181,6 @@ func (s *InMemoryChatStore) ClearChatMessages() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.messages = make([]*api.Message, 0)
+	s.attachments = make(map[string][]byte)
 	return nil
 }
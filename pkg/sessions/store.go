@@ -27,10 +27,26 @@ import (
 const sessionsDirName = "sessions"
 
 type Metadata struct {
-	ProviderID   string    `json:"providerID"`
-	ModelID      string    `json:"modelID"`
+	// Name is the name of the session shown to the user, "Session <id>" if empty.
+	Name       string `json:"name,omitempty"`
+	ProviderID string `json:"providerID"`
+	ModelID    string `json:"modelID"`
+	// KubeContext is the kubeconfig context the session was started with.
+	KubeContext  string    `json:"kubeContext,omitempty"`
 	CreatedAt    time.Time `json:"createdAt"`
 	LastAccessed time.Time `json:"lastAccessed"`
+	// AllowedCommands are the command patterns always allowed in the session.
+	AllowedCommands []string `json:"allowedCommands,omitempty"`
+	// LearnedPreferences are the preferences learned from the corrections of the user.
+	LearnedPreferences []string `json:"learnedPreferences,omitempty"`
+	// Labels attach the session to other systems, e.g. a Backstage entity.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Usage is the token usage of the session, per provider and model.
+	Usage []api.ModelUsage `json:"usage,omitempty"`
+	// ParentID is the ID of the session this session was forked from, if any.
+	ParentID string `json:"parentID,omitempty"`
+	// Feedback are the ratings of the answers of the session by the user.
+	Feedback []api.Feedback `json:"feedback,omitempty"`
 }
 
 var defaultMemoryStore Store = newMemoryStore()
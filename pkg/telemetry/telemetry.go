@@ -0,0 +1,234 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry implements the opt-in anonymous usage metrics of kubectl-ai.
+//
+// Telemetry is off by default. When enabled, one Event is sent per command run.
+// Events never contain queries, model output, tool output, cluster data or
+// error messages; see docs/telemetry.md for the schema.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"k8s.io/klog/v2"
+)
+
+// SchemaVersion is the version of the Event schema documented in docs/telemetry.md.
+const SchemaVersion = 1
+
+// Mode is the telemetry mode.
+type Mode string
+
+const (
+	// ModeOff disables telemetry. This is the default.
+	ModeOff Mode = "off"
+	// ModeOn sends the events to the telemetry endpoint.
+	ModeOn Mode = "on"
+	// ModeLog only writes the events to the kubectl-ai log, to inspect what would be sent.
+	ModeLog Mode = "log"
+)
+
+// Error categories reported instead of error messages.
+const (
+	ErrorCategoryNone     = ""
+	ErrorCategoryCanceled = "canceled"
+	ErrorCategoryTimeout  = "timeout"
+	ErrorCategoryNetwork  = "network"
+	ErrorCategoryAuth     = "auth"
+	ErrorCategoryUsage    = "usage"
+	ErrorCategoryOther    = "other"
+)
+
+// Event is the anonymous usage record of a command run.
+type Event struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	InstallID     string `json:"installID"`
+	Version       string `json:"version"`
+	OS            string `json:"os"`
+	Arch          string `json:"arch"`
+	// Command is the subcommand run, e.g. "kubectl-ai" or "kubectl-ai report capacity".
+	Command string `json:"command"`
+	// Provider is the LLM provider type, e.g. "gemini". Provider URLs are reduced to their scheme.
+	Provider string `json:"provider,omitempty"`
+	// UIType is the user interface of an interactive run.
+	UIType        string    `json:"uiType,omitempty"`
+	ErrorCategory string    `json:"errorCategory,omitempty"`
+	DurationMS    int64     `json:"durationMS"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// Client records telemetry events.
+type Client struct {
+	mode       Mode
+	endpoint   string
+	installID  string
+	version    string
+	httpClient *http.Client
+}
+
+// New returns a telemetry client for the mode. The endpoint is required when
+// telemetry is on. The anonymous install ID is stored in configDir.
+func New(mode Mode, endpoint, configDir, version string) (*Client, error) {
+	c := &Client{mode: mode, endpoint: endpoint, version: version, httpClient: &http.Client{Timeout: 3 * time.Second}}
+	switch mode {
+	case ModeOff, "":
+		c.mode = ModeOff
+		return c, nil
+	case ModeOn:
+		if endpoint == "" {
+			return nil, fmt.Errorf("--telemetry=on requires a telemetry endpoint (telemetryEndpoint in the config file)")
+		}
+	case ModeLog:
+	default:
+		return nil, fmt.Errorf("invalid telemetry mode %q, supported values: off, on, log", mode)
+	}
+
+	installID, err := loadInstallID(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("loading telemetry install ID: %w", err)
+	}
+	c.installID = installID
+	return c, nil
+}
+
+// Enabled reports whether events are recorded.
+func (c *Client) Enabled() bool {
+	return c != nil && c.mode != ModeOff
+}
+
+// NewEvent returns an event of the command run, with the fields common to all events set.
+func (c *Client) NewEvent(command, provider, uiType string, duration time.Duration, err error) Event {
+	return Event{
+		SchemaVersion: SchemaVersion,
+		InstallID:     c.installID,
+		Version:       c.version,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		Command:       command,
+		Provider:      ProviderType(provider),
+		UIType:        uiType,
+		ErrorCategory: ErrorCategory(err),
+		DurationMS:    duration.Milliseconds(),
+		Timestamp:     time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// Record sends the event. Failures are logged and never returned, telemetry
+// must not affect the command.
+func (c *Client) Record(ctx context.Context, event Event) {
+	if !c.Enabled() {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		klog.Warningf("encoding telemetry event: %v", err)
+		return
+	}
+	if c.mode == ModeLog {
+		klog.Infof("telemetry event (not sent): %s", data)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(data))
+	if err != nil {
+		klog.Warningf("creating telemetry request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		klog.V(1).Infof("sending telemetry event: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		klog.V(1).Infof("sending telemetry event: endpoint returned %s", resp.Status)
+	}
+}
+
+// ProviderType reduces a provider ID to its type, dropping the endpoint of
+// provider URLs such as "openai://my-endpoint".
+func ProviderType(provider string) string {
+	if scheme, _, ok := strings.Cut(provider, "://"); ok {
+		return scheme
+	}
+	return provider
+}
+
+// ErrorCategory classifies an error without revealing its message.
+func ErrorCategory(err error) string {
+	if err == nil {
+		return ErrorCategoryNone
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorCategoryCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorCategoryTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorCategoryTimeout
+		}
+		return ErrorCategoryNetwork
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"unauthorized", "unauthenticated", "forbidden", "permission denied", "api key", "401", "403"} {
+		if strings.Contains(msg, s) {
+			return ErrorCategoryAuth
+		}
+	}
+	for _, s := range []string{"unknown flag", "unknown command", "invalid --", "required flag", "accepts ", "parsing configuration"} {
+		if strings.Contains(msg, s) {
+			return ErrorCategoryUsage
+		}
+	}
+	return ErrorCategoryOther
+}
+
+// loadInstallID returns the random ID identifying this installation, creating it on first use.
+func loadInstallID(configDir string) (string, error) {
+	path := filepath.Join(configDir, "telemetry-id")
+	if b, err := os.ReadFile(path); err == nil {
+		if id := strings.TrimSpace(string(b)); id != "" {
+			return id, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	id := uuid.New().String()
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(id+"\n"), 0o644); err != nil {
+		return "", err
+	}
+	return id, nil
+}
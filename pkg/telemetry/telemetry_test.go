@@ -0,0 +1,98 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorCategory(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: ErrorCategoryNone},
+		{err: fmt.Errorf("running agent: %w", context.Canceled), want: ErrorCategoryCanceled},
+		{err: context.DeadlineExceeded, want: ErrorCategoryTimeout},
+		{err: errors.New("googleapi: Error 403: Permission denied on project my-secret-project"), want: ErrorCategoryAuth},
+		{err: errors.New("unknown flag: --foo"), want: ErrorCategoryUsage},
+		{err: errors.New("pod checkout-7d9f not found"), want: ErrorCategoryOther},
+	}
+	for _, tt := range tests {
+		if got := ErrorCategory(tt.err); got != tt.want {
+			t.Errorf("ErrorCategory(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRecordSendsAnonymousEvent(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	configDir := t.TempDir()
+	client, err := New(ModeOn, server.URL, configDir, "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Record(context.Background(), client.NewEvent("kubectl-ai", "openai://internal-gateway.corp", "terminal", 2*time.Second, errors.New("listing pods in namespace payments: connection refused")))
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatalf("decoding event %q: %v", body, err)
+	}
+	if event.Provider != "openai" || event.ErrorCategory != ErrorCategoryOther || event.DurationMS != 2000 || event.InstallID == "" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	for _, leaked := range []string{"internal-gateway", "payments", "connection refused"} {
+		if strings.Contains(string(body), leaked) {
+			t.Errorf("event %s contains %q", body, leaked)
+		}
+	}
+
+	// The install ID is stable across runs.
+	again, err := New(ModeLog, "", configDir, "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.installID != event.InstallID {
+		t.Errorf("install ID changed from %q to %q", event.InstallID, again.installID)
+	}
+}
+
+func TestNewRejectsMissingEndpoint(t *testing.T) {
+	if _, err := New(ModeOn, "", t.TempDir(), "1.2.3"); err == nil {
+		t.Error("expected an error for --telemetry=on without an endpoint")
+	}
+	client, err := New(ModeOff, "", t.TempDir(), "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Enabled() {
+		t.Error("telemetry is enabled with mode off")
+	}
+}
@@ -0,0 +1,377 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	defaultAlertsLimit = 50
+	// alertmanagerPort is the port Alertmanager listens on by default.
+	alertmanagerPort = 9093
+)
+
+// alertObjectLabels are the labels of the alerts of kube-state-metrics and
+// the kubelet identifying the object an alert is about, by kind.
+var alertObjectLabels = []struct{ label, kind string }{
+	{"pod", "pod"},
+	{"deployment", "deployment"},
+	{"statefulset", "statefulset"},
+	{"daemonset", "daemonset"},
+	{"job_name", "job"},
+	{"cronjob", "cronjob"},
+	{"horizontalpodautoscaler", "hpa"},
+	{"persistentvolumeclaim", "pvc"},
+	{"node", "node"},
+}
+
+// alertMatcherPattern matches the matchers of the labels filter, e.g.
+// severity="critical" or alertname=~"KubePod.*".
+var alertMatcherPattern = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*"?(.*?)"?\s*$`)
+
+// AlertmanagerTool returns the alerts firing in Alertmanager, reached through
+// the service proxy of the API server, so that the model can correlate them
+// with the state of the cluster.
+type AlertmanagerTool struct {
+	executor sandbox.Executor
+	// service is the Alertmanager service as namespace/name:port, discovered if empty.
+	service string
+}
+
+// NewAlertmanagerTool returns the alerts tool. The service is the Alertmanager
+// service as namespace/name:port, e.g. "monitoring/alertmanager-operated:9093",
+// it is discovered if empty.
+func NewAlertmanagerTool(executor sandbox.Executor, service string) *AlertmanagerTool {
+	return &AlertmanagerTool{executor: executor, service: service}
+}
+
+func (t *AlertmanagerTool) Name() string {
+	return "alerts"
+}
+
+func (t *AlertmanagerTool) Description() string {
+	return `Returns the alerts currently firing in the Alertmanager of the cluster, with their labels, summary and description, from the most to the least severe.
+The alerts can be filtered by namespace and by label matchers.
+Use it to answer what is alerting and why, then check the objects the alerts are about with kubectl.`
+}
+
+func (t *AlertmanagerTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"namespace": {
+					Type:        gollm.TypeString,
+					Description: `Only return the alerts with this namespace label.`,
+				},
+				"labels": {
+					Type:        gollm.TypeString,
+					Description: `Only return the alerts matching these label matchers, comma-separated, e.g. 'severity="critical",alertname=~"KubePod.*"'.`,
+				},
+				"include_silenced": {
+					Type:        gollm.TypeBoolean,
+					Description: `Also return the silenced and inhibited alerts.`,
+				},
+				"limit": {
+					Type:        gollm.TypeInteger,
+					Description: `The maximum number of alerts, the most severe ones are kept. Defaults to 50.`,
+				},
+			},
+		},
+	}
+}
+
+// AlertsResult is the result of the alerts tool.
+type AlertsResult struct {
+	// Alertmanager is the Alertmanager service, as namespace/name:port.
+	Alertmanager string        `json:"alertmanager,omitempty"`
+	Alerts       []FiringAlert `json:"alerts"`
+	// Omitted is the number of less severe alerts left out by the limit.
+	Omitted int    `json:"omitted,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// FiringAlert is an alert firing in Alertmanager.
+type FiringAlert struct {
+	Name      string `json:"name"`
+	Severity  string `json:"severity,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// Object is the object the alert is about according to its labels, e.g. "pod/web-0".
+	Object      string    `json:"object,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+	Description string    `json:"description,omitempty"`
+	StartsAt    time.Time `json:"startsAt"`
+	// State is "active", or "suppressed" for the silenced and inhibited alerts.
+	State string `json:"state"`
+	// Labels are the other labels of the alert.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// alertmanagerAlert is an alert of the Alertmanager API v2.
+type alertmanagerAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	Status      struct {
+		State string `json:"state"`
+	} `json:"status"`
+}
+
+func (t *AlertmanagerTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	namespace, _ := args["namespace"].(string)
+	labels, _ := args["labels"].(string)
+	includeSilenced, _ := args["include_silenced"].(bool)
+	limit := defaultAlertsLimit
+	if n := intArg(args["limit"]); n > 0 {
+		limit = n
+	}
+
+	result := &AlertsResult{Alerts: []FiringAlert{}}
+	filters, err := alertFilters(namespace, labels)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	service := t.service
+	if service == "" {
+		items, err := getKubectlItems(ctx, t.executor, "kubectl get services --all-namespaces -o json", env, workDir)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		if service = discoverAlertmanager(items); service == "" {
+			result.Error = "no Alertmanager service found in the cluster, set alertmanager to its service as namespace/name:port in the config file"
+			return result, nil
+		}
+	}
+	result.Alertmanager = service
+	path, err := alertsProxyPath(service, filters, includeSilenced)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	output, err := getRaw(ctx, t.executor, path, env, workDir)
+	if err != nil {
+		result.Error = fmt.Sprintf("querying Alertmanager %s: %v", service, err)
+		return result, nil
+	}
+	var alerts []alertmanagerAlert
+	if err := json.Unmarshal([]byte(output), &alerts); err != nil {
+		result.Error = fmt.Sprintf("parsing the alerts of Alertmanager %s: %v", service, err)
+		return result, nil
+	}
+	result.Alerts, result.Omitted = firingAlerts(alerts, limit)
+	return result, nil
+}
+
+// alertFilters returns the matchers of the filter parameter of the Alertmanager API.
+func alertFilters(namespace, labels string) ([]string, error) {
+	var filters []string
+	if namespace = strings.TrimSpace(namespace); namespace != "" {
+		filters = append(filters, fmt.Sprintf("namespace=%q", namespace))
+	}
+	for _, matcher := range strings.Split(labels, ",") {
+		if strings.TrimSpace(matcher) == "" {
+			continue
+		}
+		m := alertMatcherPattern.FindStringSubmatch(matcher)
+		if m == nil {
+			return nil, fmt.Errorf("invalid label matcher %q, expected e.g. severity=\"critical\"", strings.TrimSpace(matcher))
+		}
+		filters = append(filters, fmt.Sprintf("%s%s%q", m[1], m[2], m[3]))
+	}
+	return filters, nil
+}
+
+// alertsProxyPath returns the path of the alerts of the Alertmanager service
+// through the service proxy of the API server.
+func alertsProxyPath(service string, filters []string, includeSilenced bool) (string, error) {
+	query := url.Values{"active": {"true"}}
+	if !includeSilenced {
+		query.Set("silenced", "false")
+		query.Set("inhibited", "false")
+	}
+	for _, filter := range filters {
+		query.Add("filter", filter)
+	}
+	return serviceProxyPath(service, alertmanagerPort, "/api/v2/alerts", query)
+}
+
+// serviceProxyPath returns the path of a request to a service, given as
+// namespace/name:port, through the service proxy of the API server.
+func serviceProxyPath(service string, defaultPort int, path string, query url.Values) (string, error) {
+	namespace, name, ok := strings.Cut(service, "/")
+	if !ok || namespace == "" || name == "" {
+		return "", fmt.Errorf("invalid service %q, expected namespace/name:port", service)
+	}
+	port := strconv.Itoa(defaultPort)
+	if n, p, ok := strings.Cut(name, ":"); ok {
+		name, port = n, p
+	}
+	return fmt.Sprintf("/api/v1/namespaces/%s/services/%s:%s/proxy%s?%s", url.PathEscape(namespace), url.PathEscape(name), url.PathEscape(port), path, query.Encode()), nil
+}
+
+// getRaw runs "kubectl get --raw" with the tool environment and returns its output.
+func getRaw(ctx context.Context, executor sandbox.Executor, path string, env []string, workDir string) (string, error) {
+	command := "kubectl get --raw " + ShellQuote(path)
+	if err := consumeAPICalls(ctx, command); err != nil {
+		return "", err
+	}
+	execResult, err := executor.Execute(ctx, command, env, workDir)
+	if err != nil {
+		return "", err
+	}
+	if execResult.ExitCode != 0 || execResult.Error != "" {
+		return "", fmt.Errorf("%s", strings.TrimSpace(execResult.Stderr+" "+execResult.Error))
+	}
+	return execResult.Stdout, nil
+}
+
+// discoverAlertmanager returns the Alertmanager service of the cluster as
+// namespace/name:port, e.g. the alertmanager-operated service of the
+// Prometheus operator, or the alertmanager service of the Prometheus charts.
+func discoverAlertmanager(services []unstructured.Unstructured) string {
+	best, bestRank := "", 0
+	for _, svc := range services {
+		name := svc.GetName()
+		rank := 0
+		switch {
+		case name == "alertmanager-operated":
+			rank = 3
+		case name == "alertmanager" || strings.HasSuffix(name, "-alertmanager"):
+			rank = 2
+		case strings.Contains(name, "alertmanager"):
+			rank = 1
+		}
+		if rank <= bestRank {
+			continue
+		}
+		ports, _, _ := unstructured.NestedSlice(svc.Object, "spec", "ports")
+		port := ""
+		for _, p := range ports {
+			p, _ := p.(map[string]any)
+			number, _, _ := unstructured.NestedInt64(p, "port")
+			portName, _, _ := unstructured.NestedString(p, "name")
+			if number == alertmanagerPort || portName == "web" || portName == "http-web" || portName == "http" {
+				port = strconv.FormatInt(number, 10)
+				break
+			}
+		}
+		if port == "" {
+			continue
+		}
+		best, bestRank = svc.GetNamespace()+"/"+name+":"+port, rank
+	}
+	return best
+}
+
+// firingAlerts returns the alerts from the most to the least severe, and the
+// most recent first for the same severity. Only the limit first alerts are
+// returned, with the number of the omitted ones.
+func firingAlerts(alerts []alertmanagerAlert, limit int) ([]FiringAlert, int) {
+	result := make([]FiringAlert, 0, len(alerts))
+	for _, alert := range alerts {
+		labels := map[string]string{}
+		for name, value := range alert.Labels {
+			labels[name] = value
+		}
+		a := FiringAlert{
+			Name:        labels["alertname"],
+			Severity:    labels["severity"],
+			Namespace:   labels["namespace"],
+			Summary:     alert.Annotations["summary"],
+			Description: alert.Annotations["description"],
+			StartsAt:    alert.StartsAt,
+			State:       alert.Status.State,
+		}
+		if a.Summary == "" {
+			a.Summary = alert.Annotations["message"]
+		}
+		delete(labels, "alertname")
+		delete(labels, "severity")
+		delete(labels, "namespace")
+		for _, l := range alertObjectLabels {
+			if value := labels[l.label]; value != "" {
+				a.Object = l.kind + "/" + value
+				break
+			}
+		}
+		if len(labels) > 0 {
+			a.Labels = labels
+		}
+		result = append(result, a)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if ri, rj := severityRank(result[i].Severity), severityRank(result[j].Severity); ri != rj {
+			return ri < rj
+		}
+		return result[i].StartsAt.After(result[j].StartsAt)
+	})
+	if len(result) > limit {
+		return result[:limit], len(result) - limit
+	}
+	return result, 0
+}
+
+// severityRank orders the severities of the alerts, the most severe first.
+func severityRank(severity string) int {
+	switch strings.ToLower(severity) {
+	case "critical", "page":
+		return 0
+	case "error", "high":
+		return 1
+	case "warning", "medium":
+		return 2
+	case "info", "low":
+		return 4
+	case "none":
+		return 5
+	}
+	return 3
+}
+
+func (t *AlertmanagerTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "no", the alerts tool only reads alerts.
+func (t *AlertmanagerTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
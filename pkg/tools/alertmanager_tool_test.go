@@ -0,0 +1,118 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAlertmanagerTool(t *testing.T) {
+	executor := &scriptedExecutor{outputs: map[string]string{
+		"kubectl get services --all-namespaces -o json": `{"kind": "List", "items": [
+			{"kind": "Service", "metadata": {"name": "prometheus-alertmanager", "namespace": "prometheus"}, "spec": {"ports": [{"name": "http", "port": 80}]}},
+			{"kind": "Service", "metadata": {"name": "alertmanager-operated", "namespace": "monitoring"}, "spec": {"ports": [{"name": "web", "port": 9093}, {"name": "tcp-mesh", "port": 9094}]}},
+			{"kind": "Service", "metadata": {"name": "web", "namespace": "shop"}, "spec": {"ports": [{"port": 9093}]}}]}`,
+		"kubectl get --raw '/api/v1/namespaces/monitoring/services/alertmanager-operated:9093/proxy/api/v2/alerts?active=true&filter=namespace%3D%22shop%22&filter=severity%3D~%22critical%7Cwarning%22&inhibited=false&silenced=false'": `[
+			{"labels": {"alertname": "KubePodNotReady", "severity": "warning", "namespace": "shop", "pod": "web-0"},
+			 "annotations": {"summary": "Pod has been in a non-ready state for more than 15 minutes."}, "startsAt": "2025-06-01T11:00:00Z", "status": {"state": "active"}},
+			{"labels": {"alertname": "KubePodCrashLooping", "severity": "critical", "namespace": "shop", "pod": "web-1", "container": "app"},
+			 "annotations": {"description": "Pod shop/web-1 (app) is in waiting state (reason: \"CrashLoopBackOff\")."}, "startsAt": "2025-06-01T11:30:00Z", "status": {"state": "active"}}]`,
+	}}
+	ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+	ctx = context.WithValue(ctx, WorkDirKey, t.TempDir())
+
+	got, err := NewAlertmanagerTool(executor, "").Run(ctx, map[string]any{"namespace": "shop", "labels": `severity=~"critical|warning"`})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := &AlertsResult{
+		Alertmanager: "monitoring/alertmanager-operated:9093",
+		Alerts: []FiringAlert{
+			{Name: "KubePodCrashLooping", Severity: "critical", Namespace: "shop", Object: "pod/web-1", Description: `Pod shop/web-1 (app) is in waiting state (reason: "CrashLoopBackOff").`,
+				StartsAt: time.Date(2025, 6, 1, 11, 30, 0, 0, time.UTC), State: "active", Labels: map[string]string{"pod": "web-1", "container": "app"}},
+			{Name: "KubePodNotReady", Severity: "warning", Namespace: "shop", Object: "pod/web-0", Summary: "Pod has been in a non-ready state for more than 15 minutes.",
+				StartsAt: time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC), State: "active", Labels: map[string]string{"pod": "web-0"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Run() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAlertsProxyPath(t *testing.T) {
+	tests := []struct {
+		name            string
+		service         string
+		namespace       string
+		labels          string
+		includeSilenced bool
+		want            string
+		wantErr         bool
+	}{
+		{
+			name:    "default port",
+			service: "monitoring/alertmanager",
+			want:    "/api/v1/namespaces/monitoring/services/alertmanager:9093/proxy/api/v2/alerts?active=true&inhibited=false&silenced=false",
+		},
+		{
+			name:            "matchers and silenced alerts",
+			service:         "prometheus/prometheus-alertmanager:80",
+			labels:          `alertname!=Watchdog, team=payments`,
+			includeSilenced: true,
+			want:            "/api/v1/namespaces/prometheus/services/prometheus-alertmanager:80/proxy/api/v2/alerts?active=true&filter=alertname%21%3D%22Watchdog%22&filter=team%3D%22payments%22",
+		},
+		{name: "invalid matcher", service: "monitoring/alertmanager", labels: "critical", wantErr: true},
+		{name: "invalid service", service: "alertmanager:9093", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := alertFilters(tt.namespace, tt.labels)
+			var got string
+			if err == nil {
+				got, err = alertsProxyPath(tt.service, filters, tt.includeSilenced)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("path = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFiringAlertsLimit(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	alert := func(name, severity string, startsAt time.Time) alertmanagerAlert {
+		return alertmanagerAlert{Labels: map[string]string{"alertname": name, "severity": severity}, StartsAt: startsAt}
+	}
+	alerts, omitted := firingAlerts([]alertmanagerAlert{
+		alert("Watchdog", "none", now.Add(-time.Hour)),
+		alert("CPUThrottlingHigh", "info", now.Add(-time.Minute)),
+		alert("KubeNodeNotReady", "warning", now.Add(-30*time.Minute)),
+		alert("KubeJobFailed", "warning", now.Add(-10*time.Minute)),
+		alert("TargetDown", "critical", now.Add(-2*time.Hour)),
+	}, 3)
+	var names []string
+	for _, a := range alerts {
+		names = append(names, a.Name)
+	}
+	if want := []string{"TargetDown", "KubeJobFailed", "KubeNodeNotReady"}; !reflect.DeepEqual(names, want) || omitted != 2 {
+		t.Errorf("firingAlerts() = %v, %d omitted, want %v, 2 omitted", names, omitted, want)
+	}
+}
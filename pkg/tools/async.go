@@ -0,0 +1,147 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// ProgressKey holds the io.Writer the output of an async tool invocation is written to while it runs.
+const ProgressKey ContextKey = "progress"
+
+// AsyncTool is implemented by the tools whose invocations can take a long time,
+// e.g. image pulls or big log greps. Async invocations return an AsyncHandle
+// immediately: the agent polls it to show the progress in the UI, and only
+// sends the final result to the model, so that the LLM requests do not time
+// out while the tool runs.
+type AsyncTool interface {
+	Tool
+
+	// IsAsync returns true if the invocation with these arguments should run asynchronously.
+	IsAsync(args map[string]any) bool
+}
+
+// longRunningCommands matches the commands that typically run for a long time.
+var longRunningCommands = regexp.MustCompile(strings.Join([]string{
+	`\b(docker|podman|nerdctl|crictl|ctr)\s+(image\s+)?pull\b`,
+	`\bcrane\s+(pull|copy|cp)\b`,
+	`\bskopeo\s+copy\b`,
+	`\bkubectl\s+(.*\s)?(rollout\s+status|wait|drain)\b`,
+	`\bkubectl\s+(.*\s)?logs\b.*\|\s*(grep|egrep|awk|sort|uniq|wc)\b`,
+	`\bhelm\s+(install|upgrade)\b.*--wait\b`,
+}, "|"))
+
+// IsLongRunningCommand returns true if the shell command typically runs for a long time,
+// and should be run asynchronously.
+func IsLongRunningCommand(command string) bool {
+	return longRunningCommands.MatchString(command)
+}
+
+// AsyncHandle is the handle of an async tool invocation.
+type AsyncHandle struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	progress bytes.Buffer
+	result   any
+	err      error
+	// block is the last progress reported with ReportProgress, nil once it was read.
+	block *api.ProgressBlock
+}
+
+// StartAsync runs fn in the background and returns its handle. fn writes its progress to the writer it is passed.
+func StartAsync(ctx context.Context, fn func(ctx context.Context, progress io.Writer) (any, error)) *AsyncHandle {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &AsyncHandle{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(h.done)
+		defer cancel()
+		result, err := fn(ctx, progressWriter{h})
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.result, h.err = result, err
+	}()
+	return h
+}
+
+// progressWriter appends to the progress of the handle.
+type progressWriter struct {
+	h *AsyncHandle
+}
+
+func (w progressWriter) Write(p []byte) (int, error) {
+	w.h.mu.Lock()
+	defer w.h.mu.Unlock()
+	return w.h.progress.Write(p)
+}
+
+// ReportProgress reports the progress of an async tool invocation, e.g. the
+// resources scanned so far, shown as a progress bar in the UI. It does nothing
+// if the invocation is not async.
+func ReportProgress(ctx context.Context, progress api.ProgressBlock) {
+	if w, ok := ctx.Value(ProgressKey).(progressWriter); ok {
+		w.h.mu.Lock()
+		defer w.h.mu.Unlock()
+		w.h.block = &progress
+	}
+}
+
+// Done is closed when the invocation completes.
+func (h *AsyncHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Progress returns the progress written since the last call.
+func (h *AsyncHandle) Progress() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	progress := h.progress.String()
+	h.progress.Reset()
+	return progress
+}
+
+// ProgressBlock returns the progress reported since the last call, or nil if
+// none was reported. Only the last progress reported is kept.
+func (h *AsyncHandle) ProgressBlock() *api.ProgressBlock {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	block := h.block
+	h.block = nil
+	return block
+}
+
+// Result waits for the invocation to complete and returns its result.
+func (h *AsyncHandle) Result() (any, error) {
+	<-h.done
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.result, h.err
+}
+
+// Cancel cancels the invocation.
+func (h *AsyncHandle) Cancel() {
+	h.cancel()
+}
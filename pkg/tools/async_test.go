@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+func TestIsLongRunningCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{command: "docker pull nginx:1.27", want: true},
+		{command: "crictl pull registry.k8s.io/pause:3.9", want: true},
+		{command: "kubectl rollout status deployment/web -n prod", want: true},
+		{command: "kubectl -n prod wait --for=condition=Ready pod -l app=web", want: true},
+		{command: "kubectl logs deploy/web --all-containers | grep -i error", want: true},
+		{command: "helm upgrade web ./chart --wait", want: true},
+		{command: "kubectl get pods", want: false},
+		{command: "kubectl logs web-0", want: false},
+		{command: "helm upgrade web ./chart", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := IsLongRunningCommand(tt.command); got != tt.want {
+				t.Errorf("IsLongRunningCommand(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartAsync(t *testing.T) {
+	step := make(chan struct{})
+	handle := StartAsync(context.Background(), func(ctx context.Context, progress io.Writer) (any, error) {
+		fmt.Fprintln(progress, "pulling layer 1/2")
+		step <- struct{}{}
+		<-step
+		fmt.Fprintln(progress, "pulling layer 2/2")
+		return "done", nil
+	})
+
+	<-step
+	if got := handle.Progress(); got != "pulling layer 1/2\n" {
+		t.Errorf("Progress() = %q, want the first layer", got)
+	}
+	if got := handle.Progress(); got != "" {
+		t.Errorf("Progress() = %q, want no new progress", got)
+	}
+	step <- struct{}{}
+
+	result, err := handle.Result()
+	if err != nil || result != "done" {
+		t.Errorf("Result() = %v, %v, want done", result, err)
+	}
+	if got := handle.Progress(); got != "pulling layer 2/2\n" {
+		t.Errorf("Progress() = %q, want the second layer", got)
+	}
+}
+
+func TestStartAsyncCancel(t *testing.T) {
+	handle := StartAsync(context.Background(), func(ctx context.Context, progress io.Writer) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	handle.Cancel()
+	if _, err := handle.Result(); err != context.Canceled {
+		t.Errorf("Result() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestReportProgress(t *testing.T) {
+	handle := StartAsync(context.Background(), func(ctx context.Context, progress io.Writer) (any, error) {
+		ctx = context.WithValue(ctx, ProgressKey, progress)
+		for i := range 3 {
+			ReportProgress(ctx, api.ProgressBlock{Label: "Collecting logs", Done: i + 1, Total: 3})
+		}
+		return "done", nil
+	})
+	if _, err := handle.Result(); err != nil {
+		t.Fatalf("Result() error = %v", err)
+	}
+	if got := handle.ProgressBlock(); got == nil || got.Done != 3 {
+		t.Errorf("ProgressBlock() = %+v, want the last progress", got)
+	}
+	if got := handle.ProgressBlock(); got != nil {
+		t.Errorf("ProgressBlock() = %+v, want no new progress", got)
+	}
+
+	// Synchronous invocations do not report progress.
+	ReportProgress(context.Background(), api.ProgressBlock{Label: "Collecting logs"})
+}
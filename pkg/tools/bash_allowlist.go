@@ -0,0 +1,108 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// ProgramRefusal is the refusal of a command running a program out of the
+// allowlist of the shell commands. It is sent to the model, so that it can
+// re-plan with the allowed programs.
+type ProgramRefusal struct {
+	Command string `json:"command"`
+	// Reason is why the command is refused, e.g. "curl is not an allowed program".
+	Reason string `json:"reason"`
+}
+
+func (r *ProgramRefusal) Error() string {
+	return fmt.Sprintf("refusing to run %q: %s", r.Command, r.Reason)
+}
+
+// allowedBuiltins are the shell builtins the commands may always use, as
+// they run no other program.
+var allowedBuiltins = map[string]bool{
+	"cd": true, "echo": true, "printf": true, "true": true, "false": true,
+	"test": true, "[": true, ":": true, "pwd": true, "export": true, "set": true, "exit": true,
+}
+
+// CheckAllowedPrograms returns the refusal of a shell command running a
+// program that is not in allowed, or nil if it only runs allowed programs.
+// The programs run by wrappers, e.g. "xargs curl", and by shells, e.g.
+// "sh -c 'curl ...'", are checked too, but not the scripts run by allowed
+// programs, e.g. "sh script.sh". kubectl and a few builtins, e.g. cd and
+// echo, are always allowed. Programs given by path, e.g. "./kubectl", must be
+// allowed by that path.
+func CheckAllowedPrograms(command string, allowed []string) *ProgramRefusal {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return &ProgramRefusal{Command: command, Reason: fmt.Sprintf("the command cannot be parsed: %v", err)}
+	}
+
+	var refusal *ProgramRefusal
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if refusal != nil {
+			return false
+		}
+		if call, ok := node.(*syntax.CallExpr); ok && len(call.Args) > 0 {
+			if call.Args[0].Lit() == "" {
+				refusal = &ProgramRefusal{Reason: fmt.Sprintf("the program %s is not a literal name", shellWord(call.Args[0]))}
+				return false
+			}
+			var args []string
+			for _, word := range call.Args {
+				args = append(args, shellWord(word))
+			}
+			refusal = checkAllowedArgs(args, allowed)
+		}
+		return true
+	})
+	if refusal != nil {
+		refusal.Command = command
+	}
+	return refusal
+}
+
+// checkAllowedArgs returns the refusal of a program run with its arguments,
+// or nil if it and the programs it runs are allowed.
+func checkAllowedArgs(args []string, allowed []string) *ProgramRefusal {
+	program := args[0]
+	switch {
+	case allowedBuiltins[program]:
+		return nil
+	case program == "kubectl":
+		return nil
+	case !slices.Contains(allowed, program):
+		return &ProgramRefusal{Reason: fmt.Sprintf("%s is not an allowed program, the shell commands may only run kubectl and %s", program, strings.Join(allowed, ", "))}
+	case shellPrograms[program]:
+		for i, arg := range args[1:] {
+			if arg == "-c" && i+2 < len(args) {
+				if refusal := CheckAllowedPrograms(args[i+2], allowed); refusal != nil {
+					return &ProgramRefusal{Reason: refusal.Reason}
+				}
+			}
+		}
+	default:
+		if wrapped, ok := kubectl.UnwrapOne(args); ok && len(wrapped) > 0 {
+			return checkAllowedArgs(wrapped, allowed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCheckAllowedPrograms(t *testing.T) {
+	allowed := []string{"jq", "grep", "xargs", "sh"}
+	tests := []struct {
+		command string
+		// reason is the expected reason of the refusal, empty if the command is allowed.
+		reason string
+	}{
+		{command: "kubectl get pods -n prod"},
+		{command: "kubectl get pods -o json | jq '.items[].metadata.name' | grep web"},
+		{command: "cd /tmp && echo done"},
+		{command: "kubectl get pods -o name | xargs -n 1 kubectl describe"},
+		{command: "sh -c 'kubectl get nodes | grep Ready'"},
+		{command: "curl -s http://localhost:8080/healthz", reason: "curl is not an allowed program, the shell commands may only run kubectl and jq, grep, xargs, sh"},
+		{command: "kubectl get pods | awk '{print $1}'", reason: "awk is not an allowed program, the shell commands may only run kubectl and jq, grep, xargs, sh"},
+		{command: "echo $(rm -rf /tmp/x)", reason: "rm is not an allowed program, the shell commands may only run kubectl and jq, grep, xargs, sh"},
+		{command: "kubectl get pods -o name | xargs -n 1 rm", reason: "rm is not an allowed program, the shell commands may only run kubectl and jq, grep, xargs, sh"},
+		{command: "sh -c 'python3 -c \"print(1)\"'", reason: "python3 is not an allowed program, the shell commands may only run kubectl and jq, grep, xargs, sh"},
+		{command: "/usr/bin/jq . data.json", reason: "/usr/bin/jq is not an allowed program, the shell commands may only run kubectl and jq, grep, xargs, sh"},
+		{command: "$SHELL -c id", reason: "the program $SHELL is not a literal name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			refusal := CheckAllowedPrograms(tt.command, allowed)
+			switch {
+			case tt.reason == "" && refusal != nil:
+				t.Errorf("CheckAllowedPrograms(%q) = %q, want allowed", tt.command, refusal.Reason)
+			case tt.reason != "" && refusal == nil:
+				t.Errorf("CheckAllowedPrograms(%q) = nil, want %q", tt.command, tt.reason)
+			case tt.reason != "" && refusal.Reason != tt.reason:
+				t.Errorf("CheckAllowedPrograms(%q) = %q, want %q", tt.command, refusal.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestToolsSelect(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  []string
+		disabled []string
+		want     []string
+	}{
+		{name: "all tools", want: []string{"bash", "gdrive_list", "gdrive_read", "kubectl"}},
+		{name: "enabled tools", enabled: []string{"kubectl", "bash"}, want: []string{"bash", "kubectl"}},
+		{name: "disabled MCP server", disabled: []string{"gdrive"}, want: []string{"bash", "kubectl"}},
+		{name: "patterns", enabled: []string{"gdrive_*", "kubectl"}, disabled: []string{"*_read"}, want: []string{"gdrive_list", "kubectl"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tools Tools
+			tools.Init()
+			tools.RegisterTool(NewBashTool(nil))
+			tools.RegisterTool(NewKubectlTool(nil, nil))
+			tools.RegisterTool(NewMCPTool("gdrive", "list", "", nil, nil))
+			tools.RegisterTool(NewMCPTool("gdrive", "read", "", nil, nil))
+			if err := tools.Select(tt.enabled, tt.disabled); err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			if got := tools.Names(); !slices.Equal(got, tt.want) {
+				t.Errorf("Select() kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var tools Tools
+	tools.Init()
+	if err := tools.Select([]string{"[kubectl"}, nil); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
@@ -16,10 +16,6 @@ package tools
 
 import (
 	"context"
-	"fmt"
-	"os"
-	"path/filepath"
-	"runtime"
 	"strings"
 
 	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
@@ -30,20 +26,6 @@ const (
 	defaultBashBin = "/bin/bash"
 )
 
-// expandShellVar expands shell variables and syntax using bash
-func expandShellVar(value string) (string, error) {
-	if strings.Contains(value, "~") {
-		if len(value) >= 2 && value[0] == '~' && os.IsPathSeparator(value[1]) {
-			if runtime.GOOS == "windows" {
-				value = filepath.Join(os.Getenv("USERPROFILE"), value[2:])
-			} else {
-				value = filepath.Join(os.Getenv("HOME"), value[2:])
-			}
-		}
-	}
-	return os.ExpandEnv(value), nil
-}
-
 type BashTool struct {
 	executor sandbox.Executor
 }
@@ -90,31 +72,23 @@ func (t *BashTool) Run(ctx context.Context, args map[string]any) (any, error) {
 	workDir := ctx.Value(WorkDirKey).(string)
 	command := args["command"].(string)
 
-	if err := validateCommand(command); err != nil {
+	if err := validateKubectlCommand(command); err != nil {
 		return &sandbox.ExecResult{Command: command, Error: err.Error()}, nil
 	}
 
-	// Prepare environment
-	env := os.Environ()
-	if kubeconfig != "" {
-		kubeconfig, err := expandShellVar(kubeconfig)
+	return withReadCache(ctx, kubeconfig, command, func() (*sandbox.ExecResult, error) {
+		if err := consumeAPICalls(ctx, command); err != nil {
+			return &sandbox.ExecResult{Command: command, Error: err.Error()}, nil
+		}
+
+		// Prepare environment
+		env, err := toolEnv(ctx, kubeconfig)
 		if err != nil {
 			return nil, err
 		}
-		env = append(env, "KUBECONFIG="+kubeconfig)
-	}
 
-	return ExecuteWithStreamingHandling(ctx, t.executor, command, workDir, env, DetectKubectlStreaming)
-}
-
-func validateCommand(command string) error {
-	if strings.Contains(command, "kubectl edit") {
-		return fmt.Errorf("interactive mode not supported for kubectl, please use non-interactive commands")
-	}
-	if strings.Contains(command, "kubectl port-forward") {
-		return fmt.Errorf("port-forwarding is not allowed because assistant is running in an unattended mode, please try some other alternative")
-	}
-	return nil
+		return ExecuteWithStreamingHandling(ctx, t.executor, command, workDir, env, DetectKubectlStreaming)
+	})
 }
 
 func (t *BashTool) IsInteractive(args map[string]any) (bool, error) {
@@ -146,3 +120,9 @@ func (t *BashTool) CheckModifiesResource(args map[string]any) string {
 
 	return "unknown"
 }
+
+// IsAsync runs the long running commands (e.g. image pulls, log greps) asynchronously.
+func (t *BashTool) IsAsync(args map[string]any) bool {
+	command, _ := args["command"].(string)
+	return IsLongRunningCommand(command)
+}
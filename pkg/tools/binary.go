@@ -0,0 +1,149 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+const (
+	// binarySniffBytes is the length of the start of an output inspected to
+	// tell binary data from text.
+	binarySniffBytes = 8192
+	// maxBinaryRatio is the ratio of invalid UTF-8 sequences and control
+	// characters above which an output without NUL bytes is binary data, e.g.
+	// rather than a log in another encoding.
+	maxBinaryRatio = 0.1
+)
+
+// binaryExtensions are the extensions of the artifacts storing binary
+// outputs, by detected content type.
+var binaryExtensions = map[string]string{
+	"application/x-gzip": ".gz",
+	"application/zip":    ".zip",
+	"application/pdf":    ".pdf",
+	"image/png":          ".png",
+	"image/jpeg":         ".jpg",
+	"image/gif":          ".gif",
+}
+
+// HandleBinaryOutput makes the output of a tool call safe to send to the
+// model: binary data, e.g. the output of "kubectl exec ... cat app.log.gz",
+// is stored as an artifact of workDir and replaced by a description of it,
+// and the invalid UTF-8 sequences of text are replaced, as the providers
+// reject requests that are not valid UTF-8. Other outputs are returned as is.
+func HandleBinaryOutput(workDir string, output any) (any, error) {
+	switch output := output.(type) {
+	case string:
+		return sanitizeOutput(workDir, output)
+	case *sandbox.ExecResult:
+		if output == nil {
+			return output, nil
+		}
+		stdout, err := sanitizeOutput(workDir, output.Stdout)
+		if err != nil {
+			return nil, err
+		}
+		stderr, err := sanitizeOutput(workDir, output.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if stdout == output.Stdout && stderr == output.Stderr {
+			return output, nil
+		}
+		sanitized := *output
+		sanitized.Stdout, sanitized.Stderr = stdout, stderr
+		return &sanitized, nil
+	}
+	return output, nil
+}
+
+// sanitizeOutput returns output with its invalid UTF-8 sequences replaced,
+// or the description of the artifact storing it if it is binary data.
+func sanitizeOutput(workDir, output string) (string, error) {
+	if !isBinary(output) {
+		if utf8.ValidString(output) {
+			return output, nil
+		}
+		return strings.ToValidUTF8(output, "\uFFFD"), nil
+	}
+
+	contentType := http.DetectContentType([]byte(output))
+	if workDir == "" {
+		return fmt.Sprintf("[The output is binary data (%s, %d bytes), which cannot be shown.]", contentType, len(output)), nil
+	}
+	name, err := storeBinaryOutput(workDir, output, binaryExtensions[contentType])
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("[The output is binary data (%s, %d bytes), which cannot be shown. It was stored as the artifact %q of the working directory; decode it, e.g. with zcat or base64, to read it.]", contentType, len(output), name), nil
+}
+
+// isBinary returns true if the start of output holds a NUL byte, or too many
+// invalid UTF-8 sequences and control characters to be text.
+func isBinary(output string) bool {
+	sample := output[:min(len(output), binarySniffBytes)]
+	if strings.Contains(sample, "\x00") {
+		return true
+	}
+	var runes, suspicious int
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRuneInString(sample[i:])
+		i += size
+		runes++
+		switch {
+		case r == utf8.RuneError && size == 1:
+			// A rune cut at the end of the sample is not suspicious.
+			if len(sample) < len(output) && len(sample)-i < utf8.UTFMax {
+				continue
+			}
+			suspicious++
+		case r < 0x20 && !strings.ContainsRune("\t\n\r\f\b\x1b", r), r == 0x7f:
+			suspicious++
+		}
+	}
+	return runes > 0 && float64(suspicious)/float64(runes) > maxBinaryRatio
+}
+
+// storeBinaryOutput writes output to the next binary output file of workDir,
+// with the extension ext or ".bin", and returns its name.
+func storeBinaryOutput(workDir, output, ext string) (string, error) {
+	if ext == "" {
+		ext = ".bin"
+	}
+	for n := 1; ; n++ {
+		name := "binary-output-" + strconv.Itoa(n) + ext
+		f, err := os.OpenFile(filepath.Join(workDir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("storing binary output: %w", err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(output); err != nil {
+			return "", fmt.Errorf("storing binary output: %w", err)
+		}
+		return name, nil
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+func TestHandleBinaryOutput(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte("2025-01-01 ERROR connection refused\n"))
+	w.Close()
+
+	tests := []struct {
+		name string
+		// output is the stdout of an ExecResult.
+		output string
+		// want is the sanitized stdout, or the strings it must contain.
+		want         string
+		wantContains []string
+		wantArtifact string
+	}{
+		{
+			name:   "text",
+			output: "NAME    READY   STATUS\nweb-0   1/1     Running 🚀\n",
+			want:   "NAME    READY   STATUS\nweb-0   1/1     Running 🚀\n",
+		},
+		{
+			name:   "latin-1 text",
+			output: "caf\xe9 ouvert\n",
+			want:   "caf� ouvert\n",
+		},
+		{
+			name:         "gzip",
+			output:       gz.String(),
+			wantContains: []string{"binary data (application/x-gzip", `"binary-output-1.gz"`},
+			wantArtifact: "binary-output-1.gz",
+		},
+		{
+			name:         "control characters",
+			output:       strings.Repeat("\x01\x02\x03abc", 10),
+			wantContains: []string{"binary data (application/octet-stream, 60 bytes)", `"binary-output-1.bin"`},
+			wantArtifact: "binary-output-1.bin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			result := &sandbox.ExecResult{Command: "kubectl exec web-0 -- cat app.log", Stdout: tt.output}
+			got, err := HandleBinaryOutput(workDir, result)
+			if err != nil {
+				t.Fatalf("HandleBinaryOutput: %v", err)
+			}
+			stdout := got.(*sandbox.ExecResult).Stdout
+			if tt.want != "" && stdout != tt.want {
+				t.Errorf("Stdout = %q, want %q", stdout, tt.want)
+			}
+			if tt.want == tt.output && got != result {
+				t.Errorf("HandleBinaryOutput copied an output it did not change")
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("Stdout = %q, want it to contain %q", stdout, want)
+				}
+			}
+			if tt.wantArtifact != "" {
+				stored, err := os.ReadFile(filepath.Join(workDir, tt.wantArtifact))
+				if err != nil || string(stored) != tt.output {
+					t.Errorf("artifact %s = %q, %v, want the output", tt.wantArtifact, stored, err)
+				}
+			}
+		})
+	}
+}
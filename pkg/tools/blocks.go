@@ -0,0 +1,117 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+// BlockTool is implemented by the tools whose results can be shown as
+// structured blocks in the UI, e.g. a table or a code listing. The blocks are
+// shown in addition to the result, which is sent to the model as usual.
+type BlockTool interface {
+	Tool
+
+	// OutputBlock returns the block showing the result of the invocation with
+	// these arguments, or nil if the result has no structure.
+	OutputBlock(args map[string]any, result any) api.Block
+}
+
+// tableColumnSeparator separates the columns of the header of a kubectl table,
+// whose names may contain single spaces, e.g. "NOMINATED NODE".
+var tableColumnSeparator = regexp.MustCompile(`\s{2,}`)
+
+// ParseTable parses the columns of a kubectl table, e.g. the output of
+// "kubectl get pods". The values of a row start at the offset of their column
+// name in the header, as kubectl aligns them. It returns nil if the text is
+// not a single table.
+func ParseTable(text string) *api.TableBlock {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) < 2 {
+		return nil
+	}
+	header := lines[0]
+	if strings.TrimSpace(header) != header {
+		return nil
+	}
+	columns := tableColumnSeparator.Split(header, -1)
+	// The first column is uppercase, e.g. "NAME", the others may not, e.g. "CPU(cores)".
+	if len(columns) < 2 || columns[0] != strings.ToUpper(columns[0]) {
+		return nil
+	}
+	var offsets []int
+	for _, match := range tableColumnSeparator.FindAllStringIndex(header, -1) {
+		offsets = append(offsets, match[1])
+	}
+
+	table := &api.TableBlock{Columns: columns}
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			// e.g. several tables for "kubectl get pods,services".
+			return nil
+		}
+		row := make([]string, len(columns))
+		start := 0
+		for i := range columns {
+			end := len(line)
+			if i < len(offsets) {
+				end = min(offsets[i], len(line))
+			}
+			if start > end || start > 0 && line[start-1] != ' ' {
+				// The value does not start at the offset of its column.
+				return nil
+			}
+			row[i] = strings.TrimSpace(line[start:end])
+			start = end
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// OutputBlock shows the tables of "kubectl get" and "kubectl top" as tables,
+// and the YAML and JSON outputs as code.
+func (t *Kubectl) OutputBlock(args map[string]any, result any) api.Block {
+	command, _ := args["command"].(string)
+	if strings.ContainsAny(command, "|>") {
+		// The output is filtered or redirected.
+		return nil
+	}
+	execResult, ok := result.(*sandbox.ExecResult)
+	if !ok || execResult == nil || execResult.Error != "" || execResult.ExitCode != 0 || strings.TrimSpace(execResult.Stdout) == "" {
+		return nil
+	}
+	commands, err := kubectl.Parse(command)
+	if err != nil || len(commands) != 1 {
+		return nil
+	}
+	cmd := commands[0]
+	switch cmd.OutputFormat {
+	case "yaml", "json":
+		return &api.CodeBlock{Language: cmd.OutputFormat, Code: execResult.Stdout}
+	case "", "wide":
+		if cmd.Verb == "get" || cmd.Verb == "top" {
+			if table := ParseTable(execResult.Stdout); table != nil {
+				return table
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+func TestParseTable(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want *api.TableBlock
+	}{
+		{
+			name: "pods",
+			text: "NAME    READY   STATUS    RESTARTS      AGE\n" +
+				"web-1   1/1     Running   1 (5m ago)    2d\n" +
+				"web-2   0/1     Pending   0             10s\n",
+			want: &api.TableBlock{
+				Columns: []string{"NAME", "READY", "STATUS", "RESTARTS", "AGE"},
+				Rows: [][]string{
+					{"web-1", "1/1", "Running", "1 (5m ago)", "2d"},
+					{"web-2", "0/1", "Pending", "0", "10s"},
+				},
+			},
+		},
+		{
+			name: "empty values and column names with spaces",
+			text: "NAME    NOMINATED NODE   READINESS GATES\n" +
+				"web-1   <none>           \n",
+			want: &api.TableBlock{
+				Columns: []string{"NAME", "NOMINATED NODE", "READINESS GATES"},
+				Rows:    [][]string{{"web-1", "<none>", ""}},
+			},
+		},
+		{
+			name: "top",
+			text: "NAME    CPU(cores)   MEMORY(bytes)\nweb-1   3m           12Mi\n",
+			want: &api.TableBlock{
+				Columns: []string{"NAME", "CPU(cores)", "MEMORY(bytes)"},
+				Rows:    [][]string{{"web-1", "3m", "12Mi"}},
+			},
+		},
+		{name: "no rows", text: "NAME   READY\n"},
+		{name: "not a table", text: "pod/web-1 scaled\npod/web-2 scaled\n"},
+		{name: "several tables", text: "NAME    READY\nweb-1   1/1\n\nNAME   TYPE\nweb    ClusterIP\n"},
+		{name: "misaligned", text: "NAME   READY\nweb-1234 1/1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTable(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTable() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubectlOutputBlock(t *testing.T) {
+	tests := []struct {
+		command string
+		result  *sandbox.ExecResult
+		want    api.Block
+	}{
+		{
+			command: "kubectl get pod web-1 -o yaml",
+			result:  &sandbox.ExecResult{Stdout: "apiVersion: v1\nkind: Pod\n"},
+			want:    &api.CodeBlock{Language: "yaml", Code: "apiVersion: v1\nkind: Pod\n"},
+		},
+		{
+			command: "kubectl get pods -o wide",
+			result:  &sandbox.ExecResult{Stdout: "NAME    READY\nweb-1   1/1\n"},
+			want:    &api.TableBlock{Columns: []string{"NAME", "READY"}, Rows: [][]string{{"web-1", "1/1"}}},
+		},
+		{command: "kubectl get pods | grep web", result: &sandbox.ExecResult{Stdout: "NAME    READY\nweb-1   1/1\n"}},
+		{command: "kubectl describe pod web-1", result: &sandbox.ExecResult{Stdout: "Name:   web-1\n"}},
+		{command: "kubectl get pod web-1 -o yaml", result: &sandbox.ExecResult{Stderr: "not found", ExitCode: 1}},
+	}
+	tool := &Kubectl{}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got := tool.OutputBlock(map[string]any{"command": tt.command}, tt.result)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OutputBlock() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	defaultBundleLogsWindow = 24 * time.Hour
+	// defaultBundleTailLines bounds the log lines collected per container.
+	defaultBundleTailLines = 2000
+	// maxBundlePods bounds the pods whose descriptions and logs are collected.
+	maxBundlePods = 30
+)
+
+// bundleResources are the kinds of the objects of a namespace saved in the
+// bundle. Secrets and ConfigMaps are left out, as the bundle is meant to be
+// shared.
+const bundleResources = "deployments,statefulsets,daemonsets,replicasets,jobs,cronjobs,services,ingresses,persistentvolumeclaims,pods"
+
+// CollectBundleTool gathers the descriptions, logs and events of a namespace
+// or of a workload into a tar.gz archive of the work directory, to escalate an
+// issue to a vendor, and indexes them so that the model can read them with the
+// read_output tool.
+type CollectBundleTool struct {
+	executor sandbox.Executor
+}
+
+func NewCollectBundleTool(executor sandbox.Executor) *CollectBundleTool {
+	return &CollectBundleTool{executor: executor}
+}
+
+func (t *CollectBundleTool) Name() string {
+	return "collect_bundle"
+}
+
+func (t *CollectBundleTool) Description() string {
+	return `Collects a support bundle, in the style of must-gather or sosreport, for a namespace or a workload: the objects as YAML, the descriptions of the workload and its pods, the logs of their containers (including the previous logs of restarted containers) and the events.
+The bundle is saved as a tar.gz archive in the working directory, to be attached to a support case.
+The result lists the files of the bundle with their line ranges in the output with the returned handle; read them with the read_output tool.`
+}
+
+func (t *CollectBundleTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"namespace": {
+					Type:        gollm.TypeString,
+					Description: `The namespace to collect. Defaults to the current namespace.`,
+				},
+				"workload": {
+					Type:        gollm.TypeString,
+					Description: `Optionally restrict the bundle to a workload and its pods, as kind/name, e.g. "deployment/web", "statefulset/db" or "pod/web-0".`,
+				},
+				"since": {
+					Type:        gollm.TypeString,
+					Description: `How far back to collect the logs, as a duration, e.g. "1h", "24h", "7d". Defaults to "24h".`,
+				},
+				"tail_lines": {
+					Type:        gollm.TypeInteger,
+					Description: `The maximum number of log lines collected per container. Defaults to 2000.`,
+				},
+			},
+		},
+	}
+}
+
+// CollectBundleResult is the result of the collect_bundle tool.
+type CollectBundleResult struct {
+	// Bundle is the name of the archive in the working directory.
+	Bundle string `json:"bundle,omitempty"`
+	// Handle is the handle of the output holding the files, for read_output.
+	Handle string       `json:"handle,omitempty"`
+	Files  []BundleFile `json:"files"`
+	// Warnings lists what could not be collected.
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// BundleFile is an entry of the index of a bundle.
+type BundleFile struct {
+	Path  string `json:"path"`
+	Bytes int    `json:"bytes"`
+	// StartLine and EndLine are the lines of the file in the output of the
+	// handle of the bundle, numbered from 1.
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+}
+
+// bundleFile is a file of a bundle.
+type bundleFile struct {
+	path    string
+	content string
+}
+
+// bundleCollector runs the commands collecting the files of a bundle.
+type bundleCollector struct {
+	executor sandbox.Executor
+	env      []string
+	workDir  string
+	// namespaceFlag is the namespace flag of the commands, if any.
+	namespaceFlag string
+	files         []bundleFile
+	warnings      []string
+	// exhausted is set once the API call budget is exhausted.
+	exhausted bool
+}
+
+func (t *CollectBundleTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	namespace, _ := args["namespace"].(string)
+	workload, _ := args["workload"].(string)
+	sinceArg, _ := args["since"].(string)
+
+	result := &CollectBundleResult{Files: []BundleFile{}}
+
+	window := defaultBundleLogsWindow
+	if sinceArg != "" {
+		d, err := parseHistoryWindow(sinceArg)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		window = d
+	}
+	tailLines := defaultBundleTailLines
+	if n := intArg(args["tail_lines"]); n > 0 {
+		tailLines = n
+	}
+	kind, name, hasWorkload := strings.Cut(strings.TrimSpace(workload), "/")
+	if workload != "" && (!hasWorkload || kind == "" || name == "" || strings.HasPrefix(kind, "-") || strings.HasPrefix(name, "-")) {
+		result.Error = fmt.Sprintf("invalid workload %q, expected kind/name, e.g. \"deployment/web\"", workload)
+		return result, nil
+	}
+
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	c := &bundleCollector{executor: t.executor, env: env, workDir: workDir}
+	if namespace != "" {
+		c.namespaceFlag = " --namespace " + ShellQuote(namespace)
+	}
+
+	var pods []unstructured.Unstructured
+	if hasWorkload {
+		pods = c.collectWorkload(ctx, kind, name)
+	} else {
+		c.collect(ctx, "resources.yaml", "kubectl get "+bundleResources+" -o yaml")
+		pods = c.items(ctx, "pods", "kubectl get pods -o json")
+	}
+	if len(pods) > maxBundlePods {
+		c.warnings = append(c.warnings, fmt.Sprintf("only %d of the %d pods were collected", maxBundlePods, len(pods)))
+		pods = pods[:maxBundlePods]
+	}
+	for _, pod := range pods {
+		c.collectPod(ctx, &pod, window, tailLines)
+	}
+	c.collect(ctx, "events.txt", "kubectl get events --sort-by=.lastTimestamp")
+
+	combined, index := bundleIndex(c.files)
+	result.Files, result.Warnings = index, c.warnings
+	if len(c.files) == 0 {
+		result.Error = "nothing could be collected"
+		return result, nil
+	}
+
+	bundleName := bundleArchiveName(namespace, name, time.Now())
+	if err := writeBundleArchive(filepath.Join(workDir, bundleName+".tar.gz"), bundleName, c.files, index); err != nil {
+		return nil, err
+	}
+	result.Bundle = bundleName + ".tar.gz"
+	if result.Handle, err = storeOutput(filepath.Join(workDir, OutputsDir), combined); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// collectWorkload collects the object and the description of a workload, and
+// returns its pods.
+func (c *bundleCollector) collectWorkload(ctx context.Context, kind, name string) []unstructured.Unstructured {
+	fieldSelector := " --field-selector " + ShellQuote("metadata.name="+name)
+	base := strings.ToLower(kind) + "-" + name
+	c.collect(ctx, "resources/"+base+".yaml", "kubectl get "+ShellQuote(kind)+fieldSelector+" -o yaml")
+	objects := c.items(ctx, kind+"/"+name, "kubectl get "+ShellQuote(kind)+fieldSelector+" -o json")
+	if len(objects) == 0 {
+		return nil
+	}
+	object := objects[0]
+	if object.GetKind() == "Pod" {
+		return objects
+	}
+	c.collect(ctx, "describe/"+base+".txt", "kubectl describe "+ShellQuote(kind)+" "+ShellQuote(name))
+
+	selector, err := podSelector(&object)
+	if err != nil {
+		c.warnings = append(c.warnings, fmt.Sprintf("%s/%s: %v, its pods were not collected", kind, name, err))
+		return nil
+	}
+	return c.items(ctx, "pods", "kubectl get pods -l "+ShellQuote(selector)+" -o json")
+}
+
+// collectPod collects the description of a pod and the logs of its containers.
+func (c *bundleCollector) collectPod(ctx context.Context, pod *unstructured.Unstructured, window time.Duration, tailLines int) {
+	name := pod.GetName()
+	c.collect(ctx, "describe/pod-"+name+".txt", "kubectl describe pod "+ShellQuote(name))
+
+	restarts := map[string]int64{}
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", field)
+		for _, status := range statuses {
+			status, _ := status.(map[string]any)
+			container, _, _ := unstructured.NestedString(status, "name")
+			restarts[container], _, _ = unstructured.NestedInt64(status, "restartCount")
+		}
+	}
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", field)
+		for _, container := range containers {
+			container, _ := container.(map[string]any)
+			containerName, _, _ := unstructured.NestedString(container, "name")
+			logs := fmt.Sprintf("kubectl logs %s -c %s --since=%s --tail=%d", ShellQuote(name), ShellQuote(containerName), window, tailLines)
+			c.collect(ctx, "logs/"+name+"/"+containerName+".log", logs)
+			if restarts[containerName] > 0 {
+				c.collect(ctx, "logs/"+name+"/"+containerName+".previous.log", logs+" --previous")
+			}
+		}
+	}
+}
+
+// collect runs a command in the namespace of the bundle and adds its output
+// as a file of the bundle, or a warning if it fails.
+func (c *bundleCollector) collect(ctx context.Context, path, command string) {
+	if output, ok := c.run(ctx, path, command); ok {
+		c.files = append(c.files, bundleFile{path: path, content: output})
+	}
+}
+
+// items runs a "kubectl get ... -o json" command in the namespace of the
+// bundle and returns the listed objects.
+func (c *bundleCollector) items(ctx context.Context, what, command string) []unstructured.Unstructured {
+	output, ok := c.run(ctx, what, command)
+	if !ok {
+		return nil
+	}
+	var list unstructured.UnstructuredList
+	if err := list.UnmarshalJSON([]byte(output)); err != nil {
+		c.warnings = append(c.warnings, fmt.Sprintf("%s: parsing the output of %q: %v", what, command, err))
+		return nil
+	}
+	if len(list.Items) == 0 {
+		c.warnings = append(c.warnings, fmt.Sprintf("%s: not found", what))
+	}
+	return list.Items
+}
+
+// run runs a command in the namespace of the bundle and returns its output.
+// The failures are added to the warnings.
+func (c *bundleCollector) run(ctx context.Context, what, command string) (string, bool) {
+	if c.exhausted {
+		return "", false
+	}
+	command += c.namespaceFlag
+	if err := consumeAPICalls(ctx, command); err != nil {
+		c.exhausted = errors.Is(err, ErrAPICallBudgetExhausted)
+		c.warnings = append(c.warnings, fmt.Sprintf("%s: %v", what, err))
+		return "", false
+	}
+	execResult, err := c.executor.Execute(ctx, command, c.env, c.workDir)
+	if err != nil {
+		c.warnings = append(c.warnings, fmt.Sprintf("%s: %v", what, err))
+		return "", false
+	}
+	if execResult.ExitCode != 0 || execResult.Error != "" {
+		c.warnings = append(c.warnings, fmt.Sprintf("%s: %s", what, strings.TrimSpace(execResult.Stderr+" "+execResult.Error)))
+		return "", false
+	}
+	return execResult.Stdout, true
+}
+
+// podSelector returns the pod label selector of a workload, e.g. the
+// spec.selector of a Deployment.
+func podSelector(object *unstructured.Unstructured) (string, error) {
+	fields, ok, _ := unstructured.NestedMap(object.Object, "spec", "selector")
+	if !ok || len(fields) == 0 {
+		return "", fmt.Errorf("no pod selector")
+	}
+	if _, ok := fields["matchLabels"]; !ok {
+		if _, ok := fields["matchExpressions"]; !ok {
+			// The selector of a Service or a ReplicationController is a map of labels.
+			fields = map[string]any{"matchLabels": fields}
+		}
+	}
+	var labelSelector metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(fields, &labelSelector); err != nil {
+		return "", fmt.Errorf("parsing the pod selector: %w", err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+	if err != nil {
+		return "", fmt.Errorf("parsing the pod selector: %w", err)
+	}
+	return selector.String(), nil
+}
+
+// bundleIndex concatenates the files of a bundle, each after a "==> path <=="
+// header line, and returns their line ranges in the result.
+func bundleIndex(files []bundleFile) (string, []BundleFile) {
+	var b strings.Builder
+	index := make([]BundleFile, 0, len(files))
+	line := 1
+	for _, file := range files {
+		content := strings.TrimSuffix(file.content, "\n")
+		fmt.Fprintf(&b, "==> %s <==\n%s\n", file.path, content)
+		lines := strings.Count(content, "\n") + 1
+		index = append(index, BundleFile{Path: file.path, Bytes: len(file.content), StartLine: line + 1, EndLine: line + lines})
+		line += lines + 1
+	}
+	return b.String(), index
+}
+
+// bundleArchiveName returns the name of the archive of a bundle, without its
+// extension, e.g. "bundle-shop-web-20250601T120000Z".
+func bundleArchiveName(namespace, workload string, now time.Time) string {
+	parts := []string{"bundle"}
+	for _, part := range []string{namespace, workload} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(append(parts, now.UTC().Format("20060102T150405Z")), "-")
+}
+
+// writeBundleArchive writes the files of a bundle, and an index.txt file
+// listing them, to a tar.gz archive, in the root directory.
+func writeBundleArchive(path, root string, files []bundleFile, index []BundleFile) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("creating bundle: %w", err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	var listing strings.Builder
+	for _, file := range index {
+		fmt.Fprintf(&listing, "%s\t%d bytes\n", file.Path, file.Bytes)
+	}
+	modTime := time.Now()
+	for _, file := range append(files, bundleFile{path: "index.txt", content: listing.String()}) {
+		header := &tar.Header{Name: root + "/" + file.path, Mode: 0o600, Size: int64(len(file.content)), ModTime: modTime}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("writing bundle: %w", err)
+		}
+		if _, err := tw.Write([]byte(file.content)); err != nil {
+			return fmt.Errorf("writing bundle: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("writing bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("writing bundle: %w", err)
+	}
+	return f.Close()
+}
+
+func (t *CollectBundleTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "no", the collect_bundle tool only
+// reads resources.
+func (t *CollectBundleTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+// scriptedExecutor returns the output of the first command prefix matching
+// the executed command, and fails the other commands.
+type scriptedExecutor struct {
+	outputs map[string]string
+}
+
+func (e *scriptedExecutor) Execute(ctx context.Context, command string, env []string, workDir string) (*sandbox.ExecResult, error) {
+	for prefix, output := range e.outputs {
+		if strings.HasPrefix(command, prefix) {
+			return &sandbox.ExecResult{Command: command, Stdout: output}, nil
+		}
+	}
+	return &sandbox.ExecResult{Command: command, ExitCode: 1, Stderr: "error: not found"}, nil
+}
+
+func (e *scriptedExecutor) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestCollectBundleTool(t *testing.T) {
+	executor := &scriptedExecutor{outputs: map[string]string{
+		"kubectl get 'deployment' --field-selector 'metadata.name=web' -o yaml": "kind: Deployment\n",
+		"kubectl get 'deployment' --field-selector 'metadata.name=web' -o json": `{"kind": "List", "items": [{"kind": "Deployment", "metadata": {"name": "web"},
+			"spec": {"selector": {"matchLabels": {"app": "web"}}}}]}`,
+		"kubectl describe 'deployment' 'web'":                                        "Name: web\n",
+		"kubectl get pods -l 'app=web' -o json":                                      `{"kind": "List", "items": [{"kind": "Pod", "metadata": {"name": "web-0"}, "spec": {"containers": [{"name": "app"}]}, "status": {"containerStatuses": [{"name": "app", "restartCount": 2}]}}]}`,
+		"kubectl describe pod 'web-0'":                                               "Name: web-0\nStatus: Running\n",
+		"kubectl logs 'web-0' -c 'app' --since=1h0m0s --tail=100 --namespace 'shop'": "started\nlistening\n",
+		"kubectl get events":                                                         "LAST SEEN   TYPE      REASON    OBJECT\n",
+	}}
+	workDir := t.TempDir()
+	ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+	ctx = context.WithValue(ctx, WorkDirKey, workDir)
+
+	got, err := NewCollectBundleTool(executor).Run(ctx, map[string]any{"namespace": "shop", "workload": "deployment/web", "since": "1h", "tail_lines": float64(100)})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	result := got.(*CollectBundleResult)
+	if result.Error != "" {
+		t.Fatalf("Error = %q", result.Error)
+	}
+	wantFiles := []BundleFile{
+		{Path: "resources/deployment-web.yaml", Bytes: 17, StartLine: 2, EndLine: 2},
+		{Path: "describe/deployment-web.txt", Bytes: 10, StartLine: 4, EndLine: 4},
+		{Path: "describe/pod-web-0.txt", Bytes: 28, StartLine: 6, EndLine: 7},
+		{Path: "logs/web-0/app.log", Bytes: 18, StartLine: 9, EndLine: 10},
+		{Path: "events.txt", Bytes: 39, StartLine: 12, EndLine: 12},
+	}
+	if !reflect.DeepEqual(result.Files, wantFiles) {
+		t.Errorf("Files = %+v, want %+v", result.Files, wantFiles)
+	}
+	// The previous logs of the restarted container could not be collected.
+	if len(result.Warnings) != 1 || !strings.HasPrefix(result.Warnings[0], "logs/web-0/app.previous.log: ") {
+		t.Errorf("Warnings = %q, want the previous logs", result.Warnings)
+	}
+
+	// The files can be read with read_output.
+	read, err := NewReadOutputTool(0).Run(ctx, map[string]any{"handle": result.Handle, "start_line": float64(9), "end_line": float64(10)})
+	if err != nil {
+		t.Fatalf("read_output: %v", err)
+	}
+	if content := read.(*ReadOutputResult).Content; content != "started\nlistening\n" {
+		t.Errorf("read_output content = %q, want the logs", content)
+	}
+
+	// The archive holds the files and the index.
+	if !strings.HasPrefix(result.Bundle, "bundle-shop-web-") || !strings.HasSuffix(result.Bundle, ".tar.gz") {
+		t.Fatalf("Bundle = %q", result.Bundle)
+	}
+	f, err := os.Open(filepath.Join(workDir, result.Bundle))
+	if err != nil {
+		t.Fatalf("opening bundle: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("reading bundle: %v", err)
+	}
+	root := strings.TrimSuffix(result.Bundle, ".tar.gz") + "/"
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading bundle: %v", err)
+		}
+		names = append(names, strings.TrimPrefix(header.Name, root))
+	}
+	sort.Strings(names)
+	wantNames := []string{"describe/deployment-web.txt", "describe/pod-web-0.txt", "events.txt", "index.txt", "logs/web-0/app.log", "resources/deployment-web.yaml"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("archive files = %q, want %q", names, wantNames)
+	}
+}
@@ -0,0 +1,400 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CapacityTool reports requests and limits against allocatable capacity per
+// node pool, and simulates the headroom left by a scale-up scenario.
+type CapacityTool struct {
+	executor sandbox.Executor
+}
+
+func NewCapacityTool(executor sandbox.Executor) *CapacityTool {
+	return &CapacityTool{executor: executor}
+}
+
+func (t *CapacityTool) Name() string {
+	return "capacity_report"
+}
+
+func (t *CapacityTool) Description() string {
+	return `Computes CPU and memory requests and limits against allocatable capacity for each node pool, from the live nodes and pods.
+Optionally simulates a scale-up scenario (scaling every workload by a factor, or specific workloads to a number of replicas) by placing the additional pods on the nodes of their node pool, and reports the remaining headroom and the pods that would not fit.
+Use this tool for capacity planning questions instead of estimating from kubectl output.`
+}
+
+func (t *CapacityTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"scale_factor": {
+					Type:        gollm.TypeNumber,
+					Description: `Simulates scaling the replicas of every Deployment and StatefulSet by this factor, e.g. 1.5. Defaults to no scaling.`,
+				},
+				"replicas": {
+					Type: gollm.TypeArray,
+					Items: &gollm.Schema{
+						Type: gollm.TypeString,
+					},
+					Description: `Simulates scaling specific workloads, as "<namespace>/<kind>/<name>=<replicas>", e.g. "shop/deployment/checkout=12".`,
+				},
+			},
+		},
+	}
+}
+
+// CapacityScenario is a scale-up scenario to simulate.
+type CapacityScenario struct {
+	// ScaleFactor multiplies the replicas of every Deployment and StatefulSet. 0 or 1 means no scaling.
+	ScaleFactor float64 `json:"scaleFactor,omitempty"`
+	// Replicas sets the replicas of specific workloads, keyed by "<namespace>/<kind>/<name>".
+	Replicas map[string]int `json:"replicas,omitempty"`
+}
+
+func (s CapacityScenario) isEmpty() bool {
+	return (s.ScaleFactor == 0 || s.ScaleFactor == 1) && len(s.Replicas) == 0
+}
+
+// ParseCapacityReplicas parses "<namespace>/<kind>/<name>=<replicas>" entries.
+func ParseCapacityReplicas(entries []string) (map[string]int, error) {
+	replicas := make(map[string]int)
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.Count(key, "/") != 2 {
+			return nil, fmt.Errorf("invalid replicas %q, expected <namespace>/<kind>/<name>=<replicas>", entry)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid replicas %q: %q is not a valid number of replicas", entry, value)
+		}
+		replicas[strings.ToLower(key)] = n
+	}
+	return replicas, nil
+}
+
+// CapacityReport is the result of the capacity_report tool.
+type CapacityReport struct {
+	NodePools []PoolCapacity    `json:"nodePools"`
+	Scenario  *CapacityScenario `json:"scenario,omitempty"`
+	Warnings  []string          `json:"warnings,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// PoolCapacity is the capacity of a node pool. CPU is in cores, memory in bytes.
+type PoolCapacity struct {
+	Name              string  `json:"name"`
+	Nodes             int     `json:"nodes"`
+	Pods              int     `json:"pods"`
+	AllocatableCPU    float64 `json:"allocatableCPU"`
+	AllocatableMemory int64   `json:"allocatableMemory"`
+	RequestedCPU      float64 `json:"requestedCPU"`
+	RequestedMemory   int64   `json:"requestedMemory"`
+	LimitCPU          float64 `json:"limitCPU"`
+	LimitMemory       int64   `json:"limitMemory"`
+
+	// AddedPods and UnschedulablePods are only set when a scenario is simulated.
+	AddedPods         int `json:"addedPods,omitempty"`
+	UnschedulablePods int `json:"unschedulablePods,omitempty"`
+	// HeadroomCPU and HeadroomMemory are the unrequested capacity of the
+	// schedulable nodes, after placing the pods of the scenario if any.
+	HeadroomCPU    float64 `json:"headroomCPU"`
+	HeadroomMemory int64   `json:"headroomMemory"`
+}
+
+func (t *CapacityTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	var scenario CapacityScenario
+	scenario.ScaleFactor, _ = args["scale_factor"].(float64)
+	var entries []string
+	if list, ok := args["replicas"].([]any); ok {
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				entries = append(entries, s)
+			}
+		}
+	}
+	replicas, err := ParseCapacityReplicas(entries)
+	if err != nil {
+		return &CapacityReport{Error: err.Error()}, nil
+	}
+	scenario.Replicas = replicas
+
+	return CollectCapacity(ctx, t.executor, kubeconfig, workDir, scenario)
+}
+
+// podRequests is the CPU (millicores) and memory (bytes) of a pod.
+type podRequests struct {
+	cpu, memory int64
+}
+
+// capacityNode is the capacity of a node during the simulation.
+type capacityNode struct {
+	pool          string
+	unschedulable bool
+	allocatable   podRequests
+	requested     podRequests
+}
+
+// capacityWorkload groups the pods of a Deployment or StatefulSet.
+type capacityWorkload struct {
+	pool     string
+	pods     int
+	requests podRequests
+}
+
+// CollectCapacity aggregates the capacity of the cluster per node pool and simulates the scenario.
+func CollectCapacity(ctx context.Context, executor sandbox.Executor, kubeconfig, workDir string, scenario CapacityScenario) (*CapacityReport, error) {
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CapacityReport{NodePools: []PoolCapacity{}}
+
+	nodeItems, err := getKubectlItems(ctx, executor, "kubectl get nodes -o json", env, workDir)
+	if err != nil {
+		report.Error = fmt.Sprintf("listing nodes: %v", err)
+		return report, nil
+	}
+	podItems, err := getKubectlItems(ctx, executor, "kubectl get pods --all-namespaces --field-selector=status.phase!=Succeeded,status.phase!=Failed -o json", env, workDir)
+	if err != nil {
+		report.Error = fmt.Sprintf("listing pods: %v", err)
+		return report, nil
+	}
+
+	pools := make(map[string]*PoolCapacity)
+	nodes := make(map[string]*capacityNode)
+	var nodeNames []string
+	for _, item := range nodeItems {
+		node := &capacityNode{pool: nodePoolOf(item)}
+		node.unschedulable, _, _ = unstructured.NestedBool(item.Object, "spec", "unschedulable")
+		allocatable, _, _ := unstructured.NestedStringMap(item.Object, "status", "allocatable")
+		node.allocatable = podRequests{
+			cpu:    parseQuantity(allocatable["cpu"]).MilliValue(),
+			memory: parseQuantity(allocatable["memory"]).Value(),
+		}
+		nodes[item.GetName()] = node
+		nodeNames = append(nodeNames, item.GetName())
+
+		pool, ok := pools[node.pool]
+		if !ok {
+			pool = &PoolCapacity{Name: node.pool}
+			pools[node.pool] = pool
+		}
+		pool.Nodes++
+		pool.AllocatableCPU += milliToCores(node.allocatable.cpu)
+		pool.AllocatableMemory += node.allocatable.memory
+	}
+	sort.Strings(nodeNames)
+
+	workloads := make(map[string]*capacityWorkload)
+	for _, pod := range podItems {
+		nodeName, _, _ := unstructured.NestedString(pod.Object, "spec", "nodeName")
+		node, ok := nodes[nodeName]
+		if !ok {
+			continue
+		}
+		requests, limits := podResources(pod)
+		node.requested.cpu += requests.cpu
+		node.requested.memory += requests.memory
+
+		pool := pools[node.pool]
+		pool.Pods++
+		pool.RequestedCPU += milliToCores(requests.cpu)
+		pool.RequestedMemory += requests.memory
+		pool.LimitCPU += milliToCores(limits.cpu)
+		pool.LimitMemory += limits.memory
+
+		if key := workloadKey(pod); key != "" {
+			w, ok := workloads[key]
+			if !ok {
+				w = &capacityWorkload{pool: node.pool, requests: requests}
+				workloads[key] = w
+			}
+			w.pods++
+		}
+	}
+
+	if !scenario.isEmpty() {
+		report.Scenario = &scenario
+		report.Warnings = append(report.Warnings, simulateScaleUp(scenario, workloads, nodes, nodeNames, pools)...)
+	}
+
+	for _, name := range nodeNames {
+		node := nodes[name]
+		if node.unschedulable {
+			continue
+		}
+		pool := pools[node.pool]
+		pool.HeadroomCPU += milliToCores(max(node.allocatable.cpu-node.requested.cpu, 0))
+		pool.HeadroomMemory += max(node.allocatable.memory-node.requested.memory, 0)
+	}
+
+	for _, pool := range pools {
+		report.NodePools = append(report.NodePools, *pool)
+	}
+	sort.Slice(report.NodePools, func(i, j int) bool { return report.NodePools[i].Name < report.NodePools[j].Name })
+	return report, nil
+}
+
+// simulateScaleUp places the pods added by the scenario on the schedulable
+// nodes of their workload's node pool, first-fit, and records the results in the pools.
+func simulateScaleUp(scenario CapacityScenario, workloads map[string]*capacityWorkload, nodes map[string]*capacityNode, nodeNames []string, pools map[string]*PoolCapacity) []string {
+	var warnings []string
+
+	added := make(map[string]int)
+	if scenario.ScaleFactor > 1 {
+		for key, w := range workloads {
+			added[key] = int(math.Ceil(float64(w.pods)*scenario.ScaleFactor)) - w.pods
+		}
+	}
+	for key, replicas := range scenario.Replicas {
+		w, ok := workloads[key]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("workload %q has no running pods, it cannot be simulated", key))
+			continue
+		}
+		added[key] = max(replicas-w.pods, 0)
+	}
+
+	keys := make([]string, 0, len(added))
+	for key := range added {
+		keys = append(keys, key)
+	}
+	// Place the largest pods first, for a tighter packing.
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := workloads[keys[i]].requests, workloads[keys[j]].requests
+		if a.cpu != b.cpu {
+			return a.cpu > b.cpu
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys {
+		w := workloads[key]
+		pool := pools[w.pool]
+		for range added[key] {
+			pool.AddedPods++
+			placed := false
+			for _, name := range nodeNames {
+				node := nodes[name]
+				if node.pool != w.pool || node.unschedulable {
+					continue
+				}
+				if node.requested.cpu+w.requests.cpu <= node.allocatable.cpu && node.requested.memory+w.requests.memory <= node.allocatable.memory {
+					node.requested.cpu += w.requests.cpu
+					node.requested.memory += w.requests.memory
+					placed = true
+					break
+				}
+			}
+			if !placed {
+				pool.UnschedulablePods++
+			}
+		}
+	}
+	return warnings
+}
+
+// workloadKey returns "<namespace>/<kind>/<name>" for pods of Deployments and StatefulSets.
+func workloadKey(pod unstructured.Unstructured) string {
+	for _, ref := range pod.GetOwnerReferences() {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		switch ref.Kind {
+		case "StatefulSet":
+			return strings.ToLower(pod.GetNamespace() + "/statefulset/" + ref.Name)
+		case "ReplicaSet":
+			if hash := pod.GetLabels()["pod-template-hash"]; hash != "" {
+				return strings.ToLower(pod.GetNamespace() + "/deployment/" + strings.TrimSuffix(ref.Name, "-"+hash))
+			}
+			return strings.ToLower(pod.GetNamespace() + "/replicaset/" + ref.Name)
+		}
+	}
+	return ""
+}
+
+// podResources returns the effective requests and limits of a pod: the sum of
+// its containers, or the largest init container if that is higher.
+func podResources(pod unstructured.Unstructured) (requests, limits podRequests) {
+	sum := func(field string) (total, initMax podRequests) {
+		for _, path := range [][]string{{"spec", "containers"}, {"spec", "initContainers"}} {
+			containers, _, _ := unstructured.NestedSlice(pod.Object, path...)
+			for _, c := range containers {
+				container, ok := c.(map[string]any)
+				if !ok {
+					continue
+				}
+				values, _, _ := unstructured.NestedStringMap(container, "resources", field)
+				cpu := parseQuantity(values["cpu"]).MilliValue()
+				memory := parseQuantity(values["memory"]).Value()
+				if path[1] == "containers" {
+					total.cpu += cpu
+					total.memory += memory
+				} else {
+					initMax.cpu = max(initMax.cpu, cpu)
+					initMax.memory = max(initMax.memory, memory)
+				}
+			}
+		}
+		return total, initMax
+	}
+
+	requests, initRequests := sum("requests")
+	limits, initLimits := sum("limits")
+	requests = podRequests{cpu: max(requests.cpu, initRequests.cpu), memory: max(requests.memory, initRequests.memory)}
+	limits = podRequests{cpu: max(limits.cpu, initLimits.cpu), memory: max(limits.memory, initLimits.memory)}
+	return requests, limits
+}
+
+func parseQuantity(s string) *resource.Quantity {
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return resource.NewQuantity(0, resource.DecimalSI)
+	}
+	return &q
+}
+
+func milliToCores(milli int64) float64 {
+	return float64(milli) / 1000
+}
+
+func (t *CapacityTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "no", the capacity_report tool only reads resources.
+func (t *CapacityTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
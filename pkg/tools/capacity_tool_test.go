@@ -0,0 +1,53 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSimulateScaleUp(t *testing.T) {
+	const gi = 1 << 30
+	nodes := map[string]*capacityNode{
+		"a": {pool: "web", allocatable: podRequests{cpu: 4000, memory: 16 * gi}, requested: podRequests{cpu: 3000, memory: 4 * gi}},
+		"b": {pool: "web", allocatable: podRequests{cpu: 4000, memory: 16 * gi}, requested: podRequests{cpu: 1000, memory: 4 * gi}},
+		"c": {pool: "web", unschedulable: true, allocatable: podRequests{cpu: 4000, memory: 16 * gi}},
+	}
+	pools := map[string]*PoolCapacity{"web": {Name: "web"}}
+	workloads := map[string]*capacityWorkload{
+		"shop/deployment/checkout": {pool: "web", pods: 4, requests: podRequests{cpu: 1000, memory: 1 * gi}},
+	}
+
+	warnings := simulateScaleUp(CapacityScenario{
+		Replicas: map[string]int{"shop/deployment/checkout": 8, "shop/deployment/missing": 2},
+	}, workloads, nodes, []string{"a", "b", "c"}, pools)
+
+	// One pod fits on a, three on b, and the unschedulable node c is skipped.
+	if got, want := *pools["web"], (PoolCapacity{Name: "web", AddedPods: 4}); !reflect.DeepEqual(got, want) {
+		t.Errorf("pool = %+v, want %+v", got, want)
+	}
+	if nodes["a"].requested.cpu != 4000 || nodes["b"].requested.cpu != 4000 {
+		t.Errorf("unexpected placement: a=%+v b=%+v", nodes["a"].requested, nodes["b"].requested)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("expected a warning for the missing workload, got %v", warnings)
+	}
+
+	simulateScaleUp(CapacityScenario{ScaleFactor: 1.5}, workloads, nodes, []string{"a", "b", "c"}, pools)
+	if got := pools["web"].UnschedulablePods; got != 2 {
+		t.Errorf("UnschedulablePods = %d, want 2", got)
+	}
+}
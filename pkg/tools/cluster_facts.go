@@ -0,0 +1,193 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+// clusterFactsMarker prefixes the lines separating the sections of the
+// output of the fact sheet command.
+const clusterFactsMarker = "--- kubectl-ai facts: "
+
+// clusterFactsCommands are the sections of the fact sheet and their kubectl commands.
+var clusterFactsCommands = []struct {
+	section, command string
+}{
+	{"version", "kubectl version -o json"},
+	{"nodes", "kubectl get nodes -o name"},
+	{"namespaces", "kubectl get namespaces -o name"},
+	{"crds", `kubectl get customresourcedefinitions -o jsonpath='{range .items[*]}{.spec.group}{"\n"}{end}'`},
+	{"storageclasses", `kubectl get storageclasses -o jsonpath='{range .items[*]}{.metadata.name}{" "}{.metadata.annotations.storageclass\.kubernetes\.io/is-default-class}{"\n"}{end}'`},
+}
+
+// maxClusterFactsNames caps the namespaces and CRD groups listed in the fact sheet.
+const maxClusterFactsNames = 50
+
+// ClusterFacts is a compact fact sheet of the cluster, gathered at the start
+// of a session so that the model does not discover these facts again.
+type ClusterFacts struct {
+	ServerVersion string   `json:"serverVersion,omitempty"`
+	Nodes         int      `json:"nodes"`
+	Namespaces    []string `json:"namespaces"`
+	CRDGroups     []string `json:"crdGroups"`
+	// DefaultStorageClass is empty when no storage class is the default.
+	DefaultStorageClass string `json:"defaultStorageClass,omitempty"`
+	// Unavailable lists the sections whose command failed, e.g. "crds" when
+	// the user may not list the CustomResourceDefinitions.
+	Unavailable []string `json:"unavailable,omitempty"`
+}
+
+// clusterFactsCommand returns the single command gathering all the sections,
+// each preceded by its marker, or followed by a failure marker when its command fails.
+func clusterFactsCommand() string {
+	var parts []string
+	for _, c := range clusterFactsCommands {
+		parts = append(parts, fmt.Sprintf("echo %s; %s 2>/dev/null || echo %s",
+			ShellQuote(clusterFactsMarker+c.section), c.command, ShellQuote(clusterFactsMarker+"failed")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// CollectClusterFacts gathers the fact sheet of the cluster with a single
+// batched command. The facts whose command failed are listed as unavailable.
+func CollectClusterFacts(ctx context.Context, executor sandbox.Executor, kubeconfig, workDir string) (*ClusterFacts, error) {
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	command := clusterFactsCommand()
+	if err := consumeAPICalls(ctx, command); err != nil {
+		return nil, err
+	}
+	execResult, err := executor.Execute(ctx, command, env, workDir)
+	if err != nil {
+		return nil, err
+	}
+	if execResult.Error != "" {
+		return nil, fmt.Errorf("gathering cluster facts: %s", execResult.Error)
+	}
+	return parseClusterFacts(execResult.Stdout), nil
+}
+
+// parseClusterFacts parses the output of the fact sheet command.
+func parseClusterFacts(output string) *ClusterFacts {
+	sections := make(map[string][]string)
+	section := ""
+	for _, line := range strings.Split(output, "\n") {
+		if name, ok := strings.CutPrefix(line, clusterFactsMarker); ok {
+			if name == "failed" {
+				delete(sections, section)
+				continue
+			}
+			section = name
+			sections[section] = []string{}
+			continue
+		}
+		if _, ok := sections[section]; ok && strings.TrimSpace(line) != "" {
+			sections[section] = append(sections[section], strings.TrimSpace(line))
+		}
+	}
+
+	facts := &ClusterFacts{Namespaces: []string{}, CRDGroups: []string{}}
+	for _, c := range clusterFactsCommands {
+		if _, ok := sections[c.section]; !ok {
+			facts.Unavailable = append(facts.Unavailable, c.section)
+		}
+	}
+
+	if lines, ok := sections["version"]; ok {
+		var version struct {
+			ServerVersion struct {
+				GitVersion string `json:"gitVersion"`
+			} `json:"serverVersion"`
+		}
+		if err := json.Unmarshal([]byte(strings.Join(lines, "\n")), &version); err == nil {
+			facts.ServerVersion = version.ServerVersion.GitVersion
+		}
+	}
+	facts.Nodes = len(sections["nodes"])
+	for _, line := range sections["namespaces"] {
+		facts.Namespaces = append(facts.Namespaces, strings.TrimPrefix(line, "namespace/"))
+	}
+	groups := make(map[string]bool)
+	for _, group := range sections["crds"] {
+		groups[group] = true
+	}
+	for group := range groups {
+		facts.CRDGroups = append(facts.CRDGroups, group)
+	}
+	sort.Strings(facts.CRDGroups)
+	for _, line := range sections["storageclasses"] {
+		name, isDefault, _ := strings.Cut(line, " ")
+		if isDefault == "true" {
+			facts.DefaultStorageClass = name
+		}
+	}
+	return facts
+}
+
+// Markdown renders the fact sheet for the system prompt.
+func (f *ClusterFacts) Markdown() string {
+	if f == nil {
+		return ""
+	}
+	unavailable := make(map[string]bool)
+	for _, section := range f.Unavailable {
+		unavailable[section] = true
+	}
+	if len(unavailable) == len(clusterFactsCommands) {
+		return ""
+	}
+
+	var b strings.Builder
+	if !unavailable["version"] && f.ServerVersion != "" {
+		fmt.Fprintf(&b, "- Kubernetes version: %s\n", f.ServerVersion)
+	}
+	if !unavailable["nodes"] {
+		fmt.Fprintf(&b, "- Nodes: %d\n", f.Nodes)
+	}
+	if !unavailable["namespaces"] {
+		fmt.Fprintf(&b, "- Namespaces (%d): %s\n", len(f.Namespaces), factsList(f.Namespaces))
+	}
+	if !unavailable["crds"] {
+		fmt.Fprintf(&b, "- Custom resource API groups (%d): %s\n", len(f.CRDGroups), factsList(f.CRDGroups))
+	}
+	if !unavailable["storageclasses"] {
+		storageClass := "none"
+		if f.DefaultStorageClass != "" {
+			storageClass = f.DefaultStorageClass
+		}
+		fmt.Fprintf(&b, "- Default storage class: %s\n", storageClass)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// factsList joins the names, cut to the first maxClusterFactsNames.
+func factsList(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	if len(names) > maxClusterFactsNames {
+		return strings.Join(names[:maxClusterFactsNames], ", ") + fmt.Sprintf(" and %d more", len(names)-maxClusterFactsNames)
+	}
+	return strings.Join(names, ", ")
+}
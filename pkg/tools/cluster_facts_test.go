@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCollectClusterFacts(t *testing.T) {
+	output := `--- kubectl-ai facts: version
+{
+  "clientVersion": {"gitVersion": "v1.31.0"},
+  "serverVersion": {"gitVersion": "v1.30.2-gke.1587003"}
+}
+--- kubectl-ai facts: nodes
+node/pool-1-a
+node/pool-1-b
+node/pool-2-a
+--- kubectl-ai facts: namespaces
+namespace/default
+namespace/kube-system
+namespace/shop
+--- kubectl-ai facts: crds
+monitoring.coreos.com
+cert-manager.io
+monitoring.coreos.com
+--- kubectl-ai facts: storageclasses
+--- kubectl-ai facts: failed
+`
+	executor := &scriptedExecutor{outputs: map[string]string{"echo '--- kubectl-ai facts: version'; kubectl version -o json": output}}
+	ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+	facts, err := CollectClusterFacts(ctx, executor, "", t.TempDir())
+	if err != nil {
+		t.Fatalf("CollectClusterFacts: %v", err)
+	}
+
+	want := &ClusterFacts{
+		ServerVersion: "v1.30.2-gke.1587003",
+		Nodes:         3,
+		Namespaces:    []string{"default", "kube-system", "shop"},
+		CRDGroups:     []string{"cert-manager.io", "monitoring.coreos.com"},
+		Unavailable:   []string{"storageclasses"},
+	}
+	if !reflect.DeepEqual(facts, want) {
+		t.Errorf("CollectClusterFacts() = %+v, want %+v", facts, want)
+	}
+
+	wantMarkdown := `- Kubernetes version: v1.30.2-gke.1587003
+- Nodes: 3
+- Namespaces (3): default, kube-system, shop
+- Custom resource API groups (2): cert-manager.io, monitoring.coreos.com`
+	if got := facts.Markdown(); got != wantMarkdown {
+		t.Errorf("Markdown() = %q, want %q", got, wantMarkdown)
+	}
+
+	// Without access to the cluster, the fact sheet is empty.
+	if got := parseClusterFacts("--- kubectl-ai facts: version\n--- kubectl-ai facts: failed\n").Markdown(); got != "" {
+		t.Errorf("Markdown() = %q, want no facts", got)
+	}
+}
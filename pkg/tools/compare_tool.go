@@ -0,0 +1,367 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+// ignoredMetadataFields are server-populated metadata fields that differ
+// between any two objects and are irrelevant when comparing workloads.
+var ignoredMetadataFields = []string{
+	"resourceVersion",
+	"uid",
+	"creationTimestamp",
+	"generation",
+	"managedFields",
+	"selfLink",
+	"namespace",
+	"ownerReferences",
+}
+
+// ignoredAnnotations are annotations maintained by controllers or kubectl.
+var ignoredAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+}
+
+// CompareTool diffs the same workload across two namespaces and/or kube contexts.
+type CompareTool struct {
+	executor sandbox.Executor
+}
+
+func NewCompareTool(executor sandbox.Executor) *CompareTool {
+	return &CompareTool{executor: executor}
+}
+
+func (t *CompareTool) Name() string {
+	return "compare"
+}
+
+func (t *CompareTool) Description() string {
+	return `Compares the spec of a Kubernetes resource across two namespaces and/or two kube contexts (clusters), and returns a structured diff.
+Fields that always differ between objects (resourceVersion, uid, creationTimestamp, managedFields, status, ...) are ignored.
+Use this tool to answer questions like "why does this work in staging but not in prod?".`
+}
+
+func (t *CompareTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"kind": {
+					Type:        gollm.TypeString,
+					Description: `The kind of the resource to compare, e.g. "deployment", "statefulset", "configmap".`,
+				},
+				"name": {
+					Type:        gollm.TypeString,
+					Description: `The name of the resource to compare.`,
+				},
+				"right_name": {
+					Type:        gollm.TypeString,
+					Description: `The name of the resource on the right side, if it differs from "name".`,
+				},
+				"left_namespace": {
+					Type:        gollm.TypeString,
+					Description: `The namespace of the left side. Defaults to the current namespace.`,
+				},
+				"right_namespace": {
+					Type:        gollm.TypeString,
+					Description: `The namespace of the right side. Defaults to the current namespace.`,
+				},
+				"left_context": {
+					Type:        gollm.TypeString,
+					Description: `The kube context of the left side. Defaults to the current context.`,
+				},
+				"right_context": {
+					Type:        gollm.TypeString,
+					Description: `The kube context of the right side. Defaults to the current context.`,
+				},
+			},
+			Required: []string{"kind", "name"},
+		},
+	}
+}
+
+// CompareResult is the structured result of the compare tool.
+type CompareResult struct {
+	Left        string            `json:"left,omitempty"`
+	Right       string            `json:"right,omitempty"`
+	Identical   bool              `json:"identical"`
+	Differences []FieldDifference `json:"differences,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// FieldDifference describes a single difference between the two sides.
+type FieldDifference struct {
+	// Path is the path of the field, e.g. spec.template.spec.containers[name=web].image
+	Path string `json:"path"`
+	// Change is one of "added" (only on the right), "removed" (only on the left) or "changed".
+	Change string `json:"change"`
+	Left   any    `json:"left,omitempty"`
+	Right  any    `json:"right,omitempty"`
+}
+
+// compareTarget identifies one side of the comparison.
+type compareTarget struct {
+	kind, name, namespace, context string
+}
+
+func (c compareTarget) String() string {
+	var scope []string
+	if c.namespace != "" {
+		scope = append(scope, "namespace="+c.namespace)
+	}
+	if c.context != "" {
+		scope = append(scope, "context="+c.context)
+	}
+	if len(scope) == 0 {
+		return c.kind + "/" + c.name
+	}
+	return fmt.Sprintf("%s/%s (%s)", c.kind, c.name, strings.Join(scope, ", "))
+}
+
+func (c compareTarget) command() string {
+	command := fmt.Sprintf("kubectl get %s %s -o json", ShellQuote(c.kind), ShellQuote(c.name))
+	if c.namespace != "" {
+		command += " --namespace " + ShellQuote(c.namespace)
+	}
+	if c.context != "" {
+		command += " --context " + ShellQuote(c.context)
+	}
+	return command
+}
+
+func (t *CompareTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	stringArg := func(key string) string {
+		s, _ := args[key].(string)
+		return strings.TrimSpace(s)
+	}
+
+	left := compareTarget{
+		kind:      stringArg("kind"),
+		name:      stringArg("name"),
+		namespace: stringArg("left_namespace"),
+		context:   stringArg("left_context"),
+	}
+	right := compareTarget{
+		kind:      left.kind,
+		name:      stringArg("right_name"),
+		namespace: stringArg("right_namespace"),
+		context:   stringArg("right_context"),
+	}
+	if right.name == "" {
+		right.name = left.name
+	}
+
+	result := &CompareResult{Left: left.String(), Right: right.String()}
+	if left.kind == "" || left.name == "" {
+		result.Error = "both kind and name must be provided"
+		return result, nil
+	}
+
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	leftObj, err := t.fetch(ctx, left, env, workDir)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	rightObj, err := t.fetch(ctx, right, env, workDir)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	normalizeObject(leftObj)
+	normalizeObject(rightObj)
+
+	result.Differences = diffValues("", leftObj, rightObj)
+	result.Identical = len(result.Differences) == 0
+	return result, nil
+}
+
+// fetch gets the object for one side of the comparison as a generic map.
+func (t *CompareTool) fetch(ctx context.Context, target compareTarget, env []string, workDir string) (map[string]any, error) {
+	if err := consumeAPICalls(ctx, target.command()); err != nil {
+		return nil, err
+	}
+	execResult, err := t.executor.Execute(ctx, target.command(), env, workDir)
+	if err != nil {
+		return nil, fmt.Errorf("getting %s: %w", target, err)
+	}
+	if execResult.ExitCode != 0 || execResult.Error != "" {
+		return nil, fmt.Errorf("getting %s: %s", target, strings.TrimSpace(execResult.Error+" "+execResult.Stderr))
+	}
+
+	obj := make(map[string]any)
+	if err := json.Unmarshal([]byte(execResult.Stdout), &obj); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", target, err)
+	}
+	return obj, nil
+}
+
+// normalizeObject strips fields that are irrelevant when comparing two objects.
+func normalizeObject(obj map[string]any) {
+	delete(obj, "status")
+
+	metadata, ok := obj["metadata"].(map[string]any)
+	if !ok {
+		return
+	}
+	for _, field := range ignoredMetadataFields {
+		delete(metadata, field)
+	}
+	if annotations, ok := metadata["annotations"].(map[string]any); ok {
+		for _, annotation := range ignoredAnnotations {
+			delete(annotations, annotation)
+		}
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+}
+
+// diffValues recursively compares two JSON values and returns their differences.
+func diffValues(path string, left, right any) []FieldDifference {
+	if reflect.DeepEqual(left, right) {
+		return nil
+	}
+
+	switch l := left.(type) {
+	case map[string]any:
+		if r, ok := right.(map[string]any); ok {
+			return diffMaps(path, l, r)
+		}
+	case []any:
+		if r, ok := right.([]any); ok {
+			return diffLists(path, l, r)
+		}
+	}
+
+	switch {
+	case left == nil:
+		return []FieldDifference{{Path: path, Change: "added", Right: right}}
+	case right == nil:
+		return []FieldDifference{{Path: path, Change: "removed", Left: left}}
+	default:
+		return []FieldDifference{{Path: path, Change: "changed", Left: left, Right: right}}
+	}
+}
+
+func diffMaps(path string, left, right map[string]any) []FieldDifference {
+	keys := make(map[string]bool)
+	for k := range left {
+		keys[k] = true
+	}
+	for k := range right {
+		keys[k] = true
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	var diffs []FieldDifference
+	for _, k := range sortedKeys {
+		diffs = append(diffs, diffValues(joinPath(path, k), left[k], right[k])...)
+	}
+	return diffs
+}
+
+// diffLists compares lists; lists of named objects (containers, env vars, ports, volumes, ...)
+// are matched by name so that reordering does not produce spurious differences.
+func diffLists(path string, left, right []any) []FieldDifference {
+	leftByName, leftNamed := indexByName(left)
+	rightByName, rightNamed := indexByName(right)
+	if leftNamed && rightNamed {
+		return diffMaps(path, leftByName, rightByName)
+	}
+
+	var diffs []FieldDifference
+	for i := 0; i < max(len(left), len(right)); i++ {
+		var l, r any
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		diffs = append(diffs, diffValues(fmt.Sprintf("%s[%d]", path, i), l, r)...)
+	}
+	return diffs
+}
+
+// indexByName returns the list keyed by "[name=<name>]" if every element is an object with a unique name.
+func indexByName(list []any) (map[string]any, bool) {
+	if len(list) == 0 {
+		return nil, false
+	}
+	byName := make(map[string]any, len(list))
+	for _, item := range list {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		name, ok := obj["name"].(string)
+		if !ok {
+			return nil, false
+		}
+		key := "[name=" + name + "]"
+		if _, exists := byName[key]; exists {
+			return nil, false
+		}
+		byName[key] = item
+	}
+	return byName, true
+}
+
+func joinPath(path, key string) string {
+	if strings.HasPrefix(key, "[") || path == "" {
+		return path + key
+	}
+	return path + "." + key
+}
+
+// ShellQuote quotes s for safe use as a single word in a bash command.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (t *CompareTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "no", the compare tool only reads resources.
+func (t *CompareTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCompareNormalizedObjects(t *testing.T) {
+	parse := func(s string) map[string]any {
+		obj := make(map[string]any)
+		if err := json.Unmarshal([]byte(s), &obj); err != nil {
+			t.Fatalf("parsing %q: %v", s, err)
+		}
+		normalizeObject(obj)
+		return obj
+	}
+
+	staging := parse(`{
+		"metadata": {"name": "web", "namespace": "staging", "resourceVersion": "1", "uid": "a",
+			"annotations": {"deployment.kubernetes.io/revision": "3"}},
+		"spec": {"replicas": 1, "template": {"spec": {"containers": [
+			{"name": "sidecar", "image": "envoy:1"},
+			{"name": "web", "image": "web:1", "env": [{"name": "MODE", "value": "debug"}]}
+		]}}},
+		"status": {"readyReplicas": 1}
+	}`)
+	prod := parse(`{
+		"metadata": {"name": "web", "namespace": "prod", "resourceVersion": "2", "uid": "b",
+			"annotations": {"deployment.kubernetes.io/revision": "7"}},
+		"spec": {"replicas": 3, "template": {"spec": {"containers": [
+			{"name": "web", "image": "web:2", "env": [{"name": "MODE", "value": "debug"}]},
+			{"name": "sidecar", "image": "envoy:1"}
+		]}}},
+		"status": {"readyReplicas": 0}
+	}`)
+
+	got := diffValues("", staging, prod)
+	want := []FieldDifference{
+		{Path: "spec.replicas", Change: "changed", Left: float64(1), Right: float64(3)},
+		{Path: "spec.template.spec.containers[name=web].image", Change: "changed", Left: "web:1", Right: "web:2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffValues() = %+v, want %+v", got, want)
+	}
+
+	if diffs := diffValues("", staging, staging); len(diffs) != 0 {
+		t.Errorf("expected no differences comparing an object with itself, got %+v", diffs)
+	}
+}
+
+func TestDiffValuesAddedRemoved(t *testing.T) {
+	left := map[string]any{"a": "1", "args": []any{"--x"}}
+	right := map[string]any{"b": "2", "args": []any{"--x", "--y"}}
+
+	got := diffValues("", left, right)
+	want := []FieldDifference{
+		{Path: "a", Change: "removed", Left: "1"},
+		{Path: "args[1]", Change: "added", Right: "--y"},
+		{Path: "b", Change: "added", Right: "2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffValues() = %+v, want %+v", got, want)
+	}
+}
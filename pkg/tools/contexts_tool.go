@@ -0,0 +1,76 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+)
+
+// ListContextsTool lists the contexts of the kubeconfig, merged from all its
+// files, so that the model can target clusters by their context name.
+type ListContextsTool struct{}
+
+func NewListContextsTool() *ListContextsTool {
+	return &ListContextsTool{}
+}
+
+func (t *ListContextsTool) Name() string {
+	return "list_contexts"
+}
+
+func (t *ListContextsTool) Description() string {
+	return `Lists the contexts of the kubeconfig: their name, cluster, user and default namespace, and which one is current.
+Commands run in the current context. To run a kubectl command against another cluster, target its context by name: with the context field of the kubectl tool, or with --context in command lines, e.g. "kubectl get nodes --context prod".
+Use this tool when the user refers to a cluster by name, or before acting on a cluster you are not sure is the current one.`
+}
+
+func (t *ListContextsTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type:       gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{},
+		},
+	}
+}
+
+// ListContextsResult is the result of the list_contexts tool.
+type ListContextsResult struct {
+	Contexts []KubeContext `json:"contexts"`
+	Error    string        `json:"error,omitempty"`
+}
+
+func (t *ListContextsTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig, _ := ctx.Value(KubeconfigKey).(string)
+	contexts, err := KubeContexts(kubeconfig)
+	if err != nil {
+		return &ListContextsResult{Contexts: []KubeContext{}, Error: err.Error()}, nil
+	}
+	if contexts == nil {
+		contexts = []KubeContext{}
+	}
+	return &ListContextsResult{Contexts: contexts}, nil
+}
+
+func (t *ListContextsTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+func (t *ListContextsTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
@@ -17,8 +17,10 @@ package tools
 import (
 	"context"
 	"fmt"
-	"os"
+	"maps"
+	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
@@ -27,17 +29,30 @@ import (
 
 // CustomToolConfig defines the structure for configuring a custom tool.
 type CustomToolConfig struct {
-	Name          string `yaml:"name"`
-	Description   string `yaml:"description"`
-	Command       string `yaml:"command"`
-	CommandDesc   string `yaml:"command_desc"`
-	IsInteractive bool   `yaml:"is_interactive"`
+	Name          string `yaml:"name" json:"name"`
+	Description   string `yaml:"description" json:"description"`
+	Command       string `yaml:"command" json:"command,omitempty"`
+	CommandDesc   string `yaml:"command_desc" json:"command_desc,omitempty"`
+	IsInteractive bool   `yaml:"is_interactive" json:"is_interactive,omitempty"`
+
+	// Parameters is the schema of the arguments of a tool with a command
+	// template, an object whose properties are strings, numbers, integers,
+	// booleans or arrays of strings.
+	Parameters *gollm.Schema `yaml:"parameters" json:"parameters,omitempty"`
+	// CommandTemplate is the command line of the tool, a Go template of its
+	// arguments, e.g. "argocd app get {{.app}}{{if .refresh}} --refresh{{end}}".
+	// The strings are quoted for the shell, so that the arguments are not
+	// interpreted by the shell. The tools without a template take a command
+	// line, prefixed with Command.
+	CommandTemplate string `yaml:"command_template" json:"command_template,omitempty"`
 }
 
 // CustomTool implements the Tool interface for external commands.
 type CustomTool struct {
 	config   CustomToolConfig
 	executor sandbox.Executor
+	// template is the parsed command template, nil for the tools taking a command line.
+	template *template.Template
 }
 
 // NewCustomTool creates a new CustomTool instance.
@@ -45,11 +60,52 @@ func NewCustomTool(config CustomToolConfig) (*CustomTool, error) {
 	if config.Name == "" {
 		return nil, fmt.Errorf("custom tool name cannot be empty")
 	}
-	if len(config.Command) == 0 {
-		return nil, fmt.Errorf("custom tool command cannot be empty for tool %q", config.Name)
+	if config.CommandTemplate == "" {
+		if len(config.Command) == 0 {
+			return nil, fmt.Errorf("custom tool command cannot be empty for tool %q", config.Name)
+		}
+		if config.Parameters != nil {
+			return nil, fmt.Errorf("custom tool %q has parameters but no command_template", config.Name)
+		}
+		return &CustomTool{config: config}, nil
+	}
+
+	if err := validateCustomToolParameters(config.Parameters); err != nil {
+		return nil, fmt.Errorf("invalid parameters for tool %q: %w", config.Name, err)
+	}
+	tmpl, err := template.New(config.Name).Funcs(template.FuncMap{"join": strings.Join}).Parse(config.CommandTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command_template for tool %q: %w", config.Name, err)
 	}
+	return &CustomTool{config: config, template: tmpl}, nil
+}
 
-	return &CustomTool{config: config}, nil
+// validateCustomToolParameters checks the parameters of a tool with a command
+// template, which may have none.
+func validateCustomToolParameters(params *gollm.Schema) error {
+	if params == nil {
+		return nil
+	}
+	if params.Type != "" && params.Type != gollm.TypeObject {
+		return fmt.Errorf("type must be object, not %q", params.Type)
+	}
+	for name, property := range params.Properties {
+		switch property.Type {
+		case gollm.TypeString, gollm.TypeNumber, gollm.TypeInteger, gollm.TypeBoolean:
+		case gollm.TypeArray:
+			if property.Items != nil && property.Items.Type != gollm.TypeString {
+				return fmt.Errorf("parameter %q: only arrays of strings are supported", name)
+			}
+		default:
+			return fmt.Errorf("parameter %q: unsupported type %q, expected string, number, integer, boolean or array", name, property.Type)
+		}
+	}
+	for _, name := range params.Required {
+		if _, ok := params.Properties[name]; !ok {
+			return fmt.Errorf("required parameter %q is not declared", name)
+		}
+	}
+	return nil
 }
 
 // Name returns the tool's name.
@@ -62,8 +118,31 @@ func (t *CustomTool) Description() string {
 	return t.config.Description
 }
 
+// customToolModifiesResource is the schema of the claim of the model on the
+// effect of its command.
+var customToolModifiesResource = &gollm.Schema{
+	Type: gollm.TypeString,
+	Description: `Whether the command modifies a resource.
+Possible values:
+- "yes" if the command modifies a resource
+- "no" if the command does not modify a resource
+- "unknown" if the command's effect on the resource is unknown
+`,
+}
+
 // FunctionDefinition returns the tool's function definition.
 func (t *CustomTool) FunctionDefinition() *gollm.FunctionDefinition {
+	if t.template != nil {
+		params := &gollm.Schema{Type: gollm.TypeObject, Properties: map[string]*gollm.Schema{}}
+		if t.config.Parameters != nil {
+			params.Properties = maps.Clone(t.config.Parameters.Properties)
+			params.Required = t.config.Parameters.Required
+		}
+		if _, ok := params.Properties["modifies_resource"]; !ok {
+			params.Properties["modifies_resource"] = customToolModifiesResource
+		}
+		return &gollm.FunctionDefinition{Name: t.Name(), Description: t.Description(), Parameters: params}
+	}
 	return &gollm.FunctionDefinition{
 		Name:        t.Name(),
 		Description: t.Description(),
@@ -74,20 +153,95 @@ func (t *CustomTool) FunctionDefinition() *gollm.FunctionDefinition {
 					Type:        gollm.TypeString,
 					Description: t.config.CommandDesc,
 				},
-				"modifies_resource": {
-					Type: gollm.TypeString,
-					Description: `Whether the command modifies a resource.
-Possible values:
-- "yes" if the command modifies a resource
-- "no" if the command does not modify a resource
-- "unknown" if the command's effect on the resource is unknown
-`,
-				},
+				"modifies_resource": customToolModifiesResource,
 			},
 		},
 	}
 }
 
+// RenderCommand renders the command template with the arguments of a call,
+// quoted for the shell. The tools without a template take a command line,
+// and return "".
+func (t *CustomTool) RenderCommand(args map[string]any) (string, error) {
+	if t.template == nil {
+		return "", nil
+	}
+	var properties map[string]*gollm.Schema
+	if t.config.Parameters != nil {
+		properties = t.config.Parameters.Properties
+		for _, name := range t.config.Parameters.Required {
+			if _, ok := args[name]; !ok {
+				return "", fmt.Errorf("missing argument %q", name)
+			}
+		}
+	}
+
+	data := make(map[string]any, len(properties))
+	for name, property := range properties {
+		value, err := templateValue(args, name, property.Type)
+		if err != nil {
+			return "", err
+		}
+		data[name] = value
+	}
+	var b strings.Builder
+	if err := t.template.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("rendering the command of tool %q: %w", t.Name(), err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// templateValue returns the value of an argument in the command template: the
+// strings are quoted, and the missing arguments are empty, so that the
+// template can test them with {{if}} or {{with}}.
+func templateValue(args map[string]any, name string, typ gollm.SchemaType) (any, error) {
+	value := args[name]
+	switch typ {
+	case gollm.TypeBoolean:
+		if value == nil {
+			return false, nil
+		}
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a boolean, got %v", name, value)
+		}
+		return b, nil
+	case gollm.TypeNumber, gollm.TypeInteger:
+		switch v := value.(type) {
+		case nil:
+			return "", nil
+		case float64:
+			if typ == gollm.TypeInteger && v != float64(int64(v)) {
+				return nil, fmt.Errorf("%s must be an integer, got %v", name, v)
+			}
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case int:
+			return strconv.Itoa(v), nil
+		}
+		return nil, fmt.Errorf("%s must be a number, got %v", name, value)
+	case gollm.TypeArray:
+		items, err := stringsArg(args, name)
+		if err != nil {
+			return nil, err
+		}
+		quoted := make([]string, len(items))
+		for i, item := range items {
+			quoted[i] = quoteWord(item)
+		}
+		return quoted, nil
+	}
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		if v == "" {
+			return "", nil
+		}
+		return quoteWord(v), nil
+	}
+	return nil, fmt.Errorf("%s must be a string, got %v", name, value)
+}
+
 // addCommandPrefix adds the tool's command prefix to the input command if needed.
 // It only adds the prefix if the command is a simple command (no pipes, etc.)
 // and doesn't already start with the prefix.
@@ -127,19 +281,30 @@ func (t *CustomTool) addCommandPrefix(inputCmd string) (string, error) {
 func (t *CustomTool) Run(ctx context.Context, args map[string]any) (any, error) {
 	var command string
 	cmdVal, ok := args["command"]
-	if !ok {
+	switch {
+	case ok && t.template != nil:
+		// The command rendered when the call was parsed, possibly edited by the user.
+		command, _ = cmdVal.(string)
+	case t.template != nil:
+		var err error
+		if command, err = t.RenderCommand(args); err != nil {
+			return nil, err
+		}
+	case !ok:
 		return nil, fmt.Errorf("command not found in args")
+	default:
+		var err error
+		if command, err = t.addCommandPrefix(cmdVal.(string)); err != nil {
+			return nil, fmt.Errorf("failed to process command: %w", err)
+		}
 	}
-	command = cmdVal.(string)
 
-	command, err := t.addCommandPrefix(command)
+	workDir := ctx.Value(WorkDirKey).(string)
+	env, err := toolEnv(ctx, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to process command: %w", err)
+		return nil, err
 	}
 
-	workDir := ctx.Value(WorkDirKey).(string)
-	env := os.Environ()
-
 	// Use the injected executor, or fallback to local if not set (e.g. for global instance)
 	executor := t.executor
 	if executor == nil {
@@ -165,5 +330,6 @@ func (t *CustomTool) CloneWithExecutor(executor sandbox.Executor) *CustomTool {
 	return &CustomTool{
 		config:   t.config,
 		executor: executor,
+		template: t.template,
 	}
 }
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"sigs.k8s.io/yaml"
 )
 
 func TestCustomTool_AddCommandPrefix(t *testing.T) {
@@ -176,3 +177,119 @@ func TestCustomTool_CloneWithExecutor(t *testing.T) {
 		t.Errorf("expected workdir '/tmp', got %q", mockExec.CapturedWorkDir)
 	}
 }
+
+const argocdToolsYAML = `
+- name: argocd_app_get
+  description: Shows the sync and health status of an Argo CD application.
+  parameters:
+    type: object
+    properties:
+      app:
+        type: string
+        description: The name of the application.
+      refresh:
+        type: boolean
+      timeout:
+        type: integer
+      show:
+        type: array
+        items:
+          type: string
+    required: [app]
+  command_template: argocd app get {{.app}}{{if .refresh}} --refresh{{end}}{{with .timeout}} --timeout {{.}}{{end}}{{range .show}} --show-params {{.}}{{end}}
+- name: argocd
+  description: The Argo CD CLI.
+  command: argocd
+  command_desc: The argocd command line, e.g. "argocd app list".
+`
+
+func TestCustomToolCommandTemplate(t *testing.T) {
+	var configs []CustomToolConfig
+	if err := yaml.Unmarshal([]byte(argocdToolsYAML), &configs); err != nil {
+		t.Fatalf("parsing tools: %v", err)
+	}
+	if got := configs[1].CommandDesc; got != `The argocd command line, e.g. "argocd app list".` {
+		t.Errorf("command_desc = %q", got)
+	}
+	tool, err := NewCustomTool(configs[0])
+	if err != nil {
+		t.Fatalf("NewCustomTool() error = %v", err)
+	}
+
+	definition := tool.FunctionDefinition()
+	if _, ok := definition.Parameters.Properties["app"]; !ok {
+		t.Errorf("parameters %v do not declare app", definition.Parameters.Properties)
+	}
+	if _, ok := definition.Parameters.Properties["modifies_resource"]; !ok {
+		t.Errorf("parameters %v do not declare modifies_resource", definition.Parameters.Properties)
+	}
+
+	tests := []struct {
+		args    map[string]any
+		want    string
+		wantErr string
+	}{
+		{args: map[string]any{"app": "web"}, want: "argocd app get web"},
+		{args: map[string]any{"app": "web", "refresh": true, "timeout": float64(30)}, want: "argocd app get web --refresh --timeout 30"},
+		{args: map[string]any{"app": "web; rm -rf /", "show": []any{"a b"}}, want: "argocd app get 'web; rm -rf /' --show-params 'a b'"},
+		{args: map[string]any{"refresh": true}, wantErr: `missing argument "app"`},
+		{args: map[string]any{"app": "web", "timeout": 1.5}, wantErr: "timeout must be an integer"},
+		{args: map[string]any{"app": []any{"web"}}, wantErr: "app must be a string"},
+	}
+	for _, tt := range tests {
+		got, err := tool.RenderCommand(tt.args)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("RenderCommand(%v) error = %v, want %q", tt.args, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("RenderCommand(%v) = %q, %v, want %q", tt.args, got, err, tt.want)
+		}
+	}
+
+	// The call is approved and run as the command it renders.
+	var toolset Tools
+	toolset.Init()
+	toolset.RegisterTool(tool)
+	call, err := toolset.ParseToolInvocation(context.Background(), "argocd_app_get", map[string]any{"app": "web", "modifies_resource": "no"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := call.Arguments(); len(got) != 2 || got["command"] != "argocd app get web" || got["modifies_resource"] != "no" {
+		t.Errorf("Arguments() = %v", got)
+	}
+	executor := &MockExecutor{}
+	ctx := context.WithValue(context.Background(), WorkDirKey, "/tmp")
+	if _, err := tool.CloneWithExecutor(executor).Run(ctx, call.Arguments()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if executor.CapturedCommand != "argocd app get web" {
+		t.Errorf("ran %q", executor.CapturedCommand)
+	}
+}
+
+func TestNewCustomToolInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{name: "no command", config: "name: x"},
+		{name: "parameters without template", config: "{name: x, command: x, parameters: {properties: {a: {type: string}}}}"},
+		{name: "object parameter", config: "{name: x, command_template: x, parameters: {properties: {a: {type: object}}}}"},
+		{name: "undeclared required parameter", config: "{name: x, command_template: x, parameters: {required: [a]}}"},
+		{name: "invalid template", config: "{name: x, command_template: '{{.a'}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config CustomToolConfig
+			if err := yaml.Unmarshal([]byte(tt.config), &config); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewCustomTool(config); err == nil {
+				t.Error("NewCustomTool() succeeded, want an error")
+			}
+		})
+	}
+}
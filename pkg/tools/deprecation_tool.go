@@ -0,0 +1,295 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DeprecationTool checks the cluster against the Kubernetes API deprecation tables
+// to find what would break when upgrading to a target version.
+type DeprecationTool struct {
+	executor sandbox.Executor
+}
+
+func NewDeprecationTool(executor sandbox.Executor) *DeprecationTool {
+	return &DeprecationTool{executor: executor}
+}
+
+func (t *DeprecationTool) Name() string {
+	return "deprecation_check"
+}
+
+func (t *DeprecationTool) Description() string {
+	return `Checks the cluster for Kubernetes APIs that are removed in a target version: the deprecated group versions the API server still serves, and the live resources last written through them (by kubectl apply, controllers, Helm, ...).
+Use this tool to answer questions like "what will break if I upgrade to 1.31?" and give an actionable list of resources to migrate.`
+}
+
+func (t *DeprecationTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"target_version": {
+					Type:        gollm.TypeString,
+					Description: `The Kubernetes version to upgrade to, e.g. "1.31". Defaults to the minor version following the cluster's version.`,
+				},
+			},
+		},
+	}
+}
+
+// DeprecationReport is the result of the deprecation_check tool.
+type DeprecationReport struct {
+	ServerVersion string `json:"serverVersion,omitempty"`
+	TargetVersion string `json:"targetVersion,omitempty"`
+	// RemovedAPIs lists the served APIs that are removed by the target version.
+	RemovedAPIs []RemovedAPI `json:"removedAPIs"`
+	// Resources lists the live resources last written through a removed API.
+	Resources []DeprecatedResource `json:"resources"`
+	Warnings  []string             `json:"warnings,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// RemovedAPI is a served API version that is removed by the target version.
+type RemovedAPI struct {
+	APIVersion  string `json:"apiVersion"`
+	Kind        string `json:"kind"`
+	RemovedIn   string `json:"removedIn"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// DeprecatedResource is a live resource whose manifests or clients still use a removed API.
+type DeprecatedResource struct {
+	Resource    string `json:"resource"`
+	Namespace   string `json:"namespace,omitempty"`
+	APIVersion  string `json:"apiVersion"`
+	RemovedIn   string `json:"removedIn"`
+	Replacement string `json:"replacement,omitempty"`
+	// Managers lists the field managers that wrote the resource through the removed API.
+	Managers []string `json:"managers,omitempty"`
+}
+
+func (t *DeprecationTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	targetArg, _ := args["target_version"].(string)
+	return t.check(ctx, targetArg, env, workDir)
+}
+
+func (t *DeprecationTool) check(ctx context.Context, targetArg string, env []string, workDir string) (*DeprecationReport, error) {
+	report := &DeprecationReport{RemovedAPIs: []RemovedAPI{}, Resources: []DeprecatedResource{}}
+
+	run := func(command string) (string, error) {
+		if err := consumeAPICalls(ctx, command); err != nil {
+			return "", err
+		}
+		execResult, err := t.executor.Execute(ctx, command, env, workDir)
+		if err != nil {
+			return "", err
+		}
+		if execResult.ExitCode != 0 || execResult.Error != "" {
+			return "", fmt.Errorf("%s", strings.TrimSpace(execResult.Stderr+" "+execResult.Error))
+		}
+		return execResult.Stdout, nil
+	}
+
+	versionOutput, err := run("kubectl version -o json")
+	if err != nil {
+		report.Error = fmt.Sprintf("getting the cluster version: %v", err)
+		return report, nil
+	}
+	var versions struct {
+		ServerVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"serverVersion"`
+	}
+	if err := json.Unmarshal([]byte(versionOutput), &versions); err != nil {
+		report.Error = fmt.Sprintf("parsing the cluster version: %v", err)
+		return report, nil
+	}
+	server, err := parseKubeVersion(versions.ServerVersion.GitVersion)
+	if err != nil {
+		report.Error = err.Error()
+		return report, nil
+	}
+	report.ServerVersion = versions.ServerVersion.GitVersion
+
+	target := kubeVersion{major: server.major, minor: server.minor + 1}
+	if targetArg != "" {
+		if target, err = parseKubeVersion(targetArg); err != nil {
+			report.Error = err.Error()
+			return report, nil
+		}
+	}
+	report.TargetVersion = target.String()
+
+	apiVersionsOutput, err := run("kubectl api-versions")
+	if err != nil {
+		report.Error = fmt.Sprintf("listing served API versions: %v", err)
+		return report, nil
+	}
+	served := make(map[string]bool)
+	for _, gv := range strings.Fields(apiVersionsOutput) {
+		served[gv] = true
+	}
+
+	// Collect the APIs removed by the target version, grouped by the kind's current API.
+	removedByKind := make(map[string]map[string]deprecatedAPI)
+	for key, api := range sortedRemovedAPIs() {
+		apiVersion, kind := splitAPIKey(key)
+		removedIn, _ := parseKubeVersion(api.removedIn)
+		if !target.atLeast(removedIn) {
+			continue
+		}
+		if served[apiVersion] {
+			report.RemovedAPIs = append(report.RemovedAPIs, RemovedAPI{
+				APIVersion:  apiVersion,
+				Kind:        kind,
+				RemovedIn:   api.removedIn,
+				Replacement: api.replacement,
+			})
+		}
+		if api.replacement == "" {
+			continue
+		}
+		resource := strings.ToLower(kind)
+		if group, _, ok := strings.Cut(api.replacement, "/"); ok {
+			resource += "." + group
+		}
+		if removedByKind[resource] == nil {
+			removedByKind[resource] = make(map[string]deprecatedAPI)
+		}
+		removedByKind[resource][apiVersion] = api
+	}
+
+	resources := make([]string, 0, len(removedByKind))
+	for resource := range removedByKind {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	// Scan live resources for objects still written through a removed API.
+	for i, resource := range resources {
+		ReportProgress(ctx, api.ProgressBlock{Label: "Scanning resources for removed APIs", Stage: resource, Done: i, Total: len(resources)})
+		removed := removedByKind[resource]
+		items, err := getKubectlItems(ctx, t.executor, "kubectl get "+resource+" --all-namespaces -o json", env, workDir)
+		if err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %v", resource, err))
+			continue
+		}
+		for _, item := range items {
+			for apiVersion, managers := range deprecatedAPIUsage(item, removed) {
+				api := removed[apiVersion]
+				report.Resources = append(report.Resources, DeprecatedResource{
+					Resource:    resourceRef(item.GetKind(), item.GetName()),
+					Namespace:   item.GetNamespace(),
+					APIVersion:  apiVersion,
+					RemovedIn:   api.removedIn,
+					Replacement: api.replacement,
+					Managers:    managers,
+				})
+			}
+		}
+	}
+	ReportProgress(ctx, api.ProgressBlock{Label: "Scanning resources for removed APIs", Done: len(resources), Total: len(resources)})
+	sort.SliceStable(report.Resources, func(i, j int) bool {
+		if report.Resources[i].Namespace != report.Resources[j].Namespace {
+			return report.Resources[i].Namespace < report.Resources[j].Namespace
+		}
+		return report.Resources[i].Resource < report.Resources[j].Resource
+	})
+	return report, nil
+}
+
+// deprecatedAPIUsage returns the removed API versions an object was written through,
+// from its managed fields and last-applied configuration, with the responsible managers.
+func deprecatedAPIUsage(obj unstructured.Unstructured, removed map[string]deprecatedAPI) map[string][]string {
+	usage := make(map[string][]string)
+	for _, entry := range obj.GetManagedFields() {
+		if _, ok := removed[entry.APIVersion]; ok {
+			usage[entry.APIVersion] = append(usage[entry.APIVersion], entry.Manager)
+		}
+	}
+
+	if lastApplied := obj.GetAnnotations()["kubectl.kubernetes.io/last-applied-configuration"]; lastApplied != "" {
+		var applied struct {
+			APIVersion string `json:"apiVersion"`
+		}
+		if err := json.Unmarshal([]byte(lastApplied), &applied); err == nil {
+			if _, ok := removed[applied.APIVersion]; ok {
+				if _, exists := usage[applied.APIVersion]; !exists {
+					usage[applied.APIVersion] = []string{"kubectl apply"}
+				}
+			}
+		}
+	}
+	return usage
+}
+
+// sortedRemovedAPIs returns the removed APIs table in a stable order.
+func sortedRemovedAPIs() iter.Seq2[string, deprecatedAPI] {
+	keys := make([]string, 0, len(removedAPIs))
+	for key := range removedAPIs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return func(yield func(string, deprecatedAPI) bool) {
+		for _, key := range keys {
+			if !yield(key, removedAPIs[key]) {
+				return
+			}
+		}
+	}
+}
+
+// splitAPIKey splits a removedAPIs key into its apiVersion and kind.
+func splitAPIKey(key string) (apiVersion, kind string) {
+	i := strings.LastIndex(key, "/")
+	return key[:i], key[i+1:]
+}
+
+func (t *DeprecationTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// IsAsync always returns true, the deprecation_check tool scans every resource
+// type with a removed API and reports its progress.
+func (t *DeprecationTool) IsAsync(args map[string]any) bool {
+	return true
+}
+
+// CheckModifiesResource always returns "no", the deprecation_check tool only reads resources.
+func (t *DeprecationTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
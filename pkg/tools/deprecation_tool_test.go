@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseKubeVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    kubeVersion
+		wantErr bool
+	}{
+		{in: "1.31", want: kubeVersion{1, 31}},
+		{in: "v1.30.4-gke.1348000", want: kubeVersion{1, 30}},
+		{in: "1.29+", want: kubeVersion{1, 29}},
+		{in: "31", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseKubeVersion(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseKubeVersion(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseKubeVersion(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDeprecatedAPIUsage(t *testing.T) {
+	obj := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "autoscaling/v2",
+		"kind":       "HorizontalPodAutoscaler",
+		"metadata": map[string]any{
+			"name": "web",
+			"annotations": map[string]any{
+				"kubectl.kubernetes.io/last-applied-configuration": `{"apiVersion":"autoscaling/v2beta1","kind":"HorizontalPodAutoscaler"}`,
+			},
+			"managedFields": []any{
+				map[string]any{"manager": "helm", "apiVersion": "autoscaling/v2beta2", "operation": "Update"},
+				map[string]any{"manager": "kube-controller-manager", "apiVersion": "autoscaling/v2", "operation": "Update"},
+			},
+		},
+	}}
+	removed := map[string]deprecatedAPI{
+		"autoscaling/v2beta1": removedAPIs["autoscaling/v2beta1/HorizontalPodAutoscaler"],
+		"autoscaling/v2beta2": removedAPIs["autoscaling/v2beta2/HorizontalPodAutoscaler"],
+	}
+
+	got := deprecatedAPIUsage(obj, removed)
+	want := map[string][]string{
+		"autoscaling/v2beta1": {"kubectl apply"},
+		"autoscaling/v2beta2": {"helm"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("deprecatedAPIUsage() = %v, want %v", got, want)
+	}
+}
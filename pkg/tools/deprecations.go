@@ -0,0 +1,111 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// deprecatedAPI describes a removed Kubernetes API group version for a kind.
+type deprecatedAPI struct {
+	removedIn   string
+	replacement string
+}
+
+// removedAPIs maps "apiVersion/Kind" to the Kubernetes version the API was removed in.
+// See https://kubernetes.io/docs/reference/using-api/deprecation-guide/
+var removedAPIs = map[string]deprecatedAPI{
+	"extensions/v1beta1/Deployment":                                       {"1.16", "apps/v1"},
+	"extensions/v1beta1/DaemonSet":                                        {"1.16", "apps/v1"},
+	"extensions/v1beta1/ReplicaSet":                                       {"1.16", "apps/v1"},
+	"extensions/v1beta1/NetworkPolicy":                                    {"1.16", "networking.k8s.io/v1"},
+	"extensions/v1beta1/PodSecurityPolicy":                                {"1.16", "policy/v1beta1"},
+	"extensions/v1beta1/Ingress":                                          {"1.22", "networking.k8s.io/v1"},
+	"apps/v1beta1/Deployment":                                             {"1.16", "apps/v1"},
+	"apps/v1beta1/StatefulSet":                                            {"1.16", "apps/v1"},
+	"apps/v1beta2/Deployment":                                             {"1.16", "apps/v1"},
+	"apps/v1beta2/DaemonSet":                                              {"1.16", "apps/v1"},
+	"apps/v1beta2/ReplicaSet":                                             {"1.16", "apps/v1"},
+	"apps/v1beta2/StatefulSet":                                            {"1.16", "apps/v1"},
+	"networking.k8s.io/v1beta1/Ingress":                                   {"1.22", "networking.k8s.io/v1"},
+	"networking.k8s.io/v1beta1/IngressClass":                              {"1.22", "networking.k8s.io/v1"},
+	"rbac.authorization.k8s.io/v1beta1/ClusterRole":                       {"1.22", "rbac.authorization.k8s.io/v1"},
+	"rbac.authorization.k8s.io/v1beta1/ClusterRoleBinding":                {"1.22", "rbac.authorization.k8s.io/v1"},
+	"rbac.authorization.k8s.io/v1beta1/Role":                              {"1.22", "rbac.authorization.k8s.io/v1"},
+	"rbac.authorization.k8s.io/v1beta1/RoleBinding":                       {"1.22", "rbac.authorization.k8s.io/v1"},
+	"apiextensions.k8s.io/v1beta1/CustomResourceDefinition":               {"1.22", "apiextensions.k8s.io/v1"},
+	"apiregistration.k8s.io/v1beta1/APIService":                           {"1.22", "apiregistration.k8s.io/v1"},
+	"admissionregistration.k8s.io/v1beta1/MutatingWebhookConfiguration":   {"1.22", "admissionregistration.k8s.io/v1"},
+	"admissionregistration.k8s.io/v1beta1/ValidatingWebhookConfiguration": {"1.22", "admissionregistration.k8s.io/v1"},
+	"scheduling.k8s.io/v1beta1/PriorityClass":                             {"1.22", "scheduling.k8s.io/v1"},
+	"storage.k8s.io/v1beta1/CSIDriver":                                    {"1.22", "storage.k8s.io/v1"},
+	"storage.k8s.io/v1beta1/CSINode":                                      {"1.22", "storage.k8s.io/v1"},
+	"storage.k8s.io/v1beta1/StorageClass":                                 {"1.22", "storage.k8s.io/v1"},
+	"storage.k8s.io/v1beta1/VolumeAttachment":                             {"1.22", "storage.k8s.io/v1"},
+	"certificates.k8s.io/v1beta1/CertificateSigningRequest":               {"1.22", "certificates.k8s.io/v1"},
+	"coordination.k8s.io/v1beta1/Lease":                                   {"1.22", "coordination.k8s.io/v1"},
+	"batch/v1beta1/CronJob":                                               {"1.25", "batch/v1"},
+	"policy/v1beta1/PodDisruptionBudget":                                  {"1.25", "policy/v1"},
+	"policy/v1beta1/PodSecurityPolicy":                                    {"1.25", ""},
+	"discovery.k8s.io/v1beta1/EndpointSlice":                              {"1.25", "discovery.k8s.io/v1"},
+	"events.k8s.io/v1beta1/Event":                                         {"1.25", "events.k8s.io/v1"},
+	"autoscaling/v2beta1/HorizontalPodAutoscaler":                         {"1.25", "autoscaling/v2"},
+	"node.k8s.io/v1beta1/RuntimeClass":                                    {"1.25", "node.k8s.io/v1"},
+	"autoscaling/v2beta2/HorizontalPodAutoscaler":                         {"1.26", "autoscaling/v2"},
+	"flowcontrol.apiserver.k8s.io/v1beta1/FlowSchema":                     {"1.26", "flowcontrol.apiserver.k8s.io/v1"},
+	"flowcontrol.apiserver.k8s.io/v1beta1/PriorityLevelConfiguration":     {"1.26", "flowcontrol.apiserver.k8s.io/v1"},
+	"storage.k8s.io/v1beta1/CSIStorageCapacity":                           {"1.27", "storage.k8s.io/v1"},
+	"flowcontrol.apiserver.k8s.io/v1beta2/FlowSchema":                     {"1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	"flowcontrol.apiserver.k8s.io/v1beta2/PriorityLevelConfiguration":     {"1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	"flowcontrol.apiserver.k8s.io/v1beta3/FlowSchema":                     {"1.32", "flowcontrol.apiserver.k8s.io/v1"},
+	"flowcontrol.apiserver.k8s.io/v1beta3/PriorityLevelConfiguration":     {"1.32", "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// kubeVersion is a Kubernetes minor release, e.g. 1.31.
+type kubeVersion struct {
+	major, minor int
+}
+
+// parseKubeVersion parses versions like "1.31", "v1.31.2" or "1.31+".
+func parseKubeVersion(s string) (kubeVersion, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	parts := strings.SplitN(s, ".", 3)
+	if len(parts) < 2 {
+		return kubeVersion{}, fmt.Errorf("invalid Kubernetes version %q, expected <major>.<minor>", s)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return kubeVersion{}, fmt.Errorf("invalid Kubernetes version %q: %w", s, err)
+	}
+	minor, err := strconv.Atoi(strings.TrimRight(parts[1], "+"))
+	if err != nil {
+		return kubeVersion{}, fmt.Errorf("invalid Kubernetes version %q: %w", s, err)
+	}
+	return kubeVersion{major: major, minor: minor}, nil
+}
+
+func (v kubeVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+// atLeast returns true if v is the same as or newer than other.
+func (v kubeVersion) atLeast(other kubeVersion) bool {
+	if v.major != other.major {
+		return v.major > other.major
+	}
+	return v.minor >= other.minor
+}
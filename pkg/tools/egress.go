@@ -0,0 +1,296 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// EgressRefusal is the refusal of a command that may send data out of the
+// host in air-gapped mode. It is sent to the model, so that it can re-plan.
+type EgressRefusal struct {
+	Command string `json:"command"`
+	// Reason is why the command is refused, e.g. "curl connects to example.com".
+	Reason string `json:"reason"`
+}
+
+func (r *EgressRefusal) Error() string {
+	return fmt.Sprintf("refusing to run %q: this session is air-gapped and %s", r.Command, r.Reason)
+}
+
+// networkPrograms are the programs that connect to other hosts whatever their arguments.
+var networkPrograms = map[string]bool{
+	"ssh": true, "scp": true, "sftp": true, "ftp": true, "rsync": true,
+	"gcloud": true, "gsutil": true, "aws": true, "az": true,
+	"crane": true, "skopeo": true, "oras": true,
+	"pip": true, "pip3": true, "npm": true, "apt": true, "apt-get": true,
+	"yum": true, "dnf": true, "apk": true, "brew": true,
+}
+
+// urlPrograms are the programs that connect to the URLs of their arguments.
+var urlPrograms = map[string]bool{"curl": true, "wget": true, "http": true, "https": true, "xh": true}
+
+// hostPrograms are the programs that connect to the host of their first argument.
+var hostPrograms = map[string]bool{"nc": true, "ncat": true, "netcat": true, "telnet": true, "ping": true, "traceroute": true}
+
+// networkSubcommands are the subcommands that connect to remote repositories or registries.
+var networkSubcommands = map[string]map[string]bool{
+	"git":    {"clone": true, "fetch": true, "pull": true, "push": true, "ls-remote": true, "submodule": true},
+	"helm":   {"repo": true, "pull": true, "push": true, "search": true, "dependency": true, "registry": true, "plugin": true},
+	"docker": {"pull": true, "push": true, "login": true, "search": true, "run": true, "create": true, "build": true},
+	"podman": {"pull": true, "push": true, "login": true, "search": true, "run": true, "create": true, "build": true},
+}
+
+// localPrograms are the programs known to stay on the host. The other
+// programs, e.g. python3 or openssl, may open connections themselves and are
+// refused, as their arguments cannot be checked.
+var localPrograms = map[string]bool{
+	"cd": true, "echo": true, "printf": true, "true": true, "false": true, "test": true, "[": true,
+	":": true, "pwd": true, "export": true, "set": true, "unset": true, "exit": true, "read": true,
+	"cat": true, "head": true, "tail": true, "grep": true, "egrep": true, "fgrep": true,
+	"awk": true, "gawk": true, "sed": true, "sort": true, "uniq": true, "wc": true, "cut": true,
+	"tr": true, "paste": true, "join": true, "column": true, "tee": true, "diff": true,
+	"jq": true, "yq": true, "base64": true, "date": true, "sleep": true, "seq": true,
+	"ls": true, "find": true, "stat": true, "file": true, "basename": true, "dirname": true,
+	"realpath": true, "readlink": true, "mkdir": true, "touch": true, "cp": true, "mv": true,
+	"rm": true, "ln": true, "chmod": true, "tar": true, "gzip": true, "gunzip": true, "zcat": true,
+	"md5sum": true, "sha256sum": true, "which": true, "type": true, "whoami": true, "id": true,
+	"hostname": true, "uname": true, "df": true, "du": true, "ps": true,
+}
+
+// shellPrograms run the script of their -c flag.
+var shellPrograms = map[string]bool{"sh": true, "bash": true, "zsh": true}
+
+// urlValueFlags are the flags of the url programs taking a value that is not a URL.
+var urlValueFlags = map[string]bool{
+	"-H": true, "--header": true, "-o": true, "--output": true, "-O": true, "--output-document": true,
+	"-X": true, "--request": true, "-d": true, "--data": true, "--data-raw": true, "--data-binary": true,
+	"-u": true, "--user": true, "-A": true, "--user-agent": true, "-w": true, "--write-out": true,
+	"-m": true, "--max-time": true, "--connect-timeout": true, "--cacert": true, "--cert": true,
+	"--key": true, "-T": true, "--upload-file": true, "-F": true, "--form": true, "-P": true,
+}
+
+// CheckEgress returns the refusal of a shell command that may send data out
+// of the host, or nil if it does not: connections to loopback addresses are
+// allowed, and kubectl may call apiServer, the Kubernetes API server of the
+// current context of its kubeconfig, but not other servers nor fetch
+// manifests from remote URLs. Only kubectl, helm, git and the programs known
+// to stay on the host, e.g. jq, may run.
+func CheckEgress(command string, apiServer string) *EgressRefusal {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return &EgressRefusal{Command: command, Reason: fmt.Sprintf("the command cannot be parsed: %v", err)}
+	}
+	calls, err := kubectl.Calls(command)
+	if err != nil {
+		return &EgressRefusal{Command: command, Reason: fmt.Sprintf("the command cannot be parsed: %v", err)}
+	}
+
+	var refusal *EgressRefusal
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if redirect, ok := node.(*syntax.Redirect); ok && refusal == nil && redirect.Word != nil {
+			refusal = checkEgressRedirect(redirect.Word.Lit())
+		}
+		return refusal == nil
+	})
+	for _, args := range calls {
+		if refusal != nil {
+			break
+		}
+		refusal = checkEgressArgs(args, apiServer)
+	}
+	if refusal != nil {
+		refusal.Command = command
+	}
+	return refusal
+}
+
+// checkEgressRedirect refuses the redirections to the network devices of
+// bash, e.g. "> /dev/tcp/example.com/80", to other hosts.
+func checkEgressRedirect(target string) *EgressRefusal {
+	for _, device := range []string{"/dev/tcp/", "/dev/udp/"} {
+		if rest, ok := strings.CutPrefix(target, device); ok {
+			host, _, _ := strings.Cut(rest, "/")
+			if !isLoopbackHost(host) {
+				return &EgressRefusal{Reason: fmt.Sprintf("it connects to %s through %s", host, strings.TrimSuffix(device, "/"))}
+			}
+		}
+	}
+	return nil
+}
+
+// checkEgressArgs returns the refusal of a program run with its arguments,
+// after its wrapper programs, or nil if it does not connect to other hosts.
+func checkEgressArgs(args []string, apiServer string) *EgressRefusal {
+	if len(args) == 0 {
+		return nil
+	}
+	program := filepath.Base(args[0])
+	switch {
+	case kubectl.IsKubectl(args[0]):
+		return checkKubectlEgress(args[1:], apiServer)
+	case networkPrograms[program]:
+		return &EgressRefusal{Reason: fmt.Sprintf("%s connects to other hosts", program)}
+	case urlPrograms[program]:
+		return checkURLEgress(program, args[1:])
+	case hostPrograms[program]:
+		// The host is the first argument that is neither a flag nor a number,
+		// e.g. "example.com" for "nc -w 3 example.com 80".
+		for _, arg := range args[1:] {
+			if _, err := strconv.ParseFloat(arg, 64); err == nil || strings.HasPrefix(arg, "-") {
+				continue
+			}
+			if !isLoopbackHost(arg) {
+				return &EgressRefusal{Reason: fmt.Sprintf("%s connects to %s", program, arg)}
+			}
+			break
+		}
+		return nil
+	case networkSubcommands[program] != nil:
+		if subcommand := firstPositional(args[1:]); networkSubcommands[program][subcommand] {
+			return &EgressRefusal{Reason: fmt.Sprintf("%s %s connects to remote repositories", program, subcommand)}
+		}
+		if program == "helm" {
+			return checkURLEgress(program, args[1:])
+		}
+		return nil
+	case shellPrograms[program]:
+		for i, arg := range args[1:] {
+			if arg == "-c" && i+2 < len(args) {
+				if refusal := CheckEgress(args[i+2], apiServer); refusal != nil {
+					return &EgressRefusal{Reason: refusal.Reason}
+				}
+			}
+		}
+		return nil
+	case !localPrograms[program]:
+		return &EgressRefusal{Reason: fmt.Sprintf("%s is not known to stay on the host", program)}
+	}
+	return nil
+}
+
+// checkKubectlEgress refuses the kubectl commands calling another API server
+// than apiServer, e.g. "kubectl --server=https://example.com get secrets",
+// which would send the credentials of the kubeconfig, and the ones reading
+// manifests or kustomizations from remote URLs, e.g. "kubectl apply -f https://...".
+func checkKubectlEgress(args []string, apiServer string) *EgressRefusal {
+	cmd := kubectl.ParseArgs(args)
+	if server, ok := cmd.Flag("-s", "--server"); ok && !isLoopbackServer(server) && !sameServer(server, apiServer) {
+		return &EgressRefusal{Reason: fmt.Sprintf("kubectl calls the API server %s, not the one of the kubeconfig", server)}
+	}
+	for i, arg := range args {
+		if arg == "-s" || arg == "--server" || strings.HasPrefix(arg, "--server=") || (i > 0 && (args[i-1] == "-s" || args[i-1] == "--server")) {
+			continue
+		}
+		_, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			value = arg
+		}
+		if host, remote := remoteURLHost(value); remote {
+			return &EgressRefusal{Reason: fmt.Sprintf("kubectl reads %s from %s", value, host)}
+		}
+	}
+	return nil
+}
+
+// serverURL parses the URL of an API server, which may omit the https scheme
+// and its default port, e.g. "10.0.0.1:6443".
+func serverURL(server string) (*url.URL, bool) {
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	u, err := url.Parse(server)
+	if err != nil || u.Hostname() == "" {
+		return nil, false
+	}
+	if u.Port() == "" {
+		port := "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u, true
+}
+
+// isLoopbackServer returns true if an API server is on a loopback address.
+func isLoopbackServer(server string) bool {
+	u, ok := serverURL(server)
+	return ok && isLoopbackHost(u.Hostname())
+}
+
+// sameServer returns true if two API server URLs have the same host and port.
+func sameServer(server, other string) bool {
+	u, ok := serverURL(server)
+	o, otherOK := serverURL(other)
+	return ok && otherOK && strings.EqualFold(u.Host, o.Host)
+}
+
+// checkURLEgress refuses the commands with a URL to another host, e.g.
+// "curl https://example.com". The positional arguments of curl and wget
+// without a scheme, e.g. "example.com/healthz", are URLs too.
+func checkURLEgress(program string, args []string) *EgressRefusal {
+	for i, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "-"):
+			// e.g. "--url=https://example.com".
+			_, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				continue
+			}
+			arg = value
+		case i > 0 && urlValueFlags[args[i-1]]:
+			continue
+		case program != "helm" && !strings.Contains(arg, "://"):
+			arg = "http://" + arg
+		}
+		if host, remote := remoteURLHost(arg); remote {
+			return &EgressRefusal{Reason: fmt.Sprintf("%s connects to %s", program, host)}
+		}
+	}
+	return nil
+}
+
+// remoteURLHost returns the host of a URL and true if it is not a loopback
+// address. Values that are not URLs with a scheme are not remote.
+func remoteURLHost(value string) (string, bool) {
+	if !strings.Contains(value, "://") {
+		return "", false
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	host := u.Hostname()
+	return host, !isLoopbackHost(host)
+}
+
+// isLoopbackHost returns true if host is "localhost" or a loopback address.
+func isLoopbackHost(host string) bool {
+	host = strings.Trim(host, "[]")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// DefaultSensitiveEnvPatterns are the environment variable name patterns
+// stripped from tool subprocess environments by default.
+// They cover LLM provider API keys (GEMINI_API_KEY, OPENAI_API_KEY, ...),
+// which the commands run by the model never need.
+var DefaultSensitiveEnvPatterns = []string{
+	"*_API_KEY",
+	"*_APIKEY",
+	"*_API_TOKEN",
+	"*_AUTH_TOKEN",
+}
+
+// baseEnvPatterns are the variables of the parent environment passed to tool
+// subprocesses (shell glob syntax, case-insensitive): those locating the
+// programs, the home directory, the locale, the proxies and the configuration
+// of the cloud CLIs run by the kubeconfig credential plugins, but not their
+// credentials. The other variables are set for the session, see EnvKey.
+var baseEnvPatterns = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "TZ", "TMPDIR",
+	"LANG", "LANGUAGE", "LC_*", "XDG_*",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "ALL_PROXY",
+	"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_CONFIG_FILE", "AWS_SHARED_CREDENTIALS_FILE",
+	"CLOUDSDK_CONFIG", "CLOUDSDK_CORE_PROJECT", "CLOUDSDK_ACTIVE_CONFIG_NAME", "GOOGLE_APPLICATION_CREDENTIALS",
+	"AZURE_CONFIG_DIR", "KUBECACHEDIR",
+}
+
+var (
+	sensitiveEnvMutex    sync.RWMutex
+	sensitiveEnvPatterns = DefaultSensitiveEnvPatterns
+)
+
+// SetSensitiveEnvPatterns configures the environment variable name patterns
+// (shell glob syntax, case-insensitive) that are stripped from the parent
+// environment before it is passed to tool subprocesses.
+// An empty list disables sanitization.
+func SetSensitiveEnvPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid environment variable pattern %q: %w", pattern, err)
+		}
+	}
+
+	sensitiveEnvMutex.Lock()
+	defer sensitiveEnvMutex.Unlock()
+	sensitiveEnvPatterns = patterns
+	return nil
+}
+
+// isSensitiveEnvVar returns true if the variable name matches one of the sensitive patterns.
+func isSensitiveEnvVar(name string) bool {
+	sensitiveEnvMutex.RLock()
+	defer sensitiveEnvMutex.RUnlock()
+
+	name = strings.ToUpper(name)
+	for _, pattern := range sensitiveEnvPatterns {
+		if ok, _ := path.Match(strings.ToUpper(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeEnv returns a copy of env ("KEY=VALUE" pairs) without the sensitive variables.
+func sanitizeEnv(env []string) []string {
+	sanitized := make([]string, 0, len(env))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if isSensitiveEnvVar(name) {
+			continue
+		}
+		sanitized = append(sanitized, kv)
+	}
+	return sanitized
+}
+
+// baseEnv returns the variables of the current process environment matching
+// baseEnvPatterns, without the sensitive ones.
+func baseEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		for _, pattern := range baseEnvPatterns {
+			if ok, _ := path.Match(pattern, strings.ToUpper(name)); ok {
+				env = append(env, kv)
+				break
+			}
+		}
+	}
+	return sanitizeEnv(env)
+}
+
+// toolEnv builds the environment for a tool subprocess.
+// It starts from the base variables of the current process environment (see
+// baseEnv), then applies the session-scoped variables from the context
+// (see EnvKey), and finally sets KUBECONFIG if a kubeconfig is provided.
+func toolEnv(ctx context.Context, kubeconfig string) ([]string, error) {
+	env := baseEnv()
+
+	if sessionEnv, ok := ctx.Value(EnvKey).([]string); ok {
+		env = append(env, sessionEnv...)
+	}
+
+	if kubeconfig != "" {
+		kubeconfig, err := ExpandShellVar(kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, "KUBECONFIG="+kubeconfig)
+	}
+
+	return env, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestToolEnv(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "secret")
+	t.Setenv("openai_api_key", "secret")
+	t.Setenv("AWS_PROFILE", "dev")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("DATABASE_PASSWORD", "secret")
+	t.Setenv("PATH", "/usr/bin:/bin")
+
+	ctx := context.WithValue(context.Background(), EnvKey, []string{"HELM_NAMESPACE=apps", "MY_API_KEY=explicit"})
+	env, err := toolEnv(ctx, "/tmp/kubeconfig")
+	if err != nil {
+		t.Fatalf("toolEnv: %v", err)
+	}
+
+	for _, want := range []string{"PATH=/usr/bin:/bin", "AWS_PROFILE=dev", "HELM_NAMESPACE=apps", "MY_API_KEY=explicit", "KUBECONFIG=/tmp/kubeconfig"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("expected %q in tool environment", want)
+		}
+	}
+	for _, unwanted := range []string{"GEMINI_API_KEY=secret", "openai_api_key=secret", "AWS_SECRET_ACCESS_KEY=secret", "DATABASE_PASSWORD=secret"} {
+		if slices.Contains(env, unwanted) {
+			t.Errorf("expected %q to be stripped from tool environment", unwanted)
+		}
+	}
+}
+
+func TestSetSensitiveEnvPatterns(t *testing.T) {
+	t.Cleanup(func() { SetSensitiveEnvPatterns(DefaultSensitiveEnvPatterns) })
+
+	if err := SetSensitiveEnvPatterns([]string{"["}); err == nil {
+		t.Fatalf("expected error for invalid pattern")
+	}
+
+	if err := SetSensitiveEnvPatterns([]string{"AWS_*"}); err != nil {
+		t.Fatalf("SetSensitiveEnvPatterns: %v", err)
+	}
+	got := sanitizeEnv([]string{"AWS_PROFILE=dev", "GEMINI_API_KEY=secret", "PATH=/bin"})
+	want := []string{"GEMINI_API_KEY=secret", "PATH=/bin"}
+	if !slices.Equal(got, want) {
+		t.Errorf("sanitizeEnv() = %v, want %v", got, want)
+	}
+}
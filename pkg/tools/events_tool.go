@@ -0,0 +1,393 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	defaultEventsWindow = time.Hour
+	defaultEventsLimit  = 50
+	// maxEventMessageLength bounds the length of the messages of the timeline,
+	// some controllers report whole manifests in their events.
+	maxEventMessageLength = 300
+)
+
+// eventKindAliases are the short names and plurals accepted for the kinds of
+// the involved objects, e.g. "deploy/web" or "pods/web-0".
+var eventKindAliases = map[string]string{
+	"po": "pod", "pods": "pod",
+	"deploy": "deployment", "deployments": "deployment",
+	"rs": "replicaset", "replicasets": "replicaset",
+	"sts": "statefulset", "statefulsets": "statefulset",
+	"ds": "daemonset", "daemonsets": "daemonset",
+	"svc": "service", "services": "service",
+	"no": "node", "nodes": "node",
+	"pvc": "persistentvolumeclaim", "persistentvolumeclaims": "persistentvolumeclaim",
+	"pv": "persistentvolume", "persistentvolumes": "persistentvolume",
+	"hpa": "horizontalpodautoscaler", "horizontalpodautoscalers": "horizontalpodautoscaler",
+	"jobs": "job", "cj": "cronjob", "cronjobs": "cronjob",
+	"ing": "ingress", "ingresses": "ingress",
+}
+
+// EventsTool returns a condensed timeline of the Kubernetes events, so that
+// the model does not need to read the raw, repetitive output of
+// "kubectl get events".
+type EventsTool struct {
+	executor sandbox.Executor
+}
+
+func NewEventsTool(executor sandbox.Executor) *EventsTool {
+	return &EventsTool{executor: executor}
+}
+
+func (t *EventsTool) Name() string {
+	return "events"
+}
+
+func (t *EventsTool) Description() string {
+	return `Returns a condensed timeline of the Kubernetes events of a namespace during a time window (by default the last hour).
+Repeated events are merged into a single entry with their count and the times they were first and last seen.
+The events can be filtered by involved object, reason and type.
+Prefer this tool over "kubectl get events", whose output is long and repetitive.`
+}
+
+func (t *EventsTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"namespace": {
+					Type:        gollm.TypeString,
+					Description: `The namespace of the events. Defaults to the current namespace.`,
+				},
+				"all_namespaces": {
+					Type:        gollm.TypeBoolean,
+					Description: `Return the events of all the namespaces.`,
+				},
+				"object": {
+					Type:        gollm.TypeString,
+					Description: `Only return the events of this object, as kind/name (e.g. "pod/web-0", "deploy/web") or a name.`,
+				},
+				"reason": {
+					Type:        gollm.TypeString,
+					Description: `Only return the events with these reasons, comma-separated, e.g. "BackOff,FailedScheduling".`,
+				},
+				"type": {
+					Type:        gollm.TypeString,
+					Description: `Only return the events of this type, "Warning" or "Normal".`,
+				},
+				"since": {
+					Type:        gollm.TypeString,
+					Description: `How far back to look, as a duration, e.g. "10m", "1h", "24h", "7d". Defaults to "1h".`,
+				},
+				"limit": {
+					Type:        gollm.TypeInteger,
+					Description: `The maximum number of entries of the timeline, the most recent ones are kept. Defaults to 50.`,
+				},
+			},
+		},
+	}
+}
+
+// EventsResult is the result of the events tool.
+type EventsResult struct {
+	Namespace string    `json:"namespace,omitempty"`
+	Since     time.Time `json:"since"`
+	// Events is the timeline of the events, from the oldest to the most recent.
+	Events []EventSummary `json:"events"`
+	// Omitted is the number of older entries left out of the timeline by the limit.
+	Omitted int    `json:"omitted,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// EventSummary is an entry of the events timeline, merging the repetitions
+// of an event.
+type EventSummary struct {
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	// Object is the involved object, e.g. "Pod/web-0", prefixed with its
+	// namespace when listing all the namespaces.
+	Object  string `json:"object"`
+	Message string `json:"message"`
+	Count   int64  `json:"count"`
+}
+
+// eventsFilter selects the events of the timeline.
+type eventsFilter struct {
+	// kind and name of the involved object, empty for any.
+	kind, name string
+	// reasons are the lowercased reasons, empty for any.
+	reasons map[string]bool
+	// eventType is "Warning" or "Normal", empty for any.
+	eventType string
+	since     time.Time
+}
+
+func (t *EventsTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	namespace, _ := args["namespace"].(string)
+	allNamespaces, _ := args["all_namespaces"].(bool)
+	object, _ := args["object"].(string)
+	reason, _ := args["reason"].(string)
+	eventType, _ := args["type"].(string)
+	sinceArg, _ := args["since"].(string)
+
+	result := &EventsResult{Namespace: namespace, Events: []EventSummary{}}
+
+	window := defaultEventsWindow
+	if sinceArg != "" {
+		d, err := parseHistoryWindow(sinceArg)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		window = d
+	}
+	result.Since = time.Now().Add(-window).UTC()
+
+	filter, err := newEventsFilter(object, reason, eventType, result.Since)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	limit := defaultEventsLimit
+	if n := intArg(args["limit"]); n > 0 {
+		limit = n
+	}
+
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	command := "kubectl get events -o json"
+	switch {
+	case allNamespaces:
+		command += " --all-namespaces"
+		result.Namespace = ""
+	case namespace != "":
+		command += " --namespace " + ShellQuote(namespace)
+	}
+	// The selectors are only an optimization, the items are filtered again.
+	var selectors []string
+	if filter.name != "" {
+		selectors = append(selectors, "involvedObject.name="+filter.name)
+	}
+	if filter.eventType != "" {
+		selectors = append(selectors, "type="+filter.eventType)
+	}
+	if len(selectors) > 0 {
+		command += " --field-selector " + ShellQuote(strings.Join(selectors, ","))
+	}
+
+	items, err := getKubectlItems(ctx, t.executor, command, env, workDir)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Events, result.Omitted = eventsTimeline(items, filter, allNamespaces, limit)
+	return result, nil
+}
+
+// newEventsFilter parses the filter arguments of the events tool.
+func newEventsFilter(object, reason, eventType string, since time.Time) (*eventsFilter, error) {
+	filter := &eventsFilter{since: since}
+	if object = strings.TrimSpace(object); object != "" {
+		kind, name, ok := strings.Cut(object, "/")
+		if !ok {
+			kind, name = "", kind
+		}
+		kind = strings.ToLower(kind)
+		if alias, ok := eventKindAliases[kind]; ok {
+			kind = alias
+		}
+		if name == "" || strings.ContainsAny(name, ",=") {
+			return nil, fmt.Errorf("invalid object %q, expected kind/name or a name", object)
+		}
+		filter.kind, filter.name = kind, name
+	}
+	for _, r := range strings.Split(reason, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			if filter.reasons == nil {
+				filter.reasons = map[string]bool{}
+			}
+			filter.reasons[strings.ToLower(r)] = true
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(eventType)) {
+	case "":
+	case "warning":
+		filter.eventType = "Warning"
+	case "normal":
+		filter.eventType = "Normal"
+	default:
+		return nil, fmt.Errorf("invalid type %q, expected Warning or Normal", eventType)
+	}
+	return filter, nil
+}
+
+// matches returns true if the event is selected by the filter.
+func (f *eventsFilter) matches(event *unstructured.Unstructured, lastSeen time.Time) bool {
+	if lastSeen.Before(f.since) {
+		return false
+	}
+	kind, _, _ := unstructured.NestedString(event.Object, "involvedObject", "kind")
+	name, _, _ := unstructured.NestedString(event.Object, "involvedObject", "name")
+	if f.name != "" && name != f.name {
+		return false
+	}
+	if f.kind != "" && strings.ToLower(kind) != f.kind {
+		return false
+	}
+	reason, _, _ := unstructured.NestedString(event.Object, "reason")
+	if f.reasons != nil && !f.reasons[strings.ToLower(reason)] {
+		return false
+	}
+	eventType, _, _ := unstructured.NestedString(event.Object, "type")
+	return f.eventType == "" || eventType == f.eventType
+}
+
+// eventsTimeline returns the events selected by the filter, merging the ones
+// with the same object, type, reason and message, from the oldest to the most
+// recent. Only the limit most recent entries are returned, with the number of
+// the omitted ones.
+func eventsTimeline(items []unstructured.Unstructured, filter *eventsFilter, withNamespace bool, limit int) ([]EventSummary, int) {
+	// The entries are keyed by their fields without the times and count.
+	merged := map[EventSummary]*EventSummary{}
+	for i := range items {
+		event := &items[i]
+		firstSeen, lastSeen := eventTimes(event)
+		if !filter.matches(event, lastSeen) {
+			continue
+		}
+		kind, _, _ := unstructured.NestedString(event.Object, "involvedObject", "kind")
+		name, _, _ := unstructured.NestedString(event.Object, "involvedObject", "name")
+		object := kind + "/" + name
+		if withNamespace {
+			if namespace, _, _ := unstructured.NestedString(event.Object, "involvedObject", "namespace"); namespace != "" {
+				object = namespace + "/" + object
+			}
+		}
+		eventType, _, _ := unstructured.NestedString(event.Object, "type")
+		reason, _, _ := unstructured.NestedString(event.Object, "reason")
+		message, _, _ := unstructured.NestedString(event.Object, "message")
+		if message == "" {
+			message, _, _ = unstructured.NestedString(event.Object, "note")
+		}
+		message = strings.TrimSpace(message)
+		if len(message) > maxEventMessageLength {
+			message = message[:maxEventMessageLength] + "..."
+		}
+
+		key := EventSummary{Type: eventType, Reason: reason, Object: object, Message: message}
+		summary, ok := merged[key]
+		if !ok {
+			summary = &EventSummary{Type: eventType, Reason: reason, Object: object, Message: message, FirstSeen: firstSeen, LastSeen: lastSeen}
+			merged[key] = summary
+		}
+		summary.Count += eventCount(event)
+		if firstSeen.Before(summary.FirstSeen) {
+			summary.FirstSeen = firstSeen
+		}
+		if lastSeen.After(summary.LastSeen) {
+			summary.LastSeen = lastSeen
+		}
+	}
+
+	timeline := make([]EventSummary, 0, len(merged))
+	for _, summary := range merged {
+		timeline = append(timeline, *summary)
+	}
+	sort.Slice(timeline, func(i, j int) bool {
+		a, b := timeline[i], timeline[j]
+		if !a.LastSeen.Equal(b.LastSeen) {
+			return a.LastSeen.Before(b.LastSeen)
+		}
+		if a.Object != b.Object {
+			return a.Object < b.Object
+		}
+		return a.Reason+a.Message < b.Reason+b.Message
+	})
+	omitted := 0
+	if limit > 0 && len(timeline) > limit {
+		omitted = len(timeline) - limit
+		timeline = timeline[omitted:]
+	}
+	return timeline, omitted
+}
+
+// eventTimes returns the times an event was first and last seen. The events
+// of the events.k8s.io API, and the ones of recent clients, set eventTime and
+// series instead of firstTimestamp, lastTimestamp and count.
+func eventTimes(event *unstructured.Unstructured) (time.Time, time.Time) {
+	first := eventTime(event, []string{"firstTimestamp"}, []string{"eventTime"}, []string{"metadata", "creationTimestamp"})
+	last := eventTime(event, []string{"series", "lastObservedTime"}, []string{"lastTimestamp"}, []string{"eventTime"}, []string{"metadata", "creationTimestamp"})
+	if first.IsZero() {
+		first = last
+	}
+	return first, last
+}
+
+// eventTime returns the first of the timestamp fields that is set.
+func eventTime(event *unstructured.Unstructured, fields ...[]string) time.Time {
+	for _, field := range fields {
+		value, _, _ := unstructured.NestedString(event.Object, field...)
+		if value == "" {
+			continue
+		}
+		for _, layout := range []string{time.RFC3339Nano, time.RFC3339} {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t.UTC()
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// eventCount returns the number of occurrences of an event.
+func eventCount(event *unstructured.Unstructured) int64 {
+	if count, ok, _ := unstructured.NestedInt64(event.Object, "series", "count"); ok && count > 0 {
+		return count
+	}
+	if count, ok, _ := unstructured.NestedInt64(event.Object, "count"); ok && count > 0 {
+		return count
+	}
+	return 1
+}
+
+func (t *EventsTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "no", the events tool only reads events.
+func (t *EventsTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
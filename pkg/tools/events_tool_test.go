@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestEventsTimeline(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	event := func(kind, name, eventType, reason, message string, count int64, last time.Time) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]any{
+			"involvedObject": map[string]any{"kind": kind, "name": name, "namespace": "shop"},
+			"type":           eventType,
+			"reason":         reason,
+			"message":        message,
+			"count":          count,
+			"firstTimestamp": last.Add(-10 * time.Minute).Format(time.RFC3339),
+			"lastTimestamp":  last.Format(time.RFC3339),
+		}}
+	}
+	items := []unstructured.Unstructured{
+		event("Pod", "web-0", "Warning", "BackOff", "Back-off restarting failed container", 12, now.Add(-5*time.Minute)),
+		event("Pod", "web-0", "Warning", "BackOff", "Back-off restarting failed container", 3, now.Add(-time.Minute)),
+		event("Pod", "web-0", "Normal", "Pulled", "Container image pulled", 1, now.Add(-20*time.Minute)),
+		event("Pod", "web-1", "Warning", "BackOff", "Back-off restarting failed container", 1, now.Add(-2*time.Minute)),
+		event("Pod", "web-0", "Warning", "BackOff", "Back-off restarting failed container", 40, now.Add(-3*time.Hour)),
+		// An events.k8s.io event with a series instead of the timestamps and count.
+		{Object: map[string]any{
+			"involvedObject": map[string]any{"kind": "Node", "name": "node-a"},
+			"type":           "Warning",
+			"reason":         "NodeNotReady",
+			"note":           "Node is not ready",
+			"eventTime":      now.Add(-30 * time.Minute).Format(time.RFC3339Nano),
+			"series":         map[string]any{"count": int64(4), "lastObservedTime": now.Add(-4 * time.Minute).Format(time.RFC3339Nano)},
+		}},
+	}
+
+	tests := []struct {
+		name          string
+		object        string
+		reason        string
+		eventType     string
+		withNamespace bool
+		limit         int
+		want          []EventSummary
+		wantOmitted   int
+	}{
+		{
+			name:   "object, merged and windowed",
+			object: "po/web-0",
+			want: []EventSummary{
+				{FirstSeen: now.Add(-30 * time.Minute), LastSeen: now.Add(-20 * time.Minute), Type: "Normal", Reason: "Pulled", Object: "Pod/web-0", Message: "Container image pulled", Count: 1},
+				{FirstSeen: now.Add(-15 * time.Minute), LastSeen: now.Add(-time.Minute), Type: "Warning", Reason: "BackOff", Object: "Pod/web-0", Message: "Back-off restarting failed container", Count: 15},
+			},
+		},
+		{
+			name:      "reason and type, with namespaces",
+			reason:    "nodenotready, FailedScheduling",
+			eventType: "warning",
+			want: []EventSummary{
+				{FirstSeen: now.Add(-30 * time.Minute), LastSeen: now.Add(-4 * time.Minute), Type: "Warning", Reason: "NodeNotReady", Object: "Node/node-a", Message: "Node is not ready", Count: 4},
+			},
+		},
+		{
+			name:          "limit keeps the most recent",
+			reason:        "BackOff",
+			withNamespace: true,
+			limit:         1,
+			want: []EventSummary{
+				{FirstSeen: now.Add(-15 * time.Minute), LastSeen: now.Add(-time.Minute), Type: "Warning", Reason: "BackOff", Object: "shop/Pod/web-0", Message: "Back-off restarting failed container", Count: 15},
+			},
+			wantOmitted: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newEventsFilter(tt.object, tt.reason, tt.eventType, now.Add(-time.Hour))
+			if err != nil {
+				t.Fatalf("newEventsFilter() error = %v", err)
+			}
+			got, omitted := eventsTimeline(items, filter, tt.withNamespace, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventsTimeline() = %+v, want %+v", got, tt.want)
+			}
+			if omitted != tt.wantOmitted {
+				t.Errorf("omitted = %d, want %d", omitted, tt.wantOmitted)
+			}
+		})
+	}
+}
+
+func TestNewEventsFilterErrors(t *testing.T) {
+	for _, tt := range []struct{ object, eventType string }{
+		{object: "pod/"},
+		{object: "web,x"},
+		{eventType: "Error"},
+	} {
+		if _, err := newEventsFilter(tt.object, "", tt.eventType, time.Time{}); err == nil {
+			t.Errorf("newEventsFilter(%q, %q) expected an error", tt.object, tt.eventType)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// ExtractTool evaluates JSONPath or jq expressions against the result of the
+// previous tool call, a stored output or a live "kubectl get -o json", so
+// that the model asks for the fields it needs instead of reading whole objects.
+type ExtractTool struct {
+	executor sandbox.Executor
+	// policy decides which commands may run, nil if there is no kubectl policy.
+	policy *KubectlPolicy
+}
+
+func NewExtractTool(executor sandbox.Executor, policy *KubectlPolicy) *ExtractTool {
+	return &ExtractTool{executor: executor, policy: policy}
+}
+
+func (t *ExtractTool) Name() string {
+	return "extract"
+}
+
+func (t *ExtractTool) Description() string {
+	return `Evaluates a JSONPath or jq expression against JSON or YAML data, and returns only the matching values.
+The data is the full result of the previous tool call (even when it was truncated), the output stored with a handle, or the objects fetched with "kubectl get -o json" when a resource is given.
+Use it to pick precise fields, e.g. the images of all the pods, instead of fetching and reading full objects again.`
+}
+
+func (t *ExtractTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"expression": {
+					Type:        gollm.TypeString,
+					Description: `The expression, e.g. "{.items[*].spec.containers[*].image}" in JSONPath or ".items[] | select(.status.phase != \"Running\") | .metadata.name" in jq.`,
+				},
+				"language": {
+					Type:        gollm.TypeString,
+					Description: `The language of the expression, "jsonpath" or "jq". Defaults to "jsonpath". jq is only available when installed.`,
+				},
+				"handle": {
+					Type:        gollm.TypeString,
+					Description: `The handle of a stored output to evaluate the expression against, e.g. "output-1".`,
+				},
+				"resource": {
+					Type:        gollm.TypeString,
+					Description: `The resource to fetch with "kubectl get -o json" and evaluate the expression against, e.g. "pods" or "deployment/web".`,
+				},
+				"namespace": {
+					Type:        gollm.TypeString,
+					Description: `The namespace of the resource. Defaults to the current namespace.`,
+				},
+				"all_namespaces": {
+					Type:        gollm.TypeBoolean,
+					Description: `Whether to fetch the resource in all the namespaces.`,
+				},
+				"selector": {
+					Type:        gollm.TypeString,
+					Description: `The label selector of the resource, e.g. "app=web".`,
+				},
+			},
+			Required: []string{"expression"},
+		},
+	}
+}
+
+// ExtractResult is the result of the extract tool.
+type ExtractResult struct {
+	// Source is the data the expression was evaluated against.
+	Source     string `json:"source,omitempty"`
+	Expression string `json:"expression,omitempty"`
+	Output     string `json:"output"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (t *ExtractTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	expression, _ := args["expression"].(string)
+	language, _ := args["language"].(string)
+	handle, _ := args["handle"].(string)
+	resource, _ := args["resource"].(string)
+	namespace, _ := args["namespace"].(string)
+	allNamespaces, _ := args["all_namespaces"].(bool)
+	selector, _ := args["selector"].(string)
+
+	result := &ExtractResult{Expression: expression}
+	if strings.TrimSpace(expression) == "" {
+		result.Error = "missing expression"
+		return result, nil
+	}
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language != "" && language != "jsonpath" && language != "jq" {
+		result.Error = fmt.Sprintf("unknown language %q, expected \"jsonpath\" or \"jq\"", language)
+		return result, nil
+	}
+
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var text string
+	switch {
+	case resource != "":
+		resource = strings.TrimSpace(resource)
+		if strings.HasPrefix(resource, "-") {
+			result.Error = fmt.Sprintf("invalid resource %q", resource)
+			return result, nil
+		}
+		command := "kubectl get " + ShellQuote(resource)
+		switch {
+		case allNamespaces:
+			command += " --all-namespaces"
+		case namespace != "":
+			command += " --namespace " + ShellQuote(namespace)
+		}
+		if selector != "" {
+			command += " -l " + ShellQuote(selector)
+		}
+		command += " -o json"
+		result.Source = command
+		if denial := t.policy.denial(command, kubeconfig); denial != "" {
+			result.Error = denial
+			return result, nil
+		}
+		if err := consumeAPICalls(ctx, command); err != nil {
+			return nil, err
+		}
+		execResult, err := t.executor.Execute(ctx, command, env, workDir)
+		if err != nil {
+			return nil, err
+		}
+		if execResult.ExitCode != 0 || execResult.Error != "" {
+			result.Error = strings.TrimSpace(execResult.Stderr + " " + execResult.Error)
+			return result, nil
+		}
+		text = execResult.Stdout
+	case handle != "":
+		result.Source = handle
+		if !outputHandle.MatchString(handle) {
+			result.Error = fmt.Sprintf("invalid handle %q, expected e.g. \"output-1\"", handle)
+			return result, nil
+		}
+		b, err := os.ReadFile(filepath.Join(workDir, OutputsDir, handle+".txt"))
+		if os.IsNotExist(err) {
+			result.Error = fmt.Sprintf("no output with the handle %q", handle)
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading output: %w", err)
+		}
+		text = string(b)
+	default:
+		result.Source = "the previous tool result"
+		text, err = resultText(ctx.Value(LastResultKey))
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+	}
+
+	data, err := decodeData(text)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	if language == "jq" {
+		result.Output, result.Error, err = t.evalJQ(ctx, expression, data, env, workDir)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	result.Output, err = evalJSONPath(expression, data)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, nil
+}
+
+// resultText returns the output of a tool result, or its encoding as JSON
+// for the tools returning structured results.
+func resultText(result any) (string, error) {
+	switch result := result.(type) {
+	case nil:
+		return "", errors.New("there is no previous tool result, give a handle or a resource")
+	case string:
+		return result, nil
+	case *sandbox.ExecResult:
+		if result.Stdout == "" {
+			return "", errors.New("the previous tool call has no output")
+		}
+		return result.Stdout, nil
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("encoding the previous tool result: %w", err)
+	}
+	return string(b), nil
+}
+
+// decodeData decodes JSON or YAML data, e.g. the output of "kubectl get -o json".
+func decodeData(text string) (any, error) {
+	b, err := yaml.YAMLToJSON([]byte(text))
+	var data any
+	if err == nil {
+		err = json.Unmarshal(b, &data)
+	}
+	switch data.(type) {
+	case map[string]any, []any:
+		return data, nil
+	}
+	return nil, errors.New(`the data is not JSON or YAML, e.g. fetch the objects with "-o json" or give a resource`)
+}
+
+// evalJSONPath evaluates a JSONPath expression in the syntax of kubectl, whose
+// braces are optional, e.g. ".items[*].metadata.name".
+func evalJSONPath(expression string, data any) (string, error) {
+	expression = strings.TrimSpace(expression)
+	if !strings.Contains(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+	jp := jsonpath.New("extract")
+	if err := jp.Parse(expression); err != nil {
+		return "", fmt.Errorf("invalid JSONPath expression: %w", err)
+	}
+	var b strings.Builder
+	if err := jp.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// evalJQ evaluates a jq expression with the jq binary, if installed. It
+// returns the output, or the error of jq.
+func (t *ExtractTool) evalJQ(ctx context.Context, expression string, data any, env []string, workDir string) (output, jqErr string, err error) {
+	probe, err := t.executor.Execute(ctx, "command -v jq", env, workDir)
+	if err != nil || probe.ExitCode != 0 || strings.TrimSpace(probe.Stdout) == "" {
+		return "", "jq is not installed, use a JSONPath expression instead", nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", "", fmt.Errorf("encoding data: %w", err)
+	}
+	// The compact JSON is a single line, which cannot end the here-document.
+	command := "jq -c " + ShellQuote(expression) + " <<'KUBECTL_AI_EXTRACT_EOF'\n" + string(b) + "\nKUBECTL_AI_EXTRACT_EOF"
+	execResult, err := t.executor.Execute(ctx, command, env, workDir)
+	if err != nil {
+		return "", "", fmt.Errorf("running jq: %w", err)
+	}
+	if execResult.ExitCode != 0 || execResult.Error != "" {
+		return "", strings.TrimSpace(execResult.Stderr + " " + execResult.Error), nil
+	}
+	return execResult.Stdout, "", nil
+}
+
+func (t *ExtractTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "no", the extract tool only reads
+// outputs and resources.
+func (t *ExtractTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
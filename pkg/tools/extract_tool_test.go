@@ -0,0 +1,139 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+func TestExtractTool(t *testing.T) {
+	pods := `{"kind": "List", "items": [
+		{"metadata": {"name": "web-0"}, "spec": {"containers": [{"image": "nginx:1.27"}]}},
+		{"metadata": {"name": "web-1"}, "spec": {"containers": [{"image": "nginx:1.25"}]}}]}`
+	podsYAML := `kind: List
+items:
+- metadata:
+    name: web-0
+  spec:
+    containers:
+    - image: nginx:1.27
+- metadata:
+    name: web-1
+  spec:
+    containers:
+    - image: nginx:1.25
+`
+	executor := &scriptedExecutor{outputs: map[string]string{
+		"kubectl get 'pods' --namespace 'shop' -l 'app=web' -o json": pods,
+		"command -v jq":                      "/usr/bin/jq\n",
+		`jq -c '.items[0].metadata.name' <<`: "\"web-0\"\n",
+	}}
+
+	tests := []struct {
+		name       string
+		args       map[string]any
+		lastResult any
+		want       string
+		wantError  string
+	}{
+		{
+			name:       "previous result",
+			args:       map[string]any{"expression": ".items[*].metadata.name"},
+			lastResult: &sandbox.ExecResult{Stdout: pods},
+			want:       "web-0 web-1",
+		},
+		{
+			name:       "structured previous result",
+			args:       map[string]any{"expression": "{.events[0].reason}"},
+			lastResult: &EventsResult{Events: []EventSummary{{Reason: "BackOff"}}},
+			want:       "BackOff",
+		},
+		{
+			name: "stored output",
+			args: map[string]any{"expression": `{range .items[*]}{.metadata.name}={.spec.containers[0].image}{"\n"}{end}`, "handle": "output-1"},
+			want: "web-0=nginx:1.27\nweb-1=nginx:1.25\n",
+		},
+		{
+			name: "live resource",
+			args: map[string]any{"expression": "{.items[1].spec.containers[0].image}", "resource": "pods", "namespace": "shop", "selector": "app=web"},
+			want: "nginx:1.25",
+		},
+		{
+			name:       "jq",
+			args:       map[string]any{"expression": ".items[0].metadata.name", "language": "jq"},
+			lastResult: pods,
+			want:       "\"web-0\"\n",
+		},
+		{
+			name:      "no previous result",
+			args:      map[string]any{"expression": ".items"},
+			wantError: "no previous tool result",
+		},
+		{
+			name:       "table output",
+			args:       map[string]any{"expression": ".items"},
+			lastResult: &sandbox.ExecResult{Stdout: "NAME    READY\nweb-0   1/1\n"},
+			wantError:  "not JSON or YAML",
+		},
+		{
+			name:       "missing key",
+			args:       map[string]any{"expression": ".items[0].status.phase"},
+			lastResult: pods,
+			wantError:  "status is not found",
+		},
+		{
+			name:      "denied resource",
+			args:      map[string]any{"expression": "{.items[*].data}", "resource": "secrets", "namespace": "shop"},
+			wantError: "denied by the kubectl policy",
+		},
+	}
+	policy := &KubectlPolicy{Rules: []KubectlPolicyRule{{Resources: []string{"secrets"}, Action: KubectlPolicyDeny}}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(workDir, OutputsDir), 0o700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(workDir, OutputsDir, "output-1.txt"), []byte(podsYAML), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+			ctx = context.WithValue(ctx, WorkDirKey, workDir)
+			if tt.lastResult != nil {
+				ctx = context.WithValue(ctx, LastResultKey, tt.lastResult)
+			}
+			got, err := NewExtractTool(executor, policy).Run(ctx, tt.args)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			result := got.(*ExtractResult)
+			if tt.wantError != "" {
+				if !strings.Contains(result.Error, tt.wantError) {
+					t.Errorf("Error = %q, want %q", result.Error, tt.wantError)
+				}
+				return
+			}
+			if result.Error != "" || result.Output != tt.want {
+				t.Errorf("Output = %q, Error = %q, want %q", result.Output, result.Error, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+// helmAction is an action of the helm tool.
+type helmAction struct {
+	// subcommand is the helm subcommand run, e.g. "get values".
+	subcommand []string
+	// release is whether the action takes a release.
+	release bool
+	// chart is whether the action takes a chart, with its version and values.
+	chart bool
+	// modifies is whether the action modifies releases, unless it is a dry run.
+	modifies bool
+}
+
+var helmActions = map[string]helmAction{
+	"list":         {subcommand: []string{"list"}},
+	"status":       {subcommand: []string{"status"}, release: true},
+	"history":      {subcommand: []string{"history"}, release: true},
+	"get_values":   {subcommand: []string{"get", "values"}, release: true},
+	"get_manifest": {subcommand: []string{"get", "manifest"}, release: true},
+	"template":     {subcommand: []string{"template"}, release: true, chart: true},
+	"diff":         {subcommand: []string{"diff", "upgrade"}, release: true, chart: true},
+	"install":      {subcommand: []string{"install"}, release: true, chart: true, modifies: true},
+	"upgrade":      {subcommand: []string{"upgrade"}, release: true, chart: true, modifies: true},
+	"rollback":     {subcommand: []string{"rollback"}, release: true, modifies: true},
+	"uninstall":    {subcommand: []string{"uninstall"}, release: true, modifies: true},
+}
+
+// modifyingHelmCommands are the helm subcommands modifying releases, unless run with --dry-run.
+var modifyingHelmCommands = map[string]bool{
+	"install": true, "upgrade": true, "rollback": true, "uninstall": true, "delete": true,
+}
+
+// HelmTool inspects and upgrades Helm releases from structured arguments.
+// Like the quoted kubectl tool, every argument is quoted and the rendered
+// helm call is what is approved and run. Upgrades are refused until the same
+// upgrade was reviewed, with a diff or a dry run, in the session.
+type HelmTool struct {
+	executor sandbox.Executor
+
+	mu sync.Mutex
+	// reviewed holds the keys of the upgrades diffed or dry run, see upgradeKey.
+	reviewed map[string]bool
+}
+
+func NewHelmTool(executor sandbox.Executor) *HelmTool {
+	return &HelmTool{executor: executor, reviewed: make(map[string]bool)}
+}
+
+func (t *HelmTool) Name() string {
+	return "helm"
+}
+
+func (t *HelmTool) Description() string {
+	return `Inspects and manages the Helm releases of the user's Kubernetes cluster, given as structured fields. Use this tool instead of running helm with bash.
+Each field is passed to helm as is: do not quote or escape values.
+
+Before upgrading a release, run the "diff" action with the same release, chart, version, values and set fields, and show the changes to the user: "upgrade" is refused until the same upgrade was diffed. If the helm-diff plugin is not installed, run "upgrade" with dry_run instead.`
+}
+
+func (t *HelmTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"action": {
+					Type: gollm.TypeString,
+					Description: `The action, one of:
+- "list": lists the releases
+- "status", "history", "get_values" or "get_manifest": inspects a release
+- "template": renders the manifests of a chart
+- "diff": shows the changes an upgrade would make, with the helm-diff plugin
+- "install", "upgrade", "rollback" or "uninstall": modifies a release`,
+				},
+				"release": {
+					Type:        gollm.TypeString,
+					Description: `The name of the release, e.g. "web".`,
+				},
+				"chart": {
+					Type:        gollm.TypeString,
+					Description: `The chart of "template", "diff", "install" and "upgrade", e.g. "bitnami/nginx", "./charts/web" or "oci://registry.example.com/charts/web".`,
+				},
+				"version": {
+					Type:        gollm.TypeString,
+					Description: `The version of the chart, the latest version if empty.`,
+				},
+				"values": {
+					Type:        gollm.TypeString,
+					Description: `Values of the chart, as a YAML document.`,
+				},
+				"set": {
+					Type:        gollm.TypeArray,
+					Items:       &gollm.Schema{Type: gollm.TypeString},
+					Description: `Values of the chart set individually, e.g. ["replicaCount=3", "image.tag=1.2.0"].`,
+				},
+				"revision": {
+					Type:        gollm.TypeInteger,
+					Description: `The revision of the release to inspect with "status", "get_values" and "get_manifest", or to roll back to with "rollback"; the latest or the previous revision if empty.`,
+				},
+				"namespace": {
+					Type:        gollm.TypeString,
+					Description: `The namespace of the release, the namespace of the kubeconfig context if empty.`,
+				},
+				"all_namespaces": {
+					Type:        gollm.TypeBoolean,
+					Description: `Whether to list the releases of all namespaces with "list".`,
+				},
+				"context": {
+					Type:        gollm.TypeString,
+					Description: `The kubeconfig context to run in, the current context if empty.`,
+				},
+				"dry_run": {
+					Type:        gollm.TypeBoolean,
+					Description: `Whether to simulate "install", "upgrade", "rollback" or "uninstall" without modifying the release.`,
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
+
+// RenderCommand renders the structured arguments as a helm command line,
+// whose arguments are quoted.
+func (t *HelmTool) RenderCommand(args map[string]any) (string, error) {
+	if _, ok := args["action"]; !ok {
+		return "", nil
+	}
+	name, _ := args["action"].(string)
+	action, ok := helmActions[name]
+	if !ok {
+		return "", fmt.Errorf("unknown action %q, expected one of %s", name, strings.Join(slices.Sorted(maps.Keys(helmActions)), ", "))
+	}
+	argv := slices.Clone(action.subcommand)
+	var positional []string
+	if action.release {
+		positional = append(positional, "release")
+	}
+	if action.chart {
+		positional = append(positional, "chart")
+	}
+	for _, field := range positional {
+		value, _ := args[field].(string)
+		if value == "" {
+			return "", fmt.Errorf("%s needs a %s", name, field)
+		}
+		if strings.HasPrefix(value, "-") {
+			return "", fmt.Errorf("invalid %s %q", field, value)
+		}
+		argv = append(argv, value)
+	}
+
+	if revision := intArg(args["revision"]); revision > 0 {
+		switch name {
+		case "rollback":
+			argv = append(argv, strconv.Itoa(revision))
+		case "status", "get_values", "get_manifest":
+			argv = append(argv, "--revision", strconv.Itoa(revision))
+		}
+	}
+	if kubeContext, _ := args["context"].(string); kubeContext != "" {
+		argv = append(argv, "--kube-context", kubeContext)
+	}
+	if namespace, _ := args["namespace"].(string); namespace != "" {
+		argv = append(argv, "-n", namespace)
+	}
+	if allNamespaces, _ := args["all_namespaces"].(bool); allNamespaces && name == "list" {
+		argv = append(argv, "-A")
+	}
+
+	var values string
+	if action.chart {
+		if version, _ := args["version"].(string); version != "" {
+			argv = append(argv, "--version", version)
+		}
+		set, err := stringsArg(args, "set")
+		if err != nil {
+			return "", err
+		}
+		for _, value := range set {
+			argv = append(argv, "--set", value)
+		}
+		if values, _ = args["values"].(string); values != "" {
+			argv = append(argv, "-f", "-")
+		}
+	}
+	if dryRun, _ := args["dry_run"].(bool); dryRun && action.modifies {
+		argv = append(argv, "--dry-run")
+	}
+	return renderKubectlCommand("helm", argv, values), nil
+}
+
+// Run runs the helm call of the structured arguments, or the command line
+// replacing them, e.g. edited by the user, once it is checked to be a single
+// helm call. Upgrades are refused until they were reviewed.
+func (t *HelmTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	command, err := t.RenderCommand(args)
+	if err != nil {
+		return &sandbox.ExecResult{Error: err.Error()}, nil
+	}
+	if command == "" {
+		command, _ = args["command"].(string)
+		if command == "" {
+			return &sandbox.ExecResult{Error: "action must be provided"}, nil
+		}
+	}
+	words, stdin, err := literalCall(command, "helm")
+	if err != nil {
+		return &sandbox.ExecResult{Command: command, Error: err.Error()}, nil
+	}
+	if filepath.Base(words[0]) != "helm" {
+		return &sandbox.ExecResult{Command: command, Error: fmt.Sprintf("expected a helm call, got %q", words[0])}, nil
+	}
+
+	key, isUpgrade, isReview := upgradeKey(words, stdin)
+	if isUpgrade && !isReview && !t.isReviewed(key) {
+		return &sandbox.ExecResult{Command: command, Error: `refusing to upgrade a release whose changes were not reviewed: run the "diff" action with the same release, chart, version, values and set fields first, or "upgrade" with dry_run if the helm-diff plugin is not installed, and show the changes to the user`}, nil
+	}
+
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	result, err := ExecuteWithStreamingHandling(ctx, t.executor, renderKubectlCommand(words[0], words[1:], stdin), workDir, env, nil)
+	if err != nil {
+		return result, err
+	}
+	if result.ExitCode != 0 || result.Error != "" {
+		if firstPositional(words[1:]) == "diff" && strings.Contains(result.Stderr, `unknown command "diff"`) {
+			result.Error = `the helm-diff plugin is not installed, review the upgrade with "upgrade" and dry_run instead`
+		}
+		return result, nil
+	}
+	if isUpgrade {
+		t.mu.Lock()
+		// An upgrade needs a new review once it is applied.
+		t.reviewed[key] = isReview
+		t.mu.Unlock()
+	}
+	return result, nil
+}
+
+// upgradeKey returns the key of the upgrade of a helm call: its arguments
+// after "upgrade", without --dry-run, and its stdin, so that "helm diff upgrade
+// web ./chart" and "helm upgrade web ./chart --dry-run" review "helm upgrade
+// web ./chart". isUpgrade is false for the other calls, and isReview is true
+// for the diffs and the dry runs.
+func upgradeKey(words []string, stdin string) (key string, isUpgrade, isReview bool) {
+	i := slices.Index(words, "upgrade")
+	if i < 0 {
+		return "", false, false
+	}
+	switch subcommand := firstPositional(words[1:]); subcommand {
+	case "upgrade":
+	case "diff":
+		isReview = true
+	default:
+		return "", false, false
+	}
+	var rest []string
+	for _, word := range words[i+1:] {
+		if word == "--dry-run" || strings.HasPrefix(word, "--dry-run=") {
+			isReview = true
+			continue
+		}
+		rest = append(rest, word)
+	}
+	return strings.Join(rest, "\x00") + "\x00" + stdin, true, isReview
+}
+
+func (t *HelmTool) isReviewed(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.reviewed[key]
+}
+
+func (t *HelmTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource returns "yes" for the helm calls modifying releases,
+// unless they are dry runs, and "no" for the calls reading releases and charts.
+func (t *HelmTool) CheckModifiesResource(args map[string]any) string {
+	command, err := t.RenderCommand(args)
+	if err != nil {
+		return "unknown"
+	}
+	if command == "" {
+		command, _ = args["command"].(string)
+	}
+	words, _, err := literalCall(command, "helm")
+	if err != nil || filepath.Base(words[0]) != "helm" {
+		return "unknown"
+	}
+	switch subcommand := firstPositional(words[1:]); {
+	case modifyingHelmCommands[subcommand]:
+		if slices.ContainsFunc(words, func(word string) bool {
+			return word == "--dry-run" || strings.HasPrefix(word, "--dry-run=")
+		}) {
+			return "no"
+		}
+		return "yes"
+	case readOnlyHelmCommands[subcommand]:
+		return "no"
+	}
+	return "unknown"
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+func TestHelmRenderCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "list",
+			args: map[string]any{"action": "list", "all_namespaces": true},
+			want: "helm list -A",
+		},
+		{
+			name: "get values of a revision",
+			args: map[string]any{"action": "get_values", "release": "web", "namespace": "prod", "revision": float64(3)},
+			want: "helm get values web --revision 3 -n prod",
+		},
+		{
+			name: "diff",
+			args: map[string]any{"action": "diff", "release": "web", "chart": "bitnami/nginx", "version": "15.1.0", "set": []any{"replicaCount=3"}},
+			want: "helm diff upgrade web bitnami/nginx --version 15.1.0 --set replicaCount=3",
+		},
+		{
+			name: "upgrade with values",
+			args: map[string]any{"action": "upgrade", "release": "web", "chart": "./chart", "context": "prod", "values": "image:\n  tag: 1.2.0\n"},
+			want: "helm upgrade web ./chart --kube-context prod -f - <<'EOF'\nimage:\n  tag: 1.2.0\nEOF",
+		},
+		{
+			name: "rollback dry run",
+			args: map[string]any{"action": "rollback", "release": "web", "revision": float64(2), "dry_run": true},
+			want: "helm rollback web 2 --dry-run",
+		},
+		{
+			name: "shell syntax is quoted",
+			args: map[string]any{"action": "status", "release": "web; rm -rf /"},
+			want: "helm status 'web; rm -rf /'",
+		},
+		{
+			name: "command line",
+			args: map[string]any{"command": "helm list"},
+			want: "",
+		},
+		{
+			name:    "unknown action",
+			args:    map[string]any{"action": "push"},
+			wantErr: true,
+		},
+		{
+			name:    "missing chart",
+			args:    map[string]any{"action": "upgrade", "release": "web"},
+			wantErr: true,
+		},
+		{
+			name:    "flag as release",
+			args:    map[string]any{"action": "status", "release": "--kubeconfig=/tmp/other"},
+			wantErr: true,
+		},
+	}
+	tool := NewHelmTool(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.RenderCommand(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenderCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelmCheckModifiesResource(t *testing.T) {
+	tests := []struct {
+		args map[string]any
+		want string
+	}{
+		{args: map[string]any{"action": "history", "release": "web"}, want: "no"},
+		{args: map[string]any{"action": "diff", "release": "web", "chart": "./chart"}, want: "no"},
+		{args: map[string]any{"action": "upgrade", "release": "web", "chart": "./chart"}, want: "yes"},
+		{args: map[string]any{"action": "uninstall", "release": "web", "dry_run": true}, want: "no"},
+		{args: map[string]any{"command": "helm uninstall web"}, want: "yes"},
+		{args: map[string]any{"command": "helm list | grep web"}, want: "unknown"},
+	}
+	tool := NewHelmTool(nil)
+	for _, tt := range tests {
+		if got := tool.CheckModifiesResource(tt.args); got != tt.want {
+			t.Errorf("CheckModifiesResource(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+// TestHelmUpgradeNeedsReview checks that an upgrade runs only after the same
+// upgrade was diffed, and needs a new review once applied.
+func TestHelmUpgradeNeedsReview(t *testing.T) {
+	executor := &MockExecutor{}
+	tool := NewHelmTool(executor)
+	ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+	ctx = context.WithValue(ctx, WorkDirKey, t.TempDir())
+
+	run := func(args map[string]any) *sandbox.ExecResult {
+		t.Helper()
+		executor.CapturedCommand = ""
+		result, err := tool.Run(ctx, args)
+		if err != nil {
+			t.Fatalf("Run(%v) error = %v", args, err)
+		}
+		return result.(*sandbox.ExecResult)
+	}
+	upgrade := map[string]any{"action": "upgrade", "release": "web", "chart": "./chart", "set": []any{"replicaCount=3"}}
+	diff := map[string]any{"action": "diff", "release": "web", "chart": "./chart", "set": []any{"replicaCount=3"}}
+	otherDiff := map[string]any{"action": "diff", "release": "web", "chart": "./chart", "set": []any{"replicaCount=4"}}
+
+	if result := run(upgrade); !strings.Contains(result.Error, "not reviewed") || executor.CapturedCommand != "" {
+		t.Fatalf("upgrade before the diff: ran %q, error %q", executor.CapturedCommand, result.Error)
+	}
+	run(otherDiff)
+	if result := run(upgrade); result.Error == "" {
+		t.Fatalf("upgrade after the diff of another upgrade ran %q", executor.CapturedCommand)
+	}
+	if result := run(diff); result.Error != "" || executor.CapturedCommand != "helm diff upgrade web ./chart --set replicaCount=3" {
+		t.Fatalf("diff: ran %q, error %q", executor.CapturedCommand, result.Error)
+	}
+	if result := run(upgrade); result.Error != "" || executor.CapturedCommand != "helm upgrade web ./chart --set replicaCount=3" {
+		t.Fatalf("upgrade after the diff: ran %q, error %q", executor.CapturedCommand, result.Error)
+	}
+	if result := run(upgrade); result.Error == "" {
+		t.Errorf("second upgrade ran without a new review")
+	}
+
+	// A dry run reviews the upgrade too, e.g. when helm-diff is not installed.
+	run(map[string]any{"action": "upgrade", "release": "web", "chart": "./chart", "set": []any{"replicaCount=3"}, "dry_run": true})
+	if result := run(upgrade); result.Error != "" {
+		t.Errorf("upgrade after the dry run: error %q", result.Error)
+	}
+
+	// Edited command lines must still be a single helm call.
+	if result := run(map[string]any{"command": "helm list; rm -rf /"}); result.Error == "" {
+		t.Errorf("ran %q", executor.CapturedCommand)
+	}
+}
@@ -0,0 +1,409 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const defaultHistoryWindow = 24 * time.Hour
+
+// ChangeHistoryTool reconstructs what changed in a namespace over a time window,
+// from Deployment revision history (ReplicaSets), Helm releases,
+// Flux HelmReleases and Argo CD Applications.
+type ChangeHistoryTool struct {
+	executor sandbox.Executor
+}
+
+func NewChangeHistoryTool(executor sandbox.Executor) *ChangeHistoryTool {
+	return &ChangeHistoryTool{executor: executor}
+}
+
+func (t *ChangeHistoryTool) Name() string {
+	return "change_history"
+}
+
+func (t *ChangeHistoryTool) Description() string {
+	return `Returns a timeline of the changes rolled out in a namespace during a time window (by default the last 24 hours).
+It inspects Deployment revision history (ReplicaSets and their change-cause annotations), Helm release history, Flux HelmRelease history and Argo CD Application sync history.
+Use this tool to answer questions like "what changed in the last 24h in namespace X?".`
+}
+
+func (t *ChangeHistoryTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"namespace": {
+					Type:        gollm.TypeString,
+					Description: `The namespace to inspect. Defaults to the current namespace.`,
+				},
+				"since": {
+					Type:        gollm.TypeString,
+					Description: `How far back to look, as a duration, e.g. "30m", "6h", "24h", "7d". Defaults to "24h".`,
+				},
+				"deployment": {
+					Type:        gollm.TypeString,
+					Description: `Optionally restrict the Deployment history to a single Deployment.`,
+				},
+			},
+		},
+	}
+}
+
+// ChangeHistoryResult is the result of the change_history tool.
+type ChangeHistoryResult struct {
+	Namespace string        `json:"namespace,omitempty"`
+	Since     time.Time     `json:"since"`
+	Changes   []ChangeEvent `json:"changes"`
+	// Warnings lists the sources that could not be inspected (e.g. CRDs that are not installed).
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// ChangeEvent is a single entry of the change timeline.
+type ChangeEvent struct {
+	Time time.Time `json:"time"`
+	// Source is one of "deployment", "helm", "helmrelease" or "argocd".
+	Source      string `json:"source"`
+	Resource    string `json:"resource"`
+	Revision    string `json:"revision,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// historySource extracts change events from the output of a kubectl get command.
+type historySource struct {
+	name     string
+	resource string
+	selector string
+	// metadataOnly lists the name, creation timestamp and labels of the objects
+	// only, e.g. of the Secrets of the Helm releases, whose values may hold credentials.
+	metadataOnly bool
+	extract      func(items []unstructured.Unstructured, deployment string) []ChangeEvent
+}
+
+var historySources = []historySource{
+	{name: "deployment", resource: "replicasets.apps", extract: replicaSetChanges},
+	{name: "helm", resource: "secrets", selector: "owner=helm", metadataOnly: true, extract: helmReleaseSecretChanges},
+	{name: "helmrelease", resource: "helmreleases.helm.toolkit.fluxcd.io", extract: fluxHelmReleaseChanges},
+	{name: "argocd", resource: "applications.argoproj.io", extract: argoApplicationChanges},
+}
+
+func (t *ChangeHistoryTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	namespace, _ := args["namespace"].(string)
+	deployment, _ := args["deployment"].(string)
+	sinceArg, _ := args["since"].(string)
+
+	result := &ChangeHistoryResult{Namespace: namespace, Changes: []ChangeEvent{}}
+
+	window := defaultHistoryWindow
+	if sinceArg != "" {
+		d, err := parseHistoryWindow(sinceArg)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		window = d
+	}
+	result.Since = time.Now().Add(-window).UTC()
+
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range historySources {
+		command := "kubectl get " + source.resource
+		if source.metadataOnly {
+			command += " -o " + ShellQuote("jsonpath="+metadataJSONPath)
+		} else {
+			command += " -o json"
+		}
+		if source.selector != "" {
+			command += " -l " + ShellQuote(source.selector)
+		}
+		if namespace != "" {
+			command += " --namespace " + ShellQuote(namespace)
+		}
+
+		var items []unstructured.Unstructured
+		if source.metadataOnly {
+			items, err = getKubectlMetadata(ctx, t.executor, command, env, workDir)
+		} else {
+			items, err = getKubectlItems(ctx, t.executor, command, env, workDir)
+		}
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %v", source.name, err))
+			continue
+		}
+		for _, change := range source.extract(items, deployment) {
+			if change.Time.Before(result.Since) {
+				continue
+			}
+			change.Source = source.name
+			result.Changes = append(result.Changes, change)
+		}
+	}
+
+	sort.SliceStable(result.Changes, func(i, j int) bool {
+		return result.Changes[i].Time.After(result.Changes[j].Time)
+	})
+	return result, nil
+}
+
+// parseHistoryWindow parses a duration, additionally supporting a "d" (days) suffix.
+func parseHistoryWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		if _, err := fmt.Sscanf(days, "%d", &n); err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// ListResources runs "kubectl get <args> -o json" with the tool environment
+// and returns the listed objects, e.g. ListResources(ctx, executor, kubeconfig, workDir, "pods --all-namespaces").
+func ListResources(ctx context.Context, executor sandbox.Executor, kubeconfig, workDir, args string) ([]unstructured.Unstructured, error) {
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	return getKubectlItems(ctx, executor, "kubectl get "+args+" -o json", env, workDir)
+}
+
+// getKubectlItems runs a "kubectl get ... -o json" command and returns the listed items.
+func getKubectlItems(ctx context.Context, executor sandbox.Executor, command string, env []string, workDir string) ([]unstructured.Unstructured, error) {
+	if err := consumeAPICalls(ctx, command); err != nil {
+		return nil, err
+	}
+	execResult, err := executor.Execute(ctx, command, env, workDir)
+	if err != nil {
+		return nil, err
+	}
+	if execResult.ExitCode != 0 || execResult.Error != "" {
+		return nil, fmt.Errorf("%s", strings.TrimSpace(execResult.Stderr+" "+execResult.Error))
+	}
+
+	// UnmarshalJSON decodes numbers as int64, as expected by the unstructured helpers.
+	var list unstructured.UnstructuredList
+	if err := list.UnmarshalJSON([]byte(execResult.Stdout)); err != nil {
+		return nil, fmt.Errorf("parsing output of %q: %w", command, err)
+	}
+	return list.Items, nil
+}
+
+// metadataJSONPath prints the name, creation timestamp and labels of the
+// listed objects, one object per line, the labels as a JSON object.
+const metadataJSONPath = `{range .items[*]}{.metadata.name}{"\t"}{.metadata.creationTimestamp}{"\t"}{.metadata.labels}{"\n"}{end}`
+
+// getKubectlMetadata runs a "kubectl get ... -o jsonpath=<metadataJSONPath>"
+// command and returns the listed objects with their name, creation timestamp
+// and labels.
+func getKubectlMetadata(ctx context.Context, executor sandbox.Executor, command string, env []string, workDir string) ([]unstructured.Unstructured, error) {
+	if err := consumeAPICalls(ctx, command); err != nil {
+		return nil, err
+	}
+	execResult, err := executor.Execute(ctx, command, env, workDir)
+	if err != nil {
+		return nil, err
+	}
+	if execResult.ExitCode != 0 || execResult.Error != "" {
+		return nil, fmt.Errorf("%s", strings.TrimSpace(execResult.Stderr+" "+execResult.Error))
+	}
+
+	var items []unstructured.Unstructured
+	for _, line := range strings.Split(execResult.Stdout, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		var item unstructured.Unstructured
+		item.SetName(fields[0])
+		if created, err := time.Parse(time.RFC3339, fields[1]); err == nil {
+			item.SetCreationTimestamp(metav1.NewTime(created))
+		}
+		if fields[2] != "" {
+			var labels map[string]string
+			if err := json.Unmarshal([]byte(fields[2]), &labels); err != nil {
+				return nil, fmt.Errorf("parsing labels of %q: %w", fields[0], err)
+			}
+			item.SetLabels(labels)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func replicaSetChanges(items []unstructured.Unstructured, deployment string) []ChangeEvent {
+	var changes []ChangeEvent
+	for _, rs := range items {
+		owner := ""
+		for _, ref := range rs.GetOwnerReferences() {
+			if ref.Kind == "Deployment" {
+				owner = ref.Name
+			}
+		}
+		if owner == "" || (deployment != "" && owner != deployment) {
+			continue
+		}
+
+		annotations := rs.GetAnnotations()
+		description := annotations["kubernetes.io/change-cause"]
+		if images := containerImages(rs.Object, "spec", "template", "spec", "containers"); len(images) > 0 {
+			if description != "" {
+				description += "; "
+			}
+			description += "images: " + strings.Join(images, ", ")
+		}
+
+		changes = append(changes, ChangeEvent{
+			Time:        rs.GetCreationTimestamp().UTC(),
+			Resource:    "deployment/" + owner,
+			Revision:    annotations["deployment.kubernetes.io/revision"],
+			Description: description,
+		})
+	}
+	return changes
+}
+
+func helmReleaseSecretChanges(items []unstructured.Unstructured, _ string) []ChangeEvent {
+	var changes []ChangeEvent
+	for _, secret := range items {
+		labels := secret.GetLabels()
+		if labels["name"] == "" {
+			continue
+		}
+		changes = append(changes, ChangeEvent{
+			Time:        secret.GetCreationTimestamp().UTC(),
+			Resource:    "helm-release/" + labels["name"],
+			Revision:    labels["version"],
+			Description: "status: " + labels["status"],
+		})
+	}
+	return changes
+}
+
+func fluxHelmReleaseChanges(items []unstructured.Unstructured, _ string) []ChangeEvent {
+	var changes []ChangeEvent
+	for _, hr := range items {
+		history, _, _ := unstructured.NestedSlice(hr.Object, "status", "history")
+		for _, entry := range history {
+			snapshot, ok := entry.(map[string]any)
+			if !ok {
+				continue
+			}
+			deployed, ok := parseTimeField(snapshot, "lastDeployed")
+			if !ok {
+				continue
+			}
+			chart, _, _ := unstructured.NestedString(snapshot, "chartName")
+			chartVersion, _, _ := unstructured.NestedString(snapshot, "chartVersion")
+			status, _, _ := unstructured.NestedString(snapshot, "status")
+			version, _, _ := unstructured.NestedInt64(snapshot, "version")
+			changes = append(changes, ChangeEvent{
+				Time:        deployed,
+				Resource:    "helmrelease/" + hr.GetName(),
+				Revision:    fmt.Sprint(version),
+				Description: fmt.Sprintf("chart %s@%s, status: %s", chart, chartVersion, status),
+			})
+		}
+	}
+	return changes
+}
+
+func argoApplicationChanges(items []unstructured.Unstructured, _ string) []ChangeEvent {
+	var changes []ChangeEvent
+	for _, app := range items {
+		history, _, _ := unstructured.NestedSlice(app.Object, "status", "history")
+		for _, entry := range history {
+			sync, ok := entry.(map[string]any)
+			if !ok {
+				continue
+			}
+			deployed, ok := parseTimeField(sync, "deployedAt")
+			if !ok {
+				continue
+			}
+			revision, _, _ := unstructured.NestedString(sync, "revision")
+			repo, _, _ := unstructured.NestedString(sync, "source", "repoURL")
+			path, _, _ := unstructured.NestedString(sync, "source", "path")
+			changes = append(changes, ChangeEvent{
+				Time:        deployed,
+				Resource:    "application/" + app.GetName(),
+				Revision:    revision,
+				Description: strings.TrimSpace(fmt.Sprintf("synced from %s %s", repo, path)),
+			})
+		}
+	}
+	return changes
+}
+
+func containerImages(obj map[string]any, fields ...string) []string {
+	containers, _, _ := unstructured.NestedSlice(obj, fields...)
+	var images []string
+	for _, c := range containers {
+		container, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		if image, ok := container["image"].(string); ok {
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+func parseTimeField(obj map[string]any, field string) (time.Time, bool) {
+	s, _, _ := unstructured.NestedString(obj, field)
+	if s == "" {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts.UTC(), true
+}
+
+func (t *ChangeHistoryTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "no", the change_history tool only reads resources.
+func (t *ChangeHistoryTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
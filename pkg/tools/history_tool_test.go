@@ -0,0 +1,195 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseHistoryWindow(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "30m", want: 30 * time.Minute},
+		{input: "6h", want: 6 * time.Hour},
+		{input: " 24h ", want: 24 * time.Hour},
+		{input: "7d", want: 7 * 24 * time.Hour},
+		{input: "1h30m", want: 90 * time.Minute},
+		{input: "0d", wantErr: true},
+		{input: "-2h", wantErr: true},
+		{input: "xd", wantErr: true},
+		{input: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseHistoryWindow(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHistoryWindow(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseHistoryWindow(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// historyItems decodes the items of a kubectl list.
+func historyItems(t *testing.T, list string) []unstructured.Unstructured {
+	t.Helper()
+	var items unstructured.UnstructuredList
+	if err := items.UnmarshalJSON([]byte(list)); err != nil {
+		t.Fatalf("decoding list: %v", err)
+	}
+	return items.Items
+}
+
+func TestHistoryExtractors(t *testing.T) {
+	tests := []struct {
+		name       string
+		extract    func(items []unstructured.Unstructured, deployment string) []ChangeEvent
+		list       string
+		deployment string
+		want       []ChangeEvent
+	}{
+		{
+			name:    "replicasets",
+			extract: replicaSetChanges,
+			list: `{"kind": "List", "items": [
+				{"kind": "ReplicaSet", "metadata": {"name": "web-5d8f7", "creationTimestamp": "2025-06-01T10:00:00Z",
+					"ownerReferences": [{"kind": "Deployment", "name": "web"}],
+					"annotations": {"deployment.kubernetes.io/revision": "3", "kubernetes.io/change-cause": "bump nginx"}},
+					"spec": {"template": {"spec": {"containers": [{"name": "web", "image": "nginx:1.27"}]}}}},
+				{"kind": "ReplicaSet", "metadata": {"name": "api-7c9d", "creationTimestamp": "2025-06-01T11:00:00Z",
+					"ownerReferences": [{"kind": "Deployment", "name": "api"}],
+					"annotations": {"deployment.kubernetes.io/revision": "1"}},
+					"spec": {"template": {"spec": {"containers": [{"name": "api", "image": "api:v2"}]}}}},
+				{"kind": "ReplicaSet", "metadata": {"name": "orphan", "creationTimestamp": "2025-06-01T12:00:00Z"}}]}`,
+			want: []ChangeEvent{
+				{Time: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC), Resource: "deployment/web", Revision: "3", Description: "bump nginx; images: nginx:1.27"},
+				{Time: time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC), Resource: "deployment/api", Revision: "1", Description: "images: api:v2"},
+			},
+		},
+		{
+			name:    "replicasets of a deployment",
+			extract: replicaSetChanges,
+			list: `{"kind": "List", "items": [
+				{"kind": "ReplicaSet", "metadata": {"name": "web-5d8f7", "creationTimestamp": "2025-06-01T10:00:00Z",
+					"ownerReferences": [{"kind": "Deployment", "name": "web"}]}},
+				{"kind": "ReplicaSet", "metadata": {"name": "api-7c9d", "creationTimestamp": "2025-06-01T11:00:00Z",
+					"ownerReferences": [{"kind": "Deployment", "name": "api"}]}}]}`,
+			deployment: "api",
+			want: []ChangeEvent{
+				{Time: time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC), Resource: "deployment/api"},
+			},
+		},
+		{
+			name:    "helm release secrets",
+			extract: helmReleaseSecretChanges,
+			list: `{"kind": "List", "items": [
+				{"kind": "Secret", "metadata": {"name": "sh.helm.release.v1.web.v2", "creationTimestamp": "2025-06-01T10:00:00Z",
+					"labels": {"name": "web", "owner": "helm", "status": "deployed", "version": "2"}}},
+				{"kind": "Secret", "metadata": {"name": "unlabeled", "creationTimestamp": "2025-06-01T11:00:00Z"}}]}`,
+			want: []ChangeEvent{
+				{Time: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC), Resource: "helm-release/web", Revision: "2", Description: "status: deployed"},
+			},
+		},
+		{
+			name:    "flux helmreleases",
+			extract: fluxHelmReleaseChanges,
+			list: `{"kind": "List", "items": [
+				{"kind": "HelmRelease", "metadata": {"name": "web"}, "status": {"history": [
+					{"chartName": "nginx", "chartVersion": "15.1.0", "status": "deployed", "version": 4, "lastDeployed": "2025-06-01T10:00:00Z"},
+					{"chartName": "nginx", "chartVersion": "15.0.0", "status": "superseded", "version": 3},
+					"not a snapshot"]}}]}`,
+			want: []ChangeEvent{
+				{Time: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC), Resource: "helmrelease/web", Revision: "4", Description: "chart nginx@15.1.0, status: deployed"},
+			},
+		},
+		{
+			name:    "argocd applications",
+			extract: argoApplicationChanges,
+			list: `{"kind": "List", "items": [
+				{"kind": "Application", "metadata": {"name": "shop"}, "status": {"history": [
+					{"revision": "abc123", "deployedAt": "2025-06-01T10:00:00Z", "source": {"repoURL": "https://git.example.com/shop", "path": "deploy"}},
+					{"revision": "def456", "deployedAt": "not a time"}]}}]}`,
+			want: []ChangeEvent{
+				{Time: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC), Resource: "application/shop", Revision: "abc123", Description: "synced from https://git.example.com/shop deploy"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.extract(historyItems(t, tt.list), tt.deployment)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeHistoryTool(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+
+	executor := &scriptedExecutor{outputs: map[string]string{
+		"kubectl get replicasets.apps -o json": fmt.Sprintf(`{"kind": "List", "items": [
+			{"kind": "ReplicaSet", "metadata": {"name": "web-new", "creationTimestamp": %q, "ownerReferences": [{"kind": "Deployment", "name": "web"}]}},
+			{"kind": "ReplicaSet", "metadata": {"name": "web-old", "creationTimestamp": %q, "ownerReferences": [{"kind": "Deployment", "name": "web"}]}}]}`,
+			at(2*time.Hour), at(3*24*time.Hour)),
+		"kubectl get secrets -o 'jsonpath=": fmt.Sprintf("sh.helm.release.v1.web.v2\t%s\t{\"name\":\"web\",\"owner\":\"helm\",\"status\":\"deployed\",\"version\":\"2\"}\n",
+			at(time.Hour)),
+		// The helmreleases and applications are not installed.
+	}}
+	ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+	ctx = context.WithValue(ctx, WorkDirKey, t.TempDir())
+
+	out, err := NewChangeHistoryTool(executor).Run(ctx, map[string]any{"since": "1d"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	result := out.(*ChangeHistoryResult)
+
+	var got []string
+	for _, change := range result.Changes {
+		got = append(got, change.Source+" "+change.Resource+" "+change.Time.Format(time.RFC3339))
+	}
+	want := []string{
+		"helm helm-release/web " + at(time.Hour),
+		"deployment deployment/web " + at(2*time.Hour),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %q, want %q", got, want)
+	}
+	if len(result.Warnings) != 2 || !strings.HasPrefix(result.Warnings[0], "helmrelease:") || !strings.HasPrefix(result.Warnings[1], "argocd:") {
+		t.Errorf("warnings = %q, want the helmrelease and argocd sources", result.Warnings)
+	}
+
+	out, err = NewChangeHistoryTool(executor).Run(ctx, map[string]any{"since": "soon"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result := out.(*ChangeHistoryResult); result.Error == "" {
+		t.Errorf("Run() with an invalid window = %+v, want an error", result)
+	}
+}
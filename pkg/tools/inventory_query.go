@@ -0,0 +1,809 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// columnKind is the type of the values of an inventory column.
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindNumber
+	kindBool
+)
+
+// inventoryColumn is a column of an inventory table.
+type inventoryColumn struct {
+	name string
+	kind columnKind
+	// quantity is set for the CPU (in cores) and memory (in bytes) columns,
+	// which can be compared to quantities, e.g. "cpu_request < '500m'".
+	quantity bool
+	doc      string
+}
+
+// inventoryQuery is a parsed inventory query:
+//
+//	SELECT <items> FROM <table> [WHERE <condition>] [GROUP BY <columns>]
+//	[ORDER BY <column> [ASC|DESC], ...] [LIMIT <n>]
+type inventoryQuery struct {
+	table   string
+	items   []selectItem
+	where   condition
+	groupBy []string
+	orderBy []orderKey
+	limit   int
+}
+
+// selectItem is a column or an aggregate of the select list. A "*" item is
+// expanded to all the columns of the table.
+type selectItem struct {
+	// aggregate is "count", "sum", "avg", "min" or "max", or empty for a column.
+	aggregate string
+	// column is empty for "count(*)".
+	column string
+	name   string
+}
+
+type orderKey struct {
+	name string
+	desc bool
+}
+
+// condition is a boolean expression of the WHERE clause.
+type condition interface {
+	eval(row map[string]any) bool
+}
+
+type andCondition struct{ left, right condition }
+
+func (c andCondition) eval(row map[string]any) bool { return c.left.eval(row) && c.right.eval(row) }
+
+type orCondition struct{ left, right condition }
+
+func (c orCondition) eval(row map[string]any) bool { return c.left.eval(row) || c.right.eval(row) }
+
+type notCondition struct{ inner condition }
+
+func (c notCondition) eval(row map[string]any) bool { return !c.inner.eval(row) }
+
+// comparison compares a column to literals: a single one for the comparison
+// operators and "like", the list of values for "in".
+type comparison struct {
+	column string
+	op     string
+	values []any
+	like   *regexp.Regexp
+}
+
+func (c comparison) eval(row map[string]any) bool {
+	v := row[c.column]
+	switch c.op {
+	case "in":
+		for _, value := range c.values {
+			if compareValues(v, value) == 0 {
+				return true
+			}
+		}
+		return false
+	case "like":
+		s, _ := v.(string)
+		return c.like.MatchString(s)
+	}
+	cmp := compareValues(v, c.values[0])
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// compareValues compares two values of the same kind, false before true for booleans.
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case float64:
+		b, _ := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case bool:
+		b, _ := b.(bool)
+		switch {
+		case a == b:
+			return 0
+		case !a:
+			return -1
+		}
+		return 1
+	case string:
+		b, _ := b.(string)
+		return strings.Compare(a, b)
+	}
+	return 0
+}
+
+// queryToken is a token of an inventory query: a keyword or identifier
+// (lowercased), a number, a quoted string or a symbol.
+type queryToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(strings.TrimSpace(query))
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == r {
+					// A doubled quote is an escaped quote.
+					if j+1 < len(runes) && runes[j+1] == r {
+						b.WriteRune(r)
+						j++
+						continue
+					}
+					break
+				}
+				b.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return nil, fmt.Errorf("unterminated string %s", string(runes[i:]))
+			}
+			tokens = append(tokens, queryToken{text: b.String(), quoted: true})
+			i = j + 1
+		case unicode.IsLetter(r) || r == '_' || unicode.IsDigit(r) || r == '.' || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, queryToken{text: strings.ToLower(string(runes[i:j]))})
+			i = j
+		case strings.ContainsRune("<>!", r) && i+1 < len(runes) && (runes[i+1] == '=' || (r == '<' && runes[i+1] == '>')):
+			text := string(runes[i : i+2])
+			if text == "<>" {
+				text = "!="
+			}
+			tokens = append(tokens, queryToken{text: text})
+			i += 2
+		case strings.ContainsRune("=<>(),*", r):
+			tokens = append(tokens, queryToken{text: string(r)})
+			i++
+		case r == ';' && i == len(runes)-1:
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+// queryParser parses an inventory query against the columns of its table.
+type queryParser struct {
+	tokens  []queryToken
+	pos     int
+	columns map[string]inventoryColumn
+}
+
+func (p *queryParser) peek() queryToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return queryToken{}
+}
+
+func (p *queryParser) next() queryToken {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the given keyword or symbol.
+func (p *queryParser) accept(text string) bool {
+	if t := p.peek(); !t.quoted && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) expect(text string) error {
+	if !p.accept(text) {
+		return p.unexpected(strings.ToUpper(text))
+	}
+	return nil
+}
+
+func (p *queryParser) unexpected(want string) error {
+	t := p.peek()
+	switch {
+	case p.pos >= len(p.tokens):
+		return fmt.Errorf("expected %s at the end of the query", want)
+	case t.quoted:
+		return fmt.Errorf("expected %s, got '%s'", want, t.text)
+	}
+	return fmt.Errorf("expected %s, got %q", want, t.text)
+}
+
+func (p *queryParser) identifier() (string, error) {
+	t := p.peek()
+	if t.quoted || t.text == "" || !(unicode.IsLetter(rune(t.text[0])) || t.text[0] == '_') || queryKeywords[t.text] {
+		return "", p.unexpected("a column")
+	}
+	p.pos++
+	return t.text, nil
+}
+
+var queryKeywords = map[string]bool{
+	"select": true, "from": true, "where": true, "group": true, "order": true, "by": true, "limit": true,
+	"and": true, "or": true, "not": true, "in": true, "like": true, "as": true, "asc": true, "desc": true,
+}
+
+// column parses a column of the table.
+func (p *queryParser) column() (inventoryColumn, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return inventoryColumn{}, err
+	}
+	column, ok := p.columns[name]
+	if !ok {
+		return inventoryColumn{}, fmt.Errorf("unknown column %q", name)
+	}
+	return column, nil
+}
+
+// parseInventoryQuery parses a query against the inventory tables.
+func parseInventoryQuery(query string, tables map[string][]inventoryColumn) (*inventoryQuery, error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+
+	if err := p.expect("select"); err != nil {
+		return nil, err
+	}
+	// The select list is parsed once the table, and therefore its columns, are known.
+	start := p.pos
+	for p.pos < len(p.tokens) && !(p.peek().text == "from" && !p.peek().quoted) {
+		p.pos++
+	}
+	if err := p.expect("from"); err != nil {
+		return nil, err
+	}
+	q := &inventoryQuery{}
+	if q.table, err = p.identifier(); err != nil {
+		return nil, err
+	}
+	columns, ok := tables[q.table]
+	if !ok {
+		names := make([]string, 0, len(tables))
+		for name := range tables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown table %q, expected one of %s", q.table, strings.Join(names, ", "))
+	}
+	p.columns = make(map[string]inventoryColumn, len(columns))
+	for _, c := range columns {
+		p.columns[c.name] = c
+	}
+	end := p.pos
+
+	p.pos = start
+	if q.items, err = p.selectList(columns); err != nil {
+		return nil, err
+	}
+	if p.pos != end-2 {
+		return nil, p.unexpected("FROM")
+	}
+	p.pos = end
+
+	if p.accept("where") {
+		if q.where, err = p.or(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("group") {
+		if err := p.expect("by"); err != nil {
+			return nil, err
+		}
+		for {
+			column, err := p.column()
+			if err != nil {
+				return nil, err
+			}
+			q.groupBy = append(q.groupBy, column.name)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	if p.accept("order") {
+		if err := p.expect("by"); err != nil {
+			return nil, err
+		}
+		for {
+			key, err := p.orderKey()
+			if err != nil {
+				return nil, err
+			}
+			if p.accept("desc") {
+				key.desc = true
+			} else {
+				p.accept("asc")
+			}
+			q.orderBy = append(q.orderBy, key)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	if p.accept("limit") {
+		t := p.next()
+		n, err := strconv.Atoi(t.text)
+		if t.quoted || err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid limit %q", t.text)
+		}
+		q.limit = n
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.unexpected("the end of the query")
+	}
+	return q, q.validate(p.columns)
+}
+
+func (p *queryParser) selectList(columns []inventoryColumn) ([]selectItem, error) {
+	var items []selectItem
+	for {
+		if p.accept("*") {
+			for _, c := range columns {
+				items = append(items, selectItem{column: c.name, name: c.name})
+			}
+		} else {
+			item, err := p.selectItem()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		if !p.accept(",") {
+			return items, nil
+		}
+	}
+}
+
+func (p *queryParser) selectItem() (selectItem, error) {
+	var item selectItem
+	if t := p.peek(); !t.quoted && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "(" {
+		switch t.text {
+		case "count", "sum", "avg", "min", "max":
+		default:
+			return item, fmt.Errorf("unknown function %q, expected COUNT, SUM, AVG, MIN or MAX", t.text)
+		}
+		item.aggregate = t.text
+		p.pos += 2
+		if item.aggregate == "count" && p.accept("*") {
+			item.name = "count"
+		} else {
+			column, err := p.column()
+			if err != nil {
+				return item, err
+			}
+			if item.aggregate != "count" && column.kind != kindNumber {
+				return item, fmt.Errorf("%s(%s): the column is not numeric", strings.ToUpper(item.aggregate), column.name)
+			}
+			item.column = column.name
+			item.name = item.aggregate + "_" + column.name
+		}
+		if err := p.expect(")"); err != nil {
+			return item, err
+		}
+	} else {
+		column, err := p.column()
+		if err != nil {
+			return item, err
+		}
+		item.column = column.name
+		item.name = column.name
+	}
+	if p.accept("as") {
+		name, err := p.identifier()
+		if err != nil {
+			return item, err
+		}
+		item.name = name
+	}
+	return item, nil
+}
+
+// orderKey parses an ORDER BY key: the name of a selected item, which can
+// be an aggregate written as in the select list, or a column.
+func (p *queryParser) orderKey() (orderKey, error) {
+	if t := p.peek(); !t.quoted && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "(" {
+		item, err := p.selectItem()
+		return orderKey{name: item.name}, err
+	}
+	name, err := p.identifier()
+	return orderKey{name: name}, err
+}
+
+func (p *queryParser) or() (condition, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orCondition{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) and() (condition, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = andCondition{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) not() (condition, error) {
+	if p.accept("not") {
+		inner, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{inner}, nil
+	}
+	if p.accept("(") {
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	}
+	return p.comparison()
+}
+
+func (p *queryParser) comparison() (condition, error) {
+	column, err := p.column()
+	if err != nil {
+		return nil, err
+	}
+	c := comparison{column: column.name}
+
+	negate := p.accept("not")
+	t := p.peek()
+	switch {
+	case !t.quoted && t.text == "like":
+		p.pos++
+		pattern := p.next()
+		if column.kind != kindString || !pattern.quoted {
+			return nil, fmt.Errorf("LIKE compares a text column to a quoted pattern")
+		}
+		c.op = "like"
+		c.like = likePattern(pattern.text)
+	case !t.quoted && t.text == "in":
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		c.op = "in"
+		for {
+			value, err := p.literal(column)
+			if err != nil {
+				return nil, err
+			}
+			c.values = append(c.values, value)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	case negate:
+		return nil, p.unexpected("LIKE or IN")
+	case !t.quoted && comparisonOperators[t.text]:
+		p.pos++
+		if column.kind == kindBool && t.text != "=" && t.text != "!=" {
+			return nil, fmt.Errorf("%s is true or false, compare it with = or !=", column.name)
+		}
+		c.op = t.text
+		value, err := p.literal(column)
+		if err != nil {
+			return nil, err
+		}
+		c.values = []any{value}
+	case column.kind == kindBool:
+		// A boolean column alone is a condition, e.g. "WHERE ready".
+		c.op = "="
+		c.values = []any{true}
+	default:
+		return nil, p.unexpected("a comparison")
+	}
+	if negate {
+		return notCondition{c}, nil
+	}
+	return c, nil
+}
+
+var comparisonOperators = map[string]bool{"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// literal parses a value of the kind of the column. Numeric resource columns
+// also accept quantities, e.g. '500m' or '2Gi'.
+func (p *queryParser) literal(column inventoryColumn) (any, error) {
+	t := p.next()
+	if _, ok := p.columns[t.text]; ok && !t.quoted {
+		return nil, fmt.Errorf("%s is compared to the column %s, columns can only be compared to values", column.name, t.text)
+	}
+	switch column.kind {
+	case kindNumber:
+		if !t.quoted {
+			if n, err := strconv.ParseFloat(t.text, 64); err == nil {
+				return n, nil
+			}
+		} else if column.quantity {
+			if q, err := resource.ParseQuantity(t.text); err == nil {
+				return q.AsApproximateFloat64(), nil
+			}
+		}
+		return nil, fmt.Errorf("%s is a number, got %q", column.name, t.text)
+	case kindBool:
+		if !t.quoted && (t.text == "true" || t.text == "false") {
+			return t.text == "true", nil
+		}
+		return nil, fmt.Errorf("%s is true or false, got %q", column.name, t.text)
+	}
+	if !t.quoted {
+		return nil, fmt.Errorf("%s is text, quote the value %q", column.name, t.text)
+	}
+	return t.text, nil
+}
+
+// likePattern converts a LIKE pattern, where % matches any text and _ any
+// character, to a regular expression.
+func likePattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// validate checks that the select list, grouping and ordering of the query are consistent.
+func (q *inventoryQuery) validate(columns map[string]inventoryColumn) error {
+	aggregated := len(q.groupBy) > 0
+	for _, item := range q.items {
+		if item.aggregate != "" {
+			aggregated = true
+		}
+	}
+	names := make(map[string]bool)
+	for _, item := range q.items {
+		if names[item.name] {
+			return fmt.Errorf("duplicate column %q, rename it with AS", item.name)
+		}
+		names[item.name] = true
+		if aggregated && item.aggregate == "" && !slices.Contains(q.groupBy, item.column) {
+			return fmt.Errorf("%s must be in GROUP BY or in an aggregate like COUNT(*)", item.column)
+		}
+	}
+	for _, key := range q.orderBy {
+		if names[key.name] {
+			continue
+		}
+		// Queries without aggregates can be ordered by any column.
+		if _, ok := columns[key.name]; !ok || aggregated {
+			return fmt.Errorf("ORDER BY %s: order by a selected column or aggregate", key.name)
+		}
+	}
+	return nil
+}
+
+// inventoryRows is the result of an inventory query.
+type inventoryRows struct {
+	columns []string
+	rows    [][]any
+}
+
+// run evaluates the query on the rows of its table.
+func (q *inventoryQuery) run(table []map[string]any) *inventoryRows {
+	var rows []map[string]any
+	for _, row := range table {
+		if q.where == nil || q.where.eval(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	aggregated := len(q.groupBy) > 0
+	for _, item := range q.items {
+		if item.aggregate != "" {
+			aggregated = true
+		}
+	}
+
+	// The output rows are keyed by the names of the items and, for queries
+	// without aggregates, by the columns of the table too for ordering.
+	var out []map[string]any
+	if aggregated {
+		out = q.aggregate(rows)
+	} else {
+		for _, row := range rows {
+			o := make(map[string]any, len(row)+len(q.items))
+			for k, v := range row {
+				o[k] = v
+			}
+			for _, item := range q.items {
+				o[item.name] = row[item.column]
+			}
+			out = append(out, o)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		for _, key := range q.orderBy {
+			cmp := compareValues(out[i][key.name], out[j][key.name])
+			if cmp == 0 {
+				continue
+			}
+			return (cmp < 0) != key.desc
+		}
+		return false
+	})
+
+	result := &inventoryRows{rows: [][]any{}}
+	for _, item := range q.items {
+		result.columns = append(result.columns, item.name)
+	}
+	for _, o := range out {
+		row := make([]any, len(q.items))
+		for i, item := range q.items {
+			row[i] = roundValue(o[item.name])
+		}
+		result.rows = append(result.rows, row)
+	}
+	return result
+}
+
+// aggregate groups the rows by the GROUP BY columns, in the order of their
+// first row, and computes the aggregates of each group.
+func (q *inventoryQuery) aggregate(rows []map[string]any) []map[string]any {
+	type group struct {
+		rows []map[string]any
+	}
+	groups := make(map[string]*group)
+	var keys []string
+	for _, row := range rows {
+		var key strings.Builder
+		for _, column := range q.groupBy {
+			fmt.Fprintf(&key, "%v\x00", row[column])
+		}
+		g, ok := groups[key.String()]
+		if !ok {
+			g = &group{}
+			groups[key.String()] = g
+			keys = append(keys, key.String())
+		}
+		g.rows = append(g.rows, row)
+	}
+	// Aggregates without GROUP BY return a row even without any matching rows.
+	if len(q.groupBy) == 0 && len(keys) == 0 {
+		groups[""] = &group{}
+		keys = append(keys, "")
+	}
+
+	var out []map[string]any
+	for _, key := range keys {
+		g := groups[key]
+		o := make(map[string]any, len(q.items))
+		for _, item := range q.items {
+			switch item.aggregate {
+			case "":
+				o[item.name] = g.rows[0][item.column]
+			case "count":
+				o[item.name] = float64(len(g.rows))
+			default:
+				o[item.name] = aggregateValues(item.aggregate, item.column, g.rows)
+			}
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+func aggregateValues(aggregate, column string, rows []map[string]any) float64 {
+	if len(rows) == 0 {
+		return 0
+	}
+	var sum float64
+	minimum, maximum := math.Inf(1), math.Inf(-1)
+	for _, row := range rows {
+		v, _ := row[column].(float64)
+		sum += v
+		minimum = math.Min(minimum, v)
+		maximum = math.Max(maximum, v)
+	}
+	switch aggregate {
+	case "avg":
+		return sum / float64(len(rows))
+	case "min":
+		return minimum
+	case "max":
+		return maximum
+	}
+	return sum
+}
+
+// roundValue rounds numbers to 3 decimals, e.g. sums of CPU cores.
+func roundValue(v any) any {
+	if f, ok := v.(float64); ok {
+		return math.Round(f*1000) / 1000
+	}
+	return v
+}
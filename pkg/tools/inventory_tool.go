@@ -0,0 +1,397 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// inventoryTables are the tables of the inventory and their columns.
+var inventoryTables = map[string][]inventoryColumn{
+	"pods": {
+		{name: "namespace", kind: kindString},
+		{name: "name", kind: kindString},
+		{name: "node", kind: kindString, doc: "empty for pending pods"},
+		{name: "phase", kind: kindString, doc: "Pending, Running, Succeeded, Failed or Unknown"},
+		{name: "ready", kind: kindBool},
+		{name: "restarts", kind: kindNumber, doc: "total of the containers"},
+		{name: "containers", kind: kindNumber},
+		{name: "owner_kind", kind: kindString, doc: "Deployment, StatefulSet, DaemonSet, Job..., empty for bare pods"},
+		{name: "owner_name", kind: kindString},
+		{name: "qos_class", kind: kindString},
+		{name: "cpu_request", kind: kindNumber, quantity: true, doc: "cores"},
+		{name: "memory_request", kind: kindNumber, quantity: true, doc: "bytes"},
+		{name: "cpu_limit", kind: kindNumber, quantity: true, doc: "cores"},
+		{name: "memory_limit", kind: kindNumber, quantity: true, doc: "bytes"},
+		{name: "has_requests", kind: kindBool, doc: "every container requests CPU and memory"},
+		{name: "has_limits", kind: kindBool, doc: "every container has CPU and memory limits"},
+		{name: "images", kind: kindString, doc: "comma-separated"},
+		{name: "age_hours", kind: kindNumber},
+	},
+	"nodes": {
+		{name: "name", kind: kindString},
+		{name: "pool", kind: kindString},
+		{name: "zone", kind: kindString},
+		{name: "instance_type", kind: kindString},
+		{name: "ready", kind: kindBool},
+		{name: "unschedulable", kind: kindBool},
+		{name: "kubelet_version", kind: kindString},
+		{name: "pods", kind: kindNumber, doc: "pods running on the node"},
+		{name: "cpu_allocatable", kind: kindNumber, quantity: true, doc: "cores"},
+		{name: "memory_allocatable", kind: kindNumber, quantity: true, doc: "bytes"},
+		{name: "cpu_requested", kind: kindNumber, quantity: true, doc: "cores requested by the pods of the node"},
+		{name: "memory_requested", kind: kindNumber, quantity: true, doc: "bytes requested by the pods of the node"},
+		{name: "age_hours", kind: kindNumber},
+	},
+	"deployments": {
+		{name: "namespace", kind: kindString},
+		{name: "name", kind: kindString},
+		{name: "replicas", kind: kindNumber, doc: "desired"},
+		{name: "ready_replicas", kind: kindNumber},
+		{name: "available_replicas", kind: kindNumber},
+		{name: "updated_replicas", kind: kindNumber},
+		{name: "paused", kind: kindBool},
+		{name: "strategy", kind: kindString},
+		{name: "images", kind: kindString, doc: "comma-separated"},
+		{name: "age_hours", kind: kindNumber},
+	},
+}
+
+const (
+	defaultInventoryRows = 100
+	maxInventoryRows     = 1000
+)
+
+// InventoryTool answers SQL-like queries over an in-memory inventory of the
+// pods, nodes and deployments of the cluster, so that the model computes
+// aggregates in one call instead of parsing kubectl tables. The inventory is
+// collected on the first query, and collected again on demand.
+type InventoryTool struct {
+	executor sandbox.Executor
+	now      func() time.Time
+
+	mu        sync.Mutex
+	inventory *inventory
+}
+
+// inventory is a snapshot of the cluster, as rows keyed by column name.
+type inventory struct {
+	kubeconfig  string
+	collectedAt time.Time
+	tables      map[string][]map[string]any
+}
+
+func NewInventoryTool(executor sandbox.Executor) *InventoryTool {
+	return &InventoryTool{executor: executor, now: time.Now}
+}
+
+func (t *InventoryTool) Name() string {
+	return "query_inventory"
+}
+
+func (t *InventoryTool) Description() string {
+	var b strings.Builder
+	b.WriteString(`Runs a SQL-like query over an inventory of the pods, nodes and deployments of all the namespaces, collected on the first query and reused until refreshed.
+Use it for counts, sums and other aggregates across many objects, e.g. "SELECT node, COUNT(*) FROM pods WHERE NOT has_limits GROUP BY node ORDER BY count DESC", instead of listing objects with kubectl.
+Syntax: SELECT <columns, *, COUNT(*), SUM|AVG|MIN|MAX(<column>)> [AS <name>] FROM <table> [WHERE <conditions>] [GROUP BY <columns>] [ORDER BY <name> [DESC]] [LIMIT <n>].
+Conditions compare a column to a literal with =, !=, <, <=, >, >=, [NOT] LIKE '<pattern with %>' or [NOT] IN (...), combined with AND, OR, NOT and parentheses. Text is quoted, CPU and memory columns also accept quantities like '500m' or '2Gi'.
+Aggregates are named count or <function>_<column>, e.g. sum_cpu_request, unless renamed with AS.
+Tables and columns:`)
+	for _, table := range []string{"pods", "nodes", "deployments"} {
+		fmt.Fprintf(&b, "\n- %s:", table)
+		for i, c := range inventoryTables[table] {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(" " + c.name)
+			if c.doc != "" {
+				b.WriteString(" (" + c.doc + ")")
+			}
+		}
+	}
+	return b.String()
+}
+
+func (t *InventoryTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"query": {
+					Type:        gollm.TypeString,
+					Description: `The query, e.g. "SELECT namespace, SUM(cpu_request) AS cpu FROM pods GROUP BY namespace ORDER BY cpu DESC LIMIT 5".`,
+				},
+				"refresh": {
+					Type:        gollm.TypeBoolean,
+					Description: `Whether to collect the inventory again before the query, e.g. after changes to the cluster. Defaults to reusing the inventory.`,
+				},
+			},
+			Required: []string{"query"},
+		},
+	}
+}
+
+// InventoryResult is the result of the query_inventory tool.
+type InventoryResult struct {
+	Query string `json:"query"`
+	// CollectedAt is the time of the inventory the query ran on.
+	CollectedAt time.Time `json:"collectedAt,omitzero"`
+	Columns     []string  `json:"columns,omitempty"`
+	Rows        [][]any   `json:"rows,omitempty"`
+	// Truncated is set when the rows were cut to the limit of the query, or
+	// else to the default limit of 100 rows.
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (t *InventoryTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	query, _ := args["query"].(string)
+	refresh, _ := args["refresh"].(bool)
+
+	result := &InventoryResult{Query: query}
+	q, err := parseInventoryQuery(query, inventoryTables)
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid query: %v", err)
+		return result, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// A different kubeconfig, e.g. after switching the namespace or context, is another cluster view.
+	if refresh || t.inventory == nil || t.inventory.kubeconfig != kubeconfig {
+		env, err := toolEnv(ctx, kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		inv, err := t.collect(ctx, env, workDir)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		inv.kubeconfig = kubeconfig
+		t.inventory = inv
+	}
+	result.CollectedAt = t.inventory.collectedAt
+
+	rows := q.run(t.inventory.tables[q.table])
+	limit := q.limit
+	if limit == 0 {
+		limit = defaultInventoryRows
+	}
+	limit = min(limit, maxInventoryRows)
+	result.Columns = rows.columns
+	result.Rows = rows.rows
+	if len(result.Rows) > limit {
+		result.Rows = result.Rows[:limit]
+		result.Truncated = q.limit == 0 || q.limit > maxInventoryRows
+	}
+	return result, nil
+}
+
+// collect lists the pods, nodes and deployments of the cluster.
+func (t *InventoryTool) collect(ctx context.Context, env []string, workDir string) (*inventory, error) {
+	commands := map[string]string{
+		"pods":        "kubectl get pods --all-namespaces -o json",
+		"nodes":       "kubectl get nodes -o json",
+		"deployments": "kubectl get deployments --all-namespaces -o json",
+	}
+	items := make(map[string][]unstructured.Unstructured)
+	for _, table := range []string{"pods", "nodes", "deployments"} {
+		list, err := getKubectlItems(ctx, t.executor, commands[table], env, workDir)
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: %w", table, err)
+		}
+		items[table] = list
+	}
+
+	now := t.now()
+	inv := &inventory{collectedAt: now.UTC(), tables: make(map[string][]map[string]any)}
+	age := func(obj unstructured.Unstructured) float64 {
+		created := obj.GetCreationTimestamp().Time
+		if created.IsZero() {
+			return 0
+		}
+		return now.Sub(created).Hours()
+	}
+
+	nodes := make(map[string]map[string]any)
+	for _, node := range items["nodes"] {
+		labels := node.GetLabels()
+		unschedulable, _, _ := unstructured.NestedBool(node.Object, "spec", "unschedulable")
+		kubeletVersion, _, _ := unstructured.NestedString(node.Object, "status", "nodeInfo", "kubeletVersion")
+		allocatable, _, _ := unstructured.NestedStringMap(node.Object, "status", "allocatable")
+		row := map[string]any{
+			"name":               node.GetName(),
+			"pool":               nodePoolOf(node),
+			"zone":               labels["topology.kubernetes.io/zone"],
+			"instance_type":      labels["node.kubernetes.io/instance-type"],
+			"ready":              conditionTrue(node, "Ready"),
+			"unschedulable":      unschedulable,
+			"kubelet_version":    kubeletVersion,
+			"pods":               float64(0),
+			"cpu_allocatable":    parseQuantity(allocatable["cpu"]).AsApproximateFloat64(),
+			"memory_allocatable": parseQuantity(allocatable["memory"]).AsApproximateFloat64(),
+			"cpu_requested":      float64(0),
+			"memory_requested":   float64(0),
+			"age_hours":          age(node),
+		}
+		nodes[node.GetName()] = row
+		inv.tables["nodes"] = append(inv.tables["nodes"], row)
+	}
+
+	for _, pod := range items["pods"] {
+		nodeName, _, _ := unstructured.NestedString(pod.Object, "spec", "nodeName")
+		phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+		qosClass, _, _ := unstructured.NestedString(pod.Object, "status", "qosClass")
+		requests, limits := podResources(pod)
+		containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+		hasRequests, hasLimits := len(containers) > 0, len(containers) > 0
+		var images []string
+		for _, c := range containers {
+			container, ok := c.(map[string]any)
+			if !ok {
+				continue
+			}
+			if image, _, _ := unstructured.NestedString(container, "image"); image != "" {
+				images = append(images, image)
+			}
+			containerRequests, _, _ := unstructured.NestedStringMap(container, "resources", "requests")
+			containerLimits, _, _ := unstructured.NestedStringMap(container, "resources", "limits")
+			hasRequests = hasRequests && containerRequests["cpu"] != "" && containerRequests["memory"] != ""
+			hasLimits = hasLimits && containerLimits["cpu"] != "" && containerLimits["memory"] != ""
+		}
+		restarts := 0
+		statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", "containerStatuses")
+		for _, s := range statuses {
+			if status, ok := s.(map[string]any); ok {
+				n, _, _ := unstructured.NestedInt64(status, "restartCount")
+				restarts += int(n)
+			}
+		}
+		ownerKind, ownerName := podOwner(pod)
+		inv.tables["pods"] = append(inv.tables["pods"], map[string]any{
+			"namespace":      pod.GetNamespace(),
+			"name":           pod.GetName(),
+			"node":           nodeName,
+			"phase":          phase,
+			"ready":          conditionTrue(pod, "Ready"),
+			"restarts":       float64(restarts),
+			"containers":     float64(len(containers)),
+			"owner_kind":     ownerKind,
+			"owner_name":     ownerName,
+			"qos_class":      qosClass,
+			"cpu_request":    milliToCores(requests.cpu),
+			"memory_request": float64(requests.memory),
+			"cpu_limit":      milliToCores(limits.cpu),
+			"memory_limit":   float64(limits.memory),
+			"has_requests":   hasRequests,
+			"has_limits":     hasLimits,
+			"images":         strings.Join(images, ","),
+			"age_hours":      age(pod),
+		})
+
+		// Completed pods no longer use the resources of their node.
+		if node, ok := nodes[nodeName]; ok && phase != "Succeeded" && phase != "Failed" {
+			node["pods"] = node["pods"].(float64) + 1
+			node["cpu_requested"] = node["cpu_requested"].(float64) + milliToCores(requests.cpu)
+			node["memory_requested"] = node["memory_requested"].(float64) + float64(requests.memory)
+		}
+	}
+
+	for _, deployment := range items["deployments"] {
+		replicas, found, _ := unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		readyReplicas, _, _ := unstructured.NestedInt64(deployment.Object, "status", "readyReplicas")
+		availableReplicas, _, _ := unstructured.NestedInt64(deployment.Object, "status", "availableReplicas")
+		updatedReplicas, _, _ := unstructured.NestedInt64(deployment.Object, "status", "updatedReplicas")
+		paused, _, _ := unstructured.NestedBool(deployment.Object, "spec", "paused")
+		strategy, _, _ := unstructured.NestedString(deployment.Object, "spec", "strategy", "type")
+		var images []string
+		containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+		for _, c := range containers {
+			if container, ok := c.(map[string]any); ok {
+				if image, _, _ := unstructured.NestedString(container, "image"); image != "" {
+					images = append(images, image)
+				}
+			}
+		}
+		inv.tables["deployments"] = append(inv.tables["deployments"], map[string]any{
+			"namespace":          deployment.GetNamespace(),
+			"name":               deployment.GetName(),
+			"replicas":           float64(replicas),
+			"ready_replicas":     float64(readyReplicas),
+			"available_replicas": float64(availableReplicas),
+			"updated_replicas":   float64(updatedReplicas),
+			"paused":             paused,
+			"strategy":           strategy,
+			"images":             strings.Join(images, ","),
+			"age_hours":          age(deployment),
+		})
+	}
+	return inv, nil
+}
+
+// podOwner returns the kind and name of the controller of a pod, resolving
+// the ReplicaSets of Deployments to their Deployment.
+func podOwner(pod unstructured.Unstructured) (kind, name string) {
+	for _, ref := range pod.GetOwnerReferences() {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		if ref.Kind == "ReplicaSet" {
+			if hash := pod.GetLabels()["pod-template-hash"]; hash != "" {
+				return "Deployment", strings.TrimSuffix(ref.Name, "-"+hash)
+			}
+		}
+		return ref.Kind, ref.Name
+	}
+	return "", ""
+}
+
+// conditionTrue returns whether the status condition of the object is "True".
+func conditionTrue(obj unstructured.Unstructured, conditionType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if ok && condition["type"] == conditionType {
+			return condition["status"] == "True"
+		}
+	}
+	return false
+}
+
+func (t *InventoryTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "no", the query_inventory tool only reads resources.
+func (t *InventoryTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
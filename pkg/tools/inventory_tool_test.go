@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const inventoryPods = `{"kind": "List", "items": [
+	{"metadata": {"namespace": "shop", "name": "web-7d9f-abc", "labels": {"pod-template-hash": "7d9f"},
+		"ownerReferences": [{"kind": "ReplicaSet", "name": "web-7d9f", "controller": true}]},
+	 "spec": {"nodeName": "node-a", "containers": [{"name": "app", "image": "web:1.2",
+		"resources": {"requests": {"cpu": "500m", "memory": "256Mi"}, "limits": {"cpu": "1", "memory": "512Mi"}}}]},
+	 "status": {"phase": "Running", "conditions": [{"type": "Ready", "status": "True"}], "containerStatuses": [{"name": "app", "restartCount": 1}]}},
+	{"metadata": {"namespace": "shop", "name": "web-7d9f-def", "labels": {"pod-template-hash": "7d9f"},
+		"ownerReferences": [{"kind": "ReplicaSet", "name": "web-7d9f", "controller": true}]},
+	 "spec": {"nodeName": "node-b", "containers": [{"name": "app", "image": "web:1.2", "resources": {"requests": {"cpu": "500m", "memory": "256Mi"}}}]},
+	 "status": {"phase": "Running", "conditions": [{"type": "Ready", "status": "False"}], "containerStatuses": [{"name": "app", "restartCount": 7}]}},
+	{"metadata": {"namespace": "batch", "name": "report"},
+	 "spec": {"nodeName": "node-a", "containers": [{"name": "job", "image": "report:latest"}]},
+	 "status": {"phase": "Running", "conditions": [{"type": "Ready", "status": "True"}]}}
+]}`
+
+const inventoryNodes = `{"kind": "List", "items": [
+	{"metadata": {"name": "node-a", "creationTimestamp": "2025-06-01T00:00:00Z", "labels": {"cloud.google.com/gke-nodepool": "pool-1"}},
+	 "status": {"allocatable": {"cpu": "4", "memory": "16Gi"}, "conditions": [{"type": "Ready", "status": "True"}], "nodeInfo": {"kubeletVersion": "v1.30.2"}}},
+	{"metadata": {"name": "node-b", "creationTimestamp": "2025-06-02T00:00:00Z", "labels": {"cloud.google.com/gke-nodepool": "pool-1"}},
+	 "spec": {"unschedulable": true},
+	 "status": {"allocatable": {"cpu": "2", "memory": "8Gi"}, "conditions": [{"type": "Ready", "status": "True"}], "nodeInfo": {"kubeletVersion": "v1.29.6"}}}
+]}`
+
+const inventoryDeployments = `{"kind": "List", "items": [
+	{"metadata": {"namespace": "shop", "name": "web"}, "spec": {"replicas": 2, "strategy": {"type": "RollingUpdate"},
+		"template": {"spec": {"containers": [{"name": "app", "image": "web:1.2"}]}}},
+	 "status": {"readyReplicas": 1, "availableReplicas": 1, "updatedReplicas": 2}}
+]}`
+
+func TestInventoryTool(t *testing.T) {
+	executor := &scriptedExecutor{outputs: map[string]string{
+		"kubectl get pods --all-namespaces -o json":        inventoryPods,
+		"kubectl get nodes -o json":                        inventoryNodes,
+		"kubectl get deployments --all-namespaces -o json": inventoryDeployments,
+	}}
+	tool := NewInventoryTool(executor)
+	tool.now = func() time.Time { return time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC) }
+	ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+	ctx = context.WithValue(ctx, WorkDirKey, t.TempDir())
+
+	tests := []struct {
+		query       string
+		wantColumns []string
+		wantRows    [][]any
+		wantError   string
+	}{
+		{
+			query:       "SELECT node, COUNT(*) FROM pods WHERE NOT has_limits GROUP BY node ORDER BY count DESC, node",
+			wantColumns: []string{"node", "count"},
+			wantRows:    [][]any{{"node-a", 1.0}, {"node-b", 1.0}},
+		},
+		{
+			query:       "select owner_kind, owner_name, sum(restarts) as restarts, avg(cpu_request) from pods where owner_kind != '' group by owner_kind, owner_name",
+			wantColumns: []string{"owner_kind", "owner_name", "restarts", "avg_cpu_request"},
+			wantRows:    [][]any{{"Deployment", "web", 8.0, 0.5}},
+		},
+		{
+			query:       "SELECT name FROM pods WHERE (restarts > 0 AND NOT ready) OR images LIKE 'report:%' ORDER BY name DESC",
+			wantColumns: []string{"name"},
+			wantRows:    [][]any{{"web-7d9f-def"}, {"report"}},
+		},
+		{
+			query:       "SELECT name, pods, cpu_requested, memory_requested, age_hours FROM nodes WHERE memory_allocatable >= '16Gi' OR unschedulable = true ORDER BY cpu_allocatable;",
+			wantColumns: []string{"name", "pods", "cpu_requested", "memory_requested", "age_hours"},
+			wantRows:    [][]any{{"node-b", 1.0, 0.5, 268435456.0, 24.0}, {"node-a", 2.0, 0.5, 268435456.0, 48.0}},
+		},
+		{
+			query:     "SELECT COUNT(*), MAX(replicas) FROM deployments WHERE ready_replicas < replicas",
+			wantError: `invalid query: ready_replicas is compared to the column replicas, columns can only be compared to values`,
+		},
+		{
+			query:       "SELECT COUNT(*) AS total FROM pods WHERE namespace IN ('shop', 'batch') AND name NOT LIKE 'web-%'",
+			wantColumns: []string{"total"},
+			wantRows:    [][]any{{1.0}},
+		},
+		{
+			query:     "SELECT name, COUNT(*) FROM pods",
+			wantError: "invalid query: name must be in GROUP BY or in an aggregate like COUNT(*)",
+		},
+		{
+			query:     "SELECT name FROM services",
+			wantError: `invalid query: unknown table "services", expected one of deployments, nodes, pods`,
+		},
+		{
+			query:     "SELECT name FROM pods WHERE phase = Running",
+			wantError: `invalid query: phase is text, quote the value "running"`,
+		},
+		{
+			query:     "SELECT name FROM pods LIMIT 1 OFFSET 2",
+			wantError: `invalid query: expected the end of the query, got "offset"`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := tool.Run(ctx, map[string]any{"query": tt.query})
+		if err != nil {
+			t.Fatalf("Run(%q): %v", tt.query, err)
+		}
+		result := got.(*InventoryResult)
+		if result.Error != tt.wantError {
+			t.Errorf("Run(%q) error = %q, want %q", tt.query, result.Error, tt.wantError)
+			continue
+		}
+		if !reflect.DeepEqual(result.Columns, tt.wantColumns) || !reflect.DeepEqual(result.Rows, tt.wantRows) {
+			t.Errorf("Run(%q) = %v %v, want %v %v", tt.query, result.Columns, result.Rows, tt.wantColumns, tt.wantRows)
+		}
+	}
+}
+
+func TestInventoryToolRefresh(t *testing.T) {
+	executor := &scriptedExecutor{outputs: map[string]string{
+		"kubectl get pods --all-namespaces -o json":        inventoryPods,
+		"kubectl get nodes -o json":                        inventoryNodes,
+		"kubectl get deployments --all-namespaces -o json": inventoryDeployments,
+	}}
+	tool := NewInventoryTool(executor)
+	ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+	ctx = context.WithValue(ctx, WorkDirKey, t.TempDir())
+	count := func(args map[string]any) any {
+		t.Helper()
+		got, err := tool.Run(ctx, args)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		result := got.(*InventoryResult)
+		if result.Error != "" {
+			return result.Error
+		}
+		return result.Rows[0][0]
+	}
+
+	if got := count(map[string]any{"query": "SELECT COUNT(*) FROM pods"}); got != 3.0 {
+		t.Fatalf("COUNT(*) = %v, want 3", got)
+	}
+	// The inventory is reused until refreshed.
+	executor.outputs["kubectl get pods --all-namespaces -o json"] = `{"kind": "List", "items": []}`
+	if got := count(map[string]any{"query": "SELECT COUNT(*) FROM pods"}); got != 3.0 {
+		t.Errorf("COUNT(*) = %v, want the inventory to be reused", got)
+	}
+	if got := count(map[string]any{"query": "SELECT COUNT(*) FROM pods", "refresh": true}); got != 0.0 {
+		t.Errorf("COUNT(*) = %v, want 0 after refresh", got)
+	}
+
+	delete(executor.outputs, "kubectl get nodes -o json")
+	if got := count(map[string]any{"query": "SELECT COUNT(*) FROM nodes", "refresh": true}); !strings.HasPrefix(got.(string), "listing nodes:") {
+		t.Errorf("Run = %v, want the listing error", got)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// KubeAuthErrorKind classifies the failures to authenticate to the cluster.
+type KubeAuthErrorKind string
+
+const (
+	// KubeAuthExpired is an expired or revoked token, e.g. an OIDC token.
+	KubeAuthExpired KubeAuthErrorKind = "expired"
+	// KubeAuthMissingPlugin is an exec credential plugin that is not installed.
+	KubeAuthMissingPlugin KubeAuthErrorKind = "missing-plugin"
+	// KubeAuthPluginFailed is an exec credential plugin that failed, e.g. to refresh its token.
+	KubeAuthPluginFailed KubeAuthErrorKind = "plugin-failed"
+	// KubeAuthUnauthorized is any other rejection of the credentials by the cluster.
+	KubeAuthUnauthorized KubeAuthErrorKind = "unauthorized"
+)
+
+// KubeAuthError is a kubectl call that could not authenticate to the
+// cluster. Retrying the call or working around it does not help: the user
+// must re-authenticate.
+type KubeAuthError struct {
+	Kind KubeAuthErrorKind `json:"kind"`
+	// Plugin is the exec credential plugin named in the error, if any.
+	Plugin string `json:"plugin,omitempty"`
+	// Message is the line of the output reporting the error.
+	Message string `json:"message"`
+}
+
+// Reason describes the failure for the user, e.g. "the credentials expired".
+func (e *KubeAuthError) Reason() string {
+	switch e.Kind {
+	case KubeAuthExpired:
+		return "the credentials expired"
+	case KubeAuthMissingPlugin:
+		return fmt.Sprintf("the credential plugin %s is not installed", e.Plugin)
+	case KubeAuthPluginFailed:
+		return fmt.Sprintf("the credential plugin %s failed", e.Plugin)
+	}
+	return "the cluster rejected the credentials"
+}
+
+func (e *KubeAuthError) Error() string {
+	return fmt.Sprintf("kubectl could not authenticate to the cluster: %s (%s). The user must re-authenticate: do not retry the command or work around the error, report it to the user.", e.Reason(), e.Message)
+}
+
+// kubeAuthPatterns match the errors of kubectl failing to authenticate, in
+// the order they are tried. The first group is the name of the plugin, if any.
+var kubeAuthPatterns = []struct {
+	kind    KubeAuthErrorKind
+	pattern *regexp.Regexp
+}{
+	{KubeAuthMissingPlugin, regexp.MustCompile(`exec: executable (\S+) not found|exec: fork/exec (\S+): no such file or directory`)},
+	{KubeAuthPluginFailed, regexp.MustCompile(`exec: executable (\S+) failed with exit code \d+`)},
+	{KubeAuthExpired, regexp.MustCompile(`(?i)token (is |has )?expired|expired token|refresh token|invalid_grant|credentials (have |has )?expired`)},
+	{KubeAuthUnauthorized, regexp.MustCompile(`You must be logged in to the server|\(Unauthorized\)|the server has asked for the client to provide credentials`)},
+}
+
+// DetectKubeAuthError returns the failure to authenticate to the cluster
+// reported by a failed command, or nil. Authorization errors (Forbidden) are
+// not authentication errors: the credentials are valid.
+func DetectKubeAuthError(output any) *KubeAuthError {
+	result, ok := output.(*sandbox.ExecResult)
+	if !ok || result == nil || result.ExitCode == 0 {
+		return nil
+	}
+	lines := strings.Split(result.Stderr, "\n")
+	for _, p := range kubeAuthPatterns {
+		for _, line := range lines {
+			match := p.pattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			authErr := &KubeAuthError{Kind: p.kind, Message: strings.TrimSpace(line)}
+			for _, group := range match[1:] {
+				if group != "" {
+					authErr.Plugin = group
+					break
+				}
+			}
+			return authErr
+		}
+	}
+	return nil
+}
+
+// ExecPluginCommand returns the command line running an exec credential
+// plugin, and its environment: the environment of the tools, with the session
+// variables and the ones the plugin expects. The plugin prints a credential,
+// refreshing it first if needed, e.g. by a login in the browser.
+func ExecPluginCommand(plugin *clientcmdapi.ExecConfig, sessionEnv []string) (command string, env []string, err error) {
+	words := []string{quoteWord(plugin.Command)}
+	for _, arg := range plugin.Args {
+		words = append(words, quoteWord(arg))
+	}
+	env = append(baseEnv(), sessionEnv...)
+	for _, v := range plugin.Env {
+		env = append(env, v.Name+"="+v.Value)
+	}
+	info, err := json.Marshal(map[string]any{
+		"apiVersion": plugin.APIVersion,
+		"kind":       "ExecCredential",
+		"spec":       map[string]any{"interactive": plugin.InteractiveMode != clientcmdapi.NeverExecInteractiveMode},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	env = append(env, "KUBERNETES_EXEC_INFO="+string(info))
+	return strings.Join(words, " "), env, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestDetectKubeAuthError(t *testing.T) {
+	tests := []struct {
+		name       string
+		stderr     string
+		wantKind   KubeAuthErrorKind
+		wantPlugin string
+	}{
+		{
+			name:     "unauthorized",
+			stderr:   "error: You must be logged in to the server (Unauthorized)",
+			wantKind: KubeAuthUnauthorized,
+		},
+		{
+			name:       "missing plugin",
+			stderr:     "E0601 10:00:00.000000 memcache.go:265] couldn't get current server API group list: Get \"https://34.1.2.3/api\": getting credentials: exec: executable gke-gcloud-auth-plugin not found\n\nIt looks like you are trying to use a client-go credential plugin that is not installed.",
+			wantKind:   KubeAuthMissingPlugin,
+			wantPlugin: "gke-gcloud-auth-plugin",
+		},
+		{
+			name:       "plugin failed",
+			stderr:     "Unable to connect to the server: getting credentials: exec: executable kubelogin failed with exit code 1",
+			wantKind:   KubeAuthPluginFailed,
+			wantPlugin: "kubelogin",
+		},
+		{
+			name:     "expired oidc token",
+			stderr:   "Unable to connect to the server: failed to refresh token: oauth2: cannot fetch token: 400 Bad Request\nResponse: {\"error\":\"invalid_grant\"}",
+			wantKind: KubeAuthExpired,
+		},
+		{
+			name:   "forbidden",
+			stderr: "Error from server (Forbidden): pods is forbidden: User \"dev\" cannot list resource \"pods\"",
+		},
+		{
+			name:   "not found",
+			stderr: "Error from server (NotFound): deployments.apps \"web\" not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectKubeAuthError(&sandbox.ExecResult{Stderr: tt.stderr, ExitCode: 1})
+			if tt.wantKind == "" {
+				if got != nil {
+					t.Errorf("DetectKubeAuthError() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.Kind != tt.wantKind || got.Plugin != tt.wantPlugin {
+				t.Fatalf("DetectKubeAuthError() = %+v, want kind %q and plugin %q", got, tt.wantKind, tt.wantPlugin)
+			}
+			if !strings.Contains(got.Error(), "do not retry") {
+				t.Errorf("Error() = %q, want it to tell the model not to retry", got.Error())
+			}
+		})
+	}
+
+	if got := DetectKubeAuthError(&sandbox.ExecResult{Stderr: "error: You must be logged in to the server (Unauthorized)"}); got != nil {
+		t.Errorf("DetectKubeAuthError() of a successful command = %+v, want nil", got)
+	}
+}
+
+func TestExecPluginCommand(t *testing.T) {
+	plugin := &clientcmdapi.ExecConfig{
+		APIVersion: "client.authentication.k8s.io/v1beta1",
+		Command:    "kubelogin",
+		Args:       []string{"get-token", "--login", "device code"},
+		Env:        []clientcmdapi.ExecEnvVar{{Name: "AAD_LOGIN_METHOD", Value: "devicecode"}},
+	}
+	command, env, err := ExecPluginCommand(plugin, []string{"AWS_PROFILE=dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "kubelogin get-token --login 'device code'"; command != want {
+		t.Errorf("command = %q, want %q", command, want)
+	}
+	for _, want := range []string{"AWS_PROFILE=dev", "AAD_LOGIN_METHOD=devicecode", `KUBERNETES_EXEC_INFO={"apiVersion":"client.authentication.k8s.io/v1beta1","kind":"ExecCredential","spec":{"interactive":true}}`} {
+		if !slices.Contains(env, want) {
+			t.Errorf("env does not contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// KubeContext is a context of a kubeconfig.
+type KubeContext struct {
+	Name    string `json:"name"`
+	Cluster string `json:"cluster,omitempty"`
+	User    string `json:"user,omitempty"`
+	// Namespace is the namespace of the calls without a namespace flag, "default" if not set.
+	Namespace string `json:"namespace"`
+	// Current is set for the current context, the one of the calls without a context flag.
+	Current bool `json:"current,omitempty"`
+}
+
+// KubectlScope is where the kubectl calls without a context or a namespace flag run.
+type KubectlScope struct {
+	// Context is the current context of the kubeconfig.
+	Context string
+	// Namespace is the namespace of the current context.
+	Namespace string
+	// Namespaces are the namespaces of the contexts of the kubeconfig, by name.
+	Namespaces map[string]string
+}
+
+// namespace returns the namespace of the calls in a context without a namespace flag.
+func (s KubectlScope) namespace(context string) string {
+	if context == s.Context && s.Namespace != "" {
+		return s.Namespace
+	}
+	if namespace := s.Namespaces[context]; namespace != "" {
+		return namespace
+	}
+	return "default"
+}
+
+// KubeconfigLoadingRules returns the rules loading a kubeconfig path, which
+// may list several files separated by the OS path list separator, like
+// $KUBECONFIG. The files are merged as kubectl does: the first file setting a
+// value wins, missing files are skipped. The default kubeconfig files
+// ($KUBECONFIG or ~/.kube/config) are loaded if the path is empty.
+func KubeconfigLoadingRules(kubeconfig string) *clientcmd.ClientConfigLoadingRules {
+	if kubeconfig == "" {
+		return clientcmd.NewDefaultClientConfigLoadingRules()
+	}
+	return &clientcmd.ClientConfigLoadingRules{Precedence: filepath.SplitList(kubeconfig)}
+}
+
+// LoadKubeconfig loads the merged kubeconfig of a kubeconfig path, see
+// KubeconfigLoadingRules.
+func LoadKubeconfig(kubeconfig string) (*clientcmdapi.Config, error) {
+	config, err := KubeconfigLoadingRules(kubeconfig).Load()
+	if err != nil {
+		return nil, fmt.Errorf("loading kubeconfig: %w", err)
+	}
+	return config, nil
+}
+
+// KubeContexts returns the contexts of a kubeconfig, sorted by name.
+func KubeContexts(kubeconfig string) ([]KubeContext, error) {
+	config, err := LoadKubeconfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	var contexts []KubeContext
+	for name, context := range config.Contexts {
+		namespace := context.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+		contexts = append(contexts, KubeContext{
+			Name:      name,
+			Cluster:   context.Cluster,
+			User:      context.AuthInfo,
+			Namespace: namespace,
+			Current:   name == config.CurrentContext,
+		})
+	}
+	sort.Slice(contexts, func(i, j int) bool { return contexts[i].Name < contexts[j].Name })
+	return contexts, nil
+}
+
+// KubeconfigScope returns the current context of a kubeconfig and the
+// namespaces of its contexts. Calls run in namespace "default" of no context
+// if the kubeconfig cannot be loaded.
+func KubeconfigScope(kubeconfig string) KubectlScope {
+	scope := KubectlScope{Namespace: "default", Namespaces: map[string]string{}}
+	contexts, err := KubeContexts(kubeconfig)
+	if err != nil {
+		return scope
+	}
+	for _, context := range contexts {
+		scope.Namespaces[context.Name] = context.Namespace
+		if context.Current {
+			scope.Context = context.Name
+			scope.Namespace = context.Namespace
+		}
+	}
+	return scope
+}
+
+// KubeServer returns the API server of the current context of a kubeconfig,
+// or "" if the kubeconfig cannot be loaded or has no current context.
+func KubeServer(kubeconfig string) string {
+	config, err := LoadKubeconfig(kubeconfig)
+	if err != nil {
+		return ""
+	}
+	context, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return ""
+	}
+	cluster, ok := config.Clusters[context.Cluster]
+	if !ok {
+		return ""
+	}
+	return cluster.Server
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// WriteScopedKubeconfig writes a copy of a kubeconfig to dir, with its
+// current context set to kubeContext and the namespace of the current context
+// set to namespace, and returns its path. Empty values keep the ones of the
+// kubeconfig. The tools only receive the kubeconfig path, so that scoping
+// the kubeconfig scopes every kubectl call they make.
+func WriteScopedKubeconfig(kubeconfig, kubeContext, namespace, dir string) (string, error) {
+	// The kubeconfig may merge several files, it is written as one.
+	config, err := LoadKubeconfig(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	if kubeContext != "" {
+		if _, ok := config.Contexts[kubeContext]; !ok {
+			return "", fmt.Errorf("context %q not found in kubeconfig %q", kubeContext, kubeconfig)
+		}
+		config.CurrentContext = kubeContext
+	}
+	if namespace != "" {
+		current, ok := config.Contexts[config.CurrentContext]
+		if !ok {
+			return "", fmt.Errorf("kubeconfig %q has no current context to set the namespace of", kubeconfig)
+		}
+		scoped := *current
+		scoped.Namespace = namespace
+		config.Contexts[config.CurrentContext] = &scoped
+	}
+	// Inline the certificate files, whose paths may be relative to the original kubeconfig.
+	if err := clientcmdapi.FlattenConfig(config); err != nil {
+		return "", fmt.Errorf("flattening kubeconfig %q: %w", kubeconfig, err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	name := unsafeFileNameChars.ReplaceAllString(config.CurrentContext+"_"+namespace, "-")
+	path := filepath.Join(dir, name+".yaml")
+	// The kubeconfig holds credentials, only the user can read it.
+	if err := clientcmd.WriteToFile(*config, path); err != nil {
+		return "", fmt.Errorf("writing kubeconfig: %w", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// KubeExecPlugin returns the exec credential plugin of the user of a context
+// of a kubeconfig, the current context if empty, or nil if it has none.
+func KubeExecPlugin(kubeconfig, context string) (*clientcmdapi.ExecConfig, error) {
+	config, err := LoadKubeconfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	if context == "" {
+		context = config.CurrentContext
+	}
+	kubeContext, ok := config.Contexts[context]
+	if !ok {
+		return nil, fmt.Errorf("context %q not found in the kubeconfig", context)
+	}
+	user, ok := config.AuthInfos[kubeContext.AuthInfo]
+	if !ok {
+		return nil, nil
+	}
+	return user.Exec, nil
+}
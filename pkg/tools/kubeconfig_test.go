@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: gke-prod
+  cluster: {server: https://prod.example.com}
+- name: gke-staging
+  cluster: {server: https://staging.example.com}
+contexts:
+- name: staging
+  context: {cluster: gke-staging, user: dev}
+- name: prod
+  context: {cluster: gke-prod, user: admin, namespace: apps}
+users:
+- name: dev
+  user: {token: dev}
+- name: admin
+  user: {token: admin}
+`
+
+func TestKubeContexts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	contexts, err := KubeContexts(path)
+	if err != nil {
+		t.Fatalf("KubeContexts() error: %v", err)
+	}
+	want := []KubeContext{
+		{Name: "prod", Cluster: "gke-prod", User: "admin", Namespace: "apps"},
+		{Name: "staging", Cluster: "gke-staging", User: "dev", Namespace: "default", Current: true},
+	}
+	if !reflect.DeepEqual(contexts, want) {
+		t.Errorf("KubeContexts() = %+v, want %+v", contexts, want)
+	}
+
+	scope := KubeconfigScope(path)
+	if scope.Context != "staging" || scope.Namespace != "default" || scope.namespace("prod") != "apps" {
+		t.Errorf("KubeconfigScope() = %+v", scope)
+	}
+
+	if server := KubeServer(path); server != "https://staging.example.com" {
+		t.Errorf("KubeServer() = %q, want %q", server, "https://staging.example.com")
+	}
+}
+
+func TestKubeContextsMergedPaths(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "config")
+	if err := os.WriteFile(first, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The second file adds a context, and cannot override the current one.
+	second := filepath.Join(dir, "dev")
+	if err := os.WriteFile(second, []byte(`apiVersion: v1
+kind: Config
+current-context: kind
+contexts:
+- name: kind
+  context: {cluster: kind, user: kind}
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	contexts, err := KubeContexts(strings.Join([]string{first, missing, second}, string(filepath.ListSeparator)))
+	if err != nil {
+		t.Fatalf("KubeContexts() error: %v", err)
+	}
+	var names []string
+	var current string
+	for _, context := range contexts {
+		names = append(names, context.Name)
+		if context.Current {
+			current = context.Name
+		}
+	}
+	if want := []string{"kind", "prod", "staging"}; !reflect.DeepEqual(names, want) || current != "staging" {
+		t.Errorf("KubeContexts() = %v with current %q, want %v with current staging", names, current, want)
+	}
+}
+
+func TestListContextsTool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), KubeconfigKey, path)
+	result, err := NewListContextsTool().Run(ctx, map[string]any{})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	contexts := result.(*ListContextsResult).Contexts
+	if len(contexts) != 2 || contexts[0].Name != "prod" || !contexts[1].Current {
+		t.Errorf("Run() contexts = %+v, want prod and the current staging", contexts)
+	}
+}
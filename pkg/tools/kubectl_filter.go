@@ -17,49 +17,11 @@ package tools
 import (
 	"strings"
 
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
 	"k8s.io/klog/v2"
 	"mvdan.cc/sh/v3/syntax"
 )
 
-// Package-level constants for kubectl operations
-var (
-	readOnlyOps = map[string]bool{
-		"get": true, "describe": true, "explain": true, "top": true,
-		"logs": true, "api-resources": true, "api-versions": true,
-		"version": true, "config": true, "cluster-info": true,
-		"wait": true, "auth": true, "diff": true, "kustomize": true,
-		"help": true, "options": true, "proxy": true,
-		"completion": true, "convert": true, "events": true,
-		"port-forward": true, "can-i": true, "whoami": true,
-	}
-
-	writeOps = map[string]bool{
-		"create": true, "apply": true, "edit": true, "delete": true,
-		"patch": true, "replace": true, "scale": true, "autoscale": true,
-		"expose": true, "run": true, "exec": true, "set": true,
-		"label": true, "annotate": true, "taint": true, "drain": true,
-		"cordon": true, "uncordon": true, "debug": true, "attach": true,
-		"cp": true, "reconcile": true, "approve": true, "deny": true,
-		"certificate": true,
-	}
-
-	readOnlySubOps = map[string]map[string]bool{
-		"rollout": {
-			"history": true,
-			"status":  true,
-		},
-	}
-
-	writeSubOps = map[string]map[string]bool{
-		"rollout": {
-			"pause":   true,
-			"restart": true,
-			"resume":  true,
-			"undo":    true,
-		},
-	}
-)
-
 // KubectlModifiesResource analyzes a kubectl command to determine if it modifies resources
 func kubectlModifiesResource(command string) string {
 	parser := syntax.NewParser()
@@ -161,54 +123,8 @@ func analyzeCall(call *syntax.CallExpr) string {
 
 	klog.V(2).Infof("analyzeCall: found kubectl: %q", firstArg)
 
-	// Check for boolean or spaced key-value flags before the verb
-	for _, arg := range args[1:] {
-		if !strings.HasPrefix(arg, "-") {
-			break
-		}
-		// If flag does not contain '=', it's boolean or spaced key-value
-		if !strings.Contains(arg, "=") {
-			klog.Warningf("analyzeCall: boolean or spaced key-value flag before verb: %q", arg)
-			return "unknown"
-		}
-	}
-
-	// Parse kubectl arguments to extract verb, subverb, and flags
-	verb, subVerb, hasDryRun := parseKubectlArgs(args[1:])
-	if verb == "" {
-		klog.Warningf("analyzeCall: no verb found after kubectl in args: %v", args)
-		return "unknown"
-	}
-
-	// Check standard operations - write operations first (prioritize immediate detection)
-	if (writeOps[verb] || writeSubOps[verb][subVerb]) && !hasDryRun {
-		klog.V(1).Infof("analyzeCall: write op for verb=%q subVerb=%q", verb, subVerb)
-		return "yes"
-	}
-
-	// Check read-only operations or dry-run write operations
-	if (readOnlyOps[verb] || readOnlySubOps[verb][subVerb]) || ((writeOps[verb] || writeSubOps[verb][subVerb]) && hasDryRun) {
-		klog.V(1).Infof("analyzeCall: read op for verb=%q subVerb=%q (dry-run=%v)", verb, subVerb, hasDryRun)
-		return "no"
-	}
-
-	klog.V(1).Infof("analyzeCall: unknown op for verb=%q subVerb=%q", verb, subVerb)
-	return "unknown"
-}
-
-// parseKubectlArgs extracts verb, subverb, and dry-run flag from kubectl arguments
-func parseKubectlArgs(args []string) (verb, subVerb string, hasDryRun bool) {
-	for _, arg := range args {
-		if strings.HasPrefix(arg, "--dry-run") {
-			hasDryRun = true
-		}
-		if !strings.HasPrefix(arg, "-") {
-			if verb == "" {
-				verb = arg
-			} else if subVerb == "" {
-				subVerb = arg
-			}
-		}
-	}
-	return verb, subVerb, hasDryRun
+	cmd := kubectl.ParseArgs(args[1:])
+	result := cmd.ModifiesResource()
+	klog.V(1).Infof("analyzeCall: %s for verb=%q subcommand=%q (dry-run=%v)", result, cmd.Verb, cmd.Subcommand, cmd.DryRun())
+	return result
 }
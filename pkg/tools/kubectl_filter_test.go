@@ -19,6 +19,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
 	"mvdan.cc/sh/v3/syntax"
 )
 
@@ -157,33 +158,34 @@ func TestKubectlModifiesResource(t *testing.T) {
 
 // TestKubectlAnalyzerComponents tests the internal helper functions used by KubectlModifiesResource
 func TestKubectlAnalyzerComponents(t *testing.T) {
-	t.Run("parseKubectlArgs detection", func(t *testing.T) {
+	t.Run("kubectl argument parsing", func(t *testing.T) {
 		tests := []struct {
-			command           string
-			verbExpected      string
-			subverbExpected   string
-			hasDryRunExpected bool
+			command            string
+			verbExpected       string
+			subcommandExpected string
+			hasDryRunExpected  bool
 		}{
-			{"kubectl apply -f deploy.yaml --dry-run=client", "apply", "deploy.yaml", true},
-			{"kubectl apply -f deploy.yaml --dry-run", "apply", "deploy.yaml", true},
-			{"kubectl delete pod nginx --dry-run client", "delete", "pod", true},
-			{"kubectl delete pod nginx --dry-run=server", "delete", "pod", true},
-			{"kubectl apply -f deploy.yaml", "apply", "deploy.yaml", false},
-			{"kubectl get pods --dry", "get", "pods", false}, // Not a valid dry-run flag
-			{"echo --dry-run", "", "", true},                 // The current implementation doesn't check if it's kubectl
+			{"kubectl apply -f deploy.yaml --dry-run=client", "apply", "", true},
+			{"kubectl apply -f deploy.yaml --dry-run", "apply", "", true},
+			{"kubectl delete pod nginx --dry-run client", "delete", "", true},
+			{"kubectl delete pod nginx --dry-run=server", "delete", "", true},
+			{"kubectl delete pod nginx --dry-run=none", "delete", "", false},
+			{"kubectl apply -f deploy.yaml", "apply", "", false},
+			{"kubectl get pods --dry", "get", "", false}, // Not a valid dry-run flag
+			{"echo --dry-run", "", "", true},             // The arguments are parsed without checking the binary
 			{"kubectl rollout status deployment nginx", "rollout", "status", false},
 		}
 
 		for _, tt := range tests {
-			verb, subVerb, hasDryRun := parseKubectlArgs(strings.Split(tt.command, " ")[1:]) // Skip the first arg (kubectl)
-			if verb != tt.verbExpected {
-				t.Errorf("parseKubectlArgs(%q) verb = %q, want %q", tt.command, verb, tt.verbExpected)
+			cmd := kubectl.ParseArgs(strings.Split(tt.command, " ")[1:]) // Skip the first arg (kubectl)
+			if cmd.Verb != tt.verbExpected {
+				t.Errorf("ParseArgs(%q) verb = %q, want %q", tt.command, cmd.Verb, tt.verbExpected)
 			}
-			if subVerb != tt.subverbExpected {
-				t.Errorf("parseKubectlArgs(%q) subVerb = %q, want %q", tt.command, subVerb, tt.subverbExpected)
+			if cmd.Subcommand != tt.subcommandExpected {
+				t.Errorf("ParseArgs(%q) subcommand = %q, want %q", tt.command, cmd.Subcommand, tt.subcommandExpected)
 			}
-			if hasDryRun != tt.hasDryRunExpected {
-				t.Errorf("parseKubectlArgs(%q) hasDryRun = %v, want %v", tt.command, hasDryRun, tt.hasDryRunExpected)
+			if cmd.DryRun() != tt.hasDryRunExpected {
+				t.Errorf("ParseArgs(%q) DryRun() = %v, want %v", tt.command, cmd.DryRun(), tt.hasDryRunExpected)
 			}
 		}
 	})
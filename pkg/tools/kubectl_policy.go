@@ -0,0 +1,259 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
+	"sigs.k8s.io/yaml"
+)
+
+// KubectlPolicyAction is the action of a kubectl policy rule.
+type KubectlPolicyAction string
+
+const (
+	// KubectlPolicyAllow runs the command, after the usual approval rules.
+	KubectlPolicyAllow KubectlPolicyAction = "allow"
+	// KubectlPolicyConfirm asks the user to approve the command, even if it is read-only.
+	KubectlPolicyConfirm KubectlPolicyAction = "confirm"
+	// KubectlPolicyDeny refuses to run the command.
+	KubectlPolicyDeny KubectlPolicyAction = "deny"
+)
+
+// strictness orders the actions, the strictest action of the kubectl calls of a command applies.
+var strictness = map[KubectlPolicyAction]int{
+	KubectlPolicyAllow:   0,
+	KubectlPolicyConfirm: 1,
+	KubectlPolicyDeny:    2,
+}
+
+// KubectlPolicy is an RBAC-style policy of the kubectl commands the agent may
+// run. The first rule matching the verb, the resource, the namespace and the
+// context of a kubectl call decides its action.
+type KubectlPolicy struct {
+	Rules []KubectlPolicyRule `json:"rules"`
+	// Default is the action of the calls no rule matches, allow if empty.
+	Default KubectlPolicyAction `json:"default,omitempty"`
+}
+
+// KubectlPolicyRule matches kubectl calls. Empty lists match everything, and
+// entries may use shell patterns, e.g. "prod-*".
+type KubectlPolicyRule struct {
+	// Verbs are kubectl verbs, e.g. "delete", or verbs with their subcommand, e.g. "rollout restart".
+	Verbs []string `json:"verbs,omitempty"`
+	// Resources are plural resource names, e.g. "deployments".
+	Resources []string `json:"resources,omitempty"`
+	// Namespaces are the namespaces the calls run in.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Contexts are the kubeconfig contexts the calls run in, e.g. "prod".
+	Contexts []string            `json:"contexts,omitempty"`
+	Action   KubectlPolicyAction `json:"action"`
+	// Reason is reported when the rule denies a call or asks for confirmation.
+	Reason string `json:"reason,omitempty"`
+}
+
+// KubectlRequest is what a kubectl call does, as seen by the policy.
+type KubectlRequest struct {
+	Verb     string `json:"verb"`
+	Resource string `json:"resource,omitempty"`
+	// Namespace is "*" for calls across all namespaces.
+	Namespace string `json:"namespace,omitempty"`
+	// Context is the kubeconfig context the call runs in.
+	Context string `json:"context,omitempty"`
+}
+
+func (r KubectlRequest) String() string {
+	s := r.Verb
+	if r.Resource != "" {
+		s += " " + r.Resource
+	}
+	if r.Namespace != "" {
+		s += " in namespace " + r.Namespace
+	}
+	if r.Context != "" {
+		s += " of context " + r.Context
+	}
+	return s
+}
+
+// KubectlPolicyDecision is the decision of the policy on a command.
+type KubectlPolicyDecision struct {
+	Action KubectlPolicyAction `json:"action"`
+	// Request is the kubectl call the decision was made on, if any.
+	Request *KubectlRequest `json:"request,omitempty"`
+	// Rule is the index of the rule that matched, -1 for the default action.
+	// Rules are numbered from 1 in messages.
+	Rule   int    `json:"rule"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Explain describes the decision, e.g. "deny: delete namespaces in namespace
+// prod (rule 1: namespaces are managed by the platform team)".
+func (d KubectlPolicyDecision) Explain() string {
+	s := string(d.Action)
+	if d.Request != nil {
+		s += ": " + d.Request.String()
+	}
+	rule := "default action"
+	if d.Rule >= 0 {
+		rule = fmt.Sprintf("rule %d", d.Rule+1)
+	}
+	if d.Reason != "" {
+		return fmt.Sprintf("%s (%s: %s)", s, rule, d.Reason)
+	}
+	return fmt.Sprintf("%s (%s)", s, rule)
+}
+
+// LoadKubectlPolicy reads a kubectl policy from a YAML file.
+func LoadKubectlPolicy(filename string) (*KubectlPolicy, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading kubectl policy: %w", err)
+	}
+	policy := &KubectlPolicy{}
+	if err := yaml.UnmarshalStrict(b, policy); err != nil {
+		return nil, fmt.Errorf("parsing kubectl policy %s: %w", filename, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid kubectl policy %s: %w", filename, err)
+	}
+	return policy, nil
+}
+
+func (p *KubectlPolicy) validate() error {
+	if _, ok := strictness[p.Default]; !ok && p.Default != "" {
+		return fmt.Errorf("unknown default action %q, expected allow, confirm or deny", p.Default)
+	}
+	for i, rule := range p.Rules {
+		if _, ok := strictness[rule.Action]; !ok {
+			return fmt.Errorf("rule %d: unknown action %q, expected allow, confirm or deny", i, rule.Action)
+		}
+		for _, pattern := range slices.Concat(rule.Verbs, rule.Resources, rule.Namespaces, rule.Contexts) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule %d: invalid pattern %q: %w", i, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate returns the decision of the policy on a shell command, the
+// strictest decision on its kubectl calls. Calls without a context or a
+// namespace flag run in those of scope. Commands that cannot be parsed need a
+// confirmation.
+func (p *KubectlPolicy) Evaluate(command string, scope KubectlScope) KubectlPolicyDecision {
+	requests, err := ParseKubectlRequests(command, scope)
+	if err != nil {
+		return KubectlPolicyDecision{Action: KubectlPolicyConfirm, Rule: -1, Reason: err.Error()}
+	}
+
+	decision := KubectlPolicyDecision{Action: KubectlPolicyAllow, Rule: -1}
+	for i := range requests {
+		d := p.EvaluateRequest(requests[i])
+		if decision.Request == nil || strictness[d.Action] > strictness[decision.Action] {
+			decision = d
+		}
+	}
+	return decision
+}
+
+// denial returns why the policy denies command in the context of kubeconfig,
+// empty if it does not or if there is no policy.
+func (p *KubectlPolicy) denial(command, kubeconfig string) string {
+	if p == nil {
+		return ""
+	}
+	if decision := p.Evaluate(command, KubeconfigScope(kubeconfig)); decision.Action == KubectlPolicyDeny {
+		return "denied by the kubectl policy: " + decision.Explain()
+	}
+	return ""
+}
+
+// EvaluateRequest returns the decision of the policy on a kubectl call.
+func (p *KubectlPolicy) EvaluateRequest(request KubectlRequest) KubectlPolicyDecision {
+	for i, rule := range p.Rules {
+		if rule.matches(&request) {
+			return KubectlPolicyDecision{Action: rule.Action, Request: &request, Rule: i, Reason: rule.Reason}
+		}
+	}
+	action := p.Default
+	if action == "" {
+		action = KubectlPolicyAllow
+	}
+	return KubectlPolicyDecision{Action: action, Request: &request, Rule: -1}
+}
+
+func (r *KubectlPolicyRule) matches(request *KubectlRequest) bool {
+	verbMatches := matchesAny(r.Verbs, request.Verb)
+	if !verbMatches {
+		// "rollout" matches "rollout restart".
+		if verb, _, ok := strings.Cut(request.Verb, " "); ok {
+			verbMatches = matchesAny(r.Verbs, verb)
+		}
+	}
+	return verbMatches && matchesAny(r.Resources, request.Resource) && matchesAny(r.Namespaces, request.Namespace) && matchesAny(r.Contexts, request.Context)
+}
+
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseKubectlRequests returns the requests of the kubectl calls of a shell
+// command, one per resource type, e.g. two for "kubectl delete pods,secrets".
+func ParseKubectlRequests(command string, scope KubectlScope) ([]KubectlRequest, error) {
+	commands, err := kubectl.Parse(command)
+	if err != nil {
+		return nil, err
+	}
+	var requests []KubectlRequest
+	for _, cmd := range commands {
+		request := KubectlRequest{Verb: cmd.Verb, Namespace: cmd.Namespace, Context: cmd.Context}
+		if cmd.Subcommand != "" {
+			request.Verb += " " + cmd.Subcommand
+		}
+		if request.Context == "" {
+			request.Context = scope.Context
+		}
+		switch {
+		case cmd.AllNamespaces:
+			request.Namespace = "*"
+		case request.Namespace == "":
+			request.Namespace = scope.namespace(request.Context)
+		}
+		if len(cmd.Resources) == 0 {
+			// e.g. "kubectl apply -f app.yaml", the resources are not known.
+			requests = append(requests, request)
+			continue
+		}
+		for _, resource := range cmd.Resources {
+			request.Resource = resource
+			requests = append(requests, request)
+		}
+	}
+	return requests, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testKubectlPolicy = `
+rules:
+- verbs: [delete]
+  resources: [namespaces]
+  action: deny
+  reason: namespaces are managed by the platform team
+- verbs: [get, describe, logs]
+  action: allow
+- namespaces: [prod, prod-*]
+  action: confirm
+- verbs: [rollout]
+  action: confirm
+- contexts: [prod]
+  action: confirm
+`
+
+// testKubectlScope runs the calls without flags in namespace default of
+// context staging, the calls in context prod in its namespace apps.
+var testKubectlScope = KubectlScope{Context: "staging", Namespace: "default", Namespaces: map[string]string{"staging": "default", "prod": "apps"}}
+
+func TestKubectlPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(testKubectlPolicy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadKubectlPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadKubectlPolicy() error: %v", err)
+	}
+
+	tests := []struct {
+		command string
+		want    KubectlPolicyAction
+		rule    int
+	}{
+		{"kubectl delete ns staging", KubectlPolicyDeny, 0},
+		{"kubectl get pods -n prod", KubectlPolicyAllow, 1},
+		{"kubectl logs deploy/web -nprod", KubectlPolicyAllow, 1},
+		{"kubectl scale deploy/web --replicas=3 -n prod-eu", KubectlPolicyConfirm, 2},
+		{"kubectl scale deploy/web --replicas 3", KubectlPolicyAllow, -1},
+		{"kubectl rollout restart deployment/web", KubectlPolicyConfirm, 3},
+		{"kubectl --namespace=prod apply -f app.yaml", KubectlPolicyConfirm, 2},
+		{"kubectl delete pods,namespaces web", KubectlPolicyDeny, 0},
+		{"kubectl get pods | grep web && kubectl delete namespace web", KubectlPolicyDeny, 0},
+		{"echo no kubectl here", KubectlPolicyAllow, -1},
+		{"kubectl --context prod scale deploy/web --replicas 3", KubectlPolicyConfirm, 4},
+		{"kubectl get pods --context=prod", KubectlPolicyAllow, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got := policy.Evaluate(tt.command, testKubectlScope)
+			if got.Action != tt.want || got.Rule != tt.rule {
+				t.Errorf("Evaluate() = %s, want %s by rule %d", got.Explain(), tt.want, tt.rule)
+			}
+		})
+	}
+}
+
+func TestParseKubectlRequestsContext(t *testing.T) {
+	tests := []struct {
+		command string
+		want    KubectlRequest
+	}{
+		{"kubectl delete pod web", KubectlRequest{Verb: "delete", Resource: "pods", Namespace: "default", Context: "staging"}},
+		{"kubectl delete pod web --context prod", KubectlRequest{Verb: "delete", Resource: "pods", Namespace: "apps", Context: "prod"}},
+		{"kubectl delete pod web --context prod -n web", KubectlRequest{Verb: "delete", Resource: "pods", Namespace: "web", Context: "prod"}},
+		{"kubectl delete pod web --context dev", KubectlRequest{Verb: "delete", Resource: "pods", Namespace: "default", Context: "dev"}},
+	}
+	for _, tt := range tests {
+		requests, err := ParseKubectlRequests(tt.command, testKubectlScope)
+		if err != nil {
+			t.Fatalf("ParseKubectlRequests(%q) error: %v", tt.command, err)
+		}
+		if len(requests) != 1 || requests[0] != tt.want {
+			t.Errorf("ParseKubectlRequests(%q) = %+v, want %+v", tt.command, requests, tt.want)
+		}
+	}
+}
+
+func TestLoadKubectlPolicyErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown action":  "rules:\n- verbs: [delete]\n  action: maybe\n",
+		"unknown field":   "rules:\n- verb: [delete]\n  action: deny\n",
+		"invalid pattern": "rules:\n- namespaces: ['prod-[']\n  action: deny\n",
+		"unknown default": "default: ask\n",
+	}
+	for name, policy := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(policy), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadKubectlPolicy(path); err == nil {
+				t.Errorf("LoadKubectlPolicy() succeeded, want an error")
+			}
+		})
+	}
+}
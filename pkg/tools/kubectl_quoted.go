@@ -0,0 +1,404 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// The kubectl tools the agent can use.
+const (
+	// KubectlToolQuoted takes the verb, the resource, the namespace and
+	// the flags of a single kubectl call as schema fields, rendered as a
+	// quoted command line.
+	KubectlToolQuoted = "quoted"
+	// KubectlToolCommand takes a kubectl command line, run by the shell.
+	KubectlToolCommand = "command"
+)
+
+// CommandRenderer is implemented by the tools whose arguments are not a
+// command line, to render the command line they run. The agent classifies,
+// approves, audits and dry runs the rendered command like the other commands.
+type CommandRenderer interface {
+	// RenderCommand returns the command line a call runs, or "" if the
+	// arguments already hold a command line.
+	RenderCommand(args map[string]any) (string, error)
+}
+
+var (
+	// kubectlVerb matches the verbs and subcommands of kubectl, e.g. "get" or "can-i".
+	kubectlVerb = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	// plainWord matches the words that need no quoting.
+	plainWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+)
+
+// QuotedKubectl runs a single kubectl call from structured arguments:
+// the verb, the resource, the namespace and the flags are schema fields, and
+// every argument is quoted, so that nothing the model writes is interpreted by
+// the shell. The call is still a kubectl command line run by the executor, so
+// that it runs in the sandbox like the other commands. The kubectl policy
+// applies to the verb of the call.
+type QuotedKubectl struct {
+	*Kubectl
+}
+
+func NewQuotedKubectlTool(executor sandbox.Executor, policy *KubectlPolicy) *QuotedKubectl {
+	return &QuotedKubectl{Kubectl: NewKubectlTool(executor, policy)}
+}
+
+func (t *QuotedKubectl) Description() string {
+	return `Runs a single kubectl call against the user's Kubernetes cluster, given as structured fields. Use this tool only when you need to query or modify the state of the user's Kubernetes cluster.
+Each field is passed to kubectl as is: do not quote or escape values, and do not use pipes, redirections or shell syntax; filter the output with flags such as -o jsonpath or --selector instead.
+
+IMPORTANT: Interactive commands are not supported. Use 'get -o yaml' and 'patch' or 'apply' instead of 'edit', 'exec' with command_args instead of 'exec -it', and NodePort or LoadBalancer services instead of 'port-forward'.`
+}
+
+func (t *QuotedKubectl) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"verb": {
+					Type:        gollm.TypeString,
+					Description: `The kubectl verb, e.g. "get", "describe", "logs", "apply", "scale", "rollout" or "exec".`,
+				},
+				"subcommand": {
+					Type:        gollm.TypeString,
+					Description: `The subcommand of the verbs taking one, e.g. "restart" for "rollout restart" or "can-i" for "auth can-i".`,
+				},
+				"resource": {
+					Type:        gollm.TypeString,
+					Description: `The resource type or object, e.g. "pods", "deployment/web" or "pods,services". For "logs" and "exec", the pod, e.g. "web-5d8f7" or "deploy/web".`,
+				},
+				"name": {
+					Type:        gollm.TypeString,
+					Description: `The name of the object, when resource is a type, e.g. "web" with resource "deployment".`,
+				},
+				"context": {
+					Type:        gollm.TypeString,
+					Description: `The kubeconfig context to run the call in, to target a specific cluster, e.g. "staging" or "prod"; the current context if empty. List the contexts with verb "config" and subcommand "get-contexts".`,
+				},
+				"namespace": {
+					Type:        gollm.TypeString,
+					Description: `The namespace, the namespace of the kubeconfig context if empty.`,
+				},
+				"all_namespaces": {
+					Type:        gollm.TypeBoolean,
+					Description: `Whether to list the objects of all namespaces (-A).`,
+				},
+				"flags": {
+					Type:        gollm.TypeArray,
+					Items:       &gollm.Schema{Type: gollm.TypeString},
+					Description: `The other flags, one flag or value per item, e.g. ["-o", "jsonpath={.items[*].metadata.name}", "--selector=app=web", "--replicas=3"].`,
+				},
+				"command_args": {
+					Type:        gollm.TypeArray,
+					Items:       &gollm.Schema{Type: gollm.TypeString},
+					Description: `The command run by "exec", passed after "--", e.g. ["cat", "/etc/resolv.conf"].`,
+				},
+				"manifest": {
+					Type:        gollm.TypeString,
+					Description: `A YAML manifest passed on stdin, e.g. for "apply" or "create"; "-f -" is added unless a -f flag is given.`,
+				},
+				"modifies_resource": {
+					Type: gollm.TypeString,
+					Description: `Whether the command modifies a kubernetes resource.
+Possible values:
+- "yes" if the command modifies a resource
+- "no" if the command does not modify a resource
+- "unknown" if the command's effect on the resource is unknown`},
+			},
+			Required: []string{"verb"},
+		},
+	}
+}
+
+// RenderCommand renders the structured arguments as a kubectl command line,
+// whose arguments are quoted.
+func (t *QuotedKubectl) RenderCommand(args map[string]any) (string, error) {
+	if _, ok := args["verb"]; !ok {
+		return "", nil
+	}
+	verb, _ := args["verb"].(string)
+	if !kubectlVerb.MatchString(verb) {
+		return "", fmt.Errorf("invalid verb %q, expected a kubectl verb such as get or describe", verb)
+	}
+	argv := []string{verb}
+	if subcommand, _ := args["subcommand"].(string); subcommand != "" {
+		if !kubectlVerb.MatchString(subcommand) {
+			return "", fmt.Errorf("invalid subcommand %q", subcommand)
+		}
+		argv = append(argv, subcommand)
+	}
+	for _, key := range []string{"resource", "name"} {
+		if value, _ := args[key].(string); value != "" {
+			argv = append(argv, value)
+		}
+	}
+	if kubeContext, _ := args["context"].(string); kubeContext != "" {
+		argv = append(argv, "--context", kubeContext)
+	}
+	if namespace, _ := args["namespace"].(string); namespace != "" {
+		argv = append(argv, "-n", namespace)
+	}
+	if allNamespaces, _ := args["all_namespaces"].(bool); allNamespaces {
+		argv = append(argv, "-A")
+	}
+	flags, err := stringsArg(args, "flags")
+	if err != nil {
+		return "", err
+	}
+	argv = append(argv, flags...)
+
+	manifest, _ := args["manifest"].(string)
+	if manifest != "" && !kubectl.ParseArgs(argv).HasFlag("-f", "--filename") {
+		argv = append(argv, "-f", "-")
+	}
+	commandArgs, err := stringsArg(args, "command_args")
+	if err != nil {
+		return "", err
+	}
+	if len(commandArgs) > 0 {
+		argv = append(append(argv, "--"), commandArgs...)
+	}
+	return renderKubectlCommand("kubectl", argv, manifest), nil
+}
+
+// stringsArg returns an argument holding a list of strings.
+func stringsArg(args map[string]any, key string) ([]string, error) {
+	var values []string
+	switch v := args[key].(type) {
+	case nil:
+	case []string:
+		values = v
+	case []any:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of strings, got %v", key, item)
+			}
+			values = append(values, s)
+		}
+	default:
+		return nil, fmt.Errorf("%s must be a list of strings", key)
+	}
+	return values, nil
+}
+
+// renderKubectlCommand renders a kubectl call, or the call of another program
+// such as helm, with its stdin as a quoted heredoc.
+func renderKubectlCommand(binary string, argv []string, stdin string) string {
+	words := []string{quoteWord(binary)}
+	for _, arg := range argv {
+		words = append(words, quoteWord(arg))
+	}
+	return withHeredoc(strings.Join(words, " "), stdin)
+}
+
+// withHeredoc passes stdin to a shell command as a quoted heredoc, with a
+// delimiter that does not occur as a line of stdin.
+func withHeredoc(command string, stdin string) string {
+	if stdin == "" {
+		return command
+	}
+	delimiter := "EOF"
+	for n := 1; strings.Contains("\n"+stdin+"\n", "\n"+delimiter+"\n"); n++ {
+		delimiter = fmt.Sprintf("EOF%d", n)
+	}
+	if !strings.HasSuffix(stdin, "\n") {
+		stdin += "\n"
+	}
+	return fmt.Sprintf("%s <<'%s'\n%s%s", command, delimiter, stdin, delimiter)
+}
+
+// quoteWord quotes a word for the shell, unless it needs no quoting.
+func quoteWord(s string) string {
+	if plainWord.MatchString(s) {
+		return s
+	}
+	return ShellQuote(s)
+}
+
+// SafeKubectlCommand checks that a command line is a single kubectl call
+// whose arguments are literal words, and renders it with every argument
+// quoted, so that the shell runs exactly this call. Pipes, command lists,
+// substitutions, variables and redirections are refused, except a quoted
+// heredoc passed on stdin.
+func SafeKubectlCommand(command string) (string, error) {
+	words, stdin, err := literalCall(command, "kubectl")
+	if err != nil {
+		return "", err
+	}
+	if !kubectl.IsKubectl(words[0]) {
+		return "", fmt.Errorf("expected a kubectl call, got %q", words[0])
+	}
+	return renderKubectlCommand(words[0], words[1:], stdin), nil
+}
+
+// literalCall returns the words and the stdin of a command line that is a
+// single call of a program, named in the errors, whose arguments are literal
+// words, and refuses the other command lines, see SafeKubectlCommand.
+func literalCall(command, program string) ([]string, string, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil, "", fmt.Errorf("parsing command: %w", err)
+	}
+	if len(file.Stmts) != 1 {
+		return nil, "", fmt.Errorf("expected a single %s call, got %d commands", program, len(file.Stmts))
+	}
+	stmt := file.Stmts[0]
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || stmt.Negated || stmt.Background || stmt.Coprocess || len(call.Assigns) > 0 || len(call.Args) == 0 {
+		return nil, "", fmt.Errorf("expected a single %s call, without pipes, command lists or variable assignments", program)
+	}
+
+	var stdin string
+	for _, redirect := range stmt.Redirs {
+		if (redirect.Op != syntax.Hdoc && redirect.Op != syntax.DashHdoc) || redirect.N != nil || !quotedWord(redirect.Word) {
+			return nil, "", fmt.Errorf("redirections are not allowed, except a heredoc with a quoted delimiter passed on stdin")
+		}
+		if redirect.Hdoc != nil {
+			stdin = redirect.Hdoc.Lit()
+		}
+	}
+
+	words := make([]string, len(call.Args))
+	for i, word := range call.Args {
+		if words[i], err = literalWord(word); err != nil {
+			return nil, "", err
+		}
+	}
+	return words, stdin, nil
+}
+
+// quotedWord returns true if a heredoc delimiter is quoted, so that its body is literal.
+func quotedWord(word *syntax.Word) bool {
+	if word == nil || len(word.Parts) == 0 {
+		return false
+	}
+	switch part := word.Parts[0].(type) {
+	case *syntax.SglQuoted:
+		return !part.Dollar
+	case *syntax.DblQuoted:
+		return !part.Dollar
+	}
+	return false
+}
+
+// literalWord returns the value of a shell word made of literal text and
+// quotes, and refuses the words with expansions.
+func literalWord(word *syntax.Word) (string, error) {
+	var b strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			b.WriteString(unescape(part.Value, func(byte) bool { return true }))
+		case *syntax.SglQuoted:
+			if part.Dollar {
+				return "", fmt.Errorf("$'...' quoting is not allowed")
+			}
+			b.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			if part.Dollar {
+				return "", fmt.Errorf("$\"...\" quoting is not allowed")
+			}
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", fmt.Errorf("variables and command substitutions are not allowed")
+				}
+				b.WriteString(unescape(lit.Value, func(c byte) bool { return strings.IndexByte("$`\"\\\n", c) >= 0 }))
+			}
+		default:
+			return "", fmt.Errorf("variables, substitutions and globs are not allowed")
+		}
+	}
+	return b.String(), nil
+}
+
+// unescape removes the backslashes escaping the characters for which escaped
+// returns true, and the escaped newlines.
+func unescape(s string, escaped func(byte) bool) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && escaped(s[i+1]) {
+			i++
+			if s[i] == '\n' {
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Run runs the kubectl call of the structured arguments, or the command line
+// replacing them, e.g. edited by the user or rewritten as a dry run, once it
+// is checked to be a single kubectl call.
+func (t *QuotedKubectl) Run(ctx context.Context, args map[string]any) (any, error) {
+	command, err := t.RenderCommand(args)
+	if err != nil {
+		return &sandbox.ExecResult{Error: err.Error()}, nil
+	}
+	if command == "" {
+		command, _ = args["command"].(string)
+		if command == "" {
+			return &sandbox.ExecResult{Error: "verb must be provided"}, nil
+		}
+	}
+	safe, err := SafeKubectlCommand(command)
+	if err != nil {
+		return &sandbox.ExecResult{Command: command, Error: err.Error()}, nil
+	}
+	args = maps.Clone(args)
+	args["command"] = safe
+	return t.Kubectl.Run(ctx, args)
+}
+
+// renderedArguments returns the arguments of a call of a tool rendering its
+// command: the fields of its schema are replaced by the command, so that the
+// command is what is approved, edited and run. The claim of the model on the
+// effect of the command is kept. Arguments that cannot be rendered are
+// returned as is, the tool reports the error when it runs.
+func renderedArguments(tool Tool, renderer CommandRenderer, args map[string]any) map[string]any {
+	command, err := renderer.RenderCommand(args)
+	if err != nil || command == "" {
+		return args
+	}
+	rendered := maps.Clone(args)
+	if params := tool.FunctionDefinition().Parameters; params != nil {
+		for field := range params.Properties {
+			if field != "modifies_resource" {
+				delete(rendered, field)
+			}
+		}
+	}
+	rendered["command"] = command
+	return rendered
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestQuotedKubectlRenderCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "get",
+			args: map[string]any{"verb": "get", "resource": "pods", "namespace": "prod", "flags": []any{"-o", "jsonpath={.items[*].metadata.name}"}},
+			want: "kubectl get pods -n prod -o 'jsonpath={.items[*].metadata.name}'",
+		},
+		{
+			name: "context",
+			args: map[string]any{"verb": "get", "resource": "nodes", "context": "prod", "namespace": "kube-system"},
+			want: "kubectl get nodes --context prod -n kube-system",
+		},
+		{
+			name: "all namespaces",
+			args: map[string]any{"verb": "get", "resource": "pods", "all_namespaces": true},
+			want: "kubectl get pods -A",
+		},
+		{
+			name: "subcommand",
+			args: map[string]any{"verb": "rollout", "subcommand": "restart", "resource": "deployment", "name": "web"},
+			want: "kubectl rollout restart deployment web",
+		},
+		{
+			name: "exec",
+			args: map[string]any{"verb": "exec", "resource": "web-0", "command_args": []any{"sh", "-c", "cat /etc/resolv.conf; id"}},
+			want: "kubectl exec web-0 -- sh -c 'cat /etc/resolv.conf; id'",
+		},
+		{
+			name: "manifest",
+			args: map[string]any{"verb": "apply", "manifest": "apiVersion: v1\nkind: Namespace\n"},
+			want: "kubectl apply -f - <<'EOF'\napiVersion: v1\nkind: Namespace\nEOF",
+		},
+		{
+			name: "quotes",
+			args: map[string]any{"verb": "patch", "resource": "deploy/web", "flags": []any{"-p", `{"metadata":{"annotations":{"note":"it's"}}}`}},
+			want: `kubectl patch deploy/web -p '{"metadata":{"annotations":{"note":"it'\''s"}}}'`,
+		},
+		{
+			name: "shell syntax is quoted",
+			args: map[string]any{"verb": "get", "resource": "pods; rm -rf /", "name": "$(whoami)"},
+			want: "kubectl get 'pods; rm -rf /' '$(whoami)'",
+		},
+		{
+			name: "command line",
+			args: map[string]any{"command": "kubectl get pods"},
+			want: "",
+		},
+		{
+			name:    "invalid verb",
+			args:    map[string]any{"verb": "get pods"},
+			wantErr: true,
+		},
+		{
+			name:    "flags not a list",
+			args:    map[string]any{"verb": "get", "flags": "-o yaml"},
+			wantErr: true,
+		},
+	}
+	tool := NewQuotedKubectlTool(nil, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.RenderCommand(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenderCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeKubectlCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+		wantErr bool
+	}{
+		{command: "kubectl get pods -n prod", want: "kubectl get pods -n prod"},
+		{command: `kubectl scale deploy/web --replicas=3 --dry-run=server -o yaml`, want: "kubectl scale deploy/web --replicas=3 --dry-run=server -o yaml"},
+		{command: `kubectl patch deploy web -p "{\"spec\":{\"replicas\":3}}"`, want: `kubectl patch deploy web -p '{"spec":{"replicas":3}}'`},
+		{command: `kubectl get pods -o jsonpath='{.items[*].metadata.name}'`, want: `kubectl get pods -o 'jsonpath={.items[*].metadata.name}'`},
+		{command: "kubectl apply -f - <<'EOF'\nkind: Namespace\nEOF", want: "kubectl apply -f - <<'EOF'\nkind: Namespace\nEOF"},
+		{command: "kubectl get pods | grep web", wantErr: true},
+		{command: "kubectl get pods; rm -rf /", wantErr: true},
+		{command: "kubectl get pods && kubectl delete pod web", wantErr: true},
+		{command: "kubectl get pods -l app=$(whoami)", wantErr: true},
+		{command: "kubectl get pods -n $NAMESPACE", wantErr: true},
+		{command: "kubectl get pods > pods.txt", wantErr: true},
+		{command: "KUBECONFIG=/tmp/admin kubectl get pods", wantErr: true},
+		{command: "kubectl apply -f - <<EOF\nname: $USER\nEOF", wantErr: true},
+		{command: "rm -rf /", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SafeKubectlCommand(tt.command)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SafeKubectlCommand(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SafeKubectlCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestParseToolInvocationRendersCommand(t *testing.T) {
+	var tools Tools
+	tools.Init()
+	tools.RegisterTool(NewQuotedKubectlTool(nil, nil))
+
+	args := map[string]any{"verb": "delete", "resource": "pod", "name": "web-0", "namespace": "prod", "modifies_resource": "yes"}
+	call, err := tools.ParseToolInvocation(context.Background(), "kubectl", args)
+	if err != nil {
+		t.Fatalf("ParseToolInvocation: %v", err)
+	}
+	want := map[string]any{"command": "kubectl delete pod web-0 -n prod", "modifies_resource": "yes"}
+	if !reflect.DeepEqual(call.Arguments(), want) {
+		t.Errorf("Arguments() = %v, want %v", call.Arguments(), want)
+	}
+	if call.Description() != "kubectl delete pod web-0 -n prod" {
+		t.Errorf("Description() = %q", call.Description())
+	}
+	if got := call.GetTool().CheckModifiesResource(call.Arguments()); got != "yes" {
+		t.Errorf("CheckModifiesResource() = %q, want yes", got)
+	}
+	if _, ok := args["command"]; ok {
+		t.Errorf("ParseToolInvocation modified the arguments of the model: %v", args)
+	}
+}
@@ -17,19 +17,20 @@ package tools
 import (
 	"context"
 	"fmt"
-	"os"
-	"strings"
 
 	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
 )
 
 type Kubectl struct {
 	executor sandbox.Executor
+	// policy decides which commands may run, nil if there is no kubectl policy.
+	policy *KubectlPolicy
 }
 
-func NewKubectlTool(executor sandbox.Executor) *Kubectl {
-	return &Kubectl{executor: executor}
+func NewKubectlTool(executor sandbox.Executor, policy *KubectlPolicy) *Kubectl {
+	return &Kubectl{executor: executor, policy: policy}
 }
 
 func (t *Kubectl) Name() string {
@@ -73,6 +74,9 @@ assistant: kubectl get pods
 user: what is the status of the pod my-pod?
 assistant: kubectl get pod my-pod -o jsonpath='{.status.phase}'
 
+user: how many nodes does the staging cluster have?
+assistant: kubectl get nodes --context staging
+
 user: I need to edit the pod configuration
 assistant: # Option 1: Using patch for targeted changes
 kubectl patch pod my-pod --patch '{"spec":{"containers":[{"name":"main","image":"new-image"}]}}'
@@ -117,33 +121,36 @@ func (t *Kubectl) Run(ctx context.Context, args map[string]any) (any, error) {
 		return &sandbox.ExecResult{Command: command, Error: err.Error()}, nil
 	}
 
-	// Prepare environment
-	env := os.Environ()
-	if kubeconfig != "" {
-		kubeconfig, err := ExpandShellVar(kubeconfig)
+	if denial := t.policy.denial(command, kubeconfig); denial != "" {
+		return &sandbox.ExecResult{Command: command, Error: denial}, nil
+	}
+
+	// The reads served from the cache do not call the API server.
+	return withReadCache(ctx, kubeconfig, command, func() (*sandbox.ExecResult, error) {
+		if err := consumeAPICalls(ctx, command); err != nil {
+			return &sandbox.ExecResult{Command: command, Error: err.Error()}, nil
+		}
+
+		// Prepare environment
+		env, err := toolEnv(ctx, kubeconfig)
 		if err != nil {
 			return nil, err
 		}
-		env = append(env, "KUBECONFIG="+kubeconfig)
-	}
 
-	return ExecuteWithStreamingHandling(ctx, t.executor, command, workDir, env, DetectKubectlStreaming)
+		return ExecuteWithStreamingHandling(ctx, t.executor, command, workDir, env, DetectKubectlStreaming)
+	})
 }
 
 // DetectKubectlStreaming checks if a kubectl command is a streaming command
 func DetectKubectlStreaming(command string) (bool, string) {
-	isWatch := strings.Contains(command, " get ") && strings.Contains(command, " -w")
-	isLogs := strings.Contains(command, " logs ") && strings.Contains(command, " -f")
-	isAttach := strings.Contains(command, " attach ")
-
-	if isWatch {
-		return true, "watch"
-	}
-	if isLogs {
-		return true, "logs"
+	commands, err := kubectl.Parse(command)
+	if err != nil {
+		return false, ""
 	}
-	if isAttach {
-		return true, "attach"
+	for _, cmd := range commands {
+		if streamType := cmd.Streaming(); streamType != "" {
+			return true, streamType
+		}
 	}
 	return false, ""
 }
@@ -174,12 +181,26 @@ func (t *Kubectl) CheckModifiesResource(args map[string]any) string {
 	return kubectlModifiesResource(command)
 }
 
+// validateKubectlCommand rejects the kubectl commands that cannot run unattended.
 func validateKubectlCommand(command string) error {
-	if strings.Contains(command, "kubectl edit") {
-		return fmt.Errorf("interactive mode not supported for kubectl, please use non-interactive commands")
+	commands, err := kubectl.Parse(command)
+	if err != nil {
+		// The shell reports the syntax error.
+		return nil
 	}
-	if strings.Contains(command, "kubectl port-forward") {
-		return fmt.Errorf("port-forwarding is not allowed because assistant is running in an unattended mode, please try some other alternative")
+	for _, cmd := range commands {
+		switch cmd.Verb {
+		case "edit":
+			return fmt.Errorf("interactive mode not supported for kubectl, please use non-interactive commands")
+		case "port-forward":
+			return fmt.Errorf("port-forwarding is not allowed because assistant is running in an unattended mode, please try some other alternative")
+		}
 	}
 	return nil
 }
+
+// IsAsync runs the long running commands (e.g. rollout status, wait) asynchronously.
+func (t *Kubectl) IsAsync(args map[string]any) bool {
+	command, _ := args["command"].(string)
+	return IsLongRunningCommand(command)
+}
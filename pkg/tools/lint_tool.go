@@ -0,0 +1,424 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// LintManifestTool lints Kubernetes manifests offline, using kubeconform,
+// kube-linter and pluto if they are installed, and a basic structural lint
+// otherwise, which does not validate the manifests against the API schemas.
+type LintManifestTool struct {
+	executor sandbox.Executor
+}
+
+func NewLintManifestTool(executor sandbox.Executor) *LintManifestTool {
+	return &LintManifestTool{executor: executor}
+}
+
+func (t *LintManifestTool) Name() string {
+	return "lint_manifest"
+}
+
+func (t *LintManifestTool) Description() string {
+	return `Lints Kubernetes manifests without contacting the cluster, and returns concrete findings (schema errors, deprecated or removed APIs, missing resource limits, unpinned images, ...).
+Uses kubeconform, kube-linter and pluto when they are installed. Otherwise only a basic structural lint runs: it does not validate the fields against the API schemas, so unknown or mistyped fields are not reported.
+Use this tool to validate manifests you or the user have written before applying them.`
+}
+
+func (t *LintManifestTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"manifest": {
+					Type:        gollm.TypeString,
+					Description: `The manifest content (YAML, possibly multiple documents separated by "---"). Either manifest or path must be provided.`,
+				},
+				"path": {
+					Type:        gollm.TypeString,
+					Description: `The path of a manifest file or directory to lint. Either manifest or path must be provided.`,
+				},
+			},
+		},
+	}
+}
+
+// LintResult is the result of the lint_manifest tool.
+type LintResult struct {
+	// Linters lists the linters that were run ("structural" for the basic structural lint).
+	Linters  []string      `json:"linters"`
+	Findings []LintFinding `json:"findings"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// LintFinding is a single lint finding.
+type LintFinding struct {
+	Linter   string `json:"linter"`
+	Resource string `json:"resource,omitempty"`
+	// Severity is "error" or "warning".
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// manifestLinter adapts an external lint binary.
+type manifestLinter struct {
+	binary string
+	// args builds the arguments to lint the given target ("-" for stdin).
+	args  func(target string) string
+	parse func(stdout string) ([]LintFinding, error)
+}
+
+var manifestLinters = []manifestLinter{
+	{
+		binary: "kubeconform",
+		args:   func(target string) string { return "-strict -ignore-missing-schemas -summary -output json " + target },
+		parse:  parseKubeconformOutput,
+	},
+	{
+		binary: "kube-linter",
+		args:   func(target string) string { return "lint --format json " + target },
+		parse:  parseKubeLinterOutput,
+	},
+	{
+		binary: "pluto",
+		args:   func(target string) string { return "detect -o json " + target },
+		parse:  parsePlutoOutput,
+	},
+}
+
+func (t *LintManifestTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	manifest, _ := args["manifest"].(string)
+	path, _ := args["path"].(string)
+
+	result := &LintResult{Findings: []LintFinding{}}
+	if strings.TrimSpace(manifest) == "" && path == "" {
+		result.Error = "either manifest or path must be provided"
+		return result, nil
+	}
+
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest == "" {
+		execResult, err := t.executor.Execute(ctx, "cat "+ShellQuote(path), env, workDir)
+		if err == nil && execResult.ExitCode == 0 {
+			manifest = execResult.Stdout
+		}
+	}
+
+	for _, linter := range manifestLinters {
+		probe, err := t.executor.Execute(ctx, "command -v "+linter.binary, env, workDir)
+		if err != nil || probe.ExitCode != 0 || strings.TrimSpace(probe.Stdout) == "" {
+			continue
+		}
+
+		var command string
+		if path != "" {
+			command = linter.binary + " " + linter.args(ShellQuote(path))
+		} else {
+			command = withHeredoc(linter.binary+" "+linter.args("-"), manifest)
+		}
+
+		// Linters exit with a non-zero code when they report findings, so only the output is checked.
+		execResult, err := t.executor.Execute(ctx, command, env, workDir)
+		if err != nil {
+			return nil, fmt.Errorf("running %s: %w", linter.binary, err)
+		}
+		findings, err := linter.parse(execResult.Stdout)
+		if err != nil {
+			klog.Warningf("failed to parse %s output: %v, stderr: %q", linter.binary, err, execResult.Stderr)
+			result.Findings = append(result.Findings, LintFinding{
+				Linter:   linter.binary,
+				Severity: "error",
+				Message:  strings.TrimSpace(execResult.Stderr + " " + execResult.Error),
+			})
+			result.Linters = append(result.Linters, linter.binary)
+			continue
+		}
+		result.Linters = append(result.Linters, linter.binary)
+		result.Findings = append(result.Findings, findings...)
+	}
+
+	if len(result.Linters) == 0 {
+		if manifest == "" {
+			result.Error = fmt.Sprintf("no linters are installed and %q could not be read for the structural lint", path)
+			return result, nil
+		}
+		result.Linters = []string{"structural"}
+		result.Findings = append(result.Findings, lintManifestStructure(manifest)...)
+	}
+	return result, nil
+}
+
+func parseKubeconformOutput(stdout string) ([]LintFinding, error) {
+	var output struct {
+		Resources []struct {
+			Kind   string `json:"kind"`
+			Name   string `json:"name"`
+			Status string `json:"status"`
+			Msg    string `json:"msg"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+		return nil, err
+	}
+
+	var findings []LintFinding
+	for _, r := range output.Resources {
+		severity := "error"
+		switch r.Status {
+		case "statusValid":
+			continue
+		case "statusSkipped":
+			severity = "warning"
+		}
+		findings = append(findings, LintFinding{
+			Linter:   "kubeconform",
+			Resource: resourceRef(r.Kind, r.Name),
+			Severity: severity,
+			Message:  r.Msg,
+		})
+	}
+	return findings, nil
+}
+
+func parseKubeLinterOutput(stdout string) ([]LintFinding, error) {
+	var output struct {
+		Reports []struct {
+			Check       string `json:"Check"`
+			Remediation string `json:"Remediation"`
+			Diagnostic  struct {
+				Message string `json:"Message"`
+			} `json:"Diagnostic"`
+			Object struct {
+				K8sObject struct {
+					Name             string `json:"Name"`
+					GroupVersionKind struct {
+						Kind string `json:"Kind"`
+					} `json:"GroupVersionKind"`
+				} `json:"K8sObject"`
+			} `json:"Object"`
+		} `json:"Reports"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+		return nil, err
+	}
+
+	var findings []LintFinding
+	for _, r := range output.Reports {
+		message := fmt.Sprintf("[%s] %s", r.Check, r.Diagnostic.Message)
+		if r.Remediation != "" {
+			message += " Remediation: " + r.Remediation
+		}
+		findings = append(findings, LintFinding{
+			Linter:   "kube-linter",
+			Resource: resourceRef(r.Object.K8sObject.GroupVersionKind.Kind, r.Object.K8sObject.Name),
+			Severity: "warning",
+			Message:  message,
+		})
+	}
+	return findings, nil
+}
+
+func parsePlutoOutput(stdout string) ([]LintFinding, error) {
+	var output struct {
+		Items []struct {
+			Name string `json:"name"`
+			API  struct {
+				Version        string `json:"version"`
+				Kind           string `json:"kind"`
+				DeprecatedIn   string `json:"deprecated-in"`
+				RemovedIn      string `json:"removed-in"`
+				ReplacementAPI string `json:"replacement-api"`
+			} `json:"api"`
+			Deprecated bool `json:"deprecated"`
+			Removed    bool `json:"removed"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+		return nil, err
+	}
+
+	var findings []LintFinding
+	for _, item := range output.Items {
+		if !item.Deprecated && !item.Removed {
+			continue
+		}
+		severity := "warning"
+		message := fmt.Sprintf("%s is deprecated since Kubernetes %s", item.API.Version, item.API.DeprecatedIn)
+		if item.Removed {
+			severity = "error"
+			message = fmt.Sprintf("%s was removed in Kubernetes %s", item.API.Version, item.API.RemovedIn)
+		}
+		if item.API.ReplacementAPI != "" {
+			message += ", use " + item.API.ReplacementAPI + " instead"
+		}
+		findings = append(findings, LintFinding{
+			Linter:   "pluto",
+			Resource: resourceRef(item.API.Kind, item.Name),
+			Severity: severity,
+			Message:  message,
+		})
+	}
+	return findings, nil
+}
+
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// lintManifestStructure is the basic structural lint used when no linter
+// binary is available: it checks that the documents are objects with an
+// apiVersion, a kind and a name, the removed APIs and a few fields of the
+// containers, but not the other fields, which need the API schemas.
+func lintManifestStructure(manifest string) []LintFinding {
+	var findings []LintFinding
+	add := func(resource, severity, format string, args ...any) {
+		findings = append(findings, LintFinding{
+			Linter:   "structural",
+			Resource: resource,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for i, doc := range documentSeparator.Split(manifest, -1) {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		obj := make(map[string]any)
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			add(fmt.Sprintf("document %d", i+1), "error", "invalid YAML: %v", err)
+			continue
+		}
+		if len(obj) == 0 {
+			continue
+		}
+
+		u := unstructured.Unstructured{Object: obj}
+		resource := resourceRef(u.GetKind(), u.GetName())
+		if resource == "" {
+			resource = fmt.Sprintf("document %d", i+1)
+		}
+		if u.GetAPIVersion() == "" {
+			add(resource, "error", "missing apiVersion")
+		}
+		if u.GetKind() == "" {
+			add(resource, "error", "missing kind")
+		}
+		if u.GetName() == "" && u.GetGenerateName() == "" {
+			add(resource, "error", "missing metadata.name")
+		}
+		if removed, ok := removedAPIs[u.GetAPIVersion()+"/"+u.GetKind()]; ok {
+			message := fmt.Sprintf("%s %s was removed in Kubernetes %s", u.GetAPIVersion(), u.GetKind(), removed.removedIn)
+			if removed.replacement != "" {
+				message += ", use " + removed.replacement + " instead"
+			}
+			add(resource, "error", "%s", message)
+		}
+
+		for _, container := range podContainers(u) {
+			name, _ := container["name"].(string)
+			image, _ := container["image"].(string)
+			switch {
+			case image == "":
+				add(resource, "error", "container %q has no image", name)
+			case imageUnpinned(image):
+				add(resource, "warning", "container %q uses an unpinned image %q, pin a tag or digest", name, image)
+			}
+			if _, ok, _ := unstructured.NestedMap(container, "resources", "limits"); !ok {
+				add(resource, "warning", "container %q has no resource limits", name)
+			}
+			if _, ok, _ := unstructured.NestedMap(container, "resources", "requests"); !ok {
+				add(resource, "warning", "container %q has no resource requests", name)
+			}
+			if privileged, _, _ := unstructured.NestedBool(container, "securityContext", "privileged"); privileged {
+				add(resource, "warning", "container %q runs privileged", name)
+			}
+		}
+	}
+	return findings
+}
+
+// podContainers returns the containers of a Pod or of a workload's pod template.
+func podContainers(u unstructured.Unstructured) []map[string]any {
+	var paths [][]string
+	switch u.GetKind() {
+	case "Pod":
+		paths = [][]string{{"spec", "containers"}, {"spec", "initContainers"}}
+	case "CronJob":
+		paths = [][]string{{"spec", "jobTemplate", "spec", "template", "spec", "containers"}, {"spec", "jobTemplate", "spec", "template", "spec", "initContainers"}}
+	default:
+		paths = [][]string{{"spec", "template", "spec", "containers"}, {"spec", "template", "spec", "initContainers"}}
+	}
+
+	var containers []map[string]any
+	for _, path := range paths {
+		list, _, _ := unstructured.NestedSlice(u.Object, path...)
+		for _, c := range list {
+			if container, ok := c.(map[string]any); ok {
+				containers = append(containers, container)
+			}
+		}
+	}
+	return containers
+}
+
+// imageUnpinned returns true if the image has no tag or digest, or uses the latest tag.
+func imageUnpinned(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+	lastSegment := image[strings.LastIndex(image, "/")+1:]
+	_, tag, ok := strings.Cut(lastSegment, ":")
+	return !ok || tag == "latest"
+}
+
+func resourceRef(kind, name string) string {
+	switch {
+	case kind == "":
+		return name
+	case name == "":
+		return kind
+	default:
+		return kind + "/" + name
+	}
+}
+
+func (t *LintManifestTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "no", the lint_manifest tool never contacts the cluster.
+func (t *LintManifestTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
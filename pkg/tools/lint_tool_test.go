@@ -0,0 +1,90 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintManifestBuiltin(t *testing.T) {
+	manifest := `apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+      - name: sidecar
+        image: envoy:1.30@sha256:abc
+        resources:
+          limits: {cpu: 100m}
+          requests: {cpu: 100m}
+---
+kind: ConfigMap
+`
+
+	var got []string
+	for _, f := range lintManifestStructure(manifest) {
+		got = append(got, f.Severity+" "+f.Resource+": "+f.Message)
+	}
+	want := []string{
+		"error Ingress/web: extensions/v1beta1 Ingress was removed in Kubernetes 1.22, use networking.k8s.io/v1 instead",
+		`warning Deployment/web: container "web" uses an unpinned image "nginx", pin a tag or digest`,
+		`warning Deployment/web: container "web" has no resource limits`,
+		`warning Deployment/web: container "web" has no resource requests`,
+		"error ConfigMap: missing apiVersion",
+		"error ConfigMap: missing metadata.name",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lintManifestStructure() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestLintManifestToolHeredoc(t *testing.T) {
+	manifest := "apiVersion: v1\nkind: ConfigMap\ndata:\n  script: |\nEOF\nKUBECTL_AI_MANIFEST_EOF\ntouch /tmp/escaped\n"
+
+	executor := &MockExecutor{}
+	ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+	ctx = context.WithValue(ctx, WorkDirKey, t.TempDir())
+	if _, err := NewLintManifestTool(executor).Run(ctx, map[string]any{"manifest": manifest}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	lines := strings.Split(executor.CapturedCommand, "\n")
+	delimiter := lines[len(lines)-1]
+	if !strings.HasSuffix(lines[0], " <<'"+delimiter+"'") {
+		t.Fatalf("command %q does not end with the heredoc delimiter %q", executor.CapturedCommand, delimiter)
+	}
+	body := lines[1 : len(lines)-1]
+	for _, line := range body {
+		if line == delimiter {
+			t.Fatalf("manifest line %q ends the heredoc early in %q", line, executor.CapturedCommand)
+		}
+	}
+	if got := strings.Join(body, "\n") + "\n"; got != manifest {
+		t.Errorf("heredoc body = %q, want %q", got, manifest)
+	}
+}
@@ -0,0 +1,375 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+const (
+	defaultLokiWindow = time.Hour
+	defaultLokiLimit  = 100
+	maxLokiLimit      = 1000
+	// lokiPort is the port Loki listens on by default.
+	lokiPort = 3100
+	// maxLokiLineLength bounds the length of the log lines returned.
+	maxLokiLineLength = 2000
+)
+
+// LokiConfig configures the Loki instance of the loki_query tool, reached
+// either at its URL or through the service proxy of the API server.
+type LokiConfig struct {
+	// URL is the base URL of Loki, e.g. https://logs-prod-eu-west-0.grafana.net.
+	URL string `json:"url,omitempty"`
+	// Service is the Loki service as namespace/name:port, e.g.
+	// "monitoring/loki-gateway:80", used when URL is empty.
+	Service string `json:"service,omitempty"`
+	// TenantID is sent in the X-Scope-OrgID header to a multi-tenant Loki at URL.
+	TenantID string `json:"tenantID,omitempty"`
+	// Username and PasswordEnv are the basic auth credentials of URL, the
+	// password being read from the PasswordEnv environment variable.
+	Username    string `json:"username,omitempty"`
+	PasswordEnv string `json:"passwordEnv,omitempty"`
+	// TokenEnv is the environment variable holding a bearer token for URL.
+	TokenEnv string `json:"tokenEnv,omitempty"`
+}
+
+// Validate returns an error if the config does not select a Loki instance.
+func (c *LokiConfig) Validate() error {
+	switch {
+	case c.URL == "" && c.Service == "":
+		return fmt.Errorf("loki: set the url or the service of Loki")
+	case c.URL != "" && c.Service != "":
+		return fmt.Errorf("loki: set either the url or the service of Loki, not both")
+	case c.URL != "":
+		if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("loki: invalid url %q, expected e.g. https://loki.example.com", c.URL)
+		}
+	}
+	return nil
+}
+
+// LokiTool queries the logs kept by Grafana Loki with LogQL, so that the
+// model can search the logs of restarted or deleted pods.
+type LokiTool struct {
+	executor sandbox.Executor
+	config   LokiConfig
+}
+
+func NewLokiTool(executor sandbox.Executor, config LokiConfig) *LokiTool {
+	return &LokiTool{executor: executor, config: config}
+}
+
+func (t *LokiTool) Name() string {
+	return "loki_query"
+}
+
+func (t *LokiTool) Description() string {
+	return `Runs a LogQL query against Grafana Loki, which keeps the logs of the cluster after their pods restarted or were deleted, over a time range (by default the last hour).
+Log queries return the matching lines grouped by stream, from the oldest to the most recent, e.g. {namespace="shop", pod=~"web-.*"} |= "error".
+Metric queries, e.g. sum by (pod) (count_over_time({namespace="shop"} |= "error" [5m])), return the samples of each series.
+Select the streams with the namespace, pod and container labels and filter the lines, to keep the results small.
+Use kubectl logs for the current logs of running pods.`
+}
+
+func (t *LokiTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"query": {
+					Type:        gollm.TypeString,
+					Description: `The LogQL query, e.g. {namespace="shop", container="app"} |= "timeout".`,
+				},
+				"since": {
+					Type:        gollm.TypeString,
+					Description: `How far back to look, as a duration, e.g. "10m", "6h", "7d". Defaults to "1h". Ignored if start is set.`,
+				},
+				"start": {
+					Type:        gollm.TypeString,
+					Description: `The start of the time range, as an RFC 3339 time, e.g. "2025-06-01T10:00:00Z".`,
+				},
+				"end": {
+					Type:        gollm.TypeString,
+					Description: `The end of the time range, as an RFC 3339 time. Defaults to now.`,
+				},
+				"limit": {
+					Type:        gollm.TypeInteger,
+					Description: `The maximum number of log lines, the most recent ones are kept. Defaults to 100, at most 1000.`,
+				},
+			},
+			Required: []string{"query"},
+		},
+	}
+}
+
+// LokiResult is the result of the loki_query tool.
+type LokiResult struct {
+	Query string    `json:"query"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Streams are the log lines of a log query, by stream.
+	Streams []LokiStream `json:"streams,omitempty"`
+	// Series are the samples of a metric query, by series.
+	Series []LokiSeries `json:"series,omitempty"`
+	// Limited is true if the limit was reached, older lines were left out.
+	Limited bool   `json:"limited,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// LokiStream are the log lines of a stream.
+type LokiStream struct {
+	Labels map[string]string `json:"labels"`
+	Lines  []LokiLine        `json:"lines"`
+}
+
+// LokiLine is a log line.
+type LokiLine struct {
+	Time time.Time `json:"time"`
+	Line string    `json:"line"`
+}
+
+// LokiSeries are the samples of a series of a metric query.
+type LokiSeries struct {
+	Labels  map[string]string `json:"labels"`
+	Samples []LokiSample      `json:"samples"`
+}
+
+// LokiSample is a sample of a series.
+type LokiSample struct {
+	Time  time.Time `json:"time"`
+	Value string    `json:"value"`
+}
+
+// lokiResponse is the response of the query_range endpoint of the Loki API.
+type lokiResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Stream map[string]string `json:"stream"`
+			Metric map[string]string `json:"metric"`
+			// Values are [timestamp, value] pairs, the timestamps being
+			// nanoseconds for streams and seconds for matrices.
+			Values [][2]json.RawMessage `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+	Error string `json:"error"`
+}
+
+func (t *LokiTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	query, _ := args["query"].(string)
+	sinceArg, _ := args["since"].(string)
+	startArg, _ := args["start"].(string)
+	endArg, _ := args["end"].(string)
+
+	result := &LokiResult{Query: strings.TrimSpace(query)}
+	if result.Query == "" {
+		result.Error = "missing LogQL query"
+		return result, nil
+	}
+	start, end, err := lokiTimeRange(time.Now(), sinceArg, startArg, endArg)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Start, result.End = start, end
+	limit := defaultLokiLimit
+	if n := intArg(args["limit"]); n > 0 {
+		limit = min(n, maxLokiLimit)
+	}
+
+	params := url.Values{
+		"query":     {result.Query},
+		"start":     {strconv.FormatInt(start.UnixNano(), 10)},
+		"end":       {strconv.FormatInt(end.UnixNano(), 10)},
+		"limit":     {strconv.Itoa(limit)},
+		"direction": {"backward"},
+	}
+	var output []byte
+	if t.config.URL != "" {
+		output, err = t.queryURL(ctx, params)
+	} else {
+		var env []string
+		env, err = toolEnv(ctx, kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		var path string
+		path, err = serviceProxyPath(t.config.Service, lokiPort, "/loki/api/v1/query_range", params)
+		if err == nil {
+			var raw string
+			raw, err = getRaw(ctx, t.executor, path, env, workDir)
+			output = []byte(raw)
+		}
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("querying Loki: %v", err)
+		return result, nil
+	}
+	if err := lokiResult(output, limit, result); err != nil {
+		result.Error = err.Error()
+	}
+	return result, nil
+}
+
+// queryURL runs the query against the Loki URL of the config.
+func (t *LokiTool) queryURL(ctx context.Context, params url.Values) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(t.config.URL, "/")+"/loki/api/v1/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if t.config.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", t.config.TenantID)
+	}
+	if t.config.Username != "" {
+		req.SetBasicAuth(t.config.Username, os.Getenv(t.config.PasswordEnv))
+	}
+	if t.config.TokenEnv != "" {
+		req.Header.Set("Authorization", "Bearer "+os.Getenv(t.config.TokenEnv))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(truncateString(string(body), 500)))
+	}
+	return body, nil
+}
+
+// lokiTimeRange returns the time range of a query from the since, start and end arguments.
+func lokiTimeRange(now time.Time, since, start, end string) (time.Time, time.Time, error) {
+	to := now.UTC()
+	if end = strings.TrimSpace(end); end != "" {
+		t, err := time.Parse(time.RFC3339, end)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end %q, expected an RFC 3339 time", end)
+		}
+		to = t.UTC()
+	}
+	from := to.Add(-defaultLokiWindow)
+	switch {
+	case strings.TrimSpace(start) != "":
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(start))
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start %q, expected an RFC 3339 time", start)
+		}
+		from = t.UTC()
+	case strings.TrimSpace(since) != "":
+		d, err := parseHistoryWindow(since)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		from = to.Add(-d)
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("the start %s is not before the end %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	return from, to, nil
+}
+
+// lokiResult fills the result with the streams or the series of a response
+// of the query_range endpoint.
+func lokiResult(body []byte, limit int, result *LokiResult) error {
+	var resp lokiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("parsing the response of Loki: %w", err)
+	}
+	if resp.Status != "success" {
+		return fmt.Errorf("Loki returned %q: %s", resp.Status, resp.Error)
+	}
+	lines := 0
+	for _, r := range resp.Data.Result {
+		switch resp.Data.ResultType {
+		case "streams":
+			stream := LokiStream{Labels: r.Stream, Lines: make([]LokiLine, 0, len(r.Values))}
+			for _, value := range r.Values {
+				var ts, line string
+				if json.Unmarshal(value[0], &ts) != nil || json.Unmarshal(value[1], &line) != nil {
+					continue
+				}
+				ns, err := strconv.ParseInt(ts, 10, 64)
+				if err != nil {
+					continue
+				}
+				stream.Lines = append(stream.Lines, LokiLine{Time: time.Unix(0, ns).UTC(), Line: truncateString(line, maxLokiLineLength)})
+			}
+			sort.SliceStable(stream.Lines, func(i, j int) bool { return stream.Lines[i].Time.Before(stream.Lines[j].Time) })
+			lines += len(stream.Lines)
+			result.Streams = append(result.Streams, stream)
+		case "matrix":
+			series := LokiSeries{Labels: r.Metric, Samples: make([]LokiSample, 0, len(r.Values))}
+			for _, value := range r.Values {
+				var seconds float64
+				var v string
+				if json.Unmarshal(value[0], &seconds) != nil || json.Unmarshal(value[1], &v) != nil {
+					continue
+				}
+				series.Samples = append(series.Samples, LokiSample{Time: time.Unix(0, int64(seconds*float64(time.Second))).UTC(), Value: v})
+			}
+			result.Series = append(result.Series, series)
+		default:
+			return fmt.Errorf("unsupported result type %q", resp.Data.ResultType)
+		}
+	}
+	sort.SliceStable(result.Streams, func(i, j int) bool {
+		return fmt.Sprint(result.Streams[i].Labels) < fmt.Sprint(result.Streams[j].Labels)
+	})
+	result.Limited = lines >= limit
+	return nil
+}
+
+// truncateString returns s cut to n bytes, marked with "..." if cut.
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+func (t *LokiTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "no", the loki_query tool only reads logs.
+func (t *LokiTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLokiToolURL(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, password, _ := req.BasicAuth(); req.URL.Path != "/loki/api/v1/query_range" || req.Header.Get("X-Scope-OrgID") != "shop" || user != "reader" || password != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		query = req.URL.Query().Get("query") + " " + req.URL.Query().Get("limit") + " " + req.URL.Query().Get("start") + " " + req.URL.Query().Get("end")
+		w.Write([]byte(`{"status": "success", "data": {"resultType": "streams", "result": [
+			{"stream": {"namespace": "shop", "pod": "web-1"}, "values": [["1748772060000000000", "level=error msg=\"timeout\""]]},
+			{"stream": {"namespace": "shop", "pod": "web-0"}, "values": [["1748772120000000000", "level=error msg=\"retrying\""], ["1748772000000000000", "level=error msg=\"timeout\""]]}]}}`))
+	}))
+	defer server.Close()
+	t.Setenv("LOKI_PASSWORD", "secret")
+	ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+	ctx = context.WithValue(ctx, WorkDirKey, t.TempDir())
+
+	tool := NewLokiTool(nil, LokiConfig{URL: server.URL + "/", TenantID: "shop", Username: "reader", PasswordEnv: "LOKI_PASSWORD"})
+	got, err := tool.Run(ctx, map[string]any{"query": `{namespace="shop"} |= "error"`, "start": "2025-06-01T10:00:00Z", "end": "2025-06-01T11:00:00Z", "limit": float64(3)})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if want := `{namespace="shop"} |= "error" 3 1748772000000000000 1748775600000000000`; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	result := got.(*LokiResult)
+	want := []LokiStream{
+		{Labels: map[string]string{"namespace": "shop", "pod": "web-0"}, Lines: []LokiLine{
+			{Time: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC), Line: `level=error msg="timeout"`},
+			{Time: time.Date(2025, 6, 1, 10, 2, 0, 0, time.UTC), Line: `level=error msg="retrying"`},
+		}},
+		{Labels: map[string]string{"namespace": "shop", "pod": "web-1"}, Lines: []LokiLine{
+			{Time: time.Date(2025, 6, 1, 10, 1, 0, 0, time.UTC), Line: `level=error msg="timeout"`},
+		}},
+	}
+	if result.Error != "" || !reflect.DeepEqual(result.Streams, want) || !result.Limited {
+		t.Errorf("Run() = %+v, want the streams %+v, limited", result, want)
+	}
+
+	got, _ = NewLokiTool(nil, LokiConfig{URL: server.URL}).Run(ctx, map[string]any{"query": `{namespace="shop"}`})
+	if result := got.(*LokiResult); !strings.Contains(result.Error, "401 Unauthorized") {
+		t.Errorf("Run() without credentials error = %q, want the status", result.Error)
+	}
+}
+
+func TestLokiToolService(t *testing.T) {
+	executor := &scriptedExecutor{outputs: map[string]string{
+		"kubectl get --raw '/api/v1/namespaces/monitoring/services/loki-gateway:80/proxy/loki/api/v1/query_range?": `{"status": "success", "data": {"resultType": "matrix", "result": [
+			{"metric": {"pod": "web-0"}, "values": [[1748772000, "3"], [1748772300.5, "7"]]}]}}`,
+	}}
+	ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+	ctx = context.WithValue(ctx, WorkDirKey, t.TempDir())
+
+	got, err := NewLokiTool(executor, LokiConfig{Service: "monitoring/loki-gateway:80"}).Run(ctx, map[string]any{"query": `sum by (pod) (count_over_time({namespace="shop"} |= "error" [5m]))`, "since": "6h"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	result := got.(*LokiResult)
+	want := []LokiSeries{{Labels: map[string]string{"pod": "web-0"}, Samples: []LokiSample{
+		{Time: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC), Value: "3"},
+		{Time: time.Date(2025, 6, 1, 10, 5, 0, 500000000, time.UTC), Value: "7"},
+	}}}
+	if result.Error != "" || !reflect.DeepEqual(result.Series, want) {
+		t.Errorf("Run() = %+v, want the series %+v", result, want)
+	}
+	if d := result.End.Sub(result.Start); d != 6*time.Hour {
+		t.Errorf("time range = %s, want 6h", d)
+	}
+}
+
+func TestLokiTimeRange(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name               string
+		since, start, end  string
+		wantStart, wantEnd time.Time
+		wantErr            bool
+	}{
+		{name: "default", wantStart: now.Add(-time.Hour), wantEnd: now},
+		{name: "since", since: "2d", wantStart: now.Add(-48 * time.Hour), wantEnd: now},
+		{name: "since before end", since: "30m", end: "2025-06-01T08:00:00Z", wantStart: time.Date(2025, 6, 1, 7, 30, 0, 0, time.UTC), wantEnd: time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)},
+		{name: "start", since: "5m", start: "2025-06-01T11:00:00+02:00", wantStart: time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC), wantEnd: now},
+		{name: "start after end", start: "2025-06-01T13:00:00Z", wantErr: true},
+		{name: "invalid since", since: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := lokiTimeRange(now, tt.since, tt.start, tt.end)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("lokiTimeRange() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("lokiTimeRange() = %s, %s, want %s, %s", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+const (
+	// OutputsDir is the directory of the work directory holding the full
+	// outputs of the tool calls that were truncated. It is hidden, so that the
+	// outputs are not reported as artifacts.
+	OutputsDir = ".outputs"
+	// outputExcerptLines is the number of lines kept from the start and from
+	// the end of a truncated output.
+	outputExcerptLines = 20
+	// maxSummaryLines bounds the error lines quoted in the summary of an output.
+	maxSummaryLines = 5
+	// maxSummaryLineChars bounds the length of the lines quoted in a summary.
+	maxSummaryLineChars = 200
+)
+
+var (
+	// outputHandle matches the handles of the stored outputs.
+	outputHandle = regexp.MustCompile(`^output-\d+$`)
+	// yamlKind matches the kind of the objects of YAML outputs, e.g. the items
+	// of "kubectl get -o yaml", but not the kinds of their owner references.
+	yamlKind = regexp.MustCompile(`(?m)^(?:- |  )?kind: (\w+)\s*$`)
+	// errorLine and warningLine match the error and warning lines of logs.
+	errorLine   = regexp.MustCompile(`(?i)\b(error|fatal|panic|exception|failed|failure)\b`)
+	warningLine = regexp.MustCompile(`(?i)\bwarn(ing)?\b`)
+)
+
+// LimitOutput truncates the output of a tool call sent to the model when it
+// exceeds maxBytes. The full output is stored in the OutputsDir of workDir,
+// and the model gets a summary of it, its first and last lines, and a handle
+// to read other ranges of lines with the read_output tool. Outputs within the
+// limit, or with maxBytes <= 0, are returned as is.
+func LimitOutput(workDir string, maxBytes int, output any) (any, error) {
+	if maxBytes <= 0 || workDir == "" {
+		return output, nil
+	}
+	switch output := output.(type) {
+	case string:
+		if len(output) <= maxBytes {
+			return output, nil
+		}
+		return truncateOutput(workDir, output)
+	case *sandbox.ExecResult:
+		if output == nil || len(output.Stdout) <= maxBytes {
+			return output, nil
+		}
+		stdout, err := truncateOutput(workDir, output.Stdout)
+		if err != nil {
+			return nil, err
+		}
+		truncated := *output
+		truncated.Stdout = stdout
+		return &truncated, nil
+	}
+	return output, nil
+}
+
+// truncateOutput stores output and returns its summary.
+func truncateOutput(workDir, output string) (string, error) {
+	handle, err := storeOutput(filepath.Join(workDir, OutputsDir), output)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+
+	var b strings.Builder
+	if object, ok := parseStructure(output); ok {
+		// The excerpts of a single large object, e.g. a giant ConfigMap or CRD,
+		// rarely hold the fields the model needs, its structure tells where they are.
+		fmt.Fprintf(&b, "[The output is too large: %d bytes, %d lines. It was stored with the handle %q; call read_output with this handle to read its lines, or get_resource_field to fetch the fields listed below.]\n", len(output), len(lines), handle)
+		if name := objectName(object); name != "" {
+			fmt.Fprintf(&b, "[%s]\n", name)
+		}
+		fmt.Fprintf(&b, "\n%s\n", strings.Join(summarizeStructure(object), "\n"))
+		return b.String(), nil
+	}
+	fmt.Fprintf(&b, "[The output is too large: %d bytes, %d lines. It was truncated and stored with the handle %q; call read_output with this handle to read other lines.]\n", len(output), len(lines), handle)
+	for _, fact := range summarizeOutput(output) {
+		fmt.Fprintf(&b, "[%s]\n", fact)
+	}
+	if len(lines) <= 2*outputExcerptLines {
+		// Few but long lines.
+		b.WriteString(output[:min(len(output), 2*outputExcerptLines*maxSummaryLineChars)])
+		return b.String(), nil
+	}
+	fmt.Fprintf(&b, "\n%s\n[... lines %d to %d omitted ...]\n%s\n",
+		strings.Join(lines[:outputExcerptLines], "\n"),
+		outputExcerptLines+1, len(lines)-outputExcerptLines,
+		strings.Join(lines[len(lines)-outputExcerptLines:], "\n"))
+	return b.String(), nil
+}
+
+// storeOutput writes output to the next output file of dir and returns its handle.
+func storeOutput(dir, output string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("creating outputs directory: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("reading outputs directory: %w", err)
+	}
+	for n := len(entries) + 1; ; n++ {
+		handle := "output-" + strconv.Itoa(n)
+		f, err := os.OpenFile(filepath.Join(dir, handle+".txt"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("storing output: %w", err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(output); err != nil {
+			return "", fmt.Errorf("storing output: %w", err)
+		}
+		return handle, nil
+	}
+}
+
+// summarizeOutput returns facts about an output helping the model decide
+// which lines to read: the kinds of the objects of YAML outputs, and the
+// error and warning lines of logs.
+func summarizeOutput(output string) []string {
+	var facts []string
+
+	kinds := make(map[string]int)
+	for _, match := range yamlKind.FindAllStringSubmatch(output, -1) {
+		kinds[match[1]]++
+	}
+	if len(kinds) > 0 {
+		names := make([]string, 0, len(kinds))
+		for kind := range kinds {
+			names = append(names, kind)
+		}
+		sort.Strings(names)
+		counts := make([]string, len(names))
+		for i, kind := range names {
+			counts[i] = fmt.Sprintf("%s (%d)", kind, kinds[kind])
+		}
+		facts = append(facts, "Kinds of objects: "+strings.Join(counts, ", "))
+	}
+
+	var errors, warnings int
+	var quoted []string
+	for i, line := range strings.Split(output, "\n") {
+		switch {
+		case errorLine.MatchString(line):
+			errors++
+			if len(quoted) < maxSummaryLines {
+				if len(line) > maxSummaryLineChars {
+					line = line[:maxSummaryLineChars] + "..."
+				}
+				quoted = append(quoted, fmt.Sprintf("line %d: %s", i+1, strings.TrimSpace(line)))
+			}
+		case warningLine.MatchString(line):
+			warnings++
+		}
+	}
+	if errors > 0 || warnings > 0 {
+		facts = append(facts, fmt.Sprintf("%d error lines, %d warning lines", errors, warnings))
+	}
+	for _, line := range quoted {
+		facts = append(facts, "Error at "+line)
+	}
+	return facts
+}
+
+// ReadOutputTool reads ranges of lines of the outputs truncated by LimitOutput,
+// and of the bundles collected by the collect_bundle tool.
+type ReadOutputTool struct {
+	// maxBytes bounds the lines returned, so that they are not truncated again.
+	maxBytes int
+}
+
+func NewReadOutputTool(maxBytes int) *ReadOutputTool {
+	return &ReadOutputTool{maxBytes: maxBytes}
+}
+
+func (t *ReadOutputTool) Name() string {
+	return "read_output"
+}
+
+func (t *ReadOutputTool) Description() string {
+	return `Reads a range of lines of a tool output that was too large and was truncated, using the handle given in its place, e.g. "output-1", or of a bundle collected by collect_bundle, using its handle.
+Use the summary and the line numbers of the truncated output, or the index of the bundle, to read only the lines you need, e.g. the spec of one object or the lines around an error.`
+}
+
+func (t *ReadOutputTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"handle": {
+					Type:        gollm.TypeString,
+					Description: `The handle of the truncated output or of the bundle, e.g. "output-1".`,
+				},
+				"start_line": {
+					Type:        gollm.TypeInteger,
+					Description: `The first line to read, numbered from 1. Defaults to 1.`,
+				},
+				"end_line": {
+					Type:        gollm.TypeInteger,
+					Description: `The last line to read, included. Defaults to the last line; fewer lines are returned if they are too large.`,
+				},
+			},
+			Required: []string{"handle"},
+		},
+	}
+}
+
+// ReadOutputResult is the result of the read_output tool.
+type ReadOutputResult struct {
+	Handle     string `json:"handle,omitempty"`
+	StartLine  int    `json:"start_line,omitempty"`
+	EndLine    int    `json:"end_line,omitempty"`
+	TotalLines int    `json:"total_lines,omitempty"`
+	Content    string `json:"content,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (t *ReadOutputTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	handle, _ := args["handle"].(string)
+	if !outputHandle.MatchString(handle) {
+		return &ReadOutputResult{Handle: handle, Error: fmt.Sprintf("invalid handle %q, expected e.g. \"output-1\"", handle)}, nil
+	}
+	workDir, _ := ctx.Value(WorkDirKey).(string)
+	b, err := os.ReadFile(filepath.Join(workDir, OutputsDir, handle+".txt"))
+	if os.IsNotExist(err) {
+		return &ReadOutputResult{Handle: handle, Error: fmt.Sprintf("no output with the handle %q", handle)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading output: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	start := max(intArg(args["start_line"]), 1)
+	end := intArg(args["end_line"])
+	if end <= 0 || end > len(lines) {
+		end = len(lines)
+	}
+	if start > end {
+		return &ReadOutputResult{Handle: handle, TotalLines: len(lines), Error: fmt.Sprintf("start_line %d is after end_line %d", start, end)}, nil
+	}
+
+	var content strings.Builder
+	last := start - 1
+	for _, line := range lines[start-1 : end] {
+		if t.maxBytes > 0 && content.Len()+len(line)+1 > t.maxBytes && last >= start {
+			break
+		}
+		content.WriteString(line)
+		content.WriteString("\n")
+		last++
+	}
+	return &ReadOutputResult{Handle: handle, StartLine: start, EndLine: last, TotalLines: len(lines), Content: content.String()}, nil
+}
+
+// intArg returns an integer argument, which JSON decodes as a float64.
+func intArg(v any) int {
+	switch v := v.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+func (t *ReadOutputTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+func (t *ReadOutputTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
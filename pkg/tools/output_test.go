@@ -0,0 +1,184 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+func TestLimitOutput(t *testing.T) {
+	workDir := t.TempDir()
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	lines[49] = "2025-01-01 ERROR connection refused"
+	stdout := strings.Join(lines, "\n") + "\n"
+
+	small := &sandbox.ExecResult{Command: "kubectl logs app", Stdout: "ok\n"}
+	got, err := LimitOutput(workDir, 100, small)
+	if err != nil {
+		t.Fatalf("LimitOutput: %v", err)
+	}
+	if got != small {
+		t.Errorf("LimitOutput changed an output within the limit: %v", got)
+	}
+
+	got, err = LimitOutput(workDir, 0, stdout)
+	if err != nil || got != stdout {
+		t.Errorf("LimitOutput with no limit = %v, %v, want the output unchanged", got, err)
+	}
+
+	got, err = LimitOutput(workDir, 100, &sandbox.ExecResult{Command: "kubectl logs app", Stdout: stdout})
+	if err != nil {
+		t.Fatalf("LimitOutput: %v", err)
+	}
+	result := got.(*sandbox.ExecResult)
+	if result.Command != "kubectl logs app" {
+		t.Errorf("Command = %q, want it kept", result.Command)
+	}
+	for _, want := range []string{`handle "output-1"`, "100 lines", "1 error lines, 0 warning lines", "Error at line 50: 2025-01-01 ERROR connection refused", "line 20\n", "[... lines 21 to 80 omitted ...]", "line 81\n"} {
+		if !strings.Contains(result.Stdout, want) {
+			t.Errorf("truncated output does not contain %q:\n%s", want, result.Stdout)
+		}
+	}
+	if strings.Contains(result.Stdout, "line 21\n") {
+		t.Errorf("truncated output contains an omitted line:\n%s", result.Stdout)
+	}
+	stored, err := os.ReadFile(filepath.Join(workDir, OutputsDir, "output-1.txt"))
+	if err != nil || string(stored) != stdout {
+		t.Errorf("stored output = %q, %v, want the full output", stored, err)
+	}
+
+	got, err = LimitOutput(workDir, 100, stdout)
+	if err != nil {
+		t.Fatalf("LimitOutput: %v", err)
+	}
+	if !strings.Contains(got.(string), `handle "output-2"`) {
+		t.Errorf("second truncated output does not have the next handle:\n%s", got)
+	}
+}
+
+func TestSummarizeOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name: "list of objects",
+			output: `apiVersion: v1
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    ownerReferences:
+    - apiVersion: apps/v1
+      kind: ReplicaSet
+- apiVersion: v1
+  kind: Pod
+- apiVersion: v1
+  kind: Service
+kind: List
+`,
+			want: []string{"Kinds of objects: List (1), Pod (2), Service (1)"},
+		},
+		{
+			name:   "logs",
+			output: "starting\nWARNING: slow disk\nfailed to connect\nwarn: retrying\n",
+			want:   []string{"1 error lines, 2 warning lines", "Error at line 3: failed to connect"},
+		},
+		{
+			name:   "plain",
+			output: "NAME READY\napp 1/1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizeOutput(tt.output)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("summarizeOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadOutputTool(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workDir, OutputsDir), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, OutputsDir, "output-1.txt"), []byte("one\ntwo\nthree\nfour\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), WorkDirKey, workDir)
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		args     map[string]any
+		want     ReadOutputResult
+	}{
+		{
+			name: "range",
+			args: map[string]any{"handle": "output-1", "start_line": float64(2), "end_line": float64(3)},
+			want: ReadOutputResult{Handle: "output-1", StartLine: 2, EndLine: 3, TotalLines: 4, Content: "two\nthree\n"},
+		},
+		{
+			name: "to the end",
+			args: map[string]any{"handle": "output-1", "start_line": float64(3)},
+			want: ReadOutputResult{Handle: "output-1", StartLine: 3, EndLine: 4, TotalLines: 4, Content: "three\nfour\n"},
+		},
+		{
+			name:     "bounded",
+			maxBytes: 9,
+			args:     map[string]any{"handle": "output-1"},
+			want:     ReadOutputResult{Handle: "output-1", StartLine: 1, EndLine: 2, TotalLines: 4, Content: "one\ntwo\n"},
+		},
+		{
+			name: "unknown handle",
+			args: map[string]any{"handle": "output-2"},
+			want: ReadOutputResult{Handle: "output-2", Error: `no output with the handle "output-2"`},
+		},
+		{
+			name: "invalid handle",
+			args: map[string]any{"handle": "../secrets"},
+			want: ReadOutputResult{Handle: "../secrets", Error: `invalid handle "../secrets", expected e.g. "output-1"`},
+		},
+		{
+			name: "empty range",
+			args: map[string]any{"handle": "output-1", "start_line": float64(4), "end_line": float64(2)},
+			want: ReadOutputResult{Handle: "output-1", TotalLines: 4, Error: "start_line 4 is after end_line 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewReadOutputTool(tt.maxBytes).Run(ctx, tt.args)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if *got.(*ReadOutputResult) != tt.want {
+				t.Errorf("Run() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
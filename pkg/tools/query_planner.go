@@ -0,0 +1,249 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// ErrAPICallBudgetExhausted is returned when a run has used up its API call budget.
+var ErrAPICallBudgetExhausted = errors.New("cluster API call budget exhausted for this run")
+
+// APICallBudget caps the number of cluster API calls (kubectl invocations)
+// made during a single agent run, protecting large clusters from
+// agent-generated API storms. It is safe for concurrent use.
+type APICallBudget struct {
+	mu    sync.Mutex
+	limit int
+	used  int
+}
+
+// NewAPICallBudget creates a budget allowing limit calls. A limit <= 0 means unlimited.
+func NewAPICallBudget(limit int) *APICallBudget {
+	return &APICallBudget{limit: limit}
+}
+
+// Consume records n API calls, or returns ErrAPICallBudgetExhausted
+// (without recording anything) if that would exceed the limit.
+func (b *APICallBudget) Consume(n int) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limit > 0 && b.used+n > b.limit {
+		return fmt.Errorf("%w (%d of %d calls used); batch related queries (e.g. kubectl get pods,events,endpoints) or answer with the information gathered so far", ErrAPICallBudgetExhausted, b.used, b.limit)
+	}
+	b.used += n
+	return nil
+}
+
+// Used returns the number of API calls recorded so far.
+func (b *APICallBudget) Used() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// consumeAPICalls charges the kubectl invocations of command against the
+// API call budget of the context, if any.
+func consumeAPICalls(ctx context.Context, command string) error {
+	budget, ok := ctx.Value(APICallBudgetKey).(*APICallBudget)
+	if !ok {
+		return nil
+	}
+	n := countKubectlInvocations(command)
+	if n == 0 {
+		return nil
+	}
+	return budget.Consume(n)
+}
+
+// countKubectlInvocations returns the number of kubectl calls in a shell command.
+func countKubectlInvocations(command string) int {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		// Be conservative: count textual occurrences.
+		return strings.Count(command, "kubectl ")
+	}
+
+	count := 0
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if call, ok := node.(*syntax.CallExpr); ok && len(call.Args) > 0 {
+			if filepath.Base(call.Args[0].Lit()) == "kubectl" {
+				count++
+			}
+		}
+		return true
+	})
+	return count
+}
+
+// QueryBatch is a kubectl command that replaces one or more planned queries.
+type QueryBatch struct {
+	// Command is the command to run for the batch.
+	Command string
+	// Indexes are the positions of the batched queries in the planned commands.
+	Indexes []int
+}
+
+// kubectlValueFlags are the kubectl get flags that take a separate value argument.
+var kubectlValueFlags = map[string]bool{
+	"-n": true, "--namespace": true,
+	"-l": true, "--selector": true,
+	"-o": true, "--output": true,
+	"--context": true, "--cluster": true, "--user": true, "--kubeconfig": true,
+	"--field-selector": true, "--sort-by": true,
+	"-L": true, "--label-columns": true,
+	"--chunk-size": true, "--request-timeout": true,
+}
+
+// PlanKubectlQueries groups related "kubectl get" commands into batches that
+// can be served by a single invocation, e.g. "kubectl get pods -n x -o json"
+// and "kubectl get events -n x -o json" become
+// "kubectl get pods,events -n x -o json". Two commands are related if they list
+// resources without naming objects and use exactly the same flags.
+// Commands that cannot be batched are returned as single-command batches.
+// Batches are returned in the order of their first command.
+func PlanKubectlQueries(commands []string) []QueryBatch {
+	var batches []QueryBatch
+	byFlags := make(map[string]int)
+	var resources [][]string
+
+	for i, command := range commands {
+		resource, flags, ok := parseBatchableGet(command)
+		if !ok {
+			batches = append(batches, QueryBatch{Command: command, Indexes: []int{i}})
+			resources = append(resources, nil)
+			continue
+		}
+
+		key := strings.Join(flags, " ")
+		if j, exists := byFlags[key]; exists {
+			batches[j].Indexes = append(batches[j].Indexes, i)
+			resources[j] = append(resources[j], resource)
+			continue
+		}
+		byFlags[key] = len(batches)
+		batches = append(batches, QueryBatch{Command: command, Indexes: []int{i}})
+		resources = append(resources, []string{resource})
+	}
+
+	for flags, j := range byFlags {
+		if len(batches[j].Indexes) < 2 {
+			continue
+		}
+		command := "kubectl get " + strings.Join(dedupe(resources[j]), ",")
+		if flags != "" {
+			command += " " + flags
+		}
+		batches[j].Command = command
+	}
+	return batches
+}
+
+// parseBatchableGet parses a simple "kubectl get <resources> [flags]" command,
+// returning the resource list and the flags.
+func parseBatchableGet(command string) (resource string, flags []string, ok bool) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil || len(file.Stmts) != 1 {
+		return "", nil, false
+	}
+	stmt := file.Stmts[0]
+	if stmt.Background || stmt.Negated || len(stmt.Redirs) > 0 {
+		return "", nil, false
+	}
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Assigns) > 0 || len(call.Args) < 3 {
+		return "", nil, false
+	}
+
+	var args []string
+	for _, word := range call.Args {
+		// Only literal words can be safely re-assembled into a new command.
+		lit := word.Lit()
+		if lit == "" {
+			return "", nil, false
+		}
+		args = append(args, lit)
+	}
+	if filepath.Base(args[0]) != "kubectl" || args[1] != "get" {
+		return "", nil, false
+	}
+
+	resource = args[2]
+	if strings.HasPrefix(resource, "-") || strings.Contains(resource, "/") {
+		return "", nil, false
+	}
+
+	rest := args[3:]
+	for i := 0; i < len(rest); i++ {
+		arg := rest[i]
+		if !strings.HasPrefix(arg, "-") {
+			// A positional argument names objects, which cannot be batched.
+			return "", nil, false
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if name == "-w" || name == "--watch" || name == "--watch-only" {
+			return "", nil, false
+		}
+		if kubectlValueFlags[name] && !hasValue {
+			if i+1 >= len(rest) {
+				return "", nil, false
+			}
+			i++
+			value, hasValue = rest[i], true
+			arg = name + " " + value
+		}
+		if (name == "-o" || name == "--output") && hasValue && !batchableOutputFormat(value) {
+			return "", nil, false
+		}
+		flags = append(flags, arg)
+	}
+	return resource, flags, true
+}
+
+// batchableOutputFormat returns true if the output format still makes sense
+// when listing several resource types at once.
+func batchableOutputFormat(format string) bool {
+	switch format {
+	case "json", "yaml", "wide", "name":
+		return true
+	}
+	return false
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPlanKubectlQueries(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		want     []QueryBatch
+	}{
+		{
+			name: "related gets are batched",
+			commands: []string{
+				"kubectl get pods -n x -o json",
+				"kubectl get events --namespace x -o json",
+				"kubectl get endpoints -n x -o json",
+			},
+			want: []QueryBatch{
+				{Command: "kubectl get pods,endpoints -n x -o json", Indexes: []int{0, 2}},
+				{Command: "kubectl get events --namespace x -o json", Indexes: []int{1}},
+			},
+		},
+		{
+			name: "named objects, pipes and jsonpath are not batched",
+			commands: []string{
+				"kubectl get pod web -n x",
+				"kubectl get pods -n x | grep web",
+				"kubectl get pods -n x -o jsonpath='{.items[*].metadata.name}'",
+				"kubectl get svc -n x",
+			},
+			want: []QueryBatch{
+				{Command: "kubectl get pod web -n x", Indexes: []int{0}},
+				{Command: "kubectl get pods -n x | grep web", Indexes: []int{1}},
+				{Command: "kubectl get pods -n x -o jsonpath='{.items[*].metadata.name}'", Indexes: []int{2}},
+				{Command: "kubectl get svc -n x", Indexes: []int{3}},
+			},
+		},
+		{
+			name: "watch and write commands are not batched",
+			commands: []string{
+				"kubectl get pods -w",
+				"kubectl delete pods --all",
+				"kubectl get pods",
+			},
+			want: []QueryBatch{
+				{Command: "kubectl get pods -w", Indexes: []int{0}},
+				{Command: "kubectl delete pods --all", Indexes: []int{1}},
+				{Command: "kubectl get pods", Indexes: []int{2}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PlanKubectlQueries(tt.commands)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlanKubectlQueries() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPICallBudget(t *testing.T) {
+	budget := NewAPICallBudget(3)
+	ctx := context.WithValue(context.Background(), APICallBudgetKey, budget)
+
+	if err := consumeAPICalls(ctx, "kubectl get pods && kubectl get svc"); err != nil {
+		t.Fatalf("consumeAPICalls() unexpected error: %v", err)
+	}
+	if err := consumeAPICalls(ctx, "echo hello"); err != nil {
+		t.Fatalf("consumeAPICalls() unexpected error: %v", err)
+	}
+	if err := consumeAPICalls(ctx, "kubectl get nodes | kubectl apply -f -"); !errors.Is(err, ErrAPICallBudgetExhausted) {
+		t.Fatalf("consumeAPICalls() error = %v, want %v", err, ErrAPICallBudgetExhausted)
+	}
+	if got := budget.Used(); got != 2 {
+		t.Errorf("Used() = %d, want 2", got)
+	}
+
+	if err := NewAPICallBudget(0).Consume(1000); err != nil {
+		t.Errorf("unlimited budget Consume() unexpected error: %v", err)
+	}
+}
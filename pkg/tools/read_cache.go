@@ -0,0 +1,144 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"k8s.io/klog/v2"
+)
+
+// cacheableVerbs are the kubectl verbs whose output is cached by the read cache.
+var cacheableVerbs = map[string]bool{
+	"get":           true,
+	"describe":      true,
+	"api-resources": true,
+	"api-versions":  true,
+	"explain":       true,
+	"version":       true,
+}
+
+// ReadCache caches the output of identical kubectl reads of a session, e.g.
+// "kubectl get ns", for a time to live. It is invalidated when resources
+// are modified. It is safe for concurrent use.
+type ReadCache struct {
+	ttl time.Duration
+	// now returns the current time, overridden by tests.
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]readCacheEntry
+	hits    int
+}
+
+type readCacheEntry struct {
+	result   *sandbox.ExecResult
+	storedAt time.Time
+}
+
+// NewReadCache creates a cache keeping the outputs for ttl.
+func NewReadCache(ttl time.Duration) *ReadCache {
+	return &ReadCache{ttl: ttl, now: time.Now, entries: make(map[string]readCacheEntry)}
+}
+
+// Invalidate drops the cached outputs, e.g. once resources were modified.
+func (c *ReadCache) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// Hits returns the number of reads served from the cache.
+func (c *ReadCache) Hits() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+func (c *ReadCache) get(key string) (*sandbox.ExecResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.now().Sub(entry.storedAt) >= c.ttl {
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.hits++
+	result := *entry.result
+	return &result, true
+}
+
+func (c *ReadCache) put(key string, result *sandbox.ExecResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stored := *result
+	c.entries[key] = readCacheEntry{result: &stored, storedAt: c.now()}
+}
+
+// readCacheKey returns the key of the output of a command in the read cache:
+// the command, the kubeconfig and the session environment. It returns "" if
+// the command is not a single kubectl read, e.g. a pipeline or a watch.
+func readCacheKey(ctx context.Context, kubeconfig, command string) string {
+	command = strings.TrimSpace(command)
+	if strings.ContainsAny(command, "|&;<>`\n") || strings.Contains(command, "$(") {
+		return ""
+	}
+	commands, err := kubectl.Parse(command)
+	if err != nil || len(commands) != 1 {
+		return ""
+	}
+	cmd := commands[0]
+	if !cacheableVerbs[cmd.Verb] || cmd.Streaming() != "" || cmd.HasFlag("-w", "--watch", "--watch-only") {
+		return ""
+	}
+	env, _ := ctx.Value(EnvKey).([]string)
+	return strings.Join(append([]string{kubeconfig, strings.Join(strings.Fields(command), " ")}, env...), "\x00")
+}
+
+// withReadCache serves a kubectl read from the read cache of the context, if
+// any, or runs it and caches its output if it succeeded.
+func withReadCache(ctx context.Context, kubeconfig, command string, run func() (*sandbox.ExecResult, error)) (*sandbox.ExecResult, error) {
+	cache, _ := ctx.Value(ReadCacheKey).(*ReadCache)
+	if cache == nil {
+		return run()
+	}
+	key := readCacheKey(ctx, kubeconfig, command)
+	if key == "" {
+		return run()
+	}
+	if result, ok := cache.get(key); ok {
+		klog.V(2).Infof("Serving %q from the read cache", command)
+		return result, nil
+	}
+	result, err := run()
+	if err == nil && result != nil && result.ExitCode == 0 && result.Error == "" && result.StreamType == "" {
+		cache.put(key, result)
+	}
+	return result, err
+}
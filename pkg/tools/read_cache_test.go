@@ -0,0 +1,107 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+func TestReadCacheKey(t *testing.T) {
+	tests := []struct {
+		command   string
+		cacheable bool
+	}{
+		{command: "kubectl get ns", cacheable: true},
+		{command: "kubectl get pods -A -o wide", cacheable: true},
+		{command: "kubectl describe deploy/web -n shop", cacheable: true},
+		{command: "kubectl get pods -w"},
+		{command: "kubectl logs web"},
+		{command: "kubectl delete pod web"},
+		{command: "kubectl get pods | grep web"},
+		{command: "kubectl get pods && kubectl get svc"},
+		{command: "kubectl get pod $(cat name)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := readCacheKey(context.Background(), "/kubeconfig", tt.command) != ""; got != tt.cacheable {
+				t.Errorf("readCacheKey(%q) cacheable = %v, want %v", tt.command, got, tt.cacheable)
+			}
+		})
+	}
+
+	ctx := context.Background()
+	if readCacheKey(ctx, "/a", "kubectl get ns") == readCacheKey(ctx, "/b", "kubectl get ns") {
+		t.Errorf("readCacheKey() is the same for two kubeconfigs")
+	}
+	if readCacheKey(ctx, "/a", "kubectl get ns") != readCacheKey(ctx, "/a", "kubectl  get   ns") {
+		t.Errorf("readCacheKey() differs with the spaces of the command")
+	}
+	if readCacheKey(ctx, "/a", "kubectl get ns") == readCacheKey(context.WithValue(ctx, EnvKey, []string{"HTTPS_PROXY=proxy:3128"}), "/a", "kubectl get ns") {
+		t.Errorf("readCacheKey() is the same for two environments")
+	}
+}
+
+func TestKubectlReadCache(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := t0
+	cache := NewReadCache(30 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	executor := &MockExecutor{}
+	tool := NewKubectlTool(executor, nil)
+	ctx := context.WithValue(context.Background(), KubeconfigKey, "/kubeconfig")
+	ctx = context.WithValue(ctx, WorkDirKey, t.TempDir())
+	ctx = context.WithValue(ctx, ReadCacheKey, cache)
+	run := func(command string) *sandbox.ExecResult {
+		t.Helper()
+		executor.CapturedCommand = ""
+		output, err := tool.Run(ctx, map[string]any{"command": command})
+		if err != nil {
+			t.Fatalf("Run(%q) error = %v", command, err)
+		}
+		return output.(*sandbox.ExecResult)
+	}
+
+	run("kubectl get ns")
+	if executor.CapturedCommand != "kubectl get ns" {
+		t.Fatalf("the first read ran %q, want it run", executor.CapturedCommand)
+	}
+	now = t0.Add(10 * time.Second)
+	if result := run("kubectl get ns"); executor.CapturedCommand != "" || result.Stdout != "executed" {
+		t.Errorf("the second read ran %q, want it served from the cache", executor.CapturedCommand)
+	}
+	if cache.Hits() != 1 {
+		t.Errorf("Hits() = %d, want 1", cache.Hits())
+	}
+
+	now = t0.Add(40 * time.Second)
+	if run("kubectl get ns"); executor.CapturedCommand != "kubectl get ns" {
+		t.Errorf("the read after the TTL ran %q, want it run", executor.CapturedCommand)
+	}
+
+	cache.Invalidate()
+	if run("kubectl get ns"); executor.CapturedCommand != "kubectl get ns" {
+		t.Errorf("the read after the invalidation ran %q, want it run", executor.CapturedCommand)
+	}
+
+	run("kubectl scale deploy/web --replicas=2")
+	if run("kubectl scale deploy/web --replicas=2"); executor.CapturedCommand == "" {
+		t.Errorf("a change was served from the cache")
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// ReadOnlyRefusal is the refusal of a command in read-only mode. It is sent
+// to the model, so that it can re-plan with read-only alternatives.
+type ReadOnlyRefusal struct {
+	Command string `json:"command"`
+	// Reason is why the command is refused, e.g. "kubectl delete modifies resources".
+	Reason string `json:"reason"`
+	// Alternatives are read-only commands that may serve the same purpose.
+	Alternatives []string `json:"alternatives,omitempty"`
+}
+
+func (r *ReadOnlyRefusal) Error() string {
+	s := fmt.Sprintf("refusing to run %q: this session is read-only and %s", r.Command, r.Reason)
+	if len(r.Alternatives) > 0 {
+		s += ". Read-only alternatives: " + strings.Join(r.Alternatives, "; ")
+	}
+	return s
+}
+
+// readOnlyPrograms are the programs that do not write files or call APIs
+// unless one of the listed flags is set.
+var readOnlyPrograms = map[string][]string{
+	"base64": nil, "cat": nil, "cd": nil, "column": nil, "cut": nil,
+	"date": nil, "dig": nil, "diff": nil, "echo": nil, "egrep": nil,
+	"fgrep": nil, "grep": nil, "head": nil, "jq": nil, "ls": nil,
+	"nslookup": nil, "printf": nil, "pwd": nil, "sort": nil, "tail": nil,
+	"test": nil, "tr": nil, "true": nil, "uniq": nil, "wc": nil,
+	"which": nil, "whoami": nil,
+	"find": {"-delete", "-exec", "-execdir", "-fprint", "-fprintf", "-fls"},
+	"sed":  {"-i", "--in-place"},
+	"yq":   {"-i", "--inplace"},
+}
+
+// readOnlyHelmCommands are the helm subcommands that only read releases and
+// charts, including "diff" of the helm-diff plugin.
+var readOnlyHelmCommands = map[string]bool{
+	"diff": true, "env": true, "get": true, "history": true, "lint": true,
+	"list": true, "ls": true, "search": true, "show": true, "status": true,
+	"template": true, "version": true,
+}
+
+// mutatingHTTPMethods are the methods of the curl requests that call mutating APIs.
+var mutatingHTTPMethods = map[string]bool{"POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+// awkWrites matches the awk programs that write files or run commands, e.g.
+// 'print > "out"' or 'system("rm x")'.
+var awkWrites = regexp.MustCompile(`\bprintf?\b[^;}]*>|\||\bsystem\s*\(`)
+
+// kubectlAlternatives are read-only kubectl commands serving the purpose of the
+// kubectl verbs modifying resources.
+var kubectlAlternatives = map[string][]string{
+	"apply":     {"kubectl diff -f <file> to preview the changes", "kubectl apply --dry-run=server -f <file> to validate them"},
+	"create":    {"kubectl create --dry-run=server -o yaml to validate the object"},
+	"replace":   {"kubectl diff -f <file> to preview the changes"},
+	"patch":     {"kubectl patch --dry-run=server -o yaml to preview the patched object"},
+	"delete":    {"kubectl get to list the objects that would be deleted"},
+	"edit":      {"kubectl get -o yaml to inspect the object"},
+	"scale":     {"kubectl get to check the current replicas", "kubectl scale --dry-run=server to validate the change"},
+	"autoscale": {"kubectl get hpa to inspect the autoscalers"},
+	"set":       {"kubectl get -o yaml to inspect the current spec"},
+	"label":     {"kubectl get --show-labels to inspect the labels"},
+	"annotate":  {"kubectl get -o yaml to inspect the annotations"},
+	"rollout":   {"kubectl rollout status or kubectl rollout history to inspect the rollout"},
+	"exec":      {"kubectl logs or kubectl describe pod to inspect the pod"},
+	"cp":        {"kubectl logs to read the output of the pod"},
+	"drain":     {"kubectl describe node and kubectl get pods --field-selector spec.nodeName=<node> to inspect the node"},
+	"cordon":    {"kubectl describe node to inspect the node"},
+	"uncordon":  {"kubectl describe node to inspect the node"},
+	"taint":     {"kubectl describe node to inspect the taints"},
+}
+
+// CheckReadOnly returns the refusal of a shell command in read-only mode, or
+// nil if it is read-only: the kubectl commands that do not modify resources,
+// the programs that neither write files nor call mutating APIs, and no output
+// redirected to files. Unknown programs are refused.
+func CheckReadOnly(command string) *ReadOnlyRefusal {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return &ReadOnlyRefusal{Command: command, Reason: fmt.Sprintf("the command cannot be parsed: %v", err)}
+	}
+
+	var refusal *ReadOnlyRefusal
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if refusal != nil {
+			return false
+		}
+		switch node := node.(type) {
+		case *syntax.Redirect:
+			if node.Word != nil {
+				refusal = checkRedirect(node.Op, node.Word.Lit())
+			}
+		case *syntax.CallExpr:
+			var args []string
+			for _, word := range node.Args {
+				args = append(args, shellWord(word))
+			}
+			refusal = checkReadOnlyArgs(args)
+		}
+		return true
+	})
+	if refusal != nil {
+		refusal.Command = command
+	}
+	return refusal
+}
+
+// checkRedirect refuses the redirections writing to files other than /dev/null.
+func checkRedirect(op syntax.RedirOperator, target string) *ReadOnlyRefusal {
+	switch op {
+	case syntax.RdrOut, syntax.AppOut, syntax.RdrAll, syntax.AppAll, syntax.ClbOut, syntax.RdrInOut:
+		if target == "/dev/null" {
+			return nil
+		}
+		return &ReadOnlyRefusal{Reason: fmt.Sprintf("it redirects output to the file %q", target)}
+	}
+	return nil
+}
+
+// checkReadOnlyArgs returns the refusal of a program run with its arguments,
+// or nil if it is read-only.
+func checkReadOnlyArgs(args []string) *ReadOnlyRefusal {
+	if len(args) == 0 {
+		return nil
+	}
+	program := filepath.Base(args[0])
+	switch {
+	case kubectl.IsKubectl(args[0]):
+		cmd := kubectl.ParseArgs(args[1:])
+		switch cmd.ModifiesResource() {
+		case "no":
+			return nil
+		case "yes":
+			verb := cmd.Verb
+			if cmd.Subcommand != "" {
+				verb += " " + cmd.Subcommand
+			}
+			return &ReadOnlyRefusal{
+				Reason:       fmt.Sprintf("kubectl %s modifies resources", verb),
+				Alternatives: kubectlAlternatives[cmd.Verb],
+			}
+		}
+		return &ReadOnlyRefusal{
+			Reason:       "the kubectl command cannot be classified as read-only",
+			Alternatives: []string{"kubectl get, describe, logs or events"},
+		}
+	case program == "helm":
+		if subcommand := firstPositional(args[1:]); !readOnlyHelmCommands[subcommand] {
+			return &ReadOnlyRefusal{
+				Reason:       fmt.Sprintf("helm %s may modify releases", subcommand),
+				Alternatives: []string{"helm list, status, get values or history to inspect the releases", "helm template to render the manifests"},
+			}
+		}
+		return nil
+	case program == "curl":
+		return checkCurl(args[1:])
+	case program == "awk":
+		// awk programs can write files and run commands.
+		for _, arg := range args[1:] {
+			if awkWrites.MatchString(arg) {
+				return &ReadOnlyRefusal{Reason: "the awk program may write files or run commands"}
+			}
+		}
+		return nil
+	}
+
+	flags, ok := readOnlyPrograms[program]
+	if !ok {
+		return &ReadOnlyRefusal{Reason: fmt.Sprintf("%s is not known to be read-only", program)}
+	}
+	for _, arg := range args[1:] {
+		name, _, _ := strings.Cut(arg, "=")
+		if slices.Contains(flags, name) || (strings.HasPrefix(name, "-i") && slices.Contains(flags, "-i")) {
+			return &ReadOnlyRefusal{Reason: fmt.Sprintf("%s %s writes files", program, arg)}
+		}
+	}
+	return nil
+}
+
+// checkCurl refuses the curl requests with a mutating method, a body, or an output file.
+func checkCurl(args []string) *ReadOnlyRefusal {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch {
+		case name == "-X" || name == "--request":
+			if !hasValue && i+1 < len(args) {
+				value = args[i+1]
+			}
+		case strings.HasPrefix(arg, "-X"):
+			value = arg[len("-X"):]
+		case name == "-d" || strings.HasPrefix(name, "--data") || name == "-F" || name == "--form" || name == "-T" || name == "--upload-file":
+			return &ReadOnlyRefusal{Reason: fmt.Sprintf("curl %s sends data to an API", name)}
+		case name == "-o" || name == "--output" || name == "-O" || name == "--remote-name":
+			return &ReadOnlyRefusal{Reason: fmt.Sprintf("curl %s writes files", name)}
+		default:
+			continue
+		}
+		if mutatingHTTPMethods[strings.ToUpper(value)] {
+			return &ReadOnlyRefusal{
+				Reason:       fmt.Sprintf("curl %s calls a mutating API", strings.ToUpper(value)),
+				Alternatives: []string{"curl with a GET request"},
+			}
+		}
+	}
+	return nil
+}
+
+// firstPositional returns the first argument that is not a flag, e.g. the
+// subcommand of "helm -a list". Flag values are not skipped, the subcommand of
+// "helm -n prod list" is "prod".
+func firstPositional(args []string) string {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return ""
+}
+
+// shellWord returns a shell word as written, without its surrounding quotes.
+func shellWord(word *syntax.Word) string {
+	if lit := word.Lit(); lit != "" {
+		return lit
+	}
+	// e.g. 'python3 -c "print(1)"', whose value ends with a quote.
+	if len(word.Parts) == 1 {
+		if quoted, ok := word.Parts[0].(*syntax.SglQuoted); ok {
+			return quoted.Value
+		}
+	}
+	var sb strings.Builder
+	syntax.NewPrinter().Print(&sb, word)
+	return strings.Trim(sb.String(), "'\"")
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import "testing"
+
+func TestCheckReadOnly(t *testing.T) {
+	tests := []struct {
+		command string
+		// reason is the expected reason of the refusal, empty if the command is read-only.
+		reason string
+	}{
+		{command: "kubectl get pods -n prod"},
+		{command: "kubectl get pods -o json | jq '.items[].metadata.name' | sort"},
+		{command: "kubectl logs web-0 2>&1 | grep -i error | tail -n 20"},
+		{command: "kubectl apply -f app.yaml --dry-run=server"},
+		{command: "kubectl get pods > /dev/null"},
+		{command: "helm list -A"},
+		{command: "curl -s https://example.com/healthz"},
+		{command: "awk '$3 > 5 { print $1 }' nodes.txt"},
+		{command: "kubectl delete pod web-0", reason: "kubectl delete modifies resources"},
+		{command: "kubectl rollout restart deploy/web", reason: "kubectl rollout restart modifies resources"},
+		{command: "kubectl get pods && kubectl scale deploy/web --replicas=0", reason: "kubectl scale modifies resources"},
+		{command: "kubectl get pods -o yaml > pods.yaml", reason: `it redirects output to the file "pods.yaml"`},
+		{command: "sed -i 's/a/b/' app.yaml", reason: "sed -i writes files"},
+		{command: "find . -name '*.log' -delete", reason: "find -delete writes files"},
+		{command: "rm -rf /tmp/cache", reason: "rm is not known to be read-only"},
+		{command: "helm upgrade web ./chart", reason: "helm upgrade may modify releases"},
+		{command: "curl -X POST https://example.com/api", reason: "curl POST calls a mutating API"},
+		{command: "curl -d '{}' https://example.com/api", reason: "curl -d sends data to an API"},
+		{command: "awk '{ print > \"out.txt\" }' nodes.txt", reason: "the awk program may write files or run commands"},
+		{command: "echo $(kubectl delete ns prod)", reason: "kubectl delete modifies resources"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			refusal := CheckReadOnly(tt.command)
+			switch {
+			case tt.reason == "" && refusal != nil:
+				t.Errorf("CheckReadOnly(%q) = %q, want read-only", tt.command, refusal.Reason)
+			case tt.reason != "" && refusal == nil:
+				t.Errorf("CheckReadOnly(%q) = nil, want %q", tt.command, tt.reason)
+			case tt.reason != "" && refusal.Reason != tt.reason:
+				t.Errorf("CheckReadOnly(%q) = %q, want %q", tt.command, refusal.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestReadOnlyRefusalAlternatives(t *testing.T) {
+	refusal := CheckReadOnly("kubectl delete pod web-0")
+	if refusal == nil || len(refusal.Alternatives) == 0 {
+		t.Fatalf("CheckReadOnly() = %+v, want read-only alternatives", refusal)
+	}
+	if refusal.Command != "kubectl delete pod web-0" {
+		t.Errorf("Command = %q, want the refused command", refusal.Command)
+	}
+}
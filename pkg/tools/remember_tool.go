@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+)
+
+const (
+	// memoryHeader starts a new memory file.
+	memoryHeader = "# kubectl-ai memory\n\nFacts about this cluster remembered across sessions. You can edit this file.\n\n"
+	// maxMemorySize bounds the memory loaded into the prompt, the most recent facts are kept.
+	maxMemorySize = 16 * 1024
+)
+
+// RememberTool appends facts about the cluster to the long-term memory file,
+// which is loaded into the prompt of future sessions.
+type RememberTool struct {
+	path string
+}
+
+func NewRememberTool(path string) *RememberTool {
+	return &RememberTool{path: path}
+}
+
+func (t *RememberTool) Name() string {
+	return "remember"
+}
+
+func (t *RememberTool) Description() string {
+	return `Saves a durable fact about this cluster to the long-term memory, which is loaded into future sessions.
+Use it for cluster-specific quirks the user would otherwise have to explain again, e.g. "the ingress controller runs in namespace ingress-system" or "prod nodes are tainted with dedicated=prod".
+Only remember facts that will still hold in later sessions, one short sentence per fact. Never remember secrets or credentials.`
+}
+
+func (t *RememberTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"fact": {
+					Type:        gollm.TypeString,
+					Description: `The fact to remember, as one short sentence.`,
+				},
+			},
+			Required: []string{"fact"},
+		},
+	}
+}
+
+// RememberResult is the result of the remember tool.
+type RememberResult struct {
+	Fact   string `json:"fact,omitempty"`
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (t *RememberTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	fact, _ := args["fact"].(string)
+	fact = strings.Join(strings.Fields(fact), " ")
+	if fact == "" {
+		return &RememberResult{Error: "fact must be provided"}, nil
+	}
+	result := &RememberResult{Fact: fact}
+
+	memory, err := os.ReadFile(t.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading memory file: %w", err)
+	}
+	entry := "- " + fact + "\n"
+	if strings.Contains(string(memory), entry) {
+		result.Status = "already remembered"
+		return result, nil
+	}
+	if len(memory) == 0 {
+		entry = memoryHeader + entry
+	} else if !strings.HasSuffix(string(memory), "\n") {
+		entry = "\n" + entry
+	}
+
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o700); err != nil {
+		return nil, fmt.Errorf("creating memory directory: %w", err)
+	}
+	f, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening memory file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(entry); err != nil {
+		return nil, fmt.Errorf("writing memory file: %w", err)
+	}
+	result.Status = "remembered"
+	return result, nil
+}
+
+func (t *RememberTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "yes", so that the user approves what is remembered.
+func (t *RememberTool) CheckModifiesResource(args map[string]any) string {
+	return "yes"
+}
+
+// LoadMemory returns the content of the long-term memory file, or "" if it does not exist yet.
+func LoadMemory(path string) (string, error) {
+	memory, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading memory file: %w", err)
+	}
+	if len(memory) > maxMemorySize {
+		memory = memory[len(memory)-maxMemorySize:]
+		// Drop the partial first line.
+		if i := strings.IndexByte(string(memory), '\n'); i >= 0 {
+			memory = memory[i+1:]
+		}
+	}
+	return strings.TrimSpace(string(memory)), nil
+}
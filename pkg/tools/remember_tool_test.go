@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRememberTool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory", "prod.md")
+	tool := NewRememberTool(path)
+
+	memory, err := LoadMemory(path)
+	if err != nil || memory != "" {
+		t.Fatalf("LoadMemory() = %q, %v before remembering anything, want empty", memory, err)
+	}
+
+	for _, fact := range []string{"The ingress controller runs in namespace ingress-system.", "Prod nodes are\ntainted with dedicated=prod."} {
+		result, err := tool.Run(context.Background(), map[string]any{"fact": fact})
+		if err != nil {
+			t.Fatalf("Run() error: %v", err)
+		}
+		if status := result.(*RememberResult).Status; status != "remembered" {
+			t.Errorf("Run() status = %q, want remembered", status)
+		}
+	}
+	result, err := tool.Run(context.Background(), map[string]any{"fact": "The ingress controller runs in namespace ingress-system."})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if status := result.(*RememberResult).Status; status != "already remembered" {
+		t.Errorf("Run() status = %q for a known fact, want already remembered", status)
+	}
+	result, _ = tool.Run(context.Background(), map[string]any{"fact": "  "})
+	if result.(*RememberResult).Error == "" {
+		t.Errorf("Run() expected an error for an empty fact")
+	}
+
+	memory, err = LoadMemory(path)
+	if err != nil {
+		t.Fatalf("LoadMemory() error: %v", err)
+	}
+	for _, want := range []string{
+		"- The ingress controller runs in namespace ingress-system.\n- Prod nodes are tainted with dedicated=prod.",
+		"# kubectl-ai memory",
+	} {
+		if !strings.Contains(memory, want) {
+			t.Errorf("LoadMemory() = %q, want it to contain %q", memory, want)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("memory file should only be readable by the user, got %v", info.Mode())
+	}
+}
+
+func TestLoadMemoryKeepsRecentFacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.md")
+	content := strings.Repeat("- an old fact\n", maxMemorySize/10) + "- the most recent fact\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	memory, err := LoadMemory(path)
+	if err != nil {
+		t.Fatalf("LoadMemory() error: %v", err)
+	}
+	if len(memory) > maxMemorySize {
+		t.Errorf("LoadMemory() returned %d bytes, want at most %d", len(memory), maxMemorySize)
+	}
+	if !strings.HasPrefix(memory, "- an old fact") || !strings.HasSuffix(memory, "- the most recent fact") {
+		t.Errorf("LoadMemory() should keep whole lines and the most recent facts")
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/gollm"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// maxStructureDepth bounds the nesting of the fields of a structure summary.
+	maxStructureDepth = 4
+	// maxStructureItems bounds the items of a list described in a summary.
+	maxStructureItems = 3
+	// maxStructureLines bounds the lines of a structure summary.
+	maxStructureLines = 80
+	// maxInlineValue bounds the length of the values quoted in a summary.
+	maxInlineValue = 80
+)
+
+// parseStructure parses an output holding a single object, e.g. of
+// "kubectl get configmap big -o yaml" or of "kubectl get -o jsonpath={.spec}".
+// Lists, multi-document YAML and other outputs are not parsed.
+func parseStructure(output string) (map[string]any, bool) {
+	trimmed := strings.TrimSpace(output)
+	var object map[string]any
+	if strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal([]byte(trimmed), &object); err != nil {
+			return nil, false
+		}
+	} else {
+		if strings.Contains(trimmed, "\n---") {
+			return nil, false
+		}
+		// Only Kubernetes objects are parsed, most YAML-like outputs are not YAML.
+		if err := yaml.Unmarshal([]byte(trimmed), &object); err != nil || object["apiVersion"] == nil {
+			return nil, false
+		}
+	}
+	if kind, _ := object["kind"].(string); strings.HasSuffix(kind, "List") {
+		return nil, false
+	}
+	return object, true
+}
+
+// objectName returns the kind, name and namespace of a Kubernetes object, e.g.
+// "ConfigMap app-config in namespace shop", or "" if it is not an object.
+func objectName(object map[string]any) string {
+	kind, _ := object["kind"].(string)
+	metadata, _ := object["metadata"].(map[string]any)
+	name, _ := metadata["name"].(string)
+	if kind == "" || name == "" {
+		return ""
+	}
+	if namespace, _ := metadata["namespace"].(string); namespace != "" {
+		return fmt.Sprintf("%s %s in namespace %s", kind, name, namespace)
+	}
+	return kind + " " + name
+}
+
+// summarizeStructure returns the structure of a large object, with the sizes
+// of its fields and the checksums of its large values, e.g.
+// "data.config\.yaml: string, 1048576 bytes, 20000 lines, sha256:1f2e3d4c5b6a".
+// The fields are given as JSONPath expressions for get_resource_field.
+func summarizeStructure(object map[string]any) []string {
+	var lines []string
+	describeFields(&lines, "", object, 1)
+	if len(lines) > maxStructureLines {
+		omitted := len(lines) - maxStructureLines
+		lines = append(lines[:maxStructureLines], fmt.Sprintf("... %d more fields", omitted))
+	}
+	return lines
+}
+
+// describeFields appends the description of the fields of a map to lines.
+func describeFields(lines *[]string, path string, fields map[string]any, depth int) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	indent := strings.Repeat("  ", depth-1)
+	for _, key := range keys {
+		describeValue(lines, indent, path+"."+jsonPathKey(key), fields[key], depth)
+	}
+}
+
+// describeValue appends the description of a value, and of its fields if it
+// is not nested too deeply, to lines.
+func describeValue(lines *[]string, indent, path string, value any, depth int) {
+	switch value := value.(type) {
+	case map[string]any:
+		*lines = append(*lines, fmt.Sprintf("%s%s: map, %d keys, %d bytes", indent, path, len(value), jsonSize(value)))
+		if depth < maxStructureDepth {
+			describeFields(lines, path, value, depth+1)
+		}
+	case []any:
+		*lines = append(*lines, fmt.Sprintf("%s%s: list, %d items, %d bytes", indent, path, len(value), jsonSize(value)))
+		if depth < maxStructureDepth {
+			for i, item := range value[:min(len(value), maxStructureItems)] {
+				describeValue(lines, indent+"  ", fmt.Sprintf("%s[%d]", path, i), item, depth+1)
+			}
+			if len(value) > maxStructureItems {
+				*lines = append(*lines, fmt.Sprintf("%s  ... %d more items", indent, len(value)-maxStructureItems))
+			}
+		}
+	case string:
+		if len(value) <= maxInlineValue && !strings.Contains(value, "\n") {
+			*lines = append(*lines, fmt.Sprintf("%s%s: %q", indent, path, value))
+			return
+		}
+		sum := sha256.Sum256([]byte(value))
+		*lines = append(*lines, fmt.Sprintf("%s%s: string, %d bytes, %d lines, sha256:%s", indent, path, len(value), strings.Count(strings.TrimSuffix(value, "\n"), "\n")+1, hex.EncodeToString(sum[:6])))
+	default:
+		b, _ := json.Marshal(value)
+		*lines = append(*lines, fmt.Sprintf("%s%s: %s", indent, path, b))
+	}
+}
+
+// jsonPathKey escapes the dots of a key of a JSONPath expression, e.g.
+// "config\.yaml".
+func jsonPathKey(key string) string {
+	return strings.ReplaceAll(key, ".", `\.`)
+}
+
+// jsonSize returns the size of a value encoded as JSON.
+func jsonSize(value any) int {
+	b, _ := json.Marshal(value)
+	return len(b)
+}
+
+// GetResourceFieldTool fetches fields of a resource by JSONPath, so that the
+// model reads the parts of a large object it needs rather than all of it.
+type GetResourceFieldTool struct {
+	executor sandbox.Executor
+	// policy decides which commands may run, nil if there is no kubectl policy.
+	policy *KubectlPolicy
+}
+
+func NewGetResourceFieldTool(executor sandbox.Executor, policy *KubectlPolicy) *GetResourceFieldTool {
+	return &GetResourceFieldTool{executor: executor, policy: policy}
+}
+
+func (t *GetResourceFieldTool) Name() string {
+	return "get_resource_field"
+}
+
+func (t *GetResourceFieldTool) Description() string {
+	return `Fetches a field of a Kubernetes resource by JSONPath, e.g. the "config.yaml" key of a large ConfigMap or the schema of a version of a CRD.
+Use it to read the parts you need of resources too large to be read whole, using the fields listed in their structure summary.`
+}
+
+func (t *GetResourceFieldTool) FunctionDefinition() *gollm.FunctionDefinition {
+	return &gollm.FunctionDefinition{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &gollm.Schema{
+			Type: gollm.TypeObject,
+			Properties: map[string]*gollm.Schema{
+				"resource": {
+					Type:        gollm.TypeString,
+					Description: `The resource, as kind/name, e.g. "configmap/app-config" or "crd/certificates.cert-manager.io".`,
+				},
+				"namespace": {
+					Type:        gollm.TypeString,
+					Description: `The namespace of the resource. Defaults to the current namespace.`,
+				},
+				"field": {
+					Type:        gollm.TypeString,
+					Description: `The JSONPath of the field, with the dots of the keys escaped, e.g. ".data.config\.yaml" or ".spec.versions[0].schema".`,
+				},
+			},
+			Required: []string{"resource", "field"},
+		},
+	}
+}
+
+func (t *GetResourceFieldTool) Run(ctx context.Context, args map[string]any) (any, error) {
+	kubeconfig := ctx.Value(KubeconfigKey).(string)
+	workDir := ctx.Value(WorkDirKey).(string)
+
+	resource, _ := args["resource"].(string)
+	namespace, _ := args["namespace"].(string)
+	field, _ := args["field"].(string)
+
+	resource = strings.TrimSpace(resource)
+	if kind, name, ok := strings.Cut(resource, "/"); !ok || kind == "" || name == "" || strings.HasPrefix(resource, "-") {
+		return &sandbox.ExecResult{Error: fmt.Sprintf("invalid resource %q, expected kind/name, e.g. \"configmap/app-config\"", resource)}, nil
+	}
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return &sandbox.ExecResult{Error: "missing field, e.g. \".data.config\\.yaml\""}, nil
+	}
+	if !strings.HasPrefix(field, "{") {
+		field = "{" + field + "}"
+	}
+
+	command := "kubectl get " + ShellQuote(resource)
+	if namespace != "" {
+		command += " --namespace " + ShellQuote(namespace)
+	}
+	command += " -o " + ShellQuote("jsonpath="+field)
+	if denial := t.policy.denial(command, kubeconfig); denial != "" {
+		return &sandbox.ExecResult{Command: command, Error: denial}, nil
+	}
+
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := consumeAPICalls(ctx, command); err != nil {
+		return nil, err
+	}
+	return t.executor.Execute(ctx, command, env, workDir)
+}
+
+func (t *GetResourceFieldTool) IsInteractive(args map[string]any) (bool, error) {
+	return false, nil
+}
+
+// CheckModifiesResource always returns "no", the get_resource_field tool only
+// reads resources.
+func (t *GetResourceFieldTool) CheckModifiesResource(args map[string]any) string {
+	return "no"
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+)
+
+func TestParseStructure(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   bool
+	}{
+		{name: "yaml object", output: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n", want: true},
+		{name: "json object", output: `{"apiVersion": "v1", "kind": "ConfigMap"}`, want: true},
+		{name: "json field", output: `{"replicas": 3}`, want: true},
+		{name: "yaml list", output: "apiVersion: v1\nkind: List\nitems: []\n", want: false},
+		{name: "json list", output: `{"apiVersion": "v1", "kind": "List", "items": []}`, want: false},
+		{name: "multiple documents", output: "apiVersion: v1\nkind: Pod\n---\napiVersion: v1\nkind: Pod\n", want: false},
+		{name: "logs", output: "level: info\nmsg: started\n", want: false},
+		{name: "table", output: "NAME   READY\nweb    1/1\n", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := parseStructure(tt.output); got != tt.want {
+				t.Errorf("parseStructure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeStructure(t *testing.T) {
+	object, ok := parseStructure(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+  namespace: shop
+data:
+  config.yaml: |
+    server:
+      port: 8080
+  mode: fast
+`)
+	if !ok {
+		t.Fatal("parseStructure() failed")
+	}
+	if got, want := objectName(object), "ConfigMap app-config in namespace shop"; got != want {
+		t.Errorf("objectName() = %q, want %q", got, want)
+	}
+	sum := sha256.Sum256([]byte("server:\n  port: 8080\n"))
+	want := []string{
+		`.apiVersion: "v1"`,
+		`.data: map, 2 keys, 55 bytes`,
+		`  .data.config\.yaml: string, 21 bytes, 2 lines, sha256:` + hex.EncodeToString(sum[:6]),
+		`  .data.mode: "fast"`,
+		`.kind: "ConfigMap"`,
+		`.metadata: map, 2 keys, 40 bytes`,
+		`  .metadata.name: "app-config"`,
+		`  .metadata.namespace: "shop"`,
+	}
+	got := summarizeStructure(object)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeStructure() = %q, want %q", got, want)
+	}
+
+	var items []any
+	for i := 0; i < 5; i++ {
+		items = append(items, map[string]any{"name": "v"})
+	}
+	got = summarizeStructure(map[string]any{"versions": items})
+	want = []string{
+		`.versions: list, 5 items, 66 bytes`,
+		`  .versions[0]: map, 1 keys, 12 bytes`,
+		`    .versions[0].name: "v"`,
+		`  .versions[1]: map, 1 keys, 12 bytes`,
+		`    .versions[1].name: "v"`,
+		`  .versions[2]: map, 1 keys, 12 bytes`,
+		`    .versions[2].name: "v"`,
+		`  ... 2 more items`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeStructure() = %q, want %q", got, want)
+	}
+}
+
+func TestLimitOutputStructure(t *testing.T) {
+	output := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: big\ndata:\n  blob: " + strings.Repeat("x", 1000) + "\n"
+	got, err := LimitOutput(t.TempDir(), 100, output)
+	if err != nil {
+		t.Fatalf("LimitOutput: %v", err)
+	}
+	for _, want := range []string{`handle "output-1"`, "get_resource_field", "[ConfigMap big]", ".data.blob: string, 1000 bytes, 1 lines, sha256:"} {
+		if !strings.Contains(got.(string), want) {
+			t.Errorf("truncated output does not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got.(string), "xxxx") {
+		t.Errorf("truncated output contains the large value:\n%s", got)
+	}
+}
+
+func TestGetResourceFieldTool(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        map[string]any
+		wantCommand string
+		wantError   string
+	}{
+		{
+			name:        "namespaced",
+			args:        map[string]any{"resource": "configmap/app-config", "namespace": "shop", "field": `.data.config\.yaml`},
+			wantCommand: `kubectl get 'configmap/app-config' --namespace 'shop' -o 'jsonpath={.data.config\.yaml}'`,
+		},
+		{
+			name:        "template",
+			args:        map[string]any{"resource": "crd/certificates.cert-manager.io", "field": "{.spec.versions[*].name}"},
+			wantCommand: `kubectl get 'crd/certificates.cert-manager.io' -o 'jsonpath={.spec.versions[*].name}'`,
+		},
+		{
+			name:      "no name",
+			args:      map[string]any{"resource": "configmap", "field": ".data"},
+			wantError: "invalid resource",
+		},
+		{
+			name:      "no field",
+			args:      map[string]any{"resource": "configmap/app-config"},
+			wantError: "missing field",
+		},
+		{
+			name:      "denied",
+			args:      map[string]any{"resource": "secret/db-credentials", "field": ".data.password"},
+			wantError: "denied by the kubectl policy",
+		},
+	}
+	policy := &KubectlPolicy{Rules: []KubectlPolicyRule{{Resources: []string{"secrets"}, Action: KubectlPolicyDeny}}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &MockExecutor{}
+			ctx := context.WithValue(context.Background(), KubeconfigKey, "")
+			ctx = context.WithValue(ctx, WorkDirKey, t.TempDir())
+			got, err := NewGetResourceFieldTool(executor, policy).Run(ctx, tt.args)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			result := got.(*sandbox.ExecResult)
+			if tt.wantError != "" {
+				if !strings.Contains(result.Error, tt.wantError) || executor.CapturedCommand != "" {
+					t.Errorf("Error = %q, command = %q, want %q and no command", result.Error, executor.CapturedCommand, tt.wantError)
+				}
+				return
+			}
+			if executor.CapturedCommand != tt.wantCommand {
+				t.Errorf("command = %q, want %q", executor.CapturedCommand, tt.wantCommand)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package tools
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
@@ -47,7 +48,20 @@ func ExecuteWithStreamingHandling(ctx context.Context, executor sandbox.Executor
 		cancel = func() {} // No-op cancel
 	}
 
-	result, err := executor.Execute(cmdCtx, command, env, workDir)
+	var result *sandbox.ExecResult
+	var err error
+	progress, _ := ctx.Value(ProgressKey).(io.Writer)
+	prompter, _ := ctx.Value(PrompterKey).(sandbox.Prompter)
+	interactiveExecutor, isInteractive := executor.(sandbox.InteractiveExecutor)
+	progressExecutor, isProgress := executor.(sandbox.ProgressExecutor)
+	switch {
+	case isInteractive && prompter != nil:
+		result, err = interactiveExecutor.ExecuteInteractive(cmdCtx, command, env, workDir, progress, prompter)
+	case isProgress && progress != nil:
+		result, err = progressExecutor.ExecuteWithProgress(cmdCtx, command, env, workDir, progress)
+	default:
+		result, err = executor.Execute(cmdCtx, command, env, workDir)
+	}
 
 	// If executor returns nil result on error (it shouldn't, but let's be safe), create one
 	if result == nil {
@@ -67,5 +81,10 @@ func ExecuteWithStreamingHandling(ctx context.Context, executor sandbox.Executor
 		}
 	}
 
+	// Failures to authenticate are reported as such, so that the model does
+	// not retry the command or work around them.
+	if authErr := DetectKubeAuthError(result); authErr != nil {
+		result.Error = authErr.Error()
+	}
 	return result, err
 }
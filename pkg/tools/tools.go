@@ -18,8 +18,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"slices"
@@ -28,6 +30,7 @@ import (
 	"time"
 
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/journal"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/kubectl"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
 	"github.com/google/uuid"
 	"sigs.k8s.io/yaml"
@@ -39,6 +42,17 @@ const (
 	KubeconfigKey ContextKey = "kubeconfig"
 	WorkDirKey    ContextKey = "work_dir"
 	ExecutorKey   ContextKey = "executor"
+	// EnvKey holds session-scoped environment variables ("KEY=VALUE")
+	// that are injected into every tool subprocess.
+	EnvKey ContextKey = "env"
+	// APICallBudgetKey holds the *APICallBudget of the current agent run.
+	APICallBudgetKey ContextKey = "api_call_budget"
+	// PrompterKey holds the sandbox.Prompter answering the prompts of tool subprocesses.
+	PrompterKey ContextKey = "prompter"
+	// LastResultKey holds the full result of the previous tool call, read by the extract tool.
+	LastResultKey ContextKey = "last_result"
+	// ReadCacheKey holds the *ReadCache of the session, serving identical kubectl reads.
+	ReadCacheKey ContextKey = "read_cache"
 )
 
 func Lookup(name string) Tool {
@@ -98,6 +112,38 @@ func (t *Tools) RegisterTool(tool Tool) {
 	t.tools[name] = tool
 }
 
+// Select keeps the tools whose name matches one of enabled, or all of them if
+// enabled is empty, and none of disabled. The names are shell patterns, e.g.
+// "gdrive_*", and the tools of an MCP server also match the name of their
+// server, e.g. "gdrive".
+func (t *Tools) Select(enabled, disabled []string) error {
+	for _, pattern := range slices.Concat(enabled, disabled) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid tool pattern %q: %w", pattern, err)
+		}
+	}
+	for name, tool := range t.tools {
+		if (len(enabled) > 0 && !matchesToolName(enabled, name, tool)) || matchesToolName(disabled, name, tool) {
+			delete(t.tools, name)
+		}
+	}
+	return nil
+}
+
+// matchesToolName returns true if a pattern matches the name of a tool, or
+// the name of its MCP server.
+func matchesToolName(patterns []string, name string, tool Tool) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if mcpTool, ok := tool.(*MCPTool); ok && pattern == mcpTool.ServerName() {
+			return true
+		}
+	}
+	return false
+}
+
 // CloneWithExecutor creates a shallow copy of the Tools collection,
 // but clones any tools that need a session-specific executor (like CustomTool).
 func (t *Tools) CloneWithExecutor(executor sandbox.Executor) Tools {
@@ -161,6 +207,10 @@ func (t *Tools) ParseToolInvocation(ctx context.Context, name string, arguments
 		return nil, fmt.Errorf("tool %q not recognized", name)
 	}
 
+	if renderer, ok := tool.(CommandRenderer); ok {
+		arguments = renderedArguments(tool, renderer, arguments)
+	}
+
 	return &ToolCall{
 		tool:      tool,
 		name:      name,
@@ -176,12 +226,29 @@ type InvokeToolOptions struct {
 
 	// Executor is the executor for tool execution
 	Executor sandbox.Executor
+
+	// Env holds additional environment variables ("KEY=VALUE") for tool subprocesses.
+	Env []string
+
+	// APICallBudget caps the cluster API calls made during the current run.
+	APICallBudget *APICallBudget
+
+	// Prompter asks the user to answer the prompts of the tool subprocesses waiting for input.
+	Prompter sandbox.Prompter
+
+	// LastResult is the full result of the previous tool call, before it was truncated.
+	LastResult any
+
+	// ReadCache, if set, serves identical kubectl reads of the session.
+	ReadCache *ReadCache
 }
 
 type ToolRequestEvent struct {
 	CallID    string         `json:"id,omitempty"`
 	Name      string         `json:"name,omitempty"`
 	Arguments map[string]any `json:"arguments,omitempty"`
+	// Kubectl are the parsed kubectl calls of the command of the tool, for auditing.
+	Kubectl []*kubectl.Command `json:"kubectl,omitempty"`
 }
 
 type ToolResponseEvent struct {
@@ -190,6 +257,16 @@ type ToolResponseEvent struct {
 	Error    string `json:"error,omitempty"`
 }
 
+// parseKubectlCalls returns the kubectl calls of the command argument of a tool call, if any.
+func parseKubectlCalls(args map[string]any) []*kubectl.Command {
+	command, ok := args["command"].(string)
+	if !ok {
+		return nil
+	}
+	commands, _ := kubectl.Parse(command)
+	return commands
+}
+
 // InvokeTool handles the execution of a single action
 func (t *ToolCall) InvokeTool(ctx context.Context, opt InvokeToolOptions) (any, error) {
 	recorder := journal.RecorderFromContext(ctx)
@@ -202,6 +279,7 @@ func (t *ToolCall) InvokeTool(ctx context.Context, opt InvokeToolOptions) (any,
 			CallID:    callID,
 			Name:      t.name,
 			Arguments: t.arguments,
+			Kubectl:   parseKubectlCalls(t.arguments),
 		},
 	})
 
@@ -210,6 +288,21 @@ func (t *ToolCall) InvokeTool(ctx context.Context, opt InvokeToolOptions) (any,
 	if opt.Executor != nil {
 		ctx = context.WithValue(ctx, ExecutorKey, opt.Executor)
 	}
+	if len(opt.Env) > 0 {
+		ctx = context.WithValue(ctx, EnvKey, opt.Env)
+	}
+	if opt.APICallBudget != nil {
+		ctx = context.WithValue(ctx, APICallBudgetKey, opt.APICallBudget)
+	}
+	if opt.Prompter != nil {
+		ctx = context.WithValue(ctx, PrompterKey, opt.Prompter)
+	}
+	if opt.LastResult != nil {
+		ctx = context.WithValue(ctx, LastResultKey, opt.LastResult)
+	}
+	if opt.ReadCache != nil {
+		ctx = context.WithValue(ctx, ReadCacheKey, opt.ReadCache)
+	}
 
 	response, err := t.tool.Run(ctx, t.arguments)
 
@@ -231,6 +324,20 @@ func (t *ToolCall) InvokeTool(ctx context.Context, opt InvokeToolOptions) (any,
 	return response, err
 }
 
+// InvokeToolAsync invokes the tool in the background if it is an AsyncTool and
+// this invocation should run asynchronously. It returns false otherwise, and the
+// tool must be invoked with InvokeTool.
+func (t *ToolCall) InvokeToolAsync(ctx context.Context, opt InvokeToolOptions) (*AsyncHandle, bool) {
+	asyncTool, ok := t.tool.(AsyncTool)
+	if !ok || !asyncTool.IsAsync(t.arguments) {
+		return nil, false
+	}
+	handle := StartAsync(ctx, func(ctx context.Context, progress io.Writer) (any, error) {
+		return t.InvokeTool(context.WithValue(ctx, ProgressKey, progress), opt)
+	})
+	return handle, true
+}
+
 // ToolResultToMap converts an arbitrary result to a map[string]any
 func ToolResultToMap(result any) (map[string]any, error) {
 	// Handle simple string results (common with MCP tools)
@@ -327,6 +434,12 @@ func (t *ToolCall) GetTool() Tool {
 	return t.tool
 }
 
+// Arguments returns the arguments of the call. The structured arguments of
+// the tools rendering their command are replaced by the command.
+func (t *ToolCall) Arguments() map[string]any {
+	return t.arguments
+}
+
 // ExpandShellVar expands shell variables and syntax using bash
 func ExpandShellVar(value string) (string, error) {
 	if strings.Contains(value, "~") {
@@ -341,23 +454,17 @@ func ExpandShellVar(value string) (string, error) {
 	return os.ExpandEnv(value), nil
 }
 
+// IsInteractiveCommand returns an error if a kubectl call of the shell command
+// needs a terminal, e.g. "kubectl edit" or "kubectl exec -it".
 func IsInteractiveCommand(command string) (bool, error) {
-	// Inline isKubectlCommand logic
-	words := strings.Fields(command)
-	if len(words) == 0 {
-		return false, nil
-	}
-	base := filepath.Base(words[0])
-	if base != "kubectl" {
+	commands, err := kubectl.Parse(command)
+	if err != nil {
 		return false, nil
 	}
-
-	isExec := strings.Contains(command, " exec ") && strings.Contains(command, " -it")
-	isPortForward := strings.Contains(command, " port-forward ")
-	isEdit := strings.Contains(command, " edit ")
-
-	if isExec || isPortForward || isEdit {
-		return true, fmt.Errorf("interactive mode not supported for kubectl, please use non-interactive commands")
+	for _, cmd := range commands {
+		if cmd.Interactive() {
+			return true, fmt.Errorf("interactive mode not supported for kubectl, please use non-interactive commands")
+		}
 	}
 	return false, nil
 }
@@ -0,0 +1,183 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sandbox"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// nodePoolLabels are the node labels used by managed Kubernetes offerings
+// and autoscalers to identify the node pool of a node, in order of preference.
+var nodePoolLabels = []string{
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+	"karpenter.sh/nodepool",
+	"node.kubernetes.io/pool",
+}
+
+// nodePoolOf returns the node pool of a node, or "default" if it cannot be determined.
+func nodePoolOf(node unstructured.Unstructured) string {
+	labels := node.GetLabels()
+	for _, label := range nodePoolLabels {
+		if pool := labels[label]; pool != "" {
+			return pool
+		}
+	}
+	return "default"
+}
+
+// UpgradeFacts are the facts gathered from the cluster to plan an upgrade.
+type UpgradeFacts struct {
+	Deprecations *DeprecationReport `json:"deprecations"`
+	NodePools    []NodePool         `json:"nodePools"`
+	// BlockingPDBs lists the PodDisruptionBudgets that currently allow no disruption,
+	// and would therefore block node drains.
+	BlockingPDBs []PDBStatus `json:"blockingPDBs"`
+	Warnings     []string    `json:"warnings,omitempty"`
+}
+
+// NodePool summarizes the nodes of a node pool.
+type NodePool struct {
+	Name            string   `json:"name"`
+	Nodes           int      `json:"nodes"`
+	KubeletVersions []string `json:"kubeletVersions"`
+	InstanceTypes   []string `json:"instanceTypes,omitempty"`
+	Unschedulable   int      `json:"unschedulable,omitempty"`
+}
+
+// PDBStatus summarizes a PodDisruptionBudget.
+type PDBStatus struct {
+	Name               string `json:"name"`
+	Namespace          string `json:"namespace"`
+	MinAvailable       string `json:"minAvailable,omitempty"`
+	MaxUnavailable     string `json:"maxUnavailable,omitempty"`
+	CurrentHealthy     int64  `json:"currentHealthy"`
+	ExpectedPods       int64  `json:"expectedPods"`
+	DisruptionsAllowed int64  `json:"disruptionsAllowed"`
+}
+
+// CollectUpgradeFacts runs the data gathering steps of an upgrade plan:
+// the deprecation check for the target version, the node pool inventory
+// and the PodDisruptionBudget analysis. Steps that fail are reported as warnings.
+func CollectUpgradeFacts(ctx context.Context, executor sandbox.Executor, kubeconfig, workDir, targetVersion string) (*UpgradeFacts, error) {
+	env, err := toolEnv(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	facts := &UpgradeFacts{NodePools: []NodePool{}, BlockingPDBs: []PDBStatus{}}
+
+	facts.Deprecations, err = NewDeprecationTool(executor).check(ctx, targetVersion, env, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := getKubectlItems(ctx, executor, "kubectl get nodes -o json", env, workDir)
+	if err != nil {
+		facts.Warnings = append(facts.Warnings, fmt.Sprintf("node inventory: %v", err))
+	} else {
+		facts.NodePools = nodePoolInventory(nodes)
+	}
+
+	pdbs, err := getKubectlItems(ctx, executor, "kubectl get poddisruptionbudgets --all-namespaces -o json", env, workDir)
+	if err != nil {
+		facts.Warnings = append(facts.Warnings, fmt.Sprintf("PodDisruptionBudget analysis: %v", err))
+	} else {
+		facts.BlockingPDBs = blockingPDBs(pdbs)
+	}
+
+	return facts, nil
+}
+
+func nodePoolInventory(nodes []unstructured.Unstructured) []NodePool {
+	pools := make(map[string]*NodePool)
+	versions := make(map[string]map[string]bool)
+	instanceTypes := make(map[string]map[string]bool)
+
+	for _, node := range nodes {
+		name := nodePoolOf(node)
+		pool, ok := pools[name]
+		if !ok {
+			pool = &NodePool{Name: name}
+			pools[name] = pool
+			versions[name] = make(map[string]bool)
+			instanceTypes[name] = make(map[string]bool)
+		}
+		pool.Nodes++
+		if unschedulable, _, _ := unstructured.NestedBool(node.Object, "spec", "unschedulable"); unschedulable {
+			pool.Unschedulable++
+		}
+		if v, _, _ := unstructured.NestedString(node.Object, "status", "nodeInfo", "kubeletVersion"); v != "" {
+			versions[name][v] = true
+		}
+		if t := node.GetLabels()["node.kubernetes.io/instance-type"]; t != "" {
+			instanceTypes[name][t] = true
+		}
+	}
+
+	result := make([]NodePool, 0, len(pools))
+	for name, pool := range pools {
+		pool.KubeletVersions = sortedKeys(versions[name])
+		pool.InstanceTypes = sortedKeys(instanceTypes[name])
+		result = append(result, *pool)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func blockingPDBs(pdbs []unstructured.Unstructured) []PDBStatus {
+	result := []PDBStatus{}
+	for _, pdb := range pdbs {
+		allowed, _, _ := unstructured.NestedInt64(pdb.Object, "status", "disruptionsAllowed")
+		expected, _, _ := unstructured.NestedInt64(pdb.Object, "status", "expectedPods")
+		if allowed > 0 || expected == 0 {
+			continue
+		}
+		healthy, _, _ := unstructured.NestedInt64(pdb.Object, "status", "currentHealthy")
+		minAvailable, _, _ := unstructured.NestedFieldNoCopy(pdb.Object, "spec", "minAvailable")
+		maxUnavailable, _, _ := unstructured.NestedFieldNoCopy(pdb.Object, "spec", "maxUnavailable")
+		status := PDBStatus{
+			Name:               pdb.GetName(),
+			Namespace:          pdb.GetNamespace(),
+			CurrentHealthy:     healthy,
+			ExpectedPods:       expected,
+			DisruptionsAllowed: allowed,
+		}
+		if minAvailable != nil {
+			status.MinAvailable = fmt.Sprint(minAvailable)
+		}
+		if maxUnavailable != nil {
+			status.MaxUnavailable = fmt.Sprint(maxUnavailable)
+		}
+		result = append(result, status)
+	}
+	return result
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
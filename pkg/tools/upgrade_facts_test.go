@@ -0,0 +1,67 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func parseList(t *testing.T, s string) []unstructured.Unstructured {
+	t.Helper()
+	var list unstructured.UnstructuredList
+	if err := list.UnmarshalJSON([]byte(s)); err != nil {
+		t.Fatalf("parsing list: %v", err)
+	}
+	return list.Items
+}
+
+func TestNodePoolInventory(t *testing.T) {
+	nodes := parseList(t, `{"apiVersion": "v1", "kind": "List", "items": [
+		{"apiVersion": "v1", "kind": "Node", "metadata": {"name": "a", "labels": {"cloud.google.com/gke-nodepool": "pool-1", "node.kubernetes.io/instance-type": "e2-standard-4"}},
+			"status": {"nodeInfo": {"kubeletVersion": "v1.30.4"}}},
+		{"apiVersion": "v1", "kind": "Node", "metadata": {"name": "b", "labels": {"cloud.google.com/gke-nodepool": "pool-1", "node.kubernetes.io/instance-type": "e2-standard-4"}},
+			"spec": {"unschedulable": true}, "status": {"nodeInfo": {"kubeletVersion": "v1.29.8"}}},
+		{"apiVersion": "v1", "kind": "Node", "metadata": {"name": "c"}, "status": {"nodeInfo": {"kubeletVersion": "v1.30.4"}}}
+	]}`)
+
+	got := nodePoolInventory(nodes)
+	want := []NodePool{
+		{Name: "default", Nodes: 1, KubeletVersions: []string{"v1.30.4"}, InstanceTypes: []string{}},
+		{Name: "pool-1", Nodes: 2, KubeletVersions: []string{"v1.29.8", "v1.30.4"}, InstanceTypes: []string{"e2-standard-4"}, Unschedulable: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nodePoolInventory() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBlockingPDBs(t *testing.T) {
+	pdbs := parseList(t, `{"apiVersion": "v1", "kind": "List", "items": [
+		{"apiVersion": "policy/v1", "kind": "PodDisruptionBudget", "metadata": {"name": "db", "namespace": "prod"},
+			"spec": {"minAvailable": 3}, "status": {"currentHealthy": 3, "expectedPods": 3, "disruptionsAllowed": 0}},
+		{"apiVersion": "policy/v1", "kind": "PodDisruptionBudget", "metadata": {"name": "web", "namespace": "prod"},
+			"spec": {"maxUnavailable": "25%"}, "status": {"currentHealthy": 4, "expectedPods": 4, "disruptionsAllowed": 1}}
+	]}`)
+
+	got := blockingPDBs(pdbs)
+	want := []PDBStatus{
+		{Name: "db", Namespace: "prod", MinAvailable: "3", CurrentHealthy: 3, ExpectedPods: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("blockingPDBs() = %+v, want %+v", got, want)
+	}
+}
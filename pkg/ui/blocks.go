@@ -0,0 +1,126 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// markdownTable formats a table block as a markdown table, which glamour
+// renders with aligned columns.
+func markdownTable(table *api.TableBlock) string {
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for _, cell := range cells {
+			fmt.Fprintf(&b, " %s |", strings.ReplaceAll(cell, "|", `\|`))
+		}
+		b.WriteString("\n")
+	}
+	writeRow(table.Columns)
+	b.WriteString("|" + strings.Repeat(" --- |", len(table.Columns)) + "\n")
+	for _, row := range table.Rows {
+		writeRow(row)
+	}
+	return b.String()
+}
+
+// markdownCode formats a code block as a fenced markdown code block, which
+// glamour renders with the syntax highlighting of its language.
+func markdownCode(code *api.CodeBlock) string {
+	return fmt.Sprintf("```%s\n%s\n```", code.Language, strings.TrimRight(code.Code, "\n"))
+}
+
+// progressBarWidth is the number of cells of the progress bars.
+const progressBarWidth = 20
+
+// progressBar formats a progress block as a line of text, e.g.
+// "Scanning resources [████████░░░░░░░░░░░░] 40% (4/10) cronjobs.batch".
+func progressBar(progress *api.ProgressBlock) string {
+	var b strings.Builder
+	b.WriteString(progress.Label)
+	if percent := progress.Percent(); percent >= 0 {
+		filled := percent * progressBarWidth / 100
+		fmt.Fprintf(&b, " [%s%s] %d%% (%d/%d)", strings.Repeat("█", filled), strings.Repeat("░", progressBarWidth-filled), percent, progress.Done, progress.Total)
+	}
+	if progress.Stage != "" {
+		b.WriteString(" " + progress.Stage)
+	}
+	return b.String()
+}
+
+// latestProgress returns the messages without the progress messages superseded
+// by a later progress of the same tool call, so that the progress of a tool
+// call is shown once, as of its last update.
+func latestProgress(messages []*api.Message) []*api.Message {
+	var latest []*api.Message
+	superseded := false
+	for i := len(messages) - 1; i >= 0; i-- {
+		switch messages[i].Type {
+		case api.MessageTypeToolCallResponse:
+			// The progress before the response of a tool call is of that tool call.
+			superseded = false
+		case api.MessageTypeProgress:
+			if superseded {
+				continue
+			}
+			superseded = true
+		}
+		latest = append(latest, messages[i])
+	}
+	slices.Reverse(latest)
+	return latest
+}
+
+// artifactLine formats an artifact block as the path of its file, e.g.
+// "Artifact: /tmp/agent-workdir-1/web.yaml (1.5 KB)".
+func artifactLine(artifact *api.ArtifactBlock) string {
+	return fmt.Sprintf("Artifact: %s (%s)", artifact.Path, formatSize(artifact.Size))
+}
+
+// resourceLinksLine formats the resource links of an answer as numbered
+// shortcuts, e.g. "`[1]` deployment/web · `[2]` pod/web-0 -n shop · type
+// `/open <n>` to describe one".
+func resourceLinksLine(links []api.ResourceLink) string {
+	shortcuts := make([]string, 0, len(links)+1)
+	for _, link := range links {
+		shortcut := fmt.Sprintf("`[%d]` %s", link.Number, link.Resource)
+		if link.Namespace != "" {
+			shortcut += " -n " + link.Namespace
+		}
+		shortcuts = append(shortcuts, shortcut)
+	}
+	shortcuts = append(shortcuts, "type `/open <n>` to describe one")
+	return strings.Join(shortcuts, " · ")
+}
+
+// formatSize formats a file size, e.g. "1.5 KB".
+func formatSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
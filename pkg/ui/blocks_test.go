@@ -0,0 +1,124 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+func TestMarkdownTable(t *testing.T) {
+	table := &api.TableBlock{
+		Columns: []string{"NAME", "SELECTOR"},
+		Rows:    [][]string{{"web", "app=web|api"}, {"db", ""}},
+	}
+	want := "| NAME | SELECTOR |\n" +
+		"| --- | --- |\n" +
+		"| web | app=web\\|api |\n" +
+		"| db |  |\n"
+	if got := markdownTable(table); got != want {
+		t.Errorf("markdownTable() = %q, want %q", got, want)
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+	code := &api.CodeBlock{Language: "yaml", Code: "kind: Pod\n"}
+	if got, want := markdownCode(code), "```yaml\nkind: Pod\n```"; got != want {
+		t.Errorf("markdownCode() = %q, want %q", got, want)
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress *api.ProgressBlock
+		want     string
+	}{
+		{
+			name:     "determinate",
+			progress: &api.ProgressBlock{Label: "Collecting logs", Stage: "web-1", Done: 3, Total: 12},
+			want:     "Collecting logs [█████░░░░░░░░░░░░░░░] 25% (3/12) web-1",
+		},
+		{
+			name:     "done",
+			progress: &api.ProgressBlock{Label: "Collecting logs", Done: 12, Total: 12},
+			want:     "Collecting logs [████████████████████] 100% (12/12)",
+		},
+		{
+			name:     "indeterminate",
+			progress: &api.ProgressBlock{Label: "Collecting logs", Stage: "pulling images"},
+			want:     "Collecting logs pulling images",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := progressBar(tt.progress); got != tt.want {
+				t.Errorf("progressBar() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatestProgress(t *testing.T) {
+	messages := []*api.Message{
+		{ID: "request-1", Type: api.MessageTypeToolCallRequest},
+		{ID: "progress-1", Type: api.MessageTypeProgress},
+		{ID: "progress-2", Type: api.MessageTypeProgress},
+		{ID: "output", Type: api.MessageTypeToolCallProgress},
+		{ID: "progress-3", Type: api.MessageTypeProgress},
+		{ID: "response-1", Type: api.MessageTypeToolCallResponse},
+		{ID: "request-2", Type: api.MessageTypeToolCallRequest},
+		{ID: "progress-4", Type: api.MessageTypeProgress},
+		{ID: "response-2", Type: api.MessageTypeToolCallResponse},
+	}
+	var got []string
+	for _, message := range latestProgress(messages) {
+		got = append(got, message.ID)
+	}
+	want := []string{"request-1", "output", "progress-3", "response-1", "request-2", "progress-4", "response-2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("latestProgress() = %v, want %v", got, want)
+	}
+}
+
+func TestArtifactLine(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{size: 512, want: "Artifact: /tmp/work/web.yaml (512 B)"},
+		{size: 1536, want: "Artifact: /tmp/work/web.yaml (1.5 KB)"},
+		{size: 3 << 20, want: "Artifact: /tmp/work/web.yaml (3.0 MB)"},
+	}
+	for _, tt := range tests {
+		artifact := &api.ArtifactBlock{Name: "web.yaml", Path: "/tmp/work/web.yaml", Size: tt.size}
+		if got := artifactLine(artifact); got != tt.want {
+			t.Errorf("artifactLine(%d bytes) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestResourceLinksLine(t *testing.T) {
+	links := []api.ResourceLink{
+		{Number: 1, Resource: "deployment/web"},
+		{Number: 2, Resource: "pod/web-0", Namespace: "shop"},
+	}
+	want := "`[1]` deployment/web · `[2]` pod/web-0 -n shop · type `/open <n>` to describe one"
+	if got := resourceLinksLine(links); got != want {
+		t.Errorf("resourceLinksLine() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"context"
+	"time"
+)
+
+// RefreshInterval is the minimum interval between two refreshes of the UIs
+// rendering the whole session on each output of the agent, e.g. the TUI and
+// the HTML UI.
+const RefreshInterval = 50 * time.Millisecond
+
+// Coalesce calls notify with the messages of output, at most once per
+// interval. The first message after a quiet interval is notified right away,
+// the messages output during an interval are coalesced into a single call
+// with the latest one at the end of the interval. It returns when output is
+// closed, after notifying the pending message, or when ctx is done.
+func Coalesce(ctx context.Context, output <-chan any, interval time.Duration, notify func(msg any)) {
+	var pending any
+	hasPending := false
+	// throttle is nil when no message was notified during the last interval.
+	var throttle <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-output:
+			if !ok {
+				if hasPending {
+					notify(pending)
+				}
+				return
+			}
+			if throttle == nil {
+				notify(msg)
+				throttle = time.After(interval)
+				continue
+			}
+			pending, hasPending = msg, true
+		case <-throttle:
+			throttle = nil
+			if hasPending {
+				notify(pending)
+				pending, hasPending = nil, false
+				throttle = time.After(interval)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCoalesce(t *testing.T) {
+	output := make(chan any)
+	var notified []any
+	done := make(chan struct{})
+	go func() {
+		Coalesce(context.Background(), output, time.Hour, func(msg any) { notified = append(notified, msg) })
+		close(done)
+	}()
+
+	// The first message is notified right away, the next ones are coalesced
+	// into the latest one, notified when the output is closed.
+	for i := range 100 {
+		output <- i
+	}
+	close(output)
+	<-done
+
+	if len(notified) != 2 || notified[0] != 0 || notified[1] != 99 {
+		t.Errorf("notified %v, want [0 99]", notified)
+	}
+}
+
+func TestCoalesceInterval(t *testing.T) {
+	output := make(chan any)
+	notified := make(chan any, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Coalesce(ctx, output, 10*time.Millisecond, func(msg any) { notified <- msg })
+
+	output <- "first"
+	output <- "second"
+	output <- "third"
+	for _, want := range []string{"first", "third"} {
+		select {
+		case got := <-notified:
+			if got != want {
+				t.Errorf("notified %v, want %v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%v was not notified", want)
+		}
+	}
+}
+
+// BenchmarkCoalesce measures the notifications of a fast stream of messages,
+// e.g. the output of a streaming tool call.
+func BenchmarkCoalesce(b *testing.B) {
+	output := make(chan any)
+	notifications := 0
+	done := make(chan struct{})
+	go func() {
+		Coalesce(context.Background(), output, RefreshInterval, func(any) { notifications++ })
+		close(done)
+	}()
+	for i := 0; b.Loop(); i++ {
+		output <- i
+	}
+	close(output)
+	<-done
+	b.ReportMetric(float64(notifications)/float64(b.N), "notifications/op")
+}
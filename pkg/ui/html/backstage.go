@@ -0,0 +1,394 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package html
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/agent"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// backstageAPIPrefix is the path of the JSON API for the Backstage plugin.
+	backstageAPIPrefix = "/api/backstage/v1"
+
+	// BackstageEntityLabel is the session label holding the Backstage entity
+	// the session was started from, e.g. "component:default/checkout".
+	BackstageEntityLabel = "backstage.io/entity"
+	// BackstageNamespaceLabel is the session label holding the namespace the session is scoped to.
+	BackstageNamespaceLabel = "kubectl-ai.dev/namespace"
+)
+
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// BackstageConfig configures the JSON API used by the Backstage plugin backend.
+type BackstageConfig struct {
+	// Token is the service token the plugin backend sends as a bearer token.
+	Token string
+	// AllowedOrigins are the origins allowed to call the API from a browser. "*" allows any origin.
+	AllowedOrigins []string
+	// ScopeKubeconfig returns the path of a kubeconfig whose current context
+	// uses the namespace, for the queries scoped to the namespace of a service.
+	ScopeKubeconfig func(namespace string) (string, error)
+}
+
+// backstageAPI serves the JSON API for the Backstage plugin backend:
+// starting queries scoped to the namespace of a service, streaming the blocks
+// of a session, and listing the sessions of an entity.
+type backstageAPI struct {
+	ui     *HTMLUserInterface
+	config BackstageConfig
+}
+
+// BackstageSession describes a session started from Backstage.
+type BackstageSession struct {
+	ID           string         `json:"id"`
+	Entity       string         `json:"entity"`
+	Namespace    string         `json:"namespace,omitempty"`
+	AgentState   api.AgentState `json:"agentState"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	LastModified time.Time      `json:"lastModified"`
+}
+
+// BackstageBlock is a message of a session, as streamed to the Backstage plugin.
+type BackstageBlock struct {
+	Index     int               `json:"index"`
+	ID        string            `json:"id,omitempty"`
+	Source    api.MessageSource `json:"source"`
+	Type      api.MessageType   `json:"type"`
+	Payload   any               `json:"payload,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	// AttachmentID is set when the payload is a preview, the full payload is
+	// served by /api/sessions/{id}/attachments/{attachmentID}.
+	AttachmentID string `json:"attachmentId,omitempty"`
+}
+
+// EnableBackstageAPI serves the JSON API for the Backstage plugin under /api/backstage/v1.
+func (u *HTMLUserInterface) EnableBackstageAPI(config BackstageConfig) error {
+	if config.Token == "" {
+		return errors.New("the Backstage API requires a service token")
+	}
+	b := &backstageAPI{ui: u, config: config}
+
+	u.mux.HandleFunc("OPTIONS "+backstageAPIPrefix+"/", b.withCORS(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	u.mux.HandleFunc("POST "+backstageAPIPrefix+"/queries", b.withCORS(b.withAuth(b.handleStartQuery)))
+	u.mux.HandleFunc("GET "+backstageAPIPrefix+"/sessions", b.withCORS(b.withAuth(b.handleListSessions)))
+	u.mux.HandleFunc("GET "+backstageAPIPrefix+"/sessions/{id}/blocks", b.withCORS(b.withAuth(b.handleStreamBlocks)))
+	u.mux.HandleFunc("POST "+backstageAPIPrefix+"/sessions/{id}/messages", b.withCORS(b.withAuth(b.handleSendMessage)))
+	u.mux.HandleFunc("POST "+backstageAPIPrefix+"/sessions/{id}/choice", b.withCORS(b.withAuth(b.handleChoose)))
+	return nil
+}
+
+// withCORS allows the configured origins to call the API from a browser.
+func (b *backstageAPI) withCORS(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		if origin != "" && (slices.Contains(b.config.AllowedOrigins, "*") || slices.Contains(b.config.AllowedOrigins, origin)) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.Header().Add("Vary", "Origin")
+		}
+		next(w, req)
+	}
+}
+
+// withAuth rejects the requests without the service token.
+func (b *backstageAPI) withAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(b.config.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSONError(w, http.StatusUnauthorized, "missing or invalid service token")
+			return
+		}
+		next(w, req)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.Errorf("Encoding the response: %v", err)
+	}
+}
+
+func backstageSession(session *api.Session) *BackstageSession {
+	return &BackstageSession{
+		ID:           session.ID,
+		Entity:       session.Labels[BackstageEntityLabel],
+		Namespace:    session.Labels[BackstageNamespaceLabel],
+		AgentState:   session.AgentState,
+		CreatedAt:    session.CreatedAt,
+		LastModified: session.LastModified,
+	}
+}
+
+// agent returns the agent of a session started from Backstage.
+func (b *backstageAPI) agent(ctx context.Context, id string) (*agent.Agent, error) {
+	a, err := b.ui.manager.GetAgent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Session.Labels[BackstageEntityLabel] == "" {
+		return nil, fmt.Errorf("session %q was not started from Backstage", id)
+	}
+	return a, nil
+}
+
+// scope points the agent at a kubeconfig using the namespace of its session,
+// before it is sent a query. Sessions resumed after a restart are scoped again.
+func (b *backstageAPI) scope(a *agent.Agent) error {
+	namespace := a.Session.Labels[BackstageNamespaceLabel]
+	if namespace == "" || b.config.ScopeKubeconfig == nil {
+		return nil
+	}
+	kubeconfig, err := b.config.ScopeKubeconfig(namespace)
+	if err != nil {
+		return fmt.Errorf("scoping the kubeconfig to namespace %q: %w", namespace, err)
+	}
+	a.Kubeconfig = kubeconfig
+	return nil
+}
+
+// handleStartQuery starts a session for an entity, scoped to the namespace of
+// the service, and sends it the query.
+func (b *backstageAPI) handleStartQuery(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	var body struct {
+		Entity    string `json:"entity"`
+		Namespace string `json:"namespace"`
+		Query     string `json:"query"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	switch {
+	case body.Entity == "":
+		writeJSONError(w, http.StatusBadRequest, "missing entity")
+		return
+	case strings.TrimSpace(body.Query) == "":
+		writeJSONError(w, http.StatusBadRequest, "missing query")
+		return
+	case body.Namespace != "" && !namespacePattern.MatchString(body.Namespace):
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid namespace %q", body.Namespace))
+		return
+	}
+
+	labels := map[string]string{BackstageEntityLabel: body.Entity}
+	if body.Namespace != "" {
+		labels[BackstageNamespaceLabel] = body.Namespace
+	}
+	session, err := b.ui.sessionManager.NewSession(sessions.Metadata{
+		ModelID:    b.ui.defaultModel,
+		ProviderID: b.ui.defaultProvider,
+		Labels:     labels,
+	})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	a, err := b.agent(ctx, session.ID)
+	if err == nil {
+		err = b.scope(a)
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	a.Input <- &api.UserInputResponse{Query: body.Query}
+
+	writeJSON(w, http.StatusCreated, backstageSession(a.Session))
+}
+
+// handleListSessions lists the sessions started from Backstage, of the entity given by the entity query parameter if set.
+func (b *backstageAPI) handleListSessions(w http.ResponseWriter, req *http.Request) {
+	entity := req.URL.Query().Get("entity")
+
+	all, err := b.ui.manager.ListSessions()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result := []*BackstageSession{}
+	for _, session := range all {
+		sessionEntity := session.Labels[BackstageEntityLabel]
+		if sessionEntity == "" || (entity != "" && sessionEntity != entity) {
+			continue
+		}
+		result = append(result, backstageSession(session))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleStreamBlocks streams the messages of a session as server-sent events:
+// a "block" event per message, from the index given by the since query
+// parameter, and a "state" event when the state of the agent changes.
+func (b *backstageAPI) handleStreamBlocks(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	id := req.PathValue("id")
+
+	since := 0
+	if s := req.URL.Query().Get("since"); s != "" {
+		var err error
+		if since, err = strconv.Atoi(s); err != nil || since < 0 {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid since %q", s))
+			return
+		}
+	}
+
+	a, err := b.agent(ctx, id)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	// The broadcaster notifies every new message of the session.
+	clientChan := make(chan []byte, 10)
+	broadcaster := b.ui.getBroadcaster(id)
+	broadcaster.newClient <- clientChan
+	defer func() {
+		broadcaster.delClient <- clientChan
+	}()
+
+	next := since
+	var state api.AgentState
+	send := func() {
+		messages := a.Session.AllMessages()
+		for ; next < len(messages); next++ {
+			message := messages[next]
+			if message.Type == api.MessageTypeUserInputRequest && message.Payload == ">>>" {
+				continue
+			}
+			data, err := json.Marshal(&BackstageBlock{
+				Index:     next,
+				ID:        message.ID,
+				Source:    message.Source,
+				Type:      message.Type,
+				Payload:   message.Payload,
+				Timestamp: message.Timestamp,
+
+				AttachmentID: message.AttachmentID,
+			})
+			if err != nil {
+				klog.Errorf("Encoding block %d of session %s: %v", next, id, err)
+				continue
+			}
+			fmt.Fprintf(w, "event: block\ndata: %s\n\n", data)
+		}
+		if current := a.Session.AgentState; current != state {
+			state = current
+			fmt.Fprintf(w, "event: state\ndata: {\"agentState\":%q}\n\n", state)
+		}
+		flusher.Flush()
+	}
+
+	send()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clientChan:
+			send()
+		}
+	}
+}
+
+// handleSendMessage sends a follow-up query to a session.
+func (b *backstageAPI) handleSendMessage(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Query string `json:"query"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if strings.TrimSpace(body.Query) == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing query")
+		return
+	}
+
+	a, err := b.agent(req.Context(), req.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err := b.scope(a); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	a.Input <- &api.UserInputResponse{Query: body.Query}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleChoose answers the choice request of a session, e.g. the approval of a command.
+func (b *backstageAPI) handleChoose(w http.ResponseWriter, req *http.Request) {
+	var body api.UserChoiceResponse
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if body.Choice < 1 {
+		writeJSONError(w, http.StatusBadRequest, "missing choice")
+		return
+	}
+
+	a, err := b.agent(req.Context(), req.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if a.Session.AgentState != api.AgentStateWaitingForInput {
+		writeJSONError(w, http.StatusConflict, "the session is not waiting for a choice")
+		return
+	}
+	a.Input <- &body
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package html
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBackstageAuthAndCORS(t *testing.T) {
+	b := &backstageAPI{config: BackstageConfig{
+		Token:          "secret",
+		AllowedOrigins: []string{"https://backstage.example.com"},
+	}}
+	handler := b.withCORS(b.withAuth(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		origin        string
+		wantStatus    int
+		wantOrigin    string
+	}{
+		{
+			name:          "valid token",
+			authorization: "Bearer secret",
+			wantStatus:    http.StatusNoContent,
+		},
+		{
+			name:       "missing token",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "invalid token",
+			authorization: "Bearer guess",
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "not a bearer token",
+			authorization: "secret",
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "allowed origin",
+			authorization: "Bearer secret",
+			origin:        "https://backstage.example.com",
+			wantStatus:    http.StatusNoContent,
+			wantOrigin:    "https://backstage.example.com",
+		},
+		{
+			name:          "other origin",
+			authorization: "Bearer secret",
+			origin:        "https://evil.example.com",
+			wantStatus:    http.StatusNoContent,
+		},
+		{
+			name:       "CORS headers are set on unauthorized responses",
+			origin:     "https://backstage.example.com",
+			wantStatus: http.StatusUnauthorized,
+			wantOrigin: "https://backstage.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, backstageAPIPrefix+"/sessions", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+		})
+	}
+}
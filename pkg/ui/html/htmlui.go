@@ -20,10 +20,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"mime"
 	"net"
 	"net/http"
 	"os"
+	"path"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -37,7 +40,7 @@ import (
 	"k8s.io/klog/v2"
 )
 
-// Broadcaster manages a set of clients for Server-Sent Events.
+// Broadcaster notifies the WebSocket clients of a session of its updates.
 type Broadcaster struct {
 	clients   map[chan []byte]bool
 	newClient chan chan []byte
@@ -78,7 +81,7 @@ func (b *Broadcaster) Run(ctx context.Context) {
 				select {
 				case client <- msg:
 				default:
-					klog.Warning("SSE client buffer full, dropping message.")
+					klog.Warning("WebSocket client buffer full, dropping message.")
 				}
 			}
 			b.mu.Unlock()
@@ -94,12 +97,15 @@ func (b *Broadcaster) Broadcast(msg []byte) {
 type HTMLUserInterface struct {
 	httpServer         *http.Server
 	httpServerListener net.Listener
+	mux                *http.ServeMux
 
 	manager         *agent.AgentManager
 	sessionManager  *sessions.SessionManager
 	journal         journal.Recorder
 	defaultModel    string
 	defaultProvider string
+	// verbosity selects the messages shown, see ui.Verbosity.
+	verbosity ui.Verbosity
 
 	markdownRenderer *glamour.TermRenderer
 	broadcasters     map[string]*Broadcaster
@@ -111,14 +117,16 @@ type HTMLUserInterface struct {
 
 var _ ui.UI = &HTMLUserInterface{}
 
-func NewHTMLUserInterface(manager *agent.AgentManager, sessionManager *sessions.SessionManager, defaultModel, defaultProvider string, listenAddress string, journal journal.Recorder) (*HTMLUserInterface, error) {
+func NewHTMLUserInterface(manager *agent.AgentManager, sessionManager *sessions.SessionManager, defaultModel, defaultProvider string, listenAddress string, verbosity ui.Verbosity, journal journal.Recorder) (*HTMLUserInterface, error) {
 	mux := http.NewServeMux()
 
 	u := &HTMLUserInterface{
+		mux:                mux,
 		manager:            manager,
 		sessionManager:     sessionManager,
 		defaultModel:       defaultModel,
 		defaultProvider:    defaultProvider,
+		verbosity:          verbosity,
 		journal:            journal,
 		broadcasters:       make(map[string]*Broadcaster),
 		broadcasterCancels: make(map[string]context.CancelFunc),
@@ -139,7 +147,11 @@ func NewHTMLUserInterface(manager *agent.AgentManager, sessionManager *sessions.
 	mux.HandleFunc("POST /api/sessions", u.handleCreateSession)
 	mux.HandleFunc("POST /api/sessions/{id}/rename", u.handleRenameSession)
 	mux.HandleFunc("DELETE /api/sessions/{id}", u.handleDeleteSession)
-	mux.HandleFunc("GET /api/sessions/{id}/stream", u.handleSessionStream)
+	mux.HandleFunc("GET /api/sessions/{id}/ws", u.handleSessionWebSocket)
+	mux.HandleFunc("GET /api/sessions/{id}/history", u.handleSessionHistory)
+	mux.HandleFunc("GET /api/sessions/{id}/messages", u.handleListMessages)
+	mux.HandleFunc("GET /api/sessions/{id}/attachments/{attachmentID}", u.handleGetAttachment)
+	mux.HandleFunc("GET /api/sessions/{id}/artifacts/{name...}", u.handleGetArtifact)
 	mux.HandleFunc("POST /api/sessions/{id}/send-message", u.handlePOSTSendMessage)
 	mux.HandleFunc("POST /api/sessions/{id}/choose-option", u.handlePOSTChooseOption)
 
@@ -199,62 +211,6 @@ func (u *HTMLUserInterface) serveIndex(w http.ResponseWriter, req *http.Request)
 	w.Write(indexHTML)
 }
 
-func (u *HTMLUserInterface) handleSessionStream(w http.ResponseWriter, req *http.Request) {
-	ctx := req.Context()
-	log := klog.FromContext(ctx)
-
-	id := req.PathValue("id")
-	if id == "" {
-		http.Error(w, "missing session id", http.StatusBadRequest)
-		return
-	}
-
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-
-	clientChan := make(chan []byte, 10)
-	broadcaster := u.getBroadcaster(id)
-	broadcaster.newClient <- clientChan
-	defer func() {
-		broadcaster.delClient <- clientChan
-	}()
-
-	log.Info("SSE client connected", "sessionID", id)
-
-	agent, err := u.manager.GetAgent(ctx, id)
-	var initialData []byte
-	if err != nil {
-		log.Error(err, "getting agent for session")
-	} else {
-		initialData, err = u.getSessionStateJSON(agent.Session)
-	}
-
-	if err != nil {
-		log.Error(err, "getting initial state for SSE client")
-	} else {
-		fmt.Fprintf(w, "data: %s\n\n", initialData)
-		flusher.Flush()
-	}
-
-	for {
-		select {
-		case <-ctx.Done():
-			log.Info("SSE client disconnected")
-			return
-		case msg := <-clientChan:
-			fmt.Fprintf(w, "data: %s\n\n", msg)
-			flusher.Flush()
-		}
-	}
-}
-
 func (u *HTMLUserInterface) handleListSessions(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	log := klog.FromContext(ctx)
@@ -276,7 +232,13 @@ func (u *HTMLUserInterface) handleCreateSession(w http.ResponseWriter, req *http
 	ctx := req.Context()
 	log := klog.FromContext(ctx)
 
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	meta := sessions.Metadata{
+		Name:       strings.TrimSpace(req.FormValue("name")),
 		ModelID:    u.defaultModel,
 		ProviderID: u.defaultProvider,
 	}
@@ -298,6 +260,32 @@ func (u *HTMLUserInterface) handleCreateSession(w http.ResponseWriter, req *http
 	json.NewEncoder(w).Encode(map[string]string{"id": session.ID})
 }
 
+// handleSessionHistory serves the state of a session for reading it without
+// resuming it: unlike the stream, it does not start the agent of a past session.
+func (u *HTMLUserInterface) handleSessionHistory(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	log := klog.FromContext(ctx)
+
+	id := req.PathValue("id")
+	if id == "" {
+		http.Error(w, "missing session id", http.StatusBadRequest)
+		return
+	}
+
+	session, err := u.manager.GetSession(id)
+	if err != nil {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	data := sessionState(session, u.verbosity)
+	data["readOnly"] = true
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Error(err, "writing session history")
+	}
+}
+
 func (u *HTMLUserInterface) handleRenameSession(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	log := klog.FromContext(ctx)
@@ -371,6 +359,131 @@ func (u *HTMLUserInterface) handleDeleteSession(w http.ResponseWriter, req *http
 	w.WriteHeader(http.StatusOK)
 }
 
+// handleListMessages serves the messages of a session from index start to
+// end, fetched by the UI when the user scrolls up to the messages before the
+// window of the session state.
+func (u *HTMLUserInterface) handleListMessages(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	log := klog.FromContext(ctx)
+
+	id := req.PathValue("id")
+	if id == "" {
+		http.Error(w, "missing session id", http.StatusBadRequest)
+		return
+	}
+	start, err := strconv.Atoi(req.URL.Query().Get("start"))
+	if err != nil || start < 0 {
+		http.Error(w, "invalid start", http.StatusBadRequest)
+		return
+	}
+	end, err := strconv.Atoi(req.URL.Query().Get("end"))
+	if err != nil || end < start {
+		http.Error(w, "invalid end", http.StatusBadRequest)
+		return
+	}
+
+	session, err := u.manager.GetSession(id)
+	if err != nil {
+		log.Error(err, "getting session")
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	messages := visibleMessages(session, u.verbosity)
+	end = min(end, len(messages))
+	start = min(start, end)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"messages": messages[start:end],
+		"start":    start,
+	}); err != nil {
+		log.Error(err, "writing messages")
+	}
+}
+
+// handleGetAttachment serves a tool output stored as an attachment of the session,
+// fetched by the UI when the output is expanded.
+func (u *HTMLUserInterface) handleGetAttachment(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	log := klog.FromContext(ctx)
+
+	id := req.PathValue("id")
+	attachmentID := req.PathValue("attachmentID")
+	if id == "" || attachmentID == "" {
+		http.Error(w, "missing session or attachment id", http.StatusBadRequest)
+		return
+	}
+
+	session, err := u.manager.GetSession(id)
+	if err != nil {
+		log.Error(err, "getting session")
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	store, ok := session.ChatMessageStore.(api.AttachmentStore)
+	if !ok {
+		http.Error(w, "session does not support attachments", http.StatusNotFound)
+		return
+	}
+	data, err := store.Attachment(attachmentID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	// Attachments are content-addressed, so they never change.
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	w.Write(data)
+}
+
+// handleGetArtifact serves an artifact of a session from the work directory of
+// its agent. Only the files the session announced as artifacts can be
+// downloaded, and they cannot be outside of the work directory.
+func (u *HTMLUserInterface) handleGetArtifact(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	log := klog.FromContext(ctx)
+
+	id := req.PathValue("id")
+	name := req.PathValue("name")
+	if id == "" || name == "" {
+		http.Error(w, "missing session id or artifact name", http.StatusBadRequest)
+		return
+	}
+
+	agent, err := u.manager.GetAgent(ctx, id)
+	if err != nil {
+		log.Error(err, "getting agent for session")
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !hasArtifact(agent.GetSession(), name) || agent.WorkDir() == "" {
+		http.Error(w, "artifact not found", http.StatusNotFound)
+		return
+	}
+	// The root prevents the artifact, e.g. a symlink, from escaping the work directory.
+	root, err := os.OpenRoot(agent.WorkDir())
+	if err != nil {
+		log.Error(err, "opening work directory")
+		http.Error(w, "artifact not found", http.StatusNotFound)
+		return
+	}
+	defer root.Close()
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(name)}))
+	http.ServeFileFS(w, req, root.FS(), name)
+}
+
+// hasArtifact returns true if the session has an artifact message with this name.
+func hasArtifact(session *api.Session, name string) bool {
+	for _, message := range session.AllMessages() {
+		if artifact, ok := message.Payload.(*api.ArtifactBlock); ok && artifact.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (u *HTMLUserInterface) handlePOSTSendMessage(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	log := klog.FromContext(ctx)
@@ -443,7 +556,7 @@ func (u *HTMLUserInterface) handlePOSTChooseOption(w http.ResponseWriter, req *h
 	}
 
 	// Send the choice to the agent
-	agent.Input <- &api.UserChoiceResponse{Choice: choiceIndex}
+	agent.Input <- &api.UserChoiceResponse{Choice: choiceIndex, Command: req.FormValue("command")}
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -473,7 +586,15 @@ func (u *HTMLUserInterface) ClearScreen() {
 	// Not applicable for HTML UI
 }
 
-func (u *HTMLUserInterface) getSessionStateJSON(session *api.Session) ([]byte, error) {
+// stateWindowMessages is the number of most recent messages sent with the
+// session state. The UI fetches the earlier ones when the user scrolls up to
+// them, so that long sessions are not sent again on each update.
+const stateWindowMessages = 200
+
+// visibleMessages returns the messages of the session shown in the UI at a
+// verbosity. The UI folds the output of a command under the command, so the
+// tool call responses are kept whenever their requests are shown.
+func visibleMessages(session *api.Session, verbosity ui.Verbosity) []*api.Message {
 	allMessages := session.AllMessages()
 	// Create a copy of the messages to avoid race conditions
 	var messages []*api.Message
@@ -481,17 +602,36 @@ func (u *HTMLUserInterface) getSessionStateJSON(session *api.Session) ([]byte, e
 		if message.Type == api.MessageTypeUserInputRequest && message.Payload == ">>>" {
 			continue
 		}
+		if message.Type == api.MessageTypeToolCallResponse {
+			if verbosity == ui.VerbosityQuiet {
+				continue
+			}
+		} else if !verbosity.Shows(message) {
+			continue
+		}
 		messages = append(messages, message)
 	}
+	return messages
+}
 
-	agentState := session.AgentState
+func (u *HTMLUserInterface) getSessionStateJSON(session *api.Session) ([]byte, error) {
+	return json.Marshal(sessionState(session, u.verbosity))
+}
+
+// sessionState returns the state of the session sent to the UI, with the most
+// recent messages.
+func sessionState(session *api.Session, verbosity ui.Verbosity) map[string]interface{} {
+	messages := visibleMessages(session, verbosity)
+	// firstMessageIndex is the index of the first message sent among the visible messages.
+	firstMessageIndex := max(0, len(messages)-stateWindowMessages)
 
-	data := map[string]interface{}{
-		"messages":   messages,
-		"agentState": agentState,
-		"sessionId":  session.ID,
+	return map[string]interface{}{
+		"messages":          messages[firstMessageIndex:],
+		"firstMessageIndex": firstMessageIndex,
+		"agentState":        session.AgentState,
+		"sessionId":         session.ID,
+		"sessionName":       session.Name,
 	}
-	return json.Marshal(data)
 }
 
 func (u *HTMLUserInterface) getBroadcaster(sessionID string) *Broadcaster {
@@ -519,22 +659,22 @@ func (u *HTMLUserInterface) getBroadcaster(sessionID string) *Broadcaster {
 }
 
 func (u *HTMLUserInterface) ensureAgentListener(a *agent.Agent) {
-	// Start a goroutine to listen to this agent's output
-	go func() {
-		for range a.Output {
-			// Broadcast state
-			if a.Session == nil {
-				continue
-			}
-
-			data, err := u.getSessionStateJSON(a.Session)
-			if err != nil {
-				klog.Errorf("Error marshaling state for broadcast: %v", err)
-				continue
-			}
+	// Start a goroutine to listen to this agent's output. Each message
+	// broadcasts the whole session state, so the messages output while
+	// streaming are coalesced.
+	go ui.Coalesce(context.Background(), a.Output, ui.RefreshInterval, func(any) {
+		// Broadcast state
+		if a.Session == nil {
+			return
+		}
 
-			b := u.getBroadcaster(a.Session.ID)
-			b.Broadcast(data)
+		data, err := u.getSessionStateJSON(a.Session)
+		if err != nil {
+			klog.Errorf("Error marshaling state for broadcast: %v", err)
+			return
 		}
-	}()
+
+		b := u.getBroadcaster(a.Session.ID)
+		b.Broadcast(data)
+	})
 }
@@ -0,0 +1,160 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package html
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/agent"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+)
+
+func TestSessionHistory(t *testing.T) {
+	sessionManager, err := sessions.NewSessionManager("memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reading the history of a session must not start its agent.
+	manager := agent.NewAgentManager(func(context.Context) (*agent.Agent, error) {
+		return nil, errors.New("agent started")
+	}, sessionManager)
+	u := &HTMLUserInterface{manager: manager, sessionManager: sessionManager}
+
+	session, err := sessionManager.NewSession(sessions.Metadata{Name: "web outage"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.ChatMessageStore.AddChatMessage(&api.Message{ID: "m1", Source: api.MessageSourceUser, Type: api.MessageTypeText, Payload: "why is web failing?"})
+
+	tests := []struct {
+		name         string
+		id           string
+		wantStatus   int
+		wantMessages int
+	}{
+		{name: "past session", id: session.ID, wantStatus: http.StatusOK, wantMessages: 1},
+		{name: "missing session", id: "missing", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/sessions/"+tt.id+"/history", nil)
+			req.SetPathValue("id", tt.id)
+			rec := httptest.NewRecorder()
+			u.handleSessionHistory(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var state struct {
+				Messages    []*api.Message `json:"messages"`
+				SessionName string         `json:"sessionName"`
+				ReadOnly    bool           `json:"readOnly"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+				t.Fatal(err)
+			}
+			if len(state.Messages) != tt.wantMessages || state.SessionName != "web outage" || !state.ReadOnly {
+				t.Errorf("history = %+v, want %d messages of the read-only session \"web outage\"", state, tt.wantMessages)
+			}
+		})
+	}
+}
+
+func TestCreateNamedSession(t *testing.T) {
+	sessionManager, err := sessions.NewSessionManager("memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := agent.NewAgentManager(func(context.Context) (*agent.Agent, error) {
+		return nil, errors.New("no agent in tests")
+	}, sessionManager)
+	u := &HTMLUserInterface{manager: manager, sessionManager: sessionManager}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/sessions", strings.NewReader(url.Values{"name": {" web outage "}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	u.handleCreateSession(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	session, err := sessionManager.FindSessionByID(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Name != "web outage" {
+		t.Errorf("Name = %q, want %q", session.Name, "web outage")
+	}
+}
+
+func TestSessionStreamUpdate(t *testing.T) {
+	newMessage := func(id string) *api.Message {
+		return &api.Message{ID: id, Source: api.MessageSourceModel, Type: api.MessageTypeText, Payload: id}
+	}
+	store := sessions.NewInMemoryChatStore()
+	session := &api.Session{ID: "s1", ChatMessageStore: store}
+	other := &api.Session{ID: "s2", ChatMessageStore: sessions.NewInMemoryChatStore()}
+	stream := &sessionStream{}
+
+	tests := []struct {
+		name    string
+		session *api.Session
+		// add adds messages to the session before the update, clear clears them first.
+		add          []string
+		clear        bool
+		wantType     string
+		wantMessages int
+	}{
+		{name: "first update", session: session, add: []string{"m1"}, wantType: "state", wantMessages: 1},
+		{name: "new messages", session: session, add: []string{"m2", "m3"}, wantType: "append", wantMessages: 2},
+		{name: "state change only", session: session, wantType: "append", wantMessages: 0},
+		{name: "cleared", session: session, clear: true, add: []string{"m4"}, wantType: "state", wantMessages: 1},
+		{name: "other session", session: other, wantType: "state", wantMessages: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.clear {
+				if err := tt.session.ChatMessageStore.ClearChatMessages(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, id := range tt.add {
+				tt.session.ChatMessageStore.AddChatMessage(newMessage(id))
+			}
+			update := stream.update(tt.session)
+			if update["type"] != tt.wantType {
+				t.Errorf("type = %v, want %s", update["type"], tt.wantType)
+			}
+			if messages := update["messages"].([]*api.Message); len(messages) != tt.wantMessages {
+				t.Errorf("got %d messages, want %d", len(messages), tt.wantMessages)
+			}
+		})
+	}
+}
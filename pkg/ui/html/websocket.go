@@ -0,0 +1,174 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package html
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/agent"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/ui"
+	"github.com/gorilla/websocket"
+	"k8s.io/klog/v2"
+)
+
+// upgrader accepts the WebSocket connections of the pages served on the same
+// origin only, the default of an empty CheckOrigin.
+var upgrader = websocket.Upgrader{}
+
+// wsRequest is a request of the browser over the WebSocket of a session.
+type wsRequest struct {
+	// Type is "send-message", "choose-option" or "interrupt".
+	Type string `json:"type"`
+	// Query is the message of a "send-message" request.
+	Query string `json:"query,omitempty"`
+	// Choice and Command are the option chosen, and the command edited if
+	// any, of a "choose-option" request.
+	Choice  int    `json:"choice,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// handleSessionWebSocket streams the updates of a session to the browser, and
+// passes the input of the user, its choices and its interrupts to the agent.
+func (u *HTMLUserInterface) handleSessionWebSocket(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	log := klog.FromContext(ctx)
+
+	id := req.PathValue("id")
+	if id == "" {
+		http.Error(w, "missing session id", http.StatusBadRequest)
+		return
+	}
+
+	agent, err := u.manager.GetAgent(ctx, id)
+	if err != nil {
+		log.Error(err, "getting agent for session")
+		http.Error(w, "agent not found", http.StatusNotFound)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		// The upgrader has replied with the error.
+		log.Error(err, "upgrading to WebSocket")
+		return
+	}
+	defer conn.Close()
+
+	clientChan := make(chan []byte, 10)
+	broadcaster := u.getBroadcaster(id)
+	broadcaster.newClient <- clientChan
+	defer func() {
+		broadcaster.delClient <- clientChan
+	}()
+
+	log.Info("WebSocket client connected", "sessionID", id)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			var request wsRequest
+			if err := conn.ReadJSON(&request); err != nil {
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					log.Error(err, "reading WebSocket request")
+				}
+				return
+			}
+			if err := handleRequest(agent, &request); err != nil {
+				log.Error(err, "handling WebSocket request", "type", request.Type)
+			}
+		}
+	}()
+
+	stream := &sessionStream{verbosity: u.verbosity}
+	for {
+		if err := conn.WriteJSON(stream.update(agent.GetSession())); err != nil {
+			log.Error(err, "writing WebSocket update")
+			return
+		}
+		select {
+		case <-ctx.Done():
+			log.Info("WebSocket client disconnected", "sessionID", id)
+			return
+		case <-clientChan:
+		}
+	}
+}
+
+// handleRequest passes a request of the browser to the agent.
+func handleRequest(a *agent.Agent, request *wsRequest) error {
+	switch request.Type {
+	case "send-message":
+		if request.Query == "" {
+			return fmt.Errorf("missing query")
+		}
+		a.Input <- &api.UserInputResponse{Query: request.Query}
+	case "choose-option":
+		a.Input <- &api.UserChoiceResponse{Choice: request.Choice, Command: request.Command}
+	case "interrupt":
+		a.Interrupt()
+	default:
+		return fmt.Errorf("unknown request type %q", request.Type)
+	}
+	return nil
+}
+
+// sessionStream tracks the messages sent to a WebSocket client, so that each
+// update only carries the messages added since the previous one.
+type sessionStream struct {
+	// verbosity selects the messages sent, see visibleMessages.
+	verbosity ui.Verbosity
+	// sessionID is the session of the messages sent, empty before the first update.
+	sessionID string
+	// sent is the number of visible messages sent.
+	sent int
+	// lastID is the ID of the last message sent.
+	lastID string
+}
+
+// update returns the next update of the client: an "append" of the messages
+// added since the previous update, or the whole "state" on the first update
+// and when the messages were replaced, e.g. when the conversation is cleared.
+func (s *sessionStream) update(session *api.Session) map[string]interface{} {
+	messages := visibleMessages(session, s.verbosity)
+	appended := s.sessionID == session.ID && len(messages) >= s.sent && (s.sent == 0 || messages[s.sent-1].ID == s.lastID)
+	start := s.sent
+
+	s.sessionID = session.ID
+	s.sent = len(messages)
+	s.lastID = ""
+	if len(messages) > 0 {
+		s.lastID = messages[len(messages)-1].ID
+	}
+
+	if !appended {
+		state := sessionState(session, s.verbosity)
+		state["type"] = "state"
+		return state
+	}
+	return map[string]interface{}{
+		"type": "append",
+		// start is the index of the first message appended among the visible messages.
+		"start":       start,
+		"messages":    messages[start:],
+		"agentState":  session.AgentState,
+		"sessionId":   session.ID,
+		"sessionName": session.Name,
+	}
+}
@@ -33,17 +33,26 @@ type UI interface {
 type Type string
 
 const (
+	// UITypeAuto selects the UI from the terminal and the flags, see the --ui flag.
+	UITypeAuto     Type = "auto"
 	UITypeTerminal Type = "terminal"
 	UITypeWeb      Type = "web"
 	UITypeTUI      Type = "tui"
+	// UITypeJSONRPC serves editor extensions with JSON-RPC over stdio.
+	UITypeJSONRPC Type = "jsonrpc"
+	// UITypeNone runs the query once and prints its output as plain text.
+	UITypeNone Type = "none"
 )
 
 // Implement pflag.Value for UIType
 func (u *Type) Set(s string) error {
 	switch s {
-	case "terminal", "web", "tui":
+	case "auto", "terminal", "web", "tui", "jsonrpc", "none":
 		*u = Type(s)
 		return nil
+	case "html":
+		*u = UITypeWeb
+		return nil
 	default:
 		return fmt.Errorf("invalid UI type: %s", s)
 	}
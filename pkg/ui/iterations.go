@@ -0,0 +1,134 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"strings"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// messageGroup is a run of messages of a session, either an agent iteration
+// (thought → tool calls → results) or messages outside of any iteration.
+type messageGroup struct {
+	// ID numbers the iterations of the session from 1, it is 0 for the messages outside of iterations.
+	ID int
+	// Number numbers the iterations of the current query from 1.
+	Number   int
+	Messages []*api.Message
+}
+
+// IsIteration returns true if the group is an agent iteration.
+func (g *messageGroup) IsIteration() bool {
+	return g.ID != 0
+}
+
+// ToolCalls returns the number of tool calls of the iteration.
+func (g *messageGroup) ToolCalls() int {
+	n := 0
+	for _, message := range g.Messages {
+		if message.Type == api.MessageTypeToolCallRequest {
+			n++
+		}
+	}
+	return n
+}
+
+// Errors returns the number of errors of the iteration.
+func (g *messageGroup) Errors() int {
+	n := 0
+	for _, message := range g.Messages {
+		if message.Type == api.MessageTypeError {
+			n++
+		}
+	}
+	return n
+}
+
+// Thought returns the first line of the model text of the iteration.
+func (g *messageGroup) Thought() string {
+	for _, message := range g.Messages {
+		if message.Source == api.MessageSourceModel && message.Type == api.MessageTypeText {
+			text, _ := message.Payload.(string)
+			line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+			return line
+		}
+	}
+	return ""
+}
+
+// groupIterations groups the messages of a session into agent iterations. An
+// iteration starts with a model message or a tool call without a preceding
+// model message, and holds the following tool calls and their results. The
+// final answer of the model, which is not followed by tool calls, is not part
+// of an iteration.
+func groupIterations(messages []*api.Message) []*messageGroup {
+	var groups []*messageGroup
+	var current *messageGroup
+	id, number := 0, 0
+
+	startIteration := func() {
+		id++
+		number++
+		current = &messageGroup{ID: id, Number: number}
+		groups = append(groups, current)
+	}
+	appendUngrouped := func(message *api.Message) {
+		if n := len(groups); n > 0 && !groups[n-1].IsIteration() {
+			groups[n-1].Messages = append(groups[n-1].Messages, message)
+			return
+		}
+		groups = append(groups, &messageGroup{Messages: []*api.Message{message}})
+	}
+	// endTurn closes the current iteration, and ungroups it if it is the final answer.
+	endTurn := func() {
+		if current != nil && current.ToolCalls() == 0 && len(groups) > 0 && groups[len(groups)-1] == current {
+			groups = groups[:len(groups)-1]
+			id--
+			for _, message := range current.Messages {
+				appendUngrouped(message)
+			}
+		}
+		current = nil
+	}
+
+	for _, message := range messages {
+		switch {
+		case message.Source == api.MessageSourceUser || message.Type == api.MessageTypeUserInputRequest:
+			endTurn()
+			number = 0
+			appendUngrouped(message)
+		case message.Source == api.MessageSourceModel && message.Type == api.MessageTypeText:
+			// A model message after tool calls starts the next iteration.
+			if current == nil || current.ToolCalls() > 0 {
+				startIteration()
+			}
+			current.Messages = append(current.Messages, message)
+		case message.Type == api.MessageTypeToolCallRequest:
+			if current == nil {
+				startIteration()
+			}
+			current.Messages = append(current.Messages, message)
+		default:
+			if current != nil {
+				current.Messages = append(current.Messages, message)
+			} else {
+				appendUngrouped(message)
+			}
+		}
+	}
+	endTurn()
+	return groups
+}
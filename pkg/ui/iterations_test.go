@@ -0,0 +1,77 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+func TestGroupIterations(t *testing.T) {
+	user := func(text string) *api.Message {
+		return &api.Message{Source: api.MessageSourceUser, Type: api.MessageTypeText, Payload: text}
+	}
+	model := func(text string) *api.Message {
+		return &api.Message{Source: api.MessageSourceModel, Type: api.MessageTypeText, Payload: text}
+	}
+	toolCall := func(command string) *api.Message {
+		return &api.Message{Source: api.MessageSourceAgent, Type: api.MessageTypeToolCallRequest, Payload: command}
+	}
+	toolResult := &api.Message{Source: api.MessageSourceAgent, Type: api.MessageTypeToolCallResponse, Payload: "ok"}
+
+	messages := []*api.Message{
+		user("why is web crashing?"),
+		model("Let me check the pods."),
+		toolCall("kubectl get pods"),
+		toolResult,
+		toolCall("kubectl describe pod web-0"),
+		toolResult,
+		model("Checking the logs."),
+		toolCall("kubectl logs web-0"),
+		toolResult,
+		model("web is out of memory."),
+		user("thanks"),
+		toolCall("kubectl get events"),
+		toolResult,
+		model("No new events."),
+	}
+
+	type group struct {
+		id, number, messages, toolCalls int
+	}
+	want := []group{
+		{id: 0, number: 0, messages: 1, toolCalls: 0},
+		{id: 1, number: 1, messages: 5, toolCalls: 2},
+		{id: 2, number: 2, messages: 3, toolCalls: 1},
+		{id: 0, number: 0, messages: 2, toolCalls: 0},
+		{id: 3, number: 1, messages: 2, toolCalls: 1},
+		{id: 0, number: 0, messages: 1, toolCalls: 0},
+	}
+
+	groups := groupIterations(messages)
+	if len(groups) != len(want) {
+		t.Fatalf("groupIterations() returned %d groups, want %d", len(groups), len(want))
+	}
+	for i, g := range groups {
+		got := group{id: g.ID, number: g.Number, messages: len(g.Messages), toolCalls: g.ToolCalls()}
+		if got != want[i] {
+			t.Errorf("group %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if got := groups[1].Thought(); got != "Let me check the pods." {
+		t.Errorf("Thought() = %q, want the first model message", got)
+	}
+}
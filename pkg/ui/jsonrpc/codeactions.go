@@ -0,0 +1,179 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonrpc
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Position is a position in a document, as in the Language Server Protocol:
+// a 0-based line and a 0-based offset in UTF-16 code units.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a range of a document, the end is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// TextEdit replaces a range of a document.
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// WorkspaceEdit is a set of edits of documents, keyed by the URI of the document.
+type WorkspaceEdit struct {
+	Changes map[string][]TextEdit `json:"changes"`
+}
+
+// CodeAction is an edit of the open document proposed by the model.
+type CodeAction struct {
+	Title string        `json:"title"`
+	Kind  string        `json:"kind"`
+	Edit  WorkspaceEdit `json:"edit"`
+}
+
+var yamlBlockPattern = regexp.MustCompile("(?s)```(?:yaml|yml)[ \t]*\n(.*?)```")
+
+// resourceID identifies a resource in a manifest.
+type resourceID struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+}
+
+func (r resourceID) String() string {
+	return r.Kind + " " + r.Metadata.Name
+}
+
+func parseResourceID(manifest string) (resourceID, bool) {
+	var id resourceID
+	if err := yaml.Unmarshal([]byte(manifest), &id); err != nil {
+		return id, false
+	}
+	return id, id.Kind != "" && id.Metadata.Name != ""
+}
+
+// manifestDocument is a YAML document of a manifest file, spanning lines [start, end).
+type manifestDocument struct {
+	id         resourceID
+	start, end int
+}
+
+// splitManifest splits the lines of a manifest file into its YAML documents.
+func splitManifest(lines []string) []manifestDocument {
+	var documents []manifestDocument
+	start := 0
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) && strings.TrimRight(lines[i], " \t\r\n") != "---" {
+			continue
+		}
+		if id, ok := parseResourceID(strings.Join(lines[start:i], "")); ok {
+			documents = append(documents, manifestDocument{id: id, start: start, end: i})
+		}
+		start = i + 1
+	}
+	return documents
+}
+
+// endOfLine returns the position after the content of a line.
+func endOfLine(lines []string, line int) Position {
+	return Position{Line: line, Character: len(utf16.Encode([]rune(strings.TrimRight(lines[line], "\r\n"))))}
+}
+
+// codeActions returns the edits of the document proposed in the text of the
+// model as YAML blocks. A proposed resource replaces the document of the
+// resource with the same kind and name, and is otherwise added to the file.
+func codeActions(doc *Document, text string) []CodeAction {
+	if doc == nil {
+		return nil
+	}
+	lines := strings.SplitAfter(doc.Text, "\n")
+	documents := splitManifest(lines)
+	file := path.Base(doc.URI)
+
+	var actions []CodeAction
+	for _, match := range yamlBlockPattern.FindAllStringSubmatch(text, -1) {
+		proposed := match[1]
+		id, ok := parseResourceID(proposed)
+		if !ok {
+			continue
+		}
+		if !strings.HasSuffix(proposed, "\n") {
+			proposed += "\n"
+		}
+
+		action := CodeAction{Kind: "quickfix"}
+		var edit TextEdit
+		replaced := false
+		for _, document := range documents {
+			if document.id != id {
+				continue
+			}
+			edit.Range.Start = Position{Line: document.start}
+			if document.end < len(lines) {
+				edit.Range.End = Position{Line: document.end}
+			} else {
+				edit.Range.End = endOfLine(lines, len(lines)-1)
+			}
+			edit.NewText = proposed
+			action.Title = fmt.Sprintf("Apply the proposed %s to %s", id, file)
+			replaced = true
+			break
+		}
+		if !replaced {
+			end := endOfLine(lines, len(lines)-1)
+			edit.Range = Range{Start: end, End: end}
+			switch {
+			case strings.TrimSpace(doc.Text) == "":
+				edit.NewText = proposed
+			case strings.HasSuffix(doc.Text, "\n"):
+				edit.NewText = "---\n" + proposed
+			default:
+				edit.NewText = "\n---\n" + proposed
+			}
+			action.Title = fmt.Sprintf("Add the proposed %s to %s", id, file)
+		}
+		action.Edit = WorkspaceEdit{Changes: map[string][]TextEdit{doc.URI: {edit}}}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// queryWithDocument adds the open document to a query, and asks the model to
+// propose its changes as complete resources, from which the code actions are made.
+func queryWithDocument(query string, doc *Document) string {
+	if doc == nil {
+		return query
+	}
+	language := doc.LanguageID
+	if language == "" {
+		language = strings.TrimPrefix(path.Ext(doc.URI), ".")
+	}
+	return fmt.Sprintf("%s\n\nThe file %s is open in my editor:\n```%s\n%s\n```\n"+
+		"To propose changes to this file, reply with each changed or added resource complete, in its own ```yaml block.",
+		query, doc.URI, language, strings.TrimRight(doc.Text, "\n"))
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+const manifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+`
+
+func TestCodeActions(t *testing.T) {
+	doc := &Document{URI: "file:///app/web.yaml", Text: manifest}
+
+	tests := []struct {
+		name string
+		doc  *Document
+		text string
+		want []CodeAction
+	}{
+		{
+			name: "replaces the document of the same resource",
+			doc:  doc,
+			text: "Scale it up:\n```yaml\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 3\n```\n",
+			want: []CodeAction{{
+				Title: "Apply the proposed Deployment web to web.yaml",
+				Kind:  "quickfix",
+				Edit: WorkspaceEdit{Changes: map[string][]TextEdit{doc.URI: {{
+					Range:   Range{Start: Position{Line: 0}, End: Position{Line: 6}},
+					NewText: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 3\n",
+				}}}},
+			}},
+		},
+		{
+			name: "replaces the last document up to the end of the file",
+			doc:  doc,
+			text: "```yaml\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\nspec:\n  ports:\n  - port: 8080\n```",
+			want: []CodeAction{{
+				Title: "Apply the proposed Service web to web.yaml",
+				Kind:  "quickfix",
+				Edit: WorkspaceEdit{Changes: map[string][]TextEdit{doc.URI: {{
+					Range:   Range{Start: Position{Line: 7}, End: Position{Line: 14}},
+					NewText: "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\nspec:\n  ports:\n  - port: 8080\n",
+				}}}},
+			}},
+		},
+		{
+			name: "adds a new resource",
+			doc:  doc,
+			text: "```yml\napiVersion: policy/v1\nkind: PodDisruptionBudget\nmetadata:\n  name: web\n```",
+			want: []CodeAction{{
+				Title: "Add the proposed PodDisruptionBudget web to web.yaml",
+				Kind:  "quickfix",
+				Edit: WorkspaceEdit{Changes: map[string][]TextEdit{doc.URI: {{
+					Range:   Range{Start: Position{Line: 14}, End: Position{Line: 14}},
+					NewText: "---\napiVersion: policy/v1\nkind: PodDisruptionBudget\nmetadata:\n  name: web\n",
+				}}}},
+			}},
+		},
+		{
+			name: "ignores blocks that are not resources",
+			doc:  doc,
+			text: "```yaml\nreplicas: 3\n```\n```shell\nkubectl get pods\n```",
+		},
+		{
+			name: "no document",
+			text: "```yaml\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n```",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := codeActions(tt.doc, tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("codeActions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageFraming(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeMessage(&buf, &message{Method: "block", Params: []byte(`{"index":1}`)}); err != nil {
+		t.Fatalf("writeMessage() error: %v", err)
+	}
+	want := "Content-Length: 55\r\n\r\n" + `{"jsonrpc":"2.0","method":"block","params":{"index":1}}`
+	if buf.String() != want {
+		t.Fatalf("writeMessage() wrote %q, want %q", buf.String(), want)
+	}
+
+	body, err := readMessage(bufio.NewReader(&buf))
+	if err != nil {
+		t.Fatalf("readMessage() error: %v", err)
+	}
+	if got := string(body); got != `{"jsonrpc":"2.0","method":"block","params":{"index":1}}` {
+		t.Errorf("readMessage() = %q", got)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// The JSON-RPC 2.0 error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	// codeBusy is returned when the agent is not in a state to handle the request,
+	// e.g. a query is sent while another one is running.
+	codeBusy = -32002
+)
+
+// message is a JSON-RPC 2.0 request, notification or response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Document is a file open in the editor, sent with a query.
+type Document struct {
+	// URI is the URI of the file, e.g. "file:///home/me/app/deployment.yaml".
+	URI string `json:"uri"`
+	// LanguageID is the language of the file in the editor, e.g. "yaml".
+	LanguageID string `json:"languageId,omitempty"`
+	// Text is the content of the file, including the unsaved changes.
+	Text string `json:"text"`
+}
+
+// QueryParams are the parameters of the query method.
+type QueryParams struct {
+	Query    string    `json:"query"`
+	Document *Document `json:"document,omitempty"`
+}
+
+// QueryResult is the result of the query method, sent when the agent has
+// answered the query.
+type QueryResult struct {
+	// Answer is the last text of the model.
+	Answer string `json:"answer,omitempty"`
+	// CodeActions are the edits of the open document proposed by the model.
+	CodeActions []CodeAction `json:"codeActions,omitempty"`
+}
+
+// ChooseParams are the parameters of the choose method, answering an approval prompt.
+type ChooseParams struct {
+	// Choice is the 1-based index of the chosen option.
+	Choice int `json:"choice"`
+	// Command is the edited command, for the edit option.
+	Command string `json:"command,omitempty"`
+}
+
+// AnswerParams are the parameters of the answer method, answering the prompt of a command.
+type AnswerParams struct {
+	Answer string `json:"answer"`
+}
+
+// Block is a message of the agent, streamed to the editor with the block notification.
+type Block struct {
+	Index     int               `json:"index"`
+	Source    api.MessageSource `json:"source"`
+	Type      api.MessageType   `json:"type"`
+	Payload   any               `json:"payload,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// readMessage reads a message framed by a Content-Length header, as in the
+// Language Server Protocol.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("reading message body: %w", err)
+	}
+	return body, nil
+}
+
+// writeMessage writes a message framed by a Content-Length header.
+func writeMessage(w io.Writer, msg *message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
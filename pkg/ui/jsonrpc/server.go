@@ -0,0 +1,329 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jsonrpc implements a JSON-RPC 2.0 interface over stdio for editor
+// extensions. Messages are framed by a Content-Length header, as in the
+// Language Server Protocol.
+//
+// The editor calls:
+//   - initialize, returning the server info.
+//   - query {query, document}, answered when the agent has answered the query,
+//     with the code actions proposing edits of the open document.
+//   - choose {choice, command}, answering an approval prompt.
+//   - answer {answer}, answering the prompt of a running command.
+//   - shutdown, ending the session.
+//
+// While a query runs, the messages of the agent are sent to the editor with
+// block notifications.
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/agent"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/ui"
+	"k8s.io/klog/v2"
+)
+
+// Server serves an agent to an editor over JSON-RPC.
+type Server struct {
+	agent *agent.Agent
+	in    *bufio.Reader
+
+	outMu sync.Mutex
+	out   io.Writer
+
+	mu sync.Mutex
+	// query is the running query, nil when the agent waits for a query.
+	query *runningQuery
+	// ready is true while the agent waits for a query.
+	ready bool
+	// waitingForChoice is true while the agent waits for the answer to an approval prompt.
+	waitingForChoice bool
+	// waitingForAnswer is true while a command waits for the answer to its prompt.
+	waitingForAnswer bool
+	// blocks counts the messages of the agent.
+	blocks int
+}
+
+// runningQuery is a query the agent has not answered yet.
+type runningQuery struct {
+	id       json.RawMessage
+	query    string
+	document *Document
+	// sent is false until the agent is ready for the query.
+	sent    bool
+	answer  string
+	actions []CodeAction
+}
+
+var _ ui.UI = &Server{}
+
+// NewServer returns a server reading the requests of the editor from in and
+// writing the responses and notifications to out.
+func NewServer(agent *agent.Agent, in io.Reader, out io.Writer) *Server {
+	return &Server{
+		agent: agent,
+		in:    bufio.NewReader(in),
+		out:   out,
+	}
+}
+
+func (s *Server) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	agentExited := make(chan struct{})
+	go func() {
+		defer close(agentExited)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-s.agent.Output:
+				if !ok {
+					return
+				}
+				s.handleMessage(msg.(*api.Message))
+				if s.agent.GetSession().AgentState == api.AgentStateExited {
+					return
+				}
+			}
+		}
+	}()
+
+	requestsDone := make(chan error, 1)
+	go func() {
+		requestsDone <- s.serve()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-agentExited:
+		s.failQuery(errors.New("the agent exited"))
+		return s.agent.LastErr()
+	case err := <-requestsDone:
+		// The editor closed its end, or asked to shut down.
+		s.agent.Input <- io.EOF
+		return err
+	}
+}
+
+func (s *Server) ClearScreen() {
+	// Not applicable for editors.
+}
+
+// serve handles the requests of the editor until it shuts down the session or
+// closes the input.
+func (s *Server) serve() error {
+	for {
+		body, err := readMessage(s.in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading request: %w", err)
+		}
+
+		var req message
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.respondError(nil, codeParseError, err.Error())
+			continue
+		}
+		if req.Method == "" {
+			s.respondError(req.ID, codeInvalidRequest, "missing method")
+			continue
+		}
+		if req.Method == "shutdown" {
+			s.respond(req.ID, struct{}{})
+			return nil
+		}
+		s.handleRequest(&req)
+	}
+}
+
+func (s *Server) handleRequest(req *message) {
+	switch req.Method {
+	case "initialize":
+		s.respond(req.ID, map[string]any{
+			"serverInfo": map[string]string{"name": "kubectl-ai"},
+			"methods":    []string{"query", "choose", "answer", "shutdown"},
+		})
+
+	case "query":
+		var params QueryParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || strings.TrimSpace(params.Query) == "" {
+			s.respondError(req.ID, codeInvalidParams, "query requires a non-empty query")
+			return
+		}
+		s.mu.Lock()
+		if s.query != nil {
+			s.mu.Unlock()
+			s.respondError(req.ID, codeBusy, "another query is running")
+			return
+		}
+		s.query = &runningQuery{id: req.ID, query: queryWithDocument(params.Query, params.Document), document: params.Document}
+		if s.ready {
+			s.sendQuery()
+		}
+		s.mu.Unlock()
+
+	case "choose":
+		var params ChooseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Choice < 1 {
+			s.respondError(req.ID, codeInvalidParams, "choose requires a choice")
+			return
+		}
+		s.mu.Lock()
+		waiting := s.waitingForChoice
+		s.waitingForChoice = false
+		s.mu.Unlock()
+		if !waiting {
+			s.respondError(req.ID, codeBusy, "the agent is not waiting for a choice")
+			return
+		}
+		s.agent.Input <- &api.UserChoiceResponse{Choice: params.Choice, Command: params.Command}
+		s.respond(req.ID, struct{}{})
+
+	case "answer":
+		var params AnswerParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			s.respondError(req.ID, codeInvalidParams, err.Error())
+			return
+		}
+		s.mu.Lock()
+		waiting := s.waitingForAnswer
+		s.waitingForAnswer = false
+		s.mu.Unlock()
+		if !waiting {
+			s.respondError(req.ID, codeBusy, "no command is waiting for an answer")
+			return
+		}
+		s.agent.Input <- &api.UserInputResponse{Query: params.Answer}
+		s.respond(req.ID, struct{}{})
+
+	default:
+		if req.ID == nil {
+			// Unknown notifications are ignored.
+			return
+		}
+		s.respondError(req.ID, codeMethodNotFound, fmt.Sprintf("method %q not found", req.Method))
+	}
+}
+
+// handleMessage streams a message of the agent to the editor, and answers the
+// running query when the agent waits for the next one.
+func (s *Server) handleMessage(msg *api.Message) {
+	s.mu.Lock()
+	query := s.query
+	if query != nil && !query.sent {
+		// The query was received before the agent was ready, e.g. while it
+		// greets the user.
+		query = nil
+	}
+	switch msg.Type {
+	case api.MessageTypeUserInputRequest:
+		// The agent answered the query, and waits for the next one.
+		s.ready = true
+		if query != nil {
+			s.query = nil
+		} else if s.query != nil {
+			s.sendQuery()
+		}
+	case api.MessageTypeUserChoiceRequest:
+		s.waitingForChoice = true
+	case api.MessageTypeToolInputRequest:
+		s.waitingForAnswer = true
+	case api.MessageTypeText:
+		if query != nil && msg.Source == api.MessageSourceModel {
+			text, _ := msg.Payload.(string)
+			query.answer = text
+			query.actions = append(query.actions, codeActions(query.document, text)...)
+		}
+	}
+	index := s.blocks
+	s.blocks++
+	s.mu.Unlock()
+
+	if query == nil {
+		// The messages before the first query, e.g. the greeting, are not sent.
+		return
+	}
+	if msg.Type == api.MessageTypeUserInputRequest {
+		s.respond(query.id, &QueryResult{Answer: query.answer, CodeActions: query.actions})
+		return
+	}
+	s.notify("block", &Block{
+		Index:     index,
+		Source:    msg.Source,
+		Type:      msg.Type,
+		Payload:   msg.Payload,
+		Timestamp: msg.Timestamp,
+	})
+}
+
+// sendQuery sends the running query to the agent, s.mu must be held.
+func (s *Server) sendQuery() {
+	s.ready = false
+	s.query.sent = true
+	s.agent.Input <- &api.UserInputResponse{Query: s.query.query}
+}
+
+// failQuery answers the running query with an error.
+func (s *Server) failQuery(err error) {
+	s.mu.Lock()
+	query := s.query
+	s.query = nil
+	s.mu.Unlock()
+	if query != nil {
+		s.respondError(query.id, codeBusy, err.Error())
+	}
+}
+
+func (s *Server) respond(id json.RawMessage, result any) {
+	s.write(&message{ID: id, Result: result})
+}
+
+func (s *Server) respondError(id json.RawMessage, code int, text string) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	s.write(&message{ID: id, Error: &responseError{Code: code, Message: text}})
+}
+
+func (s *Server) notify(method string, params any) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		klog.Errorf("Encoding %s notification: %v", method, err)
+		return
+	}
+	s.write(&message{Method: method, Params: data})
+}
+
+func (s *Server) write(msg *message) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if err := writeMessage(s.out, msg); err != nil {
+		klog.Errorf("Writing JSON-RPC message: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/agent"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// StreamJSONUI writes each message of the agent as a JSON line as it occurs,
+// for programs and test harnesses wrapping kubectl-ai. It runs a single query,
+// and ends the stream with its result.
+type StreamJSONUI struct {
+	agent *agent.Agent
+	out   io.Writer
+
+	// answer is the last text of the model.
+	answer string
+}
+
+var _ UI = &StreamJSONUI{}
+
+func NewStreamJSONUI(agent *agent.Agent, out io.Writer) *StreamJSONUI {
+	return &StreamJSONUI{agent: agent, out: out}
+}
+
+// streamEvent is a line of the JSON stream.
+type streamEvent struct {
+	ID        string            `json:"id,omitempty"`
+	Type      api.MessageType   `json:"type"`
+	Source    api.MessageSource `json:"source"`
+	Content   any               `json:"content,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	// AttachmentID is set when the content is a preview of a large tool result.
+	AttachmentID string `json:"attachmentId,omitempty"`
+}
+
+func writeStreamEvent(w io.Writer, msg *api.Message) error {
+	line, err := json.Marshal(&streamEvent{
+		ID:           msg.ID,
+		Type:         msg.Type,
+		Source:       msg.Source,
+		Content:      msg.Payload,
+		Timestamp:    msg.Timestamp,
+		AttachmentID: msg.AttachmentID,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding message %s: %w", msg.ID, err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", line)
+	return err
+}
+
+// streamResult is the last line of the JSON stream: the final answer of the
+// query, its error and the tokens used.
+type streamResult struct {
+	Type      string           `json:"type"`
+	Answer    string           `json:"answer,omitempty"`
+	Error     string           `json:"error,omitempty"`
+	Usage     []api.ModelUsage `json:"usage,omitempty"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+func writeStreamResult(w io.Writer, answer string, err error, usage []api.ModelUsage) error {
+	result := &streamResult{Type: "result", Answer: answer, Usage: usage, Timestamp: time.Now()}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	line, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("encoding result: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", line)
+	return err
+}
+
+func (u *StreamJSONUI) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-u.agent.Output:
+			if !ok {
+				return u.finish()
+			}
+			message := msg.(*api.Message)
+			if message.Source == api.MessageSourceModel && message.Type == api.MessageTypeText {
+				if text, ok := message.Payload.(string); ok {
+					u.answer = text
+				}
+			}
+			if err := writeStreamEvent(u.out, message); err != nil {
+				return err
+			}
+			if u.agent.GetSession().AgentState == api.AgentStateExited {
+				return u.finish()
+			}
+		}
+	}
+}
+
+// finish writes the result of the query and returns its error.
+func (u *StreamJSONUI) finish() error {
+	lastErr := u.agent.LastErr()
+	if err := writeStreamResult(u.out, u.answer, lastErr, u.agent.SessionUsage()); err != nil {
+		return err
+	}
+	return lastErr
+}
+
+func (u *StreamJSONUI) ClearScreen() {
+	// Not applicable for JSON streams.
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+func TestWriteStreamEvent(t *testing.T) {
+	timestamp := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	messages := []*api.Message{
+		{ID: "1", Source: api.MessageSourceModel, Type: api.MessageTypeToolCallRequest, Payload: "kubectl get pods", Timestamp: timestamp},
+		{ID: "2", Source: api.MessageSourceAgent, Type: api.MessageTypeToolCallResponse, Payload: map[string]any{"stdout": "web-0 Running\n"}, Timestamp: timestamp},
+	}
+
+	var buf bytes.Buffer
+	for _, msg := range messages {
+		if err := writeStreamEvent(&buf, msg); err != nil {
+			t.Fatalf("writeStreamEvent() error: %v", err)
+		}
+	}
+
+	want := `{"id":"1","type":"tool-call-request","source":"model","content":"kubectl get pods","timestamp":"2025-06-01T12:00:00Z"}
+{"id":"2","type":"tool-call-response","source":"agent","content":{"stdout":"web-0 Running\n"},"timestamp":"2025-06-01T12:00:00Z"}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("writeStreamEvent() wrote:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteStreamResult(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		usage []api.ModelUsage
+		want  streamResult
+	}{
+		{
+			name:  "answer",
+			usage: []api.ModelUsage{{Provider: "gemini", Model: "gemini-2.5-pro", InputTokens: 1200, OutputTokens: 80}},
+			want: streamResult{Type: "result", Answer: "The web pod is out of memory.",
+				Usage: []api.ModelUsage{{Provider: "gemini", Model: "gemini-2.5-pro", InputTokens: 1200, OutputTokens: 80}}},
+		},
+		{
+			name: "error",
+			err:  errors.New("quota exceeded"),
+			want: streamResult{Type: "result", Answer: "The web pod is out of memory.", Error: "quota exceeded"},
+		},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeStreamResult(&buf, "The web pod is out of memory.", tt.err, tt.usage); err != nil {
+			t.Fatalf("%s: writeStreamResult() error: %v", tt.name, err)
+		}
+		var got streamResult
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("%s: decoding %q: %v", tt.name, buf.String(), err)
+		}
+		got.Timestamp = time.Time{}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: writeStreamResult() wrote %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -77,8 +77,8 @@ type TerminalUI struct {
 	// in such cases, stdin is already consumed and closed and reading input results in IO error.
 	// In such cases, we open /dev/tty and use it for taking input.
 	useTTYForInput bool
-	// showToolOutput disables truncation of tool output.
-	showToolOutput bool
+	// verbosity selects the messages shown.
+	verbosity Verbosity
 
 	agent *agent.Agent
 }
@@ -110,7 +110,7 @@ func getCustomTerminalWidth() int {
 	return 0
 }
 
-func NewTerminalUI(agent *agent.Agent, useTTYForInput bool, showToolOutput bool, journal journal.Recorder) (*TerminalUI, error) {
+func NewTerminalUI(agent *agent.Agent, useTTYForInput bool, verbosity Verbosity, journal journal.Recorder) (*TerminalUI, error) {
 	width := getCustomTerminalWidth()
 
 	options := []glamour.TermRendererOption{
@@ -134,7 +134,7 @@ func NewTerminalUI(agent *agent.Agent, useTTYForInput bool, showToolOutput bool,
 		journal:          journal,
 		useTTYForInput:   useTTYForInput, // Store this flag
 		agent:            agent,
-		showToolOutput:   showToolOutput,
+		verbosity:        verbosity,
 	}
 
 	return u, nil
@@ -187,6 +187,61 @@ func (u *TerminalUI) Run(ctx context.Context) error {
 	}
 }
 
+// answerToolPrompt reads the answer of the user to the prompt of a tool
+// subprocess. Unlike queries, empty answers are sent, e.g. to accept a default.
+func (u *TerminalUI) answerToolPrompt(prompt string) {
+	prompt = fmt.Sprintf("\n  The command is waiting for input: %s ", prompt)
+	var answer string
+	var err error
+	if u.useTTYForInput {
+		var tReader *bufio.Reader
+		tReader, err = u.ttyReader()
+		if err == nil {
+			fmt.Print(prompt)
+			answer, err = tReader.ReadString('\n')
+		}
+	} else {
+		var rlInstance *readline.Instance
+		rlInstance, err = u.readlineInstance()
+		if err == nil {
+			if strings.Contains(strings.ToLower(prompt), "password") {
+				var password []byte
+				password, err = rlInstance.ReadPassword(prompt)
+				answer = string(password)
+			} else {
+				rlInstance.SetPrompt(prompt)
+				answer, err = rlInstance.Readline()
+			}
+		}
+	}
+	if err != nil {
+		// The agent closes the stdin of the command, which then fails instead of hanging.
+		klog.Infof("Reading the answer to the prompt of the command: %v", err)
+		u.agent.Input <- io.EOF
+		return
+	}
+	u.agent.Input <- &api.UserInputResponse{Query: strings.TrimRight(answer, "\r\n")}
+}
+
+// readEditedCommand lets the user edit a command before approving it.
+func (u *TerminalUI) readEditedCommand(command string) (string, error) {
+	if u.useTTYForInput {
+		tReader, err := u.ttyReader()
+		if err != nil {
+			return "", err
+		}
+		fmt.Printf("Current command: %s\nEnter the edited command: ", command)
+		line, err := tReader.ReadString('\n')
+		return strings.TrimSpace(line), err
+	}
+	rlInstance, err := u.readlineInstance()
+	if err != nil {
+		return "", err
+	}
+	rlInstance.SetPrompt("Edit the command: ")
+	return rlInstance.ReadlineWithDefault(command)
+}
+
 func (u *TerminalUI) ttyReader() (*bufio.Reader, error) {
 	if u.ttyReaderInstance != nil {
 		return u.ttyReaderInstance, nil
@@ -244,6 +299,9 @@ func (u *TerminalUI) Close() error {
 }
 
 func (u *TerminalUI) handleMessage(msg *api.Message) {
+	if !u.verbosity.Shows(msg) {
+		return
+	}
 	text := ""
 	var styleOptions []styleOption
 
@@ -259,6 +317,9 @@ func (u *TerminalUI) handleMessage(msg *api.Message) {
 			styleOptions = append(styleOptions, renderMarkdown(), foreground(colorGreen))
 		case api.MessageSourceModel:
 			styleOptions = append(styleOptions, renderMarkdown())
+			if len(msg.ResourceLinks) > 0 {
+				text += "\n\n" + resourceLinksLine(msg.ResourceLinks)
+			}
 		}
 	case api.MessageTypeError:
 		styleOptions = append(styleOptions, foreground(colorRed))
@@ -266,10 +327,17 @@ func (u *TerminalUI) handleMessage(msg *api.Message) {
 	case api.MessageTypeToolCallRequest:
 		styleOptions = append(styleOptions, foreground(colorGreen))
 		text = fmt.Sprintf("\n  Running: %s\n", msg.Payload.(string))
+	case api.MessageTypeToolCallProgress:
+		text = msg.Payload.(string)
+	case api.MessageTypeProgress:
+		// The terminal cannot update a line in place, each update is printed.
+		text = progressBar(msg.Payload.(*api.ProgressBlock)) + "\n"
+	case api.MessageTypeArtifact:
+		text = artifactLine(msg.Payload.(*api.ArtifactBlock)) + "\n"
+	case api.MessageTypeToolInputRequest:
+		u.answerToolPrompt(msg.Payload.(string))
+		return
 	case api.MessageTypeToolCallResponse:
-		if !u.showToolOutput {
-			return
-		}
 		styleOptions = append(styleOptions, renderMarkdown())
 		output, err := tools.ToolResultToMap(msg.Payload)
 
@@ -416,6 +484,13 @@ func (u *TerminalUI) handleMessage(msg *api.Message) {
 			if input == "n" || input == "no" {
 				input = "3"
 			}
+			// Handle the shortcuts of the other options, e.g. "e" for edit
+			for i, option := range choiceRequest.Options {
+				if option.Value != "" && (input == option.Value || input == option.Value[:1]) {
+					input = strconv.Itoa(i + 1)
+					break
+				}
+			}
 
 			choiceIdx, err := strconv.Atoi(input)
 			if err == nil && choiceIdx > 0 && choiceIdx <= len(choiceRequest.Options) {
@@ -425,7 +500,20 @@ func (u *TerminalUI) handleMessage(msg *api.Message) {
 
 			fmt.Println("Invalid choice. Please try again.")
 		}
-		u.agent.Input <- &api.UserChoiceResponse{Choice: choice}
+		response := &api.UserChoiceResponse{Choice: choice}
+		if choiceRequest.Options[choice-1].Value == "edit" && len(choiceRequest.Commands) > 0 {
+			command, err := u.readEditedCommand(choiceRequest.Commands[0])
+			if err != nil {
+				klog.Infof("Reading the edited command: %v", err)
+				u.agent.Input <- io.EOF
+				return
+			}
+			response.Command = command
+		}
+		u.agent.Input <- response
+		return
+	case api.MessageTypeTable, api.MessageTypeCode:
+		// The blocks repeat the tool output, which is printed with --show-tool-output.
 		return
 	default:
 		klog.Warningf("unsupported message type: %v", msg.Type)
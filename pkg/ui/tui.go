@@ -25,6 +25,7 @@ import (
 
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/agent"
 	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/tools"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
@@ -35,7 +36,15 @@ import (
 	"k8s.io/klog/v2"
 )
 
-const listHeight = 5
+const (
+	listHeight = 5
+	// inputCharLimit bounds the messages typed by the user, not the commands they edit.
+	inputCharLimit = 280
+	// renderWindowBlocks is the number of most recent blocks, iteration headers
+	// and messages, rendered in the viewport. The earlier blocks are rendered
+	// when the user scrolls up to them, so long sessions stay responsive.
+	renderWindowBlocks = 200
+)
 
 var (
 	spinnerStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
@@ -49,6 +58,11 @@ var (
 	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
 	paginationStyle   = list.DefaultStyles().PaginationStyle.PaddingLeft(4)
 	quitTextStyle     = lipgloss.NewStyle().Margin(1, 0, 2, 4)
+
+	iterationStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("63")).Bold(true)
+	iterationSummaryStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	iterationErrorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	selectedIterationStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
 )
 
 type item string
@@ -99,27 +113,28 @@ type TUI struct {
 	agent   *agent.Agent
 }
 
-func NewTUI(agent *agent.Agent) *TUI {
+// NewTUI returns the rich terminal user interface. Unless inline is set, it
+// takes over the terminal with the alternate screen.
+func NewTUI(agent *agent.Agent, inline bool, verbosity Verbosity) *TUI {
 	return &TUI{
-		program: tea.NewProgram(newModel(agent), tea.WithAltScreen()),
+		program: tea.NewProgram(newModel(agent, inline, verbosity), programOptions(inline)...),
 		agent:   agent,
 	}
 }
 
+// programOptions returns the options of the TUI program: the alternate screen,
+// unless inline is set, so that the session stays in the terminal scrollback.
+func programOptions(inline bool) []tea.ProgramOption {
+	if inline {
+		return nil
+	}
+	return []tea.ProgramOption{tea.WithAltScreen()}
+}
+
 func (u *TUI) Run(ctx context.Context) error {
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case msg, ok := <-u.agent.Output:
-				if !ok {
-					return
-				}
-				u.program.Send(msg)
-			}
-		}
-	}()
+	// Each message re-renders the whole session, so the messages output
+	// while streaming are coalesced.
+	go Coalesce(ctx, u.agent.Output, RefreshInterval, func(msg any) { u.program.Send(msg) })
 
 	_, err := u.program.Run()
 	return err
@@ -160,15 +175,62 @@ type model struct {
 	list     list.Model
 	choice   string
 	username string // cached username
+
+	// editingCommand is true while the user edits a command before approving it.
+	editingCommand bool
+
+	// expanded records the iterations folded or unfolded by the user, by iteration ID.
+	expanded map[int]bool
+	// selectedIteration is the ID of the iteration toggled by ctrl+o, 0 for the latest one.
+	selectedIteration int
+
+	// inline renders the TUI below the shell prompt instead of in the alternate
+	// screen: the messages are printed to the scrollback of the terminal as
+	// they arrive, and only the input is redrawn. This plays nicely with tmux
+	// panes, but the iterations cannot be folded.
+	inline bool
+	// printed is the number of messages of the session printed to the scrollback in inline mode.
+	printed int
+	// verbosity selects the messages shown.
+	verbosity Verbosity
+
+	// window is the number of most recent blocks rendered in the viewport, 0
+	// for renderWindowBlocks.
+	window int
+	// rendered caches the rendered messages, which do not change once added.
+	rendered *renderCache
+}
+
+// renderCache holds the rendered messages by message ID, for a viewport width.
+type renderCache struct {
+	width    int
+	messages map[string]string
+}
+
+// get returns the rendered message, rendering it if it is not cached.
+func (c *renderCache) get(message *api.Message, width int, render func(*api.Message) string) string {
+	if message.ID == "" {
+		return render(message)
+	}
+	if c.width != width || c.messages == nil {
+		c.width = width
+		c.messages = make(map[string]string)
+	}
+	text, ok := c.messages[message.ID]
+	if !ok {
+		text = render(message)
+		c.messages[message.ID] = text
+	}
+	return text
 }
 
-func newModel(agent *agent.Agent) model {
+func newModel(agent *agent.Agent, inline bool, verbosity Verbosity) model {
 	ta := textarea.New()
 	ta.Placeholder = "Send a message..."
 	ta.Focus()
 
 	ta.Prompt = "┃ "
-	ta.CharLimit = 280
+	ta.CharLimit = inputCharLimit
 
 	ta.SetWidth(30)
 	ta.SetHeight(5)
@@ -196,7 +258,8 @@ func newModel(agent *agent.Agent) model {
 
 	vp := viewport.New(30, 5)
 	vp.SetContent(`Welcome to the chat room!
-Type a message and press Enter to send.`)
+Type a message and press Enter to send.
+Use ctrl+up and ctrl+down to select an iteration, and ctrl+o to fold or unfold it.`)
 
 	ta.KeyMap.InsertNewline.SetEnabled(false)
 
@@ -208,6 +271,10 @@ Type a message and press Enter to send.`)
 		// a lipgloss style for the sender
 		senderStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("5")),
 		username:    getCurrentUsername(),
+		expanded:    make(map[int]bool),
+		inline:      inline,
+		verbosity:   verbosity,
+		rendered:    &renderCache{},
 		err:         nil,
 	}
 }
@@ -224,9 +291,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		listCmd tea.Cmd
 	)
 
-	m.textarea, tiCmd = m.textarea.Update(msg)
+	// Keys answer the choice request, they are not typed in the textarea.
+	if m.choiceRequest() == nil || m.editingCommand {
+		m.textarea, tiCmd = m.textarea.Update(msg)
+	}
 	m.viewport, vpCmd = m.viewport.Update(msg)
-	m.list, listCmd = m.list.Update(msg)
+	if !m.editingCommand {
+		m.list, listCmd = m.list.Update(msg)
+	}
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -246,16 +318,49 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.viewport.GotoBottom()
 	case tea.KeyMsg:
+		if choiceRequest := m.choiceRequest(); choiceRequest != nil {
+			if m.editingCommand {
+				switch msg.Type {
+				case tea.KeyEsc:
+					// Go back to the choices.
+					m.editingCommand = false
+					m.textarea.Reset()
+					m.textarea.CharLimit = inputCharLimit
+					return m, nil
+				case tea.KeyEnter:
+					m.editingCommand = false
+					m.agent.Input <- &api.UserChoiceResponse{Choice: optionIndex(choiceRequest, "edit") + 1, Command: m.textarea.Value()}
+					m.textarea.Reset()
+					m.textarea.CharLimit = inputCharLimit
+					return m, nil
+				}
+			} else if msg.Type == tea.KeyRunes && len(msg.Runes) == 1 {
+				if index := shortcutOption(choiceRequest, msg.Runes[0]); index >= 0 {
+					return m.choose(choiceRequest, index)
+				}
+			}
+		}
+
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc, tea.KeyCtrlD:
 			return m, tea.Quit
+		case tea.KeyUp, tea.KeyPgUp:
+			// Scrolling up past the top of the window renders the earlier blocks.
+			if !m.inline && m.viewport.AtTop() {
+				m.showEarlierBlocks()
+			}
+		case tea.KeyCtrlUp, tea.KeyCtrlDown, tea.KeyCtrlO:
+			if m.inline {
+				// The printed iterations cannot be folded.
+				return m, tea.Batch(tiCmd, vpCmd, listCmd)
+			}
+			m.navigateIterations(msg.Type)
+			m.viewport.SetContent(strings.Join(m.renderedMessages(), "\n"))
+			return m, tea.Batch(tiCmd, vpCmd, listCmd)
 		case tea.KeyEnter:
-			if m.agent.GetSession().AgentState == api.AgentStateWaitingForInput {
-				i, ok := m.list.SelectedItem().(item)
-				if ok {
-					m.choice = string(i)
-					choiceIndex := m.list.Index()
-					m.agent.Input <- &api.UserChoiceResponse{Choice: choiceIndex + 1}
+			if m.agent.GetSession().AgentState == api.AgentStateWaitingForInput && !m.waitingForToolInput() {
+				if choiceRequest := m.choiceRequest(); choiceRequest != nil {
+					return m.choose(choiceRequest, m.list.Index())
 				}
 				return m, nil
 			}
@@ -265,6 +370,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				Type:    api.MessageTypeText,
 				Payload: m.textarea.Value(),
 			})
+			m.window = 0
 			m.viewport.SetContent(strings.Join(m.renderedMessages(), "\n"))
 			m.agent.Input <- &api.UserInputResponse{Query: m.textarea.Value()}
 			m.textarea.Reset()
@@ -272,6 +378,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case *api.Message:
 		m.messages = m.agent.GetSession().AllMessages()
+		if m.inline {
+			return m, tea.Batch(tiCmd, vpCmd, listCmd, m.printNewMessages())
+		}
 		m.viewport.SetContent(strings.Join(m.renderedMessages(), "\n"))
 		m.viewport.GotoBottom()
 
@@ -285,47 +394,309 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 }
 
+// choiceRequest returns the choice request the agent waits on, if any.
+func (m model) choiceRequest() *api.UserChoiceRequest {
+	if m.agent.GetSession().AgentState != api.AgentStateWaitingForInput || len(m.messages) == 0 {
+		return nil
+	}
+	if lastMsg := m.messages[len(m.messages)-1]; lastMsg.Type == api.MessageTypeUserChoiceRequest {
+		choiceRequest, _ := lastMsg.Payload.(*api.UserChoiceRequest)
+		return choiceRequest
+	}
+	return nil
+}
+
+// choose answers the choice request with the option at index. The edit
+// option lets the user edit the command in the textarea first.
+func (m model) choose(choiceRequest *api.UserChoiceRequest, index int) (tea.Model, tea.Cmd) {
+	if index < 0 || index >= len(choiceRequest.Options) {
+		return m, nil
+	}
+	option := choiceRequest.Options[index]
+	if option.Value == "edit" && len(choiceRequest.Commands) > 0 {
+		m.editingCommand = true
+		m.textarea.Reset()
+		m.textarea.CharLimit = 0
+		m.textarea.SetValue(choiceRequest.Commands[0])
+		return m, nil
+	}
+	m.choice = option.Label
+	m.agent.Input <- &api.UserChoiceResponse{Choice: index + 1}
+	return m, nil
+}
+
+// choiceShortcuts are the keys answering the approval prompt with a single key press.
+var choiceShortcuts = []struct {
+	key   rune
+	value string
+}{
+	{key: 'y', value: "yes"},
+	{key: 'n', value: "no"},
+	{key: 'e', value: "edit"},
+	{key: 's', value: "skip"},
+	{key: 'a', value: "always"},
+}
+
+// shortcutOption returns the index of the option selected by a key press, the
+// option number or its shortcut, or -1.
+func shortcutOption(choiceRequest *api.UserChoiceRequest, key rune) int {
+	if key >= '1' && key <= '9' {
+		if index := int(key - '1'); index < len(choiceRequest.Options) {
+			return index
+		}
+		return -1
+	}
+	for _, shortcut := range choiceShortcuts {
+		if shortcut.key == key {
+			return optionIndex(choiceRequest, shortcut.value)
+		}
+	}
+	return -1
+}
+
+// optionIndex returns the index of the option with the value, or -1.
+func optionIndex(choiceRequest *api.UserChoiceRequest, value string) int {
+	for i, option := range choiceRequest.Options {
+		if option.Value == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// shortcutsHelp describes the shortcuts of the choice request, e.g. "y(es), n(o)".
+func shortcutsHelp(choiceRequest *api.UserChoiceRequest) string {
+	var help []string
+	for _, shortcut := range choiceShortcuts {
+		if optionIndex(choiceRequest, shortcut.value) >= 0 {
+			help = append(help, fmt.Sprintf("%c(%s)", shortcut.key, shortcut.value[1:]))
+		}
+	}
+	return strings.Join(help, " ")
+}
+
+// waitingForToolInput returns true if a tool subprocess waits for the user to answer its prompt.
+func (m model) waitingForToolInput() bool {
+	return len(m.messages) > 0 && m.messages[len(m.messages)-1].Type == api.MessageTypeToolInputRequest
+}
+
+// navigateIterations selects the previous or next iteration (ctrl+up, ctrl+down)
+// or folds and unfolds the selected one (ctrl+o).
+func (m *model) navigateIterations(key tea.KeyType) {
+	groups := m.iterations()
+	var ids []int
+	for _, group := range groups {
+		if group.IsIteration() {
+			ids = append(ids, group.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	selected := m.selectedIteration
+	if selected == 0 || selected > ids[len(ids)-1] {
+		selected = ids[len(ids)-1]
+	}
+	switch key {
+	case tea.KeyCtrlUp:
+		if selected > ids[0] {
+			selected--
+		}
+	case tea.KeyCtrlDown:
+		if selected < ids[len(ids)-1] {
+			selected++
+		}
+	case tea.KeyCtrlO:
+		for _, group := range groups {
+			if group.ID == selected {
+				m.expanded[selected] = !m.isExpanded(group, groups)
+			}
+		}
+	}
+	m.selectedIteration = selected
+}
+
+// printNewMessages prints the messages of the session received since the last
+// call to the scrollback of the terminal, in inline mode.
+func (m *model) printNewMessages() tea.Cmd {
+	messages := m.agent.GetSession().AllMessages()
+	if m.printed > len(messages) {
+		// The session was cleared.
+		m.printed = 0
+	}
+	var rendered []string
+	for _, message := range messages[m.printed:] {
+		if !m.shows(message) {
+			continue
+		}
+		if text := m.renderMessage(message); text != "" {
+			rendered = append(rendered, text)
+		}
+	}
+	m.printed = len(messages)
+	if len(rendered) == 0 {
+		return nil
+	}
+	return tea.Println(strings.Join(rendered, "\n"))
+}
+
+// iterations returns the messages of the session grouped into agent iterations.
+func (m model) iterations() []*messageGroup {
+	return groupIterations(m.agent.GetSession().AllMessages())
+}
+
+// isExpanded returns true if the iteration is unfolded. Completed iterations
+// are folded unless the user unfolded them.
+func (m model) isExpanded(group *messageGroup, groups []*messageGroup) bool {
+	if expanded, ok := m.expanded[group.ID]; ok {
+		return expanded
+	}
+	if group != groups[len(groups)-1] {
+		return false
+	}
+	state := m.agent.GetSession().AgentState
+	return state == api.AgentStateRunning || state == api.AgentStateWaitingForInput
+}
+
+// renderedMessages renders the blocks of the window, preceded by a hint if
+// earlier blocks are not rendered.
 func (m model) renderedMessages() []string {
-	allMessages := m.agent.GetSession().AllMessages()
+	blocks := m.blocks()
 
 	var messages []string
-	for _, message := range allMessages {
-		if message.Type == api.MessageTypeUserInputRequest && message.Payload == ">>>" {
+	if hidden := len(blocks) - m.windowSize(); hidden > 0 {
+		messages = append(messages, helpStyle.Render(fmt.Sprintf("↑ %d earlier messages, scroll up to show them", hidden)))
+		blocks = blocks[hidden:]
+	}
+	for _, render := range blocks {
+		messages = append(messages, render())
+	}
+	return messages
+}
+
+// blocks returns the functions rendering the iteration headers and the
+// messages of the session, so that only the blocks of the window are rendered.
+func (m model) blocks() []func() string {
+	groups := m.iterations()
+
+	var blocks []func() string
+	for _, group := range groups {
+		expanded := true
+		if group.IsIteration() {
+			expanded = m.isExpanded(group, groups)
+			blocks = append(blocks, func() string { return m.renderIterationHeader(group, expanded) })
+		}
+		if !expanded {
 			continue
 		}
-		messages = append(messages, m.renderMessage(message))
+		for _, message := range latestProgress(group.Messages) {
+			if !m.shows(message) {
+				continue
+			}
+			blocks = append(blocks, func() string { return m.rendered.get(message, m.viewport.Width, m.renderMessage) })
+		}
 	}
-	return messages
+	return blocks
+}
+
+// shows returns true if the message is shown at the verbosity of the TUI. The
+// prompt for the next query is not shown, the textarea takes it.
+func (m model) shows(message *api.Message) bool {
+	if message.Type == api.MessageTypeUserInputRequest && message.Payload == ">>>" {
+		return false
+	}
+	return m.verbosity.Shows(message)
+}
+
+func (m model) windowSize() int {
+	if m.window == 0 {
+		return renderWindowBlocks
+	}
+	return m.window
+}
+
+// showEarlierBlocks extends the window with the previous renderWindowBlocks
+// blocks, keeping the viewport on the same content.
+func (m *model) showEarlierBlocks() {
+	if len(m.blocks()) <= m.windowSize() {
+		return
+	}
+	lines := m.viewport.TotalLineCount()
+	m.window = m.windowSize() + renderWindowBlocks
+	m.viewport.SetContent(strings.Join(m.renderedMessages(), "\n"))
+	m.viewport.SetYOffset(m.viewport.TotalLineCount() - lines)
+}
+
+// renderIterationHeader renders the numbered header of an iteration, e.g.
+// "▶ Iteration 3 · 2 tool calls · Checking the pod events".
+func (m model) renderIterationHeader(group *messageGroup, expanded bool) string {
+	marker := "▶"
+	if expanded {
+		marker = "▼"
+	}
+	style := iterationStyle
+	if group.ID == m.selectedIteration {
+		style = selectedIterationStyle
+	}
+	header := style.Render(fmt.Sprintf("%s Iteration %d", marker, group.Number))
+
+	summary := []string{}
+	switch n := group.ToolCalls(); n {
+	case 0:
+	case 1:
+		summary = append(summary, "1 tool call")
+	default:
+		summary = append(summary, fmt.Sprintf("%d tool calls", n))
+	}
+	if !expanded {
+		if thought := group.Thought(); thought != "" {
+			summary = append(summary, thought)
+		}
+	}
+	if len(summary) > 0 {
+		header += iterationSummaryStyle.Render(" · " + strings.Join(summary, " · "))
+	}
+	switch n := group.Errors(); n {
+	case 0:
+	case 1:
+		header += iterationErrorStyle.Render(" · 1 error")
+	default:
+		header += iterationErrorStyle.Render(fmt.Sprintf(" · %d errors", n))
+	}
+
+	// Keep the header on a single line.
+	if width := m.viewport.Width; width > 0 && lipgloss.Width(header) > width {
+		header = lipgloss.NewStyle().MaxWidth(width).Render(header)
+	}
+	return header + "\n"
 }
 
 func (m model) View() string {
 	if m.quitting {
 		return quitTextStyle.Render("Not safe to quit yet.")
 	}
-	mainView := fmt.Sprintf(
-		"%s%s",
-		m.viewport.View(),
-		gap,
-	)
-	if m.agent.GetSession().AgentState == api.AgentStateWaitingForInput {
-		var choiceRequest *api.UserChoiceRequest
-		if len(m.messages) > 0 {
-			if lastMsg := m.messages[len(m.messages)-1]; lastMsg.Type == api.MessageTypeUserChoiceRequest {
-				choiceRequest = lastMsg.Payload.(*api.UserChoiceRequest)
-			}
+	mainView := ""
+	if !m.inline {
+		mainView = fmt.Sprintf(
+			"%s%s",
+			m.viewport.View(),
+			gap,
+		)
+	}
+	if choiceRequest := m.choiceRequest(); choiceRequest != nil && !m.editingCommand {
+		items := make([]list.Item, len(choiceRequest.Options))
+		for i, option := range choiceRequest.Options {
+			items[i] = item(option.Label)
 		}
-
-		if choiceRequest != nil {
-			items := make([]list.Item, len(choiceRequest.Options))
-			for i, option := range choiceRequest.Options {
-				items[i] = item(option.Label)
-			}
-			m.list.SetItems(items)
-			m.list.Title = "Select an option:"
-			mainView += listStyle.Render(m.list.View())
-		} else {
-			mainView += m.textarea.View()
+		m.list.SetItems(items)
+		m.list.Title = "Select an option:"
+		if help := shortcutsHelp(choiceRequest); help != "" {
+			m.list.Title = "Select an option, or press " + help + ":"
 		}
+		mainView += listStyle.Render(m.list.View())
+	} else if m.editingCommand {
+		mainView += m.textarea.View() + helpStyle.Render("Edit the command and press Enter to run it, or Esc to go back.")
 	} else {
 		mainView += m.textarea.View()
 	}
@@ -355,11 +726,30 @@ func (m model) renderMessage(message *api.Message) string {
 	var renderedText string
 	var contentToRender string
 
+	if message.Type == api.MessageTypeToolCallResponse {
+		output, err := tools.ToolResultToMap(message.Payload)
+		if err != nil {
+			return ""
+		}
+		message = &api.Message{Type: message.Type, Payload: formatToolCallResponse(output)}
+	}
+
 	switch p := message.Payload.(type) {
 	case string:
 		contentToRender = p
+		if len(message.ResourceLinks) > 0 {
+			contentToRender += "\n\n" + resourceLinksLine(message.ResourceLinks)
+		}
 	case *api.UserChoiceRequest:
 		contentToRender = p.Prompt
+	case *api.TableBlock:
+		contentToRender = markdownTable(p)
+	case *api.CodeBlock:
+		contentToRender = markdownCode(p)
+	case *api.ProgressBlock:
+		contentToRender = progressBar(p)
+	case *api.ArtifactBlock:
+		contentToRender = fmt.Sprintf("Artifact: `%s` (%s)", p.Path, formatSize(p.Size))
 	default:
 		return "" // Don't render unknown payload types
 	}
@@ -369,8 +759,13 @@ func (m model) renderMessage(message *api.Message) string {
 		contentToRender = fmt.Sprintf("Running: `%s`", contentToRender)
 	case api.MessageTypeError:
 		contentToRender = fmt.Sprintf("Error: %s", contentToRender)
+	case api.MessageTypeToolInputRequest:
+		contentToRender = fmt.Sprintf("The command is waiting for input, type your answer: `%s`", contentToRender)
+	case api.MessageTypeToolCallProgress:
+		contentToRender = fmt.Sprintf("```\n%s\n```", strings.TrimRight(contentToRender, "\n"))
 	case api.MessageTypeToolCallResponse:
-		return "" // Or a summary
+		// Shown at the verbose verbosity only, see Verbosity.Shows.
+		contentToRender = fmt.Sprintf("```\n%s\n```", strings.TrimRight(contentToRender, "\n"))
 	}
 
 	renderedText, err = renderer.Render(contentToRender)
@@ -0,0 +1,180 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/agent"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/sessions"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestShortcutOption(t *testing.T) {
+	approval := &api.UserChoiceRequest{
+		Prompt: "Do you want to proceed ?",
+		Options: []api.UserChoiceOption{
+			{Value: "yes", Label: "Yes"},
+			{Value: "yes_and_dont_ask_me_again", Label: "Yes, and don't ask me again"},
+			{Value: "no", Label: "No"},
+			{Value: "edit", Label: "Edit the command, then run it"},
+			{Value: "skip", Label: "Skip these commands, and run the others"},
+			{Value: "always", Label: "Always allow these commands in this session"},
+		},
+	}
+	tests := []struct {
+		key  rune
+		want int
+	}{
+		{key: 'y', want: 0},
+		{key: 'n', want: 2},
+		{key: 'e', want: 3},
+		{key: 's', want: 4},
+		{key: 'a', want: 5},
+		{key: '2', want: 1},
+		{key: '7', want: -1},
+		{key: 'x', want: -1},
+	}
+	for _, tt := range tests {
+		if got := shortcutOption(approval, tt.key); got != tt.want {
+			t.Errorf("shortcutOption(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+
+	if got, want := shortcutsHelp(approval), "y(es) n(o) e(dit) s(kip) a(lways)"; got != want {
+		t.Errorf("shortcutsHelp() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderCache(t *testing.T) {
+	renders := 0
+	render := func(message *api.Message) string {
+		renders++
+		return message.Payload.(string)
+	}
+	cache := &renderCache{}
+	message := &api.Message{ID: "1", Payload: "hello"}
+
+	for _, width := range []int{80, 80, 120, 120} {
+		if got := cache.get(message, width, render); got != "hello" {
+			t.Errorf("get() = %q, want %q", got, "hello")
+		}
+	}
+	if renders != 2 {
+		t.Errorf("rendered %d times, want 2 (once per width)", renders)
+	}
+
+	// Messages without an ID are not cached.
+	cache.get(&api.Message{Payload: "hi"}, 120, render)
+	cache.get(&api.Message{Payload: "hi"}, 120, render)
+	if renders != 4 {
+		t.Errorf("rendered %d times, want 4", renders)
+	}
+}
+
+// altScreen is the escape sequence switching the terminal to the alternate screen.
+const altScreen = "\x1b[?1049h"
+
+// newTestAgent returns an agent whose session holds the given answers of the model.
+func newTestAgent(answers ...string) (*agent.Agent, *sessions.InMemoryChatStore) {
+	store := sessions.NewInMemoryChatStore()
+	for i, answer := range answers {
+		store.AddChatMessage(&api.Message{ID: fmt.Sprint(i), Source: api.MessageSourceModel, Type: api.MessageTypeText, Payload: answer})
+	}
+	return &agent.Agent{Session: &api.Session{ID: "test-session", ChatMessageStore: store, AgentState: api.AgentStateRunning}}, store
+}
+
+func TestInlineView(t *testing.T) {
+	a, _ := newTestAgent("the pod web-0 is running")
+
+	if view := newModel(a, false, VerbosityNormal).View(); !strings.Contains(view, "Welcome to the chat room!") {
+		t.Errorf("View() = %q, want the viewport of the session", view)
+	}
+	view := newModel(a, true, VerbosityNormal).View()
+	if strings.Contains(view, "Welcome to the chat room!") || strings.Contains(view, "web-0") {
+		t.Errorf("inline View() = %q, want the input only, the session is printed to the scrollback", view)
+	}
+	if !strings.Contains(view, "Send a message...") {
+		t.Errorf("inline View() = %q, want the input", view)
+	}
+}
+
+func TestPrintNewMessages(t *testing.T) {
+	a, store := newTestAgent("first answer")
+	m := newModel(a, true, VerbosityNormal)
+
+	// printed returns the text printed by a command of printNewMessages.
+	printed := func(cmd tea.Cmd) string {
+		if cmd == nil {
+			return ""
+		}
+		return fmt.Sprintf("%+v", cmd())
+	}
+
+	if got := printed(m.printNewMessages()); !strings.Contains(got, "first answer") {
+		t.Errorf("printNewMessages() printed %q, want the first answer", got)
+	}
+	if got := printed(m.printNewMessages()); got != "" {
+		t.Errorf("printNewMessages() printed %q again, want nothing", got)
+	}
+
+	store.AddChatMessage(&api.Message{ID: "2", Source: api.MessageSourceModel, Type: api.MessageTypeText, Payload: "second answer"})
+	got := printed(m.printNewMessages())
+	if !strings.Contains(got, "second answer") || strings.Contains(got, "first answer") {
+		t.Errorf("printNewMessages() printed %q, want the second answer only", got)
+	}
+
+	// A cleared session is printed from its start.
+	if err := store.ClearChatMessages(); err != nil {
+		t.Fatal(err)
+	}
+	store.AddChatMessage(&api.Message{ID: "3", Source: api.MessageSourceModel, Type: api.MessageTypeText, Payload: "after clear"})
+	if got := printed(m.printNewMessages()); !strings.Contains(got, "after clear") {
+		t.Errorf("printNewMessages() printed %q after a clear, want the new answer", got)
+	}
+}
+
+func TestInlineProgramOutput(t *testing.T) {
+	for _, inline := range []bool{true, false} {
+		t.Run(fmt.Sprintf("inline=%t", inline), func(t *testing.T) {
+			a, _ := newTestAgent("the pod web-0 is running")
+
+			var out bytes.Buffer
+			options := append(programOptions(inline), tea.WithOutput(&out), tea.WithInput(nil), tea.WithoutSignalHandler())
+			program := tea.NewProgram(newModel(a, inline, VerbosityNormal), options...)
+			go func() {
+				program.Send(a.GetSession().AllMessages()[0])
+				// Leave the program the time to print the message before quitting.
+				time.Sleep(100 * time.Millisecond)
+				program.Quit()
+			}()
+			if _, err := program.Run(); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if got := strings.Contains(out.String(), altScreen); got == inline {
+				t.Errorf("alternate screen used = %t, want %t", got, !inline)
+			}
+			if inline && !strings.Contains(out.String(), "web-0") {
+				t.Errorf("output = %q, want the answer printed to the scrollback", out.String())
+			}
+		})
+	}
+}
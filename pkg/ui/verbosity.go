@@ -0,0 +1,69 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+// Verbosity is the detail of the output of the agent shown to the user. It is
+// independent of the logs of kubectl-ai, whose detail is set with -v. The
+// terminal, the TUI and the web UI show the same messages at each verbosity.
+type Verbosity string
+
+const (
+	// VerbosityQuiet shows the answers of the model, the errors and the
+	// questions to the user, not the commands run to find the answers.
+	VerbosityQuiet Verbosity = "quiet"
+	// VerbosityNormal also shows the commands run, their progress and the
+	// reasoning of the model between them.
+	VerbosityNormal Verbosity = "normal"
+	// VerbosityVerbose also shows the output of the commands.
+	VerbosityVerbose Verbosity = "verbose"
+)
+
+// Shows returns true if the message is shown to the user at this verbosity.
+// An empty verbosity is the normal verbosity.
+func (v Verbosity) Shows(msg *api.Message) bool {
+	switch msg.Type {
+	case api.MessageTypeToolCallResponse:
+		return v == VerbosityVerbose
+	case api.MessageTypeToolCallRequest, api.MessageTypeToolCallProgress, api.MessageTypeProgress:
+		return v != VerbosityQuiet
+	case api.MessageTypeText:
+		return !msg.Reasoning || v != VerbosityQuiet
+	}
+	return true
+}
+
+// Implement pflag.Value for Verbosity
+func (v *Verbosity) Set(s string) error {
+	switch Verbosity(s) {
+	case VerbosityQuiet, VerbosityNormal, VerbosityVerbose:
+		*v = Verbosity(s)
+		return nil
+	}
+	return fmt.Errorf("invalid verbosity %q, expected quiet, normal or verbose", s)
+}
+
+func (v *Verbosity) String() string {
+	return string(*v)
+}
+
+func (v *Verbosity) Type() string {
+	return "Verbosity"
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"testing"
+
+	"github.com/GoogleCloudPlatform/kubectl-ai/pkg/api"
+)
+
+func TestVerbosityShows(t *testing.T) {
+	tests := []struct {
+		name    string
+		message *api.Message
+		// want is whether the message is shown at the quiet, normal and verbose verbosities.
+		want [3]bool
+	}{
+		{
+			name:    "answer",
+			message: &api.Message{Type: api.MessageTypeText, Payload: "The pod is running."},
+			want:    [3]bool{true, true, true},
+		},
+		{
+			name:    "reasoning",
+			message: &api.Message{Type: api.MessageTypeText, Payload: "Let me list the pods.", Reasoning: true},
+			want:    [3]bool{false, true, true},
+		},
+		{
+			name:    "tool call",
+			message: &api.Message{Type: api.MessageTypeToolCallRequest, Payload: "kubectl get pods"},
+			want:    [3]bool{false, true, true},
+		},
+		{
+			name:    "tool output",
+			message: &api.Message{Type: api.MessageTypeToolCallResponse, Payload: map[string]any{"stdout": "nginx"}},
+			want:    [3]bool{false, false, true},
+		},
+		{
+			name:    "error",
+			message: &api.Message{Type: api.MessageTypeError, Payload: "connection refused"},
+			want:    [3]bool{true, true, true},
+		},
+		{
+			name:    "choice",
+			message: &api.Message{Type: api.MessageTypeUserChoiceRequest, Payload: &api.UserChoiceRequest{Prompt: "Run it?"}},
+			want:    [3]bool{true, true, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, v := range []Verbosity{VerbosityQuiet, VerbosityNormal, VerbosityVerbose} {
+				if got := v.Shows(tt.message); got != tt.want[i] {
+					t.Errorf("%s: Shows() = %v, want %v", v, got, tt.want[i])
+				}
+			}
+			if got := Verbosity("").Shows(tt.message); got != tt.want[1] {
+				t.Errorf("empty verbosity: Shows() = %v, want %v as normal", got, tt.want[1])
+			}
+		})
+	}
+}
+
+func TestVerbositySet(t *testing.T) {
+	var v Verbosity
+	if err := v.Set("verbose"); err != nil || v != VerbosityVerbose {
+		t.Errorf("Set(verbose) = %v, verbosity %q", err, v)
+	}
+	if err := v.Set("debug"); err == nil {
+		t.Errorf("Set(debug) succeeded, want an error")
+	}
+	if v != VerbosityVerbose {
+		t.Errorf("Set(debug) changed the verbosity to %q", v)
+	}
+}